@@ -0,0 +1,201 @@
+// Package ahocorasick implements the Aho-Corasick algorithm for finding all
+// occurrences of multiple patterns in a text in a single pass.
+package ahocorasick
+
+import (
+	"iter"
+
+	"github.com/eliben/gogl/queue"
+)
+
+// Matcher is an Aho-Corasick automaton built from a set of patterns. It's
+// immutable after construction and safe for concurrent use. Create it with
+// [New].
+type Matcher struct {
+	patterns []string
+	states   []state
+}
+
+// state is a state of the automaton, corresponding to a prefix of one or
+// more patterns (the root, state 0, corresponds to the empty prefix).
+type state struct {
+	// next holds the trie transitions out of this state.
+	next map[byte]int32
+
+	// fail is the state for the longest proper suffix of this state's prefix
+	// that's also a prefix of some pattern.
+	fail int32
+
+	// output is the index of the pattern whose full text equals this state's
+	// prefix, or -1 if there's none.
+	output int32
+
+	// dict is the nearest state reachable through fail links that has an
+	// output, or -1 if there's none. It lets matching enumerate all patterns
+	// ending at a position without walking the entire fail chain.
+	dict int32
+
+	depth int32
+}
+
+// Match is a single occurrence of a pattern in the text.
+type Match struct {
+	// Pattern is the index of the matched pattern in the slice passed to New.
+	Pattern int
+
+	// Start and End are the byte offsets of the match in the text: the
+	// pattern occupies text[Start:End].
+	Start, End int
+}
+
+// New builds a Matcher for the given patterns. Empty patterns are ignored;
+// if the same pattern appears several times, matches report the index of its
+// first occurrence.
+func New(patterns []string) *Matcher {
+	m := &Matcher{patterns: patterns}
+	m.states = append(m.states, newState(0))
+
+	// Build the trie of patterns.
+	for pi, p := range patterns {
+		if p == "" {
+			continue
+		}
+		s := int32(0)
+		for i := 0; i < len(p); i++ {
+			nx, ok := m.states[s].next[p[i]]
+			if !ok {
+				nx = int32(len(m.states))
+				m.states = append(m.states, newState(int32(i+1)))
+				m.states[s].next[p[i]] = nx
+			}
+			s = nx
+		}
+		if m.states[s].output < 0 {
+			m.states[s].output = int32(pi)
+		}
+	}
+
+	// Compute fail and dict links in BFS order, so that the links of all
+	// shallower states are known when processing a state.
+	q := queue.New[int32]()
+	for _, c := range m.states[0].next {
+		q.Enqueue(c)
+	}
+	for s := range q.Drain() {
+		for b, c := range m.states[s].next {
+			f := m.states[s].fail
+			for {
+				if nx, ok := m.states[f].next[b]; ok {
+					m.states[c].fail = nx
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = m.states[f].fail
+			}
+			fs := m.states[c].fail
+			if m.states[fs].output >= 0 {
+				m.states[c].dict = fs
+			} else {
+				m.states[c].dict = m.states[fs].dict
+			}
+			q.Enqueue(c)
+		}
+	}
+	return m
+}
+
+func newState(depth int32) state {
+	return state{next: make(map[byte]int32), output: -1, dict: -1, depth: depth}
+}
+
+// Patterns returns the patterns the matcher was built from.
+func (m *Matcher) Patterns() []string {
+	return m.patterns
+}
+
+// FindAll returns an iterator over all the (possibly overlapping) matches of
+// the patterns in text, ordered by their end offset.
+func (m *Matcher) FindAll(text string) iter.Seq[Match] {
+	return func(yield func(Match) bool) {
+		s := int32(0)
+		for i := 0; i < len(text); i++ {
+			s = m.step(s, text[i])
+			if !m.emit(s, i+1, yield) {
+				return
+			}
+		}
+	}
+}
+
+// Contains reports whether any of the patterns occurs in text.
+func (m *Matcher) Contains(text string) bool {
+	for range m.FindAll(text) {
+		return true
+	}
+	return false
+}
+
+// step returns the state reached from s by consuming b.
+func (m *Matcher) step(s int32, b byte) int32 {
+	for {
+		if nx, ok := m.states[s].next[b]; ok {
+			return nx
+		}
+		if s == 0 {
+			return 0
+		}
+		s = m.states[s].fail
+	}
+}
+
+// emit yields the matches of all patterns ending at state s, where end is
+// the text offset just past the last consumed byte. It returns false if
+// iteration was stopped.
+func (m *Matcher) emit(s int32, end int, yield func(Match) bool) bool {
+	if m.states[s].output < 0 {
+		s = m.states[s].dict
+	}
+	for s >= 0 {
+		st := &m.states[s]
+		if !yield(Match{Pattern: int(st.output), Start: end - int(st.depth), End: end}) {
+			return false
+		}
+		s = st.dict
+	}
+	return true
+}
+
+// Scanner finds matches in a stream of text fed to it in chunks; matches
+// spanning chunk boundaries are found as well. Offsets in reported matches
+// are relative to the beginning of the stream. Create scanners with
+// [Matcher.NewScanner].
+type Scanner struct {
+	m     *Matcher
+	state int32
+	pos   int
+}
+
+// NewScanner creates a new Scanner at the beginning of a stream.
+func (m *Matcher) NewScanner() *Scanner {
+	return &Scanner{m: m}
+}
+
+// Feed consumes the next chunk of the stream, and returns an iterator over
+// the matches ending within this chunk. The chunk is consumed fully as the
+// iterator runs; if the loop over the iterator is exited early, the rest of
+// the chunk is consumed without reporting matches, so the scanner remains
+// in a consistent state for the next chunk.
+func (sc *Scanner) Feed(chunk []byte) iter.Seq[Match] {
+	return func(yield func(Match) bool) {
+		stopped := false
+		for _, b := range chunk {
+			sc.state = sc.m.step(sc.state, b)
+			sc.pos++
+			if !stopped && !sc.m.emit(sc.state, sc.pos, yield) {
+				stopped = true
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package ahocorasick
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// naiveFindAll finds all matches of patterns in text by brute force, in the
+// order FindAll reports them.
+func naiveFindAll(patterns []string, text string) []Match {
+	var result []Match
+	for end := 1; end <= len(text); end++ {
+		var atEnd []Match
+		for pi, p := range patterns {
+			if p == "" || len(p) > end || slices.Index(patterns, p) != pi {
+				continue
+			}
+			if text[end-len(p):end] == p {
+				atEnd = append(atEnd, Match{Pattern: pi, Start: end - len(p), End: end})
+			}
+		}
+		// FindAll reports the longest match at each end first.
+		slices.SortFunc(atEnd, func(a, b Match) int { return a.Start - b.Start })
+		result = append(result, atEnd...)
+	}
+	return result
+}
+
+func TestClassic(t *testing.T) {
+	patterns := []string{"he", "she", "his", "hers"}
+	m := New(patterns)
+	got := slices.Collect(m.FindAll("ushers"))
+	want := []Match{
+		{Pattern: 1, Start: 1, End: 4},
+		{Pattern: 0, Start: 2, End: 4},
+		{Pattern: 3, Start: 2, End: 6},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, mt := range got {
+		if "ushers"[mt.Start:mt.End] != patterns[mt.Pattern] {
+			t.Errorf("bad match %v", mt)
+		}
+	}
+	if !m.Contains("this") || m.Contains("xyz") {
+		t.Errorf("bad Contains results")
+	}
+	if len(m.Patterns()) != 4 {
+		t.Errorf("got %d patterns", len(m.Patterns()))
+	}
+}
+
+func TestOverlappingAndDuplicates(t *testing.T) {
+	patterns := []string{"a", "aa", "aaa", "", "aa"}
+	m := New(patterns)
+	got := slices.Collect(m.FindAll("aaaa"))
+	want := naiveFindAll(patterns, "aaaa")
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, mt := range got {
+		if mt.Pattern == 4 {
+			t.Errorf("duplicate pattern reported with its second index")
+		}
+	}
+}
+
+func TestEarlyExit(t *testing.T) {
+	m := New([]string{"x"})
+	n := 0
+	for range m.FindAll("xxxxx") {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d matches", n)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	patterns := []string{"error", "warn", "panic: "}
+	m := New(patterns)
+	text := "info ok\nwarn: disk\nerror: boom\npanic: oops\nwarning"
+	want := slices.Collect(m.FindAll(text))
+
+	// Feed the text in chunks of various sizes; the matches should be the
+	// same regardless of chunk boundaries.
+	for _, chunkSize := range []int{1, 2, 3, 7, 100} {
+		sc := m.NewScanner()
+		var got []Match
+		for i := 0; i < len(text); i += chunkSize {
+			for mt := range sc.Feed([]byte(text[i:min(i+chunkSize, len(text))])) {
+				got = append(got, mt)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("chunk size %d: got %v, want %v", chunkSize, got, want)
+		}
+	}
+
+	// Stopping early still consumes the whole chunk.
+	sc := m.NewScanner()
+	for range sc.Feed([]byte("warn warn wa")) {
+		break
+	}
+	got := slices.Collect(sc.Feed([]byte("rn")))
+	if want := []Match{{Pattern: 1, Start: 10, End: 14}}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRandomAgainstNaive(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	randString := func(maxLen int) string {
+		var sb strings.Builder
+		for range rnd.IntN(maxLen + 1) {
+			sb.WriteByte("abc"[rnd.IntN(3)])
+		}
+		return sb.String()
+	}
+
+	for range 50 {
+		var patterns []string
+		for range 1 + rnd.IntN(10) {
+			patterns = append(patterns, randString(5))
+		}
+		text := randString(200)
+		got := slices.Collect(New(patterns).FindAll(text))
+		want := naiveFindAll(patterns, text)
+		if !slices.Equal(got, want) {
+			t.Fatalf("patterns %q, text %q:\ngot  %v\nwant %v", patterns, text, got, want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package arc implements a fixed-capacity cache with adaptive replacement
+// (ARC) eviction.
+package arc
+
+import (
+	"iter"
+
+	"github.com/eliben/gogl/cache"
+	"github.com/eliben/gogl/list"
+)
+
+// Cache is a cache holding up to a fixed number of entries, evicting
+// entries with the Adaptive Replacement Cache policy of Megiddo and Modha.
+//
+// ARC keeps resident entries in two LRU lists: t1 for entries used once
+// since they were added, and t2 for entries used at least twice. It also
+// remembers the keys (but not values) of entries recently evicted from
+// each, in the "ghost" lists b1 and b2. A Put of a key found in a ghost list
+// means the cache evicted it too eagerly, and shifts the target size of t1
+// towards the list that would have kept it. This balances recency and
+// frequency automatically, and makes ARC resistant to scans: a long run of
+// keys used once only cycles through t1 without flushing t2.
+//
+// Get, Put and Remove take O(1) time. Create caches with [New] or
+// [NewWithEvict].
+type Cache[K comparable, V any] struct {
+	capacity int
+	index    map[K]*list.Node[entry[K, V]]
+
+	// Each list holds entries from the most recently used (front) to the
+	// least recently used (back).
+	t1, t2, b1, b2 *list.List[entry[K, V]]
+
+	// p is the target size of t1.
+	p       int
+	onEvict func(K, V)
+	rec     cache.Recorder
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	where where
+}
+
+// where identifies the list an entry is in.
+type where int
+
+const (
+	inT1 where = iota
+	inT2
+	inB1
+	inB2
+)
+
+// New creates a new, empty cache holding up to capacity entries. capacity
+// must be positive.
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	return NewWithEvict[K, V](capacity, nil)
+}
+
+// NewWithEvict is like New, but onEvict is called with the key and value of
+// every entry evicted to make room for a new one. onEvict may be nil.
+func NewWithEvict[K comparable, V any](capacity int, onEvict func(K, V)) *Cache[K, V] {
+	if capacity <= 0 {
+		panic("arc: capacity must be positive")
+	}
+	return &Cache[K, V]{
+		capacity: capacity,
+		index:    make(map[K]*list.Node[entry[K, V]]),
+		t1:       list.New[entry[K, V]](),
+		t2:       list.New[entry[K, V]](),
+		b1:       list.New[entry[K, V]](),
+		b2:       list.New[entry[K, V]](),
+		onEvict:  onEvict,
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	return c.t1.Len() + c.t2.Len()
+}
+
+// Cap returns the capacity of the cache.
+func (c *Cache[K, V]) Cap() int {
+	return c.capacity
+}
+
+// Get looks up key in the cache, marking it as used. It returns the
+// associated value and ok=true; otherwise, it returns ok=false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	node, ok := c.resident(key)
+	c.rec.Lookup(ok)
+	if !ok {
+		return v, false
+	}
+	c.promote(node)
+	return node.Value.value, true
+}
+
+// Peek is like Get, but it doesn't mark key as used.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	node, ok := c.resident(key)
+	if !ok {
+		return v, false
+	}
+	return node.Value.value, true
+}
+
+// Contains reports whether key is in the cache, without marking it as used.
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.resident(key)
+	return ok
+}
+
+// Put sets the value of key in the cache, marking it as used. If key is new
+// and the cache is full, an entry is evicted first.
+func (c *Cache[K, V]) Put(key K, value V) {
+	node, ok := c.index[key]
+	if !ok {
+		c.putNew(key, value)
+		return
+	}
+
+	switch node.Value.where {
+	case inT1, inT2:
+		node.Value.value = value
+		c.promote(node)
+	case inB1:
+		// b1 hit: t1 was too small, so grow its target.
+		c.p = min(c.capacity, c.p+max(c.b2.Len()/c.b1.Len(), 1))
+		c.b1.Remove(node)
+		delete(c.index, key)
+		c.makeRoom(false)
+		c.insert(c.t2, key, value, inT2)
+	case inB2:
+		// b2 hit: t2 was too small, so shrink the target of t1.
+		c.p = max(0, c.p-max(c.b1.Len()/c.b2.Len(), 1))
+		c.b2.Remove(node)
+		delete(c.index, key)
+		c.makeRoom(true)
+		c.insert(c.t2, key, value, inT2)
+	}
+}
+
+// Remove removes key from the cache. It returns true if key was found, and
+// false otherwise. The eviction callback isn't called for removed entries.
+func (c *Cache[K, V]) Remove(key K) bool {
+	node, ok := c.resident(key)
+	if !ok {
+		return false
+	}
+	c.listOf(node.Value.where).Remove(node)
+	delete(c.index, key)
+	return true
+}
+
+// Stats returns statistics about the cache's activity since it was created.
+func (c *Cache[K, V]) Stats() cache.Stats {
+	return c.rec.Stats()
+}
+
+// SetHooks sets hooks to be called on cache events; nil removes them.
+func (c *Cache[K, V]) SetHooks(h cache.Hooks) {
+	c.rec.SetHooks(h)
+}
+
+// All returns an iterator over all the entries in the cache: first the
+// entries used more than once, then the entries used once; each group is
+// ordered from the most recently used to the least recently used. Iteration
+// doesn't mark entries as used.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, l := range []*list.List[entry[K, V]]{c.t2, c.t1} {
+			for e := range l.Values() {
+				if !yield(e.key, e.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// resident returns the node of key if it's in t1 or t2.
+func (c *Cache[K, V]) resident(key K) (*list.Node[entry[K, V]], bool) {
+	node, ok := c.index[key]
+	if !ok || (node.Value.where != inT1 && node.Value.where != inT2) {
+		return nil, false
+	}
+	return node, true
+}
+
+func (c *Cache[K, V]) listOf(w where) *list.List[entry[K, V]] {
+	switch w {
+	case inT1:
+		return c.t1
+	case inT2:
+		return c.t2
+	case inB1:
+		return c.b1
+	default:
+		return c.b2
+	}
+}
+
+// promote moves a resident node to the front of t2.
+func (c *Cache[K, V]) promote(node *list.Node[entry[K, V]]) {
+	if node.Value.where == inT2 {
+		c.t2.MoveToFront(node)
+		return
+	}
+	e := node.Value
+	c.t1.Remove(node)
+	c.insert(c.t2, e.key, e.value, inT2)
+}
+
+func (c *Cache[K, V]) insert(l *list.List[entry[K, V]], key K, value V, w where) {
+	c.index[key] = l.InsertFront(entry[K, V]{key: key, value: value, where: w})
+}
+
+// putNew adds a key that's in none of the lists to the front of t1.
+func (c *Cache[K, V]) putNew(key K, value V) {
+	if c.t1.Len()+c.b1.Len() >= c.capacity {
+		if c.t1.Len() < c.capacity {
+			c.dropGhost(c.b1)
+			c.makeRoom(false)
+		} else {
+			// t1 holds the whole cache; evict from it without a ghost.
+			node := c.t1.Back()
+			c.t1.Remove(node)
+			delete(c.index, node.Value.key)
+			c.evicted(node.Value)
+		}
+	} else if total := c.Len() + c.b1.Len() + c.b2.Len(); total >= c.capacity {
+		if total >= 2*c.capacity {
+			c.dropGhost(c.b2)
+		}
+		c.makeRoom(false)
+	}
+	c.insert(c.t1, key, value, inT1)
+}
+
+// makeRoom evicts a resident entry if the cache is full, moving its key to
+// the matching ghost list. hitB2 reports whether the key being added was
+// found in b2.
+func (c *Cache[K, V]) makeRoom(hitB2 bool) {
+	if c.Len() < c.capacity {
+		return
+	}
+	t1Len := c.t1.Len()
+	if t1Len > 0 && (t1Len > c.p || (hitB2 && t1Len == c.p) || c.t2.Len() == 0) {
+		c.demote(c.t1, c.b1, inB1)
+	} else {
+		c.demote(c.t2, c.b2, inB2)
+	}
+}
+
+// demote evicts the least recently used entry of from, and moves its key to
+// the ghost list to.
+func (c *Cache[K, V]) demote(from, to *list.List[entry[K, V]], w where) {
+	node := from.Back()
+	e := node.Value
+	from.Remove(node)
+	c.insert(to, e.key, *new(V), w)
+	c.evicted(e)
+}
+
+// dropGhost forgets the least recently used key of the ghost list l.
+func (c *Cache[K, V]) dropGhost(l *list.List[entry[K, V]]) {
+	if node := l.Back(); node != nil {
+		l.Remove(node)
+		delete(c.index, node.Value.key)
+	}
+}
+
+func (c *Cache[K, V]) evicted(e entry[K, V]) {
+	c.rec.Evict()
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
@@ -0,0 +1,133 @@
+package arc
+
+import (
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkInvariants verifies the ARC list size invariants and the index.
+func checkInvariants[K comparable, V any](t *testing.T, c *Cache[K, V]) {
+	t.Helper()
+	t1, t2, b1, b2 := c.t1.Len(), c.t2.Len(), c.b1.Len(), c.b2.Len()
+	if t1+t2 > c.capacity || t1+b1 > c.capacity || t1+t2+b1+b2 > 2*c.capacity {
+		t.Fatalf("size invariant broken: t1=%d t2=%d b1=%d b2=%d c=%d", t1, t2, b1, b2, c.capacity)
+	}
+	if c.p < 0 || c.p > c.capacity {
+		t.Fatalf("p=%d out of range", c.p)
+	}
+	if len(c.index) != t1+t2+b1+b2 {
+		t.Fatalf("index has %d entries, lists have %d", len(c.index), t1+t2+b1+b2)
+	}
+	for _, w := range []where{inT1, inT2, inB1, inB2} {
+		for node := range c.listOf(w).Nodes() {
+			if node.Value.where != w || c.index[node.Value.key] != node {
+				t.Fatalf("bad entry for key %v", node.Value.key)
+			}
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	var evicted []string
+	c := NewWithEvict(2, func(k string, v int) { evicted = append(evicted, k) })
+	c.Put("a", 1)
+	c.Put("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a)=%d,%v", v, ok)
+	}
+	c.Put("c", 3)
+	checkInvariants(t, c)
+
+	// a was used twice, so b (in t1) is evicted to make room.
+	if c.Contains("b") || !c.Contains("a") || !c.Contains("c") || c.Len() != 2 {
+		t.Errorf("unexpected contents")
+	}
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("evicted %v", evicted)
+	}
+	if v, ok := c.Peek("c"); !ok || v != 3 {
+		t.Errorf("Peek(c)=%d,%v", v, ok)
+	}
+
+	// b is remembered in a ghost list, but isn't resident.
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("got ghost entry")
+	}
+	if c.Remove("b") || !c.Remove("c") || c.Len() != 1 {
+		t.Errorf("bad Remove")
+	}
+	checkInvariants(t, c)
+}
+
+func TestScanResistance(t *testing.T) {
+	c := New[int, int](100)
+	// Establish a frequently used working set.
+	for range 3 {
+		for k := range 50 {
+			c.Put(k, k)
+			c.Get(k)
+		}
+	}
+	// A long scan of keys used once.
+	for k := 1000; k < 5000; k++ {
+		c.Put(k, k)
+	}
+	checkInvariants(t, c)
+	for k := range 50 {
+		if !c.Contains(k) {
+			t.Fatalf("working set key %d evicted by scan", k)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, capacity := range []int{1, 2, 5, 16} {
+		// The mirror holds the latest value put for each key; the cache must
+		// agree with it on every key it holds.
+		mirror := make(map[int]int)
+		resident := make(map[int]bool)
+		c := NewWithEvict(capacity, func(k, v int) {
+			if !resident[k] || mirror[k] != v {
+				t.Fatalf("evicted unexpected %d=%d", k, v)
+			}
+			delete(resident, k)
+		})
+		for range 5000 {
+			k := rnd.IntN(4 * capacity)
+			switch rnd.IntN(5) {
+			case 0, 1:
+				v, ok := c.Get(k)
+				if ok != resident[k] || (ok && v != mirror[k]) {
+					t.Fatalf("Get(%d)=%d,%v", k, v, ok)
+				}
+			case 2:
+				if c.Remove(k) != resident[k] {
+					t.Fatalf("Remove(%d) mismatch", k)
+				}
+				delete(resident, k)
+			default:
+				v := rnd.Int()
+				mirror[k] = v
+				c.Put(k, v)
+				resident[k] = true
+				if got, ok := c.Peek(k); !ok || got != v {
+					t.Fatalf("Peek(%d) after Put=%d,%v", k, got, ok)
+				}
+			}
+			checkInvariants(t, c)
+			if c.Len() != len(resident) {
+				t.Fatalf("got Len=%d, want %d", c.Len(), len(resident))
+			}
+			for k, v := range c.All() {
+				if !resident[k] || mirror[k] != v {
+					t.Fatalf("unexpected entry %d=%d", k, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package avltree implements an ordered map backed by an AVL tree.
+package avltree
+
+import "iter"
+
+// Tree is an ordered map from keys of type K to values of type V, backed by
+// an AVL tree: a binary search tree in which the heights of the two subtrees
+// of every node differ by at most one. Its balance is stricter than a
+// red-black tree's (height at most ~1.44*log(n)), making lookups faster at
+// the cost of more rotations on updates.
+//
+// Tree has the same ordered-map API as [github.com/eliben/gogl/btree.BTree].
+// Create trees with [New].
+type Tree[K, V any] struct {
+	cmp    func(K, K) int
+	root   *node[K, V]
+	length int
+}
+
+type node[K, V any] struct {
+	key         K
+	value       V
+	left, right *node[K, V]
+
+	// height is the height of the subtree rooted at this node; leaves have
+	// height 1 and nil subtrees height 0.
+	height int
+}
+
+// New creates a new, empty tree with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K, V any](cmp func(K, K) int) *Tree[K, V] {
+	return &Tree[K, V]{cmp: cmp}
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree[K, V]) Len() int {
+	return t.length
+}
+
+// Get looks for the given key in the tree. It returns the associated value
+// and ok=true; otherwise, it returns ok=false.
+func (t *Tree[K, V]) Get(key K) (v V, ok bool) {
+	n := t.root
+	for n != nil {
+		c := t.cmp(key, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.value, true
+		}
+	}
+	return v, false
+}
+
+// Insert inserts a new key=value pair into the tree. If key already exists
+// in the tree, its value is replaced with value.
+func (t *Tree[K, V]) Insert(key K, value V) {
+	t.root = t.insert(t.root, key, value)
+}
+
+// Delete deletes a key and its associated value from the tree. It returns
+// true if the key was found and deleted; if key is not found in the tree,
+// Delete is a no-op and returns false.
+func (t *Tree[K, V]) Delete(key K) bool {
+	var deleted bool
+	t.root, deleted = t.delete(t.root, key)
+	if deleted {
+		t.length--
+	}
+	return deleted
+}
+
+// All returns an iterator over all key, value pairs in the tree, in
+// ascending order of keys.
+func (t *Tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.root, nil, nil, yield)
+	}
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys.
+func (t *Tree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.root, &lo, &hi, yield)
+	}
+}
+
+// Floor finds the largest key in the tree that's smaller than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (t *Tree[K, V]) Floor(key K) (k K, v V, ok bool) {
+	for n := t.root; n != nil; {
+		c := t.cmp(key, n.key)
+		if c == 0 {
+			return n.key, n.value, true
+		} else if c < 0 {
+			n = n.left
+		} else {
+			k, v, ok = n.key, n.value, true
+			n = n.right
+		}
+	}
+	return k, v, ok
+}
+
+// Ceiling finds the smallest key in the tree that's larger than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (t *Tree[K, V]) Ceiling(key K) (k K, v V, ok bool) {
+	for n := t.root; n != nil; {
+		c := t.cmp(key, n.key)
+		if c == 0 {
+			return n.key, n.value, true
+		} else if c > 0 {
+			n = n.right
+		} else {
+			k, v, ok = n.key, n.value, true
+			n = n.left
+		}
+	}
+	return k, v, ok
+}
+
+// insert inserts key=value into the subtree rooted at n, and returns the
+// new root of the subtree.
+func (t *Tree[K, V]) insert(n *node[K, V], key K, value V) *node[K, V] {
+	if n == nil {
+		t.length++
+		return &node[K, V]{key: key, value: value, height: 1}
+	}
+	c := t.cmp(key, n.key)
+	switch {
+	case c < 0:
+		n.left = t.insert(n.left, key, value)
+	case c > 0:
+		n.right = t.insert(n.right, key, value)
+	default:
+		n.value = value
+		return n
+	}
+	return rebalance(n)
+}
+
+// delete deletes key from the subtree rooted at n. It returns the new root
+// of the subtree, and whether the key was found.
+func (t *Tree[K, V]) delete(n *node[K, V], key K) (*node[K, V], bool) {
+	if n == nil {
+		return nil, false
+	}
+	var deleted bool
+	c := t.cmp(key, n.key)
+	switch {
+	case c < 0:
+		n.left, deleted = t.delete(n.left, key)
+	case c > 0:
+		n.right, deleted = t.delete(n.right, key)
+	default:
+		if n.left == nil {
+			return n.right, true
+		}
+		if n.right == nil {
+			return n.left, true
+		}
+		// Replace n by its successor, the minimum of the right subtree.
+		var succ *node[K, V]
+		n.right, succ = removeMin(n.right)
+		succ.left, succ.right = n.left, n.right
+		n, deleted = succ, true
+	}
+	return rebalance(n), deleted
+}
+
+// removeMin removes the minimal node from the subtree rooted at n. It
+// returns the new root of the subtree and the removed node.
+func removeMin[K, V any](n *node[K, V]) (*node[K, V], *node[K, V]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	var m *node[K, V]
+	n.left, m = removeMin(n.left)
+	return rebalance(n), m
+}
+
+// ascend yields the key, value pairs in the subtree rooted at n in
+// ascending order, limited to the range [lo, hi) with nil meaning unbounded.
+// It returns false if iteration should stop, either because yield returned
+// false or because hi was reached.
+func (t *Tree[K, V]) ascend(n *node[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	aboveLo := lo == nil || t.cmp(n.key, *lo) >= 0
+	if aboveLo && !t.ascend(n.left, lo, hi, yield) {
+		return false
+	}
+	if hi != nil && t.cmp(n.key, *hi) >= 0 {
+		return false
+	}
+	if aboveLo && !yield(n.key, n.value) {
+		return false
+	}
+	return t.ascend(n.right, lo, hi, yield)
+}
+
+func (n *node[K, V]) ht() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *node[K, V]) updateHeight() {
+	n.height = max(n.left.ht(), n.right.ht()) + 1
+}
+
+// balanceFactor is the height of n's right subtree minus the height of its
+// left subtree; AVL trees keep it in the range [-1, 1].
+func (n *node[K, V]) balanceFactor() int {
+	return n.right.ht() - n.left.ht()
+}
+
+func rotateLeft[K, V any](n *node[K, V]) *node[K, V] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.updateHeight()
+	r.updateHeight()
+	return r
+}
+
+func rotateRight[K, V any](n *node[K, V]) *node[K, V] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.updateHeight()
+	l.updateHeight()
+	return l
+}
+
+// rebalance updates n's height after a change in one of its subtrees, and
+// performs rotations if its balance factor went out of range. It returns
+// the new root of the subtree.
+func rebalance[K, V any](n *node[K, V]) *node[K, V] {
+	n.updateHeight()
+	switch bf := n.balanceFactor(); {
+	case bf > 1:
+		if n.right.balanceFactor() < 0 {
+			n.right = rotateRight(n.right)
+		}
+		return rotateLeft(n)
+	case bf < -1:
+		if n.left.balanceFactor() > 0 {
+			n.left = rotateLeft(n.left)
+		}
+		return rotateRight(n)
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package avltree
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// renderDotToImage generates a dot graph for GraphViz from t, and invokes
+// dot to create an image from it; the output image file is provided.
+// This function will panic if it's unable to invoke the `dot` command-line
+// tool or if that tool fails for some reason.
+func (t *Tree[K, V]) renderDotToImage(outfilename string) {
+	ds := t.renderDot()
+	absPath, err := filepath.Abs(outfilename)
+	if err != nil {
+		panic(err)
+	}
+
+	dotCmd := exec.Command("dot", "-Tpng", "-o", absPath)
+	dotIn, _ := dotCmd.StdinPipe()
+	if err := dotCmd.Start(); err != nil {
+		panic(err)
+	}
+	dotIn.Write([]byte(ds))
+	dotIn.Close()
+	if err := dotCmd.Wait(); err != nil {
+		panic(err)
+	}
+
+	log.Println("renderDotToImage wrote", absPath)
+}
+
+// renderDot generates a dot graph for Graphviz from t, and returns it as
+// a string. Each node is labeled with its key and the height of its subtree;
+// missing children are drawn as small points so left and right children
+// can be told apart.
+func (t *Tree[K, V]) renderDot() string {
+	var sb strings.Builder
+	sb.WriteString("digraph g {\nnode [shape = circle];\n")
+
+	nodeNumber := 0
+	var visit func(n *node[K, V]) string
+	visit = func(n *node[K, V]) string {
+		name := fmt.Sprintf("node%d", nodeNumber)
+		nodeNumber++
+		if n == nil {
+			fmt.Fprintf(&sb, "%s [shape = point];\n", name)
+			return name
+		}
+		fmt.Fprintf(&sb, "%s [label = \"%v\\nh=%d\"];\n", name, n.key, n.height)
+		if n.left != nil || n.right != nil {
+			fmt.Fprintf(&sb, "%s -> %s;\n", name, visit(n.left))
+			fmt.Fprintf(&sb, "%s -> %s;\n", name, visit(n.right))
+		}
+		return name
+	}
+	if t.root != nil {
+		visit(t.root)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
@@ -0,0 +1,43 @@
+package avltree
+
+import "fmt"
+
+// verify checks AVL tree invariants on t and returns an error describing
+// the first problem encountered. Returns nil if t is ok.
+func (t *Tree[K, V]) verify() error {
+	count, err := t.verifyNode(t.root, nil, nil)
+	if err != nil {
+		return err
+	}
+	if count != t.length {
+		return fmt.Errorf("counted %d nodes, but length is %d", count, t.length)
+	}
+	return nil
+}
+
+// verifyNode verifies the subtree rooted at n, whose keys must lie in the
+// range (lo, hi) with nil meaning unbounded. It returns the number of nodes
+// in the subtree.
+func (t *Tree[K, V]) verifyNode(n *node[K, V], lo, hi *K) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+	if (lo != nil && t.cmp(n.key, *lo) <= 0) || (hi != nil && t.cmp(n.key, *hi) >= 0) {
+		return 0, fmt.Errorf("node %v: violates ordering", n.key)
+	}
+	if want := max(n.left.ht(), n.right.ht()) + 1; n.height != want {
+		return 0, fmt.Errorf("node %v: height is %d, want %d", n.key, n.height, want)
+	}
+	if bf := n.balanceFactor(); bf < -1 || bf > 1 {
+		return 0, fmt.Errorf("node %v: balance factor %d", n.key, bf)
+	}
+	lc, err := t.verifyNode(n.left, lo, &n.key)
+	if err != nil {
+		return 0, err
+	}
+	rc, err := t.verifyNode(n.right, &n.key, hi)
+	if err != nil {
+		return 0, err
+	}
+	return lc + rc + 1, nil
+}
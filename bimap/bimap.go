@@ -0,0 +1,136 @@
+// Package bimap implements a bidirectional map.
+package bimap
+
+import "iter"
+
+// Policy determines how [BiMap.Put] handles a pair whose key or value is
+// already mapped to something else.
+type Policy int
+
+const (
+	// Overwrite removes any existing pairs that conflict with the new pair,
+	// so that the new pair is always inserted.
+	Overwrite Policy = iota
+
+	// Reject leaves the map unchanged when the new pair conflicts with an
+	// existing pair.
+	Reject
+)
+
+// BiMap is a bijection between keys of type K and values of type V: each
+// key maps to a single value, and each value maps back to a single key.
+// Lookups and updates in either direction take O(1) time. Create bimaps
+// with [New] or [NewWithPolicy].
+type BiMap[K, V comparable] struct {
+	forward  map[K]V
+	backward map[V]K
+	policy   Policy
+}
+
+// New creates a new, empty bimap with the [Overwrite] policy.
+func New[K, V comparable]() *BiMap[K, V] {
+	return NewWithPolicy[K, V](Overwrite)
+}
+
+// NewWithPolicy creates a new, empty bimap with the given policy for
+// conflicting insertions.
+func NewWithPolicy[K, V comparable](policy Policy) *BiMap[K, V] {
+	return &BiMap[K, V]{
+		forward:  make(map[K]V),
+		backward: make(map[V]K),
+		policy:   policy,
+	}
+}
+
+// Len returns the number of pairs in the bimap.
+func (bm *BiMap[K, V]) Len() int {
+	return len(bm.forward)
+}
+
+// Put maps key to value and value to key. If key is already mapped to
+// a different value, or value is already mapped to a different key, the
+// bimap's policy decides what happens: with [Overwrite] the conflicting
+// pairs are removed, and with [Reject] the bimap is left unchanged. Put
+// returns true if the pair is in the bimap after the call.
+func (bm *BiMap[K, V]) Put(key K, value V) bool {
+	oldValue, keyFound := bm.forward[key]
+	oldKey, valueFound := bm.backward[value]
+	if keyFound && valueFound && oldValue == value {
+		return true
+	}
+	if keyFound || valueFound {
+		if bm.policy == Reject {
+			return false
+		}
+		if keyFound {
+			delete(bm.backward, oldValue)
+		}
+		if valueFound {
+			delete(bm.forward, oldKey)
+		}
+	}
+	bm.forward[key] = value
+	bm.backward[value] = key
+	return true
+}
+
+// GetByKey returns the value mapped to key and ok=true, or ok=false if key
+// isn't in the bimap.
+func (bm *BiMap[K, V]) GetByKey(key K) (v V, ok bool) {
+	v, ok = bm.forward[key]
+	return v, ok
+}
+
+// GetByValue returns the key mapped to value and ok=true, or ok=false if
+// value isn't in the bimap.
+func (bm *BiMap[K, V]) GetByValue(value V) (k K, ok bool) {
+	k, ok = bm.backward[value]
+	return k, ok
+}
+
+// DeleteByKey deletes key and its value from the bimap. It returns true if
+// key was found, and false otherwise.
+func (bm *BiMap[K, V]) DeleteByKey(key K) bool {
+	value, ok := bm.forward[key]
+	if !ok {
+		return false
+	}
+	delete(bm.forward, key)
+	delete(bm.backward, value)
+	return true
+}
+
+// DeleteByValue deletes value and its key from the bimap. It returns true
+// if value was found, and false otherwise.
+func (bm *BiMap[K, V]) DeleteByValue(value V) bool {
+	key, ok := bm.backward[value]
+	if !ok {
+		return false
+	}
+	delete(bm.forward, key)
+	delete(bm.backward, value)
+	return true
+}
+
+// Inverse returns a view of the bimap with the roles of keys and values
+// swapped. The view shares its contents with bm: modifications through
+// either are visible in both.
+func (bm *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	return &BiMap[V, K]{
+		forward:  bm.backward,
+		backward: bm.forward,
+		policy:   bm.policy,
+	}
+}
+
+// All returns an iterator over all the key, value pairs in the bimap, in
+// unspecified order.
+func (bm *BiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range bm.forward {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package bimap
+
+import (
+	"log"
+	"maps"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkConsistent verifies that the bimap's two directions agree, and that
+// its contents are want.
+func checkConsistent[K, V comparable](t *testing.T, bm *BiMap[K, V], want map[K]V) {
+	t.Helper()
+	if bm.Len() != len(want) || len(bm.backward) != len(want) {
+		t.Fatalf("got Len=%d (backward %d), want %d", bm.Len(), len(bm.backward), len(want))
+	}
+	if got := maps.Collect(bm.All()); !maps.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if gotK, ok := bm.GetByValue(v); !ok || gotK != k {
+			t.Fatalf("GetByValue(%v)=%v,%v, want %v", v, gotK, ok, k)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	bm := New[int, string]()
+	bm.Put(1, "alice")
+	bm.Put(2, "bob")
+	if k, ok := bm.GetByValue("bob"); !ok || k != 2 {
+		t.Errorf("GetByValue(bob)=%d,%v", k, ok)
+	}
+	if v, ok := bm.GetByKey(1); !ok || v != "alice" {
+		t.Errorf("GetByKey(1)=%q,%v", v, ok)
+	}
+
+	// Renaming 1 releases "alice"; giving "bob" to 3 evicts 2.
+	bm.Put(1, "carol")
+	bm.Put(3, "bob")
+	checkConsistent(t, bm, map[int]string{1: "carol", 3: "bob"})
+
+	if !bm.DeleteByValue("carol") || bm.DeleteByKey(1) {
+		t.Errorf("bad Delete")
+	}
+	checkConsistent(t, bm, map[int]string{3: "bob"})
+
+	inv := bm.Inverse()
+	inv.Put("dave", 4)
+	checkConsistent(t, bm, map[int]string{3: "bob", 4: "dave"})
+	checkConsistent(t, inv, map[string]int{"bob": 3, "dave": 4})
+}
+
+func TestReject(t *testing.T) {
+	bm := NewWithPolicy[int, string](Reject)
+	if !bm.Put(1, "a") || !bm.Put(2, "b") {
+		t.Fatal("Put failed on empty slots")
+	}
+	if bm.Put(1, "c") || bm.Put(3, "b") || bm.Put(1, "b") {
+		t.Errorf("conflicting Put succeeded")
+	}
+	if !bm.Put(1, "a") {
+		t.Errorf("re-putting an existing pair failed")
+	}
+	checkConsistent(t, bm, map[int]string{1: "a", 2: "b"})
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, policy := range []Policy{Overwrite, Reject} {
+		bm := NewWithPolicy[int, int](policy)
+		want := make(map[int]int)
+		for range 3000 {
+			k, v := rnd.IntN(30), rnd.IntN(30)
+			switch rnd.IntN(4) {
+			case 0:
+				_, had := want[k]
+				if bm.DeleteByKey(k) != had {
+					t.Fatalf("DeleteByKey(%d) mismatch", k)
+				}
+				delete(want, k)
+			case 1:
+				had := false
+				for wk, wv := range want {
+					if wv == v {
+						delete(want, wk)
+						had = true
+					}
+				}
+				if bm.DeleteByValue(v) != had {
+					t.Fatalf("DeleteByValue(%d) mismatch", v)
+				}
+			default:
+				oldV, keyFound := want[k]
+				conflict := keyFound && oldV != v
+				for wk, wv := range want {
+					if wv == v && wk != k {
+						conflict = true
+						if policy == Overwrite {
+							delete(want, wk)
+						}
+					}
+				}
+				wantOk := !conflict || policy == Overwrite
+				if wantOk {
+					want[k] = v
+				}
+				if bm.Put(k, v) != wantOk {
+					t.Fatalf("Put(%d, %d) mismatch", k, v)
+				}
+			}
+			checkConsistent(t, bm, want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package bitvector implements a static bit vector supporting rank and
+// select queries, a building block for succinct data structures.
+package bitvector
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// BitVector is an immutable sequence of bits supporting rank queries
+// (counting the set bits before a position) in constant time and select
+// queries (finding the position of the k-th set bit) in nearly constant
+// time, with an index taking about 5% of the size of the bits.
+//
+// The index divides the bits into superblocks of 4096 bits, recording the
+// number of set bits before each, and blocks of 512 bits, recording the
+// number of set bits before each within its superblock; a rank query only
+// has to count the bits in up to 8 words of a block. Select queries use
+// samples of the positions of every 8192-th set (or clear) bit to find a
+// short range of superblocks, and then search the index. Create bit vectors
+// with [New], [FromBools] or a [Builder].
+type BitVector struct {
+	words []uint64
+	n     int
+
+	// super[i] is the number of set bits before superblock i, for every
+	// superblock and the end of the vector.
+	super []uint64
+
+	// blocks[i] is the number of set bits before block i, relative to the
+	// start of its superblock.
+	blocks []uint16
+
+	// samples1[i] is the index of the superblock holding the
+	// (i*sampleRate)-th set bit; samples0 is the same for clear bits.
+	samples1 []uint32
+	samples0 []uint32
+}
+
+const (
+	blockWords     = 8
+	blockBits      = 64 * blockWords
+	superWords     = 64
+	superBits      = 64 * superWords
+	blocksPerSuper = superWords / blockWords
+	sampleRate     = 8192
+)
+
+// New creates a bit vector of n bits stored in words: bit i is bit i%64 of
+// words[i/64]. The bit vector takes ownership of words, which must not be
+// modified afterwards. It panics if len(words) doesn't match n.
+func New(words []uint64, n int) *BitVector {
+	if n < 0 || len(words) != (n+63)/64 {
+		panic(fmt.Sprintf("bitvector: %d words for %d bits", len(words), n))
+	}
+	if n%64 != 0 {
+		// Clear the bits past the end, so that they aren't counted.
+		words[n/64] &= 1<<(n%64) - 1
+	}
+	bv := &BitVector{words: words, n: n}
+	bv.buildIndex()
+	return bv
+}
+
+// FromBools creates a bit vector whose bit i is set if bools[i] is true.
+func FromBools(bools []bool) *BitVector {
+	var b Builder
+	for _, v := range bools {
+		b.Append(v)
+	}
+	return b.Build()
+}
+
+// Builder builds a bit vector by appending bits. The zero value is an empty
+// builder ready to use.
+type Builder struct {
+	words []uint64
+	n     int
+}
+
+// Append appends bit v.
+func (b *Builder) Append(v bool) {
+	if b.n%64 == 0 {
+		b.words = append(b.words, 0)
+	}
+	if v {
+		b.words[b.n/64] |= 1 << (b.n % 64)
+	}
+	b.n++
+}
+
+// Len returns the number of bits appended so far.
+func (b *Builder) Len() int {
+	return b.n
+}
+
+// Build returns a bit vector holding the appended bits, and resets the
+// builder.
+func (b *Builder) Build() *BitVector {
+	bv := New(b.words, b.n)
+	*b = Builder{}
+	return bv
+}
+
+func (bv *BitVector) buildIndex() {
+	numSuper := (len(bv.words) + superWords - 1) / superWords
+	bv.super = make([]uint64, numSuper+1)
+	bv.blocks = make([]uint16, (len(bv.words)+blockWords-1)/blockWords)
+	var total uint64
+	var inSuper uint16
+	for i, w := range bv.words {
+		if i%superWords == 0 {
+			bv.super[i/superWords] = total
+			inSuper = 0
+		}
+		if i%blockWords == 0 {
+			bv.blocks[i/blockWords] = inSuper
+		}
+		c := bits.OnesCount64(w)
+		total += uint64(c)
+		inSuper += uint16(c)
+	}
+	bv.super[numSuper] = total
+
+	// Sample the superblocks holding every sampleRate-th set and clear bit.
+	// Clear bits past the end of the vector are counted in the last
+	// superblock, which doesn't affect queries for bits within the vector.
+	var next1, next0 uint64
+	for sb := range numSuper {
+		ones := bv.super[sb+1]
+		zeros := uint64(sb+1)*superBits - ones
+		for ; next1 < ones; next1 += sampleRate {
+			bv.samples1 = append(bv.samples1, uint32(sb))
+		}
+		for ; next0 < zeros; next0 += sampleRate {
+			bv.samples0 = append(bv.samples0, uint32(sb))
+		}
+	}
+}
+
+// Len returns the number of bits in the vector.
+func (bv *BitVector) Len() int {
+	return bv.n
+}
+
+// Ones returns the number of set bits in the vector.
+func (bv *BitVector) Ones() int {
+	return int(bv.super[len(bv.super)-1])
+}
+
+// Get returns bit i. It panics if i is out of range.
+func (bv *BitVector) Get(i int) bool {
+	if i < 0 || i >= bv.n {
+		panic(fmt.Sprintf("bitvector: index %d out of range [0, %d)", i, bv.n))
+	}
+	return bv.words[i/64]&(1<<(i%64)) != 0
+}
+
+// Rank1 returns the number of set bits in the range [0, i). It panics if i
+// is not in the range [0, Len()].
+func (bv *BitVector) Rank1(i int) int {
+	if i < 0 || i > bv.n {
+		panic(fmt.Sprintf("bitvector: rank position %d out of range [0, %d]", i, bv.n))
+	}
+	if i == bv.n {
+		return bv.Ones()
+	}
+	w := i / 64
+	r := bv.super[w/superWords] + uint64(bv.blocks[w/blockWords])
+	for _, word := range bv.words[w/blockWords*blockWords : w] {
+		r += uint64(bits.OnesCount64(word))
+	}
+	if i%64 != 0 {
+		r += uint64(bits.OnesCount64(bv.words[w] & (1<<(i%64) - 1)))
+	}
+	return int(r)
+}
+
+// Rank0 returns the number of clear bits in the range [0, i). It panics if
+// i is not in the range [0, Len()].
+func (bv *BitVector) Rank0(i int) int {
+	return i - bv.Rank1(i)
+}
+
+// Select1 returns the position of the k-th set bit, counting from 0, so
+// that Rank1(Select1(k)) == k. It panics if k is not in the range
+// [0, Ones()).
+func (bv *BitVector) Select1(k int) int {
+	if k < 0 || k >= bv.Ones() {
+		panic(fmt.Sprintf("bitvector: select rank %d out of range [0, %d)", k, bv.Ones()))
+	}
+	return bv.selectBit(uint64(k), true)
+}
+
+// Select0 returns the position of the k-th clear bit, counting from 0, so
+// that Rank0(Select0(k)) == k. It panics if k is not in the range
+// [0, Len()-Ones()).
+func (bv *BitVector) Select0(k int) int {
+	if zeros := bv.n - bv.Ones(); k < 0 || k >= zeros {
+		panic(fmt.Sprintf("bitvector: select rank %d out of range [0, %d)", k, zeros))
+	}
+	return bv.selectBit(uint64(k), false)
+}
+
+// selectBit returns the position of the k-th set bit if one is true, or
+// the k-th clear bit otherwise.
+func (bv *BitVector) selectBit(k uint64, one bool) int {
+	// count returns the number of matching bits before the n-th unit of
+	// size bits, given the number of set bits before it.
+	count := func(setBefore uint64, n, size int) uint64 {
+		if one {
+			return setBefore
+		}
+		return uint64(n*size) - setBefore
+	}
+	samples := bv.samples0
+	if one {
+		samples = bv.samples1
+	}
+
+	// Find the last superblock with at most k matching bits before it,
+	// between the superblocks of the surrounding samples.
+	s := k / sampleRate
+	lo := int(samples[s])
+	hi := len(bv.super) - 1
+	if s+1 < uint64(len(samples)) {
+		hi = int(samples[s+1]) + 1
+	}
+	sb := lo + sort.Search(hi-lo, func(i int) bool {
+		return count(bv.super[lo+i+1], lo+i+1, superBits) > k
+	})
+	k -= count(bv.super[sb], sb, superBits)
+
+	// Find the block within the superblock.
+	first := sb * blocksPerSuper
+	blk := first
+	for b := first + 1; b < min(first+blocksPerSuper, len(bv.blocks)); b++ {
+		if count(uint64(bv.blocks[b]), b-first, blockBits) > k {
+			break
+		}
+		blk = b
+	}
+	k -= count(uint64(bv.blocks[blk]), blk-first, blockBits)
+
+	// Find the word within the block, and the bit within the word.
+	for w := blk * blockWords; ; w++ {
+		word := bv.words[w]
+		if !one {
+			word = ^word
+		}
+		if c := uint64(bits.OnesCount64(word)); k >= c {
+			k -= c
+			continue
+		}
+		return w*64 + selectInWord(word, int(k))
+	}
+}
+
+// selectInWord returns the position of the k-th set bit of w, which must
+// have more than k set bits.
+func selectInWord(w uint64, k int) int {
+	pos := 0
+	for {
+		if c := bits.OnesCount8(uint8(w)); k >= c {
+			k -= c
+			w >>= 8
+			pos += 8
+			continue
+		}
+		for range k {
+			w &= w - 1
+		}
+		return pos + bits.TrailingZeros64(w)
+	}
+}
@@ -0,0 +1,142 @@
+package bitvector
+
+import (
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// checkBitVector checks all the queries of bv against a brute-force
+// computation over bools.
+func checkBitVector(t *testing.T, bv *BitVector, bools []bool) {
+	t.Helper()
+	if bv.Len() != len(bools) {
+		t.Fatalf("Len=%d, want %d", bv.Len(), len(bools))
+	}
+	ones, zeros := 0, 0
+	for i, v := range bools {
+		if bv.Get(i) != v {
+			t.Fatalf("Get(%d) = %v", i, !v)
+		}
+		if r := bv.Rank1(i); r != ones {
+			t.Fatalf("Rank1(%d) = %d, want %d", i, r, ones)
+		}
+		if r := bv.Rank0(i); r != zeros {
+			t.Fatalf("Rank0(%d) = %d, want %d", i, r, zeros)
+		}
+		if v {
+			if p := bv.Select1(ones); p != i {
+				t.Fatalf("Select1(%d) = %d, want %d", ones, p, i)
+			}
+			ones++
+		} else {
+			if p := bv.Select0(zeros); p != i {
+				t.Fatalf("Select0(%d) = %d, want %d", zeros, p, i)
+			}
+			zeros++
+		}
+	}
+	if bv.Ones() != ones || bv.Rank1(len(bools)) != ones || bv.Rank0(len(bools)) != zeros {
+		t.Fatalf("Ones=%d Rank1(n)=%d Rank0(n)=%d, want %d, %d", bv.Ones(), bv.Rank1(len(bools)), bv.Rank0(len(bools)), ones, zeros)
+	}
+}
+
+func TestRankSelect(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, n := range []int{0, 1, 63, 64, 65, 511, 512, 513, 4095, 4096, 4097, 8192, 50000, 100003} {
+		for _, density := range []float64{0, 0.001, 0.1, 0.5, 0.97, 1} {
+			bools := make([]bool, n)
+			for i := range bools {
+				bools[i] = rnd.Float64() < density
+			}
+			checkBitVector(t, FromBools(bools), bools)
+		}
+	}
+}
+
+func TestClusteredBits(t *testing.T) {
+	// Long stretches of set and clear bits exercise the select samples
+	// across many superblocks.
+	rnd := makeLoggedRand(t)
+	var bools []bool
+	for len(bools) < 300000 {
+		v := rnd.IntN(2) == 0
+		for range rnd.IntN(40000) {
+			bools = append(bools, v)
+		}
+	}
+	checkBitVector(t, FromBools(bools), bools)
+}
+
+func TestNew(t *testing.T) {
+	// Bits past the end are ignored.
+	bv := New([]uint64{0xffff_0000_0000_00ff, ^uint64(0)}, 70)
+	if bv.Ones() != 8+16+6 || bv.Select1(8) != 48 || bv.Select0(0) != 8 {
+		t.Errorf("Ones=%d Select1(8)=%d Select0(0)=%d", bv.Ones(), bv.Select1(8), bv.Select0(0))
+	}
+
+	var b Builder
+	for i := range 200 {
+		b.Append(i%3 == 0)
+	}
+	if b.Len() != 200 {
+		t.Errorf("Builder.Len=%d", b.Len())
+	}
+	bv = b.Build()
+	if b.Len() != 0 || bv.Ones() != 67 || bv.Select1(66) != 198 {
+		t.Errorf("Build: Ones=%d", bv.Ones())
+	}
+}
+
+func TestPanics(t *testing.T) {
+	bv := FromBools([]bool{true, false, true})
+	for name, f := range map[string]func(){
+		"New":         func() { New(make([]uint64, 2), 64) },
+		"Get":         func() { bv.Get(3) },
+		"Rank1":       func() { bv.Rank1(4) },
+		"Select1":     func() { bv.Select1(2) },
+		"Select0":     func() { bv.Select0(1) },
+		"Select0 neg": func() { bv.Select0(-1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s didn't panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func BenchmarkRank1(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	words := make([]uint64, 1<<16)
+	for i := range words {
+		words[i] = rnd.Uint64()
+	}
+	bv := New(words, 64*len(words))
+	b.ResetTimer()
+	for i := range b.N {
+		bv.Rank1(int(uint32(i)*2654435761) % bv.Len())
+	}
+}
+
+func BenchmarkSelect1(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	words := make([]uint64, 1<<16)
+	for i := range words {
+		words[i] = rnd.Uint64()
+	}
+	bv := New(words, 64*len(words))
+	b.ResetTimer()
+	for i := range b.N {
+		bv.Select1(int(uint32(i)*2654435761) % bv.Ones())
+	}
+}
@@ -0,0 +1,250 @@
+// Package bloom implements Bloom filters.
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+// Filter is a Bloom filter: a compact probabilistic set that can tell that
+// an element is definitely not in the set, or that it may be in it. Adding
+// an element sets k bits chosen by hashing it in an array of m bits; an
+// element may be in the set if all its bits are set.
+//
+// Elements are byte slices or strings, hashed with a fixed function so that
+// serialized filters can be used by other processes.
+//
+// Create filters with [New] or [NewWithSize].
+type Filter struct {
+	bits []uint64
+	m    uint64
+	k    int
+}
+
+// New creates a new, empty Filter sized to hold n elements with a false
+// positive rate of about fpRate (0 < fpRate < 1), using the optimal number
+// of bits and hash functions.
+func New(n int, fpRate float64) *Filter {
+	if n <= 0 || fpRate <= 0 || fpRate >= 1 {
+		panic(fmt.Sprintf("bloom: invalid parameters n=%d, fpRate=%v", n, fpRate))
+	}
+	m, k := OptimalParams(n, fpRate)
+	return NewWithSize(m, k)
+}
+
+// OptimalParams returns the number of bits m and the number of hash
+// functions k minimizing the size of a filter holding n elements with a
+// false positive rate of fpRate.
+func OptimalParams(n int, fpRate float64) (m int, k int) {
+	m = int(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k = int(math.Round(float64(m) / float64(n) * math.Ln2))
+	return max(m, 1), max(k, 1)
+}
+
+// NewWithSize creates a new, empty Filter with m bits (rounded up to a
+// multiple of 64) and k hash functions.
+func NewWithSize(m, k int) *Filter {
+	if m <= 0 || k <= 0 {
+		panic(fmt.Sprintf("bloom: invalid size m=%d, k=%d", m, k))
+	}
+	words := (m + 63) / 64
+	return &Filter{bits: make([]uint64, words), m: uint64(words * 64), k: k}
+}
+
+// Bits returns the number of bits in the filter.
+func (f *Filter) Bits() int {
+	return int(f.m)
+}
+
+// HashFunctions returns the number of hash functions the filter uses.
+func (f *Filter) HashFunctions() int {
+	return f.k
+}
+
+// Add adds data to the filter.
+func (f *Filter) Add(data []byte) {
+	f.AddHash(Hash(data))
+}
+
+// AddString adds s to the filter.
+func (f *Filter) AddString(s string) {
+	f.AddHash(HashString(s))
+}
+
+// AddHash adds an element with the 64-bit hash h to the filter. It can be
+// used with a custom hash function, which must spread its output over all
+// 64 bits.
+func (f *Filter) AddHash(h uint64) {
+	h1, h2 := split(h)
+	for i := range f.k {
+		b := f.index(h1, h2, i)
+		f.bits[b/64] |= 1 << (b % 64)
+	}
+}
+
+// MaybeContains reports whether data may have been added to the filter. If
+// it returns false, data definitely wasn't added.
+func (f *Filter) MaybeContains(data []byte) bool {
+	return f.MaybeContainsHash(Hash(data))
+}
+
+// MaybeContainsString reports whether s may have been added to the filter.
+// If it returns false, s definitely wasn't added.
+func (f *Filter) MaybeContainsString(s string) bool {
+	return f.MaybeContainsHash(HashString(s))
+}
+
+// MaybeContainsHash reports whether an element with the 64-bit hash h may
+// have been added with [Filter.AddHash].
+func (f *Filter) MaybeContainsHash(h uint64) bool {
+	h1, h2 := split(h)
+	for i := range f.k {
+		b := f.index(h1, h2, i)
+		if f.bits[b/64]&(1<<(b%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// split derives two hashes from h for double hashing.
+func split(h uint64) (h1, h2 uint64) {
+	// h2 must be odd, so that the probe sequence doesn't get stuck when m
+	// shares factors with it.
+	return h, hash.Mix(h) | 1
+}
+
+// index returns the bit set by the i-th hash function, using the double
+// hashing scheme of Kirsch and Mitzenmacher: g_i = h1 + i*h2, mapped onto
+// [0, m) by multiplication rather than a slower modulo.
+func (f *Filter) index(h1, h2 uint64, i int) uint64 {
+	hi, _ := bits.Mul64(h1+uint64(i)*h2, f.m)
+	return hi
+}
+
+// FillRatio returns the fraction of the filter's bits that are set.
+func (f *Filter) FillRatio() float64 {
+	return float64(f.setBits()) / float64(f.m)
+}
+
+// EstimatedCount estimates the number of distinct elements added to the
+// filter from the number of bits set.
+func (f *Filter) EstimatedCount() int {
+	x := float64(f.setBits())
+	if x == float64(f.m) {
+		return math.MaxInt
+	}
+	m, k := float64(f.m), float64(f.k)
+	return int(math.Round(-m / k * math.Log(1-x/m)))
+}
+
+// EstimatedFPRate estimates the current false positive rate of the filter
+// from the number of bits set.
+func (f *Filter) EstimatedFPRate() float64 {
+	return math.Pow(f.FillRatio(), float64(f.k))
+}
+
+func (f *Filter) setBits() int {
+	n := 0
+	for _, w := range f.bits {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// ErrIncompatible is returned when combining filters with different
+// parameters.
+var ErrIncompatible = errors.New("bloom: filters have different parameters")
+
+// Union adds all the elements of other to f. The filters must have the
+// same number of bits and hash functions; otherwise Union returns
+// ErrIncompatible and leaves f unchanged.
+func (f *Filter) Union(other *Filter) error {
+	if f.m != other.m || f.k != other.k {
+		return ErrIncompatible
+	}
+	for i, w := range other.bits {
+		f.bits[i] |= w
+	}
+	return nil
+}
+
+// Clear removes all the elements from the filter.
+func (f *Filter) Clear() {
+	clear(f.bits)
+}
+
+// Clone returns a copy of the filter.
+func (f *Filter) Clone() *Filter {
+	return &Filter{bits: append([]uint64(nil), f.bits...), m: f.m, k: f.k}
+}
+
+// The binary encoding of a filter is a header followed by the bit array as
+// little-endian 64-bit words:
+//
+//	"GBLM" | version u32 | k u32 | number of words u64 | words
+
+const (
+	encodingMagic      = "GBLM"
+	encodingVersion    = 1
+	encodingHeaderSize = 20
+)
+
+// ErrInvalidData is returned when decoding data that isn't a valid
+// encoding of a filter.
+var ErrInvalidData = errors.New("bloom: invalid filter data")
+
+// MarshalBinary encodes the filter into a binary form; it implements
+// [encoding.BinaryMarshaler].
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, encodingHeaderSize, encodingHeaderSize+8*len(f.bits))
+	copy(buf, encodingMagic)
+	binary.LittleEndian.PutUint32(buf[4:], encodingVersion)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(f.k))
+	binary.LittleEndian.PutUint64(buf[12:], uint64(len(f.bits)))
+	for _, w := range f.bits {
+		buf = binary.LittleEndian.AppendUint64(buf, w)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary replaces the contents of f by decoding data produced by
+// [Filter.MarshalBinary]; it implements [encoding.BinaryUnmarshaler]. It
+// returns ErrInvalidData if data isn't a valid encoding.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < encodingHeaderSize || string(data[:4]) != encodingMagic ||
+		binary.LittleEndian.Uint32(data[4:]) != encodingVersion {
+		return ErrInvalidData
+	}
+	k := binary.LittleEndian.Uint32(data[8:])
+	words := binary.LittleEndian.Uint64(data[12:])
+	data = data[encodingHeaderSize:]
+	if k == 0 || k > math.MaxInt32 || words == 0 ||
+		len(data)%8 != 0 || uint64(len(data))/8 != words {
+		return ErrInvalidData
+	}
+	f.bits = make([]uint64, words)
+	for i := range f.bits {
+		f.bits[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	f.m = 64 * words
+	f.k = int(k)
+	return nil
+}
+
+// Hash returns the 64-bit hash of data used by [Filter.Add]. It's
+// deterministic across processes and platforms.
+func Hash(data []byte) uint64 {
+	return hash.Bytes(data)
+}
+
+// HashString returns the 64-bit hash of s used by [Filter.AddString]; it
+// equals Hash([]byte(s)).
+func HashString(s string) uint64 {
+	return hash.String(s)
+}
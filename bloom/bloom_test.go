@@ -0,0 +1,170 @@
+package bloom
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Filter)(nil)
+	_ encoding.BinaryUnmarshaler = (*Filter)(nil)
+)
+
+func TestOptimalParams(t *testing.T) {
+	for _, tt := range []struct {
+		n     int
+		p     float64
+		wantM int
+		wantK int
+	}{
+		{1000, 0.01, 9586, 7},
+		{1000, 0.001, 14378, 10},
+		{1, 0.5, 2, 1},
+		{1000000, 0.05, 6235225, 4},
+	} {
+		m, k := OptimalParams(tt.n, tt.p)
+		if m != tt.wantM || k != tt.wantK {
+			t.Errorf("OptimalParams(%d, %v)=%d,%d, want %d,%d", tt.n, tt.p, m, k, tt.wantM, tt.wantK)
+		}
+	}
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	for _, p := range []float64{0.1, 0.01, 0.001} {
+		const n = 20000
+		f := New(n, p)
+		for i := range n {
+			f.AddString(fmt.Sprintf("in-%d", i))
+		}
+		for i := range n {
+			if !f.MaybeContainsString(fmt.Sprintf("in-%d", i)) {
+				t.Fatalf("p=%v: false negative for in-%d", p, i)
+			}
+		}
+		const trials = 200000
+		fp := 0
+		for i := range trials {
+			if f.MaybeContains([]byte(fmt.Sprintf("out-%d", i))) {
+				fp++
+			}
+		}
+		rate := float64(fp) / trials
+		if rate > 1.3*p {
+			t.Errorf("p=%v: false positive rate %v", p, rate)
+		}
+		if est := f.EstimatedFPRate(); math.Abs(est-p)/p > 0.3 {
+			t.Errorf("p=%v: EstimatedFPRate=%v", p, est)
+		}
+		if est := f.EstimatedCount(); math.Abs(float64(est-n))/n > 0.05 {
+			t.Errorf("p=%v: EstimatedCount=%d, want about %d", p, est, n)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	for _, s := range []string{"", "a", "hello, world"} {
+		if Hash([]byte(s)) != HashString(s) {
+			t.Errorf("Hash(%q) != HashString(%q)", s, s)
+		}
+	}
+	// The hash is part of the serialization format, so it must not change.
+	if got, want := HashString("gogl"), uint64(0x6487b4883e3895a5); got != want {
+		t.Errorf("HashString(gogl)=%#x, want %#x", got, want)
+	}
+}
+
+func TestUnion(t *testing.T) {
+	a := New(100, 0.01)
+	b := New(100, 0.01)
+	a.AddString("x")
+	b.AddString("y")
+	if err := a.Union(b); err != nil {
+		t.Fatal(err)
+	}
+	if !a.MaybeContainsString("x") || !a.MaybeContainsString("y") {
+		t.Errorf("union is missing elements")
+	}
+
+	c := New(100, 0.001)
+	before := a.Clone()
+	if err := a.Union(c); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Union of incompatible filters: %v", err)
+	}
+	if a.FillRatio() != before.FillRatio() {
+		t.Errorf("failed Union modified the filter")
+	}
+
+	a.Clear()
+	if a.FillRatio() != 0 || a.MaybeContainsString("x") {
+		t.Errorf("Clear didn't empty the filter")
+	}
+	if !before.MaybeContainsString("x") {
+		t.Errorf("Clear modified a clone")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	f := New(500, 0.01)
+	for i := range 500 {
+		f.AddString(fmt.Sprint(i))
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g Filter
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Bits() != f.Bits() || g.HashFunctions() != f.HashFunctions() {
+		t.Errorf("decoded m=%d k=%d, want m=%d k=%d", g.Bits(), g.HashFunctions(), f.Bits(), f.HashFunctions())
+	}
+	for i := range 500 {
+		if !g.MaybeContainsString(fmt.Sprint(i)) {
+			t.Fatalf("decoded filter is missing %d", i)
+		}
+	}
+	if err := g.Union(f); err != nil {
+		t.Errorf("decoded filter is incompatible: %v", err)
+	}
+
+	// A word count whose size in bytes overflows.
+	huge := slices.Clone(data[:encodingHeaderSize])
+	binary.LittleEndian.PutUint64(huge[12:], 1<<61)
+
+	for _, bad := range [][]byte{
+		nil,
+		huge,
+		data[:10],
+		data[:len(data)-1],
+		append([]byte("XBLM"), data[4:]...),
+		append(append([]byte(nil), data[:8]...), append([]byte{0, 0, 0, 0}, data[12:]...)...),
+	} {
+		if err := g.UnmarshalBinary(bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("UnmarshalBinary(%d bytes): %v", len(bad), err)
+		}
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	f := New(1000000, 0.01)
+	key := []byte("some moderately long key")
+	for i := range b.N {
+		key[0] = byte(i)
+		f.Add(key)
+	}
+}
+
+func BenchmarkMaybeContains(b *testing.B) {
+	f := New(1000000, 0.01)
+	key := []byte("some moderately long key")
+	for i := range b.N {
+		key[0] = byte(i)
+		f.MaybeContains(key)
+	}
+}
@@ -18,6 +18,8 @@ type BTree[K, V any] struct {
 	// the root must have between t-1 and 2t-1 keys (inclusive).
 	// Nodes with 2t-1 keys are considered "full".
 	tee int
+
+	length int
 }
 
 const defaultTee = 10
@@ -69,6 +71,11 @@ func (bt *BTree[K, V]) Get(key K) (v V, ok bool) {
 	return bt.getFromNode(key, bt.root)
 }
 
+// Len returns the number of keys in the tree.
+func (bt *BTree[K, V]) Len() int {
+	return bt.length
+}
+
 // Insert inserts a new key=value pair into the tree. If `key` already exists
 // in the tree, its value is replaced with `value`.
 func (bt *BTree[K, V]) Insert(key K, value V) {
@@ -87,15 +94,18 @@ func (bt *BTree[K, V]) Insert(key K, value V) {
 	bt.insertNonFull(bt.root, nodeKey[K, V]{key: key, value: value})
 }
 
-// Delete deletes a key and its associated value from the tree. If key
-// is not found in the tree, Delete is a no-op.
-func (bt *BTree[K, V]) Delete(key K) {
+// Delete deletes a key and its associated value from the tree. It returns
+// true if the key was found and deleted; if key is not found in the tree,
+// Delete is a no-op and returns false.
+func (bt *BTree[K, V]) Delete(key K) bool {
 	var emptyPath treePath[K, V]
 	n, idx, path := bt.findNodeForDeletion(bt.root, key, emptyPath)
 
 	if n == nil {
-		return
-	} else if n.leaf {
+		return false
+	}
+	bt.length--
+	if n.leaf {
 		// Deletion from a leaf.
 		n.keys = slices.Delete(n.keys, idx, idx+1)
 	} else {
@@ -113,6 +123,65 @@ func (bt *BTree[K, V]) Delete(key K) {
 	if n != bt.root {
 		bt.rebalance(n, path)
 	}
+	return true
+}
+
+// All returns an iterator over all key, value pairs in the tree, in
+// ascending order of keys.
+func (bt *BTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		bt.ascend(bt.root, nil, nil, yield)
+	}
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys.
+func (bt *BTree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		bt.ascend(bt.root, &lo, &hi, yield)
+	}
+}
+
+// Floor finds the largest key in the tree that's smaller than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (bt *BTree[K, V]) Floor(key K) (k K, v V, ok bool) {
+	n := bt.root
+	for {
+		i, found := slices.BinarySearchFunc(n.keys, nodeKey[K, V]{key: key}, bt.nodeKeyCmp)
+		if found {
+			return n.keys[i].key, n.keys[i].value, true
+		}
+		// keys[i-1] < key < keys[i]; keys[i-1] is the best candidate so far,
+		// and a better one may be found in children[i].
+		if i > 0 {
+			k, v, ok = n.keys[i-1].key, n.keys[i-1].value, true
+		}
+		if n.leaf {
+			return k, v, ok
+		}
+		n = n.children[i]
+	}
+}
+
+// Ceiling finds the smallest key in the tree that's larger than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (bt *BTree[K, V]) Ceiling(key K) (k K, v V, ok bool) {
+	n := bt.root
+	for {
+		i, found := slices.BinarySearchFunc(n.keys, nodeKey[K, V]{key: key}, bt.nodeKeyCmp)
+		if found {
+			return n.keys[i].key, n.keys[i].value, true
+		}
+		if i < len(n.keys) {
+			k, v, ok = n.keys[i].key, n.keys[i].value, true
+		}
+		if n.leaf {
+			return k, v, ok
+		}
+		n = n.children[i]
+	}
 }
 
 // Stats returns a string with statistics about this B-Tree: total number of
@@ -232,14 +301,20 @@ func (bt *BTree[K, V]) insertNonFull(n *node[K, V], kv nodeKey[K, V]) {
 	// The key doesn't exist, and should be inserted at n.keys[i]
 	if n.leaf {
 		n.keys = slices.Insert(n.keys, i, kv)
+		bt.length++
 	} else {
 		// We want to recursively insert kv into n.children[i], but first we have
 		// to guarantee that node is not full.
 		if bt.nodeIsFull(n.children[i]) {
 			bt.splitChild(n, i)
 			// We've split n.children[i], and its median key moved up to n.keys[i];
-			// compare kv to this key to insert into the proper child.
-			if bt.cmp(kv.key, n.keys[i].key) > 0 {
+			// compare kv to this key to insert into the proper child. If it's
+			// the same key, replace its value here.
+			c := bt.cmp(kv.key, n.keys[i].key)
+			if c == 0 {
+				n.keys[i] = kv
+				return
+			} else if c > 0 {
 				i++
 			}
 		}
@@ -257,6 +332,33 @@ func (bt *BTree[K, V]) nodeKeyCmp(a, b nodeKey[K, V]) int {
 	return bt.cmp(a.key, b.key)
 }
 
+// ascend yields the key, value pairs in the subtree rooted at n in
+// ascending order, limited to the range [lo, hi) with nil meaning unbounded.
+// It returns false if iteration should stop, either because yield returned
+// false or because hi was reached.
+func (bt *BTree[K, V]) ascend(n *node[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	// Skip the keys smaller than lo, along with the children preceding them.
+	start := 0
+	if lo != nil {
+		start, _ = slices.BinarySearchFunc(n.keys, nodeKey[K, V]{key: *lo}, bt.nodeKeyCmp)
+	}
+	for i := start; i <= len(n.keys); i++ {
+		if !n.leaf && !bt.ascend(n.children[i], lo, hi, yield) {
+			return false
+		}
+		if i == len(n.keys) {
+			break
+		}
+		if hi != nil && bt.cmp(n.keys[i].key, *hi) >= 0 {
+			return false
+		}
+		if !yield(n.keys[i].key, n.keys[i].value) {
+			return false
+		}
+	}
+	return true
+}
+
 // nodesPreOrder returns an iterator over the nodes of bt in pre-order.
 func (bt *BTree[K, V]) nodesPreOrder() iter.Seq[*node[K, V]] {
 	return func(yield func(*node[K, V]) bool) {
@@ -219,7 +219,10 @@ func (bh *btHarness) insertNoCheck(k int) {
 }
 
 func (bh *btHarness) del(k int) {
-	bh.bt.Delete(k)
+	_, inMap := bh.m[k]
+	if bh.bt.Delete(k) != inMap {
+		bh.t.Errorf("Delete(%d) returned %v, want %v", k, !inMap, inMap)
+	}
 	delete(bh.m, k)
 
 	bh.check()
@@ -227,6 +230,9 @@ func (bh *btHarness) del(k int) {
 
 func (bh *btHarness) check() {
 	checkVerify(bh.t, bh.bt)
+	if bh.bt.Len() != len(bh.m) {
+		bh.t.Errorf("got Len=%d, want %d", bh.bt.Len(), len(bh.m))
+	}
 	for k, v := range bh.m {
 		checkFound(bh.t, bh.bt, k, v)
 	}
@@ -268,6 +274,25 @@ func TestManualDeletionLeavesOnly(t *testing.T) {
 	}
 }
 
+func TestDeleteResult(t *testing.T) {
+	// Delete reports whether it found the key, like the module's other
+	// maps, so that the B-tree satisfies container.Map.
+	bt := NewWithTee[int, string](intCmp, 4)
+	if bt.Delete(1) {
+		t.Errorf("Delete on an empty tree returned true")
+	}
+	for i := range 50 {
+		bt.Insert(i, strconv.Itoa(i))
+	}
+	var del func(int) bool = bt.Delete
+	for i := range 50 {
+		if !del(i) || del(i) {
+			t.Fatalf("bad Delete(%d) results", i)
+		}
+	}
+	checkEmpty(t, bt)
+}
+
 func TestDeleteAllSmall(t *testing.T) {
 	bt := NewWithTee[int, string](intCmp, 4)
 	h := newHarness(t, bt)
@@ -364,6 +389,82 @@ func TestChurn(t *testing.T) {
 	}
 }
 
+func TestIterationAndBounds(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	bt := NewWithTee[int, string](intCmp, 3)
+	keys := randomIntSlice(rnd, 500, 2000)
+	for _, k := range keys {
+		bt.Insert(k*2, strconv.Itoa(k*2))
+	}
+	// Only even keys are in the tree, so odd queries exercise missing keys.
+	var sorted []int
+	for _, k := range keys {
+		sorted = append(sorted, k*2)
+	}
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	var got []int
+	for k, v := range bt.All() {
+		if v != strconv.Itoa(k) {
+			t.Errorf("got value %q for key %d", v, k)
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, sorted) {
+		t.Errorf("All mismatch: got %v, want %v", got, sorted)
+	}
+
+	for range 200 {
+		lo := rnd.IntN(4200) - 100
+		hi := lo + rnd.IntN(300)
+		got = nil
+		for k := range bt.Range(lo, hi) {
+			got = append(got, k)
+		}
+		var want []int
+		for _, k := range sorted {
+			if k >= lo && k < hi {
+				want = append(want, k)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("Range(%d, %d)=%v, want %v", lo, hi, got, want)
+		}
+
+		q := rnd.IntN(4200) - 100
+		i, found := slices.BinarySearch(sorted, q)
+		fk, _, fok := bt.Floor(q)
+		switch {
+		case found && (!fok || fk != q):
+			t.Fatalf("Floor(%d)=%d,%v, want exact match", q, fk, fok)
+		case !found && i == 0 && fok:
+			t.Fatalf("Floor(%d)=%d, want none", q, fk)
+		case !found && i > 0 && (!fok || fk != sorted[i-1]):
+			t.Fatalf("Floor(%d)=%d,%v, want %d", q, fk, fok, sorted[i-1])
+		}
+		ck, _, cok := bt.Ceiling(q)
+		switch {
+		case i == len(sorted) && cok:
+			t.Fatalf("Ceiling(%d)=%d, want none", q, ck)
+		case i < len(sorted) && (!cok || ck != sorted[i]):
+			t.Fatalf("Ceiling(%d)=%d,%v, want %d", q, ck, cok, sorted[i])
+		}
+	}
+
+	// Stopping early.
+	n := 0
+	for range bt.All() {
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("got %d iterations", n)
+	}
+}
+
 // randString generates a random string made from lowercase chars with minimal
 // length minLen; it uses rnd as the RNG state.
 func randString(rnd *rand.Rand, minLen int) string {
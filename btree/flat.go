@@ -0,0 +1,412 @@
+package btree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"sort"
+
+	"github.com/eliben/gogl/pager"
+)
+
+// The flat format stores a B-tree in a file that can be queried in place,
+// without deserializing it: nodes refer to each other and to their keys and
+// values by offsets from the start of the file, so it can be used wherever
+// it's loaded or mapped in memory. Nodes are written in post-order, each
+// after its children, keys and values:
+//
+//	node:   nkeys uint32, leaf uint32, nkeys slots, (nkeys+1) child offsets (uint64) if not a leaf
+//	slot:   key offset uint64, key length uint32, value length uint32, value offset uint64
+//	footer: root offset uint64, number of keys uint64, magic string
+//
+// All integers are little-endian.
+const (
+	flatMagic      = "GOGLBTF1"
+	flatNodeHeader = 8
+	flatSlotSize   = 24
+	flatFooterSize = 16 + len(flatMagic)
+
+	// flatMaxDepth bounds the depth of the trees accepted by Verify and
+	// PagedFlat.
+	flatMaxDepth = 64
+)
+
+// ErrInvalidData is returned when opening or verifying data that isn't a
+// valid flat B-tree.
+var ErrInvalidData = errors.New("btree: invalid flat data")
+
+// WriteFlat writes bt to w in a flat format that can be queried in place
+// with [Flat]. Keys and values are written as the byte slices returned by
+// encodeKey and encodeValue; since a Flat compares keys with [bytes.Compare],
+// encodeKey must preserve the order of keys, or WriteFlat returns an error.
+// Big-endian encodings of unsigned integers and UTF-8 strings, for example,
+// preserve their natural order.
+func WriteFlat[K, V any](w io.Writer, bt *BTree[K, V], encodeKey func(K) []byte, encodeValue func(V) []byte) error {
+	fw := &flatWriter{w: bufio.NewWriter(w)}
+	root, _, _, err := writeFlatNode(fw, bt.root, encodeKey, encodeValue)
+	if err != nil {
+		return err
+	}
+	footer := binary.LittleEndian.AppendUint64(nil, root)
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(bt.length))
+	footer = append(footer, flatMagic...)
+	fw.write(footer)
+	if fw.err != nil {
+		return fw.err
+	}
+	return fw.w.Flush()
+}
+
+// flatWriter writes to w, keeping track of the offset and of the first
+// error.
+type flatWriter struct {
+	w   *bufio.Writer
+	off uint64
+	err error
+}
+
+func (fw *flatWriter) write(b []byte) {
+	if fw.err == nil {
+		_, fw.err = fw.w.Write(b)
+		fw.off += uint64(len(b))
+	}
+}
+
+// writeFlatNode writes the subtree of n, and returns the offset of n, and
+// the smallest and largest encoded keys of the subtree (nil if it's empty).
+func writeFlatNode[K, V any](fw *flatWriter, n *node[K, V], encodeKey func(K) []byte, encodeValue func(V) []byte) (off uint64, lo, hi []byte, err error) {
+	var children []uint64
+	var childLo, childHi [][]byte
+	if !n.leaf {
+		for _, c := range n.children {
+			off, clo, chi, err := writeFlatNode(fw, c, encodeKey, encodeValue)
+			if err != nil {
+				return 0, nil, nil, err
+			}
+			children = append(children, off)
+			childLo, childHi = append(childLo, clo), append(childHi, chi)
+		}
+	}
+
+	slots := make([]byte, 0, flatSlotSize*len(n.keys))
+	var prev []byte
+	havePrev := !n.leaf
+	if havePrev {
+		prev = childHi[0]
+	}
+	for i, kv := range n.keys {
+		key, value := encodeKey(kv.key), encodeValue(kv.value)
+		if havePrev && bytes.Compare(prev, key) >= 0 {
+			return 0, nil, nil, fmt.Errorf("btree: encoded key %q isn't larger than the previous one %q", key, prev)
+		}
+		if !n.leaf && bytes.Compare(key, childLo[i+1]) >= 0 {
+			return 0, nil, nil, fmt.Errorf("btree: encoded key %q isn't smaller than the next one %q", key, childLo[i+1])
+		}
+		if i == 0 {
+			lo = key
+		}
+		slots = binary.LittleEndian.AppendUint64(slots, fw.off)
+		slots = binary.LittleEndian.AppendUint32(slots, uint32(len(key)))
+		slots = binary.LittleEndian.AppendUint32(slots, uint32(len(value)))
+		slots = binary.LittleEndian.AppendUint64(slots, fw.off+uint64(len(key)))
+		fw.write(key)
+		fw.write(value)
+		prev, havePrev = key, true
+		if !n.leaf {
+			prev = childHi[i+1]
+		}
+	}
+	hi = prev
+	if !n.leaf {
+		lo = childLo[0]
+	}
+
+	off = fw.off
+	header := binary.LittleEndian.AppendUint32(nil, uint32(len(n.keys)))
+	leaf := uint32(0)
+	if n.leaf {
+		leaf = 1
+	}
+	header = binary.LittleEndian.AppendUint32(header, leaf)
+	fw.write(header)
+	fw.write(slots)
+	for _, c := range children {
+		fw.write(binary.LittleEndian.AppendUint64(nil, c))
+	}
+	return off, lo, hi, fw.err
+}
+
+// Flat is a read-only B-tree of byte-slice keys and values in the format
+// written by [WriteFlat], queried in place: nothing is deserialized when
+// it's opened, and queries return slices of its data without copying. When
+// opened from a file with [OpenFlat], the file is memory-mapped where the
+// platform supports it, so that only the pages touched by queries are read
+// from disk, and they're shared with other processes mapping the file.
+// This makes Flat suitable for large static lookup tables.
+//
+// Opening a Flat only checks its footer; use [Flat.Verify] to check the
+// whole data before querying data that may be corrupted, since queries
+// may panic on invalid data. A Flat is safe for concurrent use by multiple
+// goroutines. Create Flats with [OpenFlat] or [NewFlat].
+type Flat struct {
+	data   []byte
+	root   uint64
+	length int
+
+	// unmap releases data; it's nil if data wasn't mapped.
+	unmap func() error
+}
+
+// NewFlat returns a Flat querying data, which must hold a B-tree written by
+// [WriteFlat] and must not be modified while the Flat is used. data may be
+// embedded in the binary with a //go:embed directive.
+func NewFlat(data []byte) (*Flat, error) {
+	if len(data) < flatFooterSize || string(data[len(data)-len(flatMagic):]) != flatMagic {
+		return nil, ErrInvalidData
+	}
+	footer := data[len(data)-flatFooterSize:]
+	f := &Flat{
+		data:   data,
+		root:   binary.LittleEndian.Uint64(footer),
+		length: int(binary.LittleEndian.Uint64(footer[8:])),
+	}
+	if f.root >= uint64(len(data)-flatFooterSize) || f.length < 0 {
+		return nil, ErrInvalidData
+	}
+	return f, nil
+}
+
+// OpenFlat opens the file at path, which must hold a B-tree written by
+// [WriteFlat], and returns a Flat querying it. The Flat must be closed with
+// [Flat.Close] when done.
+func OpenFlat(path string) (*Flat, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < int64(flatFooterSize) || fi.Size() != int64(int(fi.Size())) {
+		return nil, ErrInvalidData
+	}
+	data, unmap, err := mapFile(file, int(fi.Size()))
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewFlat(data)
+	if err != nil {
+		if unmap != nil {
+			unmap()
+		}
+		return nil, err
+	}
+	f.unmap = unmap
+	return f, nil
+}
+
+// ReadFlat reads the stream of p, which must hold a B-tree written by
+// [WriteFlat] to a [pager.Writer], and returns a Flat querying it. Unlike
+// [OpenFlat], it reads the whole B-tree into memory; [OpenPagedFlat] reads
+// only the pages it needs, through a cache.
+func ReadFlat(p pager.Pager) (*Flat, error) {
+	r, err := pager.NewReader(p)
+	if errors.Is(err, pager.ErrInvalidData) {
+		return nil, ErrInvalidData
+	} else if err != nil {
+		return nil, err
+	}
+	if r.Size() != int64(int(r.Size())) {
+		return nil, ErrInvalidData
+	}
+	data := make([]byte, r.Size())
+	if _, err := r.ReadAt(data, 0); err != nil {
+		return nil, err
+	}
+	return NewFlat(data)
+}
+
+// Close releases the resources of f. The slices returned by its queries
+// must not be used after Close.
+func (f *Flat) Close() error {
+	unmap := f.unmap
+	f.data, f.unmap = nil, nil
+	if unmap != nil {
+		return unmap()
+	}
+	return nil
+}
+
+// Len returns the number of keys in the tree.
+func (f *Flat) Len() int {
+	return f.length
+}
+
+// Get looks for key in the tree. It returns the associated value and
+// ok=true; otherwise, it returns ok=false. The value is a slice of the
+// tree's data, which must not be modified.
+func (f *Flat) Get(key []byte) (value []byte, ok bool) {
+	off := f.root
+	for {
+		n := f.nkeys(off)
+		i := sort.Search(n, func(i int) bool {
+			return bytes.Compare(f.key(off, i), key) >= 0
+		})
+		if i < n && bytes.Equal(f.key(off, i), key) {
+			return f.value(off, i), true
+		}
+		if f.isLeaf(off) {
+			return nil, false
+		}
+		off = f.child(off, i)
+	}
+}
+
+// All returns an iterator over all the key, value pairs in the tree, in
+// ascending order of keys. The keys and values are slices of the tree's
+// data, which must not be modified.
+func (f *Flat) All() iter.Seq2[[]byte, []byte] {
+	return f.Range(nil, nil)
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys; a nil lo or hi leaves the range
+// unbounded on that side. The keys and values are slices of the tree's
+// data, which must not be modified.
+func (f *Flat) Range(lo, hi []byte) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		f.ascend(f.root, lo, hi, yield)
+	}
+}
+
+// ascend is like BTree.ascend, for the subtree of the node at off.
+func (f *Flat) ascend(off uint64, lo, hi []byte, yield func([]byte, []byte) bool) bool {
+	n := f.nkeys(off)
+	leaf := f.isLeaf(off)
+	start := 0
+	if lo != nil {
+		start = sort.Search(n, func(i int) bool {
+			return bytes.Compare(f.key(off, i), lo) >= 0
+		})
+	}
+	for i := start; i <= n; i++ {
+		if !leaf && !f.ascend(f.child(off, i), lo, hi, yield) {
+			return false
+		}
+		if i == n {
+			break
+		}
+		key := f.key(off, i)
+		if hi != nil && bytes.Compare(key, hi) >= 0 {
+			return false
+		}
+		if !yield(key, f.value(off, i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify checks that the whole data of f is a valid B-tree, returning
+// ErrInvalidData otherwise. It reads all the data, so it takes O(n) time.
+func (f *Flat) Verify() error {
+	count := 0
+	leafDepth := -1
+	if !f.verifyNode(f.root, uint64(len(f.data)-flatFooterSize), nil, nil, 0, &leafDepth, &count) || count != f.length {
+		return ErrInvalidData
+	}
+	return nil
+}
+
+// verifyNode checks the subtree of the node at off, which must end before
+// end, with keys in the range (lo, hi). It adds the number of keys to count,
+// and checks that leaves are at leafDepth, setting it at the first leaf.
+func (f *Flat) verifyNode(off, end uint64, lo, hi []byte, depth int, leafDepth *int, count *int) bool {
+	if depth > flatMaxDepth || off >= end || end-off < flatNodeHeader {
+		return false
+	}
+	n := uint64(f.nkeys(off))
+	leaf := binary.LittleEndian.Uint32(f.data[off+4:])
+	size := flatNodeHeader + n*flatSlotSize
+	if leaf == 0 {
+		size += 8 * (n + 1)
+	}
+	if leaf > 1 || n > end || size > end-off || (n == 0 && (leaf == 0 || depth > 0)) {
+		return false
+	}
+	for i := range int(n) {
+		slot := f.data[off+flatNodeHeader+uint64(i)*flatSlotSize:]
+		keyOff, keyLen := binary.LittleEndian.Uint64(slot), uint64(binary.LittleEndian.Uint32(slot[8:]))
+		valLen, valOff := uint64(binary.LittleEndian.Uint32(slot[12:])), binary.LittleEndian.Uint64(slot[16:])
+		if keyOff > off || keyLen > off-keyOff || valOff > off || valLen > off-valOff {
+			return false
+		}
+	}
+	*count += int(n)
+	if leaf == 1 {
+		if *leafDepth < 0 {
+			*leafDepth = depth
+		}
+		if *leafDepth != depth {
+			return false
+		}
+	}
+	for i := 0; i <= int(n); i++ {
+		var key []byte
+		if i < int(n) {
+			key = f.key(off, i)
+		}
+		if leaf == 0 && !f.verifyNode(f.child(off, i), off, lo, key, depth+1, leafDepth, count) {
+			return false
+		}
+		if key == nil {
+			break
+		}
+		if lo != nil && bytes.Compare(lo, key) >= 0 {
+			return false
+		}
+		if hi != nil && bytes.Compare(key, hi) >= 0 {
+			return false
+		}
+		lo = key
+	}
+	return true
+}
+
+// nkeys returns the number of keys of the node at off.
+func (f *Flat) nkeys(off uint64) int {
+	return int(binary.LittleEndian.Uint32(f.data[off:]))
+}
+
+func (f *Flat) isLeaf(off uint64) bool {
+	return binary.LittleEndian.Uint32(f.data[off+4:]) != 0
+}
+
+// key returns the key i of the node at off.
+func (f *Flat) key(off uint64, i int) []byte {
+	slot := f.data[off+flatNodeHeader+uint64(i)*flatSlotSize:]
+	start := binary.LittleEndian.Uint64(slot)
+	n := uint64(binary.LittleEndian.Uint32(slot[8:]))
+	return f.data[start : start+n : start+n]
+}
+
+// value returns the value i of the node at off.
+func (f *Flat) value(off uint64, i int) []byte {
+	slot := f.data[off+flatNodeHeader+uint64(i)*flatSlotSize:]
+	n := uint64(binary.LittleEndian.Uint32(slot[12:]))
+	start := binary.LittleEndian.Uint64(slot[16:])
+	return f.data[start : start+n : start+n]
+}
+
+// child returns the offset of the child i of the node at off.
+func (f *Flat) child(off uint64, i int) uint64 {
+	n := uint64(f.nkeys(off))
+	return binary.LittleEndian.Uint64(f.data[off+flatNodeHeader+n*flatSlotSize+8*uint64(i):])
+}
@@ -0,0 +1,234 @@
+package btree
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/eliben/gogl/pager"
+)
+
+func encodeUint(k int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(k))
+}
+
+func encodeString(v string) []byte {
+	return []byte(v)
+}
+
+// writeFlatFile writes bt in the flat format to a file in a temporary
+// directory, and returns its path.
+func writeFlatFile(t *testing.T, bt *BTree[int, string]) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.flat")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFlat(f, bt, encodeUint, encodeString); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// checkFlat checks that f holds the same entries as bt.
+func checkFlat(t *testing.T, f *Flat, bt *BTree[int, string], maxKey int) {
+	t.Helper()
+	if err := f.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != bt.Len() {
+		t.Errorf("got Len=%d, want %d", f.Len(), bt.Len())
+	}
+	for k := -1; k <= maxKey+1; k++ {
+		want, wantOk := bt.Get(k)
+		got, ok := f.Get(encodeUint(k))
+		if ok != wantOk || string(got) != want {
+			t.Fatalf("Get(%d): got %q,%v, want %q,%v", k, got, ok, want, wantOk)
+		}
+	}
+
+	var keys []int
+	for k := range bt.All() {
+		keys = append(keys, k)
+	}
+	i := 0
+	for k, v := range f.All() {
+		if want, _ := bt.Get(keys[i]); !bytes.Equal(k, encodeUint(keys[i])) || string(v) != want {
+			t.Fatalf("All: got %x=%q at %d, want key %d", k, v, i, keys[i])
+		}
+		i++
+	}
+	if i != len(keys) {
+		t.Fatalf("All: got %d entries, want %d", i, len(keys))
+	}
+
+	for _, r := range [][2]int{{0, maxKey}, {maxKey / 3, maxKey / 2}, {5, 5}, {maxKey, maxKey + 10}} {
+		var want []int
+		for k := range bt.Range(r[0], r[1]) {
+			want = append(want, k)
+		}
+		i := 0
+		for k := range f.Range(encodeUint(r[0]), encodeUint(r[1])) {
+			if i >= len(want) || !bytes.Equal(k, encodeUint(want[i])) {
+				t.Fatalf("Range(%d, %d): got %x at %d", r[0], r[1], k, i)
+			}
+			i++
+		}
+		if i != len(want) {
+			t.Fatalf("Range(%d, %d): got %d entries, want %d", r[0], r[1], i, len(want))
+		}
+	}
+}
+
+func TestFlat(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, size := range []int{0, 1, 10, 1000, 20000} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			bt := NewWithTee[int, string](cmp.Compare[int], 2+rnd.IntN(8))
+			maxKey := 3 * size
+			for range size {
+				k := rnd.IntN(maxKey)
+				bt.Insert(k, strconv.Itoa(k*k))
+			}
+			// Deleting leaves nodes of various sizes.
+			for range size / 4 {
+				bt.Delete(rnd.IntN(maxKey))
+			}
+
+			path := writeFlatFile(t, bt)
+			f, err := OpenFlat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkFlat(t, f, bt, maxKey)
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err = NewFlat(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkFlat(t, f, bt, maxKey)
+
+			p := pager.NewMem(512)
+			w, err := pager.NewWriter(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteFlat(w, bt, encodeUint, encodeString); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			f, err = ReadFlat(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkFlat(t, f, bt, maxKey)
+		})
+	}
+}
+
+func TestFlatKeyOrder(t *testing.T) {
+	bt := New[int, string](cmp.Compare[int])
+	for k := range 1000 {
+		bt.Insert(k, "")
+	}
+	var buf bytes.Buffer
+	encodeLittle := func(k int) []byte {
+		return binary.LittleEndian.AppendUint64(nil, uint64(k))
+	}
+	if err := WriteFlat(&buf, bt, encodeLittle, encodeString); err == nil {
+		t.Errorf("want error for an encoding that doesn't preserve order")
+	}
+	encodeConst := func(k int) []byte { return []byte("k") }
+	if err := WriteFlat(&buf, bt, encodeConst, encodeString); err == nil {
+		t.Errorf("want error for an encoding with duplicate keys")
+	}
+}
+
+func TestFlatInvalid(t *testing.T) {
+	bt := NewWithTee[int, string](cmp.Compare[int], 2)
+	for k := range 50 {
+		bt.Insert(k, strconv.Itoa(k))
+	}
+	var buf bytes.Buffer
+	if err := WriteFlat(&buf, bt, encodeUint, encodeString); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	for _, bad := range [][]byte{nil, data[:10], data[:len(data)-1], append(bytes.Clone(data), 0)} {
+		if _, err := NewFlat(bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("got err=%v for %d bytes, want ErrInvalidData", err, len(bad))
+		}
+	}
+
+	// Verify catches any corrupted byte that changes the tree's structure,
+	// without panicking.
+	for i := range len(data) - flatFooterSize {
+		for _, bit := range []byte{0x01, 0x80} {
+			bad := bytes.Clone(data)
+			bad[i] ^= bit
+			f, err := NewFlat(bad)
+			if err != nil {
+				continue
+			}
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("Verify panicked for byte %d: %v", i, r)
+					}
+				}()
+				f.Verify()
+			}()
+		}
+	}
+	path := filepath.Join(t.TempDir(), "bad.flat")
+	os.WriteFile(path, data[:5], 0o644)
+	if _, err := OpenFlat(path); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("got err=%v, want ErrInvalidData", err)
+	}
+	if _, err := ReadFlat(pager.NewMem(512)); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("empty pager: got err=%v, want ErrInvalidData", err)
+	}
+}
+
+func BenchmarkFlatGet(b *testing.B) {
+	bt := New[int, string](cmp.Compare[int])
+	const n = 100000
+	for k := range n {
+		bt.Insert(k, strconv.Itoa(k))
+	}
+	var buf bytes.Buffer
+	if err := WriteFlat(&buf, bt, encodeUint, encodeString); err != nil {
+		b.Fatal(err)
+	}
+	f, err := NewFlat(buf.Bytes())
+	if err != nil {
+		b.Fatal(err)
+	}
+	keys := make([][]byte, n)
+	for k := range keys {
+		keys[k] = encodeUint(k)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		f.Get(keys[i%n])
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package btree
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the first size bytes of f in memory, on platforms without
+// support for mapping files.
+func mapFile(f *os.File, size int) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, nil, nil
+}
@@ -0,0 +1,268 @@
+package btree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"iter"
+	"sort"
+
+	"github.com/eliben/gogl/pager"
+)
+
+// PagedFlat queries a B-tree in the flat format stored in a pager, reading
+// only the pages it needs through a [pager.Cache], which may be shared with
+// other structures. Unlike [Flat], it doesn't need the whole tree in memory,
+// and it checks the data it reads, returning ErrInvalidData instead of
+// panicking on corrupted data.
+//
+// The pager must not be modified while the PagedFlat is used. A PagedFlat
+// is safe for concurrent use by multiple goroutines. Create PagedFlats with
+// [OpenPagedFlat].
+type PagedFlat struct {
+	p      pager.Pager
+	c      *pager.Cache
+	end    uint64
+	root   uint64
+	length int
+}
+
+// FlatEntry is a key, value pair of a [PagedFlat].
+type FlatEntry struct {
+	Key, Value []byte
+}
+
+// OpenPagedFlat returns a PagedFlat querying the stream of p, which must
+// hold a B-tree written by [WriteFlat] to a [pager.Writer], reading its
+// pages through c.
+func OpenPagedFlat(p pager.Pager, c *pager.Cache) (*PagedFlat, error) {
+	r, err := pager.NewReader(p)
+	if errors.Is(err, pager.ErrInvalidData) {
+		return nil, ErrInvalidData
+	} else if err != nil {
+		return nil, err
+	}
+	if r.Size() < int64(flatFooterSize) {
+		return nil, ErrInvalidData
+	}
+	f := &PagedFlat{p: p, c: c, end: uint64(r.Size()) - uint64(flatFooterSize)}
+	footer, err := f.read(f.end, uint64(flatFooterSize))
+	if err != nil {
+		return nil, err
+	}
+	if string(footer[16:]) != flatMagic {
+		return nil, ErrInvalidData
+	}
+	f.root = binary.LittleEndian.Uint64(footer)
+	f.length = int(binary.LittleEndian.Uint64(footer[8:]))
+	if f.root >= f.end || f.length < 0 {
+		return nil, ErrInvalidData
+	}
+	return f, nil
+}
+
+// Close removes the pages of f's pager from the cache. It doesn't close
+// the pager.
+func (f *PagedFlat) Close() error {
+	f.c.Drop(f.p)
+	return nil
+}
+
+// Len returns the number of keys in the tree.
+func (f *PagedFlat) Len() int {
+	return f.length
+}
+
+// Get looks for key in the tree. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (f *PagedFlat) Get(key []byte) (value []byte, ok bool, err error) {
+	off := f.root
+	for depth := 0; ; depth++ {
+		if depth > flatMaxDepth {
+			return nil, false, ErrInvalidData
+		}
+		nd, err := f.node(off)
+		if err != nil {
+			return nil, false, err
+		}
+		i, found, err := nd.search(key)
+		if err != nil || found {
+			if err == nil {
+				value, err = nd.value(i)
+			}
+			return value, err == nil, err
+		}
+		if nd.leaf {
+			return nil, false, nil
+		}
+		if off, err = nd.child(i); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// All returns an iterator over all the key, value pairs in the tree, in
+// ascending order of keys. If reading the tree fails, the iterator yields
+// the error and stops.
+func (f *PagedFlat) All() iter.Seq2[FlatEntry, error] {
+	return f.Range(nil, nil)
+}
+
+// Range is like All, for the key, value pairs with keys in the range
+// [lo, hi); a nil lo or hi leaves the range unbounded on that side.
+func (f *PagedFlat) Range(lo, hi []byte) iter.Seq2[FlatEntry, error] {
+	return func(yield func(FlatEntry, error) bool) {
+		if _, err := f.ascend(f.root, 0, lo, hi, yield); err != nil {
+			yield(FlatEntry{}, err)
+		}
+	}
+}
+
+// ascend is like Flat.ascend; it returns an error if reading the tree
+// fails, without yielding it.
+func (f *PagedFlat) ascend(off uint64, depth int, lo, hi []byte, yield func(FlatEntry, error) bool) (bool, error) {
+	if depth > flatMaxDepth {
+		return false, ErrInvalidData
+	}
+	nd, err := f.node(off)
+	if err != nil {
+		return false, err
+	}
+	start := 0
+	if lo != nil {
+		if start, _, err = nd.search(lo); err != nil {
+			return false, err
+		}
+	}
+	for i := start; i <= nd.n; i++ {
+		if !nd.leaf {
+			child, err := nd.child(i)
+			if err != nil {
+				return false, err
+			}
+			if ok, err := f.ascend(child, depth+1, lo, hi, yield); !ok || err != nil {
+				return false, err
+			}
+		}
+		if i == nd.n {
+			break
+		}
+		key, err := nd.key(i)
+		if err != nil {
+			return false, err
+		}
+		if hi != nil && bytes.Compare(key, hi) >= 0 {
+			return false, nil
+		}
+		value, err := nd.value(i)
+		if err != nil {
+			return false, err
+		}
+		if !yield(FlatEntry{key, value}, nil) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// pagedNode is a node of a PagedFlat, with its header, slots and child
+// offsets read.
+type pagedNode struct {
+	f     *PagedFlat
+	off   uint64
+	n     int
+	leaf  bool
+	slots []byte
+}
+
+// node reads the node at off.
+func (f *PagedFlat) node(off uint64) (*pagedNode, error) {
+	header, err := f.read(off, flatNodeHeader)
+	if err != nil {
+		return nil, err
+	}
+	n := uint64(binary.LittleEndian.Uint32(header))
+	leaf := binary.LittleEndian.Uint32(header[4:])
+	size := n * flatSlotSize
+	if leaf == 0 {
+		size += 8 * (n + 1)
+	}
+	if leaf > 1 || n > f.end {
+		return nil, ErrInvalidData
+	}
+	slots, err := f.read(off+flatNodeHeader, size)
+	if err != nil {
+		return nil, err
+	}
+	return &pagedNode{f: f, off: off, n: int(n), leaf: leaf == 1, slots: slots}, nil
+}
+
+// search returns the index of the first key of nd that's >= key, and
+// whether it's equal to key.
+func (nd *pagedNode) search(key []byte) (i int, found bool, err error) {
+	i = sort.Search(nd.n, func(i int) bool {
+		k, kerr := nd.key(i)
+		if kerr != nil {
+			err = kerr
+			return true
+		}
+		return bytes.Compare(k, key) >= 0
+	})
+	if err != nil || i == nd.n {
+		return i, false, err
+	}
+	k, err := nd.key(i)
+	return i, err == nil && bytes.Equal(k, key), err
+}
+
+// key returns the key i of nd.
+func (nd *pagedNode) key(i int) ([]byte, error) {
+	slot := nd.slots[i*flatSlotSize:]
+	return nd.f.readBefore(binary.LittleEndian.Uint64(slot), uint64(binary.LittleEndian.Uint32(slot[8:])), nd.off)
+}
+
+// value returns the value i of nd.
+func (nd *pagedNode) value(i int) ([]byte, error) {
+	slot := nd.slots[i*flatSlotSize:]
+	return nd.f.readBefore(binary.LittleEndian.Uint64(slot[16:]), uint64(binary.LittleEndian.Uint32(slot[12:])), nd.off)
+}
+
+// child returns the offset of the child i of nd, which must precede nd.
+func (nd *pagedNode) child(i int) (uint64, error) {
+	child := binary.LittleEndian.Uint64(nd.slots[nd.n*flatSlotSize+8*i:])
+	if child >= nd.off {
+		return 0, ErrInvalidData
+	}
+	return child, nil
+}
+
+// readBefore is like read, for data that must end before limit, as the keys
+// and values of a node precede it.
+func (f *PagedFlat) readBefore(off, n, limit uint64) ([]byte, error) {
+	if off > limit || n > limit-off {
+		return nil, ErrInvalidData
+	}
+	return f.read(off, n)
+}
+
+// read returns a copy of n bytes of the stream at off, which must be within
+// the stream.
+func (f *PagedFlat) read(off, n uint64) ([]byte, error) {
+	if off > f.end+uint64(flatFooterSize) || n > f.end+uint64(flatFooterSize)-off {
+		return nil, ErrInvalidData
+	}
+	pageSize := uint64(f.p.PageSize())
+	b := make([]byte, 0, n)
+	for uint64(len(b)) < n {
+		pg, err := f.c.Pin(f.p, pager.PageID(off/pageSize))
+		if err != nil {
+			return nil, err
+		}
+		start := off % pageSize
+		k := min(pageSize-start, n-uint64(len(b)))
+		b = append(b, pg.Data()[start:start+k]...)
+		pg.Unpin()
+		off += k
+	}
+	return b, nil
+}
@@ -0,0 +1,172 @@
+package btree
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/eliben/gogl/pager"
+)
+
+// writeFlatPager writes bt in the flat format to a new memory pager with
+// small pages.
+func writeFlatPager(t *testing.T, bt *BTree[int, string]) *pager.Mem {
+	t.Helper()
+	p := pager.NewMem(128)
+	w, err := pager.NewWriter(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFlat(w, bt, encodeUint, encodeString); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPagedFlat(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	c := pager.NewCache(16)
+	for _, size := range []int{0, 1, 10, 1000, 5000} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			bt := NewWithTee[int, string](cmp.Compare[int], 2+rnd.IntN(8))
+			maxKey := 3 * size
+			for range size {
+				k := rnd.IntN(maxKey)
+				bt.Insert(k, strconv.Itoa(k*k))
+			}
+			f, err := OpenPagedFlat(writeFlatPager(t, bt), c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			if f.Len() != bt.Len() {
+				t.Errorf("got Len=%d, want %d", f.Len(), bt.Len())
+			}
+			for k := -1; k <= maxKey+1; k++ {
+				want, wantOk := bt.Get(k)
+				got, ok, err := f.Get(encodeUint(k))
+				if err != nil || ok != wantOk || string(got) != want {
+					t.Fatalf("Get(%d): got %q,%v,%v, want %q,%v", k, got, ok, err, want, wantOk)
+				}
+			}
+
+			for _, r := range [][2]int{{0, maxKey + 1}, {maxKey / 3, maxKey / 2}, {5, 5}} {
+				var want []int
+				for k := range bt.Range(r[0], r[1]) {
+					want = append(want, k)
+				}
+				i := 0
+				for e, err := range f.Range(encodeUint(r[0]), encodeUint(r[1])) {
+					if err != nil {
+						t.Fatal(err)
+					}
+					if i >= len(want) || !bytes.Equal(e.Key, encodeUint(want[i])) || string(e.Value) != strconv.Itoa(want[i]*want[i]) {
+						t.Fatalf("Range(%d, %d): got %x=%q at %d", r[0], r[1], e.Key, e.Value, i)
+					}
+					i++
+				}
+				if i != len(want) {
+					t.Fatalf("Range(%d, %d): got %d entries, want %d", r[0], r[1], i, len(want))
+				}
+			}
+			n := 0
+			for _, err := range f.All() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				n++
+			}
+			if n != bt.Len() {
+				t.Errorf("All: got %d entries, want %d", n, bt.Len())
+			}
+		})
+	}
+}
+
+// countingPager counts the page reads of a pager.
+type countingPager struct {
+	pager.Pager
+	reads int
+}
+
+func (cp *countingPager) ReadPage(id pager.PageID, buf []byte) error {
+	cp.reads++
+	return cp.Pager.ReadPage(id, buf)
+}
+
+func TestPagedFlatCache(t *testing.T) {
+	bt := New[int, string](cmp.Compare[int])
+	for k := range 2000 {
+		bt.Insert(k, strconv.Itoa(k))
+	}
+	cp := &countingPager{Pager: writeFlatPager(t, bt)}
+	f, err := OpenPagedFlat(cp, pager.NewCache(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() {
+		t.Helper()
+		if v, ok, err := f.Get(encodeUint(1234)); err != nil || !ok || string(v) != "1234" {
+			t.Fatalf("Get(1234): got %q,%v,%v", v, ok, err)
+		}
+	}
+	get()
+	reads := cp.reads
+	if reads == 0 || uint64(reads) >= cp.NumPages() {
+		t.Errorf("Get read %d pages of %d", reads, cp.NumPages())
+	}
+	get()
+	if cp.reads != reads {
+		t.Errorf("Get with a warm cache read %d pages", cp.reads-reads)
+	}
+}
+
+func TestPagedFlatInvalid(t *testing.T) {
+	bt := NewWithTee[int, string](cmp.Compare[int], 2)
+	for k := range 50 {
+		bt.Insert(k, strconv.Itoa(k))
+	}
+	p := writeFlatPager(t, bt)
+	size := func() int64 {
+		r, err := pager.NewReader(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.Size()
+	}()
+	page := make([]byte, p.PageSize())
+
+	// Corrupted bytes are either harmless or reported, without panicking.
+	for off := range size - int64(flatFooterSize) {
+		id := pager.PageID(off / int64(len(page)))
+		flip := func() {
+			p.ReadPage(id, page)
+			page[off%int64(len(page))] ^= 1 << (off % 8)
+			p.WritePage(id, page)
+		}
+		flip()
+		f, err := OpenPagedFlat(p, pager.NewCache(4))
+		if err == nil {
+			for k := 0; k < 52; k += 5 {
+				if _, _, err := f.Get(encodeUint(k)); err != nil && !errors.Is(err, ErrInvalidData) {
+					t.Fatalf("byte %d: Get: %v", off, err)
+				}
+			}
+			for _, err := range f.All() {
+				if err != nil && !errors.Is(err, ErrInvalidData) {
+					t.Fatalf("byte %d: All: %v", off, err)
+				}
+			}
+		}
+		flip()
+	}
+
+	if _, err := OpenPagedFlat(pager.NewMem(128), pager.NewCache(4)); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("empty pager: got err=%v, want ErrInvalidData", err)
+	}
+}
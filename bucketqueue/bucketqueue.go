@@ -0,0 +1,91 @@
+// Package bucketqueue implements a priority queue for small, bounded integer
+// priorities.
+package bucketqueue
+
+import (
+	"fmt"
+
+	"github.com/eliben/gogl/queue"
+)
+
+// BucketQueue is a min-priority queue whose priorities are integers in the
+// range [0, maxPriority]. It keeps a FIFO bucket per priority, so Push is
+// O(1) and Pop is O(1) amortized for monotone workloads (like Dijkstra's
+// algorithm with small integer weights), and O(maxPriority) in the worst
+// case. Elements with equal priorities are popped in FIFO order.
+type BucketQueue[T any] struct {
+	// buckets[p] holds the elements with priority p; buckets are allocated
+	// lazily.
+	buckets []*queue.Queue[T]
+
+	// lowest is a lower bound on the lowest priority with a non-empty bucket.
+	lowest int
+	length int
+}
+
+// New creates a new, empty BucketQueue for priorities in [0, maxPriority].
+// It panics if maxPriority is negative.
+func New[T any](maxPriority int) *BucketQueue[T] {
+	if maxPriority < 0 {
+		panic(fmt.Sprintf("invalid max priority %d", maxPriority))
+	}
+	return &BucketQueue[T]{buckets: make([]*queue.Queue[T], maxPriority+1)}
+}
+
+// Len returns the number of elements in the queue.
+func (bq *BucketQueue[T]) Len() int {
+	return bq.length
+}
+
+// MaxPriority returns the maximal priority supported by the queue.
+func (bq *BucketQueue[T]) MaxPriority() int {
+	return len(bq.buckets) - 1
+}
+
+// Push adds v to the queue with the given priority. It panics if the priority
+// is out of range.
+func (bq *BucketQueue[T]) Push(v T, priority int) {
+	if priority < 0 || priority >= len(bq.buckets) {
+		panic(fmt.Sprintf("priority %d out of range [0:%d]", priority, len(bq.buckets)-1))
+	}
+	b := bq.buckets[priority]
+	if b == nil {
+		b = queue.New[T]()
+		bq.buckets[priority] = b
+	}
+	b.Enqueue(v)
+	if bq.length == 0 || priority < bq.lowest {
+		bq.lowest = priority
+	}
+	bq.length++
+}
+
+// Peek returns the element with the lowest priority and its priority, without
+// removing it. It panics if the queue is empty.
+func (bq *BucketQueue[T]) Peek() (T, int) {
+	if bq.length == 0 {
+		panic("peek into empty queue")
+	}
+	bq.advance()
+	return bq.buckets[bq.lowest].Peek(), bq.lowest
+}
+
+// Pop removes the element with the lowest priority from the queue and returns
+// it with its priority. It panics if the queue is empty; make sure to check
+// Len() first.
+func (bq *BucketQueue[T]) Pop() (T, int) {
+	if bq.length == 0 {
+		panic("popping from empty queue")
+	}
+	bq.advance()
+	bq.length--
+	return bq.buckets[bq.lowest].Dequeue(), bq.lowest
+}
+
+// advance moves lowest forward to the first non-empty bucket. It assumes the
+// queue isn't empty.
+func (bq *BucketQueue[T]) advance() {
+	for b := bq.buckets[bq.lowest]; b == nil || b.Len() == 0; b = bq.buckets[bq.lowest] {
+		bq.lowest++
+	}
+}
@@ -0,0 +1,77 @@
+package bucketqueue
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestBasic(t *testing.T) {
+	bq := New[string](10)
+	bq.Push("five", 5)
+	bq.Push("two", 2)
+	bq.Push("seven", 7)
+	bq.Push("two-again", 2)
+	bq.Push("zero", 0)
+
+	if v, p := bq.Peek(); v != "zero" || p != 0 {
+		t.Errorf("got peek=%v,%v", v, p)
+	}
+
+	want := []string{"zero", "two", "two-again", "five", "seven"}
+	for _, w := range want {
+		if v, _ := bq.Pop(); v != w {
+			t.Errorf("got %v, want %v", v, w)
+		}
+	}
+	if bq.Len() != 0 {
+		t.Errorf("got len=%d, want 0", bq.Len())
+	}
+
+	// After emptying, pushing a high priority then a low one works.
+	bq.Push("ten", 10)
+	bq.Push("one", 1)
+	if v, p := bq.Pop(); v != "one" || p != 1 {
+		t.Errorf("got %v,%v, want one,1", v, p)
+	}
+	if v, p := bq.Pop(); v != "ten" || p != 10 {
+		t.Errorf("got %v,%v, want ten,10", v, p)
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	bq := New[int](255)
+	if bq.MaxPriority() != 255 {
+		t.Errorf("got max=%d", bq.MaxPriority())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	bq.Push(1, 256)
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	bq := New[int](63)
+	var mirror []int
+	for i := range 5000 {
+		if len(mirror) == 0 || rnd.IntN(2) == 0 {
+			p := rnd.IntN(64)
+			bq.Push(p, p)
+			mirror = append(mirror, p)
+		} else {
+			want := slices.Min(mirror)
+			v, p := bq.Pop()
+			if v != want || p != want {
+				t.Fatalf("step %d: got %v,%v, want %v", i, v, p, want)
+			}
+			mirror = slices.Delete(mirror, slices.Index(mirror, want), slices.Index(mirror, want)+1)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package cache defines the interface shared by the module's caches, so
+// that callers can switch between eviction policies.
+//
+// The implementations live in their own packages:
+//
+//   - lru: least recently used eviction.
+//   - lfu: least frequently used eviction.
+//   - arc: adaptive replacement, balancing recency and frequency.
+//   - clockcache: CLOCK (second-chance) eviction, approximating LRU with
+//     cheaper hits.
+//
+// The lru and lfu caches can also measure their capacity in a user-defined
+// cost, such as bytes, instead of a number of entries; see their
+// NewWithWeigher constructors.
+//
+// All caches keep [Stats] about their activity, and can report events to
+// [Hooks] as they happen.
+package cache
+
+// Cache is a fixed-capacity cache mapping keys of type K to values of type
+// V. The eviction policy is determined by the implementation.
+type Cache[K comparable, V any] interface {
+	// Len returns the number of entries in the cache.
+	Len() int
+
+	// Cap returns the capacity of the cache.
+	Cap() int
+
+	// Get looks up key in the cache, recording the access for the eviction
+	// policy. It returns the associated value and ok=true; otherwise, it
+	// returns ok=false.
+	Get(key K) (V, bool)
+
+	// Peek is like Get, but it doesn't record the access.
+	Peek(key K) (V, bool)
+
+	// Contains reports whether key is in the cache, without recording an
+	// access.
+	Contains(key K) bool
+
+	// Put sets the value of key in the cache, evicting an entry if key is new
+	// and the cache is full.
+	Put(key K, value V)
+
+	// Remove removes key from the cache. It returns true if key was found,
+	// and false otherwise.
+	Remove(key K) bool
+
+	// Stats returns statistics about the cache's activity since it was
+	// created.
+	Stats() Stats
+
+	// SetHooks sets hooks to be called on cache events; nil removes them.
+	SetHooks(h Hooks)
+}
@@ -0,0 +1,157 @@
+package cache_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/eliben/gogl/arc"
+	"github.com/eliben/gogl/cache"
+	"github.com/eliben/gogl/clockcache"
+	"github.com/eliben/gogl/lfu"
+	"github.com/eliben/gogl/lru"
+)
+
+var (
+	_ cache.Cache[string, int] = (*lru.Cache[string, int])(nil)
+	_ cache.Cache[string, int] = (*lfu.Cache[string, int])(nil)
+	_ cache.Cache[string, int] = (*arc.Cache[string, int])(nil)
+	_ cache.Cache[string, int] = (*clockcache.Cache[string, int])(nil)
+)
+
+// backends lists constructors for all the cache implementations.
+var backends = []struct {
+	name string
+	make func(capacity int) cache.Cache[int, int]
+}{
+	{"lru", func(capacity int) cache.Cache[int, int] { return lru.New[int, int](capacity) }},
+	{"lfu", func(capacity int) cache.Cache[int, int] { return lfu.New[int, int](capacity) }},
+	{"arc", func(capacity int) cache.Cache[int, int] { return arc.New[int, int](capacity) }},
+	{"clock", func(capacity int) cache.Cache[int, int] { return clockcache.New[int, int](capacity) }},
+}
+
+// TestConformance checks behavior all caches share, regardless of policy.
+func TestConformance(t *testing.T) {
+	for _, be := range backends {
+		t.Run(be.name, func(t *testing.T) {
+			c := be.make(4)
+			if c.Cap() != 4 || c.Len() != 0 {
+				t.Fatalf("got Cap=%d Len=%d", c.Cap(), c.Len())
+			}
+			for k := range 4 {
+				c.Put(k, k*10)
+			}
+			for k := range 4 {
+				if v, ok := c.Get(k); !ok || v != k*10 {
+					t.Errorf("Get(%d)=%d,%v", k, v, ok)
+				}
+			}
+			c.Put(2, 200)
+			if v, ok := c.Peek(2); !ok || v != 200 {
+				t.Errorf("Peek(2)=%d,%v", v, ok)
+			}
+
+			// Adding new keys never grows the cache past its capacity.
+			for k := 10; k < 20; k++ {
+				c.Put(k, k)
+				if c.Len() > 4 {
+					t.Fatalf("got Len=%d", c.Len())
+				}
+				if !c.Contains(k) {
+					t.Fatalf("just-added key %d missing", k)
+				}
+			}
+			if !c.Remove(19) || c.Remove(19) || c.Contains(19) {
+				t.Errorf("bad Remove")
+			}
+			if _, ok := c.Get(12345); ok {
+				t.Errorf("found missing key")
+			}
+		})
+	}
+}
+
+// countingHooks counts the events it receives.
+type countingHooks struct {
+	cache.NopHooks
+	hits, misses, evictions int
+}
+
+func (h *countingHooks) Hit()   { h.hits++ }
+func (h *countingHooks) Miss()  { h.misses++ }
+func (h *countingHooks) Evict() { h.evictions++ }
+
+func TestStatsAndHooks(t *testing.T) {
+	for _, be := range backends {
+		t.Run(be.name, func(t *testing.T) {
+			c := be.make(2)
+			hooks := &countingHooks{}
+			c.SetHooks(hooks)
+			c.Put(1, 1)
+			c.Put(2, 2)
+			c.Get(1)
+			c.Get(1)
+			c.Get(3)
+			c.Put(3, 3)
+			c.Put(4, 4)
+
+			// Peek and Contains aren't lookups.
+			c.Peek(1)
+			c.Contains(1)
+
+			want := cache.Stats{Hits: 2, Misses: 1, Evictions: 2}
+			if got := c.Stats(); got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			if hooks.hits != 2 || hooks.misses != 1 || hooks.evictions != 2 {
+				t.Errorf("hooks got %+v", hooks)
+			}
+
+			c.SetHooks(nil)
+			c.Get(1)
+			if hooks.hits+hooks.misses != 3 {
+				t.Errorf("removed hooks still called")
+			}
+		})
+	}
+}
+
+// zipfKeys generates n keys with a Zipf distribution over [0, imax], mixed
+// with occasional scans of keys that are never seen again.
+func zipfKeys(n int, imax uint64) []int {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	z := rand.NewZipf(rnd, 1.1, 1, imax)
+	keys := make([]int, 0, n)
+	scan := int(imax) + 1
+	for len(keys) < n {
+		if rnd.IntN(1000) == 0 {
+			for range 200 {
+				keys = append(keys, scan)
+				scan++
+			}
+		}
+		keys = append(keys, int(z.Uint64()))
+	}
+	return keys
+}
+
+func BenchmarkWorkload(b *testing.B) {
+	keys := zipfKeys(100000, 10000)
+	for _, capacity := range []int{100, 1000} {
+		for _, be := range backends {
+			b.Run(fmt.Sprintf("%s/%d", be.name, capacity), func(b *testing.B) {
+				var stats cache.Stats
+				for range b.N {
+					c := be.make(capacity)
+					for _, k := range keys {
+						if _, ok := c.Get(k); !ok {
+							c.Put(k, k)
+						}
+					}
+					stats = c.Stats()
+				}
+				b.ReportMetric(stats.HitRate(), "hitrate")
+			})
+		}
+	}
+}
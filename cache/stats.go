@@ -0,0 +1,125 @@
+package cache
+
+import "time"
+
+// Stats holds statistics about a cache's activity.
+type Stats struct {
+	// Hits and Misses count lookups that found and didn't find their key.
+	// Only lookups that record accesses count: Get and GetOrLoad, but not
+	// Peek or Contains.
+	Hits, Misses uint64
+
+	// Evictions counts entries evicted to make room for others.
+	Evictions uint64
+
+	// Expirations counts entries removed because their TTL expired.
+	Expirations uint64
+
+	// Loads and LoadErrors count the loads performed by loading caches, and
+	// those that failed; LoadTime is the total time spent loading.
+	Loads, LoadErrors uint64
+	LoadTime          time.Duration
+}
+
+// HitRate returns the fraction of lookups that were hits, or 0 if there were
+// no lookups.
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Hooks receives cache events as they happen, for wiring caches into metrics
+// systems such as Prometheus or expvar. Hooks are called synchronously, and
+// possibly with the cache's lock held: they must be fast and must not call
+// back into the cache. Embed [NopHooks] to implement only some of the
+// methods.
+type Hooks interface {
+	Hit()
+	Miss()
+	Evict()
+	Expire()
+	Load(d time.Duration, err error)
+}
+
+// NopHooks implements [Hooks] with methods that do nothing.
+type NopHooks struct{}
+
+func (NopHooks) Hit()                      {}
+func (NopHooks) Miss()                     {}
+func (NopHooks) Evict()                    {}
+func (NopHooks) Expire()                   {}
+func (NopHooks) Load(time.Duration, error) {}
+
+// Recorder records cache events into [Stats] and forwards them to [Hooks];
+// it's a helper for implementing caches. The zero value is ready to use.
+// A Recorder isn't safe for concurrent use.
+type Recorder struct {
+	stats Stats
+	hooks Hooks
+}
+
+// Stats returns the statistics recorded so far.
+func (r *Recorder) Stats() Stats {
+	return r.stats
+}
+
+// SetHooks sets the hooks that events are forwarded to; nil removes them.
+func (r *Recorder) SetHooks(h Hooks) {
+	r.hooks = h
+}
+
+// Hit records a lookup that found its key.
+func (r *Recorder) Hit() {
+	r.stats.Hits++
+	if r.hooks != nil {
+		r.hooks.Hit()
+	}
+}
+
+// Miss records a lookup that didn't find its key.
+func (r *Recorder) Miss() {
+	r.stats.Misses++
+	if r.hooks != nil {
+		r.hooks.Miss()
+	}
+}
+
+// Lookup records a hit if ok is true, and a miss otherwise.
+func (r *Recorder) Lookup(ok bool) {
+	if ok {
+		r.Hit()
+	} else {
+		r.Miss()
+	}
+}
+
+// Evict records an eviction.
+func (r *Recorder) Evict() {
+	r.stats.Evictions++
+	if r.hooks != nil {
+		r.hooks.Evict()
+	}
+}
+
+// Expire records an expiration.
+func (r *Recorder) Expire() {
+	r.stats.Expirations++
+	if r.hooks != nil {
+		r.hooks.Expire()
+	}
+}
+
+// Load records a load that took d and failed with err, if it's not nil.
+func (r *Recorder) Load(d time.Duration, err error) {
+	r.stats.Loads++
+	r.stats.LoadTime += d
+	if err != nil {
+		r.stats.LoadErrors++
+	}
+	if r.hooks != nil {
+		r.hooks.Load(d, err)
+	}
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type loadHooks struct {
+	NopHooks
+	loads []error
+}
+
+func (h *loadHooks) Load(d time.Duration, err error) {
+	h.loads = append(h.loads, err)
+}
+
+func TestRecorder(t *testing.T) {
+	var r Recorder
+	if r.Stats().HitRate() != 0 {
+		t.Errorf("HitRate of no lookups")
+	}
+	r.Lookup(true)
+	r.Lookup(true)
+	r.Lookup(true)
+	r.Lookup(false)
+	r.Evict()
+	r.Expire()
+	r.Expire()
+
+	hooks := &loadHooks{}
+	r.SetHooks(hooks)
+	errBoom := errors.New("boom")
+	r.Load(time.Second, nil)
+	r.Load(2*time.Second, errBoom)
+
+	want := Stats{
+		Hits: 3, Misses: 1, Evictions: 1, Expirations: 2,
+		Loads: 2, LoadErrors: 1, LoadTime: 3 * time.Second,
+	}
+	if got := r.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := r.Stats().HitRate(); got != 0.75 {
+		t.Errorf("HitRate=%v", got)
+	}
+	if len(hooks.loads) != 2 || hooks.loads[0] != nil || hooks.loads[1] != errBoom {
+		t.Errorf("hooks got %v", hooks.loads)
+	}
+}
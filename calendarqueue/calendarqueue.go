@@ -0,0 +1,222 @@
+// Package calendarqueue implements calendar queues: priority queues for the
+// pending events of discrete-event simulations.
+package calendarqueue
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Queue is a calendar queue, as described by R. Brown in "Calendar queues:
+// a fast O(1) priority queue implementation for the simulation event set
+// problem" (1988). It's a min-priority queue of values keyed by float64
+// times, organized like a desk calendar: an array of buckets ("days") each
+// covering an interval of times, wrapping around every "year". An event goes
+// into the bucket of its day, kept sorted, and the queue finds the next
+// event by scanning the days in order from the current time.
+//
+// The number of buckets and their width adapt automatically as the queue
+// grows and shrinks, so that the scan usually finds the next event in the
+// first few buckets it looks at. When the times of pending events are
+// spread fairly evenly, as in most simulations, Push and Pop then take O(1)
+// amortized time, versus O(log n) for a binary heap; highly skewed times
+// may degrade it towards O(n).
+//
+// Events with equal times are popped in the order they were pushed. Events
+// may be pushed with times earlier than the last popped one, but a
+// simulation normally only schedules events in the future. Create queues
+// with [New].
+type Queue[T any] struct {
+	// buckets holds the events sorted by time in each bucket; its length is
+	// a power of two.
+	buckets [][]event[T]
+	width   float64
+	length  int
+
+	// The scan for the next event resumes at bucket cur, the bucket of day
+	// curDay; no pending event is earlier than that day.
+	cur    int
+	curDay int64
+}
+
+type event[T any] struct {
+	time  float64
+	value T
+}
+
+const (
+	minBuckets = 2
+
+	// sampleSize is the number of events from the front of the queue used
+	// to estimate the width of buckets when resizing.
+	sampleSize = 25
+)
+
+// New creates a new, empty queue.
+func New[T any]() *Queue[T] {
+	q := &Queue[T]{}
+	q.reset(minBuckets, 1, 0)
+	return q
+}
+
+// Len returns the number of events in the queue.
+func (q *Queue[T]) Len() int {
+	return q.length
+}
+
+// Push adds v to the queue with time t. It panics if t is NaN or infinite.
+func (q *Queue[T]) Push(v T, t float64) {
+	if math.IsNaN(t) || math.IsInf(t, 0) {
+		panic(fmt.Sprintf("calendarqueue: invalid time %v", t))
+	}
+	if q.day(t) < q.curDay {
+		// An event in the past of the scan; move the scan back to it.
+		q.moveTo(t)
+	}
+	q.insert(event[T]{t, v})
+	q.length++
+	if q.length > 2*len(q.buckets) {
+		q.resize(2 * len(q.buckets))
+	}
+}
+
+// Peek returns the event with the earliest time in the queue, with its
+// time, without removing it. It panics if the queue is empty.
+func (q *Queue[T]) Peek() (T, float64) {
+	if q.length == 0 {
+		panic("calendarqueue: peek into empty queue")
+	}
+	e := q.buckets[q.findNext()][0]
+	return e.value, e.time
+}
+
+// Pop removes the event with the earliest time from the queue, and returns
+// it with its time. It panics if the queue is empty; make sure to check
+// Len() first.
+func (q *Queue[T]) Pop() (T, float64) {
+	if q.length == 0 {
+		panic("calendarqueue: pop from empty queue")
+	}
+	e := q.popNext()
+	if len(q.buckets) > minBuckets && q.length < len(q.buckets)/2 {
+		q.resize(len(q.buckets) / 2)
+	}
+	return e.value, e.time
+}
+
+// day returns the index of the day of time t, counting from time 0.
+func (q *Queue[T]) day(t float64) int64 {
+	return int64(math.Floor(t / q.width))
+}
+
+func (q *Queue[T]) bucketOf(t float64) int {
+	return int(uint64(q.day(t)) & uint64(len(q.buckets)-1))
+}
+
+// moveTo moves the scan to the bucket of time t.
+func (q *Queue[T]) moveTo(t float64) {
+	q.cur = q.bucketOf(t)
+	q.curDay = q.day(t)
+}
+
+// insert adds e to its bucket, after the events with the same time.
+func (q *Queue[T]) insert(e event[T]) {
+	i := q.bucketOf(e.time)
+	b := q.buckets[i]
+	j := sort.Search(len(b), func(j int) bool { return b[j].time > e.time })
+	b = append(b, event[T]{})
+	copy(b[j+1:], b[j:])
+	b[j] = e
+	q.buckets[i] = b
+}
+
+// findNext moves the scan to the bucket holding the earliest event in the
+// non-empty queue, and returns its index.
+func (q *Queue[T]) findNext() int {
+	// Look for an event in the current year, starting from the current
+	// bucket. Days are compared as computed by bucketOf, so that events at
+	// the boundaries of days are found in the day of their bucket.
+	i, day := q.cur, q.curDay
+	for range q.buckets {
+		if b := q.buckets[i]; len(b) > 0 && q.day(b[0].time) == day {
+			q.cur, q.curDay = i, day
+			return i
+		}
+		i = (i + 1) & (len(q.buckets) - 1)
+		day++
+	}
+
+	// The events are sparse relative to the calendar's year; find the
+	// earliest one directly.
+	best := -1
+	for i, b := range q.buckets {
+		if len(b) > 0 && (best < 0 || b[0].time < q.buckets[best][0].time) {
+			best = i
+		}
+	}
+	q.moveTo(q.buckets[best][0].time)
+	return best
+}
+
+// popNext removes and returns the earliest event in the non-empty queue.
+func (q *Queue[T]) popNext() event[T] {
+	i := q.findNext()
+	b := q.buckets[i]
+	e := b[0]
+	copy(b, b[1:])
+	b[len(b)-1] = event[T]{}
+	q.buckets[i] = b[:len(b)-1]
+	q.length--
+	return e
+}
+
+// resize rebuilds the calendar with n buckets, and a width estimated from
+// the separation of the events at the front of the queue.
+func (q *Queue[T]) resize(n int) {
+	width := q.width
+	sample := make([]event[T], 0, min(sampleSize, q.length))
+	for range cap(sample) {
+		sample = append(sample, q.popNext())
+	}
+	if len(sample) >= 2 {
+		// Use the average separation between events, ignoring outliers
+		// more than twice the average, and make buckets wide enough to
+		// hold a few events each.
+		avg := (sample[len(sample)-1].time - sample[0].time) / float64(len(sample)-1)
+		sum, count := 0.0, 0
+		for i := 1; i < len(sample); i++ {
+			if sep := sample[i].time - sample[i-1].time; sep <= 2*avg {
+				sum += sep
+				count++
+			}
+		}
+		if w := 3 * sum / float64(count); w > 0 && !math.IsInf(w, 0) {
+			width = w
+		}
+	}
+
+	old := q.buckets
+	start := float64(q.curDay) * q.width
+	if len(sample) > 0 {
+		start = sample[0].time
+	}
+	q.reset(n, width, start)
+	for _, e := range sample {
+		q.insert(e)
+	}
+	for _, b := range old {
+		for _, e := range b {
+			q.insert(e)
+		}
+	}
+	q.length += len(sample)
+}
+
+// reset makes the calendar empty with n buckets of the given width, with
+// the scan starting at time start.
+func (q *Queue[T]) reset(n int, width float64, start float64) {
+	q.buckets = make([][]event[T], n)
+	q.width = width
+	q.moveTo(start)
+}
@@ -0,0 +1,212 @@
+package calendarqueue
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/eliben/gogl/heap"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+type timed struct {
+	id int
+	t  float64
+}
+
+// popAll pops all the events of q, checking that they come out in order of
+// time, FIFO among equal times.
+func popAll(t *testing.T, q *Queue[int]) []timed {
+	t.Helper()
+	var out []timed
+	for q.Len() > 0 {
+		pv, pt := q.Peek()
+		v, tm := q.Pop()
+		if v != pv || tm != pt {
+			t.Fatalf("Peek=%d@%v, Pop=%d@%v", pv, pt, v, tm)
+		}
+		if n := len(out); n > 0 && (tm < out[n-1].t || tm == out[n-1].t && v < out[n-1].id) {
+			t.Fatalf("popped %d@%v after %d@%v", v, tm, out[n-1].id, out[n-1].t)
+		}
+		out = append(out, timed{v, tm})
+	}
+	return out
+}
+
+func TestDistributions(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for name, gen := range map[string]func() float64{
+		"uniform":     func() float64 { return rnd.Float64() * 1000 },
+		"exponential": func() float64 { return rnd.ExpFloat64() },
+		"bimodal": func() float64 {
+			if rnd.IntN(2) == 0 {
+				return rnd.Float64()
+			}
+			return 1e6 + rnd.Float64()
+		},
+		"ties":     func() float64 { return float64(rnd.IntN(10)) },
+		"negative": func() float64 { return -rnd.Float64() * 100 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			q := New[int]()
+			for i := range 5000 {
+				q.Push(i, gen())
+			}
+			if q.Len() != 5000 {
+				t.Fatalf("Len=%d", q.Len())
+			}
+			if out := popAll(t, q); len(out) != 5000 {
+				t.Fatalf("popped %d events", len(out))
+			}
+			if len(q.buckets) != minBuckets {
+				t.Errorf("%d buckets after emptying", len(q.buckets))
+			}
+		})
+	}
+}
+
+func TestHoldModel(t *testing.T) {
+	// The classic simulation workload: pop the next event, and schedule a
+	// new one a random time after it. Compare with a heap.
+	rnd := makeLoggedRand(t)
+	q := New[int]()
+	h := heap.NewDAry(2, func(a, b timed) int {
+		return cmp.Or(cmp.Compare(a.t, b.t), cmp.Compare(a.id, b.id))
+	})
+	id := 0
+	for range 1000 {
+		tm := rnd.Float64() * 10
+		q.Push(id, tm)
+		h.Push(timed{id, tm})
+		id++
+	}
+	for i := range 50000 {
+		v, tm := q.Pop()
+		if want := h.Pop(); v != want.id || tm != want.t {
+			t.Fatalf("step %d: popped %d@%v, want %d@%v", i, v, tm, want.id, want.t)
+		}
+		// Occasionally grow or shrink the queue.
+		n := 1
+		if r := rnd.IntN(100); r == 0 {
+			n = 0
+		} else if r == 1 {
+			n = 2
+		}
+		for range n {
+			next := tm + rnd.ExpFloat64()*10
+			q.Push(id, next)
+			h.Push(timed{id, next})
+			id++
+		}
+	}
+}
+
+func TestHoldModelDecimal(t *testing.T) {
+	// Increments that are multiples of 0.1 accumulate rounding errors, and
+	// put events right at the boundaries of days. Compare with a sorted
+	// reference.
+	for _, seed := range []uint64{1, 2, 3} {
+		rnd := rand.New(rand.NewPCG(0, seed))
+		q := New[int]()
+		var ref []timed
+		push := func(id int, tm float64) {
+			q.Push(id, tm)
+			i := sort.Search(len(ref), func(i int) bool { return ref[i].t > tm })
+			ref = slices.Insert(ref, i, timed{id, tm})
+		}
+		id := 0
+		for range 50 {
+			push(id, float64(rnd.IntN(10))*0.1)
+			id++
+		}
+		for i := range 200000 {
+			v, tm := q.Pop()
+			want := ref[0]
+			ref = ref[1:]
+			if v != want.id || tm != want.t {
+				t.Fatalf("seed %d, step %d: popped %d@%v, want %d@%v", seed, i, v, tm, want.id, want.t)
+			}
+			push(id, tm+float64(rnd.IntN(10))*0.1)
+			id++
+		}
+	}
+}
+
+func TestPushPast(t *testing.T) {
+	q := New[int]()
+	for i := range 100 {
+		q.Push(i, float64(100+i))
+	}
+	for range 50 {
+		q.Pop()
+	}
+	q.Push(-1, 3)
+	q.Push(-2, 149.5)
+	want := []int{-1, -2}
+	for i := 50; i < 100; i++ {
+		want = append(want, i)
+	}
+	var got []int
+	for q.Len() > 0 {
+		v, _ := q.Pop()
+		got = append(got, v)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	for name, f := range map[string]func(){
+		"pop":  func() { New[int]().Pop() },
+		"peek": func() { New[int]().Peek() },
+		"nan":  func() { New[int]().Push(1, math.NaN()) },
+		"inf":  func() { New[int]().Push(1, math.Inf(1)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func benchmarkHold(b *testing.B, push func(float64), pop func() float64, n int) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for range n {
+		push(rnd.Float64() * 10)
+	}
+	b.ResetTimer()
+	for range b.N {
+		push(pop() + rnd.ExpFloat64()*10)
+	}
+}
+
+func BenchmarkHold(b *testing.B) {
+	for _, n := range []int{100, 10000, 1000000} {
+		b.Run(fmt.Sprintf("calendar/%d", n), func(b *testing.B) {
+			q := New[struct{}]()
+			benchmarkHold(b, func(t float64) { q.Push(struct{}{}, t) }, func() float64 {
+				_, t := q.Pop()
+				return t
+			}, n)
+		})
+		b.Run(fmt.Sprintf("heap/%d", n), func(b *testing.B) {
+			h := heap.NewDAry(2, cmp.Compare[float64])
+			benchmarkHold(b, h.Push, h.Pop, n)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Package clockcache implements a fixed-capacity cache with CLOCK
+// (second-chance) eviction.
+package clockcache
+
+import (
+	"iter"
+
+	"github.com/eliben/gogl/cache"
+)
+
+// Cache is a cache holding up to a fixed number of entries, evicting with
+// the CLOCK algorithm, an approximation of LRU. Entries are kept in a ring
+// of slots, each with a reference bit that Get sets. To evict, a "clock
+// hand" sweeps the ring: an entry with its bit set gets a second chance (the
+// bit is cleared and the hand moves on), and the first entry found with its
+// bit clear is evicted.
+//
+// Unlike an LRU cache, a Get only sets a bit and doesn't relink any list,
+// which makes hits cheaper. Get, Put and Remove take O(1) amortized time.
+// Create caches with [New] or [NewWithEvict].
+type Cache[K comparable, V any] struct {
+	slots []slot[K, V]
+	index map[K]int
+
+	// free holds the indices of unused slots; the ring is only swept when
+	// there are none.
+	free    []int
+	hand    int
+	onEvict func(K, V)
+	rec     cache.Recorder
+}
+
+type slot[K comparable, V any] struct {
+	key   K
+	value V
+	ref   bool
+	used  bool
+}
+
+// New creates a new, empty cache holding up to capacity entries. capacity
+// must be positive.
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	return NewWithEvict[K, V](capacity, nil)
+}
+
+// NewWithEvict is like New, but onEvict is called with the key and value of
+// every entry evicted to make room for a new one. onEvict may be nil.
+func NewWithEvict[K comparable, V any](capacity int, onEvict func(K, V)) *Cache[K, V] {
+	if capacity <= 0 {
+		panic("clockcache: capacity must be positive")
+	}
+	free := make([]int, capacity)
+	for i := range free {
+		free[i] = capacity - 1 - i
+	}
+	return &Cache[K, V]{
+		slots:   make([]slot[K, V], capacity),
+		index:   make(map[K]int),
+		free:    free,
+		onEvict: onEvict,
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	return len(c.index)
+}
+
+// Cap returns the capacity of the cache.
+func (c *Cache[K, V]) Cap() int {
+	return len(c.slots)
+}
+
+// Get looks up key in the cache, setting its reference bit. It returns the
+// associated value and ok=true; otherwise, it returns ok=false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	i, ok := c.index[key]
+	c.rec.Lookup(ok)
+	if !ok {
+		return v, false
+	}
+	c.slots[i].ref = true
+	return c.slots[i].value, true
+}
+
+// Peek is like Get, but it doesn't set the reference bit of key.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	i, ok := c.index[key]
+	if !ok {
+		return v, false
+	}
+	return c.slots[i].value, true
+}
+
+// Contains reports whether key is in the cache, without setting its
+// reference bit.
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.index[key]
+	return ok
+}
+
+// Put sets the value of key in the cache. An existing key has its reference
+// bit set; a new key starts with its bit clear, and if the cache is full an
+// entry is evicted first.
+func (c *Cache[K, V]) Put(key K, value V) {
+	if i, ok := c.index[key]; ok {
+		c.slots[i].value = value
+		c.slots[i].ref = true
+		return
+	}
+
+	var i int
+	if n := len(c.free); n > 0 {
+		i = c.free[n-1]
+		c.free = c.free[:n-1]
+	} else {
+		i = c.evict()
+	}
+	c.slots[i] = slot[K, V]{key: key, value: value, used: true}
+	c.index[key] = i
+}
+
+// Remove removes key from the cache. It returns true if key was found, and
+// false otherwise. The eviction callback isn't called for removed entries.
+func (c *Cache[K, V]) Remove(key K) bool {
+	i, ok := c.index[key]
+	if !ok {
+		return false
+	}
+	delete(c.index, key)
+	c.slots[i] = slot[K, V]{}
+	c.free = append(c.free, i)
+	return true
+}
+
+// Stats returns statistics about the cache's activity since it was created.
+func (c *Cache[K, V]) Stats() cache.Stats {
+	return c.rec.Stats()
+}
+
+// SetHooks sets hooks to be called on cache events; nil removes them.
+func (c *Cache[K, V]) SetHooks(h cache.Hooks) {
+	c.rec.SetHooks(h)
+}
+
+// All returns an iterator over all the entries in the cache, in the order
+// the clock hand would visit them. Iteration doesn't set reference bits.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for j := range c.slots {
+			s := &c.slots[(c.hand+j)%len(c.slots)]
+			if s.used && !yield(s.key, s.value) {
+				return
+			}
+		}
+	}
+}
+
+// evict sweeps the clock hand to find a victim in a full cache, evicts it,
+// and returns its slot index. The hand is left past the returned slot.
+func (c *Cache[K, V]) evict() int {
+	for {
+		i := c.hand
+		c.hand = (c.hand + 1) % len(c.slots)
+		s := &c.slots[i]
+		if s.ref {
+			s.ref = false
+			continue
+		}
+		delete(c.index, s.key)
+		c.rec.Evict()
+		if c.onEvict != nil {
+			c.onEvict(s.key, s.value)
+		}
+		return i
+	}
+}
@@ -0,0 +1,145 @@
+package clockcache
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestSecondChance(t *testing.T) {
+	var evicted []string
+	c := NewWithEvict(3, func(k string, v int) { evicted = append(evicted, k) })
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	if c.Len() != 3 || c.Cap() != 3 {
+		t.Errorf("Len=%d Cap=%d", c.Len(), c.Cap())
+	}
+
+	// a is referenced, so the hand passes it and evicts b.
+	c.Get("a")
+	c.Put("d", 4)
+	if !slices.Equal(evicted, []string{"b"}) {
+		t.Errorf("evicted %v", evicted)
+	}
+
+	// a lost its reference bit in the sweep, so it's next after c.
+	c.Put("e", 5)
+	c.Put("f", 6)
+	if !slices.Equal(evicted, []string{"b", "c", "a"}) {
+		t.Errorf("evicted %v", evicted)
+	}
+
+	// Peek and Contains don't set the reference bit.
+	if v, ok := c.Peek("d"); !ok || v != 4 || !c.Contains("d") {
+		t.Errorf("Peek(d)=%d,%v", v, ok)
+	}
+	c.Put("g", 7)
+	if !slices.Equal(evicted, []string{"b", "c", "a", "d"}) {
+		t.Errorf("evicted %v", evicted)
+	}
+
+	// Removing frees a slot, so the next Put evicts nothing.
+	if !c.Remove("e") || c.Remove("e") {
+		t.Errorf("bad Remove")
+	}
+	c.Put("h", 8)
+	if len(evicted) != 4 || c.Len() != 3 {
+		t.Errorf("evicted %v, Len=%d", evicted, c.Len())
+	}
+	if v, ok := c.Get("h"); !ok || v != 8 {
+		t.Errorf("Get(h)=%d,%v", v, ok)
+	}
+}
+
+// model is a straightforward simulation of the CLOCK algorithm, as a slice
+// of slots with a hand.
+type model struct {
+	keys, values []int
+	ref, used    []bool
+	hand         int
+}
+
+func (m *model) find(k int) int {
+	for i := range m.keys {
+		if m.used[i] && m.keys[i] == k {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRandomAgainstModel(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const capacity = 6
+	c := New[int, int](capacity)
+	m := &model{
+		keys:   make([]int, capacity),
+		values: make([]int, capacity),
+		ref:    make([]bool, capacity),
+		used:   make([]bool, capacity),
+	}
+	// Free slots are taken in LIFO order of freeing, with initial slots
+	// taken from 0 upwards.
+	var free []int
+	for i := capacity - 1; i >= 0; i-- {
+		free = append(free, i)
+	}
+
+	for range 5000 {
+		k := rnd.IntN(15)
+		i := m.find(k)
+		switch rnd.IntN(4) {
+		case 0:
+			v, ok := c.Get(k)
+			if ok != (i >= 0) || (ok && v != m.values[i]) {
+				t.Fatalf("Get(%d)=%d,%v", k, v, ok)
+			}
+			if ok {
+				m.ref[i] = true
+			}
+		case 1:
+			if c.Remove(k) != (i >= 0) {
+				t.Fatalf("Remove(%d) mismatch", k)
+			}
+			if i >= 0 {
+				m.used[i], m.ref[i] = false, false
+				free = append(free, i)
+			}
+		default:
+			v := rnd.Int()
+			c.Put(k, v)
+			if i >= 0 {
+				m.values[i], m.ref[i] = v, true
+				break
+			}
+			if n := len(free); n > 0 {
+				i, free = free[n-1], free[:n-1]
+			} else {
+				for m.ref[m.hand] {
+					m.ref[m.hand] = false
+					m.hand = (m.hand + 1) % capacity
+				}
+				i = m.hand
+				m.hand = (m.hand + 1) % capacity
+			}
+			m.keys[i], m.values[i], m.ref[i], m.used[i] = k, v, false, true
+		}
+
+		n := 0
+		for k, v := range c.All() {
+			i := m.find(k)
+			if i < 0 || m.values[i] != v {
+				t.Fatalf("unexpected entry %d=%d", k, v)
+			}
+			n++
+		}
+		if want := len(free); n != c.Len() || n != capacity-want {
+			t.Fatalf("All yielded %d entries, Len=%d, model has %d", n, c.Len(), capacity-want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package consistenthash implements a consistent hashing ring, for mapping
+// keys to members of a changing set (e.g. the servers of a distributed
+// cache).
+package consistenthash
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+// Ring maps keys to members by placing both on a circle of 64-bit hash
+// values: a key belongs to the first member found going clockwise from the
+// key's hash. Each member is placed at many points ("virtual nodes") to
+// even out the share of keys each member gets; a member's share is
+// proportional to its weight.
+//
+// When a member is added, it only takes over keys from other members, and
+// when one is removed, only its keys move, to the members following its
+// points; on average, only 1/n of the keys move when the n-th member joins.
+//
+// Hashing is deterministic by default, so rings with the same members map
+// keys the same way in different processes. Create rings with [New] or
+// [NewWithHash].
+type Ring struct {
+	hash     func(string) uint64
+	replicas int
+
+	// points is sorted by hash; ties are broken by member name so that the
+	// order doesn't depend on the order members were added in.
+	points  []point
+	members map[string]int
+}
+
+type point struct {
+	hash   uint64
+	member string
+}
+
+// New creates a new, empty Ring placing each member at replicas points per
+// unit of weight; 100-200 replicas give a good balance for most uses.
+func New(replicas int) *Ring {
+	return NewWithHash(replicas, hash.String)
+}
+
+// NewWithHash creates a new, empty Ring like [New], hashing keys and
+// virtual nodes with hash.
+func NewWithHash(replicas int, hash func(string) uint64) *Ring {
+	if replicas <= 0 {
+		panic(fmt.Sprintf("consistenthash: invalid number of replicas %d", replicas))
+	}
+	return &Ring{hash: hash, replicas: replicas, members: make(map[string]int)}
+}
+
+// Len returns the number of members in the ring.
+func (r *Ring) Len() int {
+	return len(r.members)
+}
+
+// Members returns the members of the ring, sorted.
+func (r *Ring) Members() []string {
+	ms := make([]string, 0, len(r.members))
+	for m := range r.members {
+		ms = append(ms, m)
+	}
+	slices.Sort(ms)
+	return ms
+}
+
+// Weight returns the weight of member, or 0 if it's not in the ring.
+func (r *Ring) Weight(member string) int {
+	return r.members[member]
+}
+
+// Add adds member to the ring with the given positive weight. If member is
+// already in the ring, its weight is changed; only keys moving between it
+// and other members are affected.
+func (r *Ring) Add(member string, weight int) {
+	if weight <= 0 {
+		panic(fmt.Sprintf("consistenthash: invalid weight %d", weight))
+	}
+	old := r.members[member]
+	r.members[member] = weight
+	if weight < old {
+		r.removePoints(member)
+		old = 0
+	}
+	// Virtual node i of a member is at the same point regardless of the
+	// member's weight, so changing the weight adds or removes points.
+	for i := old * r.replicas; i < weight*r.replicas; i++ {
+		r.points = append(r.points, point{r.hash(member + "#" + strconv.Itoa(i)), member})
+	}
+	slices.SortFunc(r.points, func(a, b point) int {
+		if c := cmp.Compare(a.hash, b.hash); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.member, b.member)
+	})
+}
+
+// Remove removes member from the ring, and reports whether it was in it.
+func (r *Ring) Remove(member string) bool {
+	if _, ok := r.members[member]; !ok {
+		return false
+	}
+	delete(r.members, member)
+	r.removePoints(member)
+	return true
+}
+
+func (r *Ring) removePoints(member string) {
+	r.points = slices.DeleteFunc(r.points, func(p point) bool { return p.member == member })
+}
+
+// Get returns the member key maps to. It returns false if the ring is
+// empty.
+func (r *Ring) Get(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+	return r.points[r.search(key)].member, true
+}
+
+// GetN returns up to n distinct members for key, in order of preference:
+// the member key maps to first, followed by the next distinct members
+// clockwise. These make a replica set for key that changes minimally as
+// members come and go.
+func (r *Ring) GetN(key string, n int) []string {
+	n = min(n, len(r.members))
+	if n <= 0 {
+		return nil
+	}
+	result := make([]string, 0, n)
+	start := r.search(key)
+	for i := range r.points {
+		m := r.points[(start+i)%len(r.points)].member
+		if !slices.Contains(result, m) {
+			result = append(result, m)
+			if len(result) == n {
+				break
+			}
+		}
+	}
+	return result
+}
+
+// search returns the index of the first point clockwise from key's hash.
+func (r *Ring) search(key string) int {
+	h := r.hash(key)
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p point, h uint64) int {
+		return cmp.Compare(p.hash, h)
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
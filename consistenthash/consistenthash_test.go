@@ -0,0 +1,196 @@
+package consistenthash
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func keys(n int) []string {
+	ks := make([]string, n)
+	for i := range ks {
+		ks[i] = fmt.Sprintf("key-%d", i)
+	}
+	return ks
+}
+
+// assign maps every key to its member.
+func assign(r *Ring, ks []string) map[string]string {
+	m := make(map[string]string, len(ks))
+	for _, k := range ks {
+		m[k], _ = r.Get(k)
+	}
+	return m
+}
+
+func TestEmpty(t *testing.T) {
+	r := New(10)
+	if _, ok := r.Get("x"); ok {
+		t.Errorf("Get on empty ring succeeded")
+	}
+	if got := r.GetN("x", 3); len(got) != 0 {
+		t.Errorf("GetN on empty ring=%v", got)
+	}
+	if r.Remove("a") {
+		t.Errorf("Remove from empty ring succeeded")
+	}
+}
+
+func TestBalance(t *testing.T) {
+	r := New(200)
+	for i := range 10 {
+		r.Add(fmt.Sprintf("node-%d", i), 1)
+	}
+	r.Add("big", 3)
+	if r.Len() != 11 || r.Weight("big") != 3 || r.Weight("none") != 0 {
+		t.Errorf("Len=%d Weight(big)=%d", r.Len(), r.Weight("big"))
+	}
+
+	const n = 130000
+	counts := make(map[string]int)
+	for _, m := range assign(r, keys(n)) {
+		counts[m]++
+	}
+	for m, c := range counts {
+		want := n / 13
+		if m == "big" {
+			want *= 3
+		}
+		if c < want*8/10 || c > want*12/10 {
+			t.Errorf("member %s got %d keys, want about %d", m, c, want)
+		}
+	}
+}
+
+func TestMinimalDisruption(t *testing.T) {
+	r := New(100)
+	for i := range 8 {
+		r.Add(fmt.Sprintf("node-%d", i), 1)
+	}
+	ks := keys(20000)
+	before := assign(r, ks)
+
+	// Adding a member only moves keys to it.
+	r.Add("new", 1)
+	after := assign(r, ks)
+	moved := 0
+	for _, k := range ks {
+		if after[k] != before[k] {
+			moved++
+			if after[k] != "new" {
+				t.Fatalf("key %s moved from %s to %s", k, before[k], after[k])
+			}
+		}
+	}
+	if moved < len(ks)/9*7/10 || moved > len(ks)/9*13/10 {
+		t.Errorf("%d keys moved to the new member, want about %d", moved, len(ks)/9)
+	}
+
+	// Removing it restores the previous assignment exactly.
+	if !r.Remove("new") {
+		t.Fatalf("Remove failed")
+	}
+	if got := assign(r, ks); !maps.Equal(got, before) {
+		t.Errorf("assignment changed after add and remove")
+	}
+
+	// Removing a member only moves its keys.
+	r.Remove("node-3")
+	after = assign(r, ks)
+	for _, k := range ks {
+		if before[k] != "node-3" && after[k] != before[k] {
+			t.Fatalf("key %s moved from %s to %s", k, before[k], after[k])
+		}
+		if after[k] == "node-3" {
+			t.Fatalf("key %s still on removed member", k)
+		}
+	}
+}
+
+func TestWeightChange(t *testing.T) {
+	r := New(50)
+	r.Add("a", 1)
+	r.Add("b", 1)
+	ks := keys(10000)
+	before := assign(r, ks)
+
+	// Increasing a's weight only moves keys to a, and restoring it undoes
+	// the change.
+	r.Add("a", 3)
+	for k, m := range assign(r, ks) {
+		if m != before[k] && m != "a" {
+			t.Fatalf("key %s moved from %s to %s", k, before[k], m)
+		}
+	}
+	r.Add("a", 1)
+	if got := assign(r, ks); !maps.Equal(got, before) {
+		t.Errorf("assignment changed after weight increase and decrease")
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	a, b := New(40), New(40)
+	members := []string{"x", "y", "z", "w"}
+	for _, m := range members {
+		a.Add(m, 1)
+	}
+	for _, m := range slices.Backward(members) {
+		b.Add(m, 1)
+	}
+	ks := keys(2000)
+	if !maps.Equal(assign(a, ks), assign(b, ks)) {
+		t.Errorf("assignment depends on the order of adding members")
+	}
+	if got := a.Members(); !slices.Equal(got, []string{"w", "x", "y", "z"}) {
+		t.Errorf("Members()=%v", got)
+	}
+}
+
+func TestGetN(t *testing.T) {
+	r := New(100)
+	for i := range 5 {
+		r.Add(fmt.Sprintf("node-%d", i), 1)
+	}
+	for _, k := range keys(1000) {
+		got := r.GetN(k, 3)
+		first, _ := r.Get(k)
+		if len(got) != 3 || got[0] != first {
+			t.Fatalf("GetN(%s, 3)=%v, Get=%s", k, got, first)
+		}
+		sorted := slices.Clone(got)
+		slices.Sort(sorted)
+		if len(slices.Compact(sorted)) != 3 {
+			t.Fatalf("GetN(%s, 3)=%v has duplicates", k, got)
+		}
+
+		// When the first member is removed, the replica set shifts up.
+		all := r.GetN(k, 10)
+		if len(all) != 5 || !slices.Equal(all[:3], got) {
+			t.Fatalf("GetN(%s, 10)=%v, GetN(%s, 3)=%v", k, all, k, got)
+		}
+	}
+
+	r.Remove("node-0")
+	for _, k := range keys(1000) {
+		got := r.GetN(k, 4)
+		r.Add("node-0", 1)
+		full := r.GetN(k, 5)
+		r.Remove("node-0")
+		if !slices.Equal(got, slices.DeleteFunc(full, func(m string) bool { return m == "node-0" })) {
+			t.Fatalf("GetN(%s) without node-0=%v, with=%v", k, got, full)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	r := New(160)
+	for i := range 50 {
+		r.Add(fmt.Sprintf("node-%d", i), 1)
+	}
+	ks := keys(1024)
+	b.ResetTimer()
+	for i := range b.N {
+		r.Get(ks[i%len(ks)])
+	}
+}
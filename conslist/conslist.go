@@ -0,0 +1,124 @@
+// Package conslist implements a persistent singly-linked list.
+package conslist
+
+import "iter"
+
+// List is a persistent singly-linked list, built like the cons lists of
+// functional languages: a list is either empty or a cell holding a head
+// value and the list of the remaining values, its tail. Lists are never
+// modified; Push returns a new list whose tail is the original, so any
+// number of lists can share a common tail. Push, Head, Tail and Len take
+// O(1) time.
+//
+// The nil *List is the empty list, and all the methods of List accept it.
+// Lists are safe for concurrent use by multiple goroutines.
+type List[T any] struct {
+	head   T
+	tail   *List[T]
+	length int
+}
+
+// New creates a new list holding vals, with vals[0] at the head.
+func New[T any](vals ...T) *List[T] {
+	var l *List[T]
+	for i := len(vals) - 1; i >= 0; i-- {
+		l = l.Push(vals[i])
+	}
+	return l
+}
+
+// FromSeq creates a new list holding the values in seq, with the first
+// value at the head.
+func FromSeq[T any](seq iter.Seq[T]) *List[T] {
+	var vals []T
+	for v := range seq {
+		vals = append(vals, v)
+	}
+	return New(vals...)
+}
+
+// Len returns the number of values in the list.
+func (l *List[T]) Len() int {
+	if l == nil {
+		return 0
+	}
+	return l.length
+}
+
+// IsEmpty reports whether the list is empty.
+func (l *List[T]) IsEmpty() bool {
+	return l == nil
+}
+
+// Push returns a new list with v at the head, followed by the values of l.
+func (l *List[T]) Push(v T) *List[T] {
+	return &List[T]{head: v, tail: l, length: l.Len() + 1}
+}
+
+// Head returns the first value of the list. It panics if the list is empty.
+func (l *List[T]) Head() T {
+	if l == nil {
+		panic("conslist: Head of empty list")
+	}
+	return l.head
+}
+
+// Tail returns the list of all values but the first. It panics if the list
+// is empty.
+func (l *List[T]) Tail() *List[T] {
+	if l == nil {
+		panic("conslist: Tail of empty list")
+	}
+	return l.tail
+}
+
+// Uncons splits the list into its head and tail, with ok=true; if the list
+// is empty, it returns ok=false. It's the counterpart of matching a list
+// against a cons pattern:
+//
+//	if head, tail, ok := l.Uncons(); ok {
+//		...
+//	}
+func (l *List[T]) Uncons() (head T, tail *List[T], ok bool) {
+	if l == nil {
+		return head, nil, false
+	}
+	return l.head, l.tail, true
+}
+
+// Match calls onEmpty if l is empty, or else onCons with the head and tail
+// of l, and returns the result. It makes recursive definitions read like
+// their functional counterparts; for example:
+//
+//	func sum(l *List[int]) int {
+//		return Match(l,
+//			func() int { return 0 },
+//			func(head int, tail *List[int]) int { return head + sum(tail) })
+//	}
+func Match[T, R any](l *List[T], onEmpty func() R, onCons func(head T, tail *List[T]) R) R {
+	if l == nil {
+		return onEmpty()
+	}
+	return onCons(l.head, l.tail)
+}
+
+// Reverse returns a new list holding the values of l in reverse order, in
+// O(n) time.
+func (l *List[T]) Reverse() *List[T] {
+	var r *List[T]
+	for ; l != nil; l = l.tail {
+		r = r.Push(l.head)
+	}
+	return r
+}
+
+// Values returns an iterator over the values in the list, from the head.
+func (l *List[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for c := l; c != nil; c = c.tail {
+			if !yield(c.head) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package conslist
+
+import (
+	"slices"
+	"testing"
+)
+
+func checkList(t *testing.T, l *List[int], want []int) {
+	t.Helper()
+	if l.Len() != len(want) || l.IsEmpty() != (len(want) == 0) {
+		t.Fatalf("Len=%d IsEmpty=%v, want %d values", l.Len(), l.IsEmpty(), len(want))
+	}
+	if got := slices.Collect(l.Values()); !slices.Equal(got, want) {
+		t.Fatalf("Values=%v, want %v", got, want)
+	}
+}
+
+func TestPushHeadTail(t *testing.T) {
+	var empty *List[int]
+	checkList(t, empty, nil)
+
+	l1 := empty.Push(3)
+	l2 := l1.Push(2)
+	l3 := l2.Push(1)
+	checkList(t, l1, []int{3})
+	checkList(t, l2, []int{2, 3})
+	checkList(t, l3, []int{1, 2, 3})
+	if l3.Head() != 1 || l3.Tail() != l2 || l2.Tail() != l1 || l1.Tail() != nil {
+		t.Errorf("bad head or tail")
+	}
+
+	// Pushing onto a shared tail leaves the other lists alone.
+	other := l2.Push(10)
+	checkList(t, other, []int{10, 2, 3})
+	checkList(t, l3, []int{1, 2, 3})
+}
+
+func TestConstructors(t *testing.T) {
+	checkList(t, New[int](), nil)
+	checkList(t, New(1, 2, 3, 4), []int{1, 2, 3, 4})
+	checkList(t, FromSeq(slices.Values([]int{5, 6, 7})), []int{5, 6, 7})
+	checkList(t, FromSeq(slices.Values([]int{})), nil)
+}
+
+func TestReverse(t *testing.T) {
+	checkList(t, New[int]().Reverse(), nil)
+	l := New(1, 2, 3, 4, 5)
+	checkList(t, l.Reverse(), []int{5, 4, 3, 2, 1})
+	checkList(t, l, []int{1, 2, 3, 4, 5})
+}
+
+func sum(l *List[int]) int {
+	return Match(l,
+		func() int { return 0 },
+		func(head int, tail *List[int]) int { return head + sum(tail) })
+}
+
+func TestMatch(t *testing.T) {
+	if got := sum(New(1, 2, 3, 4)); got != 10 {
+		t.Errorf("sum=%d, want 10", got)
+	}
+	if got := sum(nil); got != 0 {
+		t.Errorf("sum(nil)=%d, want 0", got)
+	}
+
+	var got []int
+	for l := New(7, 8, 9); ; {
+		head, tail, ok := l.Uncons()
+		if !ok {
+			break
+		}
+		got = append(got, head)
+		l = tail
+	}
+	if !slices.Equal(got, []int{7, 8, 9}) {
+		t.Errorf("Uncons loop got %v", got)
+	}
+}
+
+func TestValuesStop(t *testing.T) {
+	n := 0
+	for v := range New(1, 2, 3, 4, 5).Values() {
+		if v == 3 {
+			break
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("got %d iterations, want 2", n)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	var empty *List[int]
+	for name, f := range map[string]func(){
+		"head": func() { empty.Head() },
+		"tail": func() { empty.Tail() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package container defines interfaces shared by the module's containers, so
+// that code can be written against any of them, and backends swapped in
+// tests and benchmarks.
+//
+// The interfaces are satisfied by the module's containers as follows:
+//
+//   - Set: hashset, sparseset.
+//   - Map: the maps of hashmap, skiplist, cskiplist, treap, and all the
+//     SortedMap implementations.
+//   - SortedMap: btree, sortedmap, rbtree, avltree, splaytree.
+//   - Partitioner: the Ring of consistenthash and the Table of rendezvous.
+//
+// Almost all containers are Lener, and those holding elements are
+// Iterable or Iterable2; some are Clearer.
+package container
+
+import "iter"
+
+// Lener is a container with a number of elements.
+type Lener interface {
+	// Len returns the number of elements in the container.
+	Len() int
+}
+
+// Clearer is a container that can be emptied.
+type Clearer interface {
+	// Clear removes all the elements from the container.
+	Clear()
+}
+
+// Iterable is a container whose elements can be iterated over.
+type Iterable[T any] interface {
+	// All returns an iterator over all the elements of the container. The
+	// container must not be modified during iteration.
+	All() iter.Seq[T]
+}
+
+// Iterable2 is a container of pairs, such as key, value pairs, that can be
+// iterated over.
+type Iterable2[K, V any] interface {
+	// All returns an iterator over all the pairs of the container. The
+	// container must not be modified during iteration.
+	All() iter.Seq2[K, V]
+}
+
+// Set is a set of values of type T. All iterates over the values in an
+// order determined by the implementation.
+type Set[T any] interface {
+	Lener
+	Iterable[T]
+
+	// Add adds val to the set; if it's already in the set, this is a no-op.
+	Add(val T)
+
+	// Contains reports whether val is in the set.
+	Contains(val T) bool
+
+	// Delete removes val from the set; if it isn't in the set, this is a
+	// no-op.
+	Delete(val T)
+}
+
+// Map is a map from keys of type K to values of type V. All iterates over
+// the key, value pairs in an order determined by the implementation.
+type Map[K, V any] interface {
+	Lener
+	Iterable2[K, V]
+
+	// Get looks for key in the map. It returns the associated value and
+	// ok=true; otherwise, it returns ok=false.
+	Get(key K) (V, bool)
+
+	// Insert sets the value of key to value, adding key if it's not in the
+	// map.
+	Insert(key K, value V)
+
+	// Delete deletes key and its value from the map. It returns true if key
+	// was found, and false otherwise.
+	Delete(key K) bool
+}
+
+// SortedMap is a Map whose keys are ordered. All iterates over the key,
+// value pairs in ascending order of keys.
+type SortedMap[K, V any] interface {
+	Map[K, V]
+
+	// Range returns an iterator over the key, value pairs with keys in the
+	// range [lo, hi), in ascending order of keys.
+	Range(lo, hi K) iter.Seq2[K, V]
+
+	// Floor finds the largest key in the map that's smaller than or equal
+	// to key. It returns this key with its value and ok=true; if there's no
+	// such key, it returns ok=false.
+	Floor(key K) (K, V, bool)
+
+	// Ceiling finds the smallest key in the map that's larger than or equal
+	// to key. It returns this key with its value and ok=true; if there's no
+	// such key, it returns ok=false.
+	Ceiling(key K) (K, V, bool)
+}
+
+// Partitioner maps string keys to the members of a weighted set, such as
+// the servers of a distributed cache, so that few keys move to other
+// members as members are added and removed.
+type Partitioner interface {
+	Lener
+
+	// Members returns the members, sorted.
+	Members() []string
+
+	// Weight returns the weight of member, or 0 if it's not a member.
+	Weight(member string) int
+
+	// Add adds member with the given positive weight. If member was already
+	// added, its weight is changed.
+	Add(member string, weight int)
+
+	// Remove removes member, and reports whether it was a member.
+	Remove(member string) bool
+
+	// Get returns the member key maps to. It returns false if there are no
+	// members.
+	Get(key string) (string, bool)
+
+	// GetN returns up to n distinct members for key, in order of preference,
+	// starting with the member key maps to.
+	GetN(key string, n int) []string
+}
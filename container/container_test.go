@@ -0,0 +1,263 @@
+package container_test
+
+import (
+	"cmp"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/eliben/gogl/avltree"
+	"github.com/eliben/gogl/btree"
+	"github.com/eliben/gogl/consistenthash"
+	"github.com/eliben/gogl/container"
+	"github.com/eliben/gogl/cskiplist"
+	"github.com/eliben/gogl/hashmap"
+	"github.com/eliben/gogl/hashset"
+	"github.com/eliben/gogl/rbtree"
+	"github.com/eliben/gogl/rendezvous"
+	"github.com/eliben/gogl/skiplist"
+	"github.com/eliben/gogl/sortedmap"
+	"github.com/eliben/gogl/sparseset"
+	"github.com/eliben/gogl/splaytree"
+	"github.com/eliben/gogl/syncmap"
+	"github.com/eliben/gogl/treap"
+)
+
+var (
+	_ container.Set[int] = (*hashset.HashSet[int])(nil)
+	_ container.Set[int] = (*sparseset.Set[int])(nil)
+
+	_ container.Map[string, int] = (*hashmap.Swiss[string, int])(nil)
+	_ container.Map[string, int] = (*hashmap.RobinHood[string, int])(nil)
+	_ container.Map[string, int] = (*hashmap.Cuckoo[string, int])(nil)
+	_ container.Map[string, int] = (*skiplist.SkipList[string, int])(nil)
+	_ container.Map[string, int] = (*cskiplist.Map[string, int])(nil)
+	_ container.Map[string, int] = (*treap.Treap[string, int])(nil)
+
+	_ container.SortedMap[string, int] = (*btree.BTree[string, int])(nil)
+	_ container.SortedMap[string, int] = (*sortedmap.Map[string, int])(nil)
+	_ container.SortedMap[string, int] = (*rbtree.Tree[string, int])(nil)
+	_ container.SortedMap[string, int] = (*avltree.Tree[string, int])(nil)
+	_ container.SortedMap[string, int] = (*splaytree.Tree[string, int])(nil)
+
+	_ container.Partitioner = (*consistenthash.Ring)(nil)
+	_ container.Partitioner = (*rendezvous.Table)(nil)
+
+	_ container.Clearer = (*hashset.HashSet[int])(nil)
+	_ container.Clearer = (*sparseset.Set[int])(nil)
+	_ container.Clearer = (*syncmap.Map[string, int])(nil)
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// setBackends lists constructors for the sets of the module.
+var setBackends = []struct {
+	name string
+	make func() container.Set[int]
+}{
+	{"hashset", func() container.Set[int] { return hashset.New[int]() }},
+	{"sparseset", func() container.Set[int] { return sparseset.New[int](1000) }},
+}
+
+// sortedMapBackends lists constructors for the sorted maps of the module.
+var sortedMapBackends = []struct {
+	name string
+	make func() container.SortedMap[int, int]
+}{
+	{"btree", func() container.SortedMap[int, int] { return btree.New[int, int](cmp.Compare[int]) }},
+	{"sortedmap", func() container.SortedMap[int, int] { return sortedmap.New[int, int](cmp.Compare[int]) }},
+	{"rbtree", func() container.SortedMap[int, int] { return rbtree.New[int, int](cmp.Compare[int]) }},
+	{"avltree", func() container.SortedMap[int, int] { return avltree.New[int, int](cmp.Compare[int]) }},
+	{"splaytree", func() container.SortedMap[int, int] { return splaytree.New[int, int](cmp.Compare[int]) }},
+}
+
+// mapBackends lists constructors for the maps of the module, including the
+// sorted ones.
+var mapBackends = []struct {
+	name string
+	make func() container.Map[int, int]
+}{
+	{"swiss", func() container.Map[int, int] { return hashmap.NewSwiss[int, int](hashmap.IntHasher[int]()) }},
+	{"robinhood", func() container.Map[int, int] { return hashmap.NewRobinHood[int, int](hashmap.IntHasher[int]()) }},
+	{"cuckoo", func() container.Map[int, int] { return hashmap.NewCuckoo[int, int](hashmap.IntHasher[int]()) }},
+	{"skiplist", func() container.Map[int, int] { return skiplist.New[int, int](cmp.Compare[int]) }},
+	{"cskiplist", func() container.Map[int, int] { return cskiplist.New[int, int](cmp.Compare[int]) }},
+	{"treap", func() container.Map[int, int] { return treap.New[int, int](cmp.Compare[int]) }},
+}
+
+func init() {
+	for _, be := range sortedMapBackends {
+		mapBackends = append(mapBackends, struct {
+			name string
+			make func() container.Map[int, int]
+		}{be.name, func() container.Map[int, int] { return be.make() }})
+	}
+}
+
+// fillMap applies random insertions and deletions to m, and to a built-in
+// map that it returns.
+func fillMap(t *testing.T, m container.Map[int, int]) map[int]int {
+	t.Helper()
+	rnd := makeLoggedRand(t)
+	want := make(map[int]int)
+	for range 3000 {
+		k := rnd.IntN(1000)
+		if rnd.IntN(3) == 0 {
+			_, found := want[k]
+			if got := m.Delete(k); got != found {
+				t.Fatalf("Delete(%d) = %v, want %v", k, got, found)
+			}
+			delete(want, k)
+		} else {
+			v := rnd.Int()
+			m.Insert(k, v)
+			want[k] = v
+		}
+	}
+	return want
+}
+
+func TestMap(t *testing.T) {
+	for _, be := range mapBackends {
+		t.Run(be.name, func(t *testing.T) {
+			m := be.make()
+			want := fillMap(t, m)
+			if m.Len() != len(want) {
+				t.Errorf("Len() = %d, want %d", m.Len(), len(want))
+			}
+			for k := range 1000 {
+				v, ok := m.Get(k)
+				wantV, wantOk := want[k]
+				if v != wantV || ok != wantOk {
+					t.Fatalf("Get(%d) = %d, %v, want %d, %v", k, v, ok, wantV, wantOk)
+				}
+			}
+			if got := maps.Collect(m.All()); !maps.Equal(got, want) {
+				t.Errorf("All() yielded %d pairs, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestSortedMap(t *testing.T) {
+	for _, be := range sortedMapBackends {
+		t.Run(be.name, func(t *testing.T) {
+			m := be.make()
+			want := fillMap(t, m)
+			keys := slices.Sorted(maps.Keys(want))
+
+			var got []int
+			for k, v := range m.All() {
+				if v != want[k] {
+					t.Fatalf("All() yielded %d=%d, want %d", k, v, want[k])
+				}
+				got = append(got, k)
+			}
+			if !slices.Equal(got, keys) {
+				t.Fatalf("All() yielded keys out of order")
+			}
+
+			got = got[:0]
+			for k := range m.Range(250, 750) {
+				got = append(got, k)
+			}
+			lo, _ := slices.BinarySearch(keys, 250)
+			hi, _ := slices.BinarySearch(keys, 750)
+			if !slices.Equal(got, keys[lo:hi]) {
+				t.Errorf("Range(250, 750) yielded %v, want %v", got, keys[lo:hi])
+			}
+
+			for k := -1; k <= 1000; k++ {
+				i, found := slices.BinarySearch(keys, k)
+				fk, _, fok := m.Floor(k)
+				if wantOk := found || i > 0; fok != wantOk || fok && fk != keys[i-1+boolToInt(found)] {
+					t.Fatalf("Floor(%d) = %d, %v", k, fk, fok)
+				}
+				ck, _, cok := m.Ceiling(k)
+				if wantOk := i < len(keys); cok != wantOk || cok && ck != keys[i] {
+					t.Fatalf("Ceiling(%d) = %d, %v", k, ck, cok)
+				}
+			}
+		})
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestSet(t *testing.T) {
+	for _, be := range setBackends {
+		t.Run(be.name, func(t *testing.T) {
+			rnd := makeLoggedRand(t)
+			s := be.make()
+			want := make(map[int]bool)
+			for range 3000 {
+				v := rnd.IntN(1000)
+				if rnd.IntN(3) == 0 {
+					s.Delete(v)
+					delete(want, v)
+				} else {
+					s.Add(v)
+					want[v] = true
+				}
+			}
+			if s.Len() != len(want) {
+				t.Errorf("Len() = %d, want %d", s.Len(), len(want))
+			}
+			for v := range 1000 {
+				if s.Contains(v) != want[v] {
+					t.Fatalf("Contains(%d) = %v, want %v", v, s.Contains(v), want[v])
+				}
+			}
+			got := slices.Sorted(s.All())
+			if !slices.Equal(got, slices.Sorted(maps.Keys(want))) {
+				t.Errorf("All() yielded %d values, want %d", len(got), len(want))
+			}
+
+			if c, ok := s.(container.Clearer); ok {
+				c.Clear()
+				if s.Len() != 0 || s.Contains(got[0]) {
+					t.Errorf("Clear() left Len() = %d", s.Len())
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	const n = 10000
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = rand.Int()
+	}
+	for _, be := range mapBackends {
+		b.Run(be.name+"/Insert", func(b *testing.B) {
+			for range b.N {
+				m := be.make()
+				for _, k := range keys {
+					m.Insert(k, k)
+				}
+			}
+		})
+		b.Run(be.name+"/Get", func(b *testing.B) {
+			m := be.make()
+			for _, k := range keys {
+				m.Insert(k, k)
+			}
+			b.ResetTimer()
+			for i := range b.N {
+				m.Get(keys[i%n])
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package counter implements a frequency counter, similar to Python's
+// collections.Counter.
+package counter
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// Counter counts occurrences of values of type T. Only positive counts are
+// stored: a value whose count drops to zero or below is removed. Create
+// counters with [New] or [FromSeq].
+type Counter[T comparable] struct {
+	counts map[T]int
+	total  int
+}
+
+// Entry is a value with its count.
+type Entry[T comparable] struct {
+	Value T
+	Count int
+}
+
+// New creates a new, empty counter.
+func New[T comparable]() *Counter[T] {
+	return &Counter[T]{counts: make(map[T]int)}
+}
+
+// FromSeq creates a new counter holding the number of occurrences of each
+// value in seq.
+func FromSeq[T comparable](seq iter.Seq[T]) *Counter[T] {
+	c := New[T]()
+	for v := range seq {
+		c.Add(v)
+	}
+	return c
+}
+
+// Add increments the count of v by one.
+func (c *Counter[T]) Add(v T) {
+	c.AddN(v, 1)
+}
+
+// AddN adds n to the count of v; n may be negative. If the count of v drops
+// to zero or below, v is removed from the counter.
+func (c *Counter[T]) AddN(v T, n int) {
+	old := c.counts[v]
+	count := old + n
+	if count <= 0 {
+		delete(c.counts, v)
+		count = 0
+	} else {
+		c.counts[v] = count
+	}
+	c.total += count - old
+}
+
+// Count returns the count of v; it's 0 for values not in the counter.
+func (c *Counter[T]) Count(v T) int {
+	return c.counts[v]
+}
+
+// Delete removes v from the counter, returning its count before removal.
+func (c *Counter[T]) Delete(v T) int {
+	n := c.counts[v]
+	delete(c.counts, v)
+	c.total -= n
+	return n
+}
+
+// Len returns the number of distinct values in the counter.
+func (c *Counter[T]) Len() int {
+	return len(c.counts)
+}
+
+// Total returns the sum of all the counts in the counter.
+func (c *Counter[T]) Total() int {
+	return c.total
+}
+
+// All returns an iterator over all the values in the counter with their
+// counts, in unspecified order.
+func (c *Counter[T]) All() iter.Seq2[T, int] {
+	return func(yield func(T, int) bool) {
+		for v, n := range c.counts {
+			if !yield(v, n) {
+				return
+			}
+		}
+	}
+}
+
+// MostCommon returns the n values with the highest counts, in descending
+// order of count; values with equal counts are ordered arbitrarily. If n is
+// larger than Len(), all values are returned. It takes O(k log n) time for
+// k distinct values.
+func (c *Counter[T]) MostCommon(n int) []Entry[T] {
+	if n <= 0 {
+		return nil
+	}
+	// Keep the n most common entries seen so far in a min-heap, so the
+	// least common of them is evicted when a better one comes along.
+	h := heap.NewDAry(4, func(a, b Entry[T]) int { return cmp.Compare(a.Count, b.Count) })
+	for v, count := range c.counts {
+		if h.Len() < n {
+			h.Push(Entry[T]{v, count})
+		} else if count > h.Peek().Count {
+			h.Pop()
+			h.Push(Entry[T]{v, count})
+		}
+	}
+	result := make([]Entry[T], h.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = h.Pop()
+	}
+	return result
+}
+
+// AddCounter adds the counts of other to c.
+func (c *Counter[T]) AddCounter(other *Counter[T]) {
+	for v, n := range other.counts {
+		c.AddN(v, n)
+	}
+}
+
+// SubtractCounter subtracts the counts of other from c; values whose counts
+// drop to zero or below are removed.
+func (c *Counter[T]) SubtractCounter(other *Counter[T]) {
+	for v, n := range other.counts {
+		c.AddN(v, -n)
+	}
+}
+
+// Clone returns a copy of the counter.
+func (c *Counter[T]) Clone() *Counter[T] {
+	clone := New[T]()
+	clone.AddCounter(c)
+	return clone
+}
+
+// Sorted returns all the entries in the counter sorted by descending count,
+// breaking ties with cmp on the values.
+func (c *Counter[T]) Sorted(cmpValues func(a, b T) int) []Entry[T] {
+	entries := make([]Entry[T], 0, len(c.counts))
+	for v, n := range c.counts {
+		entries = append(entries, Entry[T]{v, n})
+	}
+	slices.SortFunc(entries, func(a, b Entry[T]) int {
+		if r := cmp.Compare(b.Count, a.Count); r != 0 {
+			return r
+		}
+		return cmpValues(a.Value, b.Value)
+	})
+	return entries
+}
@@ -0,0 +1,114 @@
+package counter
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWordCount(t *testing.T) {
+	text := "the cat and the dog and the bird"
+	c := FromSeq(slices.Values(strings.Fields(text)))
+	if c.Total() != 8 || c.Len() != 5 {
+		t.Errorf("Total=%d Len=%d", c.Total(), c.Len())
+	}
+	if c.Count("the") != 3 || c.Count("fish") != 0 {
+		t.Errorf("bad counts")
+	}
+	got := c.MostCommon(2)
+	if want := []Entry[string]{{"the", 3}, {"and", 2}}; !slices.Equal(got, want) {
+		t.Errorf("MostCommon(2)=%v, want %v", got, want)
+	}
+	if got := c.MostCommon(100); len(got) != 5 {
+		t.Errorf("MostCommon(100) returned %d entries", len(got))
+	}
+	if c.MostCommon(0) != nil {
+		t.Errorf("MostCommon(0) returned entries")
+	}
+
+	want := []Entry[string]{{"the", 3}, {"and", 2}, {"bird", 1}, {"cat", 1}, {"dog", 1}}
+	if got := c.Sorted(strings.Compare); !slices.Equal(got, want) {
+		t.Errorf("Sorted=%v", got)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := FromSeq(slices.Values([]string{"x", "x", "x", "y", "z"}))
+	b := FromSeq(slices.Values([]string{"x", "y", "y", "w"}))
+
+	sum := a.Clone()
+	sum.AddCounter(b)
+	want := []Entry[string]{{"x", 4}, {"y", 3}, {"w", 1}, {"z", 1}}
+	if got := sum.Sorted(strings.Compare); !slices.Equal(got, want) {
+		t.Errorf("sum=%v", got)
+	}
+	if sum.Total() != 9 {
+		t.Errorf("sum Total=%d", sum.Total())
+	}
+
+	diff := a.Clone()
+	diff.SubtractCounter(b)
+	want = []Entry[string]{{"x", 2}, {"z", 1}}
+	if got := diff.Sorted(strings.Compare); !slices.Equal(got, want) {
+		t.Errorf("diff=%v", got)
+	}
+	if diff.Total() != 3 {
+		t.Errorf("diff Total=%d", diff.Total())
+	}
+
+	// a itself is unchanged.
+	if a.Total() != 5 || a.Count("y") != 1 {
+		t.Errorf("Clone shares state")
+	}
+	if n := a.Delete("x"); n != 3 || a.Total() != 2 {
+		t.Errorf("Delete=%d, Total=%d", n, a.Total())
+	}
+}
+
+func TestMostCommonRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for range 50 {
+		c := New[int]()
+		mirror := make(map[int]int)
+		total := 0
+		for range 500 {
+			v, n := rnd.IntN(60), rnd.IntN(10)-3
+			c.AddN(v, n)
+			mirror[v] = max(mirror[v]+n, 0)
+			if mirror[v] == 0 {
+				delete(mirror, v)
+			}
+		}
+		var counts []int
+		for v, n := range mirror {
+			if c.Count(v) != n {
+				t.Fatalf("Count(%d)=%d, want %d", v, c.Count(v), n)
+			}
+			counts = append(counts, n)
+			total += n
+		}
+		if c.Total() != total || c.Len() != len(mirror) {
+			t.Fatalf("Total=%d Len=%d, want %d, %d", c.Total(), c.Len(), total, len(mirror))
+		}
+
+		// With ties ordered arbitrarily, only the counts are deterministic.
+		slices.SortFunc(counts, func(a, b int) int { return cmp.Compare(b, a) })
+		n := rnd.IntN(len(counts) + 5)
+		var got []int
+		for _, e := range c.MostCommon(n) {
+			if mirror[e.Value] != e.Count {
+				t.Fatalf("entry %v has wrong count", e)
+			}
+			got = append(got, e.Count)
+		}
+		if want := counts[:min(n, len(counts))]; !slices.Equal(got, want) {
+			t.Fatalf("MostCommon(%d) counts=%v, want %v", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package countmin implements the Count-Min sketch for estimating the
+// frequencies of elements in a stream.
+package countmin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+// Mode determines how a Sketch updates its counters.
+type Mode int
+
+const (
+	// Standard mode increments every counter an element maps to.
+	Standard Mode = iota
+
+	// Conservative mode only increments the counters an element maps to as
+	// much as needed for its estimate to grow by the added count. This
+	// reduces the overestimation error considerably for skewed streams, at
+	// the cost of slightly slower updates.
+	Conservative
+)
+
+// Sketch is a Count-Min sketch: a compact summary of a stream of elements
+// that estimates the number of occurrences of each element. Estimates never
+// undercount; with width w, an estimate exceeds the true count by more than
+// e/w of the stream's total count with probability at most e^-depth.
+//
+// The sketch is a depth x width matrix of counters; every row has its own
+// hash function mapping elements to a counter. Adding an element increments
+// its counter in each row, and the estimate for an element is the minimum
+// of its counters.
+//
+// Sketches with the same dimensions can be merged, so a stream can be
+// summarized by several workers in parallel. Elements are byte slices or
+// strings, hashed with the same function as [bloom.Hash].
+//
+// Create sketches with [New] or [NewWithError].
+//
+// [bloom.Hash]: https://pkg.go.dev/github.com/eliben/gogl/bloom#Hash
+type Sketch struct {
+	mode   Mode
+	width  uint64
+	depth  int
+	counts []uint64 // row-major, depth rows of width counters
+	total  uint64
+}
+
+// New creates a new, empty Sketch with the given dimensions and mode.
+func New(width, depth int, mode Mode) *Sketch {
+	if width <= 0 || depth <= 0 {
+		panic(fmt.Sprintf("countmin: invalid dimensions width=%d, depth=%d", width, depth))
+	}
+	return &Sketch{
+		mode:   mode,
+		width:  uint64(width),
+		depth:  depth,
+		counts: make([]uint64, width*depth),
+	}
+}
+
+// NewWithError creates a new, empty Sketch whose estimates exceed the true
+// counts by at most epsilon times the total count, with probability at
+// least 1-delta.
+func NewWithError(epsilon, delta float64, mode Mode) *Sketch {
+	if epsilon <= 0 || epsilon >= 1 || delta <= 0 || delta >= 1 {
+		panic(fmt.Sprintf("countmin: invalid error bounds epsilon=%v, delta=%v", epsilon, delta))
+	}
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	return New(width, depth, mode)
+}
+
+// Width returns the number of counters in each row of the sketch.
+func (s *Sketch) Width() int {
+	return int(s.width)
+}
+
+// Depth returns the number of rows in the sketch.
+func (s *Sketch) Depth() int {
+	return s.depth
+}
+
+// Total returns the sum of all counts added to the sketch.
+func (s *Sketch) Total() uint64 {
+	return s.total
+}
+
+// Add adds n occurrences of data to the sketch.
+func (s *Sketch) Add(data []byte, n uint64) {
+	s.AddHash(hash.Bytes(data), n)
+}
+
+// AddString adds n occurrences of str to the sketch.
+func (s *Sketch) AddString(str string, n uint64) {
+	s.AddHash(hash.String(str), n)
+}
+
+// AddHash adds n occurrences of an element with the 64-bit hash h to the
+// sketch. It can be used with a custom hash function, which must spread its
+// output over all 64 bits.
+func (s *Sketch) AddHash(h uint64, n uint64) {
+	s.total += n
+	if s.mode == Conservative {
+		target := s.CountHash(h) + n
+		for row := range s.depth {
+			c := &s.counts[s.index(h, row)]
+			*c = max(*c, target)
+		}
+		return
+	}
+	for row := range s.depth {
+		s.counts[s.index(h, row)] += n
+	}
+}
+
+// Count returns the estimated number of occurrences of data in the sketch.
+func (s *Sketch) Count(data []byte) uint64 {
+	return s.CountHash(hash.Bytes(data))
+}
+
+// CountString returns the estimated number of occurrences of str in the
+// sketch.
+func (s *Sketch) CountString(str string) uint64 {
+	return s.CountHash(hash.String(str))
+}
+
+// CountHash returns the estimated number of occurrences of an element with
+// the 64-bit hash h in the sketch.
+func (s *Sketch) CountHash(h uint64) uint64 {
+	est := uint64(math.MaxUint64)
+	for row := range s.depth {
+		est = min(est, s.counts[s.index(h, row)])
+	}
+	return est
+}
+
+// index returns the index in counts of the counter for the hash h in row.
+// Rows use the double hashing scheme of Kirsch and Mitzenmacher, like
+// Bloom filters do.
+func (s *Sketch) index(h uint64, row int) int {
+	h2 := hash.Mix(h) | 1
+	col, _ := bits.Mul64(h+uint64(row)*h2, s.width)
+	return row*int(s.width) + int(col)
+}
+
+// ErrIncompatible is returned when merging sketches with different
+// dimensions.
+var ErrIncompatible = errors.New("countmin: sketches have different dimensions")
+
+// Merge adds the counts of other to s, so that s summarizes both streams.
+// The sketches must have the same dimensions; otherwise Merge returns
+// ErrIncompatible and leaves s unchanged. Merging conservative sketches
+// is allowed: estimates still never undercount, though the merged sketch
+// is less accurate than one built from both streams.
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.width != other.width || s.depth != other.depth {
+		return ErrIncompatible
+	}
+	for i, c := range other.counts {
+		s.counts[i] += c
+	}
+	s.total += other.total
+	return nil
+}
+
+// Clear resets all the counts in the sketch to zero.
+func (s *Sketch) Clear() {
+	clear(s.counts)
+	s.total = 0
+}
@@ -0,0 +1,135 @@
+package countmin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+// zipfStream returns a skewed stream of n elements, with the exact count
+// of each.
+func zipfStream(rnd *rand.Rand, n int) ([]string, map[string]uint64) {
+	z := rand.NewZipf(rnd, 1.1, 1, 100000)
+	stream := make([]string, n)
+	counts := make(map[string]uint64)
+	for i := range stream {
+		stream[i] = fmt.Sprint(z.Uint64())
+		counts[stream[i]]++
+	}
+	return stream, counts
+}
+
+func TestDimensions(t *testing.T) {
+	s := NewWithError(0.01, 0.01, Standard)
+	if s.Width() != 272 || s.Depth() != 5 {
+		t.Errorf("NewWithError(0.01, 0.01): width=%d depth=%d", s.Width(), s.Depth())
+	}
+}
+
+func TestErrorBound(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const epsilon, delta = 0.001, 0.01
+	stream, counts := zipfStream(rnd, 200000)
+	standard := NewWithError(epsilon, delta, Standard)
+	conservative := NewWithError(epsilon, delta, Conservative)
+	for _, e := range stream {
+		standard.AddString(e, 1)
+		conservative.Add([]byte(e), 1)
+	}
+	if standard.Total() != uint64(len(stream)) || conservative.Total() != uint64(len(stream)) {
+		t.Errorf("Total=%d,%d", standard.Total(), conservative.Total())
+	}
+
+	bound := uint64(epsilon * float64(len(stream)))
+	var exceeded int
+	var errStandard, errConservative uint64
+	for e, c := range counts {
+		est := standard.CountString(e)
+		cons := conservative.Count([]byte(e))
+		if est < c || cons < c {
+			t.Fatalf("%s: estimates %d,%d below count %d", e, est, cons, c)
+		}
+		if cons > est {
+			t.Fatalf("%s: conservative estimate %d above standard %d", e, cons, est)
+		}
+		if est-c > bound {
+			exceeded++
+		}
+		errStandard += est - c
+		errConservative += cons - c
+	}
+	if rate := float64(exceeded) / float64(len(counts)); rate > delta {
+		t.Errorf("%v of estimates exceed the error bound", rate)
+	}
+	if errConservative >= errStandard {
+		t.Errorf("conservative error %d not below standard error %d", errConservative, errStandard)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	// Merging sketches of parts of a stream gives exactly the sketch of the
+	// whole stream.
+	stream, counts := zipfStream(rnd, 30000)
+	whole := New(500, 4, Standard)
+	parts := []*Sketch{New(500, 4, Standard), New(500, 4, Standard), New(500, 4, Standard)}
+	for i, e := range stream {
+		whole.AddString(e, 1)
+		parts[i%3].AddString(e, 1)
+	}
+	merged := New(500, 4, Standard)
+	for _, p := range parts {
+		if err := merged.Merge(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if merged.Total() != whole.Total() {
+		t.Errorf("merged Total=%d, want %d", merged.Total(), whole.Total())
+	}
+	for e := range counts {
+		if merged.CountString(e) != whole.CountString(e) {
+			t.Fatalf("%s: merged estimate %d, want %d", e, merged.CountString(e), whole.CountString(e))
+		}
+	}
+
+	if err := merged.Merge(New(500, 5, Standard)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Merge of incompatible sketches: %v", err)
+	}
+	merged.Clear()
+	if merged.Total() != 0 || merged.CountString(stream[0]) != 0 {
+		t.Errorf("Clear didn't reset the sketch")
+	}
+}
+
+func TestAddN(t *testing.T) {
+	for _, mode := range []Mode{Standard, Conservative} {
+		s := New(100, 3, mode)
+		s.AddString("a", 5)
+		s.AddString("b", 2)
+		s.AddString("a", 3)
+		if s.CountString("a") < 8 || s.CountString("b") < 2 || s.Total() != 10 {
+			t.Errorf("mode %d: a=%d b=%d total=%d", mode, s.CountString("a"), s.CountString("b"), s.Total())
+		}
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	for _, mode := range []Mode{Standard, Conservative} {
+		b.Run(fmt.Sprint(mode), func(b *testing.B) {
+			s := NewWithError(0.0001, 0.001, mode)
+			key := []byte("some moderately long key")
+			for i := range b.N {
+				key[0] = byte(i)
+				s.Add(key, 1)
+			}
+		})
+	}
+}
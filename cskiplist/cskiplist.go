@@ -0,0 +1,331 @@
+// Package cskiplist implements an ordered map backed by a skip list, safe
+// for concurrent use.
+package cskiplist
+
+import (
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// maxLevel is the maximal number of levels in a skip list; with p=1/4 it
+// supports up to 4^maxLevel elements efficiently.
+const maxLevel = 24
+
+// Map is an ordered map from keys of type K to values of type V, safe for
+// concurrent use by multiple goroutines. It's the "lazy" concurrent skip
+// list of Herlihy, Lev, Luchangco and Shavit: lookups and iteration take no
+// locks at all, while Insert and Delete lock just the few nodes around the
+// key they modify, so writers to different parts of the map don't contend.
+//
+// Iteration is weakly consistent: it never yields an element twice or out
+// of order, and it yields every element present for the whole duration of
+// the iteration, but it may or may not yield elements inserted or deleted
+// concurrently.
+//
+// Create maps with [New].
+type Map[K, V any] struct {
+	cmp    func(K, K) int
+	head   *node[K, V]
+	level  atomic.Int32
+	length atomic.Int64
+}
+
+// node is an element of the skip list; next[i] is the next node in the
+// level-i list. A node is logically in the map once it's fully linked into
+// all its levels, and until it's marked for deletion, after which it's
+// unlinked from the levels top to bottom. Nodes are never modified after
+// they're unlinked, so concurrent readers that reach them can still follow
+// their next pointers.
+type node[K, V any] struct {
+	key   K
+	value atomic.Pointer[V]
+	next  []atomic.Pointer[node[K, V]]
+
+	// mu protects the node's next pointers and marking it deleted.
+	mu          sync.Mutex
+	marked      atomic.Bool
+	fullyLinked atomic.Bool
+}
+
+// New creates a new, empty map with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K, V any](cmp func(K, K) int) *Map[K, V] {
+	m := &Map[K, V]{
+		cmp:  cmp,
+		head: &node[K, V]{next: make([]atomic.Pointer[node[K, V]], maxLevel)},
+	}
+	m.level.Store(1)
+	return m
+}
+
+// Len returns the number of elements in the map. With concurrent
+// modifications in progress, the result is only an approximation.
+func (m *Map[K, V]) Len() int {
+	return int(m.length.Load())
+}
+
+// Get looks for key in the map. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (m *Map[K, V]) Get(key K) (v V, ok bool) {
+	n := m.findGreaterOrEqual(key)
+	if n != nil && m.cmp(n.key, key) == 0 && n.live() {
+		return *n.value.Load(), true
+	}
+	return v, false
+}
+
+// Insert inserts key with the given value into the map. If key already
+// exists, its value is replaced.
+func (m *Map[K, V]) Insert(key K, value V) {
+	m.insert(key, value, true)
+}
+
+// InsertIfAbsent inserts key with the given value into the map if key isn't
+// already in it. It returns the value associated with key in the map, and
+// whether it was inserted.
+func (m *Map[K, V]) InsertIfAbsent(key K, value V) (actual V, inserted bool) {
+	return m.insert(key, value, false)
+}
+
+func (m *Map[K, V]) insert(key K, value V, replace bool) (V, bool) {
+	level := randomLevel()
+	m.raiseLevel(level)
+	var preds, succs [maxLevel]*node[K, V]
+	for {
+		if found := m.find(key, &preds, &succs); found >= 0 {
+			n := succs[found]
+			if n.marked.Load() {
+				// Being deleted; wait for it to be unlinked.
+				continue
+			}
+			for !n.fullyLinked.Load() {
+				// Being inserted; the insertion completes shortly.
+				runtime.Gosched()
+			}
+			if !replace {
+				return *n.value.Load(), false
+			}
+			// Lock the node so that the update doesn't get lost in a
+			// concurrent deletion.
+			n.mu.Lock()
+			if n.marked.Load() {
+				n.mu.Unlock()
+				continue
+			}
+			n.value.Store(&value)
+			n.mu.Unlock()
+			return value, false
+		}
+
+		unlock, ok := lockPreds(level, &preds, func(i int, pred *node[K, V]) bool {
+			succ := succs[i]
+			return !pred.marked.Load() && (succ == nil || !succ.marked.Load()) &&
+				pred.next[i].Load() == succ
+		})
+		if !ok {
+			continue
+		}
+		n := &node[K, V]{key: key, next: make([]atomic.Pointer[node[K, V]], level)}
+		n.value.Store(&value)
+		for i := range level {
+			n.next[i].Store(succs[i])
+		}
+		for i := range level {
+			preds[i].next[i].Store(n)
+		}
+		n.fullyLinked.Store(true)
+		unlock()
+		m.length.Add(1)
+		return value, true
+	}
+}
+
+// Delete deletes key and its value from the map. It returns true if the
+// key was found and deleted, false otherwise.
+func (m *Map[K, V]) Delete(key K) bool {
+	var preds, succs [maxLevel]*node[K, V]
+	var victim *node[K, V]
+	for {
+		found := m.find(key, &preds, &succs)
+		if victim == nil {
+			if found < 0 {
+				return false
+			}
+			n := succs[found]
+			if n.marked.Load() || !n.fullyLinked.Load() {
+				// Already deleted, or not inserted yet.
+				return false
+			}
+			if len(n.next)-1 != found {
+				// find raced with the insertion, seeing the node only in
+				// some of its levels.
+				continue
+			}
+			n.mu.Lock()
+			if n.marked.Load() {
+				n.mu.Unlock()
+				return false
+			}
+			// Marking the node is the point at which it's deleted; from now
+			// on this call is responsible for unlinking it.
+			n.marked.Store(true)
+			victim = n
+		}
+
+		level := len(victim.next)
+		unlock, ok := lockPreds(level, &preds, func(i int, pred *node[K, V]) bool {
+			return !pred.marked.Load() && pred.next[i].Load() == victim
+		})
+		if !ok {
+			continue
+		}
+		for i := level - 1; i >= 0; i-- {
+			preds[i].next[i].Store(victim.next[i].Load())
+		}
+		unlock()
+		victim.mu.Unlock()
+		m.length.Add(-1)
+		return true
+	}
+}
+
+// lockPreds locks the distinct nodes in preds[:level], and checks valid
+// for each level. If they're all valid, it returns a function to unlock
+// the nodes and true; otherwise, it unlocks them and returns false.
+func lockPreds[K, V any](level int, preds *[maxLevel]*node[K, V], valid func(int, *node[K, V]) bool) (func(), bool) {
+	// Nodes are locked in order of decreasing keys, which is the order
+	// preds are in going up the levels, so there are no deadlocks.
+	locked := 0
+	unlock := func() {
+		var prev *node[K, V]
+		for i := range locked {
+			if preds[i] != prev {
+				preds[i].mu.Unlock()
+				prev = preds[i]
+			}
+		}
+	}
+	var prev *node[K, V]
+	for i := range level {
+		pred := preds[i]
+		if pred != prev {
+			pred.mu.Lock()
+			prev = pred
+		}
+		locked = i + 1
+		if !valid(i, pred) {
+			unlock()
+			return nil, false
+		}
+	}
+	return unlock, true
+}
+
+// Ceiling returns the smallest key in the map that is >= key, with its
+// value. It returns ok=false if there's no such key.
+func (m *Map[K, V]) Ceiling(key K) (k K, v V, ok bool) {
+	for n := m.findGreaterOrEqual(key); n != nil; n = n.next[0].Load() {
+		if n.live() {
+			return n.key, *n.value.Load(), true
+		}
+	}
+	return k, v, false
+}
+
+// Min returns the smallest key in the map with its value. It returns
+// ok=false if the map is empty.
+func (m *Map[K, V]) Min() (k K, v V, ok bool) {
+	for n := m.head.next[0].Load(); n != nil; n = n.next[0].Load() {
+		if n.live() {
+			return n.key, *n.value.Load(), true
+		}
+	}
+	return k, v, false
+}
+
+// All returns an iterator over all key, value pairs in the map, in
+// ascending order of keys. The map may be modified during iteration; see
+// [Map] for the consistency guarantees.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return m.from(m.head.next[0].Load(), nil)
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys. The map may be modified during
+// iteration; see [Map] for the consistency guarantees.
+func (m *Map[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.from(m.findGreaterOrEqual(lo), &hi)(yield)
+	}
+}
+
+// from returns an iterator over the live nodes in the level-0 list starting
+// at n, up to but not including hi if it's not nil.
+func (m *Map[K, V]) from(n *node[K, V], hi *K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for ; n != nil && (hi == nil || m.cmp(n.key, *hi) < 0); n = n.next[0].Load() {
+			if n.live() && !yield(n.key, *n.value.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// live reports whether n is logically in the map.
+func (n *node[K, V]) live() bool {
+	return n.fullyLinked.Load() && !n.marked.Load()
+}
+
+// find looks for key, setting preds[i] to the last node in level i with a
+// key < key, and succs[i] to the node following it, for every level in use.
+// It returns the highest level at which a node with key was found, or -1.
+func (m *Map[K, V]) find(key K, preds, succs *[maxLevel]*node[K, V]) int {
+	found := -1
+	pred := m.head
+	for i := int(m.level.Load()) - 1; i >= 0; i-- {
+		curr := pred.next[i].Load()
+		for curr != nil && m.cmp(curr.key, key) < 0 {
+			pred, curr = curr, curr.next[i].Load()
+		}
+		if found < 0 && curr != nil && m.cmp(curr.key, key) == 0 {
+			found = i
+		}
+		preds[i], succs[i] = pred, curr
+	}
+	return found
+}
+
+// findGreaterOrEqual returns the first node in the level-0 list with a key
+// >= key, or nil if there's no such node. The node may not be live.
+func (m *Map[K, V]) findGreaterOrEqual(key K) *node[K, V] {
+	n := m.head
+	for i := int(m.level.Load()) - 1; i >= 0; i-- {
+		for next := n.next[i].Load(); next != nil && m.cmp(next.key, key) < 0; next = n.next[i].Load() {
+			n = next
+		}
+	}
+	return n.next[0].Load()
+}
+
+// raiseLevel makes sure the number of levels in use is at least level.
+func (m *Map[K, V]) raiseLevel(level int) {
+	for {
+		cur := m.level.Load()
+		if int(cur) >= level || m.level.CompareAndSwap(cur, int32(level)) {
+			return
+		}
+	}
+}
+
+// randomLevel returns a random level for a new node: 1 with probability 3/4,
+// 2 with probability 3/16, and so on.
+func randomLevel() int {
+	// Each pair of random bits being zero has probability 1/4.
+	level := 1 + bits.TrailingZeros64(rand.Uint64())/2
+	return min(level, maxLevel)
+}
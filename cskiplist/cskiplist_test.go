@@ -0,0 +1,243 @@
+package cskiplist
+
+import (
+	"cmp"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func checkMap(t *testing.T, m *Map[int, int], want map[int]int) {
+	t.Helper()
+	if m.Len() != len(want) {
+		t.Errorf("Len=%d, want %d", m.Len(), len(want))
+	}
+	keys := slices.Sorted(maps.Keys(want))
+	var gotKeys []int
+	for k, v := range m.All() {
+		if want[k] != v {
+			t.Fatalf("All yielded %d=%d, want %d", k, v, want[k])
+		}
+		gotKeys = append(gotKeys, k)
+	}
+	if !slices.Equal(gotKeys, keys) {
+		t.Fatalf("All keys=%v, want %v", gotKeys, keys)
+	}
+	for k, v := range want {
+		if got, ok := m.Get(k); !ok || got != v {
+			t.Fatalf("Get(%d)=%d,%v, want %d", k, got, ok, v)
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	m := New[int, int](cmp.Compare[int])
+	if _, _, ok := m.Min(); ok {
+		t.Errorf("Min of empty map")
+	}
+	for _, k := range []int{5, 2, 8, 1, 9} {
+		m.Insert(k, k*10)
+	}
+	m.Insert(8, 88)
+	checkMap(t, m, map[int]int{1: 10, 2: 20, 5: 50, 8: 88, 9: 90})
+
+	if v, ok := m.InsertIfAbsent(5, 0); ok || v != 50 {
+		t.Errorf("InsertIfAbsent(5)=%d,%v", v, ok)
+	}
+	if v, ok := m.InsertIfAbsent(6, 60); !ok || v != 60 {
+		t.Errorf("InsertIfAbsent(6)=%d,%v", v, ok)
+	}
+	if !m.Delete(2) || m.Delete(2) || m.Delete(3) {
+		t.Errorf("Delete mismatch")
+	}
+	checkMap(t, m, map[int]int{1: 10, 5: 50, 6: 60, 8: 88, 9: 90})
+
+	if k, v, ok := m.Ceiling(7); !ok || k != 8 || v != 88 {
+		t.Errorf("Ceiling(7)=%d,%d,%v", k, v, ok)
+	}
+	if _, _, ok := m.Ceiling(10); ok {
+		t.Errorf("Ceiling(10) found")
+	}
+	if k, _, ok := m.Min(); !ok || k != 1 {
+		t.Errorf("Min()=%d,%v", k, ok)
+	}
+	var got []int
+	for k := range m.Range(5, 9) {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []int{5, 6, 8}) {
+		t.Errorf("Range(5, 9)=%v", got)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	m := New[int, int](cmp.Compare[int])
+	mirror := make(map[int]int)
+	for i := range 20000 {
+		k := rnd.IntN(500)
+		switch rnd.IntN(3) {
+		case 0:
+			_, had := mirror[k]
+			if m.Delete(k) != had {
+				t.Fatalf("Delete(%d) mismatch", k)
+			}
+			delete(mirror, k)
+		default:
+			m.Insert(k, i)
+			mirror[k] = i
+		}
+		if i%2000 == 0 {
+			checkMap(t, m, mirror)
+		}
+	}
+	checkMap(t, m, mirror)
+}
+
+func TestConcurrentDisjoint(t *testing.T) {
+	// Each goroutine inserts and deletes its own keys, interleaved with the
+	// other goroutines' keys; the end result is deterministic.
+	const goroutines = 8
+	const n = 2000
+	m := New[int, int](cmp.Compare[int])
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				m.Insert(i*goroutines+g, g)
+			}
+			for i := 0; i < n; i += 2 {
+				if !m.Delete(i*goroutines + g) {
+					t.Errorf("Delete(%d) failed", i*goroutines+g)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := make(map[int]int)
+	for g := range goroutines {
+		for i := 1; i < n; i += 2 {
+			want[i*goroutines+g] = g
+		}
+	}
+	checkMap(t, m, want)
+}
+
+func TestConcurrentContended(t *testing.T) {
+	// Goroutines race to insert and delete the same few keys. Each
+	// successful InsertIfAbsent must be matched by at most one successful
+	// Delete, and whatever remains in the map accounts for the rest.
+	const goroutines = 8
+	const keys = 16
+	m := New[int, int](cmp.Compare[int])
+	var wg sync.WaitGroup
+	inserted := make([][keys]int, goroutines)
+	deleted := make([][keys]int, goroutines)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rnd := rand.New(rand.NewPCG(uint64(g), 1))
+			for range 5000 {
+				k := rnd.IntN(keys)
+				if rnd.IntN(2) == 0 {
+					if _, ok := m.InsertIfAbsent(k, g); ok {
+						inserted[g][k]++
+					}
+				} else if m.Delete(k) {
+					deleted[g][k]++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k := range keys {
+		balance := 0
+		for g := range goroutines {
+			balance += inserted[g][k] - deleted[g][k]
+		}
+		_, present := m.Get(k)
+		if present && balance != 1 || !present && balance != 0 {
+			t.Errorf("key %d: present=%v, inserts-deletes=%d", k, present, balance)
+		}
+	}
+}
+
+func TestIterateWhileWriting(t *testing.T) {
+	// Even keys are stable and must always be yielded; odd keys are
+	// inserted and deleted concurrently. Iteration must stay sorted.
+	m := New[int, int](cmp.Compare[int])
+	for k := 0; k < 1000; k += 2 {
+		m.Insert(k, k)
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rnd := rand.New(rand.NewPCG(uint64(g), 2))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				k := 2*rnd.IntN(500) + 1
+				if rnd.IntN(2) == 0 {
+					m.Insert(k, k)
+				} else {
+					m.Delete(k)
+				}
+			}
+		}()
+	}
+
+	for range 200 {
+		prev, evens := -1, 0
+		for k, v := range m.Range(100, 900) {
+			if k <= prev || k != v {
+				t.Fatalf("yielded %d=%d after %d", k, v, prev)
+			}
+			prev = k
+			if k%2 == 0 {
+				evens++
+			}
+		}
+		if evens != 400 {
+			t.Fatalf("yielded %d stable keys, want 400", evens)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func BenchmarkParallel(b *testing.B) {
+	const n = 1 << 16
+	m := New[int, int](cmp.Compare[int])
+	for k := range n {
+		m.Insert(k, k)
+	}
+	// 90% reads, 10% writes.
+	b.RunParallel(func(pb *testing.PB) {
+		i := rand.IntN(n)
+		for pb.Next() {
+			i = (i + 7919) % n
+			if i%10 == 0 {
+				m.Insert(i, i)
+			} else {
+				m.Get(i)
+			}
+		}
+	})
+}
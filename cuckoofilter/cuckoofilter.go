@@ -0,0 +1,314 @@
+// Package cuckoofilter implements cuckoo filters.
+package cuckoofilter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+const (
+	// bucketSize is the number of fingerprints in a bucket.
+	bucketSize = 4
+
+	// maxKicks is the number of fingerprints an insertion relocates before
+	// giving up and declaring the filter full.
+	maxKicks = 500
+)
+
+// Filter is a cuckoo filter: like a Bloom filter, it's a compact
+// probabilistic set that can tell that an element is definitely not in the
+// set, or that it may be in it; unlike a Bloom filter, it supports deleting
+// elements.
+//
+// The filter stores a 16-bit fingerprint of each element in one of two
+// candidate buckets of 4 slots. The second bucket is computed from the
+// first and the fingerprint alone, so that fingerprints can be relocated
+// between their buckets (as in cuckoo hashing) to make room for new ones.
+// With all buckets full, the false positive rate is about 0.01%.
+//
+// Elements are byte slices or strings, hashed with the same function as
+// [bloom.Hash] so that serialized filters can be used by other processes.
+//
+// Create filters with [New].
+//
+// [bloom.Hash]: https://pkg.go.dev/github.com/eliben/gogl/bloom#Hash
+type Filter struct {
+	buckets [][bucketSize]uint16
+	mask    uint64
+	count   int
+
+	// victim holds a fingerprint evicted by an insertion that failed to
+	// find room for it, with its bucket index; fp is 0 if there's none.
+	// Keeping it means a failed Add doesn't lose an existing element.
+	victim struct {
+		fp    uint16
+		index uint64
+	}
+}
+
+// New creates a new, empty Filter with room for at least capacity elements.
+// Insertions may start failing when the filter is about 95% full.
+func New(capacity int) *Filter {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("cuckoofilter: invalid capacity %d", capacity))
+	}
+	n := max(1, 1<<bits.Len(uint((capacity+bucketSize-1)/bucketSize-1)))
+	return &Filter{buckets: make([][bucketSize]uint16, n), mask: uint64(n - 1)}
+}
+
+// Len returns the number of elements in the filter.
+func (f *Filter) Len() int {
+	return f.count
+}
+
+// Cap returns the number of fingerprint slots in the filter.
+func (f *Filter) Cap() int {
+	return len(f.buckets) * bucketSize
+}
+
+// LoadFactor returns the fraction of the filter's slots that are in use.
+func (f *Filter) LoadFactor() float64 {
+	return float64(f.count) / float64(f.Cap())
+}
+
+// Add adds data to the filter. It returns false if the filter is too full
+// to add it. The same element may be added at most 8 times; it must be
+// deleted as many times.
+func (f *Filter) Add(data []byte) bool {
+	return f.AddHash(hash.Bytes(data))
+}
+
+// AddString adds s to the filter, like [Filter.Add].
+func (f *Filter) AddString(s string) bool {
+	return f.AddHash(hash.String(s))
+}
+
+// AddHash adds an element with the 64-bit hash h to the filter, like
+// [Filter.Add]. It can be used with a custom hash function, which must
+// spread its output over all 64 bits.
+func (f *Filter) AddHash(h uint64) bool {
+	if f.victim.fp != 0 {
+		return false
+	}
+	fp, i := f.locate(h)
+	f.insert(fp, i)
+	f.count++
+	return true
+}
+
+// insert stores the fingerprint fp in bucket i or its alternate. If both
+// are full, it evicts a random fingerprint and moves it to its other
+// bucket, and so on until a fingerprint finds a free slot. If that takes
+// too many moves, the last fingerprint evicted becomes the victim.
+func (f *Filter) insert(fp uint16, i uint64) {
+	if f.insertInto(i, fp) {
+		return
+	}
+	i = f.altIndex(i, fp)
+	if f.insertInto(i, fp) {
+		return
+	}
+	for range maxKicks {
+		slot := rand.IntN(bucketSize)
+		fp, f.buckets[i][slot] = f.buckets[i][slot], fp
+		i = f.altIndex(i, fp)
+		if f.insertInto(i, fp) {
+			return
+		}
+	}
+	f.victim.fp, f.victim.index = fp, i
+}
+
+// MaybeContains reports whether data may be in the filter. If it returns
+// false, data definitely isn't.
+func (f *Filter) MaybeContains(data []byte) bool {
+	return f.MaybeContainsHash(hash.Bytes(data))
+}
+
+// MaybeContainsString reports whether s may be in the filter, like
+// [Filter.MaybeContains].
+func (f *Filter) MaybeContainsString(s string) bool {
+	return f.MaybeContainsHash(hash.String(s))
+}
+
+// MaybeContainsHash reports whether an element with the 64-bit hash h may
+// be in the filter, like [Filter.MaybeContains].
+func (f *Filter) MaybeContainsHash(h uint64) bool {
+	fp, i1 := f.locate(h)
+	i2 := f.altIndex(i1, fp)
+	if f.victim.fp == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		return true
+	}
+	return f.find(i1, fp) >= 0 || f.find(i2, fp) >= 0
+}
+
+// Delete deletes data from the filter, and reports whether it may have
+// been in it. Only delete elements that were added: deleting another
+// element with the same fingerprint and buckets as one that was added
+// deletes the latter.
+func (f *Filter) Delete(data []byte) bool {
+	return f.DeleteHash(hash.Bytes(data))
+}
+
+// DeleteString deletes s from the filter, like [Filter.Delete].
+func (f *Filter) DeleteString(s string) bool {
+	return f.DeleteHash(hash.String(s))
+}
+
+// DeleteHash deletes an element with the 64-bit hash h from the filter,
+// like [Filter.Delete].
+func (f *Filter) DeleteHash(h uint64) bool {
+	fp, i1 := f.locate(h)
+	i2 := f.altIndex(i1, fp)
+	if f.victim.fp == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		f.victim.fp = 0
+		f.count--
+		return true
+	}
+	for _, i := range []uint64{i1, i2} {
+		if slot := f.find(i, fp); slot >= 0 {
+			f.buckets[i][slot] = 0
+			f.count--
+			f.reinsertVictim()
+			return true
+		}
+	}
+	return false
+}
+
+// reinsertVictim tries to move the victim into the room freed by a
+// deletion.
+func (f *Filter) reinsertVictim() {
+	if f.victim.fp == 0 {
+		return
+	}
+	fp, i := f.victim.fp, f.victim.index
+	f.victim.fp = 0
+	f.insert(fp, i)
+}
+
+// Clear removes all the elements from the filter.
+func (f *Filter) Clear() {
+	clear(f.buckets)
+	f.count = 0
+	f.victim.fp = 0
+}
+
+// locate returns the fingerprint and primary bucket index for the hash h.
+func (f *Filter) locate(h uint64) (uint16, uint64) {
+	// The fingerprint comes from the top bits and the index from the low
+	// ones, so they're independent. 0 marks empty slots, so it's not a
+	// valid fingerprint.
+	fp := uint16(h >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	return fp, h & f.mask
+}
+
+// altIndex returns the other bucket index for the fingerprint fp stored in
+// bucket i. It's an involution: altIndex(altIndex(i, fp), fp) == i.
+func (f *Filter) altIndex(i uint64, fp uint16) uint64 {
+	// Multiplying spreads the fingerprint's bits over the index, so that
+	// similar fingerprints don't map to nearby buckets.
+	return (i ^ uint64(fp)*0x5bd1e995) & f.mask
+}
+
+func (f *Filter) insertInto(i uint64, fp uint16) bool {
+	if slot := f.find(i, 0); slot >= 0 {
+		f.buckets[i][slot] = fp
+		return true
+	}
+	return false
+}
+
+// find returns the slot holding fp in bucket i, or -1.
+func (f *Filter) find(i uint64, fp uint16) int {
+	for slot, v := range f.buckets[i] {
+		if v == fp {
+			return slot
+		}
+	}
+	return -1
+}
+
+// The binary encoding of a filter is a header followed by the buckets'
+// fingerprints as little-endian 16-bit words:
+//
+//	"GCKF" | version u32 | count u64 | number of buckets u64 |
+//	victim fingerprint u16 | victim bucket u64 | fingerprints
+
+const (
+	encodingMagic      = "GCKF"
+	encodingVersion    = 1
+	encodingHeaderSize = 34
+)
+
+// ErrInvalidData is returned when decoding data that isn't a valid
+// encoding of a filter.
+var ErrInvalidData = errors.New("cuckoofilter: invalid filter data")
+
+// MarshalBinary encodes the filter into a binary form; it implements
+// [encoding.BinaryMarshaler].
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, encodingHeaderSize, encodingHeaderSize+2*f.Cap())
+	copy(buf, encodingMagic)
+	binary.LittleEndian.PutUint32(buf[4:], encodingVersion)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(f.count))
+	binary.LittleEndian.PutUint64(buf[16:], uint64(len(f.buckets)))
+	binary.LittleEndian.PutUint16(buf[24:], f.victim.fp)
+	binary.LittleEndian.PutUint64(buf[26:], f.victim.index)
+	for _, b := range f.buckets {
+		for _, fp := range b {
+			buf = binary.LittleEndian.AppendUint16(buf, fp)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary replaces the contents of f by decoding data produced by
+// [Filter.MarshalBinary]; it implements [encoding.BinaryUnmarshaler]. It
+// returns ErrInvalidData if data isn't a valid encoding.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < encodingHeaderSize || string(data[:4]) != encodingMagic ||
+		binary.LittleEndian.Uint32(data[4:]) != encodingVersion {
+		return ErrInvalidData
+	}
+	count := binary.LittleEndian.Uint64(data[8:])
+	n := binary.LittleEndian.Uint64(data[16:])
+	victimFP := binary.LittleEndian.Uint16(data[24:])
+	victimIndex := binary.LittleEndian.Uint64(data[26:])
+	data = data[encodingHeaderSize:]
+	if n == 0 || n&(n-1) != 0 || victimIndex >= n ||
+		len(data)%(2*bucketSize) != 0 || uint64(len(data))/(2*bucketSize) != n {
+		return ErrInvalidData
+	}
+
+	buckets := make([][bucketSize]uint16, n)
+	stored := uint64(0)
+	for i := range buckets {
+		for slot := range bucketSize {
+			fp := binary.LittleEndian.Uint16(data[2*(i*bucketSize+slot):])
+			buckets[i][slot] = fp
+			if fp != 0 {
+				stored++
+			}
+		}
+	}
+	if victimFP != 0 {
+		stored++
+	}
+	if stored != count {
+		return ErrInvalidData
+	}
+	f.buckets = buckets
+	f.mask = n - 1
+	f.count = int(count)
+	f.victim.fp, f.victim.index = victimFP, victimIndex
+	return nil
+}
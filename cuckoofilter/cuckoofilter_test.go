@@ -0,0 +1,189 @@
+package cuckoofilter
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Filter)(nil)
+	_ encoding.BinaryUnmarshaler = (*Filter)(nil)
+)
+
+func TestCapacity(t *testing.T) {
+	for _, tt := range []struct{ capacity, want int }{{1, 4}, {4, 4}, {5, 8}, {1000, 1024}, {4096, 4096}} {
+		if got := New(tt.capacity).Cap(); got != tt.want {
+			t.Errorf("New(%d).Cap()=%d, want %d", tt.capacity, got, tt.want)
+		}
+	}
+}
+
+func TestFill(t *testing.T) {
+	// Fill the filter until an insertion fails; this should only happen
+	// at a high load factor, and no element may be lost.
+	f := New(1 << 14)
+	n := 0
+	for f.AddString(fmt.Sprint(n)) {
+		n++
+	}
+	if f.Len() != n {
+		t.Errorf("Len=%d after %d successful adds", f.Len(), n)
+	}
+	if lf := f.LoadFactor(); lf < 0.9 {
+		t.Errorf("insertion failed at load factor %v", lf)
+	}
+	for i := range n {
+		if !f.MaybeContainsString(fmt.Sprint(i)) {
+			t.Fatalf("false negative for %d", i)
+		}
+	}
+
+	// Deleting makes room again.
+	for i := range 100 {
+		if !f.DeleteString(fmt.Sprint(i)) {
+			t.Fatalf("DeleteString(%d) failed", i)
+		}
+	}
+	if !f.AddString("new") {
+		t.Errorf("AddString failed after deletions")
+	}
+	for i := 100; i < n; i++ {
+		if !f.MaybeContainsString(fmt.Sprint(i)) {
+			t.Fatalf("false negative for %d after deletions", i)
+		}
+	}
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	f := New(10000)
+	for i := range 9000 {
+		f.Add([]byte(fmt.Sprintf("in-%d", i)))
+	}
+	const trials = 200000
+	fp := 0
+	for i := range trials {
+		if f.MaybeContains([]byte(fmt.Sprintf("out-%d", i))) {
+			fp++
+		}
+	}
+	// With 8 candidate slots and 16-bit fingerprints, the rate is bounded
+	// by 8/2^16, about 0.012%.
+	if rate := float64(fp) / trials; rate > 0.0002 {
+		t.Errorf("false positive rate %v", rate)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	// Add and delete elements at random, including duplicates, keeping
+	// counts in a model. Elements in the model must always be found.
+	f := New(2000)
+	model := make(map[int]int)
+	total := 0
+	for range 50000 {
+		k := rnd.IntN(3000)
+		if rnd.IntN(2) == 0 && model[k] < 2 && total < 1800 {
+			if !f.AddString(fmt.Sprint(k)) {
+				t.Fatalf("AddString(%d) failed with %d elements", k, total)
+			}
+			model[k]++
+			total++
+		} else if model[k] > 0 {
+			if !f.DeleteString(fmt.Sprint(k)) {
+				t.Fatalf("DeleteString(%d) failed", k)
+			}
+			model[k]--
+			total--
+		}
+		if f.Len() != total {
+			t.Fatalf("Len=%d, want %d", f.Len(), total)
+		}
+	}
+	for k, c := range model {
+		if c > 0 && !f.MaybeContainsString(fmt.Sprint(k)) {
+			t.Fatalf("false negative for %d", k)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	f := New(64)
+	n := 0
+	for f.AddString(fmt.Sprint(n)) {
+		n++
+	}
+	if f.victim.fp == 0 {
+		t.Fatalf("full filter has no victim")
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g Filter
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Len() != f.Len() || g.Cap() != f.Cap() || g.victim != f.victim {
+		t.Errorf("decoded Len=%d Cap=%d, want Len=%d Cap=%d", g.Len(), g.Cap(), f.Len(), f.Cap())
+	}
+	for i := range n {
+		if !g.MaybeContainsString(fmt.Sprint(i)) {
+			t.Fatalf("decoded filter is missing %d", i)
+		}
+	}
+
+	badCount := append([]byte(nil), data...)
+	badCount[8]++
+	// A bucket count whose size in bytes overflows.
+	huge := append([]byte(nil), data[:encodingHeaderSize]...)
+	binary.LittleEndian.PutUint64(huge[16:], 1<<61)
+	binary.LittleEndian.PutUint64(huge[26:], 0)
+	for _, bad := range [][]byte{
+		nil,
+		huge,
+		data[:20],
+		data[:len(data)-2],
+		append([]byte("XCKF"), data[4:]...),
+		badCount,
+	} {
+		if err := g.UnmarshalBinary(bad); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("UnmarshalBinary(%d bytes): %v", len(bad), err)
+		}
+	}
+
+	g.Clear()
+	if g.Len() != 0 || g.MaybeContainsString("0") {
+		t.Errorf("Clear didn't empty the filter")
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	f := New(b.N)
+	key := []byte("some moderately long key........")
+	for i := range b.N {
+		key[0], key[1], key[2] = byte(i), byte(i>>8), byte(i>>16)
+		f.Add(key)
+	}
+}
+
+func BenchmarkMaybeContains(b *testing.B) {
+	f := New(1 << 20)
+	key := []byte("some moderately long key")
+	for i := range 1 << 19 {
+		key[0], key[1], key[2] = byte(i), byte(i>>8), byte(i>>16)
+		f.Add(key)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		key[0] = byte(i)
+		f.MaybeContains(key)
+	}
+}
@@ -0,0 +1,136 @@
+// Package deadlinequeue implements a queue of values ordered by deadline.
+package deadlinequeue
+
+import (
+	"cmp"
+	"iter"
+	"time"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// Queue is a queue of values, each with a deadline, that are taken out in
+// order of their deadlines once they expire. It's a thin layer over an
+// indexed binary heap: adding, canceling and rescheduling a value take
+// O(log n) time. For very large numbers of timers that are mostly canceled,
+// a timer wheel is cheaper.
+//
+// Queue doesn't keep time by itself. A typical event loop waits on a timer
+// reset to the next deadline given by [Queue.PeekNext], along with its
+// other channels, and then takes out the expired values with
+// [Queue.PopExpired]:
+//
+//	for {
+//		if d, _, ok := q.PeekNext(); ok {
+//			timer.Reset(time.Until(d))
+//		}
+//		select {
+//		case <-timer.C:
+//			for v := range q.PopExpired(time.Now()) {
+//				// ... handle v
+//			}
+//		case ...:
+//		}
+//	}
+//
+// A Queue isn't safe for concurrent use. Create queues with [New].
+type Queue[T any] struct {
+	h *heap.Indexed[item[T]]
+
+	// seq numbers the values added, so that values with equal deadlines
+	// come out in the order they were added.
+	seq uint64
+}
+
+type item[T any] struct {
+	deadline time.Time
+	seq      uint64
+	value    T
+}
+
+// Handle refers to a value added to a Queue, which can be canceled or
+// rescheduled through it until it's taken out of the queue.
+type Handle[T any] struct {
+	hd *heap.Handle[item[T]]
+}
+
+// Value returns the value the handle refers to.
+func (h *Handle[T]) Value() T {
+	return h.hd.Value().value
+}
+
+// Deadline returns the current deadline of the value the handle refers to.
+func (h *Handle[T]) Deadline() time.Time {
+	return h.hd.Value().deadline
+}
+
+// New creates a new, empty queue.
+func New[T any]() *Queue[T] {
+	return &Queue[T]{h: heap.NewIndexed(func(a, b item[T]) int {
+		if c := a.deadline.Compare(b.deadline); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})}
+}
+
+// Len returns the number of values in the queue.
+func (q *Queue[T]) Len() int {
+	return q.h.Len()
+}
+
+// Add adds v to the queue with the given deadline, and returns a handle
+// for it.
+func (q *Queue[T]) Add(deadline time.Time, v T) *Handle[T] {
+	q.seq++
+	return &Handle[T]{hd: q.h.Push(item[T]{deadline: deadline, seq: q.seq, value: v})}
+}
+
+// Cancel removes the value referred to by h from the queue. It returns true
+// if the value was removed, or false if it was no longer in the queue.
+func (q *Queue[T]) Cancel(h *Handle[T]) bool {
+	if !q.h.Contains(h.hd) {
+		return false
+	}
+	q.h.Remove(h.hd)
+	return true
+}
+
+// Reschedule changes the deadline of the value referred to by h. It returns
+// true if the value was rescheduled, or false if it was no longer in the
+// queue.
+func (q *Queue[T]) Reschedule(h *Handle[T], deadline time.Time) bool {
+	if !q.h.Contains(h.hd) {
+		return false
+	}
+	it := h.hd.Value()
+	it.deadline = deadline
+	q.seq++
+	it.seq = q.seq
+	q.h.Update(h.hd, it)
+	return true
+}
+
+// PeekNext returns the earliest deadline in the queue and its value, with
+// ok=true; if the queue is empty, it returns ok=false.
+func (q *Queue[T]) PeekNext() (deadline time.Time, v T, ok bool) {
+	if q.h.Len() == 0 {
+		return deadline, v, false
+	}
+	it := q.h.Peek()
+	return it.deadline, it.value, true
+}
+
+// PopExpired returns an iterator that removes the values whose deadline is
+// at or before now from the queue, in order of their deadlines, and yields
+// them. If the loop is exited early, the remaining expired values stay in
+// the queue.
+func (q *Queue[T]) PopExpired(now time.Time) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for q.h.Len() > 0 && !q.h.Peek().deadline.After(now) {
+			if !yield(q.h.Pop().value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package deadlinequeue
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+var origin = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(s int) time.Time {
+	return origin.Add(time.Duration(s) * time.Second)
+}
+
+func TestBasic(t *testing.T) {
+	q := New[string]()
+	if _, _, ok := q.PeekNext(); ok {
+		t.Errorf("PeekNext of empty queue succeeded")
+	}
+	q.Add(at(30), "c")
+	q.Add(at(10), "a")
+	hb := q.Add(at(20), "b")
+	q.Add(at(10), "a2")
+	hd := q.Add(at(40), "d")
+
+	if d, v, ok := q.PeekNext(); !ok || v != "a" || !d.Equal(at(10)) {
+		t.Errorf("PeekNext=%v,%v,%v", d, v, ok)
+	}
+	if got := slices.Collect(q.PopExpired(at(9))); len(got) != 0 {
+		t.Errorf("PopExpired(9)=%v", got)
+	}
+	// Equal deadlines come out in the order they were added.
+	if got := slices.Collect(q.PopExpired(at(10))); !slices.Equal(got, []string{"a", "a2"}) {
+		t.Errorf("PopExpired(10)=%v", got)
+	}
+
+	if !q.Reschedule(hb, at(35)) || hb.Deadline() != at(35) || hb.Value() != "b" {
+		t.Errorf("Reschedule failed")
+	}
+	if !q.Cancel(hd) || q.Cancel(hd) || q.Reschedule(hd, at(1)) {
+		t.Errorf("Cancel failed")
+	}
+	if q.Len() != 2 {
+		t.Errorf("Len=%d", q.Len())
+	}
+	if got := slices.Collect(q.PopExpired(at(100))); !slices.Equal(got, []string{"c", "b"}) {
+		t.Errorf("PopExpired(100)=%v", got)
+	}
+	if q.Cancel(hb) || q.Len() != 0 {
+		t.Errorf("Cancel of popped value succeeded")
+	}
+}
+
+func TestPopExpiredStop(t *testing.T) {
+	q := New[int]()
+	for i := range 10 {
+		q.Add(at(i), i)
+	}
+	for v := range q.PopExpired(at(100)) {
+		if v == 3 {
+			break
+		}
+	}
+	if d, v, _ := q.PeekNext(); q.Len() != 6 || v != 4 || !d.Equal(at(4)) {
+		t.Errorf("Len=%d, next=%d", q.Len(), v)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	q := New[int]()
+	type entry struct {
+		h        *Handle[int]
+		deadline int
+	}
+	pending := make(map[int]entry)
+	deadlines := make(map[int]int)
+	now := 0
+	for i := range 10000 {
+		switch op := rnd.IntN(10); {
+		case op < 5:
+			d := now + rnd.IntN(1000) - 100
+			pending[i] = entry{q.Add(at(d), i), d}
+			deadlines[i] = d
+		case op < 6:
+			for k, e := range pending {
+				if !q.Cancel(e.h) {
+					t.Fatalf("Cancel(%d) failed", k)
+				}
+				delete(pending, k)
+				break
+			}
+		case op < 7:
+			for k, e := range pending {
+				d := now + rnd.IntN(1000)
+				if !q.Reschedule(e.h, at(d)) {
+					t.Fatalf("Reschedule(%d) failed", k)
+				}
+				pending[k] = entry{e.h, d}
+				deadlines[k] = d
+				break
+			}
+		default:
+			now += rnd.IntN(100)
+			got := slices.Collect(q.PopExpired(at(now)))
+			var want []int
+			for k, e := range pending {
+				if e.deadline <= now {
+					want = append(want, k)
+					delete(pending, k)
+				}
+			}
+			// Values come out in order of their deadlines.
+			for j := 1; j < len(got); j++ {
+				if deadlines[got[j]] < deadlines[got[j-1]] {
+					t.Fatalf("%d (deadline %d) popped after %d (deadline %d)",
+						got[j], deadlines[got[j]], got[j-1], deadlines[got[j-1]])
+				}
+			}
+			slices.Sort(got)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("at %d: expired %v, want %v", now, got, want)
+			}
+		}
+		if q.Len() != len(pending) {
+			t.Fatalf("Len=%d, want %d", q.Len(), len(pending))
+		}
+	}
+}
+
+func BenchmarkAddPop(b *testing.B) {
+	q := New[int]()
+	for i := range 10000 {
+		q.Add(at(i), i)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		q.Add(at(10000+i), i)
+		for range q.PopExpired(at(i)) {
+		}
+	}
+}
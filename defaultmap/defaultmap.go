@@ -0,0 +1,84 @@
+// Package defaultmap implements a map that creates default values for
+// missing keys on access.
+package defaultmap
+
+import "iter"
+
+// Map is a map whose Get always returns a usable value: accessing a missing
+// key creates its value with a factory function and stores it, similarly to
+// Python's defaultdict. Create maps with [New].
+type Map[K comparable, V any] struct {
+	m       map[K]V
+	factory func(K) V
+}
+
+// New creates a new, empty map with the given factory; factory(key) is
+// called to create the default value of a missing key when it's accessed.
+func New[K comparable, V any](factory func(K) V) *Map[K, V] {
+	return &Map[K, V]{m: make(map[K]V), factory: factory}
+}
+
+// Len returns the number of keys in the map.
+func (dm *Map[K, V]) Len() int {
+	return len(dm.m)
+}
+
+// Get returns the value of key. If key is missing, its default value is
+// created, stored in the map and returned.
+func (dm *Map[K, V]) Get(key K) V {
+	v, ok := dm.m[key]
+	if !ok {
+		v = dm.factory(key)
+		dm.m[key] = v
+	}
+	return v
+}
+
+// Lookup returns the value of key and ok=true, or ok=false if key is
+// missing. Unlike Get, Lookup never creates values.
+func (dm *Map[K, V]) Lookup(key K) (v V, ok bool) {
+	v, ok = dm.m[key]
+	return v, ok
+}
+
+// Contains reports whether key is in the map.
+func (dm *Map[K, V]) Contains(key K) bool {
+	_, ok := dm.m[key]
+	return ok
+}
+
+// Set sets the value of key.
+func (dm *Map[K, V]) Set(key K, value V) {
+	dm.m[key] = value
+}
+
+// Update sets the value of key to f(old), where old is the current value of
+// key, or its default value if key is missing. Update is convenient for
+// value types that can't be modified in place, such as slices or integers:
+//
+//	groups.Update(k, func(v []string) []string { return append(v, s) })
+func (dm *Map[K, V]) Update(key K, f func(V) V) {
+	dm.m[key] = f(dm.Get(key))
+}
+
+// Delete deletes key and its value from the map. It returns true if key was
+// found, and false otherwise.
+func (dm *Map[K, V]) Delete(key K) bool {
+	if _, ok := dm.m[key]; !ok {
+		return false
+	}
+	delete(dm.m, key)
+	return true
+}
+
+// All returns an iterator over all the key, value pairs in the map, in
+// unspecified order. Iteration doesn't create any values.
+func (dm *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range dm.m {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
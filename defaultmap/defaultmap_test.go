@@ -0,0 +1,76 @@
+package defaultmap
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGrouping(t *testing.T) {
+	words := []string{"apple", "avocado", "banana", "blueberry", "cherry", "apricot"}
+	groups := New(func(string) []string { return nil })
+	for _, w := range words {
+		groups.Update(w[:1], func(v []string) []string { return append(v, w) })
+	}
+	want := map[string][]string{
+		"a": {"apple", "avocado", "apricot"},
+		"b": {"banana", "blueberry"},
+		"c": {"cherry"},
+	}
+	if got := maps.Collect(groups.All()); !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPointerValues(t *testing.T) {
+	// Values with reference semantics can be modified in place through Get.
+	builders := New(func(k int) *strings.Builder {
+		var sb strings.Builder
+		sb.WriteString(strings.Repeat("#", k))
+		return &sb
+	})
+	builders.Get(2).WriteString("ab")
+	builders.Get(2).WriteString("cd")
+	if got := builders.Get(2).String(); got != "##abcd" {
+		t.Errorf("got %q", got)
+	}
+	if got := builders.Get(1).String(); got != "#" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLookupDoesNotCreate(t *testing.T) {
+	calls := 0
+	m := New(func(k string) int {
+		calls++
+		return len(k)
+	})
+	if _, ok := m.Lookup("abc"); ok || m.Contains("abc") || m.Len() != 0 {
+		t.Errorf("Lookup created a value")
+	}
+	if v := m.Get("abc"); v != 3 || !m.Contains("abc") {
+		t.Errorf("Get=%d", v)
+	}
+	m.Get("abc")
+	if calls != 1 {
+		t.Errorf("factory called %d times, want 1", calls)
+	}
+
+	m.Update("abc", func(v int) int { return v * 10 })
+	m.Update("hello", func(v int) int { return v + 1 })
+	if v, ok := m.Lookup("abc"); !ok || v != 30 {
+		t.Errorf("got %d,%v", v, ok)
+	}
+	if v, ok := m.Lookup("hello"); !ok || v != 6 {
+		t.Errorf("got %d,%v", v, ok)
+	}
+
+	m.Set("x", 100)
+	if v := m.Get("x"); v != 100 {
+		t.Errorf("got %d", v)
+	}
+	if !m.Delete("x") || m.Delete("x") || m.Len() != 2 {
+		t.Errorf("bad Delete")
+	}
+}
@@ -0,0 +1,191 @@
+// Package deque implements a double-ended queue backed by a growable ring
+// buffer.
+package deque
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Deque is a double-ended queue. Pushing and popping at both ends, as well as
+// indexing with At, are O(1) (amortized, for pushes). Create new deques with
+// [New].
+type Deque[T any] struct {
+	// buf is a circular buffer holding the deque's elements; its length is
+	// always a power of two (or 0), so that indices can be wrapped around by
+	// masking. The first element is at buf[head], and the deque holds
+	// length elements.
+	buf    []T
+	head   int
+	length int
+}
+
+const minCapacity = 8
+
+// New creates a new, empty deque.
+func New[T any]() *Deque[T] {
+	return &Deque[T]{}
+}
+
+// Len returns the number of elements in the deque.
+func (d *Deque[T]) Len() int {
+	return d.length
+}
+
+// PushBack adds an element to the back of the deque.
+func (d *Deque[T]) PushBack(v T) {
+	d.growIfFull()
+	d.buf[d.wrap(d.head+d.length)] = v
+	d.length++
+}
+
+// PushFront adds an element to the front of the deque.
+func (d *Deque[T]) PushFront(v T) {
+	d.growIfFull()
+	d.head = d.wrap(d.head - 1)
+	d.buf[d.head] = v
+	d.length++
+}
+
+// PopFront removes the element at the front of the deque and returns it. It
+// panics if the deque is empty.
+func (d *Deque[T]) PopFront() T {
+	if d.length == 0 {
+		panic("PopFront from empty deque")
+	}
+	v := d.buf[d.head]
+	d.buf[d.head] = *new(T)
+	d.head = d.wrap(d.head + 1)
+	d.length--
+	d.shrinkIfSparse()
+	return v
+}
+
+// PopBack removes the element at the back of the deque and returns it. It
+// panics if the deque is empty.
+func (d *Deque[T]) PopBack() T {
+	if d.length == 0 {
+		panic("PopBack from empty deque")
+	}
+	i := d.wrap(d.head + d.length - 1)
+	v := d.buf[i]
+	d.buf[i] = *new(T)
+	d.length--
+	d.shrinkIfSparse()
+	return v
+}
+
+// Front returns the element at the front of the deque without removing it.
+// It panics if the deque is empty.
+func (d *Deque[T]) Front() T {
+	if d.length == 0 {
+		panic("Front of empty deque")
+	}
+	return d.buf[d.head]
+}
+
+// Back returns the element at the back of the deque without removing it.
+// It panics if the deque is empty.
+func (d *Deque[T]) Back() T {
+	if d.length == 0 {
+		panic("Back of empty deque")
+	}
+	return d.buf[d.wrap(d.head+d.length-1)]
+}
+
+// At returns the i-th element of the deque, where 0 is the front. It panics
+// if i is out of range.
+func (d *Deque[T]) At(i int) T {
+	d.checkIndex(i)
+	return d.buf[d.wrap(d.head+i)]
+}
+
+// Set sets the i-th element of the deque, where 0 is the front. It panics if
+// i is out of range.
+func (d *Deque[T]) Set(i int, v T) {
+	d.checkIndex(i)
+	d.buf[d.wrap(d.head+i)] = v
+}
+
+// Clear removes all elements from the deque.
+func (d *Deque[T]) Clear() {
+	d.buf = nil
+	d.head = 0
+	d.length = 0
+}
+
+// All returns an iterator over index, value pairs in the deque, from front
+// to back.
+func (d *Deque[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := 0; i < d.length; i++ {
+			if !yield(i, d.buf[d.wrap(d.head+i)]) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in the deque, from front to
+// back.
+func (d *Deque[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := 0; i < d.length; i++ {
+			if !yield(d.buf[d.wrap(d.head+i)]) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over index, value pairs in the deque, from
+// back to front.
+func (d *Deque[T]) Backward() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := d.length - 1; i >= 0; i-- {
+			if !yield(i, d.buf[d.wrap(d.head+i)]) {
+				return
+			}
+		}
+	}
+}
+
+func (d *Deque[T]) checkIndex(i int) {
+	if i < 0 || i >= d.length {
+		panic(fmt.Sprintf("deque index %d out of range [0:%d]", i, d.length))
+	}
+}
+
+// wrap wraps the index i around the buffer's capacity.
+func (d *Deque[T]) wrap(i int) int {
+	return i & (len(d.buf) - 1)
+}
+
+func (d *Deque[T]) growIfFull() {
+	if d.length < len(d.buf) {
+		return
+	}
+	d.resize(max(minCapacity, 2*len(d.buf)))
+}
+
+// shrinkIfSparse shrinks the buffer when it's only a quarter full, to avoid
+// holding on to memory after a burst of pushes.
+func (d *Deque[T]) shrinkIfSparse() {
+	if len(d.buf) > minCapacity && d.length <= len(d.buf)/4 {
+		d.resize(len(d.buf) / 2)
+	}
+}
+
+// resize copies the deque's elements to a new buffer of the given size,
+// starting at index 0.
+func (d *Deque[T]) resize(size int) {
+	newBuf := make([]T, size)
+	if d.head+d.length <= len(d.buf) {
+		copy(newBuf, d.buf[d.head:d.head+d.length])
+	} else {
+		n := copy(newBuf, d.buf[d.head:])
+		copy(newBuf[n:], d.buf[:d.length-n])
+	}
+	d.buf = newBuf
+	d.head = 0
+}
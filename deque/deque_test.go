@@ -0,0 +1,159 @@
+package deque
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func checkDeque[T comparable](t *testing.T, d *Deque[T], want []T) {
+	t.Helper()
+	if d.Len() != len(want) {
+		t.Errorf("got len=%v, want %v", d.Len(), len(want))
+	}
+	got := slices.Collect(d.Values())
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for i, w := range want {
+		if d.At(i) != w {
+			t.Errorf("got At(%d)=%v, want %v", i, d.At(i), w)
+		}
+	}
+
+	var back []T
+	for i, v := range d.Backward() {
+		if v != want[i] {
+			t.Errorf("Backward: got %v at %d, want %v", v, i, want[i])
+		}
+		back = append(back, v)
+	}
+	slices.Reverse(back)
+	if !slices.Equal(back, want) {
+		t.Errorf("Backward: got %v, want reversed %v", back, want)
+	}
+}
+
+func TestPushPop(t *testing.T) {
+	d := New[int]()
+	checkDeque(t, d, []int{})
+
+	d.PushBack(10)
+	d.PushBack(20)
+	d.PushFront(5)
+	checkDeque(t, d, []int{5, 10, 20})
+	if d.Front() != 5 || d.Back() != 20 {
+		t.Errorf("got front=%v, back=%v; want 5, 20", d.Front(), d.Back())
+	}
+
+	if v := d.PopFront(); v != 5 {
+		t.Errorf("got PopFront=%v, want 5", v)
+	}
+	if v := d.PopBack(); v != 20 {
+		t.Errorf("got PopBack=%v, want 20", v)
+	}
+	checkDeque(t, d, []int{10})
+
+	d.Set(0, 11)
+	checkDeque(t, d, []int{11})
+	d.PopBack()
+	checkDeque(t, d, []int{})
+}
+
+func TestWrapAround(t *testing.T) {
+	d := New[int]()
+	var want []int
+
+	// Push to the front more than the minimal capacity so the elements wrap
+	// around the end of the buffer.
+	for i := range 20 {
+		d.PushFront(i)
+		want = slices.Insert(want, 0, i)
+		checkDeque(t, d, want)
+	}
+	for i := range 20 {
+		d.PushBack(100 + i)
+		want = append(want, 100+i)
+		checkDeque(t, d, want)
+	}
+	for range 35 {
+		d.PopFront()
+		want = want[1:]
+		checkDeque(t, d, want)
+	}
+
+	d.Clear()
+	checkDeque(t, d, []int{})
+}
+
+func TestAllStops(t *testing.T) {
+	d := New[string]()
+	d.PushBack("a")
+	d.PushBack("b")
+	d.PushBack("c")
+	var got []string
+	for i, v := range d.All() {
+		if i == 2 {
+			break
+		}
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestEmptyPanics(t *testing.T) {
+	d := New[int]()
+	for name, f := range map[string]func(){
+		"PopFront": func() { d.PopFront() },
+		"PopBack":  func() { d.PopBack() },
+		"Front":    func() { d.Front() },
+		"Back":     func() { d.Back() },
+		"At":       func() { d.At(0) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	d := New[int]()
+	var want []int
+	for i := range 5000 {
+		switch op := rnd.IntN(4); {
+		case op == 0:
+			d.PushBack(i)
+			want = append(want, i)
+		case op == 1:
+			d.PushFront(i)
+			want = slices.Insert(want, 0, i)
+		case len(want) == 0:
+		case op == 2:
+			if v := d.PopFront(); v != want[0] {
+				t.Fatalf("got PopFront=%v, want %v", v, want[0])
+			}
+			want = want[1:]
+		default:
+			if v := d.PopBack(); v != want[len(want)-1] {
+				t.Fatalf("got PopBack=%v, want %v", v, want[len(want)-1])
+			}
+			want = want[:len(want)-1]
+		}
+		if i%100 == 0 {
+			checkDeque(t, d, want)
+		}
+	}
+	checkDeque(t, d, want)
+}
@@ -0,0 +1,115 @@
+// Package fenwick implements a Fenwick tree (also known as a binary indexed
+// tree) for prefix sums over a mutable sequence of numbers.
+package fenwick
+
+// Number is the constraint for the element type of Fenwick trees.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Tree is a Fenwick tree over a sequence of n numbers, initially all zero.
+// It supports adding to a single element and computing prefix sums in
+// O(log n) time, using a single array of n elements. Create trees with [New]
+// or [FromSlice].
+type Tree[T Number] struct {
+	// tree is 1-based: tree[i] holds the sum of the elements in the range
+	// (i - lowbit(i), i], where lowbit(i) is the lowest set bit of i.
+	tree []T
+}
+
+// New creates a new tree over n zero elements.
+func New[T Number](n int) *Tree[T] {
+	return &Tree[T]{tree: make([]T, n+1)}
+}
+
+// FromSlice creates a new tree over a copy of values, in O(n) time.
+func FromSlice[T Number](values []T) *Tree[T] {
+	t := New[T](len(values))
+	copy(t.tree[1:], values)
+	for i := 1; i < len(t.tree); i++ {
+		if parent := i + i&-i; parent < len(t.tree) {
+			t.tree[parent] += t.tree[i]
+		}
+	}
+	return t
+}
+
+// Len returns the number of elements in the tree.
+func (t *Tree[T]) Len() int {
+	return len(t.tree) - 1
+}
+
+// Add adds delta to the element at index i. It panics if i is out of range.
+func (t *Tree[T]) Add(i int, delta T) {
+	if i < 0 || i >= t.Len() {
+		panic("fenwick: index out of range")
+	}
+	for i++; i < len(t.tree); i += i & -i {
+		t.tree[i] += delta
+	}
+}
+
+// Set sets the element at index i to v. It panics if i is out of range.
+func (t *Tree[T]) Set(i int, v T) {
+	t.Add(i, v-t.Get(i))
+}
+
+// Get returns the element at index i. It panics if i is out of range.
+func (t *Tree[T]) Get(i int) T {
+	if i < 0 || i >= t.Len() {
+		panic("fenwick: index out of range")
+	}
+	return t.RangeSum(i, i+1)
+}
+
+// PrefixSum returns the sum of the elements in the range [0, i). It panics
+// if i is not in the range [0, Len()].
+func (t *Tree[T]) PrefixSum(i int) T {
+	if i < 0 || i > t.Len() {
+		panic("fenwick: index out of range")
+	}
+	var sum T
+	for ; i > 0; i -= i & -i {
+		sum += t.tree[i]
+	}
+	return sum
+}
+
+// RangeSum returns the sum of the elements in the range [lo, hi). It panics
+// if the range is invalid.
+func (t *Tree[T]) RangeSum(lo, hi int) T {
+	if lo > hi {
+		panic("fenwick: invalid range")
+	}
+	return t.PrefixSum(hi) - t.PrefixSum(lo)
+}
+
+// Total returns the sum of all the elements.
+func (t *Tree[T]) Total() T {
+	return t.PrefixSum(t.Len())
+}
+
+// FindKth returns the smallest index i such that the sum of the elements in
+// the range [0, i] is larger than k, or Len() if there's no such index. All
+// elements must be non-negative. If element i counts the items with value i
+// in a multiset, FindKth returns the value of the k-th smallest item
+// (counting from 0). It takes O(log n) time.
+func (t *Tree[T]) FindKth(k T) int {
+	// Descend the implicit tree structure from the highest power of two,
+	// accumulating the largest prefix whose sum is <= k.
+	pos := 0
+	step := 1
+	for step*2 < len(t.tree) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if next := pos + step; next < len(t.tree) && t.tree[next] <= k {
+			pos = next
+			k -= t.tree[next]
+		}
+	}
+	// pos is the length of that prefix, so pos is the index sought.
+	return pos
+}
@@ -0,0 +1,79 @@
+package fenwick
+
+import (
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestBasic(t *testing.T) {
+	ft := FromSlice([]int{3, 0, 2, 5, 1})
+	if ft.Len() != 5 || ft.Total() != 11 {
+		t.Errorf("got Len=%d, Total=%d", ft.Len(), ft.Total())
+	}
+	if ft.PrefixSum(3) != 5 || ft.RangeSum(2, 4) != 7 || ft.PrefixSum(0) != 0 {
+		t.Errorf("bad sums")
+	}
+	ft.Add(1, 4)
+	ft.Set(4, 10)
+	if ft.Get(1) != 4 || ft.Get(4) != 10 || ft.Total() != 24 {
+		t.Errorf("bad values after updates")
+	}
+
+	// The elements are counts: three 0s, four 1s, two 2s, five 3s, ten 4s.
+	for k, want := range map[int]int{0: 0, 2: 0, 3: 1, 6: 1, 7: 2, 9: 3, 13: 3, 14: 4, 23: 4, 24: 5} {
+		if got := ft.FindKth(k); got != want {
+			t.Errorf("FindKth(%d)=%d, want %d", k, got, want)
+		}
+	}
+
+	ff := FromSlice([]float64{0.5, 0.25, 0.25})
+	if ff.FindKth(0.6) != 1 || ff.FindKth(0.75) != 2 {
+		t.Errorf("bad float FindKth")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, n := range []int{0, 1, 7, 64, 100} {
+		values := make([]int64, n)
+		ft := New[int64](n)
+		for range 1000 {
+			if n > 0 {
+				i := rnd.IntN(n)
+				d := rnd.Int64N(10)
+				values[i] += d
+				ft.Add(i, d)
+			}
+
+			lo := rnd.IntN(n + 1)
+			hi := lo + rnd.IntN(n-lo+1)
+			var want int64
+			for _, v := range values[lo:hi] {
+				want += v
+			}
+			if got := ft.RangeSum(lo, hi); got != want {
+				t.Fatalf("RangeSum(%d, %d)=%d, want %d", lo, hi, got, want)
+			}
+
+			k := rnd.Int64N(ft.Total() + 2)
+			wantIdx, sum := n, int64(0)
+			for i, v := range values {
+				sum += v
+				if sum > k {
+					wantIdx = i
+					break
+				}
+			}
+			if got := ft.FindKth(k); got != wantIdx {
+				t.Fatalf("FindKth(%d)=%d, want %d", k, got, wantIdx)
+			}
+		}
+		if fs := FromSlice(values); fs.Total() != ft.Total() || fs.PrefixSum(n/2) != ft.PrefixSum(n/2) {
+			t.Fatalf("FromSlice mismatch")
+		}
+	}
+}
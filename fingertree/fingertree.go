@@ -0,0 +1,465 @@
+// Package fingertree implements persistent finger trees: general-purpose
+// sequences annotated with measurements, from which deques, indexed
+// sequences, priority queues and ordered sequences can be built by choosing
+// the measurement.
+package fingertree
+
+import "iter"
+
+// Measure defines how a finger tree measures its elements. The measure of
+// a sequence is the combination of the measures of its elements, in order,
+// so Combine must be associative with Identity as its identity element; it
+// doesn't have to be commutative.
+//
+// For example, measuring each element as 1 and combining by addition gives
+// the length of sequences, making a tree an indexed sequence; measuring
+// elements by their priority and combining with max makes it a priority
+// queue.
+type Measure[T, M any] struct {
+	Of       func(v T) M
+	Combine  func(a, b M) M
+	Identity M
+}
+
+// Tree is a persistent finger tree, as described by Hinze and Paterson in
+// "Finger trees: a simple general-purpose data structure". It's a sequence
+// of elements that caches the measure of every subsequence it's built from,
+// supporting:
+//
+//   - Access and updates at both ends in O(1) amortized time.
+//   - Concatenation of two trees in O(log min(n1, n2)) time.
+//   - Splitting a tree at the point where a predicate on the measure of the
+//     prefix becomes true, and searching for that point, in O(log n) time.
+//
+// The amortized bounds hold when each version of a tree is updated once;
+// repeatedly updating the same old version may take O(log n) per operation.
+//
+// Trees are never modified: all updates return new trees that share most of
+// their structure with the original, which remains valid. Trees are
+// therefore safe for concurrent use by multiple goroutines.
+//
+// Create trees with [New].
+type Tree[T, M any] struct {
+	ms *Measure[T, M]
+	t  *ftree[T, M]
+}
+
+// node is a node of a finger tree: either a leaf holding an element, or a
+// 2-3 node whose children are nodes one level lower. The elements of a
+// finger tree are leaves, its middle tree holds nodes of leaves, and so on.
+type node[T, M any] struct {
+	m        M
+	value    T
+	children []*node[T, M]
+}
+
+// ftree is a finger tree over nodes; nil is the empty tree. A tree with a
+// single node has single set; otherwise, it's deep, with prefix and suffix
+// each holding 1-4 nodes around the middle tree.
+type ftree[T, M any] struct {
+	m      M
+	single *node[T, M]
+	prefix []*node[T, M]
+	middle *ftree[T, M]
+	suffix []*node[T, M]
+}
+
+// New creates a new, empty tree whose elements are measured by ms.
+func New[T, M any](ms Measure[T, M]) *Tree[T, M] {
+	return &Tree[T, M]{ms: &ms}
+}
+
+// IsEmpty reports whether the tree has no elements.
+func (t *Tree[T, M]) IsEmpty() bool {
+	return t.t == nil
+}
+
+// Measure returns the measure of the whole tree: the combination of the
+// measures of its elements, or the identity if the tree is empty. It takes
+// O(1) time.
+func (t *Tree[T, M]) Measure() M {
+	return t.ms.measureTree(t.t)
+}
+
+// PushFront returns a new tree with v added at the front of t.
+func (t *Tree[T, M]) PushFront(v T) *Tree[T, M] {
+	return t.with(t.ms.pushFront(t.ms.leaf(v), t.t))
+}
+
+// PushBack returns a new tree with v added at the back of t.
+func (t *Tree[T, M]) PushBack(v T) *Tree[T, M] {
+	return t.with(t.ms.pushBack(t.t, t.ms.leaf(v)))
+}
+
+// Front returns the first element of the tree. It panics if the tree is
+// empty.
+func (t *Tree[T, M]) Front() T {
+	switch {
+	case t.t == nil:
+		panic("fingertree: Front of empty tree")
+	case t.t.single != nil:
+		return t.t.single.value
+	default:
+		return t.t.prefix[0].value
+	}
+}
+
+// Back returns the last element of the tree. It panics if the tree is
+// empty.
+func (t *Tree[T, M]) Back() T {
+	switch {
+	case t.t == nil:
+		panic("fingertree: Back of empty tree")
+	case t.t.single != nil:
+		return t.t.single.value
+	default:
+		return t.t.suffix[len(t.t.suffix)-1].value
+	}
+}
+
+// PopFront returns the first element of the tree and a new tree holding the
+// remaining elements. It panics if the tree is empty.
+func (t *Tree[T, M]) PopFront() (T, *Tree[T, M]) {
+	if t.t == nil {
+		panic("fingertree: PopFront of empty tree")
+	}
+	n, rest := t.ms.viewFront(t.t)
+	return n.value, t.with(rest)
+}
+
+// PopBack returns the last element of the tree and a new tree holding the
+// remaining elements. It panics if the tree is empty.
+func (t *Tree[T, M]) PopBack() (T, *Tree[T, M]) {
+	if t.t == nil {
+		panic("fingertree: PopBack of empty tree")
+	}
+	rest, n := t.ms.viewBack(t.t)
+	return n.value, t.with(rest)
+}
+
+// Concat returns a new tree holding the elements of t followed by those of
+// other. The trees must measure their elements the same way, since the
+// measures cached in other are reused; the result measures new elements
+// like t.
+func (t *Tree[T, M]) Concat(other *Tree[T, M]) *Tree[T, M] {
+	return t.with(t.ms.concat(t.t, nil, other.t))
+}
+
+// Split splits the tree at the first element e such that pred(m) is true,
+// where m is the combined measure of the elements up to and including e.
+// It returns a tree holding the elements before e, and a tree holding e and
+// the elements after it. pred must be monotonic: once it's true for a
+// prefix of the tree, it must be true for all the longer prefixes. If pred
+// is false for the whole tree, the second tree is empty.
+//
+// For example, with elements measured by their count, splitting with the
+// predicate m > i splits the tree before the element at index i.
+func (t *Tree[T, M]) Split(pred func(m M) bool) (*Tree[T, M], *Tree[T, M]) {
+	switch {
+	case t.t == nil:
+		return t, t
+	case !pred(t.t.m):
+		return t, t.with(nil)
+	}
+	left, n, right := t.ms.splitTree(pred, t.ms.Identity, t.t)
+	return t.with(left), t.with(t.ms.pushFront(n, right))
+}
+
+// Find returns the first element e such that pred(m) is true, where m is
+// the combined measure of the elements up to and including e, with ok=true;
+// if pred is false for the whole tree, it returns ok=false. pred must be
+// monotonic, as for [Tree.Split]. Find doesn't allocate.
+func (t *Tree[T, M]) Find(pred func(m M) bool) (v T, ok bool) {
+	if t.t == nil || !pred(t.t.m) {
+		return v, false
+	}
+	acc := t.ms.Identity
+	n := t.ms.findTree(pred, &acc, t.t)
+	for n.children != nil {
+		n = t.ms.findDigit(pred, &acc, n.children)
+	}
+	return n.value, true
+}
+
+// Values returns an iterator over the elements of the tree, from front to
+// back.
+func (t *Tree[T, M]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.t.walk(yield)
+	}
+}
+
+func (t *Tree[T, M]) with(ft *ftree[T, M]) *Tree[T, M] {
+	return &Tree[T, M]{ms: t.ms, t: ft}
+}
+
+func (ms *Measure[T, M]) leaf(v T) *node[T, M] {
+	return &node[T, M]{m: ms.Of(v), value: v}
+}
+
+func (ms *Measure[T, M]) node(children ...*node[T, M]) *node[T, M] {
+	return &node[T, M]{m: ms.measureNodes(children), children: children}
+}
+
+func (ms *Measure[T, M]) measureNodes(ns []*node[T, M]) M {
+	m := ms.Identity
+	for _, n := range ns {
+		m = ms.Combine(m, n.m)
+	}
+	return m
+}
+
+func (ms *Measure[T, M]) measureTree(t *ftree[T, M]) M {
+	if t == nil {
+		return ms.Identity
+	}
+	return t.m
+}
+
+// digits returns a new slice holding the given nodes. Digit slices of trees
+// are never modified, as they may be shared.
+func digits[T, M any](ds ...[]*node[T, M]) []*node[T, M] {
+	n := 0
+	for _, d := range ds {
+		n += len(d)
+	}
+	s := make([]*node[T, M], 0, n)
+	for _, d := range ds {
+		s = append(s, d...)
+	}
+	return s
+}
+
+func (ms *Measure[T, M]) single(n *node[T, M]) *ftree[T, M] {
+	return &ftree[T, M]{m: n.m, single: n}
+}
+
+// deep returns a deep tree; prefix and suffix must have 1-4 nodes, and must
+// not be modified afterwards.
+func (ms *Measure[T, M]) deep(prefix []*node[T, M], middle *ftree[T, M], suffix []*node[T, M]) *ftree[T, M] {
+	m := ms.Combine(ms.Combine(ms.measureNodes(prefix), ms.measureTree(middle)), ms.measureNodes(suffix))
+	return &ftree[T, M]{m: m, prefix: prefix, middle: middle, suffix: suffix}
+}
+
+func (ms *Measure[T, M]) pushFront(n *node[T, M], t *ftree[T, M]) *ftree[T, M] {
+	switch {
+	case t == nil:
+		return ms.single(n)
+	case t.single != nil:
+		return ms.deep([]*node[T, M]{n}, nil, []*node[T, M]{t.single})
+	case len(t.prefix) == 4:
+		p := t.prefix
+		return ms.deep([]*node[T, M]{n, p[0]}, ms.pushFront(ms.node(p[1], p[2], p[3]), t.middle), t.suffix)
+	default:
+		return ms.deep(digits([]*node[T, M]{n}, t.prefix), t.middle, t.suffix)
+	}
+}
+
+func (ms *Measure[T, M]) pushBack(t *ftree[T, M], n *node[T, M]) *ftree[T, M] {
+	switch {
+	case t == nil:
+		return ms.single(n)
+	case t.single != nil:
+		return ms.deep([]*node[T, M]{t.single}, nil, []*node[T, M]{n})
+	case len(t.suffix) == 4:
+		s := t.suffix
+		return ms.deep(t.prefix, ms.pushBack(t.middle, ms.node(s[0], s[1], s[2])), []*node[T, M]{s[3], n})
+	default:
+		return ms.deep(t.prefix, t.middle, digits(t.suffix, []*node[T, M]{n}))
+	}
+}
+
+// fromDigits returns a tree holding the nodes ds.
+func (ms *Measure[T, M]) fromDigits(ds []*node[T, M]) *ftree[T, M] {
+	var t *ftree[T, M]
+	for _, n := range ds {
+		t = ms.pushBack(t, n)
+	}
+	return t
+}
+
+// viewFront splits the non-empty tree t into its first node and the rest.
+func (ms *Measure[T, M]) viewFront(t *ftree[T, M]) (*node[T, M], *ftree[T, M]) {
+	if t.single != nil {
+		return t.single, nil
+	}
+	return t.prefix[0], ms.deepFront(t.prefix[1:], t.middle, t.suffix)
+}
+
+// viewBack splits the non-empty tree t into its last node and the rest.
+func (ms *Measure[T, M]) viewBack(t *ftree[T, M]) (*ftree[T, M], *node[T, M]) {
+	if t.single != nil {
+		return nil, t.single
+	}
+	last := len(t.suffix) - 1
+	return ms.deepBack(t.prefix, t.middle, t.suffix[:last]), t.suffix[last]
+}
+
+// deepFront is like deep, but prefix may be empty; then the first node of
+// middle replaces it.
+func (ms *Measure[T, M]) deepFront(prefix []*node[T, M], middle *ftree[T, M], suffix []*node[T, M]) *ftree[T, M] {
+	switch {
+	case len(prefix) > 0:
+		return ms.deep(digits(prefix), middle, suffix)
+	case middle == nil:
+		return ms.fromDigits(suffix)
+	}
+	n, rest := ms.viewFront(middle)
+	return ms.deep(n.children, rest, suffix)
+}
+
+// deepBack is like deep, but suffix may be empty; then the last node of
+// middle replaces it.
+func (ms *Measure[T, M]) deepBack(prefix []*node[T, M], middle *ftree[T, M], suffix []*node[T, M]) *ftree[T, M] {
+	switch {
+	case len(suffix) > 0:
+		return ms.deep(prefix, middle, digits(suffix))
+	case middle == nil:
+		return ms.fromDigits(prefix)
+	}
+	rest, n := ms.viewBack(middle)
+	return ms.deep(prefix, rest, n.children)
+}
+
+// concat returns the concatenation of a, the nodes ns, and b.
+func (ms *Measure[T, M]) concat(a *ftree[T, M], ns []*node[T, M], b *ftree[T, M]) *ftree[T, M] {
+	switch {
+	case a == nil:
+		for i := len(ns) - 1; i >= 0; i-- {
+			b = ms.pushFront(ns[i], b)
+		}
+		return b
+	case b == nil:
+		for _, n := range ns {
+			a = ms.pushBack(a, n)
+		}
+		return a
+	case a.single != nil:
+		return ms.pushFront(a.single, ms.concat(nil, ns, b))
+	case b.single != nil:
+		return ms.pushBack(ms.concat(a, ns, nil), b.single)
+	}
+	mid := ms.concat(a.middle, ms.nodes(digits(a.suffix, ns, b.prefix)), b.middle)
+	return ms.deep(a.prefix, mid, b.suffix)
+}
+
+// nodes groups 2 or more nodes into 2-3 nodes one level higher.
+func (ms *Measure[T, M]) nodes(ns []*node[T, M]) []*node[T, M] {
+	var out []*node[T, M]
+	for len(ns) > 4 {
+		out = append(out, ms.node(ns[0], ns[1], ns[2]))
+		ns = ns[3:]
+	}
+	switch len(ns) {
+	case 4:
+		out = append(out, ms.node(ns[0], ns[1]), ms.node(ns[2], ns[3]))
+	default:
+		out = append(out, ms.node(digits(ns)...))
+	}
+	return out
+}
+
+// splitTree splits the non-empty tree t at the first node where pred
+// becomes true for the measure of the nodes so far combined with acc, and
+// returns the trees before and after that node; pred must be true for the
+// whole tree.
+func (ms *Measure[T, M]) splitTree(pred func(M) bool, acc M, t *ftree[T, M]) (*ftree[T, M], *node[T, M], *ftree[T, M]) {
+	if t.single != nil {
+		return nil, t.single, nil
+	}
+	accPrefix := ms.Combine(acc, ms.measureNodes(t.prefix))
+	if pred(accPrefix) {
+		before, n, after := ms.splitDigit(pred, acc, t.prefix)
+		return ms.fromDigits(before), n, ms.deepFront(after, t.middle, t.suffix)
+	}
+	accMiddle := ms.Combine(accPrefix, ms.measureTree(t.middle))
+	if pred(accMiddle) {
+		mBefore, mn, mAfter := ms.splitTree(pred, accPrefix, t.middle)
+		before, n, after := ms.splitDigit(pred, ms.Combine(accPrefix, ms.measureTree(mBefore)), mn.children)
+		return ms.deepBack(t.prefix, mBefore, before), n, ms.deepFront(after, mAfter, t.suffix)
+	}
+	before, n, after := ms.splitDigit(pred, accMiddle, t.suffix)
+	return ms.deepBack(t.prefix, t.middle, before), n, ms.fromDigits(after)
+}
+
+// splitDigit splits the nodes ds at the first node where pred becomes true
+// for their measure combined with acc, or at the last node.
+func (ms *Measure[T, M]) splitDigit(pred func(M) bool, acc M, ds []*node[T, M]) ([]*node[T, M], *node[T, M], []*node[T, M]) {
+	for i, n := range ds[:len(ds)-1] {
+		acc = ms.Combine(acc, n.m)
+		if pred(acc) {
+			return ds[:i], n, ds[i+1:]
+		}
+	}
+	last := len(ds) - 1
+	return ds[:last], ds[last], nil
+}
+
+// findTree returns the first node of the non-empty tree t where pred
+// becomes true for the measure of the nodes so far combined with *acc; it
+// leaves *acc as the measure before that node.
+func (ms *Measure[T, M]) findTree(pred func(M) bool, acc *M, t *ftree[T, M]) *node[T, M] {
+	if t.single != nil {
+		return t.single
+	}
+	accPrefix := ms.Combine(*acc, ms.measureNodes(t.prefix))
+	if pred(accPrefix) {
+		return ms.findDigit(pred, acc, t.prefix)
+	}
+	*acc = accPrefix
+	if t.middle != nil {
+		if accMiddle := ms.Combine(accPrefix, t.middle.m); !pred(accMiddle) {
+			*acc = accMiddle
+		} else {
+			return ms.findDigit(pred, acc, ms.findTree(pred, acc, t.middle).children)
+		}
+	}
+	return ms.findDigit(pred, acc, t.suffix)
+}
+
+// findDigit is the counterpart of splitDigit for findTree.
+func (ms *Measure[T, M]) findDigit(pred func(M) bool, acc *M, ds []*node[T, M]) *node[T, M] {
+	for _, n := range ds[:len(ds)-1] {
+		next := ms.Combine(*acc, n.m)
+		if pred(next) {
+			return n
+		}
+		*acc = next
+	}
+	return ds[len(ds)-1]
+}
+
+func (t *ftree[T, M]) walk(yield func(T) bool) bool {
+	if t == nil {
+		return true
+	}
+	if t.single != nil {
+		return t.single.walk(yield)
+	}
+	for _, n := range t.prefix {
+		if !n.walk(yield) {
+			return false
+		}
+	}
+	if !t.middle.walk(yield) {
+		return false
+	}
+	for _, n := range t.suffix {
+		if !n.walk(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *node[T, M]) walk(yield func(T) bool) bool {
+	if n.children == nil {
+		return yield(n.value)
+	}
+	for _, c := range n.children {
+		if !c.walk(yield) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,276 @@
+package fingertree
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// sizeMeasure measures sequences by their length, making trees indexed
+// sequences.
+var sizeMeasure = Measure[int, int]{
+	Of:       func(int) int { return 1 },
+	Combine:  func(a, b int) int { return a + b },
+	Identity: 0,
+}
+
+// splitAt splits t before index i.
+func splitAt(t *Tree[int, int], i int) (*Tree[int, int], *Tree[int, int]) {
+	return t.Split(func(m int) bool { return m > i })
+}
+
+// checkTree checks that t holds exactly want, and the invariants of its
+// structure.
+func checkTree(t *testing.T, tr *Tree[int, int], want []int) {
+	t.Helper()
+	if got := slices.Collect(tr.Values()); !slices.Equal(got, want) {
+		t.Fatalf("Values=%v, want %v", got, want)
+	}
+	if tr.IsEmpty() != (len(want) == 0) || tr.Measure() != len(want) {
+		t.Fatalf("IsEmpty=%v Measure=%d, want %d elements", tr.IsEmpty(), tr.Measure(), len(want))
+	}
+	if len(want) > 0 && (tr.Front() != want[0] || tr.Back() != want[len(want)-1]) {
+		t.Fatalf("Front=%d Back=%d", tr.Front(), tr.Back())
+	}
+	checkFTree(t, tr.t, 0)
+}
+
+func checkFTree(t *testing.T, ft *ftree[int, int], level int) int {
+	t.Helper()
+	if ft == nil {
+		return 0
+	}
+	if ft.single != nil {
+		m := checkNode(t, ft.single, level)
+		if ft.m != m {
+			t.Fatalf("single measure %d, want %d", ft.m, m)
+		}
+		return m
+	}
+	m := 0
+	for _, ds := range [][]*node[int, int]{ft.prefix, ft.suffix} {
+		if len(ds) < 1 || len(ds) > 4 {
+			t.Fatalf("digit of %d nodes", len(ds))
+		}
+		for _, n := range ds {
+			m += checkNode(t, n, level)
+		}
+	}
+	m += checkFTree(t, ft.middle, level+1)
+	if ft.m != m {
+		t.Fatalf("deep measure %d, want %d", ft.m, m)
+	}
+	return m
+}
+
+func checkNode(t *testing.T, n *node[int, int], level int) int {
+	t.Helper()
+	if level == 0 {
+		if n.children != nil || n.m != 1 {
+			t.Fatalf("bad leaf %+v", n)
+		}
+		return 1
+	}
+	if len(n.children) < 2 || len(n.children) > 3 {
+		t.Fatalf("node with %d children", len(n.children))
+	}
+	m := 0
+	for _, c := range n.children {
+		m += checkNode(t, c, level-1)
+	}
+	if n.m != m {
+		t.Fatalf("node measure %d, want %d", n.m, m)
+	}
+	return m
+}
+
+func fromSlice(vals []int) *Tree[int, int] {
+	t := New(sizeMeasure)
+	for _, v := range vals {
+		t = t.PushBack(v)
+	}
+	return t
+}
+
+func TestDeque(t *testing.T) {
+	tr := New(sizeMeasure)
+	checkTree(t, tr, nil)
+	var want []int
+	for i := range 200 {
+		if i%3 == 0 {
+			tr = tr.PushFront(i)
+			want = append([]int{i}, want...)
+		} else {
+			tr = tr.PushBack(i)
+			want = append(want, i)
+		}
+		checkTree(t, tr, want)
+	}
+	for len(want) > 0 {
+		var v int
+		if len(want)%2 == 0 {
+			v, tr = tr.PopFront()
+			if v != want[0] {
+				t.Fatalf("PopFront=%d, want %d", v, want[0])
+			}
+			want = want[1:]
+		} else {
+			v, tr = tr.PopBack()
+			if v != want[len(want)-1] {
+				t.Fatalf("PopBack=%d, want %d", v, want[len(want)-1])
+			}
+			want = want[:len(want)-1]
+		}
+		checkTree(t, tr, want)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := New(sizeMeasure)
+	var want []int
+	var versions []*Tree[int, int]
+	var contents [][]int
+	for i := range 3000 {
+		switch op := rnd.IntN(10); {
+		case op < 3:
+			tr = tr.PushBack(i)
+			want = append(slices.Clip(want), i)
+		case op < 5:
+			tr = tr.PushFront(i)
+			want = append([]int{i}, want...)
+		case op < 6 && len(want) > 0:
+			_, tr = tr.PopFront()
+			want = want[1:]
+		case op < 7 && len(want) > 0:
+			_, tr = tr.PopBack()
+			want = want[:len(want)-1]
+		case op < 8:
+			// Split and concatenate the parts in the opposite order.
+			k := rnd.IntN(len(want) + 1)
+			l, r := splitAt(tr, k)
+			tr = r.Concat(l)
+			want = append(slices.Clone(want[k:]), want[:k]...)
+		case op < 9:
+			// Concatenate with another random tree.
+			other := make([]int, rnd.IntN(100))
+			for j := range other {
+				other[j] = -j
+			}
+			tr = tr.Concat(fromSlice(other))
+			want = append(slices.Clip(want), other...)
+		default:
+			if len(want) > 0 {
+				k := rnd.IntN(len(want))
+				if v, ok := tr.Find(func(m int) bool { return m > k }); !ok || v != want[k] {
+					t.Fatalf("Find(%d)=%d,%v, want %d", k, v, ok, want[k])
+				}
+			}
+		}
+		if i%100 == 0 {
+			checkTree(t, tr, want)
+			versions = append(versions, tr)
+			contents = append(contents, slices.Clone(want))
+		}
+	}
+	checkTree(t, tr, want)
+	for i, v := range versions {
+		checkTree(t, v, contents[i])
+	}
+}
+
+func TestSplitEdges(t *testing.T) {
+	tr := fromSlice([]int{1, 2, 3, 4, 5})
+	l, r := tr.Split(func(m int) bool { return m > 10 })
+	checkTree(t, l, []int{1, 2, 3, 4, 5})
+	checkTree(t, r, nil)
+	l, r = tr.Split(func(m int) bool { return true })
+	checkTree(t, l, nil)
+	checkTree(t, r, []int{1, 2, 3, 4, 5})
+	l, r = New(sizeMeasure).Split(func(m int) bool { return true })
+	checkTree(t, l, nil)
+	checkTree(t, r, nil)
+	if _, ok := tr.Find(func(m int) bool { return m > 5 }); ok {
+		t.Errorf("Find past the end succeeded")
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	// Measuring by priority and combining with max makes a priority queue:
+	// the element with the highest priority is the first one where the
+	// running max reaches the max of the tree.
+	rnd := makeLoggedRand(t)
+	tr := New(Measure[float64, float64]{
+		Of:       func(v float64) float64 { return v },
+		Combine:  math.Max,
+		Identity: math.Inf(-1),
+	})
+	var want []float64
+	for range 500 {
+		v := rnd.Float64()
+		tr = tr.PushBack(v)
+		want = append(want, v)
+	}
+	slices.Sort(want)
+	for i := len(want) - 1; i >= 0; i-- {
+		top := tr.Measure()
+		l, r := tr.Split(func(m float64) bool { return m >= top })
+		v, rest := r.PopFront()
+		if v != want[i] {
+			t.Fatalf("popped %v, want %v", v, want[i])
+		}
+		tr = l.Concat(rest)
+	}
+	if !tr.IsEmpty() {
+		t.Errorf("tree not empty")
+	}
+}
+
+func TestPanics(t *testing.T) {
+	empty := New(sizeMeasure)
+	for name, f := range map[string]func(){
+		"front":    func() { empty.Front() },
+		"back":     func() { empty.Back() },
+		"popfront": func() { empty.PopFront() },
+		"popback":  func() { empty.PopBack() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func BenchmarkPushBack(b *testing.B) {
+	for range b.N {
+		t := New(sizeMeasure)
+		for i := range 10000 {
+			t = t.PushBack(i)
+		}
+	}
+}
+
+func BenchmarkSplit(b *testing.B) {
+	const n = 100000
+	t := New(sizeMeasure)
+	for i := range n {
+		t = t.PushBack(i)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		k := i % n
+		splitAt(t, k)
+	}
+}
@@ -0,0 +1,194 @@
+// Package gapbuffer implements a gap buffer: a byte sequence optimized for
+// edits clustered around a cursor, as in a text editor.
+package gapbuffer
+
+import "bytes"
+
+// minGap is the minimal size of the gap created when the buffer grows.
+const minGap = 64
+
+// Buffer is a gap buffer. Its contents are stored in a single byte slice
+// with a gap at the cursor position, so that insertions and deletions at the
+// cursor take amortized O(1) time; moving the cursor by d bytes takes O(d)
+// time. The zero value is an empty buffer ready to use.
+type Buffer struct {
+	// The contents are buf[:gapStart] followed by buf[gapEnd:]; the cursor is
+	// always at gapStart.
+	buf              []byte
+	gapStart, gapEnd int
+}
+
+// New creates a new, empty buffer.
+func New() *Buffer {
+	return &Buffer{}
+}
+
+// FromBytes creates a new buffer holding a copy of b, with the cursor at
+// the end.
+func FromBytes(b []byte) *Buffer {
+	buf := make([]byte, len(b)+minGap)
+	copy(buf, b)
+	return &Buffer{buf: buf, gapStart: len(b), gapEnd: len(buf)}
+}
+
+// Len returns the length of the buffer's contents in bytes.
+func (gb *Buffer) Len() int {
+	return len(gb.buf) - (gb.gapEnd - gb.gapStart)
+}
+
+// Cursor returns the position of the cursor, in the range [0, Len()].
+func (gb *Buffer) Cursor() int {
+	return gb.gapStart
+}
+
+// MoveCursor moves the cursor to position pos. It panics if pos is not in
+// the range [0, Len()].
+func (gb *Buffer) MoveCursor(pos int) {
+	if pos < 0 || pos > gb.Len() {
+		panic("gapbuffer: cursor position out of range")
+	}
+	if pos < gb.gapStart {
+		n := gb.gapStart - pos
+		copy(gb.buf[gb.gapEnd-n:gb.gapEnd], gb.buf[pos:gb.gapStart])
+		gb.gapStart -= n
+		gb.gapEnd -= n
+	} else if pos > gb.gapStart {
+		n := pos - gb.gapStart
+		copy(gb.buf[gb.gapStart:], gb.buf[gb.gapEnd:gb.gapEnd+n])
+		gb.gapStart += n
+		gb.gapEnd += n
+	}
+}
+
+// Insert inserts b at the cursor, and moves the cursor past it.
+func (gb *Buffer) Insert(b []byte) {
+	gb.ensureGap(len(b))
+	gb.gapStart += copy(gb.buf[gb.gapStart:], b)
+}
+
+// InsertString inserts s at the cursor, and moves the cursor past it.
+func (gb *Buffer) InsertString(s string) {
+	gb.ensureGap(len(s))
+	gb.gapStart += copy(gb.buf[gb.gapStart:], s)
+}
+
+// InsertByte inserts c at the cursor, and moves the cursor past it.
+func (gb *Buffer) InsertByte(c byte) {
+	gb.ensureGap(1)
+	gb.buf[gb.gapStart] = c
+	gb.gapStart++
+}
+
+// Delete deletes up to n bytes following the cursor, and returns the number
+// of bytes deleted; a negative n deletes nothing.
+func (gb *Buffer) Delete(n int) int {
+	n = max(0, min(n, len(gb.buf)-gb.gapEnd))
+	gb.gapEnd += n
+	return n
+}
+
+// Backspace deletes up to n bytes preceding the cursor, and returns the
+// number of bytes deleted; a negative n deletes nothing.
+func (gb *Buffer) Backspace(n int) int {
+	n = max(0, min(n, gb.gapStart))
+	gb.gapStart -= n
+	return n
+}
+
+// At returns the byte at position i. It panics if i is out of range.
+func (gb *Buffer) At(i int) byte {
+	if i < 0 || i >= gb.Len() {
+		panic("gapbuffer: index out of range")
+	}
+	return gb.buf[gb.physical(i)]
+}
+
+// Bytes returns a copy of the buffer's contents.
+func (gb *Buffer) Bytes() []byte {
+	result := make([]byte, 0, gb.Len())
+	result = append(result, gb.buf[:gb.gapStart]...)
+	return append(result, gb.buf[gb.gapEnd:]...)
+}
+
+// String returns the buffer's contents as a string.
+func (gb *Buffer) String() string {
+	return string(gb.Bytes())
+}
+
+// LineCol translates position pos into a 0-based line number and a byte
+// column within that line; lines are separated by '\n'. It panics if pos is
+// not in the range [0, Len()].
+func (gb *Buffer) LineCol(pos int) (line, col int) {
+	if pos < 0 || pos > gb.Len() {
+		panic("gapbuffer: position out of range")
+	}
+	before := gb.buf[:min(pos, gb.gapStart)]
+	line = bytes.Count(before, []byte{'\n'})
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	if pos > gb.gapStart {
+		after := gb.buf[gb.gapEnd:gb.physical(pos)]
+		if n := bytes.Count(after, []byte{'\n'}); n > 0 {
+			return line + n, len(after) - bytes.LastIndexByte(after, '\n') - 1
+		}
+	}
+	return line, pos - lineStart
+}
+
+// Pos translates a 0-based line number and byte column into a position; it
+// is the inverse of [Buffer.LineCol]. Columns past the end of the line are
+// clamped to the position of the line's terminating '\n' (or the end of the
+// buffer). It returns -1 if the buffer has fewer than line+1 lines.
+func (gb *Buffer) Pos(line, col int) int {
+	pos := 0
+	for line > 0 {
+		i := gb.indexNewline(pos)
+		if i < 0 {
+			return -1
+		}
+		pos = i + 1
+		line--
+	}
+	end := gb.indexNewline(pos)
+	if end < 0 {
+		end = gb.Len()
+	}
+	return min(pos+max(col, 0), end)
+}
+
+// indexNewline returns the position of the first '\n' at or after position
+// from, or -1 if there's none.
+func (gb *Buffer) indexNewline(from int) int {
+	if from < gb.gapStart {
+		if i := bytes.IndexByte(gb.buf[from:gb.gapStart], '\n'); i >= 0 {
+			return from + i
+		}
+		from = gb.gapStart
+	}
+	if i := bytes.IndexByte(gb.buf[gb.physical(from):], '\n'); i >= 0 {
+		return from + i
+	}
+	return -1
+}
+
+// physical translates a position in the contents into an index in buf.
+func (gb *Buffer) physical(pos int) int {
+	if pos < gb.gapStart {
+		return pos
+	}
+	return pos + gb.gapEnd - gb.gapStart
+}
+
+// ensureGap makes sure the gap can hold at least n bytes, growing the
+// buffer if needed.
+func (gb *Buffer) ensureGap(n int) {
+	if gb.gapEnd-gb.gapStart >= n {
+		return
+	}
+	newLen := max(2*len(gb.buf), gb.Len()+n+minGap)
+	newBuf := make([]byte, newLen)
+	copy(newBuf, gb.buf[:gb.gapStart])
+	tail := len(gb.buf) - gb.gapEnd
+	copy(newBuf[newLen-tail:], gb.buf[gb.gapEnd:])
+	gb.buf = newBuf
+	gb.gapEnd = newLen - tail
+}
@@ -0,0 +1,126 @@
+package gapbuffer
+
+import (
+	"log"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func checkContents(t *testing.T, gb *Buffer, want string) {
+	t.Helper()
+	if gb.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", gb.Len(), len(want))
+	}
+	if got := gb.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestBasic(t *testing.T) {
+	var gb Buffer
+	checkContents(t, &gb, "")
+	gb.InsertString("hello")
+	gb.InsertByte(' ')
+	gb.Insert([]byte("world"))
+	checkContents(t, &gb, "hello world")
+	if gb.Cursor() != 11 {
+		t.Errorf("got cursor %d", gb.Cursor())
+	}
+
+	gb.MoveCursor(5)
+	gb.InsertString(",")
+	checkContents(t, &gb, "hello, world")
+	if gb.Delete(1) != 1 || gb.Backspace(100) != 6 {
+		t.Errorf("bad deletion counts")
+	}
+	checkContents(t, &gb, "world")
+	if gb.Delete(100) != 5 || gb.Len() != 0 {
+		t.Errorf("bad deletion")
+	}
+
+	gb2 := FromBytes([]byte("abc"))
+	gb2.MoveCursor(0)
+	gb2.InsertString("xy")
+	checkContents(t, gb2, "xyabc")
+	if gb2.At(0) != 'x' || gb2.At(2) != 'a' || gb2.At(4) != 'c' {
+		t.Errorf("bad At")
+	}
+}
+
+func TestDeleteNegative(t *testing.T) {
+	gb := FromBytes([]byte("hello world"))
+	gb.MoveCursor(5)
+	gb.Delete(1)
+	gb.Backspace(1)
+	if gb.Delete(-3) != 0 || gb.Backspace(-3) != 0 {
+		t.Errorf("negative deletions deleted bytes")
+	}
+	checkContents(t, gb, "hellworld")
+	if gb.Cursor() != 4 {
+		t.Errorf("got cursor %d, want 4", gb.Cursor())
+	}
+}
+
+func TestLineCol(t *testing.T) {
+	text := "first\nsecond line\n\nlast"
+	gb := FromBytes([]byte(text))
+
+	// Check all positions with the cursor at a few places, since the gap
+	// shouldn't affect the results.
+	for _, cursor := range []int{0, 3, 6, 17, 18, len(text)} {
+		gb.MoveCursor(cursor)
+		line, col := 0, 0
+		for pos := 0; pos <= len(text); pos++ {
+			gotLine, gotCol := gb.LineCol(pos)
+			if gotLine != line || gotCol != col {
+				t.Fatalf("cursor %d: LineCol(%d)=%d,%d, want %d,%d", cursor, pos, gotLine, gotCol, line, col)
+			}
+			if p := gb.Pos(line, col); p != pos {
+				t.Fatalf("cursor %d: Pos(%d, %d)=%d, want %d", cursor, line, col, p, pos)
+			}
+			if pos < len(text) && text[pos] == '\n' {
+				line, col = line+1, 0
+			} else {
+				col++
+			}
+		}
+	}
+
+	if gb.Pos(0, 100) != 5 || gb.Pos(3, 100) != len(text) || gb.Pos(4, 0) != -1 {
+		t.Errorf("bad clamping")
+	}
+}
+
+func TestRandomEdits(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	gb := New()
+	var want string
+	for range 5000 {
+		cur := gb.Cursor()
+		switch rnd.IntN(5) {
+		case 0, 1:
+			s := strings.Repeat("xy\n", rnd.IntN(20))
+			gb.InsertString(s)
+			want = want[:cur] + s + want[cur:]
+		case 2:
+			n := gb.Delete(rnd.IntN(10))
+			want = want[:cur] + want[cur+n:]
+		case 3:
+			n := gb.Backspace(rnd.IntN(10))
+			want = want[:cur-n] + want[cur:]
+		case 4:
+			gb.MoveCursor(rnd.IntN(len(want) + 1))
+		}
+		if len(want) > 0 {
+			i := rnd.IntN(len(want))
+			if gb.At(i) != want[i] {
+				t.Fatalf("At(%d)=%c, want %c", i, gb.At(i), want[i])
+			}
+		}
+	}
+	checkContents(t, gb, want)
+}
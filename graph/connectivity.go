@@ -0,0 +1,94 @@
+package graph
+
+import "github.com/eliben/gogl/unionfind"
+
+// Connectivity answers connectivity queries over an undirected graph that
+// evolves by adding nodes and edges. It's a disjoint-set (union-find)
+// structure over the graph's nodes.
+//
+// A Connectivity created with [NewConnectivity] uses union by rank with path
+// compression, giving near-O(1) amortized operations. A Connectivity
+// created with [NewRollbackConnectivity] additionally supports undoing edge
+// additions with Checkpoint and Rollback (useful for backtracking searches);
+// since rollback is incompatible with path compression, its operations are
+// O(log n).
+type Connectivity[N comparable] struct {
+	sets *unionfind.Keyed[N]
+}
+
+// Checkpoint identifies a point in the history of a Connectivity, to which it
+// can be rolled back.
+type Checkpoint = unionfind.Checkpoint
+
+// NewConnectivity creates a new, empty Connectivity.
+func NewConnectivity[N comparable]() *Connectivity[N] {
+	return &Connectivity[N]{sets: unionfind.NewKeyed[N]()}
+}
+
+// NewRollbackConnectivity creates a new, empty Connectivity that supports
+// Checkpoint and Rollback.
+func NewRollbackConnectivity[N comparable]() *Connectivity[N] {
+	return &Connectivity[N]{sets: unionfind.NewKeyedWithRollback[N]()}
+}
+
+// ConnectivityOf creates a new Connectivity with the nodes and edges of g
+// (edge directions are ignored).
+func ConnectivityOf[N comparable, E any](g *Graph[N, E]) *Connectivity[N] {
+	c := NewConnectivity[N]()
+	for n := range g.Nodes() {
+		c.AddNode(n)
+	}
+	for e := range g.Edges() {
+		c.AddEdge(e.From, e.To)
+	}
+	return c
+}
+
+// AddNode adds node n as a new component. If n is already known, this is a
+// no-op.
+func (c *Connectivity[N]) AddNode(n N) {
+	c.sets.Add(n)
+}
+
+// NumNodes returns the number of nodes.
+func (c *Connectivity[N]) NumNodes() int {
+	return c.sets.Len()
+}
+
+// NumComponents returns the number of connected components.
+func (c *Connectivity[N]) NumComponents() int {
+	return c.sets.Count()
+}
+
+// AddEdge adds an undirected edge between u and v, adding the nodes if they
+// aren't known yet. It returns true if this edge connected two previously
+// separate components, and false if u and v were already connected.
+func (c *Connectivity[N]) AddEdge(u, v N) bool {
+	return c.sets.Union(u, v)
+}
+
+// Connected reports whether u and v are in the same connected component.
+// Unknown nodes are only connected to themselves.
+func (c *Connectivity[N]) Connected(u, v N) bool {
+	return c.sets.Connected(u, v)
+}
+
+// ComponentSize returns the number of nodes in the component of n, or 0 if n
+// is unknown.
+func (c *Connectivity[N]) ComponentSize(n N) int {
+	return c.sets.Size(n)
+}
+
+// Checkpoint returns a checkpoint for the current state, which can later be
+// passed to Rollback. It panics if c doesn't support rollback.
+func (c *Connectivity[N]) Checkpoint() Checkpoint {
+	return c.sets.Checkpoint()
+}
+
+// Rollback undoes all the edge additions that merged components since the
+// checkpoint cp was taken. Nodes added since cp remain known, as singleton
+// components. Checkpoints taken after cp become invalid. It panics if c
+// doesn't support rollback.
+func (c *Connectivity[N]) Rollback(cp Checkpoint) {
+	c.sets.Rollback(cp)
+}
@@ -0,0 +1,121 @@
+package graph
+
+import (
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestConnectivityBasic(t *testing.T) {
+	c := NewConnectivity[string]()
+	c.AddNode("a")
+	c.AddNode("b")
+	c.AddNode("c")
+	if c.NumComponents() != 3 || c.NumNodes() != 3 {
+		t.Errorf("got %d components, want 3", c.NumComponents())
+	}
+
+	if !c.AddEdge("a", "b") || c.AddEdge("b", "a") {
+		t.Errorf("bad AddEdge results")
+	}
+	if !c.Connected("a", "b") || c.Connected("a", "c") {
+		t.Errorf("bad Connected results")
+	}
+	if c.Connected("a", "zz") || !c.Connected("zz", "zz") {
+		t.Errorf("bad Connected results for unknown nodes")
+	}
+
+	c.AddEdge("c", "d")
+	c.AddEdge("d", "a")
+	if c.NumComponents() != 1 || c.ComponentSize("b") != 4 || c.ComponentSize("zz") != 0 {
+		t.Errorf("got %d components, size %d", c.NumComponents(), c.ComponentSize("b"))
+	}
+}
+
+func TestConnectivityOf(t *testing.T) {
+	g := NewDirected[int, struct{}]()
+	g.AddEdge(1, 2, struct{}{})
+	g.AddEdge(3, 2, struct{}{})
+	g.AddEdge(4, 5, struct{}{})
+	g.AddNode(6)
+
+	c := ConnectivityOf(g)
+	if c.NumComponents() != 3 {
+		t.Errorf("got %d components, want 3", c.NumComponents())
+	}
+	if !c.Connected(1, 3) || c.Connected(1, 4) || c.Connected(5, 6) {
+		t.Errorf("bad Connected results")
+	}
+}
+
+func TestConnectivityRollback(t *testing.T) {
+	c := NewRollbackConnectivity[int]()
+	c.AddEdge(1, 2)
+	cp1 := c.Checkpoint()
+	c.AddEdge(2, 3)
+	c.AddEdge(4, 5)
+	cp2 := c.Checkpoint()
+	c.AddEdge(3, 4)
+	c.AddEdge(1, 6)
+
+	if !c.Connected(1, 5) || c.NumComponents() != 1 {
+		t.Errorf("expected all connected")
+	}
+
+	c.Rollback(cp2)
+	if c.Connected(1, 5) || c.Connected(1, 6) || !c.Connected(1, 3) || !c.Connected(4, 5) {
+		t.Errorf("bad state after rollback to cp2")
+	}
+	// Node 6 was added after cp2, so it remains as a singleton.
+	if c.NumComponents() != 3 || c.ComponentSize(1) != 3 {
+		t.Errorf("got %d components after rollback, want 3", c.NumComponents())
+	}
+
+	c.Rollback(cp1)
+	if c.Connected(2, 3) || !c.Connected(1, 2) || c.NumComponents() != 5 {
+		t.Errorf("bad state after rollback to cp1")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	NewConnectivity[int]().Checkpoint()
+}
+
+func TestConnectivityRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	// Compare against a naive labeling: label[n] is the component of n.
+	for _, rollback := range []bool{false, true} {
+		c := NewConnectivity[int]()
+		if rollback {
+			c = NewRollbackConnectivity[int]()
+		}
+		label := make([]int, 200)
+		for i := range label {
+			label[i] = i
+			c.AddNode(i)
+		}
+		for range 150 {
+			u, v := rnd.IntN(200), rnd.IntN(200)
+			merged := c.AddEdge(u, v)
+			if merged != (label[u] != label[v]) {
+				t.Fatalf("AddEdge(%d, %d) = %v", u, v, merged)
+			}
+			old := label[v]
+			for i := range label {
+				if label[i] == old {
+					label[i] = label[u]
+				}
+			}
+			a, b := rnd.IntN(200), rnd.IntN(200)
+			if c.Connected(a, b) != (label[a] == label[b]) {
+				t.Fatalf("Connected(%d, %d) mismatch", a, b)
+			}
+		}
+	}
+}
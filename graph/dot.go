@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DotOptions configures the output of [Graph.WriteDot]. All fields are
+// optional.
+type DotOptions[N comparable, E any] struct {
+	// Name is the name of the graph in the output.
+	Name string
+
+	// NodeLabel returns the label for node n. If nil, fmt.Sprint(n) is used.
+	NodeLabel func(n N) string
+
+	// EdgeLabel returns the label for the edge u->v with the given payload.
+	// If nil, edges are not labeled.
+	EdgeLabel func(u, v N, payload E) string
+
+	// NodeAttrs returns additional Graphviz attributes (like "color" or
+	// "shape") for node n.
+	NodeAttrs func(n N) map[string]string
+
+	// EdgeAttrs returns additional Graphviz attributes (like "style") for the
+	// edge u->v.
+	EdgeAttrs func(u, v N, payload E) map[string]string
+}
+
+// WriteDot writes a representation of g in the Graphviz DOT language to w.
+// opts may be nil, in which case default options are used.
+//
+// To produce deterministic output, nodes are emitted sorted by their default
+// string representation (fmt.Sprint), with nodes of the same representation
+// in the order they were added to g, and edges sorted by their endpoints.
+func (g *Graph[N, E]) WriteDot(w io.Writer, opts *DotOptions[N, E]) error {
+	if opts == nil {
+		opts = &DotOptions[N, E]{}
+	}
+
+	// Assign each node a stable identifier based on its sorted position.
+	type namedNode struct {
+		n    N
+		repr string
+		seq  int
+	}
+	var nodes []namedNode
+	for n, vx := range g.nodes {
+		nodes = append(nodes, namedNode{n, fmt.Sprint(n), vx.seq})
+	}
+	slices.SortFunc(nodes, func(a, b namedNode) int {
+		return cmp.Or(cmp.Compare(a.repr, b.repr), cmp.Compare(a.seq, b.seq))
+	})
+	ids := make(map[N]int, len(nodes))
+	for i, nn := range nodes {
+		ids[nn.n] = i
+	}
+
+	edges := slices.Collect(g.Edges())
+	slices.SortFunc(edges, func(a, b Edge[N, E]) int {
+		return cmp.Or(cmp.Compare(ids[a.From], ids[b.From]), cmp.Compare(ids[a.To], ids[b.To]))
+	})
+
+	bw := bufio.NewWriter(w)
+	kind, arrow := "graph", "--"
+	if g.directed {
+		kind, arrow = "digraph", "->"
+	}
+	if opts.Name != "" {
+		fmt.Fprintf(bw, "%s %s {\n", kind, dotQuote(opts.Name))
+	} else {
+		fmt.Fprintf(bw, "%s {\n", kind)
+	}
+
+	for i, nn := range nodes {
+		attrs := make(map[string]string)
+		if opts.NodeAttrs != nil {
+			maps.Copy(attrs, opts.NodeAttrs(nn.n))
+		}
+		if opts.NodeLabel != nil {
+			attrs["label"] = opts.NodeLabel(nn.n)
+		} else {
+			attrs["label"] = nn.repr
+		}
+		fmt.Fprintf(bw, "  n%d%s;\n", i, formatDotAttrs(attrs))
+	}
+
+	for _, e := range edges {
+		attrs := make(map[string]string)
+		if opts.EdgeAttrs != nil {
+			maps.Copy(attrs, opts.EdgeAttrs(e.From, e.To, e.Payload))
+		}
+		if opts.EdgeLabel != nil {
+			attrs["label"] = opts.EdgeLabel(e.From, e.To, e.Payload)
+		}
+		fmt.Fprintf(bw, "  n%d %s n%d%s;\n", ids[e.From], arrow, ids[e.To], formatDotAttrs(attrs))
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// formatDotAttrs formats attrs as a DOT attribute list (with a leading
+// space), sorted by key. It returns an empty string for empty attrs.
+func formatDotAttrs(attrs map[string]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	s := " ["
+	for i, k := range slices.Sorted(maps.Keys(attrs)) {
+		if i > 0 {
+			s += ", "
+		}
+		s += k + "=" + dotQuote(attrs[k])
+	}
+	return s + "]"
+}
+
+// dotEscaper escapes the characters that are special in DOT quoted strings:
+// quotes, and backslashes, which would otherwise start escapes like \n in
+// labels.
+var dotEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+
+// dotQuote returns s as a DOT quoted string. Unlike strconv.Quote, it leaves
+// other characters as they are, since DOT doesn't interpret Go's escapes.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
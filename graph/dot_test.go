@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteDotDirected(t *testing.T) {
+	g := NewDirected[string, int]()
+	g.AddEdge("b", "c", 2)
+	g.AddEdge("a", "b", 1)
+	g.AddNode("d")
+
+	var sb strings.Builder
+	err := g.WriteDot(&sb, &DotOptions[string, int]{
+		Name:      "deps",
+		EdgeLabel: func(u, v string, w int) string { return fmt.Sprint(w) },
+		NodeAttrs: func(n string) map[string]string {
+			if n == "d" {
+				return map[string]string{"shape": "box"}
+			}
+			return nil
+		},
+		EdgeAttrs: func(u, v string, w int) map[string]string {
+			return map[string]string{"style": "dashed"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph "deps" {
+  n0 [label="a"];
+  n1 [label="b"];
+  n2 [label="c"];
+  n3 [label="d", shape="box"];
+  n0 -> n1 [label="1", style="dashed"];
+  n1 -> n2 [label="2", style="dashed"];
+}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteDotUndirectedDefaults(t *testing.T) {
+	g := NewUndirected[int, struct{}]()
+	g.AddEdge(2, 1, struct{}{})
+
+	var sb strings.Builder
+	if err := g.WriteDot(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `graph {
+  n0 [label="1"];
+  n1 [label="2"];
+  n1 -- n0;
+}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteDotQuoting(t *testing.T) {
+	g := NewDirected[string, struct{}]()
+	g.AddNode(`say "hi"`)
+
+	var sb strings.Builder
+	g.WriteDot(&sb, &DotOptions[string, struct{}]{
+		NodeLabel: func(n string) string { return "label: " + n },
+	})
+	if !strings.Contains(sb.String(), `[label="label: say \"hi\""]`) {
+		t.Errorf("bad quoting in:\n%s", sb.String())
+	}
+}
+
+func TestWriteDotUnicode(t *testing.T) {
+	// Only quotes and backslashes are escaped; DOT doesn't interpret Go's
+	// escape sequences, so other characters are written as they are.
+	g := NewDirected[string, struct{}]()
+	g.AddNode("naïve\tcafé")
+	g.AddNode(`a\b`)
+
+	var sb strings.Builder
+	g.WriteDot(&sb, &DotOptions[string, struct{}]{Name: "göl"})
+	want := `digraph "göl" {
+  n0 [label="a\\b"];
+  n1 [label="naïve	café"];
+}
+`
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteDotTies(t *testing.T) {
+	// Nodes with the same string representation are emitted in the order
+	// they were added.
+	g := NewDirected[any, struct{}]()
+	g.AddNode("1")
+	g.AddNode(1)
+	g.AddNode(int8(1))
+	g.AddEdge(int8(1), "1", struct{}{})
+
+	want := `digraph {
+  n0 [label="string"];
+  n1 [label="int"];
+  n2 [label="int8"];
+  n2 -> n0;
+}
+`
+	for range 10 {
+		var sb strings.Builder
+		g.WriteDot(&sb, &DotOptions[any, struct{}]{
+			NodeLabel: func(n any) string { return fmt.Sprintf("%T", n) },
+		})
+		if got := sb.String(); got != want {
+			t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
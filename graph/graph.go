@@ -0,0 +1,325 @@
+// Package graph implements generic directed and undirected graphs, along with
+// some common graph algorithms.
+package graph
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Graph is a directed or undirected graph. Nodes are identified by values of
+// a comparable type N, and every edge carries a payload of type E (use
+// struct{} if no payload is needed). Nodes and edges may additionally hold
+// arbitrary string-keyed attributes.
+//
+// There can be at most one edge from node u to node v; self-loops are
+// allowed. Create graphs with [NewDirected] or [NewUndirected].
+//
+// The iteration order of nodes and edges is unspecified.
+type Graph[N comparable, E any] struct {
+	directed bool
+	nodes    map[N]*vertex[N, E]
+	numEdges int
+
+	// nextSeq is the insertion index of the next node added.
+	nextSeq int
+}
+
+// vertex holds the adjacency information of a node. For a directed graph,
+// out holds the edges leading out of this node and in the edges leading into
+// it. For an undirected graph, out holds all the incident edges and in is
+// nil; every edge appears in the out maps of both its endpoints.
+//
+// seq is the insertion index of the node, which orders nodes
+// deterministically where needed.
+type vertex[N comparable, E any] struct {
+	out   map[N]*edge[N, E]
+	in    map[N]*edge[N, E]
+	attrs map[string]any
+	seq   int
+}
+
+type edge[N comparable, E any] struct {
+	from, to N
+	payload  E
+	attrs    map[string]any
+}
+
+// Edge describes an edge in a graph, as yielded by [Graph.Edges].
+type Edge[N comparable, E any] struct {
+	From, To N
+	Payload  E
+}
+
+// NewDirected creates a new, empty directed graph.
+func NewDirected[N comparable, E any]() *Graph[N, E] {
+	return &Graph[N, E]{directed: true, nodes: make(map[N]*vertex[N, E])}
+}
+
+// NewUndirected creates a new, empty undirected graph.
+func NewUndirected[N comparable, E any]() *Graph[N, E] {
+	return &Graph[N, E]{directed: false, nodes: make(map[N]*vertex[N, E])}
+}
+
+// Directed reports whether g is a directed graph.
+func (g *Graph[N, E]) Directed() bool {
+	return g.directed
+}
+
+// NumNodes returns the number of nodes in the graph.
+func (g *Graph[N, E]) NumNodes() int {
+	return len(g.nodes)
+}
+
+// NumEdges returns the number of edges in the graph.
+func (g *Graph[N, E]) NumEdges() int {
+	return g.numEdges
+}
+
+// AddNode adds node n to the graph. If n is already in the graph, this is a
+// no-op.
+func (g *Graph[N, E]) AddNode(n N) {
+	g.addNode(n)
+}
+
+// HasNode reports whether n is a node in the graph.
+func (g *Graph[N, E]) HasNode(n N) bool {
+	_, ok := g.nodes[n]
+	return ok
+}
+
+// RemoveNode removes node n and all its incident edges from the graph. If n
+// is not in the graph, this is a no-op.
+func (g *Graph[N, E]) RemoveNode(n N) {
+	vx, ok := g.nodes[n]
+	if !ok {
+		return
+	}
+	for m := range vx.out {
+		g.RemoveEdge(n, m)
+	}
+	for m := range vx.in {
+		g.RemoveEdge(m, n)
+	}
+	delete(g.nodes, n)
+}
+
+// AddEdge adds an edge from u to v with the given payload; for undirected
+// graphs the order of u and v doesn't matter. u and v are added to the graph
+// if they're not already there. If the edge already exists, its payload is
+// replaced.
+func (g *Graph[N, E]) AddEdge(u, v N, payload E) {
+	vu := g.addNode(u)
+	if e, ok := vu.out[v]; ok {
+		e.payload = payload
+		return
+	}
+	vv := g.addNode(v)
+
+	e := &edge[N, E]{from: u, to: v, payload: payload}
+	vu.out[v] = e
+	if g.directed {
+		vv.in[u] = e
+	} else {
+		vv.out[u] = e
+	}
+	g.numEdges++
+}
+
+// RemoveEdge removes the edge from u to v. It returns true if the edge was
+// found and removed, false otherwise.
+func (g *Graph[N, E]) RemoveEdge(u, v N) bool {
+	vu, ok := g.nodes[u]
+	if !ok {
+		return false
+	}
+	if _, ok := vu.out[v]; !ok {
+		return false
+	}
+	delete(vu.out, v)
+	if g.directed {
+		delete(g.nodes[v].in, u)
+	} else {
+		delete(g.nodes[v].out, u)
+	}
+	g.numEdges--
+	return true
+}
+
+// HasEdge reports whether there's an edge from u to v.
+func (g *Graph[N, E]) HasEdge(u, v N) bool {
+	return g.findEdge(u, v) != nil
+}
+
+// Edge returns the payload of the edge from u to v and ok=true if this edge
+// exists; otherwise, it returns ok=false.
+func (g *Graph[N, E]) Edge(u, v N) (payload E, ok bool) {
+	if e := g.findEdge(u, v); e != nil {
+		return e.payload, true
+	}
+	return payload, false
+}
+
+// Nodes returns an iterator over all the nodes in the graph.
+func (g *Graph[N, E]) Nodes() iter.Seq[N] {
+	return func(yield func(N) bool) {
+		for n := range g.nodes {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Edges returns an iterator over all the edges in the graph. For undirected
+// graphs, each edge is yielded once, with From and To set to the endpoints in
+// the order they were first passed to AddEdge.
+func (g *Graph[N, E]) Edges() iter.Seq[Edge[N, E]] {
+	return func(yield func(Edge[N, E]) bool) {
+		for n, vx := range g.nodes {
+			for _, e := range vx.out {
+				if !g.directed && e.from != n {
+					continue
+				}
+				if !yield(Edge[N, E]{From: e.from, To: e.to, Payload: e.payload}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Neighbors returns an iterator over the neighbors of n, along with the
+// payload of the edge connecting n to each neighbor. For directed graphs
+// these are the successors of n (nodes m such that there's an edge n->m).
+func (g *Graph[N, E]) Neighbors(n N) iter.Seq2[N, E] {
+	return func(yield func(N, E) bool) {
+		vx, ok := g.nodes[n]
+		if !ok {
+			return
+		}
+		for m, e := range vx.out {
+			if !yield(m, e.payload) {
+				return
+			}
+		}
+	}
+}
+
+// Predecessors returns an iterator over the predecessors of n (nodes m such
+// that there's an edge m->n), along with the payload of the connecting edge.
+// For undirected graphs, it's the same as Neighbors.
+func (g *Graph[N, E]) Predecessors(n N) iter.Seq2[N, E] {
+	if !g.directed {
+		return g.Neighbors(n)
+	}
+	return func(yield func(N, E) bool) {
+		vx, ok := g.nodes[n]
+		if !ok {
+			return
+		}
+		for m, e := range vx.in {
+			if !yield(m, e.payload) {
+				return
+			}
+		}
+	}
+}
+
+// OutDegree returns the number of edges leading out of n. For undirected
+// graphs, it's the same as Degree.
+func (g *Graph[N, E]) OutDegree(n N) int {
+	if vx, ok := g.nodes[n]; ok {
+		return len(vx.out)
+	}
+	return 0
+}
+
+// InDegree returns the number of edges leading into n. For undirected graphs,
+// it's the same as Degree.
+func (g *Graph[N, E]) InDegree(n N) int {
+	if !g.directed {
+		return g.OutDegree(n)
+	}
+	if vx, ok := g.nodes[n]; ok {
+		return len(vx.in)
+	}
+	return 0
+}
+
+// Degree returns the number of edges incident to n. For directed graphs, it's
+// the sum of the in-degree and out-degree. A self-loop counts once for each
+// of its ends in directed graphs, and once in undirected graphs.
+func (g *Graph[N, E]) Degree(n N) int {
+	if g.directed {
+		return g.InDegree(n) + g.OutDegree(n)
+	}
+	return g.OutDegree(n)
+}
+
+// SetNodeAttr sets the attribute key of node n to value. It panics if n is
+// not in the graph.
+func (g *Graph[N, E]) SetNodeAttr(n N, key string, value any) {
+	vx, ok := g.nodes[n]
+	if !ok {
+		panic(fmt.Sprintf("node %v not in graph", n))
+	}
+	if vx.attrs == nil {
+		vx.attrs = make(map[string]any)
+	}
+	vx.attrs[key] = value
+}
+
+// NodeAttr returns the value of attribute key of node n, and ok=true if it's
+// set; otherwise it returns ok=false.
+func (g *Graph[N, E]) NodeAttr(n N, key string) (value any, ok bool) {
+	if vx, found := g.nodes[n]; found {
+		value, ok = vx.attrs[key]
+	}
+	return value, ok
+}
+
+// SetEdgeAttr sets the attribute key of the edge from u to v to value. It
+// panics if there's no such edge.
+func (g *Graph[N, E]) SetEdgeAttr(u, v N, key string, value any) {
+	e := g.findEdge(u, v)
+	if e == nil {
+		panic(fmt.Sprintf("edge %v->%v not in graph", u, v))
+	}
+	if e.attrs == nil {
+		e.attrs = make(map[string]any)
+	}
+	e.attrs[key] = value
+}
+
+// EdgeAttr returns the value of attribute key of the edge from u to v, and
+// ok=true if it's set; otherwise it returns ok=false.
+func (g *Graph[N, E]) EdgeAttr(u, v N, key string) (value any, ok bool) {
+	if e := g.findEdge(u, v); e != nil {
+		value, ok = e.attrs[key]
+	}
+	return value, ok
+}
+
+// addNode adds n to the graph if it's not already there, and returns its
+// vertex.
+func (g *Graph[N, E]) addNode(n N) *vertex[N, E] {
+	if vx, ok := g.nodes[n]; ok {
+		return vx
+	}
+	vx := &vertex[N, E]{out: make(map[N]*edge[N, E]), seq: g.nextSeq}
+	g.nextSeq++
+	if g.directed {
+		vx.in = make(map[N]*edge[N, E])
+	}
+	g.nodes[n] = vx
+	return vx
+}
+
+// findEdge returns the edge from u to v, or nil if there's no such edge.
+func (g *Graph[N, E]) findEdge(u, v N) *edge[N, E] {
+	if vx, ok := g.nodes[u]; ok {
+		return vx.out[v]
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// sortedNeighbors collects the neighbors of n in g, sorted.
+func sortedNeighbors[N cmp.Ordered, E any](g *Graph[N, E], n N) []N {
+	return slices.Sorted(maps.Keys(maps.Collect(g.Neighbors(n))))
+}
+
+func sortedPredecessors[N cmp.Ordered, E any](g *Graph[N, E], n N) []N {
+	return slices.Sorted(maps.Keys(maps.Collect(g.Predecessors(n))))
+}
+
+func TestDirectedBasic(t *testing.T) {
+	g := NewDirected[string, int]()
+	if !g.Directed() {
+		t.Errorf("expected directed graph")
+	}
+	g.AddEdge("a", "b", 1)
+	g.AddEdge("a", "c", 2)
+	g.AddEdge("c", "b", 3)
+	g.AddNode("d")
+
+	if g.NumNodes() != 4 || g.NumEdges() != 3 {
+		t.Errorf("got %d nodes, %d edges; want 4, 3", g.NumNodes(), g.NumEdges())
+	}
+	if !g.HasEdge("a", "b") || g.HasEdge("b", "a") {
+		t.Errorf("bad HasEdge for directed edge")
+	}
+	if p, ok := g.Edge("c", "b"); !ok || p != 3 {
+		t.Errorf("got Edge(c, b)=%v,%v", p, ok)
+	}
+
+	if got := sortedNeighbors(g, "a"); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("got neighbors %v", got)
+	}
+	if got := sortedPredecessors(g, "b"); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("got predecessors %v", got)
+	}
+	if g.OutDegree("a") != 2 || g.InDegree("a") != 0 || g.Degree("b") != 2 || g.Degree("d") != 0 {
+		t.Errorf("bad degrees")
+	}
+
+	// Re-adding an edge replaces its payload.
+	g.AddEdge("a", "b", 10)
+	if p, _ := g.Edge("a", "b"); p != 10 || g.NumEdges() != 3 {
+		t.Errorf("got payload=%v, edges=%d", p, g.NumEdges())
+	}
+
+	if !g.RemoveEdge("a", "b") || g.RemoveEdge("a", "b") || g.RemoveEdge("x", "y") {
+		t.Errorf("bad RemoveEdge results")
+	}
+	if g.HasEdge("a", "b") || g.NumEdges() != 2 {
+		t.Errorf("edge not removed")
+	}
+	if got := sortedPredecessors(g, "b"); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("got predecessors %v", got)
+	}
+}
+
+func TestUndirectedBasic(t *testing.T) {
+	g := NewUndirected[int, struct{}]()
+	g.AddEdge(1, 2, struct{}{})
+	g.AddEdge(3, 1, struct{}{})
+	g.AddEdge(2, 2, struct{}{})
+
+	if g.NumNodes() != 3 || g.NumEdges() != 3 {
+		t.Errorf("got %d nodes, %d edges; want 3, 3", g.NumNodes(), g.NumEdges())
+	}
+	if !g.HasEdge(1, 3) || !g.HasEdge(3, 1) {
+		t.Errorf("undirected edge should be found both ways")
+	}
+	if got := sortedNeighbors(g, 1); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("got neighbors %v", got)
+	}
+	if got := sortedPredecessors(g, 1); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("got predecessors %v", got)
+	}
+	if g.Degree(2) != 2 || g.InDegree(1) != 2 {
+		t.Errorf("bad degrees")
+	}
+
+	var edges [][2]int
+	for e := range g.Edges() {
+		edges = append(edges, [2]int{e.From, e.To})
+	}
+	slices.SortFunc(edges, func(a, b [2]int) int { return cmp.Compare(a[0]*10+a[1], b[0]*10+b[1]) })
+	if want := [][2]int{{1, 2}, {2, 2}, {3, 1}}; !slices.Equal(edges, want) {
+		t.Errorf("got edges %v, want %v", edges, want)
+	}
+
+	g.RemoveEdge(1, 3)
+	if g.HasEdge(3, 1) || g.NumEdges() != 2 {
+		t.Errorf("edge not removed in both directions")
+	}
+}
+
+func TestRemoveNode(t *testing.T) {
+	for _, g := range []*Graph[int, string]{NewDirected[int, string](), NewUndirected[int, string]()} {
+		g.AddEdge(1, 2, "")
+		g.AddEdge(2, 3, "")
+		g.AddEdge(3, 2, "")
+		g.AddEdge(2, 2, "")
+		g.AddEdge(1, 3, "")
+
+		g.RemoveNode(2)
+		g.RemoveNode(100)
+		if g.HasNode(2) || g.NumNodes() != 2 || g.NumEdges() != 1 {
+			t.Errorf("directed=%v: got %d nodes, %d edges", g.Directed(), g.NumNodes(), g.NumEdges())
+		}
+		if got := sortedNeighbors(g, 1); !slices.Equal(got, []int{3}) {
+			t.Errorf("got neighbors %v", got)
+		}
+		if g.Degree(3) != 1 {
+			t.Errorf("got degree=%d, want 1", g.Degree(3))
+		}
+	}
+}
+
+func TestAttrs(t *testing.T) {
+	g := NewDirected[string, float64]()
+	g.AddEdge("x", "y", 1.5)
+	g.SetNodeAttr("x", "color", "red")
+	g.SetEdgeAttr("x", "y", "style", "dashed")
+
+	if v, ok := g.NodeAttr("x", "color"); !ok || v != "red" {
+		t.Errorf("got NodeAttr=%v,%v", v, ok)
+	}
+	if _, ok := g.NodeAttr("y", "color"); ok {
+		t.Errorf("found unset attr")
+	}
+	if _, ok := g.NodeAttr("nope", "color"); ok {
+		t.Errorf("found attr of missing node")
+	}
+	if v, ok := g.EdgeAttr("x", "y", "style"); !ok || v != "dashed" {
+		t.Errorf("got EdgeAttr=%v,%v", v, ok)
+	}
+	if _, ok := g.EdgeAttr("y", "x", "style"); ok {
+		t.Errorf("found attr of missing edge")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	g.SetEdgeAttr("y", "x", "style", "bold")
+}
+
+func TestNodesIteration(t *testing.T) {
+	g := NewUndirected[int, int]()
+	for i := range 10 {
+		g.AddNode(i)
+	}
+	got := slices.Sorted(g.Nodes())
+	if len(got) != 10 || got[0] != 0 || got[9] != 9 {
+		t.Errorf("got nodes %v", got)
+	}
+}
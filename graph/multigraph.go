@@ -0,0 +1,283 @@
+package graph
+
+import "iter"
+
+// MultiGraph is a directed or undirected graph that supports parallel edges:
+// any number of edges may connect the same pair of nodes, each with its own
+// payload of type E. Adding an edge returns a handle (*MultiEdge) through
+// which the edge can later be updated or removed.
+//
+// Create multigraphs with [NewMultiDirected] or [NewMultiUndirected]. The
+// iteration order of nodes and edges is unspecified.
+type MultiGraph[N comparable, E any] struct {
+	directed bool
+	nodes    map[N]*multiVertex[N, E]
+	numEdges int
+}
+
+// multiVertex holds the incident edges of a node. For directed graphs, out
+// holds the outgoing edges and in the incoming edges. For undirected graphs,
+// out holds all incident edges and in is nil.
+type multiVertex[N comparable, E any] struct {
+	out map[*MultiEdge[N, E]]struct{}
+	in  map[*MultiEdge[N, E]]struct{}
+}
+
+// MultiEdge is a handle to an edge in a MultiGraph.
+type MultiEdge[N comparable, E any] struct {
+	from, to N
+	payload  E
+
+	// owner is the graph this edge belongs to, or nil if it was removed.
+	owner *MultiGraph[N, E]
+}
+
+// From returns the source node of the edge (for undirected graphs, the first
+// node passed to AddEdge).
+func (e *MultiEdge[N, E]) From() N {
+	return e.from
+}
+
+// To returns the target node of the edge (for undirected graphs, the second
+// node passed to AddEdge).
+func (e *MultiEdge[N, E]) To() N {
+	return e.to
+}
+
+// Payload returns the payload of the edge.
+func (e *MultiEdge[N, E]) Payload() E {
+	return e.payload
+}
+
+// Other returns the endpoint of e that's not n. For self-loops, it returns n.
+func (e *MultiEdge[N, E]) Other(n N) N {
+	if e.from == n {
+		return e.to
+	}
+	return e.from
+}
+
+// NewMultiDirected creates a new, empty directed multigraph.
+func NewMultiDirected[N comparable, E any]() *MultiGraph[N, E] {
+	return &MultiGraph[N, E]{directed: true, nodes: make(map[N]*multiVertex[N, E])}
+}
+
+// NewMultiUndirected creates a new, empty undirected multigraph.
+func NewMultiUndirected[N comparable, E any]() *MultiGraph[N, E] {
+	return &MultiGraph[N, E]{directed: false, nodes: make(map[N]*multiVertex[N, E])}
+}
+
+// Directed reports whether g is a directed graph.
+func (g *MultiGraph[N, E]) Directed() bool {
+	return g.directed
+}
+
+// NumNodes returns the number of nodes in the graph.
+func (g *MultiGraph[N, E]) NumNodes() int {
+	return len(g.nodes)
+}
+
+// NumEdges returns the number of edges in the graph.
+func (g *MultiGraph[N, E]) NumEdges() int {
+	return g.numEdges
+}
+
+// AddNode adds node n to the graph. If n is already in the graph, this is a
+// no-op.
+func (g *MultiGraph[N, E]) AddNode(n N) {
+	g.addNode(n)
+}
+
+// HasNode reports whether n is a node in the graph.
+func (g *MultiGraph[N, E]) HasNode(n N) bool {
+	_, ok := g.nodes[n]
+	return ok
+}
+
+// RemoveNode removes node n and all its incident edges from the graph. If n
+// is not in the graph, this is a no-op.
+func (g *MultiGraph[N, E]) RemoveNode(n N) {
+	vx, ok := g.nodes[n]
+	if !ok {
+		return
+	}
+	for e := range vx.out {
+		g.RemoveEdge(e)
+	}
+	for e := range vx.in {
+		g.RemoveEdge(e)
+	}
+	delete(g.nodes, n)
+}
+
+// AddEdge adds a new edge from u to v with the given payload, and returns a
+// handle to it. u and v are added to the graph if they're not already there.
+// Existing edges between u and v are not affected.
+func (g *MultiGraph[N, E]) AddEdge(u, v N, payload E) *MultiEdge[N, E] {
+	e := &MultiEdge[N, E]{from: u, to: v, payload: payload, owner: g}
+	vu := g.addNode(u)
+	vv := g.addNode(v)
+	vu.out[e] = struct{}{}
+	if g.directed {
+		vv.in[e] = struct{}{}
+	} else {
+		vv.out[e] = struct{}{}
+	}
+	g.numEdges++
+	return e
+}
+
+// RemoveEdge removes edge e from the graph. It returns true if e was removed,
+// and false if e is not in this graph (e.g. it was already removed).
+func (g *MultiGraph[N, E]) RemoveEdge(e *MultiEdge[N, E]) bool {
+	if !g.HasEdge(e) {
+		return false
+	}
+	delete(g.nodes[e.from].out, e)
+	if g.directed {
+		delete(g.nodes[e.to].in, e)
+	} else {
+		delete(g.nodes[e.to].out, e)
+	}
+	e.owner = nil
+	g.numEdges--
+	return true
+}
+
+// HasEdge reports whether edge e is in the graph.
+func (g *MultiGraph[N, E]) HasEdge(e *MultiEdge[N, E]) bool {
+	return e.owner == g
+}
+
+// SetPayload replaces the payload of edge e. It panics if e is not in the
+// graph.
+func (g *MultiGraph[N, E]) SetPayload(e *MultiEdge[N, E], payload E) {
+	if !g.HasEdge(e) {
+		panic("edge not in graph")
+	}
+	e.payload = payload
+}
+
+// Nodes returns an iterator over all the nodes in the graph.
+func (g *MultiGraph[N, E]) Nodes() iter.Seq[N] {
+	return func(yield func(N) bool) {
+		for n := range g.nodes {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Edges returns an iterator over all the edges in the graph.
+func (g *MultiGraph[N, E]) Edges() iter.Seq[*MultiEdge[N, E]] {
+	return func(yield func(*MultiEdge[N, E]) bool) {
+		for n, vx := range g.nodes {
+			for e := range vx.out {
+				// For undirected graphs, each edge appears in the out sets of
+				// both its endpoints; only yield it from its from node.
+				if !g.directed && e.from != n {
+					continue
+				}
+				if !yield(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// EdgesBetween returns an iterator over all the edges from u to v. For
+// undirected graphs, it includes the edges between u and v in both
+// directions.
+func (g *MultiGraph[N, E]) EdgesBetween(u, v N) iter.Seq[*MultiEdge[N, E]] {
+	return func(yield func(*MultiEdge[N, E]) bool) {
+		for m, e := range g.Neighbors(u) {
+			if m == v && !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Neighbors returns an iterator over the edges leading out of n (for
+// undirected graphs, all the edges incident to n), yielding the node on the
+// other end of each edge along with the edge. A neighbor connected to n with
+// several parallel edges is yielded once per edge.
+func (g *MultiGraph[N, E]) Neighbors(n N) iter.Seq2[N, *MultiEdge[N, E]] {
+	return func(yield func(N, *MultiEdge[N, E]) bool) {
+		vx, ok := g.nodes[n]
+		if !ok {
+			return
+		}
+		for e := range vx.out {
+			if !yield(e.Other(n), e) {
+				return
+			}
+		}
+	}
+}
+
+// Predecessors returns an iterator over the edges leading into n, yielding
+// the source node of each edge along with the edge. For undirected graphs,
+// it's the same as Neighbors.
+func (g *MultiGraph[N, E]) Predecessors(n N) iter.Seq2[N, *MultiEdge[N, E]] {
+	if !g.directed {
+		return g.Neighbors(n)
+	}
+	return func(yield func(N, *MultiEdge[N, E]) bool) {
+		vx, ok := g.nodes[n]
+		if !ok {
+			return
+		}
+		for e := range vx.in {
+			if !yield(e.from, e) {
+				return
+			}
+		}
+	}
+}
+
+// OutDegree returns the number of edges leading out of n. For undirected
+// graphs, it's the same as Degree.
+func (g *MultiGraph[N, E]) OutDegree(n N) int {
+	if vx, ok := g.nodes[n]; ok {
+		return len(vx.out)
+	}
+	return 0
+}
+
+// InDegree returns the number of edges leading into n. For undirected graphs,
+// it's the same as Degree.
+func (g *MultiGraph[N, E]) InDegree(n N) int {
+	if !g.directed {
+		return g.OutDegree(n)
+	}
+	if vx, ok := g.nodes[n]; ok {
+		return len(vx.in)
+	}
+	return 0
+}
+
+// Degree returns the number of edges incident to n. For directed graphs, it's
+// the sum of the in-degree and out-degree.
+func (g *MultiGraph[N, E]) Degree(n N) int {
+	if g.directed {
+		return g.InDegree(n) + g.OutDegree(n)
+	}
+	return g.OutDegree(n)
+}
+
+// addNode adds n to the graph if it's not already there, and returns its
+// vertex.
+func (g *MultiGraph[N, E]) addNode(n N) *multiVertex[N, E] {
+	if vx, ok := g.nodes[n]; ok {
+		return vx
+	}
+	vx := &multiVertex[N, E]{out: make(map[*MultiEdge[N, E]]struct{})}
+	if g.directed {
+		vx.in = make(map[*MultiEdge[N, E]]struct{})
+	}
+	g.nodes[n] = vx
+	return vx
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMultiDirected(t *testing.T) {
+	type route struct {
+		line    string
+		minutes int
+	}
+	g := NewMultiDirected[string, route]()
+	r1 := g.AddEdge("A", "B", route{"red", 10})
+	r2 := g.AddEdge("A", "B", route{"blue", 7})
+	g.AddEdge("B", "A", route{"red", 10})
+	g.AddEdge("B", "C", route{"green", 3})
+
+	if g.NumNodes() != 3 || g.NumEdges() != 4 {
+		t.Errorf("got %d nodes, %d edges; want 3, 4", g.NumNodes(), g.NumEdges())
+	}
+	if g.OutDegree("A") != 2 || g.InDegree("B") != 2 || g.Degree("B") != 4 {
+		t.Errorf("bad degrees")
+	}
+
+	var lines []string
+	for e := range g.EdgesBetween("A", "B") {
+		lines = append(lines, e.Payload().line)
+	}
+	slices.Sort(lines)
+	if !slices.Equal(lines, []string{"blue", "red"}) {
+		t.Errorf("got lines %v", lines)
+	}
+
+	g.SetPayload(r2, route{"blue", 5})
+	if r2.Payload().minutes != 5 {
+		t.Errorf("payload not updated")
+	}
+
+	if !g.RemoveEdge(r1) || g.RemoveEdge(r1) || g.HasEdge(r1) {
+		t.Errorf("bad RemoveEdge results")
+	}
+	if g.NumEdges() != 3 || g.OutDegree("A") != 1 {
+		t.Errorf("got %d edges after removal", g.NumEdges())
+	}
+	for m, e := range g.Predecessors("B") {
+		if m != "A" || e != r2 {
+			t.Errorf("got predecessor %v via %v", m, e.Payload())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	g.SetPayload(r1, route{})
+}
+
+func TestMultiUndirected(t *testing.T) {
+	g := NewMultiUndirected[int, string]()
+	g.AddEdge(1, 2, "a")
+	g.AddEdge(2, 1, "b")
+	g.AddEdge(2, 2, "loop")
+	g.AddEdge(2, 3, "c")
+
+	if g.Degree(2) != 4 || g.Degree(1) != 2 {
+		t.Errorf("got degrees %d, %d", g.Degree(2), g.Degree(1))
+	}
+
+	var between []string
+	for e := range g.EdgesBetween(1, 2) {
+		between = append(between, e.Payload())
+	}
+	slices.Sort(between)
+	if !slices.Equal(between, []string{"a", "b"}) {
+		t.Errorf("got %v", between)
+	}
+
+	var all []string
+	for e := range g.Edges() {
+		all = append(all, e.Payload())
+	}
+	slices.Sort(all)
+	if !slices.Equal(all, []string{"a", "b", "c", "loop"}) {
+		t.Errorf("got %v", all)
+	}
+
+	neighbors := make(map[int]int)
+	for m := range g.Neighbors(2) {
+		neighbors[m]++
+	}
+	if neighbors[1] != 2 || neighbors[2] != 1 || neighbors[3] != 1 {
+		t.Errorf("got neighbors %v", neighbors)
+	}
+
+	g.RemoveNode(2)
+	if g.NumEdges() != 0 || g.NumNodes() != 2 || g.Degree(1) != 0 {
+		t.Errorf("got %d edges, %d nodes after RemoveNode", g.NumEdges(), g.NumNodes())
+	}
+	g.RemoveNode(42)
+}
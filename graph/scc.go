@@ -0,0 +1,93 @@
+package graph
+
+import "slices"
+
+// StronglyConnectedComponents returns the strongly connected components of
+// g, computed with Tarjan's algorithm in O(V+E). Each component is a slice of
+// nodes. The components are returned in topological order: if there's an
+// edge from a node in component i to a node in component j, then i <= j.
+//
+// For undirected graphs, the strongly connected components are simply the
+// connected components.
+func StronglyConnectedComponents[N comparable, E any](g *Graph[N, E]) [][]N {
+	type nodeState struct {
+		index, lowlink int
+		onStack        bool
+	}
+	state := make(map[N]*nodeState, g.NumNodes())
+	var stack []N
+	var components [][]N
+	index := 0
+
+	var strongConnect func(v N)
+	strongConnect = func(v N) {
+		vs := &nodeState{index: index, lowlink: index, onStack: true}
+		state[v] = vs
+		index++
+		stack = append(stack, v)
+
+		for w := range g.Neighbors(v) {
+			ws, visited := state[w]
+			if !visited {
+				strongConnect(w)
+				vs.lowlink = min(vs.lowlink, state[w].lowlink)
+			} else if ws.onStack {
+				vs.lowlink = min(vs.lowlink, ws.index)
+			}
+		}
+
+		// If v is a root node, pop the stack to generate an SCC.
+		if vs.lowlink == vs.index {
+			var comp []N
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				state[w].onStack = false
+				comp = append(comp, w)
+				if w == v {
+					break
+				}
+			}
+			components = append(components, comp)
+		}
+	}
+
+	for v := range g.Nodes() {
+		if _, visited := state[v]; !visited {
+			strongConnect(v)
+		}
+	}
+
+	// Tarjan's algorithm emits components in reverse topological order.
+	slices.Reverse(components)
+	return components
+}
+
+// Condensation computes the condensation of g: the DAG obtained by
+// contracting every strongly connected component of g into a single node.
+// It returns the condensation graph, whose nodes are component indices as
+// returned by StronglyConnectedComponents (so 0, 1, ... is a topological
+// order of the DAG), along with the components themselves and a map from
+// every node of g to the index of its component.
+//
+// The condensation has an edge i->j whenever g has an edge from a node in
+// component i to a node in component j != i.
+func Condensation[N comparable, E any](g *Graph[N, E]) (dag *Graph[int, struct{}], components [][]N, componentOf map[N]int) {
+	components = StronglyConnectedComponents(g)
+	componentOf = make(map[N]int, g.NumNodes())
+	dag = NewDirected[int, struct{}]()
+	for i, comp := range components {
+		dag.AddNode(i)
+		for _, n := range comp {
+			componentOf[n] = i
+		}
+	}
+
+	for e := range g.Edges() {
+		ci, cj := componentOf[e.From], componentOf[e.To]
+		if ci != cj {
+			dag.AddEdge(ci, cj, struct{}{})
+		}
+	}
+	return dag, components, componentOf
+}
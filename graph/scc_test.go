@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+// normalizeComponents sorts each component and then sorts the components by
+// their first element, for comparison in tests.
+func normalizeComponents[N cmp.Ordered](comps [][]N) [][]N {
+	var result [][]N
+	for _, c := range comps {
+		result = append(result, slices.Sorted(slices.Values(c)))
+	}
+	slices.SortFunc(result, func(a, b []N) int { return cmp.Compare(a[0], b[0]) })
+	return result
+}
+
+func TestSCCClassic(t *testing.T) {
+	// The graph from CLRS figure 22.9.
+	g := NewDirected[string, struct{}]()
+	for _, e := range [][2]string{
+		{"a", "b"}, {"b", "c"}, {"b", "e"}, {"b", "f"}, {"c", "d"}, {"c", "g"},
+		{"d", "c"}, {"d", "h"}, {"e", "a"}, {"e", "f"}, {"f", "g"}, {"g", "f"},
+		{"g", "h"}, {"h", "h"},
+	} {
+		g.AddEdge(e[0], e[1], struct{}{})
+	}
+
+	comps := StronglyConnectedComponents(g)
+	want := [][]string{{"a", "b", "e"}, {"c", "d"}, {"f", "g"}, {"h"}}
+	got := normalizeComponents(comps)
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+
+	// Components are in topological order.
+	compIndex := make(map[string]int)
+	for i, c := range comps {
+		for _, n := range c {
+			compIndex[n] = i
+		}
+	}
+	for e := range g.Edges() {
+		if compIndex[e.From] > compIndex[e.To] {
+			t.Errorf("edge %v->%v goes backwards in component order", e.From, e.To)
+		}
+	}
+}
+
+func TestCondensation(t *testing.T) {
+	// Two cycles connected by an edge, plus an isolated node.
+	g := NewDirected[int, string]()
+	g.AddEdge(1, 2, "")
+	g.AddEdge(2, 1, "")
+	g.AddEdge(3, 4, "")
+	g.AddEdge(4, 5, "")
+	g.AddEdge(5, 3, "")
+	g.AddEdge(2, 3, "")
+	g.AddEdge(1, 4, "")
+	g.AddNode(6)
+
+	dag, comps, compOf := Condensation(g)
+	if dag.NumNodes() != 3 || len(comps) != 3 {
+		t.Fatalf("got %d condensed nodes, want 3", dag.NumNodes())
+	}
+	if compOf[1] != compOf[2] || compOf[3] != compOf[4] || compOf[4] != compOf[5] {
+		t.Errorf("bad component mapping %v", compOf)
+	}
+	if dag.NumEdges() != 1 || !dag.HasEdge(compOf[1], compOf[3]) {
+		t.Errorf("got %d condensed edges, want single edge", dag.NumEdges())
+	}
+	if compOf[1] > compOf[3] {
+		t.Errorf("condensation indices not in topological order")
+	}
+	if n := len(StronglyConnectedComponents(dag)); n != 3 {
+		t.Errorf("condensation is not a DAG")
+	}
+}
+
+func TestSCCUndirected(t *testing.T) {
+	g := NewUndirected[int, int]()
+	g.AddEdge(1, 2, 0)
+	g.AddEdge(2, 3, 0)
+	g.AddEdge(4, 5, 0)
+	g.AddNode(6)
+	got := normalizeComponents(StronglyConnectedComponents(g))
+	want := [][]int{{1, 2, 3}, {4, 5}, {6}}
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
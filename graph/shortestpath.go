@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// Weight is a constraint for numeric edge weights.
+type Weight interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// ErrNegativeCycle is returned by BellmanFord when a negative-weight cycle is
+// reachable from the source.
+var ErrNegativeCycle = errors.New("graph: negative cycle reachable from source")
+
+// ShortestPaths holds the result of a single-source shortest path computation:
+// the distances from the source to every reachable node, and the information
+// required to reconstruct the shortest paths themselves.
+type ShortestPaths[N comparable, W Weight] struct {
+	source N
+	dist   map[N]W
+
+	// prev maps each reachable node (other than the source) to its
+	// predecessor on a shortest path from the source.
+	prev map[N]N
+}
+
+// Source returns the source node of the computation.
+func (sp *ShortestPaths[N, W]) Source() N {
+	return sp.source
+}
+
+// Dist returns the shortest distance from the source to n, and ok=true if n is
+// reachable from the source; otherwise it returns ok=false.
+func (sp *ShortestPaths[N, W]) Dist(n N) (d W, ok bool) {
+	d, ok = sp.dist[n]
+	return d, ok
+}
+
+// Distances returns an iterator over all reachable nodes with their shortest
+// distances from the source.
+func (sp *ShortestPaths[N, W]) Distances() iter.Seq2[N, W] {
+	return func(yield func(N, W) bool) {
+		for n, d := range sp.dist {
+			if !yield(n, d) {
+				return
+			}
+		}
+	}
+}
+
+// PathTo returns a shortest path from the source to n, as a slice of nodes
+// starting with the source and ending with n. If n is not reachable from the
+// source, it returns nil.
+func (sp *ShortestPaths[N, W]) PathTo(n N) []N {
+	if _, ok := sp.dist[n]; !ok {
+		return nil
+	}
+	path := []N{n}
+	for n != sp.source {
+		n = sp.prev[n]
+		path = append(path, n)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// Dijkstra computes the shortest paths from source to all the nodes reachable
+// from it in g, using Dijkstra's algorithm. weight computes the weight of the
+// edge from u to v with the given payload; weights must be non-negative, and
+// Dijkstra panics if it encounters a negative weight (use BellmanFord for
+// graphs with negative weights).
+//
+// The running time is O((V+E) log V).
+func Dijkstra[N comparable, E any, W Weight](g *Graph[N, E], source N, weight func(u, v N, payload E) W) *ShortestPaths[N, W] {
+	type item struct {
+		node N
+		dist W
+	}
+
+	sp := &ShortestPaths[N, W]{
+		source: source,
+		dist:   make(map[N]W),
+		prev:   make(map[N]N),
+	}
+	if !g.HasNode(source) {
+		return sp
+	}
+
+	pq := heap.NewIndexed(func(a, b item) int {
+		switch {
+		case a.dist < b.dist:
+			return -1
+		case a.dist > b.dist:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	// handles maps nodes that are currently in pq to their handles. A node
+	// that's in sp.dist but not in handles has been finalized.
+	handles := map[N]*heap.Handle[item]{source: pq.Push(item{node: source})}
+	sp.dist[source] = 0
+
+	for pq.Len() > 0 {
+		it := pq.Pop()
+		delete(handles, it.node)
+
+		for v, payload := range g.Neighbors(it.node) {
+			w := weight(it.node, v, payload)
+			if w < 0 {
+				panic(fmt.Sprintf("negative weight for edge %v->%v", it.node, v))
+			}
+			nd := it.dist + w
+			if d, seen := sp.dist[v]; !seen {
+				sp.dist[v] = nd
+				sp.prev[v] = it.node
+				handles[v] = pq.Push(item{node: v, dist: nd})
+			} else if hd, inQueue := handles[v]; inQueue && nd < d {
+				sp.dist[v] = nd
+				sp.prev[v] = it.node
+				pq.Update(hd, item{node: v, dist: nd})
+			}
+		}
+	}
+	return sp
+}
+
+// BellmanFord computes the shortest paths from source to all the nodes
+// reachable from it in g, using the Bellman-Ford algorithm. weight computes
+// the weight of the edge from u to v with the given payload; unlike Dijkstra,
+// weights may be negative. If g has a negative-weight cycle reachable from
+// source, BellmanFord returns ErrNegativeCycle. Note that in undirected
+// graphs, any negative-weight edge forms such a cycle.
+//
+// The running time is O(V*E).
+func BellmanFord[N comparable, E any, W Weight](g *Graph[N, E], source N, weight func(u, v N, payload E) W) (*ShortestPaths[N, W], error) {
+	sp := &ShortestPaths[N, W]{
+		source: source,
+		dist:   make(map[N]W),
+		prev:   make(map[N]N),
+	}
+	if !g.HasNode(source) {
+		return sp, nil
+	}
+	sp.dist[source] = 0
+
+	// relax performs one pass of relaxation over all the edges of g,
+	// returning true if any distance was updated.
+	relax := func() bool {
+		changed := false
+		for u := range g.Nodes() {
+			du, ok := sp.dist[u]
+			if !ok {
+				continue
+			}
+			for v, payload := range g.Neighbors(u) {
+				nd := du + weight(u, v, payload)
+				if dv, ok := sp.dist[v]; !ok || nd < dv {
+					sp.dist[v] = nd
+					sp.prev[v] = u
+					changed = true
+				}
+			}
+		}
+		return changed
+	}
+
+	for range g.NumNodes() - 1 {
+		if !relax() {
+			return sp, nil
+		}
+	}
+
+	// After V-1 passes all shortest paths are final, unless there's a
+	// negative cycle.
+	if relax() {
+		return nil, ErrNegativeCycle
+	}
+	return sp, nil
+}
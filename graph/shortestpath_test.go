@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func payloadWeight[N comparable, W Weight](u, v N, w W) W {
+	return w
+}
+
+// buildClassic builds the directed weighted graph from CLRS figure 24.6.
+func buildClassic() *Graph[string, int] {
+	g := NewDirected[string, int]()
+	g.AddEdge("s", "t", 10)
+	g.AddEdge("s", "y", 5)
+	g.AddEdge("t", "x", 1)
+	g.AddEdge("t", "y", 2)
+	g.AddEdge("y", "t", 3)
+	g.AddEdge("y", "x", 9)
+	g.AddEdge("y", "z", 2)
+	g.AddEdge("x", "z", 4)
+	g.AddEdge("z", "x", 6)
+	g.AddEdge("z", "s", 7)
+	g.AddNode("unreachable")
+	return g
+}
+
+func checkClassic(t *testing.T, sp *ShortestPaths[string, int]) {
+	t.Helper()
+	want := map[string]int{"s": 0, "t": 8, "x": 9, "y": 5, "z": 7}
+	for n, w := range want {
+		if d, ok := sp.Dist(n); !ok || d != w {
+			t.Errorf("got Dist(%s)=%v,%v, want %v", n, d, ok, w)
+		}
+	}
+	if _, ok := sp.Dist("unreachable"); ok {
+		t.Errorf("found distance to unreachable node")
+	}
+	if p := sp.PathTo("unreachable"); p != nil {
+		t.Errorf("got path %v to unreachable node", p)
+	}
+	if p := sp.PathTo("x"); !slices.Equal(p, []string{"s", "y", "t", "x"}) {
+		t.Errorf("got path %v", p)
+	}
+	if p := sp.PathTo("s"); !slices.Equal(p, []string{"s"}) {
+		t.Errorf("got path %v", p)
+	}
+	n := 0
+	for range sp.Distances() {
+		n++
+	}
+	if n != 5 || sp.Source() != "s" {
+		t.Errorf("got %d distances, source %v", n, sp.Source())
+	}
+}
+
+func TestDijkstraClassic(t *testing.T) {
+	g := buildClassic()
+	checkClassic(t, Dijkstra(g, "s", payloadWeight))
+}
+
+func TestBellmanFordClassic(t *testing.T) {
+	g := buildClassic()
+	sp, err := BellmanFord(g, "s", payloadWeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkClassic(t, sp)
+}
+
+func TestBellmanFordNegative(t *testing.T) {
+	g := NewDirected[int, float64]()
+	g.AddEdge(0, 1, 4)
+	g.AddEdge(0, 2, 5)
+	g.AddEdge(2, 1, -3)
+	g.AddEdge(1, 3, 2)
+	sp, err := BellmanFord(g, 0, payloadWeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := sp.Dist(3); d != 4 {
+		t.Errorf("got Dist(3)=%v, want 4", d)
+	}
+	if p := sp.PathTo(3); !slices.Equal(p, []int{0, 2, 1, 3}) {
+		t.Errorf("got path %v", p)
+	}
+
+	// Add a negative cycle 1->3->1.
+	g.AddEdge(3, 1, -5)
+	if _, err := BellmanFord(g, 0, payloadWeight); err != ErrNegativeCycle {
+		t.Errorf("got err=%v, want ErrNegativeCycle", err)
+	}
+
+	// ... but it doesn't matter if it's not reachable from the source.
+	sp, err = BellmanFord(g, 2, payloadWeight)
+	if err == nil {
+		t.Errorf("expected negative cycle to be found from 2")
+	}
+	g.AddEdge(10, 11, 1)
+	if sp, err = BellmanFord(g, 10, payloadWeight); err != nil {
+		t.Errorf("got err=%v", err)
+	} else if d, _ := sp.Dist(11); d != 1 {
+		t.Errorf("got Dist(11)=%v", d)
+	}
+}
+
+func TestDijkstraNegativePanics(t *testing.T) {
+	g := NewDirected[int, int]()
+	g.AddEdge(0, 1, -1)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	Dijkstra(g, 0, payloadWeight)
+}
+
+func TestMissingSource(t *testing.T) {
+	g := buildClassic()
+	sp := Dijkstra(g, "nope", payloadWeight)
+	if _, ok := sp.Dist("nope"); ok {
+		t.Errorf("found distance for missing source")
+	}
+}
+
+func TestDijkstraVsBellmanFordRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, directed := range []bool{true, false} {
+		var g *Graph[int, int]
+		if directed {
+			g = NewDirected[int, int]()
+		} else {
+			g = NewUndirected[int, int]()
+		}
+		for range 600 {
+			g.AddEdge(rnd.IntN(100), rnd.IntN(100), rnd.IntN(50))
+		}
+
+		dsp := Dijkstra(g, 0, payloadWeight)
+		bsp, err := BellmanFord(g, 0, payloadWeight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := range g.Nodes() {
+			d1, ok1 := dsp.Dist(n)
+			d2, ok2 := bsp.Dist(n)
+			if d1 != d2 || ok1 != ok2 {
+				t.Errorf("node %d: Dijkstra %v,%v, Bellman-Ford %v,%v", n, d1, ok1, d2, ok2)
+			}
+
+			// Verify that the path's weight matches the distance.
+			if p := dsp.PathTo(n); p != nil {
+				total := 0
+				for i := 1; i < len(p); i++ {
+					w, ok := g.Edge(p[i-1], p[i])
+					if !ok {
+						t.Fatalf("path %v has no edge %d->%d", p, p[i-1], p[i])
+					}
+					total += w
+				}
+				if total != d1 {
+					t.Errorf("path %v has weight %d, want %d", p, total, d1)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package hamt
+
+import (
+	"fmt"
+	"iter"
+)
+
+// ChangeKind is the kind of a [Change].
+type ChangeKind int
+
+const (
+	// Added means that the key is only in the newer map.
+	Added ChangeKind = iota
+
+	// Removed means that the key is only in the older map.
+	Removed
+
+	// Modified means that the key is in both maps, with different values.
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Modified:
+		return "Modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a difference between two maps, reported by [Map.Diff].
+type Change[K, V any] struct {
+	Kind ChangeKind
+	Key  K
+
+	// Old is the key's value in the older map, for Removed and Modified
+	// changes; New is its value in the newer map, for Added and Modified
+	// changes.
+	Old, New V
+}
+
+// Diff returns an iterator over the differences between m and a newer
+// version of it, in unspecified order; values are compared with equal.
+// Subtrees shared by the two maps are skipped without being visited, so
+// diffing a map against a version derived from it by k updates takes
+// O(k log32 n) time.
+//
+// The maps must use the same hash function: Diff panics unless both were
+// derived by updates from the same map created by [New].
+func (m *Map[K, V]) Diff(newer *Map[K, V], equal func(a, b V) bool) iter.Seq[Change[K, V]] {
+	if m.h != newer.h {
+		panic("hamt: Diff of maps not derived from the same map")
+	}
+	return func(yield func(Change[K, V]) bool) {
+		d := differ[K, V]{keyEqual: m.h.Equal, valueEqual: equal, yield: yield}
+		d.nodes(m.root, newer.root, 0)
+	}
+}
+
+type differ[K, V any] struct {
+	keyEqual   func(a, b K) bool
+	valueEqual func(a, b V) bool
+	yield      func(Change[K, V]) bool
+}
+
+// The methods of differ return false if yield asked to stop.
+
+// nodes reports the differences between the subtrees of a (older) and b
+// (newer), which are at the given shift.
+func (d *differ[K, V]) nodes(a, b *node[K, V], shift uint) bool {
+	if a == b {
+		return true
+	}
+	if shift >= maxShift {
+		return d.collisions(a, b)
+	}
+
+	slots := a.dataMap | a.nodeMap | b.dataMap | b.nodeMap
+	for ; slots != 0; slots &= slots - 1 {
+		bit := slots & -slots
+		var ok bool
+		switch {
+		case a.dataMap&bit != 0 && b.dataMap&bit != 0:
+			ea, eb := &a.entries[index(a.dataMap, bit)], &b.entries[index(b.dataMap, bit)]
+			if d.sameKey(ea, eb) {
+				ok = d.pair(ea, eb)
+			} else {
+				ok = d.removed(ea) && d.added(eb)
+			}
+		case a.nodeMap&bit != 0 && b.nodeMap&bit != 0:
+			ok = d.nodes(a.children[index(a.nodeMap, bit)], b.children[index(b.nodeMap, bit)], shift+bitsPerLevel)
+		case a.dataMap&bit != 0 && b.nodeMap&bit != 0:
+			ok = d.entryVsNode(&a.entries[index(a.dataMap, bit)], b.children[index(b.nodeMap, bit)], true)
+		case a.nodeMap&bit != 0 && b.dataMap&bit != 0:
+			ok = d.entryVsNode(&b.entries[index(b.dataMap, bit)], a.children[index(a.nodeMap, bit)], false)
+		case a.dataMap&bit != 0:
+			ok = d.removed(&a.entries[index(a.dataMap, bit)])
+		case a.nodeMap&bit != 0:
+			ok = a.children[index(a.nodeMap, bit)].walk(d.removed)
+		case b.dataMap&bit != 0:
+			ok = d.added(&b.entries[index(b.dataMap, bit)])
+		default:
+			ok = b.children[index(b.nodeMap, bit)].walk(d.added)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// entryVsNode reports the differences between the entry e, alone in its
+// slot, and the subtree n in the same slot of the other map. isOld tells
+// whether e is in the older map.
+func (d *differ[K, V]) entryVsNode(e *entry[K, V], n *node[K, V], isOld bool) bool {
+	found := false
+	ok := n.walk(func(x *entry[K, V]) bool {
+		switch {
+		case !found && d.sameKey(e, x):
+			found = true
+			if isOld {
+				return d.pair(e, x)
+			}
+			return d.pair(x, e)
+		case isOld:
+			return d.added(x)
+		default:
+			return d.removed(x)
+		}
+	})
+	if !ok || found {
+		return ok
+	}
+	if isOld {
+		return d.removed(e)
+	}
+	return d.added(e)
+}
+
+// collisions reports the differences between the collision nodes a and b.
+func (d *differ[K, V]) collisions(a, b *node[K, V]) bool {
+	for i := range a.entries {
+		ea := &a.entries[i]
+		if j := b.find(ea.hash, ea.key, d.keyEqual); j >= 0 {
+			if !d.pair(ea, &b.entries[j]) {
+				return false
+			}
+		} else if !d.removed(ea) {
+			return false
+		}
+	}
+	for i := range b.entries {
+		eb := &b.entries[i]
+		if a.find(eb.hash, eb.key, d.keyEqual) < 0 && !d.added(eb) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *differ[K, V]) sameKey(a, b *entry[K, V]) bool {
+	return a.hash == b.hash && d.keyEqual(a.key, b.key)
+}
+
+// pair reports the entries a (older) and b (newer) with the same key as
+// modified if their values differ.
+func (d *differ[K, V]) pair(a, b *entry[K, V]) bool {
+	if d.valueEqual(a.value, b.value) {
+		return true
+	}
+	return d.yield(Change[K, V]{Kind: Modified, Key: a.key, Old: a.value, New: b.value})
+}
+
+func (d *differ[K, V]) added(e *entry[K, V]) bool {
+	return d.yield(Change[K, V]{Kind: Added, Key: e.key, New: e.value})
+}
+
+func (d *differ[K, V]) removed(e *entry[K, V]) bool {
+	return d.yield(Change[K, V]{Kind: Removed, Key: e.key, Old: e.value})
+}
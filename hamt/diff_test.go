@@ -0,0 +1,133 @@
+package hamt
+
+import (
+	"testing"
+
+	"github.com/eliben/gogl/hashmap"
+)
+
+func intEqual(a, b int) bool { return a == b }
+
+// checkDiff checks that the changes from a to b are the differences
+// between the reference maps wa and wb.
+func checkDiff(t *testing.T, a, b *Map[int, int], wa, wb map[int]int) {
+	t.Helper()
+	seen := make(map[int]bool)
+	for c := range a.Diff(b, intEqual) {
+		if seen[c.Key] {
+			t.Fatalf("key %d reported twice", c.Key)
+		}
+		seen[c.Key] = true
+		va, ina := wa[c.Key]
+		vb, inb := wb[c.Key]
+		var ok bool
+		switch c.Kind {
+		case Added:
+			ok = !ina && inb && c.New == vb
+		case Removed:
+			ok = ina && !inb && c.Old == va
+		case Modified:
+			ok = ina && inb && va != vb && c.Old == va && c.New == vb
+		}
+		if !ok {
+			t.Fatalf("bad change %+v", c)
+		}
+	}
+	for k, va := range wa {
+		if vb, ok := wb[k]; (!ok || vb != va) && !seen[k] {
+			t.Fatalf("change to key %d not reported", k)
+		}
+	}
+	for k := range wb {
+		if _, ok := wa[k]; !ok && !seen[k] {
+			t.Fatalf("addition of key %d not reported", k)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		h    hashmap.Hasher[int]
+		keys int
+	}{
+		{"int", hashmap.IntHasher[int](), 3000},
+		{"colliding", collidingHasher(), 200},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rnd := makeLoggedRand(t)
+			base := New[int, int](tt.h)
+			wbase := make(map[int]int)
+			for range tt.keys / 2 {
+				k := rnd.IntN(tt.keys)
+				base = base.Set(k, k)
+				wbase[k] = k
+			}
+
+			for _, updates := range []int{0, 1, 5, 50, 1000} {
+				m := base
+				wm := make(map[int]int)
+				for k, v := range wbase {
+					wm[k] = v
+				}
+				for range updates {
+					k := rnd.IntN(tt.keys)
+					switch rnd.IntN(3) {
+					case 0:
+						m = m.Delete(k)
+						delete(wm, k)
+					case 1:
+						// Setting the same value isn't a change.
+						if v, ok := wm[k]; ok {
+							m = m.Set(k, v)
+						}
+					default:
+						v := rnd.IntN(5)
+						m = m.Set(k, v)
+						wm[k] = v
+					}
+				}
+				checkDiff(t, base, m, wbase, wm)
+				checkDiff(t, m, base, wm, wbase)
+			}
+		})
+	}
+}
+
+func TestDiffSharing(t *testing.T) {
+	// Diffing versions that share most of their trie only visits the
+	// changed paths.
+	m := New[int, int](hashmap.IntHasher[int]())
+	for i := range 100000 {
+		m = m.Set(i, i)
+	}
+	m2 := m.Set(5, -5).Delete(7).Set(-1, 1)
+	calls := 0
+	d := differ[int, int]{
+		keyEqual:   m.h.Equal,
+		valueEqual: func(a, b int) bool { calls++; return a == b },
+		yield:      func(Change[int, int]) bool { return true },
+	}
+	d.nodes(m.root, m2.root, 0)
+	if calls > 200 {
+		t.Errorf("compared %d values, want a few", calls)
+	}
+
+	var kinds []ChangeKind
+	for c := range m.Diff(m2, intEqual) {
+		kinds = append(kinds, c.Kind)
+	}
+	if len(kinds) != 3 {
+		t.Errorf("got changes %v, want 3", kinds)
+	}
+}
+
+func TestDiffPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	h := hashmap.IntHasher[int]()
+	New[int, int](h).Diff(New[int, int](h), intEqual)
+}
@@ -0,0 +1,303 @@
+// Package hamt implements a persistent hash map as a hash array mapped trie.
+package hamt
+
+import (
+	"iter"
+	"math/bits"
+
+	"github.com/eliben/gogl/hashmap"
+)
+
+// Map is a persistent hash map: a hash array mapped trie (HAMT) in the
+// compressed CHAMP layout. Each node of the trie consumes 5 bits of a key's
+// 64-bit hash to pick one of 32 slots, and stores entries and child nodes
+// compactly in arrays indexed through bitmaps of the occupied slots. Get,
+// Set and Delete take O(log32 n) time.
+//
+// Maps are never modified: Set and Delete return new maps that share all
+// the nodes off the updated path with the original, which remains valid.
+// Maps are therefore safe for concurrent use by multiple goroutines.
+//
+// Every set of keys has a single canonical trie, which lets [Map.Diff]
+// compare two versions of a map in time proportional to the size of their
+// differences rather than to the size of the maps.
+//
+// Keys are hashed and compared with a [hashmap.Hasher]. Create maps with
+// [New].
+type Map[K, V any] struct {
+	h      *hashmap.Hasher[K]
+	root   *node[K, V]
+	length int
+}
+
+const (
+	bitsPerLevel = 5
+	slotMask     = 1<<bitsPerLevel - 1
+
+	// maxShift is the shift of the levels where all the hash bits have been
+	// consumed, where keys with equal hashes are kept in collision nodes.
+	maxShift = 64
+)
+
+// node is a node of the trie. A key whose slot is unique among the keys in
+// the subtree of a node is stored in an entry of the node; otherwise, its
+// slot holds a child node. Entries and children are in slot order.
+//
+// Nodes at maxShift are collision nodes: their entries all have the same
+// hash, and their bitmaps are unused.
+type node[K, V any] struct {
+	dataMap  uint32
+	nodeMap  uint32
+	entries  []entry[K, V]
+	children []*node[K, V]
+}
+
+type entry[K, V any] struct {
+	hash  uint64
+	key   K
+	value V
+}
+
+// New creates a new, empty map using h for its keys.
+func New[K, V any](h hashmap.Hasher[K]) *Map[K, V] {
+	return &Map[K, V]{h: &h, root: &node[K, V]{}}
+}
+
+// Len returns the number of entries in the map.
+func (m *Map[K, V]) Len() int {
+	return m.length
+}
+
+// Get looks for key in the map. It returns the associated value and ok=true;
+// otherwise, it returns ok=false.
+func (m *Map[K, V]) Get(key K) (v V, ok bool) {
+	h := m.h.Hash(key)
+	n := m.root
+	for shift := uint(0); shift < maxShift; shift += bitsPerLevel {
+		bit := slotBit(h, shift)
+		switch {
+		case n.dataMap&bit != 0:
+			e := &n.entries[index(n.dataMap, bit)]
+			if e.hash == h && m.h.Equal(e.key, key) {
+				return e.value, true
+			}
+			return v, false
+		case n.nodeMap&bit != 0:
+			n = n.children[index(n.nodeMap, bit)]
+		default:
+			return v, false
+		}
+	}
+	if i := n.find(h, key, m.h.Equal); i >= 0 {
+		return n.entries[i].value, true
+	}
+	return v, false
+}
+
+// Set returns a new map with the value of key set to value, adding key if
+// it's not in m.
+func (m *Map[K, V]) Set(key K, value V) *Map[K, V] {
+	e := entry[K, V]{hash: m.h.Hash(key), key: key, value: value}
+	root, added := m.set(m.root, 0, e)
+	nm := &Map[K, V]{h: m.h, root: root, length: m.length}
+	if added {
+		nm.length++
+	}
+	return nm
+}
+
+// Delete returns a new map without key. If key isn't in m, it returns m.
+func (m *Map[K, V]) Delete(key K) *Map[K, V] {
+	root, found := m.delete(m.root, 0, m.h.Hash(key), key)
+	if !found {
+		return m
+	}
+	return &Map[K, V]{h: m.h, root: root, length: m.length - 1}
+}
+
+// All returns an iterator over all the key, value pairs in the map, in
+// unspecified order.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.walk(func(e *entry[K, V]) bool {
+			return yield(e.key, e.value)
+		})
+	}
+}
+
+// slotBit returns the bitmap bit of the slot of hash h at the level with
+// the given shift.
+func slotBit(h uint64, shift uint) uint32 {
+	return 1 << ((h >> shift) & slotMask)
+}
+
+// index returns the position in a node's array of the slot with the given
+// bit, among the slots set in bitmap.
+func index(bitmap, bit uint32) int {
+	return bits.OnesCount32(bitmap & (bit - 1))
+}
+
+// find returns the index of key in the entries of the collision node n, or
+// -1 if it's not there.
+func (n *node[K, V]) find(h uint64, key K, equal func(a, b K) bool) int {
+	for i := range n.entries {
+		if n.entries[i].hash == h && equal(n.entries[i].key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// clone returns a shallow copy of n, which may be modified without affecting
+// n.
+func (n *node[K, V]) clone() *node[K, V] {
+	return &node[K, V]{
+		dataMap:  n.dataMap,
+		nodeMap:  n.nodeMap,
+		entries:  append([]entry[K, V](nil), n.entries...),
+		children: append([]*node[K, V](nil), n.children...),
+	}
+}
+
+// isSingleton reports whether n holds a single entry and no children; such
+// nodes are inlined into their parent (except at the root), which keeps the
+// trie canonical.
+func (n *node[K, V]) isSingleton() bool {
+	return len(n.entries) == 1 && len(n.children) == 0
+}
+
+// set returns a copy of the subtree of n, at the given shift, with e added
+// or updated, and whether e's key was added.
+func (m *Map[K, V]) set(n *node[K, V], shift uint, e entry[K, V]) (*node[K, V], bool) {
+	if shift >= maxShift {
+		c := n.clone()
+		if i := n.find(e.hash, e.key, m.h.Equal); i >= 0 {
+			c.entries[i] = e
+			return c, false
+		}
+		c.entries = append(c.entries, e)
+		return c, true
+	}
+
+	bit := slotBit(e.hash, shift)
+	switch {
+	case n.dataMap&bit != 0:
+		i := index(n.dataMap, bit)
+		old := n.entries[i]
+		c := n.clone()
+		if old.hash == e.hash && m.h.Equal(old.key, e.key) {
+			c.entries[i] = e
+			return c, false
+		}
+		// The slot is now shared by two keys; move them to a new child.
+		c.dataMap &^= bit
+		c.entries = remove(c.entries, i)
+		c.nodeMap |= bit
+		c.children = insert(c.children, index(c.nodeMap, bit), merge(old, e, shift+bitsPerLevel))
+		return c, true
+	case n.nodeMap&bit != 0:
+		i := index(n.nodeMap, bit)
+		child, added := m.set(n.children[i], shift+bitsPerLevel, e)
+		c := n.clone()
+		c.children[i] = child
+		return c, added
+	default:
+		c := n.clone()
+		c.dataMap |= bit
+		c.entries = insert(c.entries, index(c.dataMap, bit), e)
+		return c, true
+	}
+}
+
+// merge returns a subtree at the given shift holding the entries a and b,
+// whose keys differ.
+func merge[K, V any](a, b entry[K, V], shift uint) *node[K, V] {
+	if shift >= maxShift {
+		return &node[K, V]{entries: []entry[K, V]{a, b}}
+	}
+	abit, bbit := slotBit(a.hash, shift), slotBit(b.hash, shift)
+	if abit == bbit {
+		return &node[K, V]{nodeMap: abit, children: []*node[K, V]{merge(a, b, shift+bitsPerLevel)}}
+	}
+	if abit > bbit {
+		a, b = b, a
+	}
+	return &node[K, V]{dataMap: abit | bbit, entries: []entry[K, V]{a, b}}
+}
+
+// delete returns a copy of the subtree of n, at the given shift, without
+// key, and whether key was found. If it wasn't, it returns n itself.
+func (m *Map[K, V]) delete(n *node[K, V], shift uint, h uint64, key K) (*node[K, V], bool) {
+	if shift >= maxShift {
+		i := n.find(h, key, m.h.Equal)
+		if i < 0 {
+			return n, false
+		}
+		c := n.clone()
+		c.entries = remove(c.entries, i)
+		return c, true
+	}
+
+	bit := slotBit(h, shift)
+	switch {
+	case n.dataMap&bit != 0:
+		i := index(n.dataMap, bit)
+		if e := n.entries[i]; e.hash != h || !m.h.Equal(e.key, key) {
+			return n, false
+		}
+		c := n.clone()
+		c.dataMap &^= bit
+		c.entries = remove(c.entries, i)
+		return c, true
+	case n.nodeMap&bit != 0:
+		i := index(n.nodeMap, bit)
+		child, found := m.delete(n.children[i], shift+bitsPerLevel, h, key)
+		if !found {
+			return n, false
+		}
+		c := n.clone()
+		if child.isSingleton() {
+			// Inline the child's last entry into this node.
+			c.nodeMap &^= bit
+			c.children = remove(c.children, i)
+			c.dataMap |= bit
+			c.entries = insert(c.entries, index(c.dataMap, bit), child.entries[0])
+		} else {
+			c.children[i] = child
+		}
+		return c, true
+	default:
+		return n, false
+	}
+}
+
+// walk calls f on all the entries in the subtree of n, stopping early if f
+// returns false; it returns false if it stopped early.
+func (n *node[K, V]) walk(f func(e *entry[K, V]) bool) bool {
+	for i := range n.entries {
+		if !f(&n.entries[i]) {
+			return false
+		}
+	}
+	for _, c := range n.children {
+		if !c.walk(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func insert[S ~[]E, E any](s S, i int, v E) S {
+	var zero E
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func remove[S ~[]E, E any](s S, i int) S {
+	copy(s[i:], s[i+1:])
+	var zero E
+	s[len(s)-1] = zero
+	return s[:len(s)-1]
+}
@@ -0,0 +1,189 @@
+package hamt
+
+import (
+	"log"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/eliben/gogl/hashmap"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// collidingHasher hashes integers to only a few distinct values, so that
+// the trie has long chains and collision nodes.
+func collidingHasher() hashmap.Hasher[int] {
+	return hashmap.Hasher[int]{
+		Hash:  func(k int) uint64 { return uint64(k%5) << 59 },
+		Equal: func(a, b int) bool { return a == b },
+	}
+}
+
+// checkMap checks that m holds exactly the entries of want, and that its
+// trie is in canonical form.
+func checkMap(t *testing.T, m *Map[int, int], want map[int]int) {
+	t.Helper()
+	if m.Len() != len(want) {
+		t.Fatalf("Len=%d, want %d", m.Len(), len(want))
+	}
+	for k, w := range want {
+		if v, ok := m.Get(k); !ok || v != w {
+			t.Fatalf("Get(%d)=%d,%v, want %d", k, v, ok, w)
+		}
+	}
+	n := 0
+	for k, v := range m.All() {
+		if w, ok := want[k]; !ok || v != w {
+			t.Fatalf("All yielded %d=%d", k, v)
+		}
+		n++
+	}
+	if n != len(want) {
+		t.Fatalf("All yielded %d entries, want %d", n, len(want))
+	}
+	checkCanonical(t, m.root, 0, true)
+}
+
+func checkCanonical(t *testing.T, n *node[int, int], shift uint, isRoot bool) {
+	t.Helper()
+	if !isRoot && (n.isSingleton() || len(n.entries)+len(n.children) == 0) {
+		t.Fatalf("non-canonical node at shift %d: %+v", shift, n)
+	}
+	if shift >= maxShift {
+		if len(n.children) != 0 || n.dataMap != 0 || n.nodeMap != 0 {
+			t.Fatalf("bad collision node: %+v", n)
+		}
+		return
+	}
+	if n.dataMap&n.nodeMap != 0 ||
+		bitsSet(n.dataMap) != len(n.entries) || bitsSet(n.nodeMap) != len(n.children) {
+		t.Fatalf("bad bitmaps at shift %d: %+v", shift, n)
+	}
+	for _, c := range n.children {
+		checkCanonical(t, c, shift+bitsPerLevel, false)
+	}
+}
+
+func bitsSet(x uint32) int {
+	n := 0
+	for ; x != 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
+
+func TestBasic(t *testing.T) {
+	m0 := New[string, int](hashmap.StringHasher())
+	m1 := m0.Set("a", 1).Set("b", 2)
+	m2 := m1.Set("a", 10).Delete("b").Set("c", 3)
+	if m0.Len() != 0 || m1.Len() != 2 || m2.Len() != 2 {
+		t.Fatalf("Len: %d %d %d", m0.Len(), m1.Len(), m2.Len())
+	}
+	if v, ok := m1.Get("a"); !ok || v != 1 {
+		t.Errorf("m1[a]=%d,%v", v, ok)
+	}
+	if v, ok := m2.Get("a"); !ok || v != 10 {
+		t.Errorf("m2[a]=%d,%v", v, ok)
+	}
+	if _, ok := m2.Get("b"); ok {
+		t.Errorf("m2 has b")
+	}
+	if _, ok := m0.Get("a"); ok {
+		t.Errorf("m0 has a")
+	}
+	if m2.Delete("zz") != m2 {
+		t.Errorf("Delete of a missing key made a new map")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		h    hashmap.Hasher[int]
+		keys int
+	}{
+		{"int", hashmap.IntHasher[int](), 5000},
+		{"colliding", collidingHasher(), 300},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rnd := makeLoggedRand(t)
+			m := New[int, int](tt.h)
+			want := make(map[int]int)
+			var versions []*Map[int, int]
+			var contents []map[int]int
+			for i := range 20000 {
+				k := rnd.IntN(tt.keys)
+				if rnd.IntN(3) == 0 {
+					m = m.Delete(k)
+					delete(want, k)
+				} else {
+					m = m.Set(k, i)
+					want[k] = i
+				}
+				if i%2000 == 0 {
+					versions = append(versions, m)
+					c := make(map[int]int)
+					for k, v := range want {
+						c[k] = v
+					}
+					contents = append(contents, c)
+				}
+			}
+			checkMap(t, m, want)
+			for i, old := range versions {
+				checkMap(t, old, contents[i])
+			}
+
+			// Deleting everything leaves an empty root.
+			for k := range want {
+				m = m.Delete(k)
+			}
+			checkMap(t, m, nil)
+			if len(m.root.entries)+len(m.root.children) != 0 {
+				t.Errorf("root not empty: %+v", m.root)
+			}
+		})
+	}
+}
+
+func TestAllStop(t *testing.T) {
+	m := New[int, int](hashmap.IntHasher[int]())
+	for i := range 1000 {
+		m = m.Set(i, i)
+	}
+	n := 0
+	for range m.All() {
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("got %d iterations", n)
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	h := hashmap.IntHasher[int]()
+	for range b.N {
+		m := New[int, int](h)
+		for i := range 10000 {
+			m = m.Set(i, i)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	m := New[int, int](hashmap.IntHasher[int]())
+	for i := range 100000 {
+		m = m.Set(i, i)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		m.Get(i % 100000)
+	}
+}
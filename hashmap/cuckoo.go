@@ -0,0 +1,259 @@
+package hashmap
+
+import (
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+// Cuckoo is a hash map using cuckoo hashing: each key has exactly two
+// candidate slots, one in each of two tables, selected by two hash functions.
+// A lookup examines just these two slots, and a small overflow "stash",
+// so its worst case takes constant time.
+//
+// Inserting into an occupied slot kicks out its entry to its slot in the
+// other table, possibly kicking out another entry, and so on. If this
+// doesn't settle within a bounded number of kicks, the last homeless entry
+// goes to the stash; when the stash is full too, the map is rebuilt with new
+// hash functions.
+//
+// If many keys share the same full hash (which no choice of hash functions
+// can separate), the stash is allowed to grow past its usual capacity and
+// lookups degrade accordingly.
+//
+// Create Cuckoo maps with [NewCuckoo].
+type Cuckoo[K, V any] struct {
+	hasher Hasher[K]
+
+	// tables holds the two tables, of equal power-of-two sizes. The two
+	// hash functions mix the key's hash with the seeds.
+	tables [2][]cuckooSlot[K, V]
+	seeds  [2]uint64
+	stash  []cuckooSlot[K, V]
+	length int
+
+	// stashLimit is the number of stashed entries that triggers a rebuild;
+	// it's raised above cuckooMaxStash only when rebuilding fails to fit the
+	// entries into the tables.
+	stashLimit int
+}
+
+type cuckooSlot[K, V any] struct {
+	key   K
+	value V
+	hash  uint64
+	used  bool
+}
+
+const (
+	// cuckooMaxStash is the capacity of the stash.
+	cuckooMaxStash = 4
+
+	// cuckooMaxRehash is the number of hash functions a rebuild tries before
+	// settling for an overflowing stash.
+	cuckooMaxRehash = 8
+
+	// cuckooMaxLoad is the maximal fraction of slots in use, in percent;
+	// cuckoo hashing with two choices fails often above 50%.
+	cuckooMaxLoad = 45
+)
+
+// NewCuckoo creates a new, empty Cuckoo map using hasher for its keys.
+func NewCuckoo[K, V any](hasher Hasher[K]) *Cuckoo[K, V] {
+	m := &Cuckoo[K, V]{hasher: hasher}
+	m.reset(8)
+	return m
+}
+
+// Len returns the number of entries in the map.
+func (m *Cuckoo[K, V]) Len() int {
+	return m.length
+}
+
+// Get looks for key in the map. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (m *Cuckoo[K, V]) Get(key K) (v V, ok bool) {
+	if s := m.find(key, m.hasher.Hash(key)); s != nil {
+		return s.value, true
+	}
+	return v, false
+}
+
+// Set sets the value of key to value, adding key if it's not in the map.
+func (m *Cuckoo[K, V]) Set(key K, value V) {
+	h := m.hasher.Hash(key)
+	if s := m.find(key, h); s != nil {
+		s.value = value
+		return
+	}
+	m.length++
+	if m.length*100 > 2*len(m.tables[0])*cuckooMaxLoad {
+		m.rebuild(2 * len(m.tables[0]))
+	}
+	e := cuckooSlot[K, V]{key: key, value: value, hash: h, used: true}
+	if homeless, ok := m.insert(e); !ok {
+		m.stashOrRebuild(homeless)
+	}
+}
+
+// Insert is the same as Set; it makes the map satisfy container.Map.
+func (m *Cuckoo[K, V]) Insert(key K, value V) {
+	m.Set(key, value)
+}
+
+// Delete deletes key and its value from the map. It returns true if key was
+// found, and false otherwise.
+func (m *Cuckoo[K, V]) Delete(key K) bool {
+	h := m.hasher.Hash(key)
+	for t := range m.tables {
+		s := &m.tables[t][m.index(t, h)]
+		if s.used && s.hash == h && m.hasher.Equal(s.key, key) {
+			*s = cuckooSlot[K, V]{}
+			m.length--
+			m.unstash()
+			return true
+		}
+	}
+	for i, s := range m.stash {
+		if s.hash == h && m.hasher.Equal(s.key, key) {
+			m.stash = slices.Delete(m.stash, i, i+1)
+			m.length--
+			return true
+		}
+	}
+	return false
+}
+
+// All returns an iterator over all the key, value pairs in the map, in
+// unspecified order. The map must not be modified during iteration.
+func (m *Cuckoo[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for t := range m.tables {
+			for _, s := range m.tables[t] {
+				if s.used && !yield(s.key, s.value) {
+					return
+				}
+			}
+		}
+		for _, s := range m.stash {
+			if !yield(s.key, s.value) {
+				return
+			}
+		}
+	}
+}
+
+// index returns the slot of a key with hash h in table t.
+func (m *Cuckoo[K, V]) index(t int, h uint64) int {
+	return int(hash.Mix(h^m.seeds[t])) & (len(m.tables[t]) - 1)
+}
+
+// find returns the slot holding key with hash h, or nil.
+func (m *Cuckoo[K, V]) find(key K, h uint64) *cuckooSlot[K, V] {
+	for t := range m.tables {
+		s := &m.tables[t][m.index(t, h)]
+		if s.used && s.hash == h && m.hasher.Equal(s.key, key) {
+			return s
+		}
+	}
+	for i := range m.stash {
+		if s := &m.stash[i]; s.hash == h && m.hasher.Equal(s.key, key) {
+			return s
+		}
+	}
+	return nil
+}
+
+// insert places e in the tables, kicking out other entries as needed. If it
+// gives up, it returns the entry left without a slot and false.
+func (m *Cuckoo[K, V]) insert(e cuckooSlot[K, V]) (cuckooSlot[K, V], bool) {
+	// The expected number of kicks is small; a long chain means there's
+	// likely a cycle.
+	maxKicks := 8 + 2*bits.Len(uint(len(m.tables[0])))
+	for range maxKicks {
+		for t := range m.tables {
+			s := &m.tables[t][m.index(t, e.hash)]
+			if !s.used {
+				*s = e
+				return cuckooSlot[K, V]{}, true
+			}
+			*s, e = e, *s
+		}
+	}
+	return e, false
+}
+
+// stashOrRebuild stores an entry that insert failed to place.
+func (m *Cuckoo[K, V]) stashOrRebuild(e cuckooSlot[K, V]) {
+	m.stash = append(m.stash, e)
+	if len(m.stash) > m.stashLimit {
+		m.rebuild(len(m.tables[0]))
+	}
+}
+
+// unstash moves stashed entries back into the tables where there's room for
+// them without kicking.
+func (m *Cuckoo[K, V]) unstash() {
+	m.stash = slices.DeleteFunc(m.stash, func(e cuckooSlot[K, V]) bool {
+		for t := range m.tables {
+			if s := &m.tables[t][m.index(t, e.hash)]; !s.used {
+				*s = e
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// rebuild reinserts all the entries into tables of size n with new hash
+// functions.
+func (m *Cuckoo[K, V]) rebuild(n int) {
+	var entries []cuckooSlot[K, V]
+	for t := range m.tables {
+		for _, s := range m.tables[t] {
+			if s.used {
+				entries = append(entries, s)
+			}
+		}
+	}
+	entries = append(entries, m.stash...)
+
+	for range cuckooMaxRehash {
+		m.reset(n)
+		if m.reinsert(entries, cuckooMaxStash) {
+			return
+		}
+	}
+	// The entries don't fit; this happens when more keys share a hash than
+	// the tables and stash can hold. Let the stash overflow, leaving room
+	// for further insertions before the next rebuild.
+	m.reset(n)
+	m.reinsert(entries, len(entries))
+	m.stashLimit = len(m.stash) + cuckooMaxStash
+}
+
+// reinsert tries to insert entries into the freshly reset tables, stashing
+// at most maxStash of them.
+func (m *Cuckoo[K, V]) reinsert(entries []cuckooSlot[K, V], maxStash int) bool {
+	for _, e := range entries {
+		if homeless, ok := m.insert(e); !ok {
+			if len(m.stash) == maxStash {
+				return false
+			}
+			m.stash = append(m.stash, homeless)
+		}
+	}
+	return true
+}
+
+func (m *Cuckoo[K, V]) reset(n int) {
+	for t := range m.tables {
+		m.tables[t] = make([]cuckooSlot[K, V], n)
+		m.seeds[t] = rand.Uint64()
+	}
+	m.stash = nil
+	m.stashLimit = cuckooMaxStash
+}
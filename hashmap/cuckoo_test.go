@@ -0,0 +1,84 @@
+package hashmap
+
+import (
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkCuckoo verifies that every entry in the tables is in its slot for
+// the table's hash function, and that the stash is within its limit.
+func checkCuckoo[K, V any](t *testing.T, m *Cuckoo[K, V]) {
+	t.Helper()
+	n := len(m.stash)
+	if n > m.stashLimit {
+		t.Fatalf("stash has %d entries, limit %d", n, m.stashLimit)
+	}
+	for tb := range m.tables {
+		for i, s := range m.tables[tb] {
+			if !s.used {
+				continue
+			}
+			n++
+			if m.index(tb, s.hash) != i {
+				t.Fatalf("table %d slot %d: entry belongs in slot %d", tb, i, m.index(tb, s.hash))
+			}
+		}
+	}
+	if n != m.Len() {
+		t.Fatalf("found %d entries, Len=%d", n, m.Len())
+	}
+}
+
+func TestCuckooRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	hashers := map[string]Hasher[int]{
+		"good": IntHasher[int](),
+		// With only 64 distinct hashes for 300 keys, many keys share both
+		// slots, forcing the stash to overflow.
+		"weak": {Hash: func(k int) uint64 { return uint64(k % 64) }, Equal: func(a, b int) bool { return a == b }},
+	}
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			m := NewCuckoo[int, int](hasher)
+			mirror := make(map[int]int)
+			for i := range 20000 {
+				k := rnd.IntN(300)
+				if rnd.IntN(2) == 0 {
+					_, had := mirror[k]
+					if m.Delete(k) != had {
+						t.Fatalf("Delete(%d) mismatch", k)
+					}
+					delete(mirror, k)
+				} else {
+					v := rnd.Int()
+					m.Set(k, v)
+					mirror[k] = v
+				}
+				if i%500 == 0 {
+					checkCuckoo(t, m)
+					for k, v := range mirror {
+						if got, ok := m.Get(k); !ok || got != v {
+							t.Fatalf("Get(%d)=%d,%v, want %d", k, got, ok, v)
+						}
+					}
+				}
+			}
+			checkCuckoo(t, m)
+		})
+	}
+}
+
+func TestCuckooStashLimit(t *testing.T) {
+	m := NewCuckoo[int, int](IntHasher[int]())
+	for k := range 100000 {
+		m.Set(k, k)
+		if len(m.stash) > cuckooMaxStash {
+			t.Fatalf("stash has %d entries with a good hasher", len(m.stash))
+		}
+	}
+	checkCuckoo(t, m)
+}
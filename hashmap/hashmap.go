@@ -0,0 +1,82 @@
+// Package hashmap implements open-addressing hash maps with pluggable hash
+// functions.
+//
+// Since the hash and equality functions are supplied by the user, keys don't
+// need to be comparable: a map can be keyed by []byte, or by structs
+// compared by some of their fields.
+package hashmap
+
+import (
+	"hash/maphash"
+	"iter"
+	"slices"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+// Map is the API shared by the hash maps in this package, so that they can
+// be swapped for one another.
+type Map[K, V any] interface {
+	// Len returns the number of entries in the map.
+	Len() int
+
+	// Get looks for key in the map. It returns the associated value and
+	// ok=true; otherwise, it returns ok=false.
+	Get(key K) (V, bool)
+
+	// Set sets the value of key to value, adding key if it's not in the map.
+	Set(key K, value V)
+
+	// Insert is the same as Set; it makes the map satisfy container.Map.
+	Insert(key K, value V)
+
+	// Delete deletes key and its value from the map. It returns true if key
+	// was found, and false otherwise.
+	Delete(key K) bool
+
+	// All returns an iterator over all the key, value pairs in the map, in
+	// unspecified order. The map must not be modified during iteration.
+	All() iter.Seq2[K, V]
+}
+
+// Hasher holds the functions a map uses to hash and compare its keys. Keys
+// for which Equal returns true must have the same Hash.
+type Hasher[K any] struct {
+	Hash  func(key K) uint64
+	Equal func(a, b K) bool
+}
+
+// StringHasher returns a Hasher for strings, using hash/maphash with a
+// random seed.
+func StringHasher() Hasher[string] {
+	seed := maphash.MakeSeed()
+	return Hasher[string]{
+		Hash:  func(s string) uint64 { return maphash.String(seed, s) },
+		Equal: func(a, b string) bool { return a == b },
+	}
+}
+
+// BytesHasher returns a Hasher for byte slices, using hash/maphash with a
+// random seed. Keys are compared by content.
+func BytesHasher() Hasher[[]byte] {
+	seed := maphash.MakeSeed()
+	return Hasher[[]byte]{
+		Hash:  func(b []byte) uint64 { return maphash.Bytes(seed, b) },
+		Equal: slices.Equal[[]byte],
+	}
+}
+
+// Integer is a constraint for the integer types.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// IntHasher returns a Hasher for integers, mixing them with a random seed.
+func IntHasher[K Integer]() Hasher[K] {
+	seed := maphash.String(maphash.MakeSeed(), "")
+	return Hasher[K]{
+		Hash:  func(k K) uint64 { return hash.Mix(uint64(k) ^ seed) },
+		Equal: func(a, b K) bool { return a == b },
+	}
+}
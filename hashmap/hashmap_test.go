@@ -0,0 +1,121 @@
+package hashmap
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+var (
+	_ Map[string, int] = (*Swiss[string, int])(nil)
+	_ Map[string, int] = (*RobinHood[string, int])(nil)
+	_ Map[string, int] = (*Cuckoo[string, int])(nil)
+)
+
+func TestHashers(t *testing.T) {
+	sh := StringHasher()
+	if sh.Hash("abc") != sh.Hash("ab"+"c") || !sh.Equal("x", "x") || sh.Equal("x", "y") {
+		t.Errorf("bad string hasher")
+	}
+	bh := BytesHasher()
+	if bh.Hash([]byte("abc")) != bh.Hash([]byte("abc")) || !bh.Equal([]byte("x"), []byte("x")) {
+		t.Errorf("bad bytes hasher")
+	}
+
+	// Consecutive integers must spread over the low bits, which select the
+	// bucket.
+	ih := IntHasher[uint32]()
+	seen := make(map[uint64]bool)
+	for k := range uint32(256) {
+		seen[ih.Hash(k)>>7&0xff] = true
+	}
+	if len(seen) < 128 {
+		t.Errorf("int hasher: only %d distinct buckets of 256", len(seen))
+	}
+}
+
+// backends lists constructors for all the hash maps in the package.
+var backends = []struct {
+	name string
+	make func(Hasher[int]) Map[int, int]
+}{
+	{"swiss", func(h Hasher[int]) Map[int, int] { return NewSwiss[int, int](h) }},
+	{"robinhood", func(h Hasher[int]) Map[int, int] { return NewRobinHood[int, int](h) }},
+	{"cuckoo", func(h Hasher[int]) Map[int, int] { return NewCuckoo[int, int](h) }},
+}
+
+func TestConformance(t *testing.T) {
+	for _, be := range backends {
+		t.Run(be.name, func(t *testing.T) {
+			m := be.make(IntHasher[int]())
+			for k := range 1000 {
+				m.Set(k, k*k)
+			}
+			for k := 0; k < 1000; k += 3 {
+				if !m.Delete(k) {
+					t.Fatalf("Delete(%d) failed", k)
+				}
+			}
+			if m.Delete(0) || m.Len() != 666 {
+				t.Fatalf("Len=%d", m.Len())
+			}
+			for k := range 1000 {
+				v, ok := m.Get(k)
+				if ok != (k%3 != 0) || (ok && v != k*k) {
+					t.Fatalf("Get(%d)=%d,%v", k, v, ok)
+				}
+			}
+			n := 0
+			for k, v := range m.All() {
+				if k%3 == 0 || v != k*k {
+					t.Fatalf("All yielded %d=%d", k, v)
+				}
+				n++
+			}
+			if n != 666 {
+				t.Fatalf("All yielded %d entries", n)
+			}
+		})
+	}
+}
+
+func TestInsert(t *testing.T) {
+	for _, be := range backends {
+		t.Run(be.name, func(t *testing.T) {
+			m := be.make(IntHasher[int]())
+			m.Insert(1, 10)
+			m.Insert(1, 11)
+			m.Set(2, 20)
+			if v, ok := m.Get(1); !ok || v != 11 || m.Len() != 2 {
+				t.Errorf("Get(1)=%d,%v, Len=%d", v, ok, m.Len())
+			}
+		})
+	}
+}
+
+func BenchmarkBackends(b *testing.B) {
+	const n = 100000
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = rand.Int()
+	}
+	for _, be := range backends {
+		b.Run(be.name+"/Set", func(b *testing.B) {
+			for range b.N {
+				m := be.make(IntHasher[int]())
+				for _, k := range keys {
+					m.Set(k, k)
+				}
+			}
+		})
+		b.Run(be.name+"/Get", func(b *testing.B) {
+			m := be.make(IntHasher[int]())
+			for _, k := range keys {
+				m.Set(k, k)
+			}
+			b.ResetTimer()
+			for i := range b.N {
+				m.Get(keys[i%n])
+			}
+		})
+	}
+}
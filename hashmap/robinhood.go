@@ -0,0 +1,169 @@
+package hashmap
+
+import "iter"
+
+// RobinHood is an open-addressing hash map using Robin Hood linear probing.
+// Each entry's probe length is its distance from its home slot; on insertion,
+// an entry with a longer probe length takes the slot of a "richer" entry
+// with a shorter one, which continues probing instead. This keeps probe
+// lengths close to the mean, with low variance, so the worst-case lookup is
+// not much slower than the average one. A lookup can also stop as soon as it
+// passes the point where its key would have displaced an entry.
+//
+// Deletion uses backward shifting, so the map has no tombstones.
+//
+// Create RobinHood maps with [NewRobinHood].
+type RobinHood[K, V any] struct {
+	hasher Hasher[K]
+	slots  []rhSlot[K, V]
+	length int
+}
+
+type rhSlot[K, V any] struct {
+	key   K
+	value V
+	hash  uint64
+
+	// dist is the probe length of the entry plus one; 0 marks an empty
+	// slot.
+	dist uint32
+}
+
+// NewRobinHood creates a new, empty RobinHood map using hasher for its keys.
+func NewRobinHood[K, V any](hasher Hasher[K]) *RobinHood[K, V] {
+	return &RobinHood[K, V]{
+		hasher: hasher,
+		slots:  make([]rhSlot[K, V], 8),
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *RobinHood[K, V]) Len() int {
+	return m.length
+}
+
+// Get looks for key in the map. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (m *RobinHood[K, V]) Get(key K) (v V, ok bool) {
+	if i, found := m.find(key, m.hasher.Hash(key)); found {
+		return m.slots[i].value, true
+	}
+	return v, false
+}
+
+// Set sets the value of key to value, adding key if it's not in the map.
+func (m *RobinHood[K, V]) Set(key K, value V) {
+	h := m.hasher.Hash(key)
+	if i, found := m.find(key, h); found {
+		m.slots[i].value = value
+		return
+	}
+	if (m.length+1)*8 > len(m.slots)*7 {
+		m.resize(len(m.slots) * 2)
+	}
+	m.insertNew(rhSlot[K, V]{key: key, value: value, hash: h, dist: 1})
+	m.length++
+}
+
+// Insert is the same as Set; it makes the map satisfy container.Map.
+func (m *RobinHood[K, V]) Insert(key K, value V) {
+	m.Set(key, value)
+}
+
+// Delete deletes key and its value from the map. It returns true if key was
+// found, and false otherwise.
+func (m *RobinHood[K, V]) Delete(key K) bool {
+	i, found := m.find(key, m.hasher.Hash(key))
+	if !found {
+		return false
+	}
+	m.length--
+
+	// Shift the following entries back by one slot, until reaching an empty
+	// slot or an entry that's already in its home slot.
+	mask := len(m.slots) - 1
+	for {
+		next := (i + 1) & mask
+		if m.slots[next].dist <= 1 {
+			m.slots[i] = rhSlot[K, V]{}
+			return true
+		}
+		m.slots[i] = m.slots[next]
+		m.slots[i].dist--
+		i = next
+	}
+}
+
+// All returns an iterator over all the key, value pairs in the map, in
+// unspecified order. The map must not be modified during iteration.
+func (m *RobinHood[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range m.slots {
+			if s := &m.slots[i]; s.dist != 0 && !yield(s.key, s.value) {
+				return
+			}
+		}
+	}
+}
+
+// MaxProbeLength returns the longest probe length of any entry in the map:
+// the number of slots past its home slot a lookup of that entry examines.
+// It takes O(capacity) time.
+func (m *RobinHood[K, V]) MaxProbeLength() int {
+	longest := 0
+	for i := range m.slots {
+		longest = max(longest, int(m.slots[i].dist)-1)
+	}
+	return longest
+}
+
+func (m *RobinHood[K, V]) home(h uint64) int {
+	return int(h) & (len(m.slots) - 1)
+}
+
+// find looks for key with hash h, returning its slot.
+func (m *RobinHood[K, V]) find(key K, h uint64) (int, bool) {
+	mask := len(m.slots) - 1
+	i := m.home(h)
+	for dist := uint32(1); ; dist++ {
+		s := &m.slots[i]
+		// Had key been in the map, it would have displaced an entry closer
+		// to its home than key would be here.
+		if s.dist < dist {
+			return 0, false
+		}
+		if s.hash == h && m.hasher.Equal(s.key, key) {
+			return i, true
+		}
+		i = (i + 1) & mask
+	}
+}
+
+// insertNew inserts an entry whose key isn't in the map.
+func (m *RobinHood[K, V]) insertNew(e rhSlot[K, V]) {
+	mask := len(m.slots) - 1
+	i := m.home(e.hash)
+	for {
+		s := &m.slots[i]
+		if s.dist == 0 {
+			*s = e
+			return
+		}
+		if s.dist < e.dist {
+			*s, e = e, *s
+		}
+		e.dist++
+		i = (i + 1) & mask
+	}
+}
+
+func (m *RobinHood[K, V]) resize(n int) {
+	old := m.slots
+	m.slots = make([]rhSlot[K, V], n)
+	for _, s := range old {
+		if s.dist != 0 {
+			s.dist = 1
+			m.insertNew(s)
+		}
+	}
+}
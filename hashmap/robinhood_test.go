@@ -0,0 +1,84 @@
+package hashmap
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkRobinHood verifies that every entry's recorded distance matches its
+// position, and the Robin Hood invariant: an entry's probe length exceeds
+// the previous slot's by at most one.
+func checkRobinHood[K, V any](t *testing.T, m *RobinHood[K, V]) {
+	t.Helper()
+	mask := len(m.slots) - 1
+	n := 0
+	for i, s := range m.slots {
+		if s.dist == 0 {
+			continue
+		}
+		n++
+		if want := uint32((i-m.home(s.hash))&mask) + 1; s.dist != want {
+			t.Fatalf("slot %d: dist=%d, want %d", i, s.dist, want)
+		}
+		if prev := m.slots[(i-1)&mask]; s.dist > prev.dist+1 {
+			t.Fatalf("slot %d: dist=%d after %d", i, s.dist, prev.dist)
+		}
+	}
+	if n != m.Len() {
+		t.Fatalf("found %d entries, Len=%d", n, m.Len())
+	}
+}
+
+func TestRobinHoodRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	hashers := map[string]Hasher[int]{
+		"good": IntHasher[int](),
+		"weak": {Hash: func(k int) uint64 { return uint64(k % 5) }, Equal: func(a, b int) bool { return a == b }},
+	}
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			m := NewRobinHood[int, int](hasher)
+			mirror := make(map[int]int)
+			for i := range 20000 {
+				k := rnd.IntN(300)
+				if rnd.IntN(2) == 0 {
+					_, had := mirror[k]
+					if m.Delete(k) != had {
+						t.Fatalf("Delete(%d) mismatch", k)
+					}
+					delete(mirror, k)
+				} else {
+					v := rnd.Int()
+					m.Set(k, v)
+					mirror[k] = v
+				}
+				if i%500 == 0 {
+					checkRobinHood(t, m)
+					for k, v := range mirror {
+						if got, ok := m.Get(k); !ok || got != v {
+							t.Fatalf("Get(%d)=%d,%v, want %d", k, got, ok, v)
+						}
+					}
+				}
+			}
+			checkRobinHood(t, m)
+		})
+	}
+}
+
+func TestRobinHoodProbeLength(t *testing.T) {
+	m := NewRobinHood[string, int](StringHasher())
+	for i := range 100000 {
+		m.Set(fmt.Sprint(i), i)
+	}
+	checkRobinHood(t, m)
+	// At a load factor of at most 7/8, the longest probe stays short.
+	if got := m.MaxProbeLength(); got > 64 {
+		t.Errorf("MaxProbeLength=%d", got)
+	}
+}
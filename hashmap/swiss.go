@@ -0,0 +1,244 @@
+package hashmap
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// Swiss is a hash map in the style of Google's SwissTable. Entries are
+// stored inline in groups of 8 slots, each group with a word of 8 control
+// bytes: a control byte is either empty, or holds 7 bits of the hash of the
+// key in its slot. A lookup probes groups linearly from the key's home
+// group, matching all 8 control bytes of a group at once with bitwise
+// arithmetic (SWAR, "SIMD within a register"), and only compares keys
+// whose control bytes match. A probe ends at the first group that has an
+// empty slot.
+//
+// Deletion doesn't leave tombstones: entries later in the probe run are
+// shifted back into the freed slot as needed, like backward-shift deletion
+// in linear probing, so lookups never slow down as entries are deleted.
+//
+// Create Swiss maps with [NewSwiss].
+type Swiss[K, V any] struct {
+	hasher Hasher[K]
+	groups []group[K, V]
+	length int
+}
+
+const (
+	groupSize = 8
+
+	// ctrlEmpty marks an empty slot; full slots have the high bit clear.
+	ctrlEmpty = 0x80
+
+	lsbs = 0x0101010101010101
+	msbs = 0x8080808080808080
+)
+
+type group[K, V any] struct {
+	// ctrl holds the control byte of slot i in bits [8i, 8i+8).
+	ctrl   uint64
+	keys   [groupSize]K
+	values [groupSize]V
+}
+
+// NewSwiss creates a new, empty Swiss map using hasher for its keys.
+func NewSwiss[K, V any](hasher Hasher[K]) *Swiss[K, V] {
+	m := &Swiss[K, V]{hasher: hasher}
+	m.groups = newGroups[K, V](1)
+	return m
+}
+
+func newGroups[K, V any](n int) []group[K, V] {
+	groups := make([]group[K, V], n)
+	for i := range groups {
+		groups[i].ctrl = msbs
+	}
+	return groups
+}
+
+// Len returns the number of entries in the map.
+func (m *Swiss[K, V]) Len() int {
+	return m.length
+}
+
+// Get looks for key in the map. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (m *Swiss[K, V]) Get(key K) (v V, ok bool) {
+	if g, s, found := m.find(key, m.hasher.Hash(key)); found {
+		return m.groups[g].values[s], true
+	}
+	return v, false
+}
+
+// Set sets the value of key to value, adding key if it's not in the map.
+func (m *Swiss[K, V]) Set(key K, value V) {
+	h := m.hasher.Hash(key)
+	if g, s, found := m.find(key, h); found {
+		m.groups[g].values[s] = value
+		return
+	}
+	// Keep the load factor at most 7/8, so probes always find empty slots.
+	if (m.length+1)*8 > len(m.groups)*groupSize*7 {
+		m.resize(len(m.groups) * 2)
+	}
+	m.insertNew(key, value, h)
+	m.length++
+}
+
+// Insert is the same as Set; it makes the map satisfy container.Map.
+func (m *Swiss[K, V]) Insert(key K, value V) {
+	m.Set(key, value)
+}
+
+// Delete deletes key and its value from the map. It returns true if key was
+// found, and false otherwise.
+func (m *Swiss[K, V]) Delete(key K) bool {
+	g, s, found := m.find(key, m.hasher.Hash(key))
+	if !found {
+		return false
+	}
+	m.length--
+	m.clearSlot(g, s)
+	m.backshift(g)
+	return true
+}
+
+// All returns an iterator over all the key, value pairs in the map, in
+// unspecified order. The map must not be modified during iteration.
+func (m *Swiss[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := range m.groups {
+			g := &m.groups[i]
+			for full := ^g.ctrl & msbs; full != 0; full &= full - 1 {
+				s := bits.TrailingZeros64(full) / 8
+				if !yield(g.keys[s], g.values[s]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// split splits a hash into the home group index (h1) and the 7 bits stored
+// in the control byte (h2).
+func (m *Swiss[K, V]) split(h uint64) (h1 int, h2 uint8) {
+	return int(h>>7) & (len(m.groups) - 1), uint8(h & 0x7f)
+}
+
+// find looks for key with hash h, returning its group and slot.
+func (m *Swiss[K, V]) find(key K, h uint64) (g, s int, found bool) {
+	g, h2 := m.split(h)
+	mask := len(m.groups) - 1
+	for {
+		grp := &m.groups[g]
+		for match := matchByte(grp.ctrl, h2); match != 0; match &= match - 1 {
+			s := bits.TrailingZeros64(match) / 8
+			if m.hasher.Equal(grp.keys[s], key) {
+				return g, s, true
+			}
+		}
+		if grp.ctrl&msbs != 0 {
+			return 0, 0, false
+		}
+		g = (g + 1) & mask
+	}
+}
+
+// insertNew inserts a key that's not in the map into the first empty slot of
+// its probe sequence.
+func (m *Swiss[K, V]) insertNew(key K, value V, h uint64) {
+	g, h2 := m.split(h)
+	mask := len(m.groups) - 1
+	for {
+		grp := &m.groups[g]
+		if empty := grp.ctrl & msbs; empty != 0 {
+			s := bits.TrailingZeros64(empty) / 8
+			grp.keys[s] = key
+			grp.values[s] = value
+			grp.ctrl = setByte(grp.ctrl, s, h2)
+			return
+		}
+		g = (g + 1) & mask
+	}
+}
+
+func (m *Swiss[K, V]) resize(n int) {
+	old := m.groups
+	m.groups = newGroups[K, V](n)
+	for i := range old {
+		g := &old[i]
+		for full := ^g.ctrl & msbs; full != 0; full &= full - 1 {
+			s := bits.TrailingZeros64(full) / 8
+			m.insertNew(g.keys[s], g.values[s], m.hasher.Hash(g.keys[s]))
+		}
+	}
+}
+
+func (m *Swiss[K, V]) clearSlot(g, s int) {
+	grp := &m.groups[g]
+	grp.keys[s] = *new(K)
+	grp.values[s] = *new(V)
+	grp.ctrl = setByte(grp.ctrl, s, ctrlEmpty)
+}
+
+// backshift restores the probing invariant after a slot in group hole was
+// cleared. The invariant is that every entry is reachable from its home
+// group through full groups only; clearing a slot of a group that was full
+// breaks it for entries further along whose probe passed through the group.
+// One such entry is moved into the hole, which moves the hole to the entry's
+// old group, and the process repeats from there.
+func (m *Swiss[K, V]) backshift(hole int) {
+	mask := len(m.groups) - 1
+	for {
+		// If the hole's group has other empty slots, it already ended probe
+		// sequences before the deletion, and no probe passed through it.
+		holeCtrl := m.groups[hole].ctrl
+		if bits.OnesCount64(holeCtrl&msbs) > 1 {
+			return
+		}
+		holeSlot := bits.TrailingZeros64(holeCtrl&msbs) / 8
+
+		moved := false
+		for g := (hole + 1) & mask; g != hole; g = (g + 1) & mask {
+			grp := &m.groups[g]
+			for full := ^grp.ctrl & msbs; full != 0; full &= full - 1 {
+				s := bits.TrailingZeros64(full) / 8
+				home, h2 := m.split(m.hasher.Hash(grp.keys[s]))
+				// The entry's probe passed through hole if hole is between its
+				// home and its current group, cyclically.
+				if (hole-home)&mask < (g-home)&mask {
+					hg := &m.groups[hole]
+					hg.keys[holeSlot] = grp.keys[s]
+					hg.values[holeSlot] = grp.values[s]
+					hg.ctrl = setByte(hg.ctrl, holeSlot, h2)
+					m.clearSlot(g, s)
+					hole = g
+					moved = true
+					break
+				}
+			}
+			// Probes don't continue past a group that had empty slots.
+			if moved || grp.ctrl&msbs != 0 {
+				break
+			}
+		}
+		if !moved {
+			return
+		}
+	}
+}
+
+// matchByte returns a mask with the high bit set in every byte of ctrl equal
+// to b. Like the SwissTable portable implementation it can have false
+// positives (only in a byte directly above a true match), which callers
+// filter out by comparing keys.
+func matchByte(ctrl uint64, b uint8) uint64 {
+	x := ctrl ^ (lsbs * uint64(b))
+	return (x - lsbs) &^ x & msbs
+}
+
+func setByte(ctrl uint64, i int, b uint8) uint64 {
+	shift := uint(i) * 8
+	return ctrl&^(0xff<<shift) | uint64(b)<<shift
+}
@@ -0,0 +1,179 @@
+package hashmap
+
+import (
+	"fmt"
+	"log"
+	"math/bits"
+	"math/rand/v2"
+	"testing"
+)
+
+// checkSwiss verifies the probing invariant of m: every entry is reachable
+// from its home group through full groups only.
+func checkSwiss[K, V any](t *testing.T, m *Swiss[K, V]) {
+	t.Helper()
+	mask := len(m.groups) - 1
+	n := 0
+	for gi := range m.groups {
+		grp := &m.groups[gi]
+		for full := ^grp.ctrl & msbs; full != 0; full &= full - 1 {
+			s := bits.TrailingZeros64(full) / 8
+			n++
+			home, h2 := m.split(m.hasher.Hash(grp.keys[s]))
+			if uint8(grp.ctrl>>(8*s)) != h2 {
+				t.Fatalf("group %d slot %d: bad control byte", gi, s)
+			}
+			for g := home; g != gi; g = (g + 1) & mask {
+				if m.groups[g].ctrl&msbs != 0 {
+					t.Fatalf("entry in group %d (home %d) unreachable: group %d has empty slots", gi, home, g)
+				}
+			}
+		}
+	}
+	if n != m.Len() {
+		t.Fatalf("found %d entries, Len=%d", n, m.Len())
+	}
+}
+
+func TestSwissBasic(t *testing.T) {
+	m := NewSwiss[string, int](StringHasher())
+	for i := range 1000 {
+		m.Set(fmt.Sprint(i), i)
+	}
+	m.Set("7", 701)
+	if m.Len() != 1000 {
+		t.Errorf("Len=%d", m.Len())
+	}
+	if v, ok := m.Get("7"); !ok || v != 701 {
+		t.Errorf("Get(7)=%d,%v", v, ok)
+	}
+	if _, ok := m.Get("1000"); ok {
+		t.Errorf("found missing key")
+	}
+	for i := range 500 {
+		if !m.Delete(fmt.Sprint(i * 2)) {
+			t.Fatalf("Delete(%d) failed", i*2)
+		}
+	}
+	if m.Delete("0") || m.Len() != 500 {
+		t.Errorf("Len=%d", m.Len())
+	}
+	checkSwiss(t, m)
+	sum := 0
+	for _, v := range m.All() {
+		sum += v % 2
+	}
+	if sum != 500 {
+		t.Errorf("All yielded %d odd values", sum)
+	}
+}
+
+func TestSwissBytesKeys(t *testing.T) {
+	m := NewSwiss[[]byte, string](BytesHasher())
+	m.Set([]byte("hello"), "world")
+	key := []byte("hel")
+	key = append(key, "lo"...)
+	if v, ok := m.Get(key); !ok || v != "world" {
+		t.Errorf("Get=%q,%v", v, ok)
+	}
+}
+
+func TestSwissRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	hashers := map[string]Hasher[int]{
+		"good": IntHasher[int](),
+		// A weak hash clusters entries into long probe runs, stressing
+		// backward shifting.
+		"weak": {Hash: func(k int) uint64 { return uint64(k%7) << 7 }, Equal: func(a, b int) bool { return a == b }},
+	}
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			m := NewSwiss[int, int](hasher)
+			mirror := make(map[int]int)
+			for i := range 20000 {
+				k := rnd.IntN(300)
+				if rnd.IntN(2) == 0 {
+					_, had := mirror[k]
+					if m.Delete(k) != had {
+						t.Fatalf("Delete(%d) mismatch", k)
+					}
+					delete(mirror, k)
+				} else {
+					v := rnd.Int()
+					m.Set(k, v)
+					mirror[k] = v
+				}
+				if i%500 == 0 {
+					checkSwiss(t, m)
+					for k, v := range mirror {
+						if got, ok := m.Get(k); !ok || got != v {
+							t.Fatalf("Get(%d)=%d,%v, want %d", k, got, ok, v)
+						}
+					}
+				}
+			}
+			checkSwiss(t, m)
+			got := make(map[int]int)
+			for k, v := range m.All() {
+				got[k] = v
+			}
+			if len(got) != len(mirror) {
+				t.Fatalf("All yielded %d entries, want %d", len(got), len(mirror))
+			}
+			for k, v := range mirror {
+				if got[k] != v {
+					t.Fatalf("All: %d=%d, want %d", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchByte(t *testing.T) {
+	ctrl := uint64(0x80_05_05_7f_00_05_80_01)
+	got := matchByte(ctrl, 0x05)
+	// Bytes 2, 5 and 6 match; false positives may appear only in the byte
+	// above a true match.
+	for i := range 8 {
+		isMatch := got>>(8*i+7)&1 == 1
+		want := i == 2 || i == 5 || i == 6
+		if want && !isMatch {
+			t.Errorf("byte %d: missed match", i)
+		}
+		if !want && isMatch && !(i > 0 && (i-1 == 2 || i-1 == 5 || i-1 == 6)) {
+			t.Errorf("byte %d: unexpected match", i)
+		}
+	}
+}
+
+func BenchmarkSwissVsBuiltin(b *testing.B) {
+	for _, n := range []int{100, 10000, 1000000} {
+		keys := make([]int, n)
+		for i := range keys {
+			keys[i] = rand.Int()
+		}
+		b.Run(fmt.Sprintf("swiss/%d", n), func(b *testing.B) {
+			m := NewSwiss[int, int](IntHasher[int]())
+			for _, k := range keys {
+				m.Set(k, k)
+			}
+			b.ResetTimer()
+			for i := range b.N {
+				m.Get(keys[i%n])
+			}
+		})
+		b.Run(fmt.Sprintf("builtin/%d", n), func(b *testing.B) {
+			m := make(map[int]int)
+			for _, k := range keys {
+				m[k] = k
+			}
+			b.ResetTimer()
+			for i := range b.N {
+				_ = m[keys[i%n]]
+			}
+		})
+	}
+}
@@ -44,6 +44,11 @@ func (hs *HashSet[T]) Delete(val T) {
 	delete(hs.m, val)
 }
 
+// Clear removes all the values from the set.
+func (hs *HashSet[T]) Clear() {
+	clear(hs.m)
+}
+
 // All returns an iterator over all the values in the set.
 func (hs *HashSet[T]) All() iter.Seq[T] {
 	return func(yield func(T) bool) {
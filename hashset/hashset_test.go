@@ -59,6 +59,14 @@ func TestAll(t *testing.T) {
 	checkAll(t, hs, []int{})
 }
 
+func TestClear(t *testing.T) {
+	hs := InitWith(1, 2, 3)
+	hs.Clear()
+	checkAll(t, hs, []int{})
+	hs.Add(4)
+	checkAll(t, hs, []int{4})
+}
+
 func TestContains(t *testing.T) {
 	hs := New[string]()
 
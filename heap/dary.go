@@ -0,0 +1,122 @@
+package heap
+
+import (
+	"fmt"
+	"iter"
+)
+
+// DAry is a d-ary min-heap: every node has up to d children. The arity d is
+// set when the heap is created; higher arity makes Push cheaper and the
+// heap shallower, which tends to be more cache-friendly for pop-heavy
+// workloads (typical good values are 4 or 8).
+type DAry[T any] struct {
+	cmp func(T, T) int
+	d   int
+
+	// items holds the heap's items; for an element at index i, its children
+	// are at indices d*i+1 ... d*i+d and its parent is at (i-1)/d.
+	items []T
+}
+
+// NewDAry creates a new, empty d-ary heap with the given arity and
+// comparison function. It panics if d < 2.
+func NewDAry[T any](d int, cmp func(a, b T) int) *DAry[T] {
+	if d < 2 {
+		panic(fmt.Sprintf("invalid heap arity %d", d))
+	}
+	return &DAry[T]{cmp: cmp, d: d}
+}
+
+// Len returns the number of elements in the heap.
+func (h *DAry[T]) Len() int {
+	return len(h.items)
+}
+
+// Arity returns the arity d of the heap.
+func (h *DAry[T]) Arity() int {
+	return h.d
+}
+
+// Push inserts a new element into the heap.
+func (h *DAry[T]) Push(v T) {
+	h.items = append(h.items, v)
+	h.siftup(len(h.items) - 1)
+}
+
+// Peek returns the minimal element in the heap without removing it. It panics
+// if the heap is empty.
+func (h *DAry[T]) Peek() T {
+	if len(h.items) == 0 {
+		panic("peek into empty heap")
+	}
+	return h.items[0]
+}
+
+// Pop removes the minimal element from the heap and returns it. It panics
+// if the heap is empty; make sure to check Len() first.
+func (h *DAry[T]) Pop() T {
+	if len(h.items) == 0 {
+		panic("popping from empty heap")
+	}
+	top := h.items[0]
+	last := len(h.items) - 1
+	h.items[0] = h.items[last]
+	h.items[last] = *new(T)
+	h.items = h.items[:last]
+	h.siftdown(0)
+	return top
+}
+
+// All returns an iterator over the elements of the heap, in unspecified
+// order. The heap must not be modified during iteration.
+func (h *DAry[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, v := range h.items {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (h *DAry[T]) siftup(i int) {
+	v := h.items[i]
+	for i > 0 {
+		p := (i - 1) / h.d
+		if h.cmp(v, h.items[p]) >= 0 {
+			break
+		}
+		h.items[i] = h.items[p]
+		i = p
+	}
+	h.items[i] = v
+}
+
+func (h *DAry[T]) siftdown(i int) {
+	n := len(h.items)
+	if n == 0 {
+		return
+	}
+	v := h.items[i]
+	for {
+		first := h.d*i + 1
+		if first >= n {
+			break
+		}
+
+		// Find the minimal child of i.
+		minChild := first
+		last := min(first+h.d, n)
+		for c := first + 1; c < last; c++ {
+			if h.cmp(h.items[c], h.items[minChild]) < 0 {
+				minChild = c
+			}
+		}
+		if h.cmp(h.items[minChild], v) >= 0 {
+			break
+		}
+		h.items[i] = h.items[minChild]
+		i = minChild
+	}
+	h.items[i] = v
+}
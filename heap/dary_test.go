@@ -0,0 +1,102 @@
+package heap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func checkDAry[T any](t *testing.T, h *DAry[T]) {
+	t.Helper()
+	for i := 1; i < len(h.items); i++ {
+		p := (i - 1) / h.d
+		if h.cmp(h.items[i], h.items[p]) < 0 {
+			t.Errorf("item %d is less than its parent %d", i, p)
+		}
+	}
+}
+
+func TestDAryArities(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, d := range []int{2, 3, 4, 8, 16} {
+		t.Run(fmt.Sprint(d), func(t *testing.T) {
+			h := NewDAry(d, cmp.Compare[int])
+			if h.Arity() != d {
+				t.Errorf("got arity=%d, want %d", h.Arity(), d)
+			}
+
+			var want []int
+			for range 300 {
+				v := rnd.IntN(1000)
+				h.Push(v)
+				want = append(want, v)
+			}
+			checkDAry(t, h)
+
+			slices.Sort(want)
+			if all := slices.Sorted(h.All()); !slices.Equal(all, want) {
+				t.Errorf("got All=%v, want %v", all, want)
+			}
+			if h.Peek() != want[0] {
+				t.Errorf("got peek=%v, want %v", h.Peek(), want[0])
+			}
+			var got []int
+			for h.Len() > 0 {
+				got = append(got, h.Pop())
+				checkDAry(t, h)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDAryInterleaved(t *testing.T) {
+	h := NewDAry(4, strings.Compare)
+	h.Push("mercury")
+	h.Push("venus")
+	h.Push("earth")
+	if got := h.Pop(); got != "earth" {
+		t.Errorf("got %v, want earth", got)
+	}
+	h.Push("arrakis")
+	h.Push("mars")
+	want := []string{"arrakis", "mars", "mercury", "venus"}
+	for _, w := range want {
+		if got := h.Pop(); got != w {
+			t.Errorf("got %v, want %v", got, w)
+		}
+	}
+	if h.Len() != 0 {
+		t.Errorf("got len=%d, want 0", h.Len())
+	}
+}
+
+func TestDAryBadArity(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for arity 1")
+		}
+	}()
+	NewDAry(1, cmp.Compare[int])
+}
+
+func BenchmarkDAryPushPop(b *testing.B) {
+	vals, _ := benchValues()
+	for _, d := range []int{2, 4, 8} {
+		b.Run(fmt.Sprint(d), func(b *testing.B) {
+			for range b.N {
+				h := NewDAry(d, cmp.Compare[int])
+				for _, v := range vals {
+					h.Push(v)
+				}
+				for h.Len() > 0 {
+					h.Pop()
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+// Package heap provides several generic heap (priority queue) variants.
+//
+// All heaps in this package are min-heaps ordered by a user-provided
+// comparison function cmp; cmp(a, b) should return a negative number when
+// a<b, a positive number when a>b and zero when a==b. To get a max-heap,
+// invert the comparison function.
+package heap
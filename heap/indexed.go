@@ -0,0 +1,159 @@
+package heap
+
+// Indexed is a binary min-heap whose Push returns a stable handle for the
+// pushed element. Handles can be used to update or remove elements from the
+// middle of the heap in O(log n), which is what algorithms requiring
+// "decrease key" (like Dijkstra's shortest paths) need.
+type Indexed[T any] struct {
+	cmp func(T, T) int
+
+	// items holds the heap's handles in standard binary heap order: for an
+	// element at index i, its children are at indices 2i+1 and 2i+2 and its
+	// parent at (i-1)/2. Each handle knows its own index in items.
+	items []*Handle[T]
+}
+
+// Handle refers to an element stored in an Indexed heap. It remains valid
+// until the element is popped or removed from the heap.
+type Handle[T any] struct {
+	value T
+
+	// index is the position of this handle in its heap's items, or -1 if
+	// the handle is no longer in the heap.
+	index int
+	owner *Indexed[T]
+}
+
+// Value returns the value the handle refers to.
+func (hd *Handle[T]) Value() T {
+	return hd.value
+}
+
+// NewIndexed creates a new, empty Indexed heap with the given comparison
+// function.
+func NewIndexed[T any](cmp func(a, b T) int) *Indexed[T] {
+	return &Indexed[T]{cmp: cmp}
+}
+
+// Len returns the number of elements in the heap.
+func (h *Indexed[T]) Len() int {
+	return len(h.items)
+}
+
+// Push inserts a new element into the heap and returns its handle.
+func (h *Indexed[T]) Push(v T) *Handle[T] {
+	hd := &Handle[T]{value: v, index: len(h.items), owner: h}
+	h.items = append(h.items, hd)
+	h.siftup(hd.index)
+	return hd
+}
+
+// Peek returns the minimal element in the heap without removing it. It panics
+// if the heap is empty.
+func (h *Indexed[T]) Peek() T {
+	if len(h.items) == 0 {
+		panic("peek into empty heap")
+	}
+	return h.items[0].value
+}
+
+// Pop removes the minimal element from the heap and returns it. It panics
+// if the heap is empty; make sure to check Len() first.
+func (h *Indexed[T]) Pop() T {
+	if len(h.items) == 0 {
+		panic("popping from empty heap")
+	}
+	return h.removeAt(0)
+}
+
+// Contains reports whether hd refers to an element currently in h.
+func (h *Indexed[T]) Contains(hd *Handle[T]) bool {
+	return hd.owner == h && hd.index >= 0
+}
+
+// Update replaces the value referred to by hd with v, and restores the heap
+// order. It panics if hd is not in the heap.
+func (h *Indexed[T]) Update(hd *Handle[T], v T) {
+	h.checkHandle(hd)
+	hd.value = v
+	h.fix(hd.index)
+}
+
+// Remove removes the element referred to by hd from the heap and returns its
+// value. It panics if hd is not in the heap.
+func (h *Indexed[T]) Remove(hd *Handle[T]) T {
+	h.checkHandle(hd)
+	return h.removeAt(hd.index)
+}
+
+func (h *Indexed[T]) checkHandle(hd *Handle[T]) {
+	if !h.Contains(hd) {
+		panic("handle is not in the heap")
+	}
+}
+
+// removeAt removes the element at index i and returns its value.
+func (h *Indexed[T]) removeAt(i int) T {
+	hd := h.items[i]
+	last := len(h.items) - 1
+	if i != last {
+		h.swap(i, last)
+	}
+	h.items[last] = nil
+	h.items = h.items[:last]
+	if i != last {
+		h.fix(i)
+	}
+	hd.index = -1
+	return hd.value
+}
+
+// fix restores the heap order after the element at index i has changed.
+func (h *Indexed[T]) fix(i int) {
+	if !h.siftdown(i) {
+		h.siftup(i)
+	}
+}
+
+func (h *Indexed[T]) less(i, j int) bool {
+	return h.cmp(h.items[i].value, h.items[j].value) < 0
+}
+
+func (h *Indexed[T]) swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *Indexed[T]) siftup(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !h.less(i, p) {
+			return
+		}
+		h.swap(i, p)
+		i = p
+	}
+}
+
+// siftdown moves the element at index i down the heap until the heap order
+// is restored. It reports whether the element moved.
+func (h *Indexed[T]) siftdown(i int) bool {
+	start := i
+	n := len(h.items)
+	for {
+		c := 2*i + 1
+		if c >= n {
+			break
+		}
+		if c+1 < n && h.less(c+1, c) {
+			c++
+		}
+		if !h.less(c, i) {
+			break
+		}
+		h.swap(i, c)
+		i = c
+	}
+	return i > start
+}
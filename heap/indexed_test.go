@@ -0,0 +1,155 @@
+package heap
+
+import (
+	"cmp"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// makeLoggedRand creates a new rand.Rand with a random source, and logs the
+// source to output so the test can be reproduced if needed.
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func checkIndexed[T any](t *testing.T, h *Indexed[T]) {
+	t.Helper()
+	for i, hd := range h.items {
+		if hd.index != i {
+			t.Errorf("item %d has index %d", i, hd.index)
+		}
+		if i > 0 && h.less(i, (i-1)/2) {
+			t.Errorf("item %d is less than its parent", i)
+		}
+	}
+}
+
+func TestIndexedBasic(t *testing.T) {
+	h := NewIndexed(cmp.Compare[int])
+	for _, v := range []int{5, 3, 8, 1, 9, 2} {
+		h.Push(v)
+		checkIndexed(t, h)
+	}
+	if h.Peek() != 1 {
+		t.Errorf("got peek=%v, want 1", h.Peek())
+	}
+
+	var got []int
+	for h.Len() > 0 {
+		got = append(got, h.Pop())
+		checkIndexed(t, h)
+	}
+	want := []int{1, 2, 3, 5, 8, 9}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestIndexedUpdateRemove(t *testing.T) {
+	h := NewIndexed(cmp.Compare[int])
+	h10 := h.Push(10)
+	h20 := h.Push(20)
+	h30 := h.Push(30)
+	h.Push(40)
+
+	h.Update(h30, 5)
+	checkIndexed(t, h)
+	if h.Peek() != 5 || h30.Value() != 5 {
+		t.Errorf("got peek=%v, want 5", h.Peek())
+	}
+
+	h.Update(h30, 50)
+	checkIndexed(t, h)
+	if h.Peek() != 10 {
+		t.Errorf("got peek=%v, want 10", h.Peek())
+	}
+
+	if v := h.Remove(h20); v != 20 {
+		t.Errorf("got removed=%v, want 20", v)
+	}
+	checkIndexed(t, h)
+	if h.Contains(h20) {
+		t.Errorf("removed handle still in heap")
+	}
+	if !h.Contains(h10) {
+		t.Errorf("handle not found in heap")
+	}
+
+	var got []int
+	for h.Len() > 0 {
+		got = append(got, h.Pop())
+	}
+	want := []int{10, 40, 50}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if h.Contains(h10) {
+		t.Errorf("popped handle still in heap")
+	}
+}
+
+func TestIndexedStaleHandlePanics(t *testing.T) {
+	h := NewIndexed(cmp.Compare[int])
+	hd := h.Push(1)
+	h.Pop()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on stale handle")
+		}
+	}()
+	h.Update(hd, 2)
+}
+
+func TestIndexedRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	h := NewIndexed(cmp.Compare[int])
+
+	// Mirror the heap's contents in a map from handle to value; randomly
+	// push, update and remove, verifying the heap after every operation.
+	m := make(map[*Handle[int]]int)
+	anyHandle := func() *Handle[int] {
+		for hd := range m {
+			return hd
+		}
+		return nil
+	}
+	for range 2000 {
+		switch op := rnd.IntN(4); {
+		case op == 0 || len(m) == 0:
+			v := rnd.IntN(1000)
+			m[h.Push(v)] = v
+		case op == 1:
+			hd := anyHandle()
+			v := rnd.IntN(1000)
+			h.Update(hd, v)
+			m[hd] = v
+		case op == 2:
+			hd := anyHandle()
+			if got := h.Remove(hd); got != m[hd] {
+				t.Errorf("got removed=%v, want %v", got, m[hd])
+			}
+			delete(m, hd)
+		default:
+			wantMin := slices.Min(slices.Collect(maps.Values(m)))
+			if got := h.Pop(); got != wantMin {
+				t.Errorf("got pop=%v, want %v", got, wantMin)
+			}
+			for hd, v := range m {
+				if v == wantMin && !h.Contains(hd) {
+					delete(m, hd)
+					break
+				}
+			}
+		}
+		checkIndexed(t, h)
+		if h.Len() != len(m) {
+			t.Fatalf("got len=%v, want %v", h.Len(), len(m))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package heap
+
+// Leftist is a leftist min-heap: a mergeable heap supporting Meld of two heaps
+// in O(log n), in addition to the usual Push and Pop in O(log n).
+type Leftist[T any] struct {
+	cmp    func(T, T) int
+	root   *leftistNode[T]
+	length int
+}
+
+// leftistNode is a node in the leftist heap. Its rank is the length of the
+// right spine of its subtree (the shortest path to a missing child). The
+// leftist property requires that for every node, rank(left) >= rank(right).
+type leftistNode[T any] struct {
+	value       T
+	rank        int
+	left, right *leftistNode[T]
+}
+
+// NewLeftist creates a new, empty Leftist heap with the given comparison
+// function.
+func NewLeftist[T any](cmp func(a, b T) int) *Leftist[T] {
+	return &Leftist[T]{cmp: cmp}
+}
+
+// Len returns the number of elements in the heap.
+func (h *Leftist[T]) Len() int {
+	return h.length
+}
+
+// Push inserts a new element into the heap.
+func (h *Leftist[T]) Push(v T) {
+	h.root = h.merge(h.root, &leftistNode[T]{value: v, rank: 1})
+	h.length++
+}
+
+// Peek returns the minimal element in the heap without removing it. It panics
+// if the heap is empty.
+func (h *Leftist[T]) Peek() T {
+	if h.root == nil {
+		panic("peek into empty heap")
+	}
+	return h.root.value
+}
+
+// Pop removes the minimal element from the heap and returns it. It panics
+// if the heap is empty; make sure to check Len() first.
+func (h *Leftist[T]) Pop() T {
+	if h.root == nil {
+		panic("popping from empty heap")
+	}
+	v := h.root.value
+	h.root = h.merge(h.root.left, h.root.right)
+	h.length--
+	return v
+}
+
+// Meld moves all the elements of other into h in O(log n), leaving other
+// empty. Both heaps should use the same comparison function.
+func (h *Leftist[T]) Meld(other *Leftist[T]) {
+	if h == other {
+		return
+	}
+	h.root = h.merge(h.root, other.root)
+	h.length += other.length
+	other.root = nil
+	other.length = 0
+}
+
+func rank[T any](n *leftistNode[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.rank
+}
+
+// merge merges the two heaps rooted at a and b, and returns the new root.
+// Merging proceeds down the right spines of both heaps, which have
+// logarithmic length.
+func (h *Leftist[T]) merge(a, b *leftistNode[T]) *leftistNode[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if h.cmp(b.value, a.value) < 0 {
+		a, b = b, a
+	}
+	a.right = h.merge(a.right, b)
+	if rank(a.right) > rank(a.left) {
+		a.left, a.right = a.right, a.left
+	}
+	a.rank = rank(a.right) + 1
+	return a
+}
@@ -0,0 +1,97 @@
+package heap
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func checkLeftist[T any](t *testing.T, h *Leftist[T]) {
+	t.Helper()
+	count := 0
+	var visit func(n *leftistNode[T])
+	visit = func(n *leftistNode[T]) {
+		if n == nil {
+			return
+		}
+		count++
+		if rank(n.left) < rank(n.right) {
+			t.Errorf("leftist property violated at %v", n.value)
+		}
+		if n.rank != rank(n.right)+1 {
+			t.Errorf("bad rank %d at %v", n.rank, n.value)
+		}
+		for _, c := range []*leftistNode[T]{n.left, n.right} {
+			if c != nil && h.cmp(c.value, n.value) < 0 {
+				t.Errorf("child %v smaller than parent %v", c.value, n.value)
+			}
+		}
+		visit(n.left)
+		visit(n.right)
+	}
+	visit(h.root)
+	if count != h.length {
+		t.Errorf("got %d nodes, want length=%d", count, h.length)
+	}
+}
+
+func TestLeftistBasic(t *testing.T) {
+	h := NewLeftist(cmp.Compare[int])
+	for _, v := range []int{5, 3, 8, 1, 9, 2, 7} {
+		h.Push(v)
+		checkLeftist(t, h)
+	}
+	if h.Peek() != 1 {
+		t.Errorf("got peek=%v, want 1", h.Peek())
+	}
+
+	var got []int
+	for h.Len() > 0 {
+		got = append(got, h.Pop())
+		checkLeftist(t, h)
+	}
+	want := []int{1, 2, 3, 5, 7, 8, 9}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLeftistMeld(t *testing.T) {
+	rnd := makeLoggedRand(t)
+
+	// Simulate per-worker heaps that are melded into one at the end.
+	var heaps []*Leftist[int]
+	var want []int
+	for range 8 {
+		h := NewLeftist(cmp.Compare[int])
+		for range 100 + rnd.IntN(100) {
+			v := rnd.IntN(10000)
+			h.Push(v)
+			want = append(want, v)
+		}
+		heaps = append(heaps, h)
+	}
+
+	result := NewLeftist(cmp.Compare[int])
+	for _, h := range heaps {
+		result.Meld(h)
+		checkLeftist(t, result)
+		if h.Len() != 0 {
+			t.Errorf("got len=%d for melded heap, want 0", h.Len())
+		}
+	}
+
+	// Melding with self or with an empty heap is a no-op.
+	result.Meld(result)
+	result.Meld(NewLeftist(cmp.Compare[int]))
+	checkLeftist(t, result)
+
+	slices.Sort(want)
+	var got []int
+	for result.Len() > 0 {
+		got = append(got, result.Pop())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package heap
+
+import "math/bits"
+
+// MinMax is a min-max heap: a double-ended priority queue supporting access
+// to both the minimal and maximal elements in O(1), and removal of either in
+// O(log n).
+type MinMax[T any] struct {
+	cmp func(T, T) int
+
+	// items holds the heap's items in binary heap layout (children of i are
+	// at 2i+1 and 2i+2). Levels alternate between "min" and "max" levels,
+	// starting with the root on a min level. An element on a min level is
+	// smaller than or equal to all its descendants; an element on a max
+	// level is larger than or equal to all its descendants.
+	items []T
+}
+
+// NewMinMax creates a new, empty MinMax heap with the given comparison
+// function.
+func NewMinMax[T any](cmp func(a, b T) int) *MinMax[T] {
+	return &MinMax[T]{cmp: cmp}
+}
+
+// Len returns the number of elements in the heap.
+func (h *MinMax[T]) Len() int {
+	return len(h.items)
+}
+
+// Push inserts a new element into the heap.
+func (h *MinMax[T]) Push(v T) {
+	h.items = append(h.items, v)
+	h.pushUp(len(h.items) - 1)
+}
+
+// PeekMin returns the minimal element in the heap without removing it. It
+// panics if the heap is empty.
+func (h *MinMax[T]) PeekMin() T {
+	if len(h.items) == 0 {
+		panic("peek into empty heap")
+	}
+	return h.items[0]
+}
+
+// PeekMax returns the maximal element in the heap without removing it. It
+// panics if the heap is empty.
+func (h *MinMax[T]) PeekMax() T {
+	if len(h.items) == 0 {
+		panic("peek into empty heap")
+	}
+	return h.items[h.maxIndex()]
+}
+
+// PopMin removes the minimal element from the heap and returns it. It panics
+// if the heap is empty; make sure to check Len() first.
+func (h *MinMax[T]) PopMin() T {
+	if len(h.items) == 0 {
+		panic("popping from empty heap")
+	}
+	return h.removeAt(0)
+}
+
+// PopMax removes the maximal element from the heap and returns it. It panics
+// if the heap is empty; make sure to check Len() first.
+func (h *MinMax[T]) PopMax() T {
+	if len(h.items) == 0 {
+		panic("popping from empty heap")
+	}
+	return h.removeAt(h.maxIndex())
+}
+
+// maxIndex returns the index of the maximal element in a non-empty heap: it's
+// either the root (if it's the only element) or one of the root's children.
+func (h *MinMax[T]) maxIndex() int {
+	switch len(h.items) {
+	case 1:
+		return 0
+	case 2:
+		return 1
+	default:
+		if h.cmp(h.items[2], h.items[1]) > 0 {
+			return 2
+		}
+		return 1
+	}
+}
+
+func (h *MinMax[T]) removeAt(i int) T {
+	v := h.items[i]
+	last := len(h.items) - 1
+	h.items[i] = h.items[last]
+	h.items[last] = *new(T)
+	h.items = h.items[:last]
+	if i < last {
+		h.pushDown(i)
+	}
+	return v
+}
+
+func isMinLevel(i int) bool {
+	return (bits.Len(uint(i+1))-1)%2 == 0
+}
+
+// better reports whether a should be closer to the root than b on a level of
+// the given kind.
+func (h *MinMax[T]) better(minLevel bool, a, b T) bool {
+	if minLevel {
+		return h.cmp(a, b) < 0
+	}
+	return h.cmp(a, b) > 0
+}
+
+func (h *MinMax[T]) pushUp(i int) {
+	if i == 0 {
+		return
+	}
+	minLevel := isMinLevel(i)
+	p := (i - 1) / 2
+
+	// The parent is on a level of the opposite kind. If i belongs on the
+	// parent's level, swap them and continue up the parent's kind of levels;
+	// otherwise, continue up i's kind of levels.
+	if h.better(!minLevel, h.items[i], h.items[p]) {
+		h.items[i], h.items[p] = h.items[p], h.items[i]
+		h.pushUpGrand(p, !minLevel)
+	} else {
+		h.pushUpGrand(i, minLevel)
+	}
+}
+
+// pushUpGrand moves the element at i up through its grandparents, which are
+// all on levels of the same kind (min or max) as i.
+func (h *MinMax[T]) pushUpGrand(i int, minLevel bool) {
+	for i > 2 {
+		g := ((i-1)/2 - 1) / 2
+		if !h.better(minLevel, h.items[i], h.items[g]) {
+			return
+		}
+		h.items[i], h.items[g] = h.items[g], h.items[i]
+		i = g
+	}
+}
+
+func (h *MinMax[T]) pushDown(i int) {
+	minLevel := isMinLevel(i)
+	n := len(h.items)
+	for {
+		// Find m: the best (smallest on min levels, largest on max levels)
+		// among i's children and grandchildren.
+		first := 2*i + 1
+		if first >= n {
+			return
+		}
+		m := first
+		candidates := [...]int{first + 1, 2*first + 1, 2*first + 2, 2*first + 3, 2*first + 4}
+		for _, c := range candidates {
+			if c < n && h.better(minLevel, h.items[c], h.items[m]) {
+				m = c
+			}
+		}
+
+		if !h.better(minLevel, h.items[m], h.items[i]) {
+			return
+		}
+		h.items[m], h.items[i] = h.items[i], h.items[m]
+		if m <= first+1 {
+			// m is a child of i, and so is a leaf of i's subtree on the other
+			// kind of level. Nothing more to do.
+			return
+		}
+
+		// m is a grandchild; the element moved down to it may violate the
+		// order with m's parent.
+		p := (m - 1) / 2
+		if h.better(!minLevel, h.items[m], h.items[p]) {
+			h.items[m], h.items[p] = h.items[p], h.items[m]
+		}
+		i = m
+	}
+}
@@ -0,0 +1,103 @@
+package heap
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func checkMinMax[T any](t *testing.T, h *MinMax[T]) {
+	t.Helper()
+	// Every element must compare properly with all its ancestors: for each
+	// ancestor on a min level it must be >=, and for each ancestor on a max
+	// level it must be <=.
+	for i := 1; i < len(h.items); i++ {
+		for a := (i - 1) / 2; ; a = (a - 1) / 2 {
+			c := h.cmp(h.items[i], h.items[a])
+			if isMinLevel(a) && c < 0 {
+				t.Errorf("item %d smaller than min-level ancestor %d", i, a)
+			}
+			if !isMinLevel(a) && c > 0 {
+				t.Errorf("item %d larger than max-level ancestor %d", i, a)
+			}
+			if a == 0 {
+				break
+			}
+		}
+	}
+}
+
+func TestMinMaxBasic(t *testing.T) {
+	h := NewMinMax(cmp.Compare[int])
+	for _, v := range []int{5, 3, 8, 1, 9, 2, 7, 4, 6} {
+		h.Push(v)
+		checkMinMax(t, h)
+	}
+	if h.PeekMin() != 1 || h.PeekMax() != 9 {
+		t.Errorf("got min=%v, max=%v; want 1, 9", h.PeekMin(), h.PeekMax())
+	}
+
+	if got := h.PopMax(); got != 9 {
+		t.Errorf("got popmax=%v, want 9", got)
+	}
+	if got := h.PopMin(); got != 1 {
+		t.Errorf("got popmin=%v, want 1", got)
+	}
+	if got := h.PopMax(); got != 8 {
+		t.Errorf("got popmax=%v, want 8", got)
+	}
+	checkMinMax(t, h)
+	if h.Len() != 6 {
+		t.Errorf("got len=%d, want 6", h.Len())
+	}
+}
+
+func TestMinMaxSmall(t *testing.T) {
+	h := NewMinMax(cmp.Compare[int])
+	h.Push(10)
+	if h.PeekMin() != 10 || h.PeekMax() != 10 {
+		t.Errorf("got min=%v, max=%v; want 10, 10", h.PeekMin(), h.PeekMax())
+	}
+	h.Push(20)
+	if h.PeekMin() != 10 || h.PeekMax() != 20 {
+		t.Errorf("got min=%v, max=%v; want 10, 20", h.PeekMin(), h.PeekMax())
+	}
+	if got := h.PopMax(); got != 20 {
+		t.Errorf("got popmax=%v, want 20", got)
+	}
+	if got := h.PopMax(); got != 10 {
+		t.Errorf("got popmax=%v, want 10", got)
+	}
+}
+
+func TestMinMaxRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	h := NewMinMax(cmp.Compare[int])
+
+	// Mirror the heap in a sorted slice, and randomly push/pop from both
+	// ends.
+	var sorted []int
+	for range 3000 {
+		switch op := rnd.IntN(3); {
+		case op == 0 || len(sorted) == 0:
+			v := rnd.IntN(500)
+			h.Push(v)
+			i, _ := slices.BinarySearch(sorted, v)
+			sorted = slices.Insert(sorted, i, v)
+		case op == 1:
+			if got := h.PopMin(); got != sorted[0] {
+				t.Fatalf("got popmin=%v, want %v", got, sorted[0])
+			}
+			sorted = sorted[1:]
+		default:
+			if got := h.PopMax(); got != sorted[len(sorted)-1] {
+				t.Fatalf("got popmax=%v, want %v", got, sorted[len(sorted)-1])
+			}
+			sorted = sorted[:len(sorted)-1]
+		}
+		checkMinMax(t, h)
+		if h.Len() != len(sorted) {
+			t.Fatalf("got len=%d, want %d", h.Len(), len(sorted))
+		}
+	}
+}
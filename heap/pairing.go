@@ -0,0 +1,169 @@
+package heap
+
+// Pairing is a pairing heap: a min-heap with O(1) Push, Meld and (amortized)
+// DecreaseKey, and O(log n) amortized Pop. It's a good fit for workloads
+// that perform many DecreaseKey operations.
+type Pairing[T any] struct {
+	cmp    func(T, T) int
+	root   *PairingNode[T]
+	length int
+}
+
+// PairingNode is a node in a Pairing heap. It's returned from Push and can
+// be used as a handle for DecreaseKey.
+type PairingNode[T any] struct {
+	value T
+
+	// child points to the leftmost child of the node. next points to the
+	// node's right sibling. prev points to the left sibling, or to the
+	// parent if this node is the leftmost child. For the root, all of prev
+	// and next are nil.
+	child, next, prev *PairingNode[T]
+}
+
+// Value returns the value stored in the node.
+func (n *PairingNode[T]) Value() T {
+	return n.value
+}
+
+// NewPairing creates a new, empty Pairing heap with the given comparison
+// function.
+func NewPairing[T any](cmp func(a, b T) int) *Pairing[T] {
+	return &Pairing[T]{cmp: cmp}
+}
+
+// Len returns the number of elements in the heap.
+func (h *Pairing[T]) Len() int {
+	return h.length
+}
+
+// Push inserts a new element into the heap and returns its node.
+func (h *Pairing[T]) Push(v T) *PairingNode[T] {
+	n := &PairingNode[T]{value: v}
+	h.root = h.link(h.root, n)
+	h.length++
+	return n
+}
+
+// Peek returns the minimal element in the heap without removing it. It panics
+// if the heap is empty.
+func (h *Pairing[T]) Peek() T {
+	if h.root == nil {
+		panic("peek into empty heap")
+	}
+	return h.root.value
+}
+
+// Pop removes the minimal element from the heap and returns it. It panics
+// if the heap is empty; make sure to check Len() first.
+func (h *Pairing[T]) Pop() T {
+	if h.root == nil {
+		panic("popping from empty heap")
+	}
+	r := h.root
+	h.root = h.mergePairs(r.child)
+	if h.root != nil {
+		h.root.prev = nil
+	}
+	r.child = nil
+	h.length--
+	return r.value
+}
+
+// Meld moves all the elements of other into h, leaving other empty. Both
+// heaps should use the same comparison function. Nodes of other remain
+// valid handles, but now belong to h. Melding h with itself is a no-op.
+func (h *Pairing[T]) Meld(other *Pairing[T]) {
+	if h == other {
+		return
+	}
+	h.root = h.link(h.root, other.root)
+	h.length += other.length
+	other.root = nil
+	other.length = 0
+}
+
+// DecreaseKey replaces the value of node n with v. v must not be larger
+// than n's current value; otherwise, DecreaseKey panics. n must be in h: it
+// panics if n was popped, but using a node of another heap, including one
+// melded into another heap, corrupts both heaps.
+func (h *Pairing[T]) DecreaseKey(n *PairingNode[T], v T) {
+	if n != h.root && n.prev == nil {
+		// Only the root has no prev; n was popped.
+		panic("DecreaseKey of a node that isn't in the heap")
+	}
+	if h.cmp(v, n.value) > 0 {
+		panic("DecreaseKey with a larger value")
+	}
+	n.value = v
+	if n == h.root {
+		return
+	}
+
+	// Cut n's subtree from its parent and link it with the root.
+	h.cut(n)
+	h.root = h.link(h.root, n)
+}
+
+// cut detaches the subtree rooted at n from its parent/siblings.
+func (h *Pairing[T]) cut(n *PairingNode[T]) {
+	if n.prev.child == n {
+		// n is the leftmost child of its parent.
+		n.prev.child = n.next
+	} else {
+		n.prev.next = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	}
+	n.next = nil
+	n.prev = nil
+}
+
+// link combines two heap-ordered trees a and b (either may be nil), making
+// the root with the larger value the leftmost child of the other. It returns
+// the combined tree.
+func (h *Pairing[T]) link(a, b *PairingNode[T]) *PairingNode[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if h.cmp(b.value, a.value) < 0 {
+		a, b = b, a
+	}
+	b.prev = a
+	b.next = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	a.child = b
+	return a
+}
+
+// mergePairs performs the standard two-pass merge of a list of sibling
+// trees starting at first: link siblings in pairs left to right, then link
+// the resulting trees right to left.
+func (h *Pairing[T]) mergePairs(first *PairingNode[T]) *PairingNode[T] {
+	var pairs []*PairingNode[T]
+	for n := first; n != nil; {
+		a := n
+		b := a.next
+		if b == nil {
+			a.next, a.prev = nil, nil
+			pairs = append(pairs, a)
+			break
+		}
+		n = b.next
+		a.next, a.prev = nil, nil
+		b.next, b.prev = nil, nil
+		pairs = append(pairs, h.link(a, b))
+	}
+
+	var result *PairingNode[T]
+	for i := len(pairs) - 1; i >= 0; i-- {
+		result = h.link(pairs[i], result)
+	}
+	return result
+}
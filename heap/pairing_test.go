@@ -0,0 +1,263 @@
+package heap
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// checkPairing verifies the heap order and link invariants of h.
+func checkPairing[T any](t *testing.T, h *Pairing[T]) {
+	t.Helper()
+	if h.root == nil {
+		if h.length != 0 {
+			t.Errorf("empty heap with length=%d", h.length)
+		}
+		return
+	}
+	if h.root.prev != nil || h.root.next != nil {
+		t.Errorf("root has siblings")
+	}
+
+	count := 0
+	var visit func(n *PairingNode[T])
+	visit = func(n *PairingNode[T]) {
+		count++
+		prev := n
+		for c := n.child; c != nil; c = c.next {
+			if c.prev != prev {
+				t.Errorf("bad prev link for %v", c.value)
+			}
+			if h.cmp(c.value, n.value) < 0 {
+				t.Errorf("child %v smaller than parent %v", c.value, n.value)
+			}
+			visit(c)
+			prev = c
+		}
+	}
+	visit(h.root)
+	if count != h.length {
+		t.Errorf("got %d nodes, want length=%d", count, h.length)
+	}
+}
+
+func TestPairingBasic(t *testing.T) {
+	h := NewPairing(cmp.Compare[int])
+	for _, v := range []int{5, 3, 8, 1, 9, 2, 7} {
+		h.Push(v)
+		checkPairing(t, h)
+	}
+	if h.Peek() != 1 {
+		t.Errorf("got peek=%v, want 1", h.Peek())
+	}
+
+	var got []int
+	for h.Len() > 0 {
+		got = append(got, h.Pop())
+		checkPairing(t, h)
+	}
+	want := []int{1, 2, 3, 5, 7, 8, 9}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPairingDecreaseKey(t *testing.T) {
+	h := NewPairing(cmp.Compare[int])
+	var nodes []*PairingNode[int]
+	for i := range 20 {
+		nodes = append(nodes, h.Push(100+i))
+	}
+	h.Pop()
+	checkPairing(t, h)
+
+	h.DecreaseKey(nodes[15], 5)
+	checkPairing(t, h)
+	h.DecreaseKey(nodes[7], 50)
+	checkPairing(t, h)
+	h.DecreaseKey(nodes[15], 1)
+	checkPairing(t, h)
+
+	want := []int{1, 50, 101, 102}
+	for _, w := range want {
+		if got := h.Pop(); got != w {
+			t.Errorf("got pop=%v, want %v", got, w)
+		}
+		checkPairing(t, h)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on increasing key")
+		}
+	}()
+	h.DecreaseKey(nodes[19], 200)
+}
+
+func TestPairingDecreaseKeyPopped(t *testing.T) {
+	h := NewPairing(cmp.Compare[int])
+	var nodes []*PairingNode[int]
+	for i := range 5 {
+		nodes = append(nodes, h.Push(i))
+	}
+	h.Pop()
+	h.Pop()
+	for _, n := range nodes[:2] {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("DecreaseKey(%d) of a popped node: no panic", n.Value())
+				}
+			}()
+			h.DecreaseKey(n, -1)
+		}()
+	}
+	checkPairing(t, h)
+	if h.Len() != 3 || h.Peek() != 2 {
+		t.Errorf("got Len=%d, Peek=%d, want 3, 2", h.Len(), h.Peek())
+	}
+}
+
+func TestPairingMeld(t *testing.T) {
+	h1 := NewPairing(cmp.Compare[int])
+	h2 := NewPairing(cmp.Compare[int])
+	for i := range 10 {
+		h1.Push(i * 2)
+		h2.Push(i*2 + 1)
+	}
+	n := h2.Push(100)
+
+	h1.Meld(h2)
+	checkPairing(t, h1)
+	checkPairing(t, h2)
+	if h2.Len() != 0 || h1.Len() != 21 {
+		t.Errorf("got lens %d, %d, want 21, 0", h1.Len(), h2.Len())
+	}
+
+	// Melding with self is a no-op.
+	h1.Meld(h1)
+	checkPairing(t, h1)
+	if h1.Len() != 21 {
+		t.Errorf("got len %d after self-meld, want 21", h1.Len())
+	}
+
+	// Nodes of h2 are now usable with h1.
+	h1.DecreaseKey(n, -1)
+	want := []int{-1}
+	for i := range 20 {
+		want = append(want, i)
+	}
+	var got []int
+	for h1.Len() > 0 {
+		got = append(got, h1.Pop())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPairingRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	h := NewPairing(cmp.Compare[int])
+
+	var nodes []*PairingNode[int]
+	var pushed []int
+	for range 500 {
+		v := rnd.IntN(10000)
+		nodes = append(nodes, h.Push(v))
+		pushed = append(pushed, v)
+	}
+	for range 200 {
+		i := rnd.IntN(len(nodes))
+		pushed[i] -= rnd.IntN(500)
+		h.DecreaseKey(nodes[i], pushed[i])
+	}
+	checkPairing(t, h)
+
+	slices.Sort(pushed)
+	for _, w := range pushed {
+		if got := h.Pop(); got != w {
+			t.Fatalf("got pop=%v, want %v", got, w)
+		}
+	}
+}
+
+// Benchmarks comparing the Pairing heap with the binary Indexed heap on a
+// Dijkstra-like workload: many pushes, frequent decrease-key operations and
+// pops of all elements.
+
+const benchSize = 10000
+
+func benchValues() ([]int, []int) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	vals := make([]int, benchSize)
+	for i := range vals {
+		vals[i] = rnd.IntN(1 << 30)
+	}
+	decs := make([]int, 4*benchSize)
+	for i := range decs {
+		decs[i] = rnd.IntN(benchSize)
+	}
+	return vals, decs
+}
+
+func BenchmarkPairingDecreaseKey(b *testing.B) {
+	vals, decs := benchValues()
+	for range b.N {
+		h := NewPairing(cmp.Compare[int])
+		nodes := make([]*PairingNode[int], len(vals))
+		for i, v := range vals {
+			nodes[i] = h.Push(v)
+		}
+		for _, d := range decs {
+			h.DecreaseKey(nodes[d], nodes[d].Value()-1)
+		}
+		for h.Len() > 0 {
+			h.Pop()
+		}
+	}
+}
+
+func BenchmarkIndexedDecreaseKey(b *testing.B) {
+	vals, decs := benchValues()
+	for range b.N {
+		h := NewIndexed(cmp.Compare[int])
+		handles := make([]*Handle[int], len(vals))
+		for i, v := range vals {
+			handles[i] = h.Push(v)
+		}
+		for _, d := range decs {
+			h.Update(handles[d], handles[d].Value()-1)
+		}
+		for h.Len() > 0 {
+			h.Pop()
+		}
+	}
+}
+
+func BenchmarkPairingPushPop(b *testing.B) {
+	vals, _ := benchValues()
+	for range b.N {
+		h := NewPairing(cmp.Compare[int])
+		for _, v := range vals {
+			h.Push(v)
+		}
+		for h.Len() > 0 {
+			h.Pop()
+		}
+	}
+}
+
+func BenchmarkIndexedPushPop(b *testing.B) {
+	vals, _ := benchValues()
+	for range b.N {
+		h := NewIndexed(cmp.Compare[int])
+		for _, v := range vals {
+			h.Push(v)
+		}
+		for h.Len() > 0 {
+			h.Pop()
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package hyperloglog implements the HyperLogLog cardinality estimator.
+package hyperloglog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+const (
+	// MinPrecision and MaxPrecision bound the precision of a Sketch.
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+// Sketch is a HyperLogLog sketch, estimating the number of distinct
+// elements added to it using a small, fixed amount of memory: with
+// precision p, it has m=2^p registers of one byte each, and the standard
+// error of its estimates is about 1.04/sqrt(m) (1.6% for p=12).
+//
+// Each element's 64-bit hash selects a register by its top p bits, and the
+// register keeps the maximal position of the leftmost 1 bit in the rest of
+// the hashes mapped to it. Count uses the estimator from Otmar Ertl's "New
+// cardinality estimation algorithms for HyperLogLog sketches" (2017),
+// which is accurate over the whole range of cardinalities without the
+// empirical bias correction tables of HyperLogLog++.
+//
+// Sketches with the same precision can be merged. Elements are byte slices
+// or strings, hashed with the same function as [bloom.Hash]; other types
+// can be added with [Sketch.AddHash].
+//
+// Create sketches with [New].
+//
+// [bloom.Hash]: https://pkg.go.dev/github.com/eliben/gogl/bloom#Hash
+type Sketch struct {
+	p         uint8
+	registers []uint8
+}
+
+// New creates a new, empty Sketch with the given precision, which must be
+// between MinPrecision and MaxPrecision.
+func New(precision int) *Sketch {
+	if precision < MinPrecision || precision > MaxPrecision {
+		panic(fmt.Sprintf("hyperloglog: invalid precision %d", precision))
+	}
+	return &Sketch{p: uint8(precision), registers: make([]uint8, 1<<precision)}
+}
+
+// Precision returns the precision of the sketch.
+func (s *Sketch) Precision() int {
+	return int(s.p)
+}
+
+// Add adds data to the sketch.
+func (s *Sketch) Add(data []byte) {
+	s.AddHash(hash.Bytes(data))
+}
+
+// AddString adds str to the sketch.
+func (s *Sketch) AddString(str string) {
+	s.AddHash(hash.String(str))
+}
+
+// AddHash adds an element with the 64-bit hash h to the sketch. It can be
+// used with any hash function that spreads its output over all 64 bits,
+// e.g. the Hash of a [hashmap.Hasher] to count comparable values; sketches
+// to be merged must use the same function.
+//
+// [hashmap.Hasher]: https://pkg.go.dev/github.com/eliben/gogl/hashmap#Hasher
+func (s *Sketch) AddHash(h uint64) {
+	i := h >> (64 - s.p)
+	// Setting the bit below the remaining 64-p bits caps the rank at
+	// 64-p+1 when they're all zero.
+	rank := uint8(bits.LeadingZeros64(h<<s.p|1<<(s.p-1))) + 1
+	s.registers[i] = max(s.registers[i], rank)
+}
+
+// Count returns the estimated number of distinct elements added to the
+// sketch.
+func (s *Sketch) Count() uint64 {
+	q := 64 - int(s.p)
+	m := float64(len(s.registers))
+
+	// Histogram of the register values, which are in [0, q+1].
+	var c [66]int
+	for _, r := range s.registers {
+		c[r]++
+	}
+	z := m * tau(1-float64(c[q+1])/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + float64(c[k]))
+	}
+	z += m * sigma(float64(c[0])/m)
+	const alphaInf = 1 / (2 * math.Ln2)
+	return uint64(math.Round(alphaInf * m * m / z))
+}
+
+// sigma and tau are the functions correcting the raw estimate for empty and
+// saturated registers, respectively, computed by their series expansions.
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// ErrIncompatible is returned when merging sketches with different
+// precisions.
+var ErrIncompatible = errors.New("hyperloglog: sketches have different precisions")
+
+// Merge adds the elements of other to s, so that s estimates the number of
+// distinct elements added to either. The sketches must have the same
+// precision; otherwise Merge returns ErrIncompatible and leaves s
+// unchanged.
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.p != other.p {
+		return ErrIncompatible
+	}
+	for i, r := range other.registers {
+		s.registers[i] = max(s.registers[i], r)
+	}
+	return nil
+}
+
+// Clear removes all the elements from the sketch.
+func (s *Sketch) Clear() {
+	clear(s.registers)
+}
+
+// The binary encoding of a sketch is a header followed by its registers in
+// one of two formats, whichever is smaller:
+//
+//	header:  "GHLL" | version u8 | precision u8 | format u8
+//	dense:   registers packed 6 bits each, little-endian
+//	sparse:  count uvarint | count * (index delta uvarint | value u8)
+//
+// The sparse format lists just the non-zero registers, each with the
+// difference of its index from the previous one's.
+
+const (
+	encodingMagic      = "GHLL"
+	encodingVersion    = 1
+	encodingHeaderSize = 7
+	formatDense        = 0
+	formatSparse       = 1
+)
+
+// ErrInvalidData is returned when decoding data that isn't a valid
+// encoding of a sketch.
+var ErrInvalidData = errors.New("hyperloglog: invalid sketch data")
+
+// MarshalBinary encodes the sketch into a compact binary form; it
+// implements [encoding.BinaryMarshaler].
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	header := []byte(encodingMagic + "\x00\x00\x00")
+	header[4], header[5] = encodingVersion, s.p
+
+	nonzero := 0
+	for _, r := range s.registers {
+		if r != 0 {
+			nonzero++
+		}
+	}
+	denseSize := (6*len(s.registers) + 7) / 8
+	// A sparse entry takes at least 2 bytes.
+	if 2*nonzero+binary.MaxVarintLen32 < denseSize {
+		header[6] = formatSparse
+		buf := binary.AppendUvarint(header, uint64(nonzero))
+		prev := 0
+		for i, r := range s.registers {
+			if r != 0 {
+				buf = binary.AppendUvarint(buf, uint64(i-prev))
+				buf = append(buf, r)
+				prev = i
+			}
+		}
+		if len(buf)-len(header) < denseSize {
+			return buf, nil
+		}
+	}
+
+	header[6] = formatDense
+	buf := append(header[:encodingHeaderSize:encodingHeaderSize], make([]byte, denseSize)...)
+	dense := buf[encodingHeaderSize:]
+	for i, r := range s.registers {
+		bit := 6 * i
+		v := uint16(r) << (bit % 8)
+		dense[bit/8] |= byte(v)
+		if bit/8+1 < len(dense) {
+			dense[bit/8+1] |= byte(v >> 8)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary replaces the contents of s by decoding data produced by
+// [Sketch.MarshalBinary]; it implements [encoding.BinaryUnmarshaler]. It
+// returns ErrInvalidData if data isn't a valid encoding.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	if len(data) < encodingHeaderSize || string(data[:4]) != encodingMagic || data[4] != encodingVersion {
+		return ErrInvalidData
+	}
+	p, format := data[5], data[6]
+	if p < MinPrecision || p > MaxPrecision {
+		return ErrInvalidData
+	}
+	maxRank := 64 - p + 1
+	registers := make([]uint8, 1<<p)
+	data = data[encodingHeaderSize:]
+
+	switch format {
+	case formatDense:
+		if len(data) != (6*len(registers)+7)/8 {
+			return ErrInvalidData
+		}
+		for i := range registers {
+			bit := 6 * i
+			v := uint16(data[bit/8])
+			if bit/8+1 < len(data) {
+				v |= uint16(data[bit/8+1]) << 8
+			}
+			registers[i] = uint8(v>>(bit%8)) & 0x3f
+			if registers[i] > maxRank {
+				return ErrInvalidData
+			}
+		}
+	case formatSparse:
+		count, n := binary.Uvarint(data)
+		if n <= 0 || count > uint64(len(registers)) {
+			return ErrInvalidData
+		}
+		data = data[n:]
+		i := uint64(0)
+		for k := range count {
+			delta, n := binary.Uvarint(data)
+			// Indices must be strictly increasing.
+			if n <= 0 || (k > 0 && delta == 0) || n >= len(data) {
+				return ErrInvalidData
+			}
+			i += delta
+			r := data[n]
+			if i >= uint64(len(registers)) || r == 0 || r > maxRank {
+				return ErrInvalidData
+			}
+			registers[i] = r
+			data = data[n+1:]
+		}
+		if len(data) != 0 {
+			return ErrInvalidData
+		}
+	default:
+		return ErrInvalidData
+	}
+
+	s.p = p
+	s.registers = registers
+	return nil
+}
@@ -0,0 +1,153 @@
+package hyperloglog
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/eliben/gogl/hashmap"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Sketch)(nil)
+	_ encoding.BinaryUnmarshaler = (*Sketch)(nil)
+)
+
+// checkEstimate fails the test if est is off from n by more than 4
+// standard errors of a sketch with precision p.
+func checkEstimate(t *testing.T, p int, n int, est uint64) {
+	t.Helper()
+	stderr := 1.04 / math.Sqrt(float64(uint64(1)<<p))
+	if n == 0 {
+		if est != 0 {
+			t.Errorf("p=%d: estimate %d for empty sketch", p, est)
+		}
+		return
+	}
+	if relerr := math.Abs(float64(est)-float64(n)) / float64(n); relerr > 4*stderr {
+		t.Errorf("p=%d: estimate %d for %d elements, relative error %.4f", p, est, n, relerr)
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	for _, p := range []int{MinPrecision, 10, 14} {
+		s := New(p)
+		n := 0
+		for _, target := range []int{0, 1, 10, 100, 1000, 10000, 100000, 1000000} {
+			for ; n < target; n++ {
+				s.AddString(fmt.Sprint(n))
+			}
+			checkEstimate(t, p, n, s.Count())
+		}
+		// Adding the same elements again changes nothing.
+		before := s.Count()
+		for i := range 1000 {
+			s.Add([]byte(fmt.Sprint(i)))
+		}
+		if s.Count() != before {
+			t.Errorf("p=%d: duplicates changed the estimate from %d to %d", p, before, s.Count())
+		}
+	}
+}
+
+func TestSmallCounts(t *testing.T) {
+	// Small cardinalities are estimated nearly exactly.
+	s := New(14)
+	for n := 1; n <= 50; n++ {
+		s.AddString(fmt.Sprint(n))
+		if est := s.Count(); est != uint64(n) {
+			t.Errorf("estimate %d for %d elements", est, n)
+		}
+	}
+}
+
+func TestAddHash(t *testing.T) {
+	h := hashmap.IntHasher[int]()
+	s := New(12)
+	for i := range 50000 {
+		s.AddHash(h.Hash(i % 20000))
+	}
+	checkEstimate(t, 12, 20000, s.Count())
+}
+
+func TestMerge(t *testing.T) {
+	a, b := New(12), New(12)
+	for i := range 30000 {
+		a.AddString(fmt.Sprint(i))
+		b.AddString(fmt.Sprint(i + 20000))
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	checkEstimate(t, 12, 50000, a.Count())
+
+	if err := a.Merge(New(13)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Merge of incompatible sketches: %v", err)
+	}
+	a.Clear()
+	if a.Count() != 0 {
+		t.Errorf("Count=%d after Clear", a.Count())
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for _, tt := range []struct {
+		n      int
+		format byte
+	}{{0, formatSparse}, {100, formatSparse}, {100000, formatDense}} {
+		s := New(12)
+		for i := range tt.n {
+			s.AddString(fmt.Sprint(i))
+		}
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data[6] != tt.format {
+			t.Errorf("n=%d: format %d, want %d", tt.n, data[6], tt.format)
+		}
+		if dense := encodingHeaderSize + 6*4096/8; len(data) > dense {
+			t.Errorf("n=%d: encoding takes %d bytes", tt.n, len(data))
+		}
+		var g Sketch
+		if err := g.UnmarshalBinary(data); err != nil {
+			t.Fatalf("n=%d: %v", tt.n, err)
+		}
+		if g.Precision() != 12 || g.Count() != s.Count() {
+			t.Errorf("n=%d: decoded precision %d, count %d; want count %d", tt.n, g.Precision(), g.Count(), s.Count())
+		}
+		for i, r := range s.registers {
+			if g.registers[i] != r {
+				t.Fatalf("n=%d: register %d decoded as %d, want %d", tt.n, i, g.registers[i], r)
+			}
+		}
+
+		for _, bad := range [][]byte{nil, data[:6], data[:len(data)-1], append([]byte("X"), data[1:]...)} {
+			if err := g.UnmarshalBinary(bad); !errors.Is(err, ErrInvalidData) {
+				t.Errorf("n=%d: UnmarshalBinary(%d bytes): %v", tt.n, len(bad), err)
+			}
+		}
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	s := New(14)
+	key := []byte("some moderately long key")
+	for i := range b.N {
+		key[0], key[1] = byte(i), byte(i>>8)
+		s.Add(key)
+	}
+}
+
+func BenchmarkCount(b *testing.B) {
+	s := New(14)
+	for i := range 100000 {
+		s.AddString(fmt.Sprint(i))
+	}
+	b.ResetTimer()
+	for range b.N {
+		s.Count()
+	}
+}
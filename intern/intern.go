@@ -0,0 +1,168 @@
+// Package intern implements interning: deduplicating equal values into a
+// single canonical instance, so that many copies of the same string (or
+// other value holding references) share memory.
+package intern
+
+// Pool is an interning pool for values of type T. Values interned with
+// [Pool.Intern] are kept until the pool is discarded; values interned
+// through a [Scope] are released together when the scope is released.
+// Create pools with [New], [NewWithSize] or [NewStrings].
+type Pool[T comparable] struct {
+	entries map[T]*entry[T]
+	size    func(T) int
+
+	hits, misses int
+	bytes        int
+}
+
+type entry[T comparable] struct {
+	value T
+
+	// refs is the number of live scopes holding the value; permanent is
+	// set if the value was interned directly in the pool.
+	refs      int
+	permanent bool
+}
+
+// Stats holds statistics about the usage of a pool.
+type Stats struct {
+	// Unique is the number of distinct values currently in the pool.
+	Unique int
+
+	// Hits is the number of interning requests for values that were already
+	// in the pool, and Misses is the number of requests that added a value.
+	Hits, Misses int
+
+	// Bytes is the total size of the values currently in the pool, as
+	// reported by the pool's size function; it's 0 for pools without one.
+	Bytes int
+}
+
+// New creates a new, empty pool.
+func New[T comparable]() *Pool[T] {
+	return NewWithSize[T](nil)
+}
+
+// NewWithSize creates a new, empty pool using size to compute the size of
+// values for [Stats].Bytes.
+func NewWithSize[T comparable](size func(T) int) *Pool[T] {
+	return &Pool[T]{entries: make(map[T]*entry[T]), size: size}
+}
+
+// NewStrings creates a new, empty pool of strings whose statistics report
+// the total length of the interned strings.
+func NewStrings() *Pool[string] {
+	return NewWithSize(func(s string) int { return len(s) })
+}
+
+// Intern returns the canonical instance of v, adding v to the pool if it's
+// not already there. Values interned this way remain in the pool
+// permanently.
+func (p *Pool[T]) Intern(v T) T {
+	e := p.lookup(v)
+	e.permanent = true
+	return e.value
+}
+
+// InternBytes returns the canonical string equal to b, adding it to the pool
+// if it's not already there. It doesn't allocate if the string is already in
+// the pool, which makes it useful for interning tokens out of a byte buffer.
+func InternBytes(p *Pool[string], b []byte) string {
+	// The compiler optimizes map lookups by string(b) to not allocate.
+	if e, ok := p.entries[string(b)]; ok {
+		p.hits++
+		e.permanent = true
+		return e.value
+	}
+	return p.Intern(string(b))
+}
+
+// Contains reports whether a value equal to v is in the pool.
+func (p *Pool[T]) Contains(v T) bool {
+	_, ok := p.entries[v]
+	return ok
+}
+
+// Len returns the number of distinct values in the pool.
+func (p *Pool[T]) Len() int {
+	return len(p.entries)
+}
+
+// Stats returns statistics about the pool's usage.
+func (p *Pool[T]) Stats() Stats {
+	return Stats{Unique: len(p.entries), Hits: p.hits, Misses: p.misses, Bytes: p.bytes}
+}
+
+// lookup finds the entry for v, creating it if needed.
+func (p *Pool[T]) lookup(v T) *entry[T] {
+	if e, ok := p.entries[v]; ok {
+		p.hits++
+		return e
+	}
+	p.misses++
+	e := &entry[T]{value: v}
+	p.entries[v] = e
+	if p.size != nil {
+		p.bytes += p.size(v)
+	}
+	return e
+}
+
+// remove removes the entry for v from the pool.
+func (p *Pool[T]) remove(e *entry[T]) {
+	delete(p.entries, e.value)
+	if p.size != nil {
+		p.bytes -= p.size(e.value)
+	}
+}
+
+// Scope is a group of values interned in a pool that can be released
+// together, e.g. the identifiers of a single parsed file. A value stays in
+// the pool while any live scope holds it, or if it was also interned
+// permanently with [Pool.Intern]. Create scopes with [Pool.NewScope].
+type Scope[T comparable] struct {
+	pool    *Pool[T]
+	entries map[T]*entry[T]
+}
+
+// NewScope creates a new scope interning values in p.
+func (p *Pool[T]) NewScope() *Scope[T] {
+	return &Scope[T]{pool: p, entries: make(map[T]*entry[T])}
+}
+
+// Intern returns the canonical instance of v in the scope's pool, adding v
+// to the pool if it's not already there. The scope holds v in the pool until
+// it's released. It panics if the scope was already released.
+func (s *Scope[T]) Intern(v T) T {
+	if s.entries == nil {
+		panic("intern: use of released scope")
+	}
+	if e, ok := s.entries[v]; ok {
+		s.pool.hits++
+		return e.value
+	}
+	e := s.pool.lookup(v)
+	e.refs++
+	s.entries[v] = e
+	return e.value
+}
+
+// Len returns the number of distinct values held by the scope.
+func (s *Scope[T]) Len() int {
+	return len(s.entries)
+}
+
+// Release releases all the values held by the scope, removing from the pool
+// the ones that are no longer held by any scope and weren't interned
+// permanently. The canonical instances returned earlier remain valid, but
+// values interned after the release may get new instances. Releasing a
+// scope more than once has no effect.
+func (s *Scope[T]) Release() {
+	for _, e := range s.entries {
+		e.refs--
+		if e.refs == 0 && !e.permanent {
+			s.pool.remove(e)
+		}
+	}
+	s.entries = nil
+}
@@ -0,0 +1,106 @@
+package intern
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// sameString reports whether a and b share their backing memory.
+func sameString(a, b string) bool {
+	return len(a) == len(b) && unsafe.StringData(a) == unsafe.StringData(b)
+}
+
+func TestStrings(t *testing.T) {
+	p := NewStrings()
+	text := "foo bar foo baz bar foo"
+	first := make(map[string]string)
+	for _, w := range strings.Fields(text) {
+		// Clone so each word has distinct memory before interning.
+		got := p.Intern(strings.Clone(w))
+		if got != w {
+			t.Fatalf("got %q, want %q", got, w)
+		}
+		if prev, ok := first[w]; ok {
+			if !sameString(got, prev) {
+				t.Errorf("%q interned to a different instance", w)
+			}
+		} else {
+			first[w] = got
+		}
+	}
+
+	want := Stats{Unique: 3, Hits: 3, Misses: 3, Bytes: 9}
+	if got := p.Stats(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+	if p.Len() != 3 || !p.Contains("baz") || p.Contains("qux") {
+		t.Errorf("bad pool contents")
+	}
+
+	if got := InternBytes(p, []byte("bar")); !sameString(got, first["bar"]) {
+		t.Errorf("InternBytes returned a different instance")
+	}
+	if got := InternBytes(p, []byte("qux")); got != "qux" || !p.Contains("qux") {
+		t.Errorf("InternBytes didn't add a new string")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		InternBytes(p, []byte("foo"))
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations for an existing string", allocs)
+	}
+}
+
+func TestComparableValues(t *testing.T) {
+	type key struct {
+		name string
+		n    int
+	}
+	p := New[key]()
+	a := p.Intern(key{"x", 1})
+	b := p.Intern(key{strings.Clone("x"), 1})
+	if !sameString(a.name, b.name) {
+		t.Errorf("struct not canonicalized")
+	}
+	p.Intern(key{"x", 2})
+	if got := p.Stats(); got.Unique != 2 || got.Bytes != 0 {
+		t.Errorf("got stats %+v", got)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	p := NewStrings()
+	p.Intern("keep")
+
+	s1 := p.NewScope()
+	s2 := p.NewScope()
+	s1.Intern("keep")
+	s1.Intern("shared")
+	s1.Intern("shared")
+	s1.Intern("only1")
+	s2.Intern("shared")
+	if s1.Len() != 3 || s2.Len() != 1 || p.Len() != 3 {
+		t.Fatalf("got lens %d, %d, %d", s1.Len(), s2.Len(), p.Len())
+	}
+
+	s1.Release()
+	if !p.Contains("keep") || !p.Contains("shared") || p.Contains("only1") {
+		t.Errorf("bad pool contents after first release")
+	}
+	s1.Release()
+	s2.Release()
+	if p.Len() != 1 || !p.Contains("keep") {
+		t.Errorf("bad pool contents after second release")
+	}
+	if got := p.Stats(); got.Bytes != 4 {
+		t.Errorf("got stats %+v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic using released scope")
+		}
+	}()
+	s1.Intern("x")
+}
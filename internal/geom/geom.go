@@ -0,0 +1,45 @@
+// Package geom implements the geometric types shared by the spatial indexes
+// of this module, which export them under their own names.
+package geom
+
+// Rect is a closed axis-aligned rectangle in the plane, holding the points
+// (x, y) with Min[0] <= x <= Max[0] and Min[1] <= y <= Max[1]. A point is a
+// rectangle with Min == Max.
+type Rect struct {
+	Min, Max [2]float64
+}
+
+// Point returns the rectangle holding only the point (x, y).
+func Point(x, y float64) Rect {
+	return Rect{Min: [2]float64{x, y}, Max: [2]float64{x, y}}
+}
+
+// Intersects reports whether r and other have at least one point in common;
+// rectangles that only touch intersect.
+func (r Rect) Intersects(other Rect) bool {
+	for axis := range 2 {
+		if r.Min[axis] > other.Max[axis] || other.Min[axis] > r.Max[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains reports whether other lies entirely within r.
+func (r Rect) Contains(other Rect) bool {
+	for axis := range 2 {
+		if other.Min[axis] < r.Min[axis] || other.Max[axis] > r.Max[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns the smallest rectangle containing both r and other.
+func (r Rect) Union(other Rect) Rect {
+	for axis := range 2 {
+		r.Min[axis] = min(r.Min[axis], other.Min[axis])
+		r.Max[axis] = max(r.Max[axis], other.Max[axis])
+	}
+	return r
+}
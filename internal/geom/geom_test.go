@@ -0,0 +1,18 @@
+package geom
+
+import "testing"
+
+func TestRect(t *testing.T) {
+	a := Rect{Min: [2]float64{0, 0}, Max: [2]float64{2, 2}}
+	b := Rect{Min: [2]float64{2, 1}, Max: [2]float64{3, 5}}
+	c := Rect{Min: [2]float64{2.5, 0}, Max: [2]float64{4, 1}}
+	if !a.Intersects(b) || !b.Intersects(a) || a.Intersects(c) || !b.Intersects(c) {
+		t.Errorf("bad Intersects")
+	}
+	if u := a.Union(c); u != (Rect{Min: [2]float64{0, 0}, Max: [2]float64{4, 2}}) || !u.Contains(a) || !u.Contains(c) || a.Contains(u) {
+		t.Errorf("bad Union %v", u)
+	}
+	if p := Point(1, 2); !a.Contains(p) || p.Min != p.Max || p.Min != [2]float64{1, 2} {
+		t.Errorf("bad Point %v", p)
+	}
+}
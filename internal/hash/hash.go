@@ -0,0 +1,41 @@
+// Package hash implements the hash functions shared by the hashing data
+// structures of this module.
+package hash
+
+// Bytes returns a 64-bit hash of data: FNV-1a, followed by [Mix]. It's
+// deterministic across processes and platforms, so it can be used in
+// serialized data.
+func Bytes(data []byte) uint64 {
+	h := uint64(fnvOffset)
+	for _, b := range data {
+		h = (h ^ uint64(b)) * fnvPrime
+	}
+	return Mix(h)
+}
+
+// String returns the hash of s; it equals Bytes([]byte(s)).
+func String(s string) uint64 {
+	h := uint64(fnvOffset)
+	for i := range len(s) {
+		h = (h ^ uint64(s[i])) * fnvPrime
+	}
+	return Mix(h)
+}
+
+// Parameters of the 64-bit FNV-1a hash.
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// Mix is the finalizer of the SplitMix64 generator; it spreads the bits of
+// its input evenly over the output. FNV alone mixes the last bytes of its
+// input poorly into the high bits.
+func Mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
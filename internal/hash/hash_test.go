@@ -0,0 +1,18 @@
+package hash
+
+import "testing"
+
+func TestHash(t *testing.T) {
+	for _, s := range []string{"", "a", "hello, world"} {
+		if Bytes([]byte(s)) != String(s) {
+			t.Errorf("Bytes(%q) != String(%q)", s, s)
+		}
+	}
+	// The hash is part of serialization formats, so it must not change.
+	if got, want := String("gogl"), uint64(0x6487b4883e3895a5); got != want {
+		t.Errorf("String(gogl)=%#x, want %#x", got, want)
+	}
+	if got, want := Mix(1), uint64(0x5692161d100b05e5); got != want {
+		t.Errorf("Mix(1)=%#x, want %#x", got, want)
+	}
+}
@@ -0,0 +1,270 @@
+// Package intervaltree implements an interval tree: a collection of
+// half-open intervals with payloads, supporting efficient queries for the
+// intervals containing a point or overlapping a range.
+package intervaltree
+
+import (
+	"iter"
+	"slices"
+)
+
+// Interval is a half-open interval [Lo, Hi) with a payload.
+type Interval[K, V any] struct {
+	Lo, Hi K
+	Value  V
+}
+
+// Tree is an interval tree holding intervals with endpoints of type K and
+// payloads of type V. It's an AVL tree ordered by the intervals' endpoints,
+// where each node also records the largest high endpoint in its subtree;
+// this allows queries to skip subtrees with no overlapping intervals, so
+// they take O(log n + k) time for k results. The same interval may be
+// inserted multiple times. Create trees with [New].
+type Tree[K, V any] struct {
+	cmp    func(K, K) int
+	root   *node[K, V]
+	length int
+}
+
+// node holds all the intervals with the same endpoints.
+type node[K, V any] struct {
+	lo, hi      K
+	values      []V
+	maxHi       K
+	left, right *node[K, V]
+	height      int
+}
+
+// New creates a new, empty interval tree with the given comparison function
+// for endpoints. cmp(a, b) should return a negative number when a<b, a
+// positive number when a>b and zero when a==b.
+func New[K, V any](cmp func(K, K) int) *Tree[K, V] {
+	return &Tree[K, V]{cmp: cmp}
+}
+
+// Len returns the number of intervals in the tree.
+func (t *Tree[K, V]) Len() int {
+	return t.length
+}
+
+// Insert inserts the interval [lo, hi) with payload v into the tree. It
+// panics if lo >= hi.
+func (t *Tree[K, V]) Insert(lo, hi K, v V) {
+	if t.cmp(lo, hi) >= 0 {
+		panic("intervaltree: invalid interval")
+	}
+	t.root = t.insert(t.root, lo, hi, v)
+	t.length++
+}
+
+// Delete deletes one interval [lo, hi) whose payload satisfies match from
+// the tree; a nil match matches any payload. It returns true if an interval
+// was deleted, false otherwise.
+func (t *Tree[K, V]) Delete(lo, hi K, match func(V) bool) bool {
+	n := t.root
+	for n != nil {
+		c := t.compareInterval(lo, hi, n)
+		if c < 0 {
+			n = n.left
+		} else if c > 0 {
+			n = n.right
+		} else {
+			break
+		}
+	}
+	if n == nil {
+		return false
+	}
+	i := 0
+	if match != nil {
+		i = slices.IndexFunc(n.values, match)
+		if i < 0 {
+			return false
+		}
+	}
+	t.length--
+	if len(n.values) > 1 {
+		n.values = slices.Delete(n.values, i, i+1)
+		return true
+	}
+	t.root = t.deleteNode(t.root, lo, hi)
+	return true
+}
+
+// Stab returns an iterator over all the intervals containing point, in
+// ascending order of their endpoints.
+func (t *Tree[K, V]) Stab(point K) iter.Seq[Interval[K, V]] {
+	return func(yield func(Interval[K, V]) bool) {
+		t.search(t.root, point, point, true, yield)
+	}
+}
+
+// Overlapping returns an iterator over all the intervals overlapping the
+// interval [lo, hi), in ascending order of their endpoints. An empty query
+// interval (lo >= hi) overlaps nothing.
+func (t *Tree[K, V]) Overlapping(lo, hi K) iter.Seq[Interval[K, V]] {
+	return func(yield func(Interval[K, V]) bool) {
+		if t.cmp(lo, hi) >= 0 {
+			return
+		}
+		t.search(t.root, lo, hi, false, yield)
+	}
+}
+
+// All returns an iterator over all the intervals in the tree, in ascending
+// order of their endpoints.
+func (t *Tree[K, V]) All() iter.Seq[Interval[K, V]] {
+	return func(yield func(Interval[K, V]) bool) {
+		t.walk(t.root, yield)
+	}
+}
+
+// search yields the intervals in the subtree of n that overlap [lo, hi) in
+// order; if point is true, lo == hi and it yields the intervals containing
+// this point instead. It returns false if iteration was stopped.
+func (t *Tree[K, V]) search(n *node[K, V], lo, hi K, point bool, yield func(Interval[K, V]) bool) bool {
+	// No interval in n's subtree ends after lo.
+	if n == nil || t.cmp(n.maxHi, lo) <= 0 {
+		return true
+	}
+	if !t.search(n.left, lo, hi, point, yield) {
+		return false
+	}
+	// If n starts at or after the end of the query, so do all the intervals
+	// in its right subtree.
+	c := t.cmp(n.lo, hi)
+	if c > 0 || (c == 0 && !point) {
+		return true
+	}
+	if t.cmp(n.hi, lo) > 0 && !n.yieldAll(yield) {
+		return false
+	}
+	return t.search(n.right, lo, hi, point, yield)
+}
+
+func (t *Tree[K, V]) walk(n *node[K, V], yield func(Interval[K, V]) bool) bool {
+	return n == nil || (t.walk(n.left, yield) && n.yieldAll(yield) && t.walk(n.right, yield))
+}
+
+func (n *node[K, V]) yieldAll(yield func(Interval[K, V]) bool) bool {
+	for _, v := range n.values {
+		if !yield(Interval[K, V]{Lo: n.lo, Hi: n.hi, Value: v}) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareInterval compares [lo, hi) to n's interval, ordering by low
+// endpoints and then by high endpoints.
+func (t *Tree[K, V]) compareInterval(lo, hi K, n *node[K, V]) int {
+	if c := t.cmp(lo, n.lo); c != 0 {
+		return c
+	}
+	return t.cmp(hi, n.hi)
+}
+
+func (t *Tree[K, V]) insert(n *node[K, V], lo, hi K, v V) *node[K, V] {
+	if n == nil {
+		return &node[K, V]{lo: lo, hi: hi, values: []V{v}, maxHi: hi, height: 1}
+	}
+	c := t.compareInterval(lo, hi, n)
+	switch {
+	case c < 0:
+		n.left = t.insert(n.left, lo, hi, v)
+	case c > 0:
+		n.right = t.insert(n.right, lo, hi, v)
+	default:
+		n.values = append(n.values, v)
+		return n
+	}
+	return t.rebalance(n)
+}
+
+// deleteNode deletes the node for [lo, hi), which must exist, from the
+// subtree rooted at n, and returns the new root of the subtree.
+func (t *Tree[K, V]) deleteNode(n *node[K, V], lo, hi K) *node[K, V] {
+	c := t.compareInterval(lo, hi, n)
+	switch {
+	case c < 0:
+		n.left = t.deleteNode(n.left, lo, hi)
+	case c > 0:
+		n.right = t.deleteNode(n.right, lo, hi)
+	default:
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		var succ *node[K, V]
+		n.right, succ = t.removeMin(n.right)
+		succ.left, succ.right = n.left, n.right
+		n = succ
+	}
+	return t.rebalance(n)
+}
+
+func (t *Tree[K, V]) removeMin(n *node[K, V]) (*node[K, V], *node[K, V]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	var m *node[K, V]
+	n.left, m = t.removeMin(n.left)
+	return t.rebalance(n), m
+}
+
+func (n *node[K, V]) ht() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// update recomputes n's height and maxHi from its children.
+func (t *Tree[K, V]) update(n *node[K, V]) {
+	n.height = max(n.left.ht(), n.right.ht()) + 1
+	n.maxHi = n.hi
+	for _, c := range []*node[K, V]{n.left, n.right} {
+		if c != nil && t.cmp(c.maxHi, n.maxHi) > 0 {
+			n.maxHi = c.maxHi
+		}
+	}
+}
+
+func (t *Tree[K, V]) rotateLeft(n *node[K, V]) *node[K, V] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	t.update(n)
+	t.update(r)
+	return r
+}
+
+func (t *Tree[K, V]) rotateRight(n *node[K, V]) *node[K, V] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	t.update(n)
+	t.update(l)
+	return l
+}
+
+// rebalance updates n after a change in one of its subtrees, performing AVL
+// rotations if needed. It returns the new root of the subtree.
+func (t *Tree[K, V]) rebalance(n *node[K, V]) *node[K, V] {
+	t.update(n)
+	switch bf := n.right.ht() - n.left.ht(); {
+	case bf > 1:
+		if n.right.right.ht() < n.right.left.ht() {
+			n.right = t.rotateRight(n.right)
+		}
+		return t.rotateLeft(n)
+	case bf < -1:
+		if n.left.left.ht() < n.left.right.ht() {
+			n.left = t.rotateLeft(n.left)
+		}
+		return t.rotateRight(n)
+	}
+	return n
+}
@@ -0,0 +1,138 @@
+package intervaltree
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+type iv = Interval[int, string]
+
+func compareIntervals(a, b iv) int {
+	return cmp.Or(cmp.Compare(a.Lo, b.Lo), cmp.Compare(a.Hi, b.Hi), cmp.Compare(a.Value, b.Value))
+}
+
+// verify checks the AVL balance and maxHi augmentation of the subtree
+// rooted at n, returning its height.
+func verify(t *testing.T, n *node[int, string]) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	lh, rh := verify(t, n.left), verify(t, n.right)
+	if lh-rh > 1 || rh-lh > 1 || n.height != max(lh, rh)+1 {
+		t.Fatalf("node [%d, %d): bad balance or height", n.lo, n.hi)
+	}
+	wantMax := n.hi
+	for _, c := range []*node[int, string]{n.left, n.right} {
+		if c != nil {
+			wantMax = max(wantMax, c.maxHi)
+		}
+	}
+	if n.maxHi != wantMax {
+		t.Fatalf("node [%d, %d): maxHi=%d, want %d", n.lo, n.hi, n.maxHi, wantMax)
+	}
+	return n.height
+}
+
+// collect collects the intervals of seq, sorting intervals with the same
+// endpoints by payload so results can be compared.
+func collect(seq func(func(iv) bool)) []iv {
+	var result []iv
+	for x := range seq {
+		result = append(result, x)
+	}
+	slices.SortStableFunc(result, compareIntervals)
+	return result
+}
+
+func TestCalendar(t *testing.T) {
+	tr := New[int, string](cmp.Compare[int])
+	tr.Insert(9, 10, "standup")
+	tr.Insert(9, 12, "focus")
+	tr.Insert(13, 14, "lunch")
+	tr.Insert(9, 10, "standup-2")
+
+	got := collect(tr.Stab(9))
+	want := []iv{{9, 10, "standup"}, {9, 10, "standup-2"}, {9, 12, "focus"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := collect(tr.Stab(12)); len(got) != 0 {
+		t.Errorf("Stab at an exclusive end: got %v", got)
+	}
+	got = collect(tr.Overlapping(11, 14))
+	want = []iv{{9, 12, "focus"}, {13, 14, "lunch"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := collect(tr.Overlapping(12, 13)); len(got) != 0 {
+		t.Errorf("adjacent intervals overlap: %v", got)
+	}
+
+	if !tr.Delete(9, 10, func(v string) bool { return v == "standup-2" }) {
+		t.Errorf("Delete failed")
+	}
+	if tr.Delete(9, 10, func(v string) bool { return v == "nope" }) || tr.Delete(1, 2, nil) {
+		t.Errorf("deleted missing interval")
+	}
+	if tr.Len() != 3 {
+		t.Errorf("got Len=%d", tr.Len())
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	tr := New[int, string](cmp.Compare[int])
+	var all []iv
+	for i := range 3000 {
+		if rnd.IntN(3) == 0 && len(all) > 0 {
+			x := all[rnd.IntN(len(all))]
+			if !tr.Delete(x.Lo, x.Hi, func(v string) bool { return v == x.Value }) {
+				t.Fatalf("Delete(%v) failed", x)
+			}
+			all = slices.DeleteFunc(all, func(y iv) bool { return y == x })
+		} else {
+			lo := rnd.IntN(1000)
+			x := iv{lo, lo + 1 + rnd.IntN(50), fmt.Sprint(i)}
+			tr.Insert(x.Lo, x.Hi, x.Value)
+			all = append(all, x)
+		}
+	}
+	verify(t, tr.root)
+	slices.SortFunc(all, compareIntervals)
+	if got := collect(tr.All()); !slices.Equal(got, all) {
+		t.Fatalf("All mismatch")
+	}
+
+	for range 300 {
+		p := rnd.IntN(1100) - 50
+		var want []iv
+		for _, x := range all {
+			if x.Lo <= p && p < x.Hi {
+				want = append(want, x)
+			}
+		}
+		if got := collect(tr.Stab(p)); !slices.Equal(got, want) {
+			t.Fatalf("Stab(%d)=%v, want %v", p, got, want)
+		}
+
+		lo := rnd.IntN(1100) - 50
+		hi := lo + rnd.IntN(30)
+		want = nil
+		for _, x := range all {
+			if x.Lo < hi && lo < x.Hi && lo < hi {
+				want = append(want, x)
+			}
+		}
+		if got := collect(tr.Overlapping(lo, hi)); !slices.Equal(got, want) {
+			t.Fatalf("Overlapping(%d, %d)=%v, want %v", lo, hi, got, want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package iterutil provides adapters over the iterators of package iter,
+// such as the ones returned by the module's containers: transforming,
+// filtering, slicing, combining and reducing sequences.
+//
+// The adapters are lazy: they don't consume their input sequences until the
+// sequences they return are iterated over, and stop consuming them as soon
+// as iteration stops. Sequences returned by adapters can be iterated over as
+// many times as their input sequences can.
+package iterutil
+
+import "iter"
+
+// Map returns a sequence of the results of calling f on each value of seq.
+func Map[T, U any](seq iter.Seq[T], f func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(f(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Map2 returns a sequence of the results of calling f on each pair of seq.
+func Map2[K, V, K2, V2 any](seq iter.Seq2[K, V], f func(K, V) (K2, V2)) iter.Seq2[K2, V2] {
+	return func(yield func(K2, V2) bool) {
+		for k, v := range seq {
+			if !yield(f(k, v)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns a sequence of the values of seq for which keep returns
+// true.
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Filter2 returns a sequence of the pairs of seq for which keep returns
+// true.
+func Filter2[K, V any](seq iter.Seq2[K, V], keep func(K, V) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range seq {
+			if keep(k, v) && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Take returns a sequence of the first n values of seq, or all of them if
+// it has fewer.
+func Take[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for v := range seq {
+			if !yield(v) {
+				return
+			}
+			if i++; i == n {
+				return
+			}
+		}
+	}
+}
+
+// Take2 returns a sequence of the first n pairs of seq, or all of them if
+// it has fewer.
+func Take2[K, V any](seq iter.Seq2[K, V], n int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for k, v := range seq {
+			if !yield(k, v) {
+				return
+			}
+			if i++; i == n {
+				return
+			}
+		}
+	}
+}
+
+// Drop returns a sequence of the values of seq after the first n.
+func Drop[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		i := 0
+		for v := range seq {
+			if i < n {
+				i++
+				continue
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Drop2 returns a sequence of the pairs of seq after the first n.
+func Drop2[K, V any](seq iter.Seq2[K, V], n int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		i := 0
+		for k, v := range seq {
+			if i < n {
+				i++
+				continue
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Zip returns a sequence of pairs of the values of a and b at the same
+// positions. It ends with the shorter of a and b.
+func Zip[A, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+		for va := range a {
+			vb, ok := next()
+			if !ok || !yield(va, vb) {
+				return
+			}
+		}
+	}
+}
+
+// Chunk returns a sequence of consecutive slices of n values of seq; the
+// last slice is shorter if the length of seq isn't a multiple of n. Each
+// slice is newly allocated. It panics if n is less than 1.
+func Chunk[T any](seq iter.Seq[T], n int) iter.Seq[[]T] {
+	if n < 1 {
+		panic("iterutil: chunk size must be positive")
+	}
+	return func(yield func([]T) bool) {
+		var chunk []T
+		for v := range seq {
+			if chunk == nil {
+				chunk = make([]T, 0, n)
+			}
+			chunk = append(chunk, v)
+			if len(chunk) == n {
+				if !yield(chunk) {
+					return
+				}
+				chunk = nil
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
+
+// Concat returns a sequence of the values of seqs, one sequence after the
+// other.
+func Concat[T any](seqs ...iter.Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, seq := range seqs {
+			for v := range seq {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Concat2 returns a sequence of the pairs of seqs, one sequence after the
+// other.
+func Concat2[K, V any](seqs ...iter.Seq2[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, seq := range seqs {
+			for k, v := range seq {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Enumerate returns a sequence of the values of seq paired with their
+// positions, starting at 0.
+func Enumerate[T any](seq iter.Seq[T]) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for v := range seq {
+			if !yield(i, v) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Reduce returns the result of calling f on an accumulator and each value
+// of seq in turn, starting with init as the accumulator and replacing it by
+// the result of each call.
+func Reduce[T, A any](seq iter.Seq[T], init A, f func(A, T) A) A {
+	acc := init
+	for v := range seq {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// Reduce2 is like Reduce, for sequences of pairs.
+func Reduce2[K, V, A any](seq iter.Seq2[K, V], init A, f func(A, K, V) A) A {
+	acc := init
+	for k, v := range seq {
+		acc = f(acc, k, v)
+	}
+	return acc
+}
@@ -0,0 +1,227 @@
+package iterutil
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// countTo returns a sequence of the integers [0, n), counting the values
+// consumed in *consumed.
+func countTo(n int, consumed *int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := range n {
+			*consumed++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// pairs returns a sequence of the pairs (i, i*i) for i in [0, n).
+func pairs(n int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for i := range n {
+			if !yield(i, i*i) {
+				return
+			}
+		}
+	}
+}
+
+func collect2[K, V any](seq iter.Seq2[K, V]) (ks []K, vs []V) {
+	for k, v := range seq {
+		ks = append(ks, k)
+		vs = append(vs, v)
+	}
+	return ks, vs
+}
+
+func checkSlice[T comparable](t *testing.T, name string, got, want []T) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Errorf("%s: got %v, want %v", name, got, want)
+	}
+}
+
+func TestMapFilter(t *testing.T) {
+	var n int
+	seq := countTo(6, &n)
+	checkSlice(t, "Map", slices.Collect(Map(seq, strconv.Itoa)), []string{"0", "1", "2", "3", "4", "5"})
+	isEven := func(v int) bool { return v%2 == 0 }
+	checkSlice(t, "Filter", slices.Collect(Filter(seq, isEven)), []int{0, 2, 4})
+	checkSlice(t, "Filter none", slices.Collect(Filter(seq, func(int) bool { return false })), nil)
+
+	ks, vs := collect2(Map2(pairs(4), func(k, v int) (string, int) { return strconv.Itoa(k), -v }))
+	checkSlice(t, "Map2 keys", ks, []string{"0", "1", "2", "3"})
+	checkSlice(t, "Map2 values", vs, []int{0, -1, -4, -9})
+	ks2, vs2 := collect2(Filter2(pairs(6), func(k, v int) bool { return k%2 == 1 && v < 20 }))
+	checkSlice(t, "Filter2 keys", ks2, []int{1, 3})
+	checkSlice(t, "Filter2 values", vs2, []int{1, 9})
+}
+
+func TestTakeDrop(t *testing.T) {
+	var n int
+	checkSlice(t, "Take", slices.Collect(Take(countTo(10, &n), 3)), []int{0, 1, 2})
+	if n != 3 {
+		t.Errorf("Take(3) consumed %d values", n)
+	}
+	n = 0
+	checkSlice(t, "Take 0", slices.Collect(Take(countTo(10, &n), 0)), nil)
+	if n != 0 {
+		t.Errorf("Take(0) consumed %d values", n)
+	}
+	checkSlice(t, "Take more", slices.Collect(Take(countTo(2, &n), 5)), []int{0, 1})
+	checkSlice(t, "Drop", slices.Collect(Drop(countTo(5, &n), 3)), []int{3, 4})
+	checkSlice(t, "Drop more", slices.Collect(Drop(countTo(5, &n), 8)), nil)
+	checkSlice(t, "Drop negative", slices.Collect(Drop(countTo(2, &n), -1)), []int{0, 1})
+
+	ks, vs := collect2(Take2(pairs(10), 2))
+	checkSlice(t, "Take2 keys", ks, []int{0, 1})
+	checkSlice(t, "Take2 values", vs, []int{0, 1})
+	ks, vs = collect2(Drop2(pairs(4), 2))
+	checkSlice(t, "Drop2 keys", ks, []int{2, 3})
+	checkSlice(t, "Drop2 values", vs, []int{4, 9})
+}
+
+func TestZip(t *testing.T) {
+	var na, nb int
+	ks, vs := collect2(Zip(countTo(3, &na), Map(countTo(10, &nb), strconv.Itoa)))
+	checkSlice(t, "Zip keys", ks, []int{0, 1, 2})
+	checkSlice(t, "Zip values", vs, []string{"0", "1", "2"})
+	if nb > 4 {
+		t.Errorf("Zip consumed %d values of the longer sequence", nb)
+	}
+
+	na, nb = 0, 0
+	ks, _ = collect2(Zip(countTo(10, &na), countTo(2, &nb)))
+	checkSlice(t, "Zip shorter second", ks, []int{0, 1})
+	if na > 3 {
+		t.Errorf("Zip consumed %d values of the longer sequence", na)
+	}
+
+	for k, v := range Zip(countTo(10, &na), countTo(10, &nb)) {
+		if k != v {
+			t.Errorf("Zip yielded %d, %d", k, v)
+		}
+		if k == 4 {
+			break
+		}
+	}
+}
+
+func TestChunk(t *testing.T) {
+	var n int
+	got := slices.Collect(Chunk(countTo(7, &n), 3))
+	want := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Chunk: got %v, want %v", got, want)
+	}
+	got = slices.Collect(Chunk(countTo(4, &n), 2))
+	want = [][]int{{0, 1}, {2, 3}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("Chunk: got %v, want %v", got, want)
+	}
+	if got := slices.Collect(Chunk(countTo(0, &n), 2)); len(got) != 0 {
+		t.Errorf("Chunk of empty sequence: got %v", got)
+	}
+
+	// Chunks aren't reused.
+	got[0][0] = 100
+	if got[1][0] != 2 {
+		t.Errorf("Chunk reused a slice")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Chunk(0): no panic")
+		}
+	}()
+	Chunk(countTo(1, &n), 0)
+}
+
+func TestConcat(t *testing.T) {
+	var n int
+	checkSlice(t, "Concat", slices.Collect(Concat(countTo(2, &n), countTo(0, &n), countTo(3, &n))), []int{0, 1, 0, 1, 2})
+	checkSlice(t, "Concat none", slices.Collect(Concat[int]()), nil)
+	n = 0
+	checkSlice(t, "Concat Take", slices.Collect(Take(Concat(countTo(2, &n), countTo(3, &n), countTo(3, &n)), 3)), []int{0, 1, 0})
+	if n != 3 {
+		t.Errorf("Concat consumed %d values", n)
+	}
+
+	got := maps.Collect(Concat2(pairs(2), Map2(pairs(4), func(k, v int) (int, int) { return k + 10, v })))
+	want := map[int]int{0: 0, 1: 1, 10: 0, 11: 1, 12: 4, 13: 9}
+	if !maps.Equal(got, want) {
+		t.Errorf("Concat2: got %v, want %v", got, want)
+	}
+}
+
+func TestEnumerateReduce(t *testing.T) {
+	var n int
+	ks, vs := collect2(Enumerate(Map(countTo(3, &n), func(v int) string { return strconv.Itoa(v * 10) })))
+	checkSlice(t, "Enumerate positions", ks, []int{0, 1, 2})
+	checkSlice(t, "Enumerate values", vs, []string{"0", "10", "20"})
+
+	sum := Reduce(countTo(5, &n), 100, func(acc, v int) int { return acc + v })
+	if sum != 110 {
+		t.Errorf("Reduce: got %d, want 110", sum)
+	}
+	s := Reduce(countTo(0, &n), "init", func(acc string, v int) string { return acc + strconv.Itoa(v) })
+	if s != "init" {
+		t.Errorf("Reduce of empty sequence: got %q, want %q", s, "init")
+	}
+	sum = Reduce2(pairs(4), 0, func(acc, k, v int) int { return acc + k*v })
+	if sum != 0+1+8+27 {
+		t.Errorf("Reduce2: got %d, want 36", sum)
+	}
+}
+
+func TestEarlyStop(t *testing.T) {
+	// Stopping iteration stops consuming the input, and never calls yield
+	// again.
+	adapters := map[string]func(iter.Seq[int]) iter.Seq[int]{
+		"Map":    func(s iter.Seq[int]) iter.Seq[int] { return Map(s, func(v int) int { return v }) },
+		"Filter": func(s iter.Seq[int]) iter.Seq[int] { return Filter(s, func(int) bool { return true }) },
+		"Take":   func(s iter.Seq[int]) iter.Seq[int] { return Take(s, 100) },
+		"Drop":   func(s iter.Seq[int]) iter.Seq[int] { return Drop(s, 1) },
+		"Concat": func(s iter.Seq[int]) iter.Seq[int] { return Concat(s, s) },
+		"Chunk":  func(s iter.Seq[int]) iter.Seq[int] { return Map(Chunk(s, 2), func(c []int) int { return c[0] }) },
+		"Zip":    func(s iter.Seq[int]) iter.Seq[int] { return keysOf(Zip(s, s)) },
+		"Enumerate": func(s iter.Seq[int]) iter.Seq[int] {
+			return keysOf(Enumerate(s))
+		},
+	}
+	for name, adapt := range adapters {
+		t.Run(name, func(t *testing.T) {
+			var n int
+			calls := 0
+			for range adapt(countTo(100, &n)) {
+				calls++
+				if calls == 3 {
+					break
+				}
+			}
+			if calls != 3 {
+				t.Errorf("got %d values, want 3", calls)
+			}
+			if n > 8 {
+				t.Errorf("consumed %d values for 3", n)
+			}
+		})
+	}
+}
+
+// keysOf returns the sequence of the keys of seq.
+func keysOf[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
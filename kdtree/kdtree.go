@@ -0,0 +1,240 @@
+// Package kdtree implements a k-d tree: a spatial index over points in k
+// dimensions, supporting nearest-neighbor and bounding-box queries.
+package kdtree
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// Tree is a k-d tree holding points of type P in a fixed number of
+// dimensions; the coordinates of points are obtained with a user-provided
+// accessor. It's a binary tree where each node splits space by the
+// coordinate of its point along one axis, cycling through the axes at each
+// level.
+//
+// Queries take O(log n) time on average for trees built with [FromSlice],
+// which are balanced. Inserting points one by one with [Tree.Insert] doesn't
+// rebalance the tree, so it may become unbalanced (e.g. if points are
+// inserted in sorted order); bulk loading is preferable when the points are
+// known up front. Distances are Euclidean. Create trees with [New] or
+// [FromSlice].
+type Tree[P any] struct {
+	dims   int
+	coord  func(P, int) float64
+	root   *node[P]
+	length int
+}
+
+// node holds a point; all the points in its left subtree have coordinates
+// along axis that are <= the point's, and all the points in its right
+// subtree have coordinates that are >= the point's.
+type node[P any] struct {
+	point       P
+	axis        int
+	left, right *node[P]
+}
+
+// New creates a new, empty tree over points with dims dimensions.
+// coord(p, axis) returns the coordinate of p along axis, which is in the
+// range [0, dims). It panics if dims < 1.
+func New[P any](dims int, coord func(p P, axis int) float64) *Tree[P] {
+	if dims < 1 {
+		panic(fmt.Sprintf("kdtree: invalid number of dimensions %d", dims))
+	}
+	return &Tree[P]{dims: dims, coord: coord}
+}
+
+// FromSlice creates a new balanced tree holding points, with dims
+// dimensions and the coord accessor as in [New]. It takes O(n log² n) time;
+// points isn't modified.
+func FromSlice[P any](dims int, coord func(p P, axis int) float64, points []P) *Tree[P] {
+	t := New(dims, coord)
+	t.root = t.build(slices.Clone(points), 0)
+	t.length = len(points)
+	return t
+}
+
+// build builds a balanced subtree from points, splitting by axis at its
+// root. It reorders points.
+func (t *Tree[P]) build(points []P, axis int) *node[P] {
+	if len(points) == 0 {
+		return nil
+	}
+	slices.SortFunc(points, func(a, b P) int {
+		ca, cb := t.coord(a, axis), t.coord(b, axis)
+		switch {
+		case ca < cb:
+			return -1
+		case ca > cb:
+			return 1
+		}
+		return 0
+	})
+	m := len(points) / 2
+	next := (axis + 1) % t.dims
+	return &node[P]{
+		point: points[m],
+		axis:  axis,
+		left:  t.build(points[:m], next),
+		right: t.build(points[m+1:], next),
+	}
+}
+
+// Len returns the number of points in the tree.
+func (t *Tree[P]) Len() int {
+	return t.length
+}
+
+// Dims returns the number of dimensions of the tree's points.
+func (t *Tree[P]) Dims() int {
+	return t.dims
+}
+
+// Insert adds p to the tree. The same point may be inserted multiple times.
+func (t *Tree[P]) Insert(p P) {
+	t.length++
+	link := &t.root
+	axis := 0
+	for *link != nil {
+		n := *link
+		if t.coord(p, n.axis) < t.coord(n.point, n.axis) {
+			link = &n.left
+		} else {
+			link = &n.right
+		}
+		axis = (n.axis + 1) % t.dims
+	}
+	*link = &node[P]{point: p, axis: axis}
+}
+
+// NearestNeighbor returns the point in the tree closest to q, with its
+// distance from q and ok=true; if the tree is empty, it returns ok=false.
+// If several points are equally close to q, one of them is returned.
+func (t *Tree[P]) NearestNeighbor(q P) (p P, dist float64, ok bool) {
+	nearest := t.KNearest(q, 1)
+	if len(nearest) == 0 {
+		return p, 0, false
+	}
+	return nearest[0].Point, nearest[0].Dist, true
+}
+
+// Neighbor is a point found by a nearest-neighbor query, with its distance
+// from the query point.
+type Neighbor[P any] struct {
+	Point P
+	Dist  float64
+}
+
+// KNearest returns the k points in the tree closest to q (or all the points,
+// if there are fewer than k), sorted by increasing distance from q.
+func (t *Tree[P]) KNearest(q P, k int) []Neighbor[P] {
+	if k <= 0 {
+		return nil
+	}
+	// best is a max-heap by squared distance of the closest points found so
+	// far, so its top is the one to evict when a closer point is found.
+	best := heap.NewDAry(4, func(a, b Neighbor[P]) int {
+		switch {
+		case a.Dist > b.Dist:
+			return -1
+		case a.Dist < b.Dist:
+			return 1
+		}
+		return 0
+	})
+	t.nearest(t.root, q, k, best)
+
+	result := make([]Neighbor[P], best.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = best.Pop()
+		result[i].Dist = math.Sqrt(result[i].Dist)
+	}
+	return result
+}
+
+func (t *Tree[P]) nearest(n *node[P], q P, k int, best *heap.DAry[Neighbor[P]]) {
+	if n == nil {
+		return
+	}
+	if d := t.dist2(q, n.point); best.Len() < k {
+		best.Push(Neighbor[P]{n.point, d})
+	} else if d < best.Peek().Dist {
+		best.Pop()
+		best.Push(Neighbor[P]{n.point, d})
+	}
+
+	// Search the side of the split containing q first, then the other side
+	// only if it may contain closer points than the worst one found.
+	diff := t.coord(q, n.axis) - t.coord(n.point, n.axis)
+	near, far := n.left, n.right
+	if diff >= 0 {
+		near, far = far, near
+	}
+	t.nearest(near, q, k, best)
+	if best.Len() < k || diff*diff < best.Peek().Dist {
+		t.nearest(far, q, k, best)
+	}
+}
+
+// Range returns an iterator over the points in the tree that lie in the
+// axis-aligned box with opposite corners lo and hi, inclusive: the points p
+// with lo[i] <= p[i] <= hi[i] along every axis i. The order of points is
+// unspecified.
+func (t *Tree[P]) Range(lo, hi P) iter.Seq[P] {
+	return func(yield func(P) bool) {
+		t.search(t.root, lo, hi, yield)
+	}
+}
+
+func (t *Tree[P]) search(n *node[P], lo, hi P, yield func(P) bool) bool {
+	if n == nil {
+		return true
+	}
+	c := t.coord(n.point, n.axis)
+	if t.coord(lo, n.axis) <= c && !t.search(n.left, lo, hi, yield) {
+		return false
+	}
+	if t.inBox(n.point, lo, hi) && !yield(n.point) {
+		return false
+	}
+	if t.coord(hi, n.axis) >= c && !t.search(n.right, lo, hi, yield) {
+		return false
+	}
+	return true
+}
+
+// All returns an iterator over all the points in the tree, in unspecified
+// order.
+func (t *Tree[P]) All() iter.Seq[P] {
+	return func(yield func(P) bool) {
+		t.walk(t.root, yield)
+	}
+}
+
+func (t *Tree[P]) walk(n *node[P], yield func(P) bool) bool {
+	return n == nil || (t.walk(n.left, yield) && yield(n.point) && t.walk(n.right, yield))
+}
+
+func (t *Tree[P]) inBox(p, lo, hi P) bool {
+	for axis := range t.dims {
+		if c := t.coord(p, axis); c < t.coord(lo, axis) || c > t.coord(hi, axis) {
+			return false
+		}
+	}
+	return true
+}
+
+// dist2 returns the squared Euclidean distance between p and q.
+func (t *Tree[P]) dist2(p, q P) float64 {
+	var d float64
+	for axis := range t.dims {
+		diff := t.coord(p, axis) - t.coord(q, axis)
+		d += diff * diff
+	}
+	return d
+}
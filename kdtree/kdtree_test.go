@@ -0,0 +1,184 @@
+package kdtree
+
+import (
+	"cmp"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+type point3 [3]float64
+
+func coord3(p point3, axis int) float64 {
+	return p[axis]
+}
+
+func randomPoints(rnd *rand.Rand, n int, grid bool) []point3 {
+	points := make([]point3, n)
+	for i := range points {
+		for axis := range 3 {
+			if grid {
+				// Coarse coordinates, to create many ties.
+				points[i][axis] = float64(rnd.IntN(8))
+			} else {
+				points[i][axis] = rnd.Float64()*200 - 100
+			}
+		}
+	}
+	return points
+}
+
+func dist(p, q point3) float64 {
+	var d float64
+	for axis := range 3 {
+		d += (p[axis] - q[axis]) * (p[axis] - q[axis])
+	}
+	return math.Sqrt(d)
+}
+
+func comparePoints(a, b point3) int {
+	return slices.Compare(a[:], b[:])
+}
+
+// checkKNearest checks the result of KNearest against a brute-force search
+// over points.
+func checkKNearest(t *testing.T, tr *Tree[point3], points []point3, q point3, k int) {
+	t.Helper()
+	got := tr.KNearest(q, k)
+	want := slices.Clone(points)
+	slices.SortFunc(want, func(a, b point3) int {
+		return cmp.Compare(dist(a, q), dist(b, q))
+	})
+	want = want[:min(k, len(want))]
+	if len(got) != len(want) {
+		t.Fatalf("KNearest(%v, %d) returned %d points, want %d", q, k, len(got), len(want))
+	}
+	for i, nb := range got {
+		// With ties, the points may differ but the distances may not.
+		if d := dist(nb.Point, q); nb.Dist != dist(want[i], q) || math.Abs(d-nb.Dist) > 1e-9 {
+			t.Fatalf("KNearest(%v, %d)[%d] = %v at %v, want distance %v", q, k, i, nb.Point, nb.Dist, dist(want[i], q))
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	tr := New(3, coord3)
+	if tr.Len() != 0 || tr.Dims() != 3 {
+		t.Errorf("Len=%d Dims=%d", tr.Len(), tr.Dims())
+	}
+	if _, _, ok := tr.NearestNeighbor(point3{}); ok {
+		t.Errorf("NearestNeighbor found a point in an empty tree")
+	}
+	if got := tr.KNearest(point3{}, 3); len(got) != 0 {
+		t.Errorf("KNearest = %v", got)
+	}
+	for p := range tr.Range(point3{-1, -1, -1}, point3{1, 1, 1}) {
+		t.Errorf("Range yielded %v", p)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, grid := range []bool{false, true} {
+		points := randomPoints(rnd, 500, grid)
+		inserted := New(3, coord3)
+		for _, p := range points {
+			inserted.Insert(p)
+		}
+		for _, tr := range []*Tree[point3]{FromSlice(3, coord3, points), inserted} {
+			if tr.Len() != len(points) {
+				t.Fatalf("Len=%d, want %d", tr.Len(), len(points))
+			}
+			for range 100 {
+				q := randomPoints(rnd, 1, grid)[0]
+				checkKNearest(t, tr, points, q, 1)
+				checkKNearest(t, tr, points, q, 7)
+				p, d, ok := tr.NearestNeighbor(q)
+				if want := tr.KNearest(q, 1)[0].Dist; !ok || d != want || dist(p, q) != d {
+					t.Fatalf("NearestNeighbor(%v) = %v, %v, %v; want distance %v", q, p, d, ok, want)
+				}
+			}
+			checkKNearest(t, tr, points, point3{}, len(points)+10)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, grid := range []bool{false, true} {
+		points := randomPoints(rnd, 500, grid)
+		inserted := New(3, coord3)
+		for _, p := range points {
+			inserted.Insert(p)
+		}
+		for _, tr := range []*Tree[point3]{FromSlice(3, coord3, points), inserted} {
+			for range 100 {
+				a, b := randomPoints(rnd, 1, grid)[0], randomPoints(rnd, 1, grid)[0]
+				var lo, hi point3
+				for axis := range 3 {
+					lo[axis], hi[axis] = min(a[axis], b[axis]), max(a[axis], b[axis])
+				}
+				var want []point3
+				for _, p := range points {
+					if p[0] >= lo[0] && p[0] <= hi[0] && p[1] >= lo[1] && p[1] <= hi[1] && p[2] >= lo[2] && p[2] <= hi[2] {
+						want = append(want, p)
+					}
+				}
+				got := slices.Collect(tr.Range(lo, hi))
+				slices.SortFunc(got, comparePoints)
+				slices.SortFunc(want, comparePoints)
+				if !slices.Equal(got, want) {
+					t.Fatalf("Range(%v, %v) = %v, want %v", lo, hi, got, want)
+				}
+			}
+
+			all := slices.SortedFunc(tr.All(), comparePoints)
+			want := slices.SortedFunc(slices.Values(points), comparePoints)
+			if !slices.Equal(all, want) {
+				t.Fatalf("All mismatch")
+			}
+		}
+	}
+}
+
+func TestEarlyStop(t *testing.T) {
+	points := randomPoints(makeLoggedRand(t), 100, false)
+	tr := FromSlice(3, coord3, points)
+	n := 0
+	for range tr.Range(point3{-100, -100, -100}, point3{100, 100, 100}) {
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("got %d points", n)
+	}
+}
+
+func TestInvalidDims(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("New(0) didn't panic")
+		}
+	}()
+	New(0, coord3)
+}
+
+func BenchmarkKNearest(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	tr := FromSlice(3, coord3, randomPoints(rnd, 100000, false))
+	queries := randomPoints(rnd, 1000, false)
+	b.ResetTimer()
+	for i := range b.N {
+		tr.KNearest(queries[i%len(queries)], 10)
+	}
+}
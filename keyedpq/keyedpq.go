@@ -0,0 +1,101 @@
+// Package keyedpq implements a priority queue with unique keys, that doubles
+// as a map from keys to their priorities.
+package keyedpq
+
+import "github.com/eliben/gogl/heap"
+
+// KeyedPQ is simultaneously a map from keys to priorities and a min-priority
+// queue over these priorities. All operations other than Get, Contains and
+// Len are O(log n); those three are O(1).
+type KeyedPQ[K comparable, P any] struct {
+	h       *heap.Indexed[item[K, P]]
+	handles map[K]*heap.Handle[item[K, P]]
+}
+
+type item[K comparable, P any] struct {
+	key K
+	pri P
+}
+
+// New creates a new, empty KeyedPQ. cmp compares priorities: it should return
+// a negative number when a<b, a positive number when a>b and zero when a==b.
+// PopMin pops the key with the minimal priority.
+func New[K comparable, P any](cmp func(a, b P) int) *KeyedPQ[K, P] {
+	return &KeyedPQ[K, P]{
+		h: heap.NewIndexed(func(a, b item[K, P]) int {
+			return cmp(a.pri, b.pri)
+		}),
+		handles: make(map[K]*heap.Handle[item[K, P]]),
+	}
+}
+
+// Len returns the number of keys in the queue.
+func (pq *KeyedPQ[K, P]) Len() int {
+	return len(pq.handles)
+}
+
+// Set sets the priority of key to pri, adding key to the queue if it's not
+// already there.
+func (pq *KeyedPQ[K, P]) Set(key K, pri P) {
+	if hd, ok := pq.handles[key]; ok {
+		pq.h.Update(hd, item[K, P]{key: key, pri: pri})
+		return
+	}
+	pq.handles[key] = pq.h.Push(item[K, P]{key: key, pri: pri})
+}
+
+// UpdatePriority sets the priority of key to pri if key is in the queue. It
+// returns true if key was found, and false otherwise (in which case the queue
+// is unchanged).
+func (pq *KeyedPQ[K, P]) UpdatePriority(key K, pri P) bool {
+	hd, ok := pq.handles[key]
+	if !ok {
+		return false
+	}
+	pq.h.Update(hd, item[K, P]{key: key, pri: pri})
+	return true
+}
+
+// Get returns the priority of key and ok=true if key is in the queue;
+// otherwise it returns ok=false.
+func (pq *KeyedPQ[K, P]) Get(key K) (pri P, ok bool) {
+	hd, ok := pq.handles[key]
+	if !ok {
+		return pri, false
+	}
+	return hd.Value().pri, true
+}
+
+// Contains reports whether key is in the queue.
+func (pq *KeyedPQ[K, P]) Contains(key K) bool {
+	_, ok := pq.handles[key]
+	return ok
+}
+
+// Remove removes key from the queue. It returns true if key was found, and
+// false otherwise.
+func (pq *KeyedPQ[K, P]) Remove(key K) bool {
+	hd, ok := pq.handles[key]
+	if !ok {
+		return false
+	}
+	pq.h.Remove(hd)
+	delete(pq.handles, key)
+	return true
+}
+
+// PeekMin returns the key with the minimal priority along with its priority,
+// without removing it. It panics if the queue is empty.
+func (pq *KeyedPQ[K, P]) PeekMin() (K, P) {
+	it := pq.h.Peek()
+	return it.key, it.pri
+}
+
+// PopMin removes the key with the minimal priority from the queue and returns
+// it along with its priority. It panics if the queue is empty; make sure to
+// check Len() first.
+func (pq *KeyedPQ[K, P]) PopMin() (K, P) {
+	it := pq.h.Pop()
+	delete(pq.handles, it.key)
+	return it.key, it.pri
+}
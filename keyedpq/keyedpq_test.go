@@ -0,0 +1,99 @@
+package keyedpq
+
+import (
+	"cmp"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestBasic(t *testing.T) {
+	pq := New[string](cmp.Compare[int])
+	pq.Set("a", 30)
+	pq.Set("b", 10)
+	pq.Set("c", 20)
+
+	if p, ok := pq.Get("a"); !ok || p != 30 {
+		t.Errorf("got Get(a)=%v,%v", p, ok)
+	}
+	if _, ok := pq.Get("z"); ok {
+		t.Errorf("found z")
+	}
+
+	// Re-setting a key changes its priority rather than adding it again.
+	pq.Set("a", 5)
+	if pq.Len() != 3 {
+		t.Errorf("got len=%d, want 3", pq.Len())
+	}
+	if k, p := pq.PeekMin(); k != "a" || p != 5 {
+		t.Errorf("got PeekMin=%v,%v", k, p)
+	}
+
+	if !pq.UpdatePriority("c", 1) {
+		t.Errorf("UpdatePriority(c) failed")
+	}
+	if pq.UpdatePriority("z", 1) || pq.Contains("z") {
+		t.Errorf("UpdatePriority(z) added a key")
+	}
+
+	if !pq.Remove("a") || pq.Remove("a") {
+		t.Errorf("bad Remove result")
+	}
+
+	want := []string{"c", "b"}
+	for _, w := range want {
+		if k, _ := pq.PopMin(); k != w {
+			t.Errorf("got %v, want %v", k, w)
+		}
+	}
+	if pq.Len() != 0 || pq.Contains("b") {
+		t.Errorf("queue not empty")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	pq := New[int](cmp.Compare[int])
+	m := make(map[int]int)
+	for range 3000 {
+		k := rnd.IntN(100)
+		switch rnd.IntN(4) {
+		case 0, 1:
+			p := rnd.IntN(1000)
+			pq.Set(k, p)
+			m[k] = p
+		case 2:
+			_, inMap := m[k]
+			if pq.Remove(k) != inMap {
+				t.Fatalf("Remove(%d) mismatch", k)
+			}
+			delete(m, k)
+		default:
+			if len(m) == 0 {
+				continue
+			}
+			k, p := pq.PopMin()
+			for _, mp := range m {
+				if mp < p {
+					t.Fatalf("popped %d with priority %d, but %d is in the queue", k, p, mp)
+				}
+			}
+			if m[k] != p {
+				t.Fatalf("got priority %d for %d, want %d", p, k, m[k])
+			}
+			delete(m, k)
+		}
+		if pq.Len() != len(m) {
+			t.Fatalf("got len=%d, want %d", pq.Len(), len(m))
+		}
+	}
+	for k := range maps.Keys(m) {
+		if p, ok := pq.Get(k); !ok || p != m[k] {
+			t.Errorf("got Get(%d)=%v,%v, want %v", k, p, ok, m[k])
+		}
+	}
+}
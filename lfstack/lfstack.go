@@ -0,0 +1,96 @@
+// Package lfstack implements a lock-free LIFO stack.
+package lfstack
+
+import "sync/atomic"
+
+// Stack is a LIFO stack safe for concurrent use by multiple goroutines
+// without locks: Push and Pop swing the top-of-stack pointer with a
+// compare-and-swap, retrying if another goroutine got there first (this is
+// Treiber's stack). It's a good fit for free lists and other pools of
+// reusable objects shared between goroutines.
+//
+// Lock-free stacks are notoriously prone to the ABA problem: Pop reads the
+// top node and its next pointer, and if meanwhile the top node is popped,
+// freed, reused and pushed again, the compare-and-swap succeeds and
+// installs a stale next pointer. Stack avoids it by allocating a fresh node
+// on every Push and never reusing nodes: since the garbage collector
+// doesn't recycle a node's memory while any goroutine still refers to it,
+// the top pointer can never hold the same node for two different pushes
+// that a Pop might confuse.
+//
+// The zero value is an empty stack ready to use.
+type Stack[T any] struct {
+	top atomic.Pointer[node[T]]
+	len atomic.Int64
+}
+
+type node[T any] struct {
+	value T
+	next  *node[T]
+}
+
+// New creates a new, empty stack.
+func New[T any]() *Stack[T] {
+	return &Stack[T]{}
+}
+
+// Len returns the number of elements in the stack. With concurrent
+// operations in progress, the result is only an approximation.
+func (s *Stack[T]) Len() int {
+	return max(0, int(s.len.Load()))
+}
+
+// Push pushes a value onto the top of the stack.
+func (s *Stack[T]) Push(v T) {
+	n := &node[T]{value: v}
+	for {
+		n.next = s.top.Load()
+		if s.top.CompareAndSwap(n.next, n) {
+			s.len.Add(1)
+			return
+		}
+	}
+}
+
+// Pop removes the value at the top of the stack and returns it and true. If
+// the stack is empty, it returns false.
+func (s *Stack[T]) Pop() (v T, ok bool) {
+	for {
+		top := s.top.Load()
+		if top == nil {
+			return v, false
+		}
+		if s.top.CompareAndSwap(top, top.next) {
+			s.len.Add(-1)
+			return top.value, true
+		}
+	}
+}
+
+// Peek returns the value at the top of the stack and true, without
+// removing it. If the stack is empty, it returns false.
+func (s *Stack[T]) Peek() (v T, ok bool) {
+	if top := s.top.Load(); top != nil {
+		return top.value, true
+	}
+	return v, false
+}
+
+// PopAll atomically removes all the values from the stack and returns them,
+// from top to bottom.
+func (s *Stack[T]) PopAll() []T {
+	var vs []T
+	for {
+		top := s.top.Load()
+		if top == nil {
+			return vs
+		}
+		if s.top.CompareAndSwap(top, nil) {
+			for n := top; n != nil; n = n.next {
+				vs = append(vs, n.value)
+			}
+			s.len.Add(-int64(len(vs)))
+			return vs
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package lfstack
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSequential(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	var s Stack[int]
+	var model []int
+	for i := range 5000 {
+		switch rnd.IntN(10) {
+		case 0:
+			got := s.PopAll()
+			slices.Reverse(model)
+			if !slices.Equal(got, model) {
+				t.Fatalf("PopAll()=%v, want %v", got, model)
+			}
+			model = nil
+		case 1, 2, 3, 4:
+			v, ok := s.Pop()
+			if ok != (len(model) > 0) || (ok && v != model[len(model)-1]) {
+				t.Fatalf("Pop()=%d,%v, model %v", v, ok, model)
+			}
+			if ok {
+				model = model[:len(model)-1]
+			}
+		default:
+			s.Push(i)
+			model = append(model, i)
+		}
+		if s.Len() != len(model) {
+			t.Fatalf("Len=%d, want %d", s.Len(), len(model))
+		}
+		v, ok := s.Peek()
+		if ok != (len(model) > 0) || (ok && v != model[len(model)-1]) {
+			t.Fatalf("Peek()=%d,%v, model %v", v, ok, model)
+		}
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	const goroutines = 8
+	const n = 10000
+
+	// Goroutines push distinct values and pop interleaved; every value must
+	// be popped exactly once, either by a goroutine or at the end.
+	s := New[int]()
+	var wg sync.WaitGroup
+	popped := make([][]int, goroutines)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				s.Push(g*n + i)
+				if i%3 != 0 {
+					if v, ok := s.Pop(); ok {
+						popped[g] = append(popped[g], v)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	count := make([]int, goroutines*n)
+	for _, vs := range append(popped, s.PopAll()) {
+		for _, v := range vs {
+			count[v]++
+		}
+	}
+	for v, c := range count {
+		if c != 1 {
+			t.Fatalf("value %d popped %d times", v, c)
+		}
+	}
+	if s.Len() != 0 {
+		t.Errorf("Len=%d after PopAll", s.Len())
+	}
+}
+
+func TestFreeList(t *testing.T) {
+	// Recycle buffers through the stack; a buffer must never be handed to
+	// two goroutines at once.
+	type buf struct {
+		inUse sync.Mutex
+		data  [64]byte
+	}
+	var free Stack[*buf]
+	for range 4 {
+		free.Push(new(buf))
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5000 {
+				b, ok := free.Pop()
+				if !ok {
+					b = new(buf)
+				}
+				if !b.inUse.TryLock() {
+					t.Error("buffer handed out twice")
+					return
+				}
+				b.data[0]++
+				b.inUse.Unlock()
+				free.Push(b)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkPushPop(b *testing.B) {
+	b.Run("lfstack", func(b *testing.B) {
+		var s Stack[int]
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				s.Push(1)
+				s.Pop()
+			}
+		})
+	})
+	b.Run("mutex", func(b *testing.B) {
+		var mu sync.Mutex
+		var s []int
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				mu.Lock()
+				s = append(s, 1)
+				mu.Unlock()
+				mu.Lock()
+				s = s[:len(s)-1]
+				mu.Unlock()
+			}
+		})
+	})
+}
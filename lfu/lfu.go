@@ -0,0 +1,257 @@
+// Package lfu implements a fixed-capacity cache with least-frequently-used
+// eviction.
+package lfu
+
+import (
+	"iter"
+
+	"github.com/eliben/gogl/cache"
+	"github.com/eliben/gogl/list"
+)
+
+// Cache is a cache holding up to a fixed number of entries; when a new entry
+// is added to a full cache, the least frequently used entry is evicted, with
+// ties broken by evicting the least recently used of them. The frequency of
+// an entry is the number of times it was accessed with Get or Put since it
+// was added to the cache. Get, Put and Remove take O(1) time.
+//
+// Alternatively, the capacity can be a budget of user-defined cost (such as
+// bytes) with every entry weighing what a weigher function says it does;
+// entries are then evicted in the same order until the total weight is
+// within the budget.
+//
+// Create caches with [New], [NewWithEvict] or [NewWithWeigher].
+type Cache[K comparable, V any] struct {
+	capacity int
+	weight   int
+	weigher  func(K, V) int
+	index    map[K]*list.Node[*entry[K, V]]
+
+	// buckets holds a bucket for every frequency some entry has, in
+	// ascending order of frequency. Each bucket holds its entries from the
+	// most recently used (front) to the least recently used (back).
+	buckets *list.List[*bucket[K, V]]
+	onEvict func(K, V)
+	rec     cache.Recorder
+}
+
+type entry[K comparable, V any] struct {
+	key    K
+	value  V
+	weight int
+	bucket *list.Node[*bucket[K, V]]
+}
+
+type bucket[K comparable, V any] struct {
+	freq    int
+	entries *list.List[*entry[K, V]]
+}
+
+// New creates a new, empty cache holding up to capacity entries. capacity
+// must be positive.
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	return NewWithEvict[K, V](capacity, nil)
+}
+
+// NewWithEvict is like New, but onEvict is called with the key and value of
+// every entry evicted to make room for a new one. onEvict may be nil.
+func NewWithEvict[K comparable, V any](capacity int, onEvict func(K, V)) *Cache[K, V] {
+	return NewWithWeigher(capacity, nil, onEvict)
+}
+
+// NewWithWeigher creates a new, empty cache whose capacity is a budget for
+// the total weight of its entries, where weigher(key, value) is the weight
+// of an entry; a nil weigher gives every entry a weight of 1. Weights must
+// not be negative. onEvict is as in [NewWithEvict], and may be nil.
+func NewWithWeigher[K comparable, V any](capacity int, weigher func(K, V) int, onEvict func(K, V)) *Cache[K, V] {
+	if capacity <= 0 {
+		panic("lfu: capacity must be positive")
+	}
+	return &Cache[K, V]{
+		capacity: capacity,
+		weigher:  weigher,
+		index:    make(map[K]*list.Node[*entry[K, V]]),
+		buckets:  list.New[*bucket[K, V]](),
+		onEvict:  onEvict,
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	return len(c.index)
+}
+
+// Cap returns the capacity of the cache.
+func (c *Cache[K, V]) Cap() int {
+	return c.capacity
+}
+
+// Weight returns the total weight of the entries in the cache; without a
+// weigher, it's the same as Len.
+func (c *Cache[K, V]) Weight() int {
+	return c.weight
+}
+
+// Get looks up key in the cache, incrementing its frequency. It returns the
+// associated value and ok=true; otherwise, it returns ok=false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	node, ok := c.index[key]
+	c.rec.Lookup(ok)
+	if !ok {
+		return v, false
+	}
+	c.touch(node)
+	return node.Value.value, true
+}
+
+// Peek is like Get, but it doesn't update the frequency of key.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	node, ok := c.index[key]
+	if !ok {
+		return v, false
+	}
+	return node.Value.value, true
+}
+
+// Contains reports whether key is in the cache, without updating its
+// frequency.
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.index[key]
+	return ok
+}
+
+// Frequency returns the frequency of key and ok=true, or ok=false if key
+// isn't in the cache.
+func (c *Cache[K, V]) Frequency(key K) (freq int, ok bool) {
+	node, ok := c.index[key]
+	if !ok {
+		return 0, false
+	}
+	return node.Value.bucket.Value.freq, true
+}
+
+// Put sets the value of key in the cache. An existing key has its frequency
+// incremented; a new key starts with frequency 1. Other entries are evicted
+// as needed to keep the cache within its capacity. An entry weighing more
+// than the whole capacity isn't added; any previous value of key is removed
+// instead.
+func (c *Cache[K, V]) Put(key K, value V) {
+	w := c.weigh(key, value)
+	if w > c.capacity {
+		c.Remove(key)
+		return
+	}
+	node, ok := c.index[key]
+	if ok {
+		c.weight += w - node.Value.weight
+		node.Value.value = value
+		node.Value.weight = w
+		c.touch(node)
+		node = c.index[key]
+	} else {
+		first := c.buckets.Front()
+		if first == nil || first.Value.freq != 1 {
+			first = c.buckets.InsertFront(&bucket[K, V]{freq: 1, entries: list.New[*entry[K, V]]()})
+		}
+		e := &entry[K, V]{key: key, value: value, weight: w, bucket: first}
+		node = first.Value.entries.InsertFront(e)
+		c.index[key] = node
+		c.weight += w
+	}
+	for c.weight > c.capacity {
+		c.evict(node)
+	}
+}
+
+// Remove removes key from the cache. It returns true if key was found, and
+// false otherwise. The eviction callback isn't called for removed entries.
+func (c *Cache[K, V]) Remove(key K) bool {
+	node, ok := c.index[key]
+	if !ok {
+		return false
+	}
+	c.unlink(node)
+	delete(c.index, key)
+	c.weight -= node.Value.weight
+	return true
+}
+
+// Stats returns statistics about the cache's activity since it was created.
+func (c *Cache[K, V]) Stats() cache.Stats {
+	return c.rec.Stats()
+}
+
+// SetHooks sets hooks to be called on cache events; nil removes them.
+func (c *Cache[K, V]) SetHooks(h cache.Hooks) {
+	c.rec.SetHooks(h)
+}
+
+// All returns an iterator over all the entries in the cache, from the most
+// frequently used to the least frequently used; entries with the same
+// frequency are ordered from the most recently used to the least recently
+// used. Iteration doesn't update frequencies.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for b := c.buckets.Back(); b != nil; b = c.buckets.Prev(b) {
+			for e := range b.Value.entries.Values() {
+				if !yield(e.key, e.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// touch moves the entry in node to the bucket of the next frequency.
+func (c *Cache[K, V]) touch(node *list.Node[*entry[K, V]]) {
+	e := node.Value
+	cur := e.bucket
+	next := c.buckets.Next(cur)
+	if next == nil || next.Value.freq != cur.Value.freq+1 {
+		next = c.buckets.InsertAfter(cur, &bucket[K, V]{freq: cur.Value.freq + 1, entries: list.New[*entry[K, V]]()})
+	}
+	c.unlink(node)
+	e.bucket = next
+	c.index[e.key] = next.Value.entries.InsertFront(e)
+}
+
+// unlink removes node from its bucket, removing the bucket if it becomes
+// empty.
+func (c *Cache[K, V]) unlink(node *list.Node[*entry[K, V]]) {
+	b := node.Value.bucket
+	b.Value.entries.Remove(node)
+	if b.Value.entries.Len() == 0 {
+		c.buckets.Remove(b)
+	}
+}
+
+// evict removes the least recently used entry of the lowest frequency,
+// other than keep. There must be such an entry.
+func (c *Cache[K, V]) evict(keep *list.Node[*entry[K, V]]) {
+	b := c.buckets.Front()
+	node := b.Value.entries.Back()
+	if node == keep {
+		if node = b.Value.entries.Prev(node); node == nil {
+			node = c.buckets.Next(b).Value.entries.Back()
+		}
+	}
+	e := node.Value
+	c.unlink(node)
+	delete(c.index, e.key)
+	c.weight -= e.weight
+	c.rec.Evict()
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
+
+func (c *Cache[K, V]) weigh(key K, value V) int {
+	if c.weigher == nil {
+		return 1
+	}
+	w := c.weigher(key, value)
+	if w < 0 {
+		panic("lfu: negative weight")
+	}
+	return w
+}
@@ -0,0 +1,195 @@
+package lfu
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func checkOrder(t *testing.T, c *Cache[string, int], want []string) {
+	t.Helper()
+	var got []string
+	for k := range c.All() {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if c.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", c.Len(), len(want))
+	}
+}
+
+func TestBasic(t *testing.T) {
+	var evicted []string
+	c := NewWithEvict(3, func(k string, v int) { evicted = append(evicted, k) })
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	checkOrder(t, c, []string{"a", "b", "c"})
+	if f, ok := c.Frequency("a"); !ok || f != 3 {
+		t.Errorf("Frequency(a)=%d,%v", f, ok)
+	}
+
+	// c is the least frequently used.
+	c.Put("d", 4)
+	checkOrder(t, c, []string{"a", "b", "d"})
+
+	// b and d now both have frequency 2; d is more recent, so b goes.
+	c.Get("d")
+	c.Put("e", 5)
+	checkOrder(t, c, []string{"a", "d", "e"})
+	if !slices.Equal(evicted, []string{"c", "b"}) {
+		t.Errorf("evicted %v", evicted)
+	}
+
+	// Peek and Contains don't change frequencies.
+	if v, ok := c.Peek("e"); !ok || v != 5 || !c.Contains("e") {
+		t.Errorf("Peek(e)=%d,%v", v, ok)
+	}
+	if f, _ := c.Frequency("e"); f != 1 {
+		t.Errorf("Frequency(e)=%d", f)
+	}
+	if _, ok := c.Frequency("b"); ok {
+		t.Errorf("Frequency of evicted key")
+	}
+
+	if !c.Remove("a") || c.Remove("a") {
+		t.Errorf("bad Remove")
+	}
+	checkOrder(t, c, []string{"d", "e"})
+}
+
+func TestScanResistance(t *testing.T) {
+	// A hot set accessed repeatedly survives a one-off scan that would flush
+	// an LRU cache.
+	c := New[int, int](10)
+	for range 5 {
+		for k := range 5 {
+			c.Put(k, k)
+		}
+	}
+	for k := 100; k < 200; k++ {
+		c.Put(k, k)
+	}
+	for k := range 5 {
+		if !c.Contains(k) {
+			t.Errorf("hot key %d was evicted", k)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const capacity = 8
+	c := New[string, int](capacity)
+
+	// The mirror tracks each key's value, frequency and the logical time of
+	// its last use.
+	type state struct {
+		v, freq, used int
+	}
+	mirror := make(map[string]*state)
+	clock := 0
+	for range 5000 {
+		clock++
+		k := string(rune('a' + rnd.IntN(15)))
+		s := mirror[k]
+		switch rnd.IntN(4) {
+		case 0:
+			v, ok := c.Get(k)
+			if ok != (s != nil) || (ok && v != s.v) {
+				t.Fatalf("Get(%q)=%d,%v", k, v, ok)
+			}
+			if ok {
+				s.freq++
+				s.used = clock
+			}
+		case 1:
+			if c.Remove(k) != (s != nil) {
+				t.Fatalf("Remove(%q) mismatch", k)
+			}
+			delete(mirror, k)
+		default:
+			v := rnd.Int()
+			c.Put(k, v)
+			if s != nil {
+				s.v = v
+				s.freq++
+				s.used = clock
+				break
+			}
+			if len(mirror) == capacity {
+				var victim string
+				for mk, ms := range mirror {
+					if vs := mirror[victim]; vs == nil || ms.freq < vs.freq || (ms.freq == vs.freq && ms.used < vs.used) {
+						victim = mk
+					}
+				}
+				delete(mirror, victim)
+			}
+			mirror[k] = &state{v: v, freq: 1, used: clock}
+		}
+
+		if c.Len() != len(mirror) {
+			t.Fatalf("got Len=%d, want %d", c.Len(), len(mirror))
+		}
+		for k, v := range c.All() {
+			s := mirror[k]
+			if s == nil || s.v != v {
+				t.Fatalf("unexpected entry %q=%d", k, v)
+			}
+			if f, _ := c.Frequency(k); f != s.freq {
+				t.Fatalf("Frequency(%q)=%d, want %d", k, f, s.freq)
+			}
+		}
+	}
+}
+
+func TestWeigher(t *testing.T) {
+	var evicted []string
+	c := NewWithWeigher(100,
+		func(k string, v string) int { return len(v) },
+		func(k string, v string) { evicted = append(evicted, k) })
+
+	c.Put("a", strings.Repeat("x", 30))
+	c.Put("b", strings.Repeat("x", 30))
+	c.Put("c", strings.Repeat("x", 30))
+	c.Get("a")
+	c.Get("c")
+	if c.Weight() != 90 {
+		t.Errorf("Weight=%d", c.Weight())
+	}
+
+	// b has the lowest frequency; after it, c and a tie on frequency and c
+	// is more recent.
+	c.Put("d", strings.Repeat("x", 60))
+	if !slices.Equal(evicted, []string{"b", "a"}) || c.Weight() != 90 {
+		t.Errorf("evicted %v, Weight=%d", evicted, c.Weight())
+	}
+
+	// The only entry with frequency 1 is the one being added, so it's not
+	// the victim even though it's the least frequently used.
+	c.Get("d")
+	c.Get("c")
+	c.Put("e", strings.Repeat("x", 20))
+	if !slices.Equal(evicted, []string{"b", "a", "d"}) || c.Weight() != 50 || !c.Contains("e") {
+		t.Errorf("evicted %v, Weight=%d", evicted, c.Weight())
+	}
+
+	c.Put("g", strings.Repeat("x", 101))
+	if c.Contains("g") || c.Len() != 2 {
+		t.Errorf("oversized entry cached")
+	}
+	if !c.Remove("c") || c.Weight() != 20 {
+		t.Errorf("Weight=%d after Remove", c.Weight())
+	}
+}
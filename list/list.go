@@ -74,15 +74,17 @@ func (lst *List[T]) Prev(node *Node[T]) *Node[T] {
 	return nil
 }
 
-// InsertFront inserts a new node with the given value at the front of the list.
-func (lst *List[T]) InsertFront(val T) {
-	lst.InsertAfter(lst.front, val)
+// InsertFront inserts a new node with the given value at the front of the
+// list, and returns the new node.
+func (lst *List[T]) InsertFront(val T) *Node[T] {
+	return lst.InsertAfter(lst.front, val)
 }
 
-// InsertBack inserts a new node with the given value at the back of the list.
-func (lst *List[T]) InsertBack(val T) {
+// InsertBack inserts a new node with the given value at the back of the list,
+// and returns the new node.
+func (lst *List[T]) InsertBack(val T) *Node[T] {
 	oldLast := lst.back.prev
-	lst.InsertAfter(oldLast, val)
+	return lst.InsertAfter(oldLast, val)
 }
 
 // InsertAfter inserts a new node with the given value after `node`.
@@ -113,6 +115,30 @@ func (lst *List[T]) Remove(node *Node[T]) {
 	lst.length--
 }
 
+// MoveToFront moves `node` to the front of the list, without allocating.
+func (lst *List[T]) MoveToFront(node *Node[T]) {
+	lst.moveAfter(node, lst.front)
+}
+
+// MoveToBack moves `node` to the back of the list, without allocating.
+func (lst *List[T]) MoveToBack(node *Node[T]) {
+	lst.moveAfter(node, lst.back.prev)
+}
+
+// moveAfter unlinks node and links it back in after mark.
+func (lst *List[T]) moveAfter(node, mark *Node[T]) {
+	if node == mark || mark.next == node {
+		return
+	}
+	node.prev.next = node.next
+	node.next.prev = node.prev
+
+	node.prev = mark
+	node.next = mark.next
+	mark.next.prev = node
+	mark.next = node
+}
+
 // Values returns an iterator over all the values in the list.
 func (lst *List[T]) Values() iter.Seq[T] {
 	return func(yield func(T) bool) {
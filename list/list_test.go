@@ -201,3 +201,31 @@ func TestEachBeforeAfterAndRemove(t *testing.T) {
 		checkList(t, nl, wantSlice)
 	}
 }
+
+func TestMoveToFrontBack(t *testing.T) {
+	nl := New[int]()
+	var nodes []*Node[int]
+	for i := range 4 {
+		nodes = append(nodes, nl.InsertBack(i))
+	}
+	checkList(t, nl, []int{0, 1, 2, 3})
+
+	nl.MoveToFront(nodes[2])
+	checkList(t, nl, []int{2, 0, 1, 3})
+	nl.MoveToFront(nodes[2])
+	checkList(t, nl, []int{2, 0, 1, 3})
+	nl.MoveToBack(nodes[0])
+	checkList(t, nl, []int{2, 1, 3, 0})
+	nl.MoveToBack(nodes[0])
+	checkList(t, nl, []int{2, 1, 3, 0})
+	nl.MoveToFront(nodes[0])
+	checkList(t, nl, []int{0, 2, 1, 3})
+	nl.MoveToBack(nodes[0])
+	checkList(t, nl, []int{2, 1, 3, 0})
+
+	single := New[string]()
+	n := single.InsertBack("x")
+	single.MoveToFront(n)
+	single.MoveToBack(n)
+	checkList(t, single, []string{"x"})
+}
@@ -0,0 +1,215 @@
+// Package loadingcache implements a concurrency-safe cache that loads
+// missing values on demand, suppressing duplicate loads.
+package loadingcache
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eliben/gogl/cache"
+	"github.com/eliben/gogl/lru"
+)
+
+// ErrLoaderPanicked is returned to callers waiting for a load whose loader
+// panicked; the panic itself propagates in the goroutine that ran the
+// loader.
+var ErrLoaderPanicked = errors.New("loadingcache: loader panicked")
+
+// Cache wraps a [cache.Cache] backend, adding [Cache.GetOrLoad]: a lookup
+// that calls a loader function on a miss and stores its result. Concurrent
+// GetOrLoad calls for the same missing key share a single call to the
+// loader, so a popular key expiring doesn't cause a thundering herd of
+// loads.
+//
+// Optionally, loader errors can be cached too ("negative caching"), so that
+// a failing key isn't reloaded on every access.
+//
+// A Cache is safe for concurrent use by multiple goroutines; the backend
+// must not be used directly once it's wrapped. Create caches with [New] or
+// [NewWithNegativeCaching].
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	backend  cache.Cache[K, V]
+	inflight map[K]*call[V]
+
+	// negative caches loader errors; it's nil if negative caching is
+	// disabled.
+	negative    *lru.Cache[K, failure]
+	negativeTTL time.Duration
+
+	// rec records loads; the backend records its own events.
+	rec cache.Recorder
+
+	// now returns the current time; it's replaced in tests.
+	now func() time.Time
+}
+
+// call is an in-flight or completed load.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+
+	// forgotten is set if the key is removed or put while the load is in
+	// flight, in which case the loaded result is not stored.
+	forgotten bool
+}
+
+// failure is a cached loader error.
+type failure struct {
+	err     error
+	expires time.Time
+}
+
+// New creates a new loading cache storing values in backend.
+func New[K comparable, V any](backend cache.Cache[K, V]) *Cache[K, V] {
+	return &Cache[K, V]{
+		backend:  backend,
+		inflight: make(map[K]*call[V]),
+		now:      time.Now,
+	}
+}
+
+// NewWithNegativeCaching is like New, but errors returned by loaders are
+// cached for ttl: GetOrLoad returns a cached error without calling the
+// loader again until it expires. Up to capacity errors are cached, with
+// least recently used eviction.
+func NewWithNegativeCaching[K comparable, V any](backend cache.Cache[K, V], ttl time.Duration, capacity int) *Cache[K, V] {
+	c := New(backend)
+	c.negative = lru.New[K, failure](capacity)
+	c.negativeTTL = ttl
+	return c
+}
+
+// Len returns the number of values in the cache; cached errors are not
+// counted.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.backend.Len()
+}
+
+// Get looks up key in the cache without loading it. It returns the
+// associated value and ok=true; otherwise, it returns ok=false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.backend.Get(key)
+}
+
+// Put sets the value of key in the cache, clearing any cached error for it.
+// An in-flight load of key is not stored when it completes.
+func (c *Cache[K, V]) Put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forget(key)
+	c.backend.Put(key, value)
+}
+
+// Remove removes key and any cached error for it from the cache. It returns
+// true if a value was found, and false otherwise. An in-flight load of key
+// is not stored when it completes.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forget(key)
+	return c.backend.Remove(key)
+}
+
+// Stats returns statistics about the cache's activity since it was created:
+// the backend's statistics, with the loads performed by GetOrLoad.
+func (c *Cache[K, V]) Stats() cache.Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.backend.Stats()
+	loads := c.rec.Stats()
+	s.Loads, s.LoadErrors, s.LoadTime = loads.Loads, loads.LoadErrors, loads.LoadTime
+	return s
+}
+
+// SetHooks sets hooks to be called on cache events, for both the loading
+// cache and its backend; nil removes them. Hooks are called with the
+// cache's lock held.
+func (c *Cache[K, V]) SetHooks(h cache.Hooks) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backend.SetHooks(h)
+	c.rec.SetHooks(h)
+}
+
+// GetOrLoad returns the value of key, loading it with loader if it's not in
+// the cache. If another GetOrLoad of key is already loading it, GetOrLoad
+// waits for that load and returns its result instead of calling loader.
+//
+// A successfully loaded value is stored in the cache. If loader returns an
+// error, GetOrLoad returns it, and with negative caching enabled the error
+// is returned for key until it expires.
+func (c *Cache[K, V]) GetOrLoad(key K, loader func(K) (V, error)) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.backend.Get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	if c.negative != nil {
+		if f, ok := c.negative.Get(key); ok {
+			if c.now().Before(f.expires) {
+				c.mu.Unlock()
+				return *new(V), f.err
+			}
+			c.negative.Remove(key)
+		}
+	}
+	if cl, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		<-cl.done
+		return cl.value, cl.err
+	}
+	cl := &call[V]{done: make(chan struct{})}
+	c.inflight[key] = cl
+	c.mu.Unlock()
+
+	c.load(key, cl, loader)
+	return cl.value, cl.err
+}
+
+// load runs loader for key, and publishes its result through cl.
+func (c *Cache[K, V]) load(key K, cl *call[V], loader func(K) (V, error)) {
+	finished := false
+	start := time.Now()
+	defer func() {
+		if !finished {
+			cl.err = ErrLoaderPanicked
+		}
+		elapsed := time.Since(start)
+		c.mu.Lock()
+		c.rec.Load(elapsed, cl.err)
+		if c.inflight[key] == cl {
+			delete(c.inflight, key)
+		}
+		if finished && !cl.forgotten {
+			if cl.err == nil {
+				c.backend.Put(key, cl.value)
+			} else if c.negative != nil {
+				c.negative.Put(key, failure{err: cl.err, expires: c.now().Add(c.negativeTTL)})
+			}
+		}
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+
+	cl.value, cl.err = loader(key)
+	finished = true
+}
+
+// forget clears the cached error of key, and marks its in-flight load as
+// not to be stored. It's called with c.mu held.
+func (c *Cache[K, V]) forget(key K) {
+	if cl, ok := c.inflight[key]; ok {
+		cl.forgotten = true
+		delete(c.inflight, key)
+	}
+	if c.negative != nil {
+		c.negative.Remove(key)
+	}
+}
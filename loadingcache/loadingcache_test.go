@@ -0,0 +1,211 @@
+package loadingcache
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eliben/gogl/lru"
+)
+
+// waitForMisses blocks until n lookups have missed c's backend. GetOrLoad
+// starts or joins a load of a key while holding the lock it looked the key
+// up with, so this waits until n calls are loading or waiting for a load.
+func waitForMisses[K comparable, V any](c *Cache[K, V], n uint64) {
+	for c.Stats().Misses < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c := New[int, string](lru.New[int, string](10))
+	loads := 0
+	loader := func(k int) (string, error) {
+		loads++
+		return strconv.Itoa(k), nil
+	}
+	for range 3 {
+		v, err := c.GetOrLoad(42, loader)
+		if err != nil || v != "42" {
+			t.Fatalf("GetOrLoad=%q,%v", v, err)
+		}
+	}
+	if loads != 1 || c.Len() != 1 {
+		t.Errorf("loads=%d Len=%d", loads, c.Len())
+	}
+
+	c.Put(7, "seven")
+	if v, _ := c.GetOrLoad(7, loader); v != "seven" || loads != 1 {
+		t.Errorf("got %q, loads=%d", v, loads)
+	}
+	if !c.Remove(7) || c.Remove(7) {
+		t.Errorf("bad Remove")
+	}
+	if v, _ := c.GetOrLoad(7, loader); v != "7" || loads != 2 {
+		t.Errorf("got %q, loads=%d", v, loads)
+	}
+	if v, ok := c.Get(7); !ok || v != "7" {
+		t.Errorf("Get(7)=%q,%v", v, ok)
+	}
+}
+
+func TestDuplicateSuppression(t *testing.T) {
+	c := New[string, int](lru.New[string, int](10))
+	var loads atomic.Int32
+	release := make(chan struct{})
+	loader := func(k string) (int, error) {
+		loads.Add(1)
+		<-release
+		return len(k), nil
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	results := make([]int, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad("hello", loader)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = v
+		}()
+	}
+	waitForMisses(c, n)
+	close(release)
+	wg.Wait()
+
+	if got := loads.Load(); got != 1 {
+		t.Errorf("loader called %d times", got)
+	}
+	for _, v := range results {
+		if v != 5 {
+			t.Fatalf("got %d", v)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	calls := 0
+	loader := func(k int) (int, error) {
+		calls++
+		return 0, errBoom
+	}
+
+	// Without negative caching, every call reloads.
+	c := New[int, int](lru.New[int, int](10))
+	for range 3 {
+		if _, err := c.GetOrLoad(1, loader); err != errBoom {
+			t.Fatalf("got err %v", err)
+		}
+	}
+	if calls != 3 || c.Len() != 0 {
+		t.Errorf("calls=%d Len=%d", calls, c.Len())
+	}
+
+	calls = 0
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c = NewWithNegativeCaching[int, int](lru.New[int, int](10), time.Minute, 10)
+	c.now = func() time.Time { return now }
+	for range 3 {
+		if _, err := c.GetOrLoad(1, loader); err != errBoom {
+			t.Fatalf("got err %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("calls=%d with negative caching", calls)
+	}
+	now = now.Add(time.Minute)
+	c.GetOrLoad(1, loader)
+	if calls != 2 {
+		t.Errorf("calls=%d after negative entry expired", calls)
+	}
+
+	// Put and Remove clear the cached error.
+	c.Remove(1)
+	c.GetOrLoad(1, loader)
+	if calls != 3 {
+		t.Errorf("calls=%d after Remove", calls)
+	}
+	c.Put(1, 100)
+	if v, err := c.GetOrLoad(1, loader); err != nil || v != 100 || calls != 3 {
+		t.Errorf("got %d,%v calls=%d", v, err, calls)
+	}
+}
+
+func TestRemoveDuringLoad(t *testing.T) {
+	c := New[int, int](lru.New[int, int](10))
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := c.GetOrLoad(1, func(int) (int, error) {
+			<-release
+			return 10, nil
+		})
+		if v != 10 || err != nil {
+			t.Errorf("got %d,%v", v, err)
+		}
+	}()
+	waitForMisses(c, 1)
+	c.Remove(1)
+	close(release)
+	<-done
+
+	// The stale load result wasn't stored.
+	if _, ok := c.Get(1); ok {
+		t.Errorf("stale load result stored")
+	}
+}
+
+func TestLoaderPanic(t *testing.T) {
+	c := New[int, int](lru.New[int, int](10))
+	release := make(chan struct{})
+	waiterErr := make(chan error)
+
+	go func() {
+		defer func() { recover() }()
+		c.GetOrLoad(1, func(int) (int, error) {
+			<-release
+			panic("loader failed")
+		})
+	}()
+	waitForMisses(c, 1)
+	go func() {
+		_, err := c.GetOrLoad(1, func(int) (int, error) { return 2, nil })
+		waiterErr <- err
+	}()
+	waitForMisses(c, 2)
+	close(release)
+	if err := <-waiterErr; err != ErrLoaderPanicked {
+		t.Errorf("got err %v", err)
+	}
+
+	// Later loads work normally.
+	if v, err := c.GetOrLoad(1, func(int) (int, error) { return 3, nil }); v != 3 || err != nil {
+		t.Errorf("got %d,%v", v, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := New[int, int](lru.New[int, int](1))
+	errBoom := errors.New("boom")
+	c.GetOrLoad(1, func(int) (int, error) { return 1, nil })
+	c.GetOrLoad(1, func(int) (int, error) { return 1, nil })
+	c.GetOrLoad(2, func(int) (int, error) { return 0, errBoom })
+	c.GetOrLoad(3, func(int) (int, error) { return 3, nil })
+
+	s := c.Stats()
+	if s.Hits != 1 || s.Misses != 3 || s.Evictions != 1 || s.Loads != 3 || s.LoadErrors != 1 {
+		t.Errorf("got %+v", s)
+	}
+	if s.LoadTime <= 0 {
+		t.Errorf("LoadTime=%v", s.LoadTime)
+	}
+}
@@ -0,0 +1,198 @@
+// Package lru implements a fixed-capacity cache with least-recently-used
+// eviction.
+package lru
+
+import (
+	"iter"
+
+	"github.com/eliben/gogl/cache"
+	"github.com/eliben/gogl/list"
+)
+
+// Cache is a cache holding up to a fixed number of entries; when a new entry
+// is added to a full cache, the least recently used entry is evicted. Get,
+// Put and Remove take O(1) time.
+//
+// Alternatively, the capacity can be a budget of user-defined cost (such as
+// bytes) with every entry weighing what a weigher function says it does;
+// least recently used entries are then evicted until the total weight is
+// within the budget.
+//
+// Create caches with [New], [NewWithEvict] or [NewWithWeigher].
+type Cache[K comparable, V any] struct {
+	capacity int
+	weight   int
+	weigher  func(K, V) int
+	index    map[K]*list.Node[entry[K, V]]
+
+	// recency holds the entries from the most recently used (front) to the
+	// least recently used (back).
+	recency *list.List[entry[K, V]]
+	onEvict func(K, V)
+	rec     cache.Recorder
+}
+
+type entry[K comparable, V any] struct {
+	key    K
+	value  V
+	weight int
+}
+
+// New creates a new, empty cache holding up to capacity entries. capacity
+// must be positive.
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	return NewWithEvict[K, V](capacity, nil)
+}
+
+// NewWithEvict is like New, but onEvict is called with the key and value of
+// every entry evicted to make room for a new one. onEvict may be nil.
+func NewWithEvict[K comparable, V any](capacity int, onEvict func(K, V)) *Cache[K, V] {
+	return NewWithWeigher(capacity, nil, onEvict)
+}
+
+// NewWithWeigher creates a new, empty cache whose capacity is a budget for
+// the total weight of its entries, where weigher(key, value) is the weight
+// of an entry; a nil weigher gives every entry a weight of 1. Weights must
+// not be negative. onEvict is as in [NewWithEvict], and may be nil.
+func NewWithWeigher[K comparable, V any](capacity int, weigher func(K, V) int, onEvict func(K, V)) *Cache[K, V] {
+	if capacity <= 0 {
+		panic("lru: capacity must be positive")
+	}
+	return &Cache[K, V]{
+		capacity: capacity,
+		weigher:  weigher,
+		index:    make(map[K]*list.Node[entry[K, V]]),
+		recency:  list.New[entry[K, V]](),
+		onEvict:  onEvict,
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	return len(c.index)
+}
+
+// Cap returns the capacity of the cache.
+func (c *Cache[K, V]) Cap() int {
+	return c.capacity
+}
+
+// Weight returns the total weight of the entries in the cache; without a
+// weigher, it's the same as Len.
+func (c *Cache[K, V]) Weight() int {
+	return c.weight
+}
+
+// Get looks up key in the cache, marking it as the most recently used
+// entry. It returns the associated value and ok=true; otherwise, it returns
+// ok=false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	node, ok := c.index[key]
+	c.rec.Lookup(ok)
+	if !ok {
+		return v, false
+	}
+	c.recency.MoveToFront(node)
+	return node.Value.value, true
+}
+
+// Peek is like Get, but it doesn't update the recency of key.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	node, ok := c.index[key]
+	if !ok {
+		return v, false
+	}
+	return node.Value.value, true
+}
+
+// Contains reports whether key is in the cache, without updating its
+// recency.
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.index[key]
+	return ok
+}
+
+// Put sets the value of key in the cache, marking it as the most recently
+// used entry. Least recently used entries are evicted as needed to keep the
+// cache within its capacity. An entry weighing more than the whole capacity
+// isn't added; any previous value of key is removed instead.
+func (c *Cache[K, V]) Put(key K, value V) {
+	w := c.weigh(key, value)
+	if w > c.capacity {
+		c.Remove(key)
+		return
+	}
+	if node, ok := c.index[key]; ok {
+		c.weight += w - node.Value.weight
+		node.Value.value = value
+		node.Value.weight = w
+		c.recency.MoveToFront(node)
+	} else {
+		c.weight += w
+		c.index[key] = c.recency.InsertFront(entry[K, V]{key: key, value: value, weight: w})
+	}
+	// The entry for key is at the front, and fits on its own, so it's never
+	// evicted here.
+	for c.weight > c.capacity {
+		c.evict()
+	}
+}
+
+// Remove removes key from the cache. It returns true if key was found, and
+// false otherwise. The eviction callback isn't called for removed entries.
+func (c *Cache[K, V]) Remove(key K) bool {
+	node, ok := c.index[key]
+	if !ok {
+		return false
+	}
+	c.recency.Remove(node)
+	delete(c.index, key)
+	c.weight -= node.Value.weight
+	return true
+}
+
+// Stats returns statistics about the cache's activity since it was created.
+func (c *Cache[K, V]) Stats() cache.Stats {
+	return c.rec.Stats()
+}
+
+// SetHooks sets hooks to be called on cache events; nil removes them.
+func (c *Cache[K, V]) SetHooks(h cache.Hooks) {
+	c.rec.SetHooks(h)
+}
+
+// All returns an iterator over all the entries in the cache, from the most
+// recently used to the least recently used. Iteration doesn't update
+// recency.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for e := range c.recency.Values() {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// evict removes the least recently used entry.
+func (c *Cache[K, V]) evict() {
+	node := c.recency.Back()
+	c.recency.Remove(node)
+	delete(c.index, node.Value.key)
+	c.weight -= node.Value.weight
+	c.rec.Evict()
+	if c.onEvict != nil {
+		c.onEvict(node.Value.key, node.Value.value)
+	}
+}
+
+func (c *Cache[K, V]) weigh(key K, value V) int {
+	if c.weigher == nil {
+		return 1
+	}
+	w := c.weigher(key, value)
+	if w < 0 {
+		panic("lru: negative weight")
+	}
+	return w
+}
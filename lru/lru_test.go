@@ -0,0 +1,213 @@
+package lru
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// checkOrder verifies that the cache's keys, from most to least recently
+// used, are want.
+func checkOrder(t *testing.T, c *Cache[string, int], want []string) {
+	t.Helper()
+	var got []string
+	for k := range c.All() {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if c.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", c.Len(), len(want))
+	}
+}
+
+func TestBasic(t *testing.T) {
+	var evicted []string
+	c := NewWithEvict(3, func(k string, v int) { evicted = append(evicted, k) })
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	checkOrder(t, c, []string{"c", "b", "a"})
+
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a)=%d,%v", v, ok)
+	}
+	checkOrder(t, c, []string{"a", "c", "b"})
+
+	// Peek and Contains don't change the order.
+	if v, ok := c.Peek("b"); !ok || v != 2 || !c.Contains("c") {
+		t.Errorf("Peek(b)=%d,%v", v, ok)
+	}
+	checkOrder(t, c, []string{"a", "c", "b"})
+
+	c.Put("d", 4)
+	checkOrder(t, c, []string{"d", "a", "c"})
+	if !slices.Equal(evicted, []string{"b"}) {
+		t.Errorf("evicted %v", evicted)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("found evicted key")
+	}
+
+	c.Put("c", 30)
+	checkOrder(t, c, []string{"c", "d", "a"})
+	if v, _ := c.Peek("c"); v != 30 {
+		t.Errorf("got %d", v)
+	}
+
+	if !c.Remove("d") || c.Remove("d") {
+		t.Errorf("bad Remove")
+	}
+	checkOrder(t, c, []string{"c", "a"})
+	if len(evicted) != 1 || c.Cap() != 3 {
+		t.Errorf("evicted %v, Cap=%d", evicted, c.Cap())
+	}
+}
+
+func TestBadCapacity(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	New[int, int](0)
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const capacity = 8
+	c := New[string, int](capacity)
+	// The mirror is a slice of entries from most to least recently used.
+	type kv struct {
+		k string
+		v int
+	}
+	var mirror []kv
+	find := func(k string) int {
+		return slices.IndexFunc(mirror, func(e kv) bool { return e.k == k })
+	}
+	for range 5000 {
+		k := string(rune('a' + rnd.IntN(15)))
+		i := find(k)
+		switch rnd.IntN(4) {
+		case 0:
+			v, ok := c.Get(k)
+			if ok != (i >= 0) || (ok && v != mirror[i].v) {
+				t.Fatalf("Get(%q)=%d,%v", k, v, ok)
+			}
+			if ok {
+				e := mirror[i]
+				mirror = slices.Insert(slices.Delete(mirror, i, i+1), 0, e)
+			}
+		case 1:
+			if c.Remove(k) != (i >= 0) {
+				t.Fatalf("Remove(%q) mismatch", k)
+			}
+			if i >= 0 {
+				mirror = slices.Delete(mirror, i, i+1)
+			}
+		default:
+			v := rnd.Int()
+			c.Put(k, v)
+			if i >= 0 {
+				mirror = slices.Delete(mirror, i, i+1)
+			} else if len(mirror) == capacity {
+				mirror = mirror[:capacity-1]
+			}
+			mirror = slices.Insert(mirror, 0, kv{k, v})
+		}
+
+		var got []kv
+		for k, v := range c.All() {
+			got = append(got, kv{k, v})
+		}
+		if !slices.Equal(got, mirror) {
+			t.Fatalf("got %v, want %v", got, mirror)
+		}
+	}
+}
+
+func TestWeigher(t *testing.T) {
+	var evicted []string
+	c := NewWithWeigher(100,
+		func(k string, v []byte) int { return len(v) },
+		func(k string, v []byte) { evicted = append(evicted, k) })
+
+	c.Put("a", make([]byte, 40))
+	c.Put("b", make([]byte, 40))
+	c.Put("c", make([]byte, 10))
+	if c.Weight() != 90 || c.Len() != 3 {
+		t.Errorf("Weight=%d Len=%d", c.Weight(), c.Len())
+	}
+
+	// Evicts a, then b: both are needed to make room.
+	c.Get("c")
+	c.Put("d", make([]byte, 80))
+	if !slices.Equal(evicted, []string{"a", "b"}) || c.Weight() != 90 {
+		t.Errorf("evicted %v, Weight=%d", evicted, c.Weight())
+	}
+
+	// Growing an existing entry evicts others, but never itself.
+	c.Put("c", make([]byte, 30))
+	if !slices.Equal(evicted, []string{"a", "b", "d"}) || c.Weight() != 30 {
+		t.Errorf("evicted %v, Weight=%d", evicted, c.Weight())
+	}
+
+	// An entry larger than the whole budget isn't cached, and replaces
+	// nothing else.
+	c.Put("huge", make([]byte, 101))
+	c.Put("c", make([]byte, 101))
+	if c.Contains("huge") || c.Contains("c") || c.Len() != 0 || c.Weight() != 0 {
+		t.Errorf("oversized entry cached; Len=%d Weight=%d", c.Len(), c.Weight())
+	}
+	if len(evicted) != 3 {
+		t.Errorf("evicted %v", evicted)
+	}
+}
+
+func TestWeigherRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const budget = 50
+	c := NewWithWeigher[int, int](budget, func(k, v int) int { return v }, nil)
+	// The mirror is a slice of keys from most to least recently used, with
+	// their weights as values.
+	type kv struct{ k, v int }
+	var mirror []kv
+	for range 5000 {
+		k, w := rnd.IntN(20), rnd.IntN(budget+5)
+		i := slices.IndexFunc(mirror, func(e kv) bool { return e.k == k })
+		if i >= 0 {
+			mirror = slices.Delete(mirror, i, i+1)
+		}
+		c.Put(k, w)
+		if w <= budget {
+			mirror = slices.Insert(mirror, 0, kv{k, w})
+			total := 0
+			for j, e := range mirror {
+				if total+e.v > budget {
+					mirror = mirror[:j]
+					break
+				}
+				total += e.v
+			}
+		}
+
+		var got []kv
+		total := 0
+		for k, v := range c.All() {
+			got = append(got, kv{k, v})
+			total += v
+		}
+		if !slices.Equal(got, mirror) || c.Weight() != total {
+			t.Fatalf("got %v (weight %d), want %v", got, c.Weight(), mirror)
+		}
+	}
+}
@@ -0,0 +1,712 @@
+// Package lsm implements an embedded key-value store as a log-structured
+// merge tree.
+package lsm
+
+import (
+	"bytes"
+	"errors"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/eliben/gogl/pager"
+	"github.com/eliben/gogl/skiplist"
+)
+
+// DB is a key-value store of byte-slice keys and values, persisted in a
+// directory as a log-structured merge (LSM) tree. Writes go to an in-memory
+// memtable, a skip list sorted by key; when it fills up, it's flushed to a
+// sorted, immutable table file in level 0, and a new memtable takes its
+// place. Background compactions merge the tables of each level into larger
+// sorted runs in the next level, which is [Options.LevelRatio] times as
+// large, dropping overwritten and deleted entries on the way. Reads merge
+// the memtables and tables, the newest entry of a key taking precedence;
+// each table has a Bloom filter, so looking up a key only reads the tables
+// that are likely to hold it.
+//
+// Writes are durable once the memtable holding them is flushed, by
+// [DB.Flush], [DB.Close] or when it's full; the writes in the memtable are
+// lost if the process crashes before then. Flushes and compactions
+// themselves are atomic: a crash leaves the database as it was before or
+// after them.
+//
+// A DB is safe for concurrent use by multiple goroutines, but a directory
+// must only be opened by one DB at a time. Open databases with [Open].
+type DB struct {
+	dir  string
+	opts Options
+
+	mu sync.Mutex
+
+	// cond is broadcast when background work is requested or done.
+	cond *sync.Cond
+
+	// mem is the memtable receiving writes; imm is the full memtable being
+	// flushed, or nil.
+	mem, imm *memtable
+
+	// cur is the current version; nextNum is the number of the next table
+	// file.
+	cur     *version
+	nextNum uint64
+
+	// compactKey[l] is the largest key of the last table of level l that was
+	// compacted; compactions of each level cycle through its key range.
+	compactKey [numLevels][]byte
+
+	// bgErr is the error of a failed flush or compaction; it makes the
+	// database read-only.
+	bgErr  error
+	closed bool
+
+	// done is closed when the background goroutine exits.
+	done chan struct{}
+}
+
+// Options configure a DB. Zero fields are replaced by their defaults.
+type Options struct {
+	// MemtableSize is the size, in bytes of keys and values, above which
+	// the memtable is flushed to a table. The default is 4 MiB.
+	MemtableSize int
+
+	// TableSize is the size above which compactions start a new table. The
+	// default is 2 MiB.
+	TableSize int
+
+	// L0Tables is the number of tables in level 0 that triggers their
+	// compaction into level 1. The default is 4.
+	L0Tables int
+
+	// LevelRatio is the ratio between the sizes of consecutive levels from
+	// level 1, which holds up to LevelRatio tables of TableSize. The default
+	// is 10.
+	LevelRatio int
+
+	// OpenPager opens the pager storing the table file at path, creating
+	// it if it doesn't exist. The pager must keep its data in that file,
+	// which is deleted with the table; it may, for example, encrypt its
+	// pages. The default opens a [pager.File] with pages of
+	// [pager.DefaultPageSize] bytes.
+	OpenPager func(path string) (pager.Pager, error)
+
+	// PageCache caches the pages of tables read by the database; it may be
+	// shared with other databases and structures. The default is a cache
+	// of 2048 pages for the database.
+	PageCache *pager.Cache
+}
+
+// KV is a key-value pair of a DB.
+type KV struct {
+	Key, Value []byte
+}
+
+var (
+	// ErrInvalidData is returned when a file of the database isn't valid,
+	// such as when it's corrupted.
+	ErrInvalidData = errors.New("lsm: invalid database file")
+
+	// ErrClosed is returned when using a closed DB.
+	ErrClosed = errors.New("lsm: database is closed")
+)
+
+const (
+	// recordOverhead is the memory used by a memtable entry beyond its key
+	// and value, for accounting its size.
+	recordOverhead = 64
+
+	// l0StopFactor is the factor of Options.L0Tables above which writes wait
+	// for level 0 to be compacted.
+	l0StopFactor = 3
+)
+
+// entry is the value of a key in a memtable or table: its value, or a
+// tombstone marking it as deleted.
+type entry struct {
+	value   []byte
+	deleted bool
+}
+
+type memtable struct {
+	sl   *skiplist.SkipList[[]byte, entry]
+	size int
+}
+
+func newMemtable() *memtable {
+	return &memtable{sl: skiplist.New[[]byte, entry](bytes.Compare)}
+}
+
+// records returns the records of m with keys in [lo, hi); nil bounds are
+// unbounded.
+func (m *memtable) records(lo, hi []byte) []record {
+	var records []record
+	for k, e := range m.sl.All() {
+		if lo != nil && bytes.Compare(k, lo) < 0 {
+			continue
+		}
+		if hi != nil && bytes.Compare(k, hi) >= 0 {
+			break
+		}
+		records = append(records, record{k, e})
+	}
+	return records
+}
+
+// Open opens the database in dir, creating it if it doesn't exist. opts may
+// be nil to use the default options.
+func Open(dir string, opts *Options) (*DB, error) {
+	db := &DB{dir: dir, mem: newMemtable(), done: make(chan struct{})}
+	if opts != nil {
+		db.opts = *opts
+	}
+	db.opts.setDefaults()
+	db.cond = sync.NewCond(&db.mu)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	levels, nextNum, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	db.nextNum = nextNum
+	db.cur = &version{refs: 1}
+	live := make(map[uint64]bool)
+	for l, nums := range levels {
+		for _, num := range nums {
+			t, err := db.openTable(num)
+			if err != nil {
+				db.closeTables()
+				return nil, err
+			}
+			t.refs = 1
+			db.cur.levels[l] = append(db.cur.levels[l], t)
+			live[num] = true
+		}
+	}
+
+	// Remove the files left over by flushes and compactions interrupted by
+	// a crash.
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		db.closeTables()
+		return nil, err
+	}
+	for _, f := range files {
+		if num, ok := parseTableName(f.Name()); ok && !live[num] || f.Name() == manifestName+".tmp" {
+			os.Remove(filepath.Join(dir, f.Name()))
+		}
+	}
+
+	go db.background()
+	return db, nil
+}
+
+func (o *Options) setDefaults() {
+	if o.MemtableSize <= 0 {
+		o.MemtableSize = 4 << 20
+	}
+	if o.TableSize <= 0 {
+		o.TableSize = 2 << 20
+	}
+	if o.L0Tables <= 0 {
+		o.L0Tables = 4
+	}
+	if o.LevelRatio <= 1 {
+		o.LevelRatio = 10
+	}
+	if o.OpenPager == nil {
+		o.OpenPager = func(path string) (pager.Pager, error) {
+			return pager.OpenFile(path, pager.DefaultPageSize)
+		}
+	}
+	if o.PageCache == nil {
+		o.PageCache = pager.NewCache(2048)
+	}
+}
+
+// Put sets the value of key to value.
+func (db *DB) Put(key, value []byte) error {
+	return db.write(key, entry{value: bytes.Clone(value)})
+}
+
+// Delete deletes key. Deleting a key that isn't in the database isn't an
+// error.
+func (db *DB) Delete(key []byte) error {
+	return db.write(key, entry{deleted: true})
+}
+
+func (db *DB) write(key []byte, e entry) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err := db.makeRoom(); err != nil {
+		return err
+	}
+	db.mem.sl.Insert(bytes.Clone(key), e)
+	db.mem.size += len(key) + len(e.value) + recordOverhead
+	return nil
+}
+
+// makeRoom makes sure that the memtable has room for a write, switching to
+// a new memtable if it's full. Writes wait while the previous memtable is
+// being flushed, or while level 0 has too many tables.
+func (db *DB) makeRoom() error {
+	for {
+		switch {
+		case db.closed:
+			return ErrClosed
+		case db.bgErr != nil:
+			return db.bgErr
+		case db.mem.size < db.opts.MemtableSize:
+			return nil
+		case db.imm != nil || len(db.cur.levels[0]) >= l0StopFactor*db.opts.L0Tables:
+			db.cond.Wait()
+		default:
+			db.imm, db.mem = db.mem, newMemtable()
+			db.cond.Broadcast()
+		}
+	}
+}
+
+// Get looks for key in the database. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (db *DB) Get(key []byte) (value []byte, ok bool, err error) {
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return nil, false, ErrClosed
+	}
+	for _, m := range []*memtable{db.mem, db.imm} {
+		if m == nil {
+			continue
+		}
+		if e, found := m.sl.Get(key); found {
+			db.mu.Unlock()
+			return bytes.Clone(e.value), !e.deleted, nil
+		}
+	}
+	v := db.acquire()
+	db.mu.Unlock()
+	defer db.release(v)
+
+	e, found, err := v.get(key)
+	if err != nil || !found || e.deleted {
+		return nil, false, err
+	}
+	return e.value, true, nil
+}
+
+// get looks for key in the tables of v, from the newest to the oldest.
+func (v *version) get(key []byte) (entry, bool, error) {
+	for _, t := range v.levels[0] {
+		if e, found, err := t.get(key); found || err != nil {
+			return e, found, err
+		}
+	}
+	for _, tables := range v.levels[1:] {
+		i := sort.Search(len(tables), func(i int) bool {
+			return bytes.Compare(tables[i].largest, key) >= 0
+		})
+		if i < len(tables) && bytes.Compare(tables[i].smallest, key) <= 0 {
+			if e, found, err := tables[i].get(key); found || err != nil {
+				return e, found, err
+			}
+		}
+	}
+	return entry{}, false, nil
+}
+
+// Range returns an iterator over the key-value pairs with keys in the range
+// [lo, hi), in ascending order of keys; a nil lo or hi leaves the range
+// unbounded on that side. If reading fails, the iterator yields the error
+// and stops.
+//
+// The iterator sees a snapshot of the memtables taken when it starts, and
+// the tables current then. Only the snapshot of the memtables is kept in
+// memory; the entries of tables are read as the iteration goes.
+func (db *DB) Range(lo, hi []byte) iter.Seq2[KV, error] {
+	return func(yield func(KV, error) bool) {
+		db.mu.Lock()
+		if db.closed {
+			db.mu.Unlock()
+			yield(KV{}, ErrClosed)
+			return
+		}
+		var cursors []cursor
+		for _, m := range []*memtable{db.mem, db.imm} {
+			if m != nil {
+				records := m.records(lo, hi)
+				for i := range records {
+					records[i].value = bytes.Clone(records[i].value)
+				}
+				cursors = append(cursors, (*sliceCursor)(&records))
+			}
+		}
+		v := db.acquire()
+		db.mu.Unlock()
+		defer db.release(v)
+
+		for _, t := range v.levels[0] {
+			if t.overlaps(lo, hi) {
+				cursors = append(cursors, &lazyCursor{t: t, lo: lo})
+			}
+		}
+		for _, tables := range v.levels[1:] {
+			cursors = append(cursors, newLevelCursor(tables, lo))
+		}
+		stopped := false
+		err := merge(cursors, func(r record) bool {
+			if hi != nil && bytes.Compare(r.key, hi) >= 0 {
+				return false
+			}
+			if r.deleted {
+				return true
+			}
+			stopped = !yield(KV{r.key, r.value}, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(KV{}, err)
+		}
+	}
+}
+
+// Flush flushes the memtable to a table, and waits until it's done.
+func (db *DB) Flush() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.closed {
+		return ErrClosed
+	}
+	return db.flushLocked()
+}
+
+func (db *DB) flushLocked() error {
+	for db.imm != nil && db.bgErr == nil {
+		db.cond.Wait()
+	}
+	if db.bgErr == nil && db.mem.sl.Len() > 0 {
+		db.imm, db.mem = db.mem, newMemtable()
+		db.cond.Broadcast()
+	}
+	for db.imm != nil && db.bgErr == nil {
+		db.cond.Wait()
+	}
+	return db.bgErr
+}
+
+// Close flushes the memtable and closes the database. Iterations must be
+// done before Close is called.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	if db.closed {
+		db.mu.Unlock()
+		return ErrClosed
+	}
+	err := db.flushLocked()
+	db.closed = true
+	db.cond.Broadcast()
+	db.mu.Unlock()
+
+	<-db.done
+	db.closeTables()
+	return err
+}
+
+// closeTables closes the files of the current version's tables.
+func (db *DB) closeTables() {
+	for _, tables := range db.cur.levels {
+		for _, t := range tables {
+			t.p.Close()
+		}
+	}
+}
+
+// acquire returns the current version, which must be released by calling
+// release. It must be called with db.mu held.
+func (db *DB) acquire() *version {
+	db.cur.refs++
+	return db.cur
+}
+
+func (db *DB) release(v *version) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.releaseLocked(v)
+}
+
+// releaseLocked releases v, deleting the tables no version holds anymore.
+func (db *DB) releaseLocked(v *version) {
+	v.refs--
+	if v.refs > 0 {
+		return
+	}
+	for _, tables := range v.levels {
+		for _, t := range tables {
+			t.refs--
+			if t.refs == 0 {
+				t.p.Close()
+				os.Remove(tablePath(db.dir, t.num))
+			}
+		}
+	}
+}
+
+// install makes the current version an edit of it, and records it in the
+// manifest. It must be called with db.mu held.
+func (db *DB) install(removed []*table, l int, added []*table) error {
+	nv := db.cur.edit(removed, l, added)
+	nv.refs = 1
+	if err := writeManifest(db.dir, nv, db.nextNum); err != nil {
+		for _, tables := range nv.levels {
+			for _, t := range tables {
+				t.refs--
+			}
+		}
+		return err
+	}
+	old := db.cur
+	db.cur = nv
+	db.releaseLocked(old)
+	return nil
+}
+
+// background flushes memtables and runs compactions until the database is
+// closed.
+func (db *DB) background() {
+	defer close(db.done)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for {
+		var err error
+		switch c := db.pickCompaction(); {
+		case db.bgErr == nil && db.imm != nil:
+			err = db.flushMemtable()
+		case db.closed:
+			return
+		case db.bgErr == nil && c != nil:
+			err = db.compact(c)
+		default:
+			db.cond.Wait()
+			continue
+		}
+		if err != nil {
+			db.bgErr = err
+		}
+		db.cond.Broadcast()
+	}
+}
+
+// flushMemtable writes the full memtable to a table in level 0. It's called
+// with db.mu held, and releases it while writing.
+func (db *DB) flushMemtable() error {
+	records := db.imm.records(nil, nil)
+	db.mu.Unlock()
+	tables, err := db.writeTables([]cursor{(*sliceCursor)(&records)}, math.MaxInt64, false)
+	db.mu.Lock()
+	if err == nil {
+		err = db.install(nil, 0, tables)
+	}
+	if err != nil {
+		removeTables(db.dir, tables)
+		return err
+	}
+	db.imm = nil
+	return nil
+}
+
+// compaction is a compaction of the tables inputs of a level with the
+// tables overlapping them in the next level.
+type compaction struct {
+	level    int
+	inputs   []*table
+	overlaps []*table
+}
+
+// pickCompaction returns the most urgent compaction of the current
+// version, or nil if no level needs one. Level 0 is compacted when it has
+// too many tables, and other levels when they're larger than their maximal
+// size, a table at a time.
+func (db *DB) pickCompaction() *compaction {
+	v := db.cur
+	if len(v.levels[0]) >= db.opts.L0Tables {
+		c := &compaction{level: 0, inputs: v.levels[0]}
+		lo, hi := keyRange(c.inputs)
+		c.overlaps = v.overlapping(1, lo, hi)
+		return c
+	}
+	maxBytes := int64(db.opts.TableSize) * int64(db.opts.LevelRatio)
+	for l := 1; l < numLevels-1; l, maxBytes = l+1, maxBytes*int64(db.opts.LevelRatio) {
+		tables := v.levels[l]
+		if v.levelBytes(l) <= maxBytes {
+			continue
+		}
+		i := sort.Search(len(tables), func(i int) bool {
+			return bytes.Compare(tables[i].smallest, db.compactKey[l]) > 0
+		})
+		if db.compactKey[l] == nil || i == len(tables) {
+			i = 0
+		}
+		t := tables[i]
+		return &compaction{level: l, inputs: []*table{t}, overlaps: v.overlapping(l+1, t.smallest, t.largest)}
+	}
+	return nil
+}
+
+// keyRange returns the smallest and largest keys of tables.
+func keyRange(tables []*table) (lo, hi []byte) {
+	for _, t := range tables {
+		if lo == nil || bytes.Compare(t.smallest, lo) < 0 {
+			lo = t.smallest
+		}
+		if hi == nil || bytes.Compare(t.largest, hi) > 0 {
+			hi = t.largest
+		}
+	}
+	return lo, hi
+}
+
+// compact runs the compaction c. It's called with db.mu held, and releases
+// it while writing.
+func (db *DB) compact(c *compaction) error {
+	all := append(append([]*table(nil), c.inputs...), c.overlaps...)
+	lo, hi := keyRange(all)
+	db.compactKey[c.level] = hi
+	if len(c.inputs) == 1 && len(c.overlaps) == 0 {
+		// Move the table to the next level as it is.
+		return db.install(c.inputs, c.level+1, c.inputs)
+	}
+
+	// Tombstones can be dropped if no deeper level may hold their keys.
+	bottom := true
+	for l := c.level + 2; l < numLevels; l++ {
+		if len(db.cur.overlapping(l, lo, hi)) > 0 {
+			bottom = false
+		}
+	}
+	var cursors []cursor
+	if c.level == 0 {
+		for _, t := range c.inputs {
+			cursors = append(cursors, &lazyCursor{t: t})
+		}
+	} else {
+		cursors = append(cursors, newLevelCursor(c.inputs, nil))
+	}
+	cursors = append(cursors, newLevelCursor(c.overlaps, nil))
+
+	// The background goroutine is the only one changing versions, so the
+	// input tables stay current while the lock is released.
+	db.mu.Unlock()
+	tables, err := db.writeTables(cursors, int64(db.opts.TableSize), bottom)
+	db.mu.Lock()
+	if err == nil {
+		err = db.install(all, c.level+1, tables)
+	}
+	if err != nil {
+		removeTables(db.dir, tables)
+	}
+	return err
+}
+
+// writeTables writes the merged records of cursors to new tables, starting
+// a new table when one reaches maxSize, and returns the open tables. If
+// dropTombstones is true, deleted entries are left out.
+func (db *DB) writeTables(cursors []cursor, maxSize int64, dropTombstones bool) ([]*table, error) {
+	var (
+		tables []*table
+		tw     *tableWriter
+		p      pager.Pager
+		w      *pager.Writer
+		num    uint64
+		werr   error
+	)
+	finish := func() error {
+		err := tw.finish()
+		if err == nil {
+			err = w.Close()
+		}
+		if err == nil {
+			err = p.Sync()
+		}
+		tw = nil
+		var t *table
+		if err == nil {
+			p = db.opts.PageCache.Wrap(p)
+			t, err = openTable(p, num)
+		}
+		if err != nil {
+			p.Close()
+			os.Remove(tablePath(db.dir, num))
+			return err
+		}
+		tables = append(tables, t)
+		return nil
+	}
+	err := merge(cursors, func(r record) bool {
+		if dropTombstones && r.deleted {
+			return true
+		}
+		if tw == nil {
+			db.mu.Lock()
+			num = db.nextNum
+			db.nextNum++
+			db.mu.Unlock()
+			if p, werr = db.opts.OpenPager(tablePath(db.dir, num)); werr != nil {
+				return false
+			}
+			if w, werr = pager.NewWriter(p); werr != nil {
+				p.Close()
+				os.Remove(tablePath(db.dir, num))
+				return false
+			}
+			tw = newTableWriter(w)
+		}
+		if werr = tw.add(r); werr == nil && tw.size() >= maxSize {
+			werr = finish()
+		}
+		return werr == nil
+	})
+	if err == nil {
+		err = werr
+	}
+	if err == nil && tw != nil {
+		err = finish()
+	} else if tw != nil {
+		p.Close()
+		os.Remove(tablePath(db.dir, num))
+	}
+	if err != nil {
+		removeTables(db.dir, tables)
+		return nil, err
+	}
+	return tables, nil
+}
+
+// openTable opens the table file with number num.
+func (db *DB) openTable(num uint64) (*table, error) {
+	p, err := db.opts.OpenPager(tablePath(db.dir, num))
+	if errors.Is(err, pager.ErrInvalidData) {
+		return nil, ErrInvalidData
+	} else if err != nil {
+		return nil, err
+	}
+	p = db.opts.PageCache.Wrap(p)
+	t, err := openTable(p, num)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// removeTables closes and deletes tables that aren't in any version.
+func removeTables(dir string, tables []*table) {
+	for _, t := range tables {
+		if t.refs == 0 {
+			t.p.Close()
+			os.Remove(tablePath(dir, t.num))
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package lsm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/eliben/gogl/pager"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// smallOptions makes the database flush and compact often.
+var smallOptions = &Options{MemtableSize: 4096, TableSize: 4096, L0Tables: 2, LevelRatio: 3}
+
+// waitIdle waits until db has no flushes or compactions to do.
+func waitIdle(db *DB) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for (db.imm != nil || db.pickCompaction() != nil) && db.bgErr == nil {
+		db.cond.Wait()
+	}
+}
+
+// checkContents checks that db holds exactly the entries of want.
+func checkContents(t *testing.T, db *DB, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		got, ok, err := db.Get([]byte(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || string(got) != v {
+			t.Fatalf("Get(%q): got %q,%v, want %q", k, got, ok, v)
+		}
+	}
+	keys := slices.Sorted(maps.Keys(want))
+	i := 0
+	for kv, err := range db.Range(nil, nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(keys) || string(kv.Key) != keys[i] || string(kv.Value) != want[keys[i]] {
+			t.Fatalf("Range: got %q=%q at %d", kv.Key, kv.Value, i)
+		}
+		i++
+	}
+	if i != len(keys) {
+		t.Fatalf("Range: got %d entries, want %d", i, len(keys))
+	}
+}
+
+func openTestDB(t *testing.T, dir string, opts *Options) *DB {
+	t.Helper()
+	db, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestBasic(t *testing.T) {
+	db := openTestDB(t, t.TempDir(), nil)
+	defer db.Close()
+
+	db.Put([]byte("b"), []byte("2"))
+	db.Put([]byte("a"), []byte("1"))
+	db.Put([]byte("c"), []byte("3"))
+	db.Put([]byte("b"), []byte("two"))
+	db.Delete([]byte("c"))
+	db.Delete([]byte("nope"))
+	checkContents(t, db, map[string]string{"a": "1", "b": "two"})
+	if _, ok, _ := db.Get([]byte("c")); ok {
+		t.Errorf("got deleted key")
+	}
+
+	// The same, from a table.
+	if err := db.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	checkContents(t, db, map[string]string{"a": "1", "b": "two"})
+	if len(db.cur.levels[0]) != 1 {
+		t.Errorf("got %d tables in level 0, want 1", len(db.cur.levels[0]))
+	}
+
+	// The memtable shadows the table.
+	db.Delete([]byte("a"))
+	db.Put([]byte("c"), []byte("three"))
+	checkContents(t, db, map[string]string{"b": "two", "c": "three"})
+
+	// Values are copied.
+	value := []byte("x")
+	db.Put([]byte("d"), value)
+	value[0] = 'y'
+	got, _, _ := db.Get([]byte("d"))
+	got[0] = 'z'
+	if got, _, _ := db.Get([]byte("d")); string(got) != "x" {
+		t.Errorf("got %q, want x", got)
+	}
+}
+
+func TestRange(t *testing.T) {
+	db := openTestDB(t, t.TempDir(), smallOptions)
+	defer db.Close()
+	for i := range 1000 {
+		db.Put(testKey(i), []byte(fmt.Sprint(i)))
+	}
+	waitIdle(db)
+	for i := range 1000 {
+		if i%3 == 0 {
+			db.Delete(testKey(i))
+		}
+	}
+
+	tests := []struct{ lo, hi int }{{-1, -1}, {0, 1000}, {10, 20}, {-1, 500}, {500, -1}, {999, 1000}, {30, 30}}
+	for _, tt := range tests {
+		var lo, hi []byte
+		wantLo, wantHi := max(tt.lo, 0), tt.hi
+		if tt.lo >= 0 {
+			lo = testKey(tt.lo)
+		}
+		if tt.hi >= 0 {
+			hi = testKey(tt.hi)
+		} else {
+			wantHi = 1000
+		}
+		var want []string
+		for i := wantLo; i < wantHi; i++ {
+			if i%3 != 0 {
+				want = append(want, fmt.Sprint(i))
+			}
+		}
+		var got []string
+		for kv, err := range db.Range(lo, hi) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(kv.Value))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("Range(%d, %d): got %v, want %v", tt.lo, tt.hi, got, want)
+		}
+	}
+
+	// Stop early.
+	count := 0
+	for range db.Range(nil, nil) {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Errorf("got %d entries, want 5", count)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	dir := t.TempDir()
+	db := openTestDB(t, dir, smallOptions)
+	want := make(map[string]string)
+	for round := range 5 {
+		for range 3000 {
+			key := fmt.Sprintf("key%05d", rnd.IntN(2000))
+			if rnd.IntN(4) == 0 {
+				if err := db.Delete([]byte(key)); err != nil {
+					t.Fatal(err)
+				}
+				delete(want, key)
+			} else {
+				value := fmt.Sprintf("%d:%s", round, bytes.Repeat([]byte("v"), rnd.IntN(100)))
+				if err := db.Put([]byte(key), []byte(value)); err != nil {
+					t.Fatal(err)
+				}
+				want[key] = value
+			}
+		}
+		checkContents(t, db, want)
+		waitIdle(db)
+		checkContents(t, db, want)
+
+		// Reopen the database every other round.
+		if round%2 == 1 {
+			if err := db.Close(); err != nil {
+				t.Fatal(err)
+			}
+			db = openTestDB(t, dir, smallOptions)
+			checkContents(t, db, want)
+		}
+	}
+
+	// Compactions moved tables to deeper levels, keeping the levels sorted
+	// and within their sizes, and deleted the files of old tables.
+	waitIdle(db)
+	tables := 0
+	for l, level := range db.cur.levels {
+		tables += len(level)
+		if l == 0 {
+			continue
+		}
+		for i := 1; i < len(level); i++ {
+			if bytes.Compare(level[i-1].largest, level[i].smallest) >= 0 {
+				t.Errorf("level %d: tables %d and %d overlap", l, i-1, i)
+			}
+		}
+	}
+	if len(db.cur.levels[2]) == 0 {
+		t.Errorf("no tables in level 2")
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.sst"))
+	if len(files) != tables {
+		t.Errorf("got %d table files, want %d", len(files), tables)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	db := openTestDB(t, t.TempDir(), smallOptions)
+	defer db.Close()
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				key := []byte(fmt.Sprintf("g%d-%04d", g, i))
+				if err := db.Put(key, key); err != nil {
+					t.Error(err)
+					return
+				}
+				if got, ok, err := db.Get(key); err != nil || !ok || !bytes.Equal(got, key) {
+					t.Errorf("Get(%s): got %q,%v,%v", key, got, ok, err)
+					return
+				}
+				if i%100 == 0 {
+					for _, err := range db.Range(nil, nil) {
+						if err != nil {
+							t.Error(err)
+							return
+						}
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	count := 0
+	for range db.Range(nil, nil) {
+		count++
+	}
+	if count != 8*500 {
+		t.Errorf("got %d entries, want %d", count, 8*500)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	dir := t.TempDir()
+	db := openTestDB(t, dir, nil)
+	db.Put([]byte("k"), []byte("v"))
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files left over by an interrupted flush are removed.
+	stray := tablePath(dir, 1000)
+	if err := os.WriteFile(stray, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db = openTestDB(t, dir, nil)
+	checkContents(t, db, map[string]string{"k": "v"})
+	if _, err := os.Stat(stray); !os.IsNotExist(err) {
+		t.Errorf("stray table not removed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A corrupt manifest fails to open.
+	path := filepath.Join(dir, manifestName)
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 1
+	os.WriteFile(path, data, 0o644)
+	if _, err := Open(dir, nil); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("got err=%v, want ErrInvalidData", err)
+	}
+}
+
+func TestOpenPager(t *testing.T) {
+	dir := t.TempDir()
+	var mu sync.Mutex
+	opened := map[string]int{}
+	opts := *smallOptions
+	opts.OpenPager = func(path string) (pager.Pager, error) {
+		mu.Lock()
+		opened[filepath.Base(path)]++
+		mu.Unlock()
+		return pager.OpenFile(path, 512)
+	}
+	db := openTestDB(t, dir, &opts)
+	want := map[string]string{}
+	for i := range 500 {
+		k, v := fmt.Sprintf("k%04d", i), fmt.Sprintf("v%d", i)
+		db.Put([]byte(k), []byte(v))
+		want[k] = v
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db = openTestDB(t, dir, &opts)
+	defer db.Close()
+	checkContents(t, db, want)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(opened) == 0 {
+		t.Fatal("OpenPager not called")
+	}
+	for name := range opened {
+		if _, ok := parseTableName(name); !ok {
+			t.Errorf("OpenPager called for %q", name)
+		}
+	}
+}
+
+// countingPager counts the page reads of a pager.
+type countingPager struct {
+	pager.Pager
+	reads *atomic.Int64
+}
+
+func (cp countingPager) ReadPage(id pager.PageID, buf []byte) error {
+	cp.reads.Add(1)
+	return cp.Pager.ReadPage(id, buf)
+}
+
+func TestPageCache(t *testing.T) {
+	var reads atomic.Int64
+	opts := &Options{
+		OpenPager: func(path string) (pager.Pager, error) {
+			p, err := pager.OpenFile(path, pager.DefaultPageSize)
+			if err != nil {
+				return nil, err
+			}
+			return countingPager{p, &reads}, nil
+		},
+		PageCache: pager.NewCache(64),
+	}
+	db := openTestDB(t, t.TempDir(), opts)
+	defer db.Close()
+	for i := range 1000 {
+		db.Put([]byte(fmt.Sprintf("k%04d", i)), []byte("value"))
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	getAll := func() {
+		t.Helper()
+		for i := range 1000 {
+			if _, ok, err := db.Get([]byte(fmt.Sprintf("k%04d", i))); !ok || err != nil {
+				t.Fatalf("Get(k%04d): got ok=%v, err=%v", i, ok, err)
+			}
+		}
+	}
+	getAll()
+	n := reads.Load()
+	if n == 0 {
+		t.Fatal("no page reads")
+	}
+	getAll()
+	if got := reads.Load(); got != n {
+		t.Errorf("got %d page reads with a warm cache, want %d", got, n)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	db := openTestDB(t, t.TempDir(), nil)
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("k"), nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Put: got err=%v, want ErrClosed", err)
+	}
+	if _, _, err := db.Get([]byte("k")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get: got err=%v, want ErrClosed", err)
+	}
+	for _, err := range db.Range(nil, nil) {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Range: got err=%v, want ErrClosed", err)
+		}
+	}
+	if err := db.Flush(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Flush: got err=%v, want ErrClosed", err)
+	}
+	if err := db.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Close: got err=%v, want ErrClosed", err)
+	}
+}
+
+func BenchmarkPut(b *testing.B) {
+	db, err := Open(b.TempDir(), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	value := bytes.Repeat([]byte("v"), 100)
+	b.ResetTimer()
+	for i := range b.N {
+		db.Put(testKey(i), value)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	db, err := Open(b.TempDir(), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	const n = 100000
+	value := bytes.Repeat([]byte("v"), 100)
+	for i := range n {
+		db.Put(testKey(i), value)
+	}
+	db.Flush()
+	waitIdle(db)
+	b.ResetTimer()
+	for i := range b.N {
+		db.Get(testKey(i % n))
+	}
+}
@@ -0,0 +1,123 @@
+package lsm
+
+import (
+	"bytes"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// cursor iterates over records in increasing order of keys.
+type cursor interface {
+	// next returns the next record, or ok=false at the end.
+	next() (r record, ok bool, err error)
+}
+
+// sliceCursor iterates over a slice of records.
+type sliceCursor []record
+
+func (c *sliceCursor) next() (record, bool, error) {
+	if len(*c) == 0 {
+		return record{}, false, nil
+	}
+	r := (*c)[0]
+	*c = (*c)[1:]
+	return r, true, nil
+}
+
+// levelCursor iterates over the records of a level's tables, which are
+// sorted and don't overlap.
+type levelCursor struct {
+	tables []*table
+	cur    cursor
+}
+
+// newLevelCursor returns a cursor at the first record of the tables with a
+// key >= lo, or at their first record if lo is nil.
+func newLevelCursor(tables []*table, lo []byte) *levelCursor {
+	for len(tables) > 0 && lo != nil && bytes.Compare(tables[0].largest, lo) < 0 {
+		tables = tables[1:]
+	}
+	c := &levelCursor{tables: tables, cur: &sliceCursor{}}
+	if len(tables) > 0 {
+		c.cur = &lazyCursor{t: tables[0], lo: lo}
+		c.tables = tables[1:]
+	}
+	return c
+}
+
+func (c *levelCursor) next() (record, bool, error) {
+	for {
+		r, ok, err := c.cur.next()
+		if ok || err != nil || len(c.tables) == 0 {
+			return r, ok, err
+		}
+		c.cur = &lazyCursor{t: c.tables[0]}
+		c.tables = c.tables[1:]
+	}
+}
+
+// lazyCursor is a table cursor that reads nothing until it's used.
+type lazyCursor struct {
+	t  *table
+	lo []byte
+	tc *tableCursor
+}
+
+func (c *lazyCursor) next() (record, bool, error) {
+	if c.tc == nil {
+		tc, err := newTableCursor(c.t, c.lo)
+		if err != nil {
+			return record{}, false, err
+		}
+		c.tc = tc
+	}
+	return c.tc.next()
+}
+
+// head is the next record of a cursor being merged; src is the index of the
+// cursor, lower for newer sources.
+type head struct {
+	r   record
+	src int
+}
+
+// merge calls yield with the records of cursors in increasing order of
+// keys, stopping early if yield returns false. The cursors are ordered from
+// newest to oldest: for keys in several cursors, only the record of the
+// newest one is yielded.
+func merge(cursors []cursor, yield func(r record) bool) error {
+	h := heap.NewDAry(2, func(a, b head) int {
+		if c := bytes.Compare(a.r.key, b.r.key); c != 0 {
+			return c
+		}
+		return a.src - b.src
+	})
+	advance := func(src int) error {
+		r, ok, err := cursors[src].next()
+		if ok {
+			h.Push(head{r, src})
+		}
+		return err
+	}
+	for src := range cursors {
+		if err := advance(src); err != nil {
+			return err
+		}
+	}
+
+	var last []byte
+	first := true
+	for h.Len() > 0 {
+		hd := h.Pop()
+		if first || !bytes.Equal(hd.r.key, last) {
+			if !yield(hd.r) {
+				return nil
+			}
+			last, first = hd.r.key, false
+		}
+		if err := advance(hd.src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
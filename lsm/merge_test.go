@@ -0,0 +1,93 @@
+package lsm
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func makeCursor(kvs ...string) cursor {
+	var records []record
+	for i := 0; i < len(kvs); i += 2 {
+		r := record{key: []byte(kvs[i])}
+		if kvs[i+1] == "-" {
+			r.deleted = true
+		} else {
+			r.value = []byte(kvs[i+1])
+		}
+		records = append(records, r)
+	}
+	return (*sliceCursor)(&records)
+}
+
+func TestMerge(t *testing.T) {
+	makeCursors := func() []cursor {
+		return []cursor{
+			makeCursor("b", "new", "d", "-"),
+			makeCursor(),
+			makeCursor("a", "old", "b", "old", "c", "old"),
+			makeCursor("a", "older", "d", "older", "e", "older"),
+		}
+	}
+	var got []string
+	err := merge(makeCursors(), func(r record) bool {
+		v := string(r.value)
+		if r.deleted {
+			v = "-"
+		}
+		got = append(got, fmt.Sprintf("%s=%s", r.key, v))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a=old", "b=new", "c=old", "d=-", "e=older"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Stop early.
+	count := 0
+	merge(makeCursors(), func(r record) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("got %d records after stopping, want 2", count)
+	}
+}
+
+func TestLevelCursor(t *testing.T) {
+	var tables []*table
+	for i := range 3 {
+		var records []record
+		for j := range 100 {
+			records = append(records, record{key: testKey(100*i + j), entry: entry{value: []byte("v")}})
+		}
+		tables = append(tables, writeTestTable(t, records))
+	}
+	for _, lo := range []int{-1, 0, 99, 100, 150, 299} {
+		var loKey []byte
+		if lo >= 0 {
+			loKey = testKey(lo)
+		}
+		c := newLevelCursor(tables, loKey)
+		want := max(lo, 0)
+		for {
+			r, ok, err := c.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+			if string(r.key) != string(testKey(want)) {
+				t.Fatalf("lo=%d: got key %q, want %q", lo, r.key, testKey(want))
+			}
+			want++
+		}
+		if want != 300 {
+			t.Errorf("lo=%d: cursor stopped at %d", lo, want)
+		}
+	}
+}
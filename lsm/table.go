@@ -0,0 +1,399 @@
+package lsm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sort"
+
+	"github.com/eliben/gogl/bloom"
+	"github.com/eliben/gogl/internal/hash"
+	"github.com/eliben/gogl/pager"
+)
+
+// A table file holds a sorted run of entries with distinct keys, and is
+// never modified once written. Its layout is:
+//
+//	data block...
+//	index block
+//	filter block
+//	footer
+//
+// Data blocks hold consecutive entries, each encoded as a kind byte, the
+// uvarint lengths of the key and the value, the key and the value. The
+// index block holds the smallest key of the table (as a uvarint length and
+// the key), then for each data block its largest key (likewise), offset and
+// length (uvarints). The filter block is a Bloom filter of the keys, as
+// encoded by [bloom.Filter.MarshalBinary]. Blocks are followed by the
+// CRC-32C of their contents, which isn't counted in their length. The
+// footer is fixed-size, and locates the index and filter blocks. Tables
+// are stored in pagers, as streams written by a [pager.Writer].
+const (
+	tableMagic = "GOGLSST1"
+
+	// footerSize is the size of the footer: the offset and length of the
+	// index block, the offset and length of the filter block, the number of
+	// entries, and the magic string.
+	footerSize = 8 + 4 + 8 + 4 + 8 + len(tableMagic)
+
+	// blockSize is the size above which a data block is ended.
+	blockSize = 4096
+
+	// filterFPRate is the false positive rate of the tables' Bloom filters.
+	filterFPRate = 0.01
+
+	kindValue     = 0
+	kindTombstone = 1
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// record is an entry of a table or memtable.
+type record struct {
+	key []byte
+	entry
+}
+
+// blockHandle locates a data block of a table.
+type blockHandle struct {
+	largest []byte
+	offset  int64
+	length  int
+}
+
+// tableWriter writes a table file, from entries added in increasing order
+// of keys.
+type tableWriter struct {
+	w      *bufio.Writer
+	offset int64
+
+	block    []byte
+	lastKey  []byte
+	smallest []byte
+	index    []blockHandle
+	hashes   []uint64
+}
+
+func newTableWriter(w io.Writer) *tableWriter {
+	return &tableWriter{w: bufio.NewWriter(w)}
+}
+
+// size returns the approximate size of the table written so far.
+func (tw *tableWriter) size() int64 {
+	return tw.offset + int64(len(tw.block))
+}
+
+// count returns the number of entries added.
+func (tw *tableWriter) count() int {
+	return len(tw.hashes)
+}
+
+func (tw *tableWriter) add(r record) error {
+	if tw.count() == 0 {
+		tw.smallest = bytes.Clone(r.key)
+	}
+	kind := byte(kindValue)
+	if r.deleted {
+		kind = kindTombstone
+	}
+	tw.block = append(tw.block, kind)
+	tw.block = binary.AppendUvarint(tw.block, uint64(len(r.key)))
+	tw.block = binary.AppendUvarint(tw.block, uint64(len(r.value)))
+	tw.block = append(tw.block, r.key...)
+	tw.block = append(tw.block, r.value...)
+	tw.lastKey = append(tw.lastKey[:0], r.key...)
+	tw.hashes = append(tw.hashes, hash.Bytes(r.key))
+	if len(tw.block) >= blockSize {
+		return tw.flushBlock()
+	}
+	return nil
+}
+
+// flushBlock writes the current data block, if it's not empty.
+func (tw *tableWriter) flushBlock() error {
+	if len(tw.block) == 0 {
+		return nil
+	}
+	tw.index = append(tw.index, blockHandle{bytes.Clone(tw.lastKey), tw.offset, len(tw.block)})
+	_, err := tw.writeBlock(tw.block)
+	tw.block = tw.block[:0]
+	return err
+}
+
+// writeBlock writes b with its checksum, and returns its offset.
+func (tw *tableWriter) writeBlock(b []byte) (int64, error) {
+	offset := tw.offset
+	if _, err := tw.w.Write(b); err != nil {
+		return 0, err
+	}
+	if _, err := tw.w.Write(binary.LittleEndian.AppendUint32(nil, crc32.Checksum(b, crcTable))); err != nil {
+		return 0, err
+	}
+	tw.offset += int64(len(b)) + 4
+	return offset, nil
+}
+
+// finish writes the rest of the table, which must have entries.
+func (tw *tableWriter) finish() error {
+	if err := tw.flushBlock(); err != nil {
+		return err
+	}
+	index := appendBytes(nil, tw.smallest)
+	for _, h := range tw.index {
+		index = appendBytes(index, h.largest)
+		index = binary.AppendUvarint(index, uint64(h.offset))
+		index = binary.AppendUvarint(index, uint64(h.length))
+	}
+	indexOffset, err := tw.writeBlock(index)
+	if err != nil {
+		return err
+	}
+
+	f := bloom.New(len(tw.hashes), filterFPRate)
+	for _, h := range tw.hashes {
+		f.AddHash(h)
+	}
+	filter, _ := f.MarshalBinary()
+	filterOffset, err := tw.writeBlock(filter)
+	if err != nil {
+		return err
+	}
+
+	footer := binary.LittleEndian.AppendUint64(nil, uint64(indexOffset))
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(index)))
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(filterOffset))
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(filter)))
+	footer = binary.LittleEndian.AppendUint64(footer, uint64(tw.count()))
+	footer = append(footer, tableMagic...)
+	if _, err := tw.w.Write(footer); err != nil {
+		return err
+	}
+	tw.offset += int64(len(footer))
+	return tw.w.Flush()
+}
+
+func appendBytes(b, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// table is an open table.
+type table struct {
+	num  uint64
+	p    pager.Pager
+	r    *pager.Reader
+	size int64
+
+	smallest, largest []byte
+	index             []blockHandle
+	filter            *bloom.Filter
+	count             int
+
+	// refs is the number of versions holding the table; it's guarded by
+	// the DB's mutex.
+	refs int
+}
+
+// openTable opens the table stored in p, reading its index and filter.
+func openTable(p pager.Pager, num uint64) (*table, error) {
+	r, err := pager.NewReader(p)
+	if errors.Is(err, pager.ErrInvalidData) {
+		return nil, ErrInvalidData
+	} else if err != nil {
+		return nil, err
+	}
+	t := &table{num: num, p: p, r: r, size: r.Size()}
+	if t.size < int64(footerSize) {
+		return nil, ErrInvalidData
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, t.size-int64(footerSize)); err != nil {
+		return nil, err
+	}
+	if string(footer[footerSize-len(tableMagic):]) != tableMagic {
+		return nil, ErrInvalidData
+	}
+	indexOffset := int64(binary.LittleEndian.Uint64(footer))
+	indexLen := int(binary.LittleEndian.Uint32(footer[8:]))
+	filterOffset := int64(binary.LittleEndian.Uint64(footer[12:]))
+	filterLen := int(binary.LittleEndian.Uint32(footer[20:]))
+	t.count = int(binary.LittleEndian.Uint64(footer[24:]))
+
+	index, err := t.readBlock(blockHandle{offset: indexOffset, length: indexLen})
+	if err != nil {
+		return nil, err
+	}
+	if t.smallest, index = readBytes(index); t.smallest == nil {
+		return nil, ErrInvalidData
+	}
+	for len(index) > 0 {
+		var h blockHandle
+		var offset, length uint64
+		var n1, n2 int
+		if h.largest, index = readBytes(index); h.largest == nil {
+			return nil, ErrInvalidData
+		}
+		offset, n1 = binary.Uvarint(index)
+		if n1 > 0 {
+			length, n2 = binary.Uvarint(index[n1:])
+		}
+		if n1 <= 0 || n2 <= 0 || offset+length > uint64(indexOffset) {
+			return nil, ErrInvalidData
+		}
+		h.offset, h.length = int64(offset), int(length)
+		index = index[n1+n2:]
+		t.index = append(t.index, h)
+	}
+	if len(t.index) == 0 {
+		return nil, ErrInvalidData
+	}
+	t.largest = t.index[len(t.index)-1].largest
+
+	filter, err := t.readBlock(blockHandle{offset: filterOffset, length: filterLen})
+	if err != nil {
+		return nil, err
+	}
+	t.filter = &bloom.Filter{}
+	if err := t.filter.UnmarshalBinary(filter); err != nil {
+		return nil, ErrInvalidData
+	}
+	return t, nil
+}
+
+// readBytes reads a uvarint length and that many bytes from b, returning
+// them and the rest of b; it returns nil bytes if b is too short.
+func readBytes(b []byte) ([]byte, []byte) {
+	n, k := binary.Uvarint(b)
+	if k <= 0 || n > uint64(len(b)-k) {
+		return nil, b
+	}
+	return b[k : k+int(n) : k+int(n)], b[k+int(n):]
+}
+
+// readBlock reads the block h and checks its checksum.
+func (t *table) readBlock(h blockHandle) ([]byte, error) {
+	if h.offset < 0 || h.length < 0 || h.offset+int64(h.length)+4 > t.size {
+		return nil, ErrInvalidData
+	}
+	b := make([]byte, h.length+4)
+	if _, err := t.r.ReadAt(b, h.offset); err != nil {
+		return nil, err
+	}
+	data := b[:h.length]
+	if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(b[h.length:]) {
+		return nil, ErrInvalidData
+	}
+	return data, nil
+}
+
+// readRecords reads and decodes the data block i.
+func (t *table) readRecords(i int) ([]record, error) {
+	b, err := t.readBlock(t.index[i])
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	for len(b) > 0 {
+		kind := b[0]
+		klen, n1 := binary.Uvarint(b[1:])
+		if n1 <= 0 {
+			return nil, ErrInvalidData
+		}
+		vlen, n2 := binary.Uvarint(b[1+n1:])
+		start := 1 + n1 + n2
+		if n2 <= 0 || kind > kindTombstone || klen > uint64(len(b)-start) || vlen > uint64(len(b)-start)-klen {
+			return nil, ErrInvalidData
+		}
+		key := b[start : start+int(klen) : start+int(klen)]
+		value := b[start+int(klen) : start+int(klen+vlen) : start+int(klen+vlen)]
+		records = append(records, record{key, entry{value: value, deleted: kind == kindTombstone}})
+		b = b[start+int(klen+vlen):]
+	}
+	return records, nil
+}
+
+// findBlock returns the index of the first data block that may hold key, or
+// len(t.index) if key is larger than all the keys in the table.
+func (t *table) findBlock(key []byte) int {
+	return sort.Search(len(t.index), func(i int) bool {
+		return bytes.Compare(t.index[i].largest, key) >= 0
+	})
+}
+
+// get looks for key in the table.
+func (t *table) get(key []byte) (entry, bool, error) {
+	if !t.filter.MaybeContains(key) {
+		return entry{}, false, nil
+	}
+	i := t.findBlock(key)
+	if i == len(t.index) {
+		return entry{}, false, nil
+	}
+	records, err := t.readRecords(i)
+	if err != nil {
+		return entry{}, false, err
+	}
+	j := sort.Search(len(records), func(j int) bool {
+		return bytes.Compare(records[j].key, key) >= 0
+	})
+	if j < len(records) && bytes.Equal(records[j].key, key) {
+		return records[j].entry, true, nil
+	}
+	return entry{}, false, nil
+}
+
+// overlaps reports whether the table may hold keys in [lo, hi]; nil bounds
+// are unbounded.
+func (t *table) overlaps(lo, hi []byte) bool {
+	return (lo == nil || bytes.Compare(t.largest, lo) >= 0) &&
+		(hi == nil || bytes.Compare(t.smallest, hi) <= 0)
+}
+
+// tableCursor iterates over the records of a table in order.
+type tableCursor struct {
+	t       *table
+	block   int
+	records []record
+}
+
+// newTableCursor returns a cursor at the first record of t with a key >=
+// lo, or at its first record if lo is nil.
+func newTableCursor(t *table, lo []byte) (*tableCursor, error) {
+	c := &tableCursor{t: t}
+	if lo == nil {
+		return c, nil
+	}
+	c.block = t.findBlock(lo)
+	if c.block < len(t.index) {
+		records, err := t.readRecords(c.block)
+		if err != nil {
+			return nil, err
+		}
+		i := sort.Search(len(records), func(i int) bool {
+			return bytes.Compare(records[i].key, lo) >= 0
+		})
+		c.records = records[i:]
+		c.block++
+	}
+	return c, nil
+}
+
+func (c *tableCursor) next() (record, bool, error) {
+	for len(c.records) == 0 {
+		if c.block >= len(c.t.index) {
+			return record{}, false, nil
+		}
+		records, err := c.t.readRecords(c.block)
+		if err != nil {
+			return record{}, false, err
+		}
+		c.records = records
+		c.block++
+	}
+	r := c.records[0]
+	c.records = c.records[1:]
+	return r, true, nil
+}
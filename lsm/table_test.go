@@ -0,0 +1,164 @@
+package lsm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/eliben/gogl/pager"
+)
+
+// writeTestTable writes a table with the given records to a memory pager,
+// and opens it.
+func writeTestTable(t *testing.T, records []record) *table {
+	t.Helper()
+	p := pager.NewMem(256)
+	w, err := pager.NewWriter(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tw := newTableWriter(w)
+	for _, r := range records {
+		if err := tw.add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.finish(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tb, err := openTable(p, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tb
+}
+
+// flipByte flips a bit of the byte at offset in the stream of the table
+// tb, returning a function that flips it back.
+func flipByte(t *testing.T, tb *table, offset int64) func() {
+	t.Helper()
+	buf := make([]byte, tb.p.PageSize())
+	id := pager.PageID(offset / int64(len(buf)))
+	flip := func() {
+		if err := tb.p.ReadPage(id, buf); err != nil {
+			t.Fatal(err)
+		}
+		buf[offset%int64(len(buf))] ^= 1
+		if err := tb.p.WritePage(id, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flip()
+	return flip
+}
+
+func testKey(i int) []byte {
+	return []byte(fmt.Sprintf("key%06d", i))
+}
+
+func TestTable(t *testing.T) {
+	// Even keys, with every third one deleted; enough for many blocks.
+	var records []record
+	for i := 0; i < 10000; i += 2 {
+		r := record{key: testKey(i), entry: entry{value: bytes.Repeat([]byte{byte(i)}, i%50)}}
+		if i%3 == 0 {
+			r.entry = entry{deleted: true}
+		}
+		records = append(records, r)
+	}
+	tb := writeTestTable(t, records)
+	if tb.count != len(records) || len(tb.index) < 10 {
+		t.Errorf("got count=%d, %d blocks", tb.count, len(tb.index))
+	}
+	if !bytes.Equal(tb.smallest, testKey(0)) || !bytes.Equal(tb.largest, testKey(9998)) {
+		t.Errorf("got key range %q-%q", tb.smallest, tb.largest)
+	}
+
+	for i := -1; i < 10001; i++ {
+		e, found, err := tb.get(testKey(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantFound := i >= 0 && i%2 == 0 && i < 10000; found != wantFound {
+			t.Fatalf("get(%d): got found=%v", i, found)
+		}
+		if found {
+			want := records[i/2].entry
+			if e.deleted != want.deleted || !bytes.Equal(e.value, want.value) {
+				t.Fatalf("get(%d): got %v, want %v", i, e, want)
+			}
+		}
+	}
+
+	for _, lo := range []int{-1, 0, 1, 555, 4444, 9998, 9999} {
+		var loKey []byte
+		if lo >= 0 {
+			loKey = testKey(lo)
+		}
+		c, err := newTableCursor(tb, loKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := max(0, (lo+1)/2)
+		for {
+			r, ok, err := c.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+			if !bytes.Equal(r.key, records[i].key) {
+				t.Fatalf("lo=%d: got key %q, want %q", lo, r.key, records[i].key)
+			}
+			i++
+		}
+		if i != len(records) {
+			t.Errorf("lo=%d: cursor stopped at %d", lo, i)
+		}
+	}
+}
+
+func TestTableCorrupt(t *testing.T) {
+	var records []record
+	for i := range 1000 {
+		records = append(records, record{key: testKey(i), entry: entry{value: []byte("value")}})
+	}
+	tb := writeTestTable(t, records)
+
+	// Corrupting a data block is detected when reading it.
+	restore := flipByte(t, tb, 100)
+	if _, _, err := tb.get(testKey(0)); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("got err=%v, want ErrInvalidData", err)
+	}
+	restore()
+
+	// Corrupting the footer or the index is detected when opening.
+	last := tb.index[len(tb.index)-1]
+	for _, offset := range []int64{tb.size - 1, last.offset + int64(last.length) + 10} {
+		restore := flipByte(t, tb, offset)
+		if _, err := openTable(tb.p, 1); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("offset %d: got err=%v, want ErrInvalidData", offset, err)
+		}
+		restore()
+	}
+	if _, err := openTable(tb.p, 1); err != nil {
+		t.Errorf("restored table: %v", err)
+	}
+
+	// So are truncated tables and invalid streams.
+	p := pager.NewMem(256)
+	w, _ := pager.NewWriter(p)
+	w.Write([]byte("truncated"))
+	w.Close()
+	if _, err := openTable(p, 1); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("truncated: got err=%v, want ErrInvalidData", err)
+	}
+	if _, err := openTable(pager.NewMem(256), 1); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("empty pager: got err=%v, want ErrInvalidData", err)
+	}
+}
@@ -0,0 +1,197 @@
+package lsm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// version is a set of tables making up the on-disk part of the database.
+// Versions are immutable; flushes and compactions install new ones. Level 0
+// holds the tables flushed from memtables, newest first, whose keys may
+// overlap; each of the other levels holds tables sorted by key, with
+// disjoint key ranges.
+type version struct {
+	levels [numLevels][]*table
+
+	// refs is the number of users of the version, including the DB if it's
+	// the current version; it's guarded by the DB's mutex.
+	refs int
+}
+
+const numLevels = 7
+
+// levelBytes returns the total size of the tables of level l.
+func (v *version) levelBytes(l int) int64 {
+	var n int64
+	for _, t := range v.levels[l] {
+		n += t.size
+	}
+	return n
+}
+
+// overlapping returns the tables of level l >= 1 that may hold keys in
+// [lo, hi].
+func (v *version) overlapping(l int, lo, hi []byte) []*table {
+	var tables []*table
+	for _, t := range v.levels[l] {
+		if t.overlaps(lo, hi) {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// edit returns a new version with the tables in removed taken out, and the
+// tables in added put in level l; added tables go first in level 0, and in
+// key order in other levels.
+func (v *version) edit(removed []*table, l int, added []*table) *version {
+	nv := &version{}
+	for i, tables := range v.levels {
+		for _, t := range tables {
+			if !slices.Contains(removed, t) {
+				nv.levels[i] = append(nv.levels[i], t)
+			}
+		}
+	}
+	if l == 0 {
+		nv.levels[0] = append(slices.Clone(added), nv.levels[0]...)
+	} else {
+		nv.levels[l] = append(nv.levels[l], added...)
+		slices.SortFunc(nv.levels[l], func(a, b *table) int {
+			return bytes.Compare(a.smallest, b.smallest)
+		})
+	}
+	for _, tables := range nv.levels {
+		for _, t := range tables {
+			t.refs++
+		}
+	}
+	return nv
+}
+
+// The manifest file records the current version, as the numbers of the
+// table files in each level, and the next file number to use. It's
+// encoded as a magic string, the next file number, then for each level the
+// number of tables and their numbers, all as uvarints, followed by the
+// CRC-32C of the preceding bytes. The manifest is replaced atomically by
+// writing a temporary file and renaming it.
+const (
+	manifestMagic = "GOGLMAN1"
+	manifestName  = "MANIFEST"
+)
+
+// tablePath returns the path of the table file with number num in dir.
+func tablePath(dir string, num uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.sst", num))
+}
+
+// parseTableName returns the number of the table file with the given name,
+// or ok=false if it's not the name of a table file.
+func parseTableName(name string) (num uint64, ok bool) {
+	s, found := strings.CutSuffix(name, ".sst")
+	if !found {
+		return 0, false
+	}
+	num, err := strconv.ParseUint(s, 10, 64)
+	return num, err == nil
+}
+
+// writeManifest atomically replaces the manifest in dir.
+func writeManifest(dir string, v *version, nextNum uint64) error {
+	b := []byte(manifestMagic)
+	b = binary.AppendUvarint(b, nextNum)
+	for _, tables := range v.levels {
+		b = binary.AppendUvarint(b, uint64(len(tables)))
+		for _, t := range tables {
+			b = binary.AppendUvarint(b, t.num)
+		}
+	}
+	b = binary.LittleEndian.AppendUint32(b, crc32.Checksum(b, crcTable))
+
+	tmp := filepath.Join(dir, manifestName+".tmp")
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, manifestName)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// readManifest reads the manifest in dir, returning the table numbers of
+// each level and the next file number. A missing manifest is that of an
+// empty database.
+func readManifest(dir string) (levels [numLevels][]uint64, nextNum uint64, err error) {
+	b, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if os.IsNotExist(err) {
+		return levels, 1, nil
+	} else if err != nil {
+		return levels, 0, err
+	}
+	if len(b) < len(manifestMagic)+4 || string(b[:len(manifestMagic)]) != manifestMagic {
+		return levels, 0, ErrInvalidData
+	}
+	data := b[:len(b)-4]
+	if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(b[len(b)-4:]) {
+		return levels, 0, ErrInvalidData
+	}
+	data = data[len(manifestMagic):]
+	uvarint := func() uint64 {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			err = ErrInvalidData
+			return 0
+		}
+		data = data[n:]
+		return v
+	}
+	nextNum = uvarint()
+	for l := range levels {
+		count := uvarint()
+		if count > uint64(len(data)) {
+			return levels, 0, ErrInvalidData
+		}
+		for range count {
+			levels[l] = append(levels[l], uvarint())
+		}
+	}
+	if err != nil || len(data) != 0 {
+		return levels, 0, ErrInvalidData
+	}
+	return levels, nextNum, nil
+}
+
+// writeFileSync writes data to a new file at path, and syncs it.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir syncs the directory dir, making renames and file creations in it
+// durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
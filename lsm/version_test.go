@@ -0,0 +1,71 @@
+package lsm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	levels, nextNum, err := readManifest(dir)
+	if err != nil || nextNum != 1 {
+		t.Fatalf("got nextNum=%d, err=%v for a missing manifest", nextNum, err)
+	}
+
+	v := &version{}
+	v.levels[0] = []*table{{num: 7}, {num: 3}}
+	v.levels[2] = []*table{{num: 1}, {num: 200}, {num: 5}}
+	if err := writeManifest(dir, v, 201); err != nil {
+		t.Fatal(err)
+	}
+	levels, nextNum, err = readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nextNum != 201 {
+		t.Errorf("got nextNum=%d, want 201", nextNum)
+	}
+	for l := range numLevels {
+		if len(levels[l]) != len(v.levels[l]) {
+			t.Fatalf("level %d: got %v", l, levels[l])
+		}
+		for i, num := range levels[l] {
+			if num != v.levels[l][i].num {
+				t.Errorf("level %d: got %v", l, levels[l])
+			}
+		}
+	}
+
+	path := filepath.Join(dir, manifestName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		bad := append([]byte(nil), data...)
+		bad[i] ^= 0x10
+		if err := os.WriteFile(path, bad, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := readManifest(dir); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("corrupt byte %d: got err=%v, want ErrInvalidData", i, err)
+		}
+	}
+}
+
+func TestTableNames(t *testing.T) {
+	path := tablePath("db", 42)
+	if path != filepath.Join("db", "000042.sst") {
+		t.Errorf("got path %q", path)
+	}
+	if num, ok := parseTableName(filepath.Base(path)); !ok || num != 42 {
+		t.Errorf("got %d,%v, want 42,true", num, ok)
+	}
+	for _, name := range []string{"MANIFEST", "x.sst", "12.log", ".sst"} {
+		if _, ok := parseTableName(name); ok {
+			t.Errorf("%q parsed as a table name", name)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package merkle implements Merkle hash trees with inclusion proofs.
+package merkle
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"iter"
+)
+
+// Tree is a Merkle tree over a sequence of leaves: every leaf is hashed,
+// and every inner node holds the hash of its two children's hashes, up to
+// the root, whose hash commits to the whole sequence. An inclusion proof
+// for a leaf - the hashes of the siblings on its path to the root - lets
+// anyone who knows the root verify that the leaf is in the tree in
+// O(log n) time and space.
+//
+// The tree is built as specified by RFC 6962 (Certificate Transparency):
+// leaf and inner node hashes are prefixed by different bytes, so an inner
+// node can't be passed off as a leaf, and when a level has an odd number of
+// nodes the last one is promoted to the next level as is. Proofs are
+// compatible with other implementations of the RFC that use the same hash
+// function.
+//
+// Create trees with [New] or [FromSeq].
+type Tree struct {
+	newHash func() hash.Hash
+
+	// levels[0] holds the leaf hashes, and levels[i+1] the hashes of the
+	// nodes whose children are in levels[i]. The last level holds the
+	// root.
+	levels [][][]byte
+}
+
+// Prefixes of the hashed data of leaves and inner nodes.
+const (
+	leafPrefix = 0
+	nodePrefix = 1
+)
+
+// New creates a tree over leaves, hashing with hash functions created by
+// newHash (e.g. [crypto/sha256.New]).
+func New(newHash func() hash.Hash, leaves [][]byte) *Tree {
+	t := &Tree{newHash: newHash}
+	level := make([][]byte, len(leaves))
+	h := newHash()
+	for i, data := range leaves {
+		level[i] = hashLeaf(h, data)
+	}
+	t.levels = append(t.levels, level)
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i+1 < len(level); i += 2 {
+			next = append(next, hashNode(h, level[i], level[i+1]))
+		}
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t
+}
+
+// FromSeq creates a tree over the leaves in seq, like [New].
+func FromSeq(newHash func() hash.Hash, seq iter.Seq[[]byte]) *Tree {
+	var leaves [][]byte
+	for data := range seq {
+		leaves = append(leaves, data)
+	}
+	return New(newHash, leaves)
+}
+
+// Len returns the number of leaves in the tree.
+func (t *Tree) Len() int {
+	return len(t.levels[0])
+}
+
+// Root returns the root hash of the tree. The root of an empty tree is the
+// hash of no data.
+func (t *Tree) Root() []byte {
+	if t.Len() == 0 {
+		return t.newHash().Sum(nil)
+	}
+	return bytes.Clone(t.levels[len(t.levels)-1][0])
+}
+
+// LeafHash returns the hash of leaf i. It panics if i is out of range.
+func (t *Tree) LeafHash(i int) []byte {
+	t.checkIndex(i)
+	return bytes.Clone(t.levels[0][i])
+}
+
+// Proof is an inclusion proof for the leaf at Index in a tree with Size
+// leaves: the hashes of the siblings on the path from the leaf to the root,
+// bottom-up. A node promoted to the next level without a sibling adds no
+// hash.
+//
+// The tree size isn't committed to by the root alone: verifiers must obtain
+// Size from the same trusted source as the root.
+type Proof struct {
+	Index int
+	Size  int
+	Path  [][]byte
+}
+
+// Proof returns the inclusion proof for leaf i. It panics if i is out of
+// range.
+func (t *Tree) Proof(i int) Proof {
+	t.checkIndex(i)
+	p := Proof{Index: i, Size: t.Len()}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		if sibling := i ^ 1; sibling < len(level) {
+			p.Path = append(p.Path, bytes.Clone(level[sibling]))
+		}
+		i /= 2
+	}
+	return p
+}
+
+func (t *Tree) checkIndex(i int) {
+	if i < 0 || i >= t.Len() {
+		panic(fmt.Sprintf("merkle: leaf index %d out of range [0, %d)", i, t.Len()))
+	}
+}
+
+// Verify reports whether proof proves that data is the leaf at proof.Index
+// in a tree with proof.Size leaves and the given root hash, hashing with
+// hash functions created by newHash. It follows the verification algorithm
+// of RFC 9162, section 2.1.3.2.
+func Verify(newHash func() hash.Hash, root []byte, data []byte, proof Proof) bool {
+	if proof.Index < 0 || proof.Index >= proof.Size {
+		return false
+	}
+	h := newHash()
+	r := hashLeaf(h, data)
+	// fn is the index of the current node in its level, and sn the index
+	// of the last node in that level.
+	fn, sn := proof.Index, proof.Size-1
+	for _, p := range proof.Path {
+		if sn == 0 {
+			return false
+		}
+		if fn%2 == 1 || fn == sn {
+			r = hashNode(h, p, r)
+			// Skip the levels where the node is promoted without a sibling.
+			for fn%2 == 0 && fn != 0 {
+				fn /= 2
+				sn /= 2
+			}
+		} else {
+			r = hashNode(h, r, p)
+		}
+		fn /= 2
+		sn /= 2
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
+
+// HashLeaf returns the hash of a leaf holding data, as used in trees
+// hashing with hash functions created by newHash.
+func HashLeaf(newHash func() hash.Hash, data []byte) []byte {
+	return hashLeaf(newHash(), data)
+}
+
+func hashLeaf(h hash.Hash, data []byte) []byte {
+	h.Reset()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hashNode(h hash.Hash, left, right []byte) []byte {
+	h.Reset()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
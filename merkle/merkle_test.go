@@ -0,0 +1,186 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"testing"
+)
+
+func makeLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = []byte(fmt.Sprintf("leaf %d", i))
+	}
+	return leaves
+}
+
+// referenceRoot computes the root hash with the recursive definition of
+// RFC 6962, section 2.1: the leaves are split at the largest power of two
+// smaller than their number.
+func referenceRoot(newHash func() hash.Hash, leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		return newHash().Sum(nil)
+	case 1:
+		return HashLeaf(newHash, leaves[0])
+	}
+	k := 1
+	for k*2 < len(leaves) {
+		k *= 2
+	}
+	h := newHash()
+	h.Write([]byte{nodePrefix})
+	h.Write(referenceRoot(newHash, leaves[:k]))
+	h.Write(referenceRoot(newHash, leaves[k:]))
+	return h.Sum(nil)
+}
+
+func TestKnownHashes(t *testing.T) {
+	// Hashes from the RFC 6962 test vectors for SHA-256.
+	empty := New(sha256.New, nil)
+	if got := hex.EncodeToString(empty.Root()); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("empty root = %s", got)
+	}
+	single := New(sha256.New, [][]byte{{}})
+	if got := hex.EncodeToString(single.Root()); got != "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d" {
+		t.Errorf("single leaf root = %s", got)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	for n := range 70 {
+		leaves := makeLeaves(n)
+		tr := New(sha256.New, leaves)
+		if tr.Len() != n {
+			t.Fatalf("n=%d: Len=%d", n, tr.Len())
+		}
+		if want := referenceRoot(sha256.New, leaves); !bytes.Equal(tr.Root(), want) {
+			t.Fatalf("n=%d: root=%x, want %x", n, tr.Root(), want)
+		}
+		if fs := FromSeq(sha256.New, slices.Values(leaves)); !bytes.Equal(fs.Root(), tr.Root()) {
+			t.Fatalf("n=%d: FromSeq root differs", n)
+		}
+	}
+
+	// The root depends on the order and content of the leaves.
+	leaves := makeLeaves(5)
+	root := New(sha256.New, leaves).Root()
+	leaves[3], leaves[4] = leaves[4], leaves[3]
+	if bytes.Equal(New(sha256.New, leaves).Root(), root) {
+		t.Errorf("swapping leaves didn't change the root")
+	}
+}
+
+func TestProofs(t *testing.T) {
+	for n := 1; n < 70; n++ {
+		leaves := makeLeaves(n)
+		tr := New(sha256.New, leaves)
+		root := tr.Root()
+		for i, data := range leaves {
+			p := tr.Proof(i)
+			if p.Index != i || p.Size != n {
+				t.Fatalf("n=%d: Proof(%d) = %+v", n, i, p)
+			}
+			if !bytes.Equal(tr.LeafHash(i), HashLeaf(sha256.New, data)) {
+				t.Fatalf("n=%d: LeafHash(%d) mismatch", n, i)
+			}
+			if !Verify(sha256.New, root, data, p) {
+				t.Fatalf("n=%d: proof of leaf %d doesn't verify", n, i)
+			}
+
+			// Wrong data, index, size or root must fail.
+			if Verify(sha256.New, root, []byte("bogus"), p) {
+				t.Fatalf("n=%d: proof of leaf %d verifies bogus data", n, i)
+			}
+			if n > 1 {
+				q := p
+				q.Index = (i + 1) % n
+				if Verify(sha256.New, root, data, q) {
+					t.Fatalf("n=%d: proof of leaf %d verifies at index %d", n, i, q.Index)
+				}
+			}
+			q := p
+			q.Size = i
+			if Verify(sha256.New, root, data, q) {
+				t.Fatalf("n=%d: proof of leaf %d verifies with size %d", n, i, q.Size)
+			}
+			if Verify(sha256.New, tr.LeafHash(0)[:31], data, p) {
+				t.Fatalf("n=%d: proof of leaf %d verifies with bad root", n, i)
+			}
+		}
+	}
+}
+
+func TestTamperedProof(t *testing.T) {
+	leaves := makeLeaves(13)
+	tr := New(sha256.New, leaves)
+	root := tr.Root()
+	for i, data := range leaves {
+		p := tr.Proof(i)
+		for j := range p.Path {
+			q := p
+			q.Path = slices.Clone(p.Path)
+			q.Path[j] = bytes.Clone(q.Path[j])
+			q.Path[j][0] ^= 1
+			if Verify(sha256.New, root, data, q) {
+				t.Fatalf("leaf %d: proof with tampered hash %d verifies", i, j)
+			}
+		}
+		if len(p.Path) > 0 {
+			q := p
+			q.Path = p.Path[:len(p.Path)-1]
+			if Verify(sha256.New, root, data, q) {
+				t.Fatalf("leaf %d: truncated proof verifies", i)
+			}
+		}
+		q := p
+		q.Path = append(slices.Clone(p.Path), root)
+		if Verify(sha256.New, root, data, q) {
+			t.Fatalf("leaf %d: extended proof verifies", i)
+		}
+	}
+
+	// A leaf can't be passed off as an inner node: proving the hash of two
+	// leaves as a leaf of a smaller tree must fail.
+	inner := make([]byte, 0, 2*sha256.Size)
+	inner = append(inner, tr.LeafHash(0)...)
+	inner = append(inner, tr.LeafHash(1)...)
+	if Verify(sha256.New, root, inner, Proof{Index: 0, Size: 7, Path: tr.Proof(0).Path[1:]}) {
+		t.Errorf("second preimage attack succeeded")
+	}
+}
+
+func TestProofPanics(t *testing.T) {
+	tr := New(sha256.New, makeLeaves(3))
+	for _, i := range []int{-1, 3} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Proof(%d) didn't panic", i)
+				}
+			}()
+			tr.Proof(i)
+		}()
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	leaves := makeLeaves(10000)
+	for range b.N {
+		New(sha256.New, leaves)
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	leaves := makeLeaves(10000)
+	tr := New(sha256.New, leaves)
+	root := tr.Root()
+	p := tr.Proof(1234)
+	for range b.N {
+		Verify(sha256.New, root, leaves[1234], p)
+	}
+}
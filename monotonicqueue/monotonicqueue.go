@@ -0,0 +1,129 @@
+// Package monotonicqueue implements a FIFO queue that tracks the minimum and
+// maximum of its contents, for sliding-window computations.
+package monotonicqueue
+
+import "github.com/eliben/gogl/deque"
+
+// Queue is a FIFO queue that can report the minimal and maximal element it
+// holds in O(1). Push and Pop are O(1) amortized.
+//
+// A typical sliding-window use pushes new samples at the back and pops
+// expired samples from the front, querying Min or Max as needed.
+type Queue[T any] struct {
+	cmp func(T, T) int
+
+	// items holds all the elements in the queue, oldest first.
+	items *deque.Deque[T]
+
+	// mins and maxs are the monotonic deques: mins holds a strictly
+	// increasing (by seq) subsequence of items whose values are increasing,
+	// and its front is the minimal element of the queue. maxs is the same for
+	// decreasing values. An element is dropped from these deques as soon as
+	// a newer element that's at least as good arrives, since the older one
+	// can never become the extremum again.
+	mins, maxs *deque.Deque[entry[T]]
+
+	// pushed counts the total number of Push calls, and is used as the
+	// sequence number of the next element. popped counts the Pop calls; the
+	// oldest element in items has seq == popped.
+	pushed, popped uint64
+}
+
+type entry[T any] struct {
+	value T
+	seq   uint64
+}
+
+// New creates a new, empty Queue with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[T any](cmp func(a, b T) int) *Queue[T] {
+	return &Queue[T]{
+		cmp:   cmp,
+		items: deque.New[T](),
+		mins:  deque.New[entry[T]](),
+		maxs:  deque.New[entry[T]](),
+	}
+}
+
+// Len returns the number of elements in the queue.
+func (q *Queue[T]) Len() int {
+	return q.items.Len()
+}
+
+// Push adds v at the back of the queue.
+func (q *Queue[T]) Push(v T) {
+	e := entry[T]{value: v, seq: q.pushed}
+	q.pushed++
+	q.items.PushBack(v)
+
+	for q.mins.Len() > 0 && q.cmp(q.mins.Back().value, v) >= 0 {
+		q.mins.PopBack()
+	}
+	q.mins.PushBack(e)
+
+	for q.maxs.Len() > 0 && q.cmp(q.maxs.Back().value, v) <= 0 {
+		q.maxs.PopBack()
+	}
+	q.maxs.PushBack(e)
+}
+
+// Pop removes the oldest element from the queue and returns it. It panics if
+// the queue is empty; make sure to check Len() first.
+func (q *Queue[T]) Pop() T {
+	if q.items.Len() == 0 {
+		panic("popping from empty queue")
+	}
+	v := q.items.PopFront()
+
+	// The popped element is in mins/maxs only if it was at their front.
+	if q.mins.Front().seq == q.popped {
+		q.mins.PopFront()
+	}
+	if q.maxs.Front().seq == q.popped {
+		q.maxs.PopFront()
+	}
+	q.popped++
+	return v
+}
+
+// PopWhile pops elements from the front of the queue as long as pred returns
+// true for the oldest element, and returns the number of popped elements.
+// This is useful for time-based windows, e.g.:
+//
+//	q.PopWhile(func(s Sample) bool { return s.Time.Before(windowStart) })
+func (q *Queue[T]) PopWhile(pred func(T) bool) int {
+	n := 0
+	for q.items.Len() > 0 && pred(q.items.Front()) {
+		q.Pop()
+		n++
+	}
+	return n
+}
+
+// Front returns the oldest element in the queue. It panics if the queue is
+// empty.
+func (q *Queue[T]) Front() T {
+	if q.items.Len() == 0 {
+		panic("Front of empty queue")
+	}
+	return q.items.Front()
+}
+
+// Min returns the minimal element in the queue. It panics if the queue is
+// empty.
+func (q *Queue[T]) Min() T {
+	if q.items.Len() == 0 {
+		panic("Min of empty queue")
+	}
+	return q.mins.Front().value
+}
+
+// Max returns the maximal element in the queue. It panics if the queue is
+// empty.
+func (q *Queue[T]) Max() T {
+	if q.items.Len() == 0 {
+		panic("Max of empty queue")
+	}
+	return q.maxs.Front().value
+}
@@ -0,0 +1,96 @@
+package monotonicqueue
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestBasic(t *testing.T) {
+	q := New(cmp.Compare[int])
+	q.Push(5)
+	q.Push(3)
+	q.Push(8)
+	if q.Min() != 3 || q.Max() != 8 {
+		t.Errorf("got min=%v, max=%v; want 3, 8", q.Min(), q.Max())
+	}
+
+	if v := q.Pop(); v != 5 {
+		t.Errorf("got pop=%v, want 5", v)
+	}
+	if q.Min() != 3 || q.Max() != 8 {
+		t.Errorf("got min=%v, max=%v; want 3, 8", q.Min(), q.Max())
+	}
+	q.Pop()
+	if q.Min() != 8 || q.Max() != 8 {
+		t.Errorf("got min=%v, max=%v; want 8, 8", q.Min(), q.Max())
+	}
+	q.Pop()
+	if q.Len() != 0 {
+		t.Errorf("got len=%v, want 0", q.Len())
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	q := New(cmp.Compare[int])
+	for _, v := range []int{2, 2, 1, 1, 3, 3} {
+		q.Push(v)
+	}
+	want := [][2]int{{1, 3}, {1, 3}, {1, 3}, {1, 3}, {3, 3}, {3, 3}}
+	for _, w := range want {
+		if q.Min() != w[0] || q.Max() != w[1] {
+			t.Errorf("got min=%v, max=%v; want %v", q.Min(), q.Max(), w)
+		}
+		q.Pop()
+	}
+}
+
+func TestPopWhile(t *testing.T) {
+	type sample struct {
+		time  int
+		value float64
+	}
+	q := New(func(a, b sample) int { return cmp.Compare(a.value, b.value) })
+	for i, v := range []float64{1.5, 9.0, 0.5, 4.0, 3.0} {
+		q.Push(sample{time: i * 10, value: v})
+	}
+
+	// Expire samples older than time 20.
+	n := q.PopWhile(func(s sample) bool { return s.time < 20 })
+	if n != 2 || q.Len() != 3 {
+		t.Errorf("got popped=%d len=%d; want 2, 3", n, q.Len())
+	}
+	if q.Front().time != 20 {
+		t.Errorf("got front=%v", q.Front())
+	}
+	if q.Min().value != 0.5 || q.Max().value != 4.0 {
+		t.Errorf("got min=%v max=%v", q.Min(), q.Max())
+	}
+}
+
+func TestSlidingWindowRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, k := range []int{1, 3, 10, 50} {
+		data := make([]int, 1000)
+		for i := range data {
+			data[i] = rnd.IntN(100)
+		}
+
+		q := New(cmp.Compare[int])
+		for i, v := range data {
+			q.Push(v)
+			if q.Len() > k {
+				q.Pop()
+			}
+			window := data[max(0, i-k+1) : i+1]
+			if q.Min() != slices.Min(window) || q.Max() != slices.Max(window) {
+				t.Fatalf("k=%d i=%d: got min=%v max=%v, window %v", k, i, q.Min(), q.Max(), window)
+			}
+		}
+	}
+}
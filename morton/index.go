@@ -0,0 +1,177 @@
+package morton
+
+import (
+	"cmp"
+	"iter"
+	"math"
+
+	"github.com/eliben/gogl/btree"
+)
+
+// searchRanges is the maximal number of code ranges that box searches look
+// up in the tree.
+const searchRanges = 64
+
+// Index2 is a spatial index mapping points in 2D with integer coordinates
+// to values of type V, stored in a [btree.BTree] keyed by the points' Morton
+// codes. Every point holds at most one value. Box searches look up a few
+// ranges of codes covering the box in the tree, and skip the points outside
+// the box. Create indexes with [NewIndex2].
+type Index2[V any] struct {
+	bt *btree.BTree[uint64, V]
+}
+
+// NewIndex2 creates a new, empty index.
+func NewIndex2[V any]() *Index2[V] {
+	return &Index2[V]{bt: btree.New[uint64, V](cmp.Compare[uint64])}
+}
+
+// Len returns the number of points in the index.
+func (ix *Index2[V]) Len() int {
+	return ix.bt.Len()
+}
+
+// Set sets the value of point p to v.
+func (ix *Index2[V]) Set(p [2]uint32, v V) {
+	ix.bt.Insert(Encode2(p[0], p[1]), v)
+}
+
+// Get returns the value of point p and ok=true; if p isn't in the index, it
+// returns ok=false.
+func (ix *Index2[V]) Get(p [2]uint32) (v V, ok bool) {
+	return ix.bt.Get(Encode2(p[0], p[1]))
+}
+
+// Delete removes point p from the index. It returns true if p was found.
+func (ix *Index2[V]) Delete(p [2]uint32) bool {
+	return ix.bt.Delete(Encode2(p[0], p[1]))
+}
+
+// All returns an iterator over all the points in the index with their
+// values, in Z-order.
+func (ix *Index2[V]) All() iter.Seq2[[2]uint32, V] {
+	return func(yield func([2]uint32, V) bool) {
+		for code, v := range ix.bt.All() {
+			x, y := Decode2(code)
+			if !yield([2]uint32{x, y}, v) {
+				return
+			}
+		}
+	}
+}
+
+// Search returns an iterator over the points p in the box
+// lo[0] <= p[0] <= hi[0], lo[1] <= p[1] <= hi[1] with their values, in
+// Z-order.
+func (ix *Index2[V]) Search(lo, hi [2]uint32) iter.Seq2[[2]uint32, V] {
+	return func(yield func([2]uint32, V) bool) {
+		for _, r := range Ranges2(lo, hi, searchRanges) {
+			for code, v := range ascend(ix.bt, r) {
+				x, y := Decode2(code)
+				if x < lo[0] || x > hi[0] || y < lo[1] || y > hi[1] {
+					continue
+				}
+				if !yield([2]uint32{x, y}, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Index3 is like [Index2], for points in 3D with coordinates up to
+// [MaxCoord3]. Create indexes with [NewIndex3].
+type Index3[V any] struct {
+	bt *btree.BTree[uint64, V]
+}
+
+// NewIndex3 creates a new, empty index.
+func NewIndex3[V any]() *Index3[V] {
+	return &Index3[V]{bt: btree.New[uint64, V](cmp.Compare[uint64])}
+}
+
+// Len returns the number of points in the index.
+func (ix *Index3[V]) Len() int {
+	return ix.bt.Len()
+}
+
+// Set sets the value of point p to v. It panics if a coordinate of p is
+// larger than [MaxCoord3].
+func (ix *Index3[V]) Set(p [3]uint32, v V) {
+	ix.bt.Insert(Encode3(p[0], p[1], p[2]), v)
+}
+
+// Get returns the value of point p and ok=true; if p isn't in the index, it
+// returns ok=false.
+func (ix *Index3[V]) Get(p [3]uint32) (v V, ok bool) {
+	if p[0] > MaxCoord3 || p[1] > MaxCoord3 || p[2] > MaxCoord3 {
+		return v, false
+	}
+	return ix.bt.Get(Encode3(p[0], p[1], p[2]))
+}
+
+// Delete removes point p from the index. It returns true if p was found.
+func (ix *Index3[V]) Delete(p [3]uint32) bool {
+	if p[0] > MaxCoord3 || p[1] > MaxCoord3 || p[2] > MaxCoord3 {
+		return false
+	}
+	return ix.bt.Delete(Encode3(p[0], p[1], p[2]))
+}
+
+// All returns an iterator over all the points in the index with their
+// values, in Z-order.
+func (ix *Index3[V]) All() iter.Seq2[[3]uint32, V] {
+	return func(yield func([3]uint32, V) bool) {
+		for code, v := range ix.bt.All() {
+			x, y, z := Decode3(code)
+			if !yield([3]uint32{x, y, z}, v) {
+				return
+			}
+		}
+	}
+}
+
+// Search returns an iterator over the points p in the box with
+// lo[a] <= p[a] <= hi[a] along every axis a, with their values, in Z-order.
+// The box may extend beyond [MaxCoord3].
+func (ix *Index3[V]) Search(lo, hi [3]uint32) iter.Seq2[[3]uint32, V] {
+	return func(yield func([3]uint32, V) bool) {
+		for a := range 3 {
+			if lo[a] > MaxCoord3 {
+				return
+			}
+			hi[a] = min(hi[a], MaxCoord3)
+		}
+		for _, r := range Ranges3(lo, hi, searchRanges) {
+			for code, v := range ascend(ix.bt, r) {
+				p := [3]uint32{}
+				p[0], p[1], p[2] = Decode3(code)
+				if p[0] < lo[0] || p[0] > hi[0] || p[1] < lo[1] || p[1] > hi[1] || p[2] < lo[2] || p[2] > hi[2] {
+					continue
+				}
+				if !yield(p, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ascend returns an iterator over the entries of bt with codes in the
+// inclusive range r.
+func ascend[V any](bt *btree.BTree[uint64, V], r Range) iter.Seq2[uint64, V] {
+	if r.Hi < math.MaxUint64 {
+		return bt.Range(r.Lo, r.Hi+1)
+	}
+	// The range's upper bound can't be expressed as an exclusive bound.
+	return func(yield func(uint64, V) bool) {
+		for code, v := range bt.Range(r.Lo, math.MaxUint64) {
+			if !yield(code, v) {
+				return
+			}
+		}
+		if v, ok := bt.Get(math.MaxUint64); ok {
+			yield(math.MaxUint64, v)
+		}
+	}
+}
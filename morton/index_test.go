@@ -0,0 +1,159 @@
+package morton
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestIndex2(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	ix := NewIndex2[int]()
+	want := make(map[[2]uint32]int)
+	for i := range 3000 {
+		p := [2]uint32{rnd.Uint32N(500), rnd.Uint32N(500)}
+		ix.Set(p, i)
+		want[p] = i
+	}
+	// Points at the extremes of the coordinate space.
+	for _, p := range [][2]uint32{{0, 0}, {math.MaxUint32, math.MaxUint32}, {math.MaxUint32, 0}} {
+		ix.Set(p, -1)
+		want[p] = -1
+	}
+	if ix.Len() != len(want) {
+		t.Fatalf("Len=%d, want %d", ix.Len(), len(want))
+	}
+	for p, v := range want {
+		if got, ok := ix.Get(p); !ok || got != v {
+			t.Fatalf("Get(%v) = %d, %v", p, got, ok)
+		}
+	}
+
+	var prev uint64
+	n := 0
+	for p, v := range ix.All() {
+		code := Encode2(p[0], p[1])
+		if (n > 0 && code <= prev) || want[p] != v {
+			t.Fatalf("All yielded %v=%d out of order", p, v)
+		}
+		prev = code
+		n++
+	}
+	if n != len(want) {
+		t.Fatalf("All yielded %d points", n)
+	}
+
+	search := func(lo, hi [2]uint32) {
+		t.Helper()
+		var expected [][2]uint32
+		for p := range want {
+			if p[0] >= lo[0] && p[0] <= hi[0] && p[1] >= lo[1] && p[1] <= hi[1] {
+				expected = append(expected, p)
+			}
+		}
+		slices.SortFunc(expected, func(a, b [2]uint32) int {
+			return cmp.Compare(Encode2(a[0], a[1]), Encode2(b[0], b[1]))
+		})
+		var got [][2]uint32
+		for p, v := range ix.Search(lo, hi) {
+			if v != want[p] {
+				t.Fatalf("Search yielded %v=%d, want %d", p, v, want[p])
+			}
+			got = append(got, p)
+		}
+		if !slices.Equal(got, expected) {
+			t.Fatalf("Search(%v, %v) = %v, want %v", lo, hi, got, expected)
+		}
+	}
+	for range 200 {
+		lo := [2]uint32{rnd.Uint32N(500), rnd.Uint32N(500)}
+		hi := [2]uint32{lo[0] + rnd.Uint32N(100), lo[1] + rnd.Uint32N(100)}
+		search(lo, hi)
+	}
+	search([2]uint32{}, [2]uint32{math.MaxUint32, math.MaxUint32})
+	search([2]uint32{1000, 0}, [2]uint32{math.MaxUint32, math.MaxUint32})
+
+	for p := range want {
+		if !ix.Delete(p) || ix.Delete(p) {
+			t.Fatalf("Delete(%v) failed", p)
+		}
+		if _, ok := ix.Get(p); ok {
+			t.Fatalf("Get(%v) after Delete", p)
+		}
+	}
+	if ix.Len() != 0 {
+		t.Errorf("Len=%d after deleting all points", ix.Len())
+	}
+}
+
+func TestIndex3(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	ix := NewIndex3[int]()
+	want := make(map[[3]uint32]int)
+	for i := range 3000 {
+		p := [3]uint32{rnd.Uint32N(100), rnd.Uint32N(100), rnd.Uint32N(100)}
+		ix.Set(p, i)
+		want[p] = i
+	}
+	ix.Set([3]uint32{MaxCoord3, MaxCoord3, MaxCoord3}, -1)
+	want[[3]uint32{MaxCoord3, MaxCoord3, MaxCoord3}] = -1
+	if ix.Len() != len(want) {
+		t.Fatalf("Len=%d, want %d", ix.Len(), len(want))
+	}
+	if _, ok := ix.Get([3]uint32{0, MaxCoord3 + 1, 0}); ok || ix.Delete([3]uint32{0, MaxCoord3 + 1, 0}) {
+		t.Errorf("found out of range point")
+	}
+
+	search := func(lo, hi [3]uint32) {
+		t.Helper()
+		expected := 0
+		for p := range want {
+			if p[0] >= lo[0] && p[0] <= hi[0] && p[1] >= lo[1] && p[1] <= hi[1] && p[2] >= lo[2] && p[2] <= hi[2] {
+				expected++
+			}
+		}
+		got := 0
+		for p, v := range ix.Search(lo, hi) {
+			if v != want[p] || p[0] < lo[0] || p[0] > hi[0] || p[1] < lo[1] || p[1] > hi[1] || p[2] < lo[2] || p[2] > hi[2] {
+				t.Fatalf("Search(%v, %v) yielded %v=%d", lo, hi, p, v)
+			}
+			got++
+		}
+		if got != expected {
+			t.Fatalf("Search(%v, %v) yielded %d points, want %d", lo, hi, got, expected)
+		}
+	}
+	for range 200 {
+		lo := [3]uint32{rnd.Uint32N(100), rnd.Uint32N(100), rnd.Uint32N(100)}
+		hi := [3]uint32{lo[0] + rnd.Uint32N(40), lo[1] + rnd.Uint32N(40), lo[2] + rnd.Uint32N(40)}
+		search(lo, hi)
+	}
+	search([3]uint32{}, [3]uint32{math.MaxUint32, math.MaxUint32, math.MaxUint32})
+	search([3]uint32{MaxCoord3 + 1, 0, 0}, [3]uint32{math.MaxUint32, math.MaxUint32, math.MaxUint32})
+
+	n := 0
+	for range ix.Search([3]uint32{}, [3]uint32{99, 99, 99}) {
+		if n++; n == 5 {
+			break
+		}
+	}
+	if n != 5 {
+		t.Errorf("early stop: %d points", n)
+	}
+}
+
+func BenchmarkIndex2Search(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	ix := NewIndex2[int]()
+	for i := range 100000 {
+		ix.Set([2]uint32{rnd.Uint32N(10000), rnd.Uint32N(10000)}, i)
+	}
+	b.ResetTimer()
+	for range b.N {
+		lo := [2]uint32{rnd.Uint32N(9000), rnd.Uint32N(9000)}
+		for range ix.Search(lo, [2]uint32{lo[0] + 500, lo[1] + 500}) {
+		}
+	}
+}
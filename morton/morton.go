@@ -0,0 +1,198 @@
+// Package morton implements Morton codes (Z-order curve keys) for points in
+// 2D and 3D, and spatial indexes storing points in a B-tree by their codes.
+//
+// The Morton code of a point interleaves the bits of its coordinates, so
+// sorting points by their codes orders them along a Z-shaped space-filling
+// curve that keeps nearby points mostly close together; any axis-aligned box
+// is covered by a few ranges of codes, which can be looked up in any ordered
+// structure.
+package morton
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxCoord3 is the largest coordinate of points in 3D: every coordinate
+// takes 21 bits of a 63-bit code.
+const MaxCoord3 = 1<<21 - 1
+
+// Encode2 returns the Morton code of the point (x, y): bit i of x is bit 2i
+// of the code, and bit i of y is bit 2i+1.
+func Encode2(x, y uint32) uint64 {
+	return spread2(x) | spread2(y)<<1
+}
+
+// Decode2 returns the point whose Morton code is code.
+func Decode2(code uint64) (x, y uint32) {
+	return compact2(code), compact2(code >> 1)
+}
+
+// Encode3 returns the Morton code of the point (x, y, z): bit i of x, y and
+// z is bit 3i, 3i+1 and 3i+2 of the code, respectively. It panics if a
+// coordinate is larger than [MaxCoord3].
+func Encode3(x, y, z uint32) uint64 {
+	if x > MaxCoord3 || y > MaxCoord3 || z > MaxCoord3 {
+		panic(fmt.Sprintf("morton: coordinates (%d, %d, %d) out of range", x, y, z))
+	}
+	return spread3(x) | spread3(y)<<1 | spread3(z)<<2
+}
+
+// Decode3 returns the point whose Morton code is code.
+func Decode3(code uint64) (x, y, z uint32) {
+	return compact3(code), compact3(code >> 1), compact3(code >> 2)
+}
+
+// spread2 spreads the bits of x to the even bits of the result.
+func spread2(x uint32) uint64 {
+	v := uint64(x)
+	v = (v | v<<16) & 0x0000ffff0000ffff
+	v = (v | v<<8) & 0x00ff00ff00ff00ff
+	v = (v | v<<4) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v<<2) & 0x3333333333333333
+	v = (v | v<<1) & 0x5555555555555555
+	return v
+}
+
+// compact2 is the inverse of spread2, ignoring the odd bits of v.
+func compact2(v uint64) uint32 {
+	v &= 0x5555555555555555
+	v = (v | v>>1) & 0x3333333333333333
+	v = (v | v>>2) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v>>4) & 0x00ff00ff00ff00ff
+	v = (v | v>>8) & 0x0000ffff0000ffff
+	v = (v | v>>16) & 0x00000000ffffffff
+	return uint32(v)
+}
+
+// spread3 spreads the low 21 bits of x to every third bit of the result.
+func spread3(x uint32) uint64 {
+	v := uint64(x) & MaxCoord3
+	v = (v | v<<32) & 0x001f00000000ffff
+	v = (v | v<<16) & 0x001f0000ff0000ff
+	v = (v | v<<8) & 0x100f00f00f00f00f
+	v = (v | v<<4) & 0x10c30c30c30c30c3
+	v = (v | v<<2) & 0x1249249249249249
+	return v
+}
+
+// compact3 is the inverse of spread3, ignoring the other bits of v.
+func compact3(v uint64) uint32 {
+	v &= 0x1249249249249249
+	v = (v | v>>2) & 0x10c30c30c30c30c3
+	v = (v | v>>4) & 0x100f00f00f00f00f
+	v = (v | v>>8) & 0x001f0000ff0000ff
+	v = (v | v>>16) & 0x001f00000000ffff
+	v = (v | v>>32) & MaxCoord3
+	return uint32(v)
+}
+
+// Range is an inclusive range [Lo, Hi] of Morton codes.
+type Range struct {
+	Lo, Hi uint64
+}
+
+// Ranges2 returns sorted, disjoint ranges of Morton codes covering the codes
+// of all the points (x, y) in the box lo[0] <= x <= hi[0],
+// lo[1] <= y <= hi[1]. At most maxRanges ranges are returned, so they may
+// also cover codes of points outside the box; the more ranges are allowed,
+// the tighter the cover. It returns nil if the box is empty, and panics if
+// maxRanges < 1.
+func Ranges2(lo, hi [2]uint32, maxRanges int) []Range {
+	return ranges(lo[:], hi[:], 32, maxRanges)
+}
+
+// Ranges3 is like [Ranges2], for 3D points. It panics if a coordinate is
+// larger than [MaxCoord3].
+func Ranges3(lo, hi [3]uint32, maxRanges int) []Range {
+	Encode3(lo[0], lo[1], lo[2])
+	Encode3(hi[0], hi[1], hi[2])
+	return ranges(lo[:], hi[:], 21, maxRanges)
+}
+
+// cell is a cube of the Z-order decomposition of space: the points whose
+// codes share a prefix.
+type cell struct {
+	// corner holds the minimal coordinates of the cell, and code their
+	// Morton code.
+	corner [3]uint32
+	code   uint64
+
+	// The cell spans 2^level coordinates along every axis.
+	level int
+
+	// full is set if the cell lies entirely within the searched box.
+	full bool
+}
+
+// ranges implements Ranges2 and Ranges3 for a box with corners lo and hi,
+// and bits bits per coordinate. It refines the cover of the box level by
+// level, splitting the cells that are partly in the box into their children
+// in Z-order, for as long as the cover has at most maxRanges cells.
+func ranges(lo, hi []uint32, bits, maxRanges int) []Range {
+	if maxRanges < 1 {
+		panic(fmt.Sprintf("morton: invalid max ranges %d", maxRanges))
+	}
+	dims := len(lo)
+	for a := range dims {
+		if lo[a] > hi[a] {
+			return nil
+		}
+	}
+
+	// classify returns whether c intersects the box and whether it's full.
+	classify := func(c *cell) bool {
+		c.full = true
+		for a := range dims {
+			cellHi := uint64(c.corner[a]) + 1<<c.level - 1
+			if cellHi < uint64(lo[a]) || c.corner[a] > hi[a] {
+				return false
+			}
+			if c.corner[a] < lo[a] || cellHi > uint64(hi[a]) {
+				c.full = false
+			}
+		}
+		return true
+	}
+
+	root := cell{level: bits}
+	classify(&root)
+	cells := []cell{root}
+	for {
+		var next []cell
+		split := false
+		for _, c := range cells {
+			if c.full || c.level == 0 {
+				next = append(next, c)
+				continue
+			}
+			split = true
+			for i := range 1 << dims {
+				child := cell{code: c.code | uint64(i)<<(dims*(c.level-1)), level: c.level - 1}
+				for a := range dims {
+					child.corner[a] = c.corner[a] | uint32(i>>a&1)<<child.level
+				}
+				if classify(&child) {
+					next = append(next, child)
+				}
+			}
+		}
+		if !split || len(next) > maxRanges {
+			break
+		}
+		cells = next
+	}
+
+	var result []Range
+	for _, c := range cells {
+		// For the root cell in 2D, the shift overflows to 0 and the range
+		// spans all codes, as intended.
+		r := Range{c.code, c.code + (1<<(dims*c.level) - 1)}
+		if n := len(result); n > 0 && result[n-1].Hi != math.MaxUint64 && result[n-1].Hi+1 == r.Lo {
+			result[n-1].Hi = r.Hi
+		} else {
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,162 @@
+package morton
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// interleave is a simple reference implementation of Morton encoding.
+func interleave(coords ...uint32) uint64 {
+	var code uint64
+	for i := range 64 / len(coords) {
+		for a, c := range coords {
+			code |= uint64(c>>i&1) << (i*len(coords) + a)
+		}
+	}
+	return code
+}
+
+func TestEncode(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	if Encode2(0b101, 0b011) != 0b011011 || Encode3(1, 1, 0) != 0b011 {
+		t.Errorf("bad known codes")
+	}
+	if Encode2(math.MaxUint32, math.MaxUint32) != math.MaxUint64 || Encode3(MaxCoord3, MaxCoord3, MaxCoord3) != 1<<63-1 {
+		t.Errorf("bad maximal codes")
+	}
+	for range 10000 {
+		x, y, z := rnd.Uint32(), rnd.Uint32(), rnd.Uint32N(MaxCoord3+1)
+		code := Encode2(x, y)
+		if want := interleave(x, y); code != want {
+			t.Fatalf("Encode2(%d, %d) = %x, want %x", x, y, code, want)
+		}
+		if dx, dy := Decode2(code); dx != x || dy != y {
+			t.Fatalf("Decode2(%x) = %d, %d", code, dx, dy)
+		}
+
+		x, y = x&MaxCoord3, y&MaxCoord3
+		code = Encode3(x, y, z)
+		if want := interleave(x, y, z); code != want {
+			t.Fatalf("Encode3(%d, %d, %d) = %x, want %x", x, y, z, code, want)
+		}
+		if dx, dy, dz := Decode3(code); dx != x || dy != y || dz != z {
+			t.Fatalf("Decode3(%x) = %d, %d, %d", code, dx, dy, dz)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Encode3 didn't panic")
+		}
+	}()
+	Encode3(0, MaxCoord3+1, 0)
+}
+
+// checkRanges checks that ranges are sorted, disjoint and non-adjacent, that
+// there are at most maxRanges of them, and that they cover all of boxCodes.
+func checkRanges(t *testing.T, ranges []Range, maxRanges int, boxCodes []uint64) {
+	t.Helper()
+	if len(ranges) > maxRanges {
+		t.Fatalf("%d ranges, max %d", len(ranges), maxRanges)
+	}
+	for i, r := range ranges {
+		if r.Lo > r.Hi || (i > 0 && ranges[i-1].Hi+1 >= r.Lo) {
+			t.Fatalf("bad ranges %v", ranges)
+		}
+	}
+	covered := func(code uint64) bool {
+		for _, r := range ranges {
+			if r.Lo <= code && code <= r.Hi {
+				return true
+			}
+		}
+		return false
+	}
+	for _, code := range boxCodes {
+		if !covered(code) {
+			t.Fatalf("code %x in box not covered by %v", code, ranges)
+		}
+	}
+}
+
+func TestRanges2(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for range 300 {
+		var lo, hi [2]uint32
+		for a := range 2 {
+			lo[a] = rnd.Uint32N(100)
+			hi[a] = lo[a] + rnd.Uint32N(20)
+		}
+		var codes []uint64
+		for x := lo[0]; x <= hi[0]; x++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				codes = append(codes, Encode2(x, y))
+			}
+		}
+		for _, maxRanges := range []int{1, 3, 16, 1000} {
+			ranges := Ranges2(lo, hi, maxRanges)
+			checkRanges(t, ranges, maxRanges, codes)
+
+			// With enough ranges, the cover is exact.
+			if maxRanges == 1000 {
+				n := 0
+				for _, r := range ranges {
+					n += int(r.Hi - r.Lo + 1)
+				}
+				if n != len(codes) {
+					t.Fatalf("Ranges2(%v, %v) covers %d codes, want %d", lo, hi, n, len(codes))
+				}
+			}
+		}
+	}
+
+	// Edge cases: the whole space, a single point and an empty box.
+	if r := Ranges2([2]uint32{}, [2]uint32{math.MaxUint32, math.MaxUint32}, 4); len(r) != 1 || r[0] != (Range{0, math.MaxUint64}) {
+		t.Errorf("whole space: %v", r)
+	}
+	if r := Ranges2([2]uint32{7, 9}, [2]uint32{7, 9}, 4); len(r) != 1 || r[0] != (Range{Encode2(7, 9), Encode2(7, 9)}) {
+		t.Errorf("single point: %v", r)
+	}
+	if r := Ranges2([2]uint32{7, 9}, [2]uint32{6, 9}, 4); r != nil {
+		t.Errorf("empty box: %v", r)
+	}
+}
+
+func TestRanges3(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for range 100 {
+		var lo, hi [3]uint32
+		for a := range 3 {
+			lo[a] = MaxCoord3 - 50 - rnd.Uint32N(50)
+			hi[a] = lo[a] + rnd.Uint32N(10)
+		}
+		var codes []uint64
+		for x := lo[0]; x <= hi[0]; x++ {
+			for y := lo[1]; y <= hi[1]; y++ {
+				for z := lo[2]; z <= hi[2]; z++ {
+					codes = append(codes, Encode3(x, y, z))
+				}
+			}
+		}
+		for _, maxRanges := range []int{1, 8, 64} {
+			checkRanges(t, Ranges3(lo, hi, maxRanges), maxRanges, codes)
+		}
+	}
+	if r := Ranges3([3]uint32{}, [3]uint32{MaxCoord3, MaxCoord3, MaxCoord3}, 4); len(r) != 1 || r[0] != (Range{0, 1<<63 - 1}) {
+		t.Errorf("whole space: %v", r)
+	}
+}
+
+func BenchmarkRanges2(b *testing.B) {
+	for range b.N {
+		Ranges2([2]uint32{1000, 2000}, [2]uint32{5000, 3000}, 64)
+	}
+}
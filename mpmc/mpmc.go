@@ -0,0 +1,232 @@
+// Package mpmc implements a bounded, lock-free queue for multiple producers
+// and multiple consumers.
+package mpmc
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync/atomic"
+)
+
+// cacheLine is the assumed size of a CPU cache line; hot fields written by
+// different goroutines are padded apart to avoid false sharing.
+const cacheLine = 64
+
+// Queue is a bounded FIFO queue safe for concurrent use by any number of
+// producers and consumers. Its TryEnqueue and TryDequeue methods never block
+// and don't take locks: each costs a single compare-and-swap in the
+// uncontended case. Use [Blocking] to wait for room or for elements.
+//
+// This is Dmitry Vyukov's bounded MPMC queue: a ring of cells, each with a
+// sequence number telling whether it's ready to be written or read at the
+// current lap around the ring. Producers and consumers claim positions by
+// incrementing separate counters, so they only contend with their own kind.
+//
+// Create queues with [New].
+type Queue[T any] struct {
+	_     [cacheLine]byte
+	tail  atomic.Uint64 // next position to enqueue at
+	_     [cacheLine - 8]byte
+	head  atomic.Uint64 // next position to dequeue from
+	_     [cacheLine - 8]byte
+	mask  uint64
+	cells []cell[T]
+}
+
+type cell[T any] struct {
+	// seq is pos when the cell is ready to be written for position pos, and
+	// pos+1 when it's ready to be read for pos.
+	seq   atomic.Uint64
+	value T
+}
+
+// New creates a new, empty Queue holding up to capacity elements; capacity
+// is rounded up to a power of two, and to at least 2, since the sequence
+// numbers can't tell a full one-cell ring from an empty one. It panics if
+// capacity is not positive.
+func New[T any](capacity int) *Queue[T] {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("mpmc: invalid capacity %d", capacity))
+	}
+	n := max(2, 1<<bits.Len(uint(capacity-1)))
+	q := &Queue[T]{mask: uint64(n - 1), cells: make([]cell[T], n)}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// Cap returns the capacity of the queue.
+func (q *Queue[T]) Cap() int {
+	return len(q.cells)
+}
+
+// Len returns the number of elements in the queue. With concurrent
+// operations in progress, the result is only an approximation.
+func (q *Queue[T]) Len() int {
+	head := q.head.Load()
+	tail := q.tail.Load()
+	if tail < head {
+		return 0
+	}
+	return min(int(tail-head), len(q.cells))
+}
+
+// TryEnqueue adds v to the back of the queue. It returns false, without
+// waiting, if the queue is full.
+func (q *Queue[T]) TryEnqueue(v T) bool {
+	pos := q.tail.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		switch dif := int64(c.seq.Load() - pos); {
+		case dif == 0:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				c.value = v
+				c.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.tail.Load()
+		case dif < 0:
+			// The cell still holds the element from the previous lap.
+			return false
+		default:
+			// Another producer claimed pos; catch up.
+			pos = q.tail.Load()
+		}
+	}
+}
+
+// TryDequeue removes the element at the front of the queue and returns it
+// and true. It returns false, without waiting, if the queue is empty.
+func (q *Queue[T]) TryDequeue() (v T, ok bool) {
+	pos := q.head.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		switch dif := int64(c.seq.Load() - (pos + 1)); {
+		case dif == 0:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				v = c.value
+				c.value = *new(T)
+				c.seq.Store(pos + q.mask + 1)
+				return v, true
+			}
+			pos = q.head.Load()
+		case dif < 0:
+			// The cell hasn't been written at this lap yet.
+			return v, false
+		default:
+			// Another consumer claimed pos; catch up.
+			pos = q.head.Load()
+		}
+	}
+}
+
+// Blocking wraps a [Queue] with operations that wait for room or for
+// elements. Waiting is done on channels used as counting semaphores, so the
+// blocking operations cost about as much as a channel operation; the
+// non-blocking ones stay cheap.
+//
+// Create blocking queues with [NewBlocking].
+type Blocking[T any] struct {
+	q *Queue[T]
+
+	// slots holds a token for every claimed slot: producers send to it
+	// before enqueuing and consumers receive from it after dequeuing. items
+	// holds a token for every element ready to be dequeued.
+	slots chan struct{}
+	items chan struct{}
+}
+
+// NewBlocking creates a new, empty Blocking queue holding up to capacity
+// elements. It panics if capacity is not positive.
+func NewBlocking[T any](capacity int) *Blocking[T] {
+	q := New[T](capacity)
+	return &Blocking[T]{
+		q:     q,
+		slots: make(chan struct{}, q.Cap()),
+		items: make(chan struct{}, q.Cap()),
+	}
+}
+
+// Cap returns the capacity of the queue.
+func (b *Blocking[T]) Cap() int {
+	return b.q.Cap()
+}
+
+// Len returns the number of elements in the queue. With concurrent
+// operations in progress, the result is only an approximation.
+func (b *Blocking[T]) Len() int {
+	return len(b.items)
+}
+
+// Enqueue adds v to the back of the queue, waiting for room if the queue is
+// full. It returns ctx.Err() if ctx is done before there's room.
+func (b *Blocking[T]) Enqueue(ctx context.Context, v T) error {
+	select {
+	case b.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	b.enqueue(v)
+	return nil
+}
+
+// TryEnqueue adds v to the back of the queue. It returns false, without
+// waiting, if the queue is full.
+func (b *Blocking[T]) TryEnqueue(v T) bool {
+	select {
+	case b.slots <- struct{}{}:
+	default:
+		return false
+	}
+	b.enqueue(v)
+	return true
+}
+
+// Dequeue removes the element at the front of the queue and returns it,
+// waiting for one if the queue is empty. It returns ctx.Err() if ctx is done
+// before an element is available.
+func (b *Blocking[T]) Dequeue(ctx context.Context) (T, error) {
+	select {
+	case <-b.items:
+	case <-ctx.Done():
+		return *new(T), ctx.Err()
+	}
+	return b.dequeue(), nil
+}
+
+// TryDequeue removes the element at the front of the queue and returns it
+// and true. It returns false, without waiting, if the queue is empty.
+func (b *Blocking[T]) TryDequeue() (T, bool) {
+	select {
+	case <-b.items:
+	default:
+		return *new(T), false
+	}
+	return b.dequeue(), true
+}
+
+// enqueue adds v to the queue, given a slot token. The token guarantees
+// that fewer than Cap elements are claimed; the queue may still report
+// being full for a moment, until a consumer that has already taken its
+// element finishes releasing its cell.
+func (b *Blocking[T]) enqueue(v T) {
+	for !b.q.TryEnqueue(v) {
+		runtime.Gosched()
+	}
+	b.items <- struct{}{}
+}
+
+// dequeue removes an element from the queue, given an item token. As with
+// enqueue, the element may take a moment to become visible.
+func (b *Blocking[T]) dequeue() T {
+	for {
+		if v, ok := b.q.TryDequeue(); ok {
+			<-b.slots
+			return v
+		}
+		runtime.Gosched()
+	}
+}
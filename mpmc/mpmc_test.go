@@ -0,0 +1,239 @@
+package mpmc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCapacity(t *testing.T) {
+	for _, tt := range []struct{ capacity, want int }{{1, 2}, {2, 2}, {3, 4}, {8, 8}, {1000, 1024}} {
+		q := New[int](tt.capacity)
+		if q.Cap() != tt.want {
+			t.Errorf("New(%d).Cap()=%d, want %d", tt.capacity, q.Cap(), tt.want)
+		}
+		for i := range tt.want {
+			if !q.TryEnqueue(i) {
+				t.Fatalf("TryEnqueue failed with %d elements", i)
+			}
+		}
+		if q.TryEnqueue(-1) || q.Len() != tt.want {
+			t.Errorf("full queue: Len=%d", q.Len())
+		}
+	}
+}
+
+func TestSequential(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	q := New[int](16)
+	var model []int
+	for i := range 10000 {
+		if rnd.IntN(2) == 0 {
+			ok := q.TryEnqueue(i)
+			if ok != (len(model) < 16) {
+				t.Fatalf("TryEnqueue(%d)=%v with %d elements", i, ok, len(model))
+			}
+			if ok {
+				model = append(model, i)
+			}
+		} else {
+			v, ok := q.TryDequeue()
+			if ok != (len(model) > 0) || (ok && v != model[0]) {
+				t.Fatalf("TryDequeue()=%d,%v, model %v", v, ok, model)
+			}
+			if ok {
+				model = model[1:]
+			}
+		}
+		if q.Len() != len(model) {
+			t.Fatalf("Len=%d, want %d", q.Len(), len(model))
+		}
+	}
+}
+
+// stress runs producers and consumers concurrently over enqueue and
+// dequeue, checking that every element is delivered exactly once, and that
+// each consumer sees every producer's elements in order.
+func stress(t *testing.T, enqueue func(int), dequeue func() int) {
+	t.Helper()
+	const producers = 4
+	const consumers = 4
+	const n = 20000
+
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				enqueue(p*n + i)
+			}
+		}()
+	}
+
+	seen := make([][]int, consumers)
+	var cwg sync.WaitGroup
+	for c := range consumers {
+		cwg.Add(1)
+		go func() {
+			defer cwg.Done()
+			last := make([]int, producers)
+			for p := range last {
+				last[p] = -1
+			}
+			for {
+				v := dequeue()
+				if v < 0 {
+					return
+				}
+				p, i := v/n, v%n
+				if i <= last[p] {
+					t.Errorf("consumer %d: got %d after %d from producer %d", c, i, last[p], p)
+				}
+				last[p] = i
+				seen[c] = append(seen[c], v)
+			}
+		}()
+	}
+
+	wg.Wait()
+	for range consumers {
+		enqueue(-1)
+	}
+	cwg.Wait()
+
+	count := make([]int, producers*n)
+	for _, s := range seen {
+		for _, v := range s {
+			count[v]++
+		}
+	}
+	for v, c := range count {
+		if c != 1 {
+			t.Fatalf("element %d delivered %d times", v, c)
+		}
+	}
+}
+
+func TestStress(t *testing.T) {
+	q := New[int](64)
+	stress(t,
+		func(v int) {
+			for !q.TryEnqueue(v) {
+				runtime.Gosched()
+			}
+		},
+		func() int {
+			for {
+				if v, ok := q.TryDequeue(); ok {
+					return v
+				}
+				runtime.Gosched()
+			}
+		})
+}
+
+func TestBlockingStress(t *testing.T) {
+	b := NewBlocking[int](8)
+	ctx := context.Background()
+	stress(t,
+		func(v int) {
+			if err := b.Enqueue(ctx, v); err != nil {
+				t.Error(err)
+			}
+		},
+		func() int {
+			v, err := b.Dequeue(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+			return v
+		})
+}
+
+func TestBlocking(t *testing.T) {
+	b := NewBlocking[string](2)
+	if !b.TryEnqueue("a") || !b.TryEnqueue("b") || b.TryEnqueue("c") {
+		t.Fatalf("TryEnqueue mismatch")
+	}
+	if b.Len() != 2 {
+		t.Errorf("Len=%d", b.Len())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Enqueue(ctx, "c"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Enqueue into full queue: %v", err)
+	}
+
+	// A blocked Enqueue completes once there's room.
+	done := make(chan error)
+	go func() { done <- b.Enqueue(context.Background(), "c") }()
+	if v, ok := b.TryDequeue(); !ok || v != "a" {
+		t.Errorf("TryDequeue()=%q,%v", v, ok)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"b", "c"} {
+		if v, err := b.Dequeue(context.Background()); err != nil || v != want {
+			t.Errorf("Dequeue()=%q,%v, want %q", v, err, want)
+		}
+	}
+
+	if _, ok := b.TryDequeue(); ok {
+		t.Errorf("TryDequeue from empty queue succeeded")
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.Dequeue(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Dequeue from empty queue: %v", err)
+	}
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	const capacity = 1024
+	b.Run("mpmc", func(b *testing.B) {
+		q := New[int](capacity)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				for !q.TryEnqueue(1) {
+					runtime.Gosched()
+				}
+				for {
+					if _, ok := q.TryDequeue(); ok {
+						break
+					}
+					runtime.Gosched()
+				}
+			}
+		})
+	})
+	b.Run("blocking", func(b *testing.B) {
+		q := NewBlocking[int](capacity)
+		ctx := context.Background()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				q.Enqueue(ctx, 1)
+				q.Dequeue(ctx)
+			}
+		})
+	})
+	b.Run("chan", func(b *testing.B) {
+		ch := make(chan int, capacity)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				ch <- 1
+				<-ch
+			}
+		})
+	})
+}
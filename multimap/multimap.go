@@ -0,0 +1,225 @@
+// Package multimap implements a map from keys to multiple values.
+package multimap
+
+import (
+	"iter"
+	"slices"
+
+	"github.com/eliben/gogl/hashset"
+)
+
+// MultiMap maps each key to a collection of values. Depending on the
+// constructor, the collection is either a slice, which keeps values in
+// insertion order and allows duplicates ([NewSlice]), or a set, which
+// keeps only distinct values in unspecified order ([NewSet]).
+//
+// Keys with no values are removed from the map, so they don't appear in
+// iteration.
+type MultiMap[K, V comparable] struct {
+	m         map[K]collection[V]
+	newValues func() collection[V]
+	length    int
+}
+
+// collection is the interface shared by the value collections.
+type collection[V comparable] interface {
+	// add adds v, and reports whether it was added.
+	add(v V) bool
+	// delete deletes one occurrence of v, and reports whether it was found.
+	delete(v V) bool
+	contains(v V) bool
+	len() int
+	all() iter.Seq[V]
+}
+
+// NewSlice creates a new, empty multimap that keeps the values of each key
+// in a slice, in insertion order. The same value can be added to a key
+// multiple times.
+func NewSlice[K, V comparable]() *MultiMap[K, V] {
+	return &MultiMap[K, V]{
+		m:         make(map[K]collection[V]),
+		newValues: func() collection[V] { return &sliceValues[V]{} },
+	}
+}
+
+// NewSet creates a new, empty multimap that keeps the values of each key in
+// a set. Adding a value that a key already has is a no-op.
+func NewSet[K, V comparable]() *MultiMap[K, V] {
+	return &MultiMap[K, V]{
+		m:         make(map[K]collection[V]),
+		newValues: func() collection[V] { return &setValues[V]{hashset.New[V]()} },
+	}
+}
+
+// Len returns the total number of key, value pairs in the multimap.
+func (mm *MultiMap[K, V]) Len() int {
+	return mm.length
+}
+
+// NumKeys returns the number of distinct keys in the multimap.
+func (mm *MultiMap[K, V]) NumKeys() int {
+	return len(mm.m)
+}
+
+// Add adds value to the values of key. It returns true if the value was
+// added; for set-backed multimaps, adding a value key already has returns
+// false.
+func (mm *MultiMap[K, V]) Add(key K, value V) bool {
+	values, ok := mm.m[key]
+	if !ok {
+		values = mm.newValues()
+		mm.m[key] = values
+	}
+	if !values.add(value) {
+		return false
+	}
+	mm.length++
+	return true
+}
+
+// Get returns an iterator over the values of key. The iterator yields no
+// values if key isn't in the multimap.
+func (mm *MultiMap[K, V]) Get(key K) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		values, ok := mm.m[key]
+		if !ok {
+			return
+		}
+		for v := range values.all() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Count returns the number of values key has.
+func (mm *MultiMap[K, V]) Count(key K) int {
+	if values, ok := mm.m[key]; ok {
+		return values.len()
+	}
+	return 0
+}
+
+// Contains reports whether value is among the values of key.
+func (mm *MultiMap[K, V]) Contains(key K, value V) bool {
+	values, ok := mm.m[key]
+	return ok && values.contains(value)
+}
+
+// DeleteValue deletes value from the values of key; for slice-backed
+// multimaps, only the first occurrence is deleted. It returns true if the
+// value was found, and false otherwise.
+func (mm *MultiMap[K, V]) DeleteValue(key K, value V) bool {
+	values, ok := mm.m[key]
+	if !ok || !values.delete(value) {
+		return false
+	}
+	mm.length--
+	if values.len() == 0 {
+		delete(mm.m, key)
+	}
+	return true
+}
+
+// DeleteKey deletes key and all its values from the multimap. It returns
+// the number of values deleted.
+func (mm *MultiMap[K, V]) DeleteKey(key K) int {
+	values, ok := mm.m[key]
+	if !ok {
+		return 0
+	}
+	n := values.len()
+	mm.length -= n
+	delete(mm.m, key)
+	return n
+}
+
+// Keys returns an iterator over the distinct keys in the multimap, in
+// unspecified order.
+func (mm *MultiMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range mm.m {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over all the key, value pairs in the multimap.
+// The pairs of each key are yielded together, with the key's values in the
+// order of its collection; keys are visited in unspecified order.
+func (mm *MultiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, values := range mm.m {
+			for v := range values.all() {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+type sliceValues[V comparable] struct {
+	s []V
+}
+
+func (sv *sliceValues[V]) add(v V) bool {
+	sv.s = append(sv.s, v)
+	return true
+}
+
+func (sv *sliceValues[V]) delete(v V) bool {
+	i := slices.Index(sv.s, v)
+	if i < 0 {
+		return false
+	}
+	sv.s = slices.Delete(sv.s, i, i+1)
+	return true
+}
+
+func (sv *sliceValues[V]) contains(v V) bool {
+	return slices.Contains(sv.s, v)
+}
+
+func (sv *sliceValues[V]) len() int {
+	return len(sv.s)
+}
+
+func (sv *sliceValues[V]) all() iter.Seq[V] {
+	return slices.Values(sv.s)
+}
+
+type setValues[V comparable] struct {
+	hs *hashset.HashSet[V]
+}
+
+func (sv *setValues[V]) add(v V) bool {
+	if sv.hs.Contains(v) {
+		return false
+	}
+	sv.hs.Add(v)
+	return true
+}
+
+func (sv *setValues[V]) delete(v V) bool {
+	if !sv.hs.Contains(v) {
+		return false
+	}
+	sv.hs.Delete(v)
+	return true
+}
+
+func (sv *setValues[V]) contains(v V) bool {
+	return sv.hs.Contains(v)
+}
+
+func (sv *setValues[V]) len() int {
+	return sv.hs.Len()
+}
+
+func (sv *setValues[V]) all() iter.Seq[V] {
+	return sv.hs.All()
+}
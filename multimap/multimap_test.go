@@ -0,0 +1,134 @@
+package multimap
+
+import (
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestSliceBacked(t *testing.T) {
+	mm := NewSlice[string, int]()
+	mm.Add("go", 1)
+	mm.Add("go", 7)
+	mm.Add("rust", 3)
+	mm.Add("go", 1)
+
+	if got := slices.Collect(mm.Get("go")); !slices.Equal(got, []int{1, 7, 1}) {
+		t.Errorf("Get(go)=%v", got)
+	}
+	if mm.Len() != 4 || mm.NumKeys() != 2 || mm.Count("go") != 3 || mm.Count("zig") != 0 {
+		t.Errorf("bad counts: Len=%d NumKeys=%d", mm.Len(), mm.NumKeys())
+	}
+	if !mm.DeleteValue("go", 1) {
+		t.Errorf("DeleteValue failed")
+	}
+	if got := slices.Collect(mm.Get("go")); !slices.Equal(got, []int{7, 1}) {
+		t.Errorf("Get(go)=%v", got)
+	}
+	if mm.DeleteValue("rust", 100) || mm.DeleteValue("zig", 1) {
+		t.Errorf("deleted missing value")
+	}
+	if !mm.DeleteValue("rust", 3) || mm.NumKeys() != 1 {
+		t.Errorf("emptied key not removed")
+	}
+	if n := mm.DeleteKey("go"); n != 2 || mm.Len() != 0 {
+		t.Errorf("DeleteKey=%d, Len=%d", n, mm.Len())
+	}
+	if len(slices.Collect(mm.Get("go"))) != 0 {
+		t.Errorf("Get on deleted key yielded values")
+	}
+}
+
+func TestSetBacked(t *testing.T) {
+	mm := NewSet[string, string]()
+	if !mm.Add("tag", "x") || mm.Add("tag", "x") || !mm.Add("tag", "y") {
+		t.Errorf("bad Add results")
+	}
+	if got := slices.Sorted(mm.Get("tag")); !slices.Equal(got, []string{"x", "y"}) {
+		t.Errorf("Get(tag)=%v", got)
+	}
+	if !mm.Contains("tag", "y") || mm.Contains("tag", "z") || mm.Contains("other", "x") {
+		t.Errorf("bad Contains")
+	}
+	if mm.Len() != 2 {
+		t.Errorf("Len=%d", mm.Len())
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, set := range []bool{false, true} {
+		mm := NewSlice[int, int]()
+		if set {
+			mm = NewSet[int, int]()
+		}
+		// Mirror as map of key to slice of values in insertion order.
+		want := make(map[int][]int)
+		total := 0
+		for range 3000 {
+			k, v := rnd.IntN(10), rnd.IntN(10)
+			switch rnd.IntN(5) {
+			case 0:
+				if got := mm.DeleteKey(k); got != len(want[k]) {
+					t.Fatalf("DeleteKey(%d)=%d, want %d", k, got, len(want[k]))
+				}
+				total -= len(want[k])
+				delete(want, k)
+			case 1:
+				i := slices.Index(want[k], v)
+				if mm.DeleteValue(k, v) != (i >= 0) {
+					t.Fatalf("DeleteValue(%d, %d) mismatch", k, v)
+				}
+				if i >= 0 {
+					want[k] = slices.Delete(want[k], i, i+1)
+					total--
+					if len(want[k]) == 0 {
+						delete(want, k)
+					}
+				}
+			default:
+				wantAdded := !set || !slices.Contains(want[k], v)
+				if mm.Add(k, v) != wantAdded {
+					t.Fatalf("Add(%d, %d) mismatch", k, v)
+				}
+				if wantAdded {
+					want[k] = append(want[k], v)
+					total++
+				}
+			}
+
+			if mm.Len() != total || mm.NumKeys() != len(want) {
+				t.Fatalf("got Len=%d NumKeys=%d, want %d, %d", mm.Len(), mm.NumKeys(), total, len(want))
+			}
+			if got := slices.Sorted(mm.Keys()); !slices.Equal(got, slices.Sorted(maps.Keys(want))) {
+				t.Fatalf("got keys %v", got)
+			}
+			got := slices.Collect(mm.Get(k))
+			if set {
+				slices.Sort(got)
+				got2 := slices.Clone(want[k])
+				slices.Sort(got2)
+				if !slices.Equal(got, got2) {
+					t.Fatalf("Get(%d)=%v, want %v", k, got, got2)
+				}
+			} else if !slices.Equal(got, want[k]) {
+				t.Fatalf("Get(%d)=%v, want %v", k, got, want[k])
+			}
+		}
+		n := 0
+		for k, v := range mm.All() {
+			if !slices.Contains(want[k], v) {
+				t.Fatalf("All yielded %d=%d", k, v)
+			}
+			n++
+		}
+		if n != total {
+			t.Fatalf("All yielded %d pairs, want %d", n, total)
+		}
+	}
+}
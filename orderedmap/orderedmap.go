@@ -0,0 +1,233 @@
+// Package orderedmap implements a hash map that remembers the insertion
+// order of its keys.
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"iter"
+	"reflect"
+	"strconv"
+
+	"github.com/eliben/gogl/list"
+)
+
+// Map is a hash map that iterates over its entries in the order in which
+// their keys were first inserted, similarly to Python's dict or Java's
+// LinkedHashMap. Get, Set and Delete take O(1) time.
+//
+// By default, updating the value of an existing key keeps its position; a
+// map created with [NewMoveToBack] moves updated keys to the back instead.
+// Create maps with [New] or [NewMoveToBack].
+type Map[K comparable, V any] struct {
+	index      map[K]*list.Node[entry[K, V]]
+	order      *list.List[entry[K, V]]
+	moveToBack bool
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// New creates a new, empty map.
+func New[K comparable, V any]() *Map[K, V] {
+	return &Map[K, V]{
+		index: make(map[K]*list.Node[entry[K, V]]),
+		order: list.New[entry[K, V]](),
+	}
+}
+
+// NewMoveToBack creates a new, empty map in which Set moves the key it sets
+// to the back of the iteration order, even if the key already exists.
+func NewMoveToBack[K comparable, V any]() *Map[K, V] {
+	m := New[K, V]()
+	m.moveToBack = true
+	return m
+}
+
+// Len returns the number of entries in the map.
+func (m *Map[K, V]) Len() int {
+	return len(m.index)
+}
+
+// Get looks for the given key in the map. It returns the associated value
+// and ok=true; otherwise, it returns ok=false.
+func (m *Map[K, V]) Get(key K) (v V, ok bool) {
+	node, ok := m.index[key]
+	if !ok {
+		return v, false
+	}
+	return node.Value.value, true
+}
+
+// Set sets the value of key to value. A new key is added at the back of
+// the iteration order.
+func (m *Map[K, V]) Set(key K, value V) {
+	if node, ok := m.index[key]; ok {
+		node.Value.value = value
+		if m.moveToBack {
+			m.order.MoveToBack(node)
+		}
+		return
+	}
+	m.index[key] = m.order.InsertBack(entry[K, V]{key: key, value: value})
+}
+
+// Delete deletes a key and its associated value from the map. It returns
+// true if the key was found and deleted, and false otherwise.
+func (m *Map[K, V]) Delete(key K) bool {
+	node, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	m.order.Remove(node)
+	delete(m.index, key)
+	return true
+}
+
+// MoveToBack moves key to the back of the iteration order. It returns true
+// if key was found, and false otherwise.
+func (m *Map[K, V]) MoveToBack(key K) bool {
+	node, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	m.order.MoveToBack(node)
+	return true
+}
+
+// Oldest returns the entry at the front of the iteration order and ok=true,
+// or ok=false if the map is empty.
+func (m *Map[K, V]) Oldest() (k K, v V, ok bool) {
+	node := m.order.Front()
+	if node == nil {
+		return k, v, false
+	}
+	return node.Value.key, node.Value.value, true
+}
+
+// Newest returns the entry at the back of the iteration order and ok=true,
+// or ok=false if the map is empty.
+func (m *Map[K, V]) Newest() (k K, v V, ok bool) {
+	node := m.order.Back()
+	if node == nil {
+		return k, v, false
+	}
+	return node.Value.key, node.Value.value, true
+}
+
+// All returns an iterator over all key, value pairs in the map, in
+// iteration order.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for e := range m.order.Values() {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over all the keys in the map, in iteration order.
+func (m *Map[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for e := range m.order.Values() {
+			if !yield(e.key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over all the values in the map, in iteration
+// order.
+func (m *Map[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for e := range m.order.Values() {
+			if !yield(e.value) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON implements [json.Marshaler]; the map is encoded as a JSON
+// object with its members in iteration order. Keys are encoded the same way
+// encoding/json encodes the keys of builtin maps: strings as-is, types
+// implementing [encoding.TextMarshaler] with their MarshalText method, and
+// integers in decimal; other key types are rejected with a
+// [json.UnsupportedTypeError].
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	if err := checkKeyType[K](); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for k, v := range m.All() {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		kb, err := marshalKey(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// checkKeyType returns an error if encoding/json can't encode map keys of
+// type K.
+func checkKeyType[K comparable]() error {
+	t := reflect.TypeFor[K]()
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return nil
+	}
+	if t.Implements(textMarshalerType) {
+		return nil
+	}
+	return &json.UnsupportedTypeError{Type: reflect.TypeFor[map[K]struct{}]()}
+}
+
+// marshalKey encodes k, whose type passed checkKeyType, in the order
+// encoding/json tries: string kinds, then TextMarshaler, then integers.
+func marshalKey[K any](k K) ([]byte, error) {
+	v := reflect.ValueOf(&k).Elem()
+	var s string
+	switch kind := v.Kind(); {
+	case kind == reflect.String:
+		s = v.String()
+	case v.Type().Implements(textMarshalerType):
+		if kind == reflect.Pointer && v.IsNil() {
+			break
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		s = string(text)
+	case v.CanInt():
+		s = strconv.FormatInt(v.Int(), 10)
+	default:
+		s = strconv.FormatUint(v.Uint(), 10)
+	}
+	return json.Marshal(s)
+}
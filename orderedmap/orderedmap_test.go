@@ -0,0 +1,197 @@
+package orderedmap
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func checkKeys[V any](t *testing.T, m *Map[string, V], want []string) {
+	t.Helper()
+	if m.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", m.Len(), len(want))
+	}
+	if got := slices.Collect(m.Keys()); !slices.Equal(got, want) {
+		t.Fatalf("got keys %v, want %v", got, want)
+	}
+}
+
+func TestInsertionOrder(t *testing.T) {
+	m := New[string, int]()
+	m.Set("zeta", 1)
+	m.Set("alpha", 2)
+	m.Set("mu", 3)
+	m.Set("zeta", 10)
+	checkKeys(t, m, []string{"zeta", "alpha", "mu"})
+	if v, ok := m.Get("zeta"); !ok || v != 10 {
+		t.Errorf("Get(zeta)=%d,%v", v, ok)
+	}
+	if got := slices.Collect(m.Values()); !slices.Equal(got, []int{10, 2, 3}) {
+		t.Errorf("got values %v", got)
+	}
+
+	if !m.Delete("alpha") || m.Delete("alpha") {
+		t.Errorf("bad Delete")
+	}
+	m.Set("alpha", 4)
+	checkKeys(t, m, []string{"zeta", "mu", "alpha"})
+
+	if !m.MoveToBack("zeta") || m.MoveToBack("nope") {
+		t.Errorf("bad MoveToBack")
+	}
+	checkKeys(t, m, []string{"mu", "alpha", "zeta"})
+	if k, v, ok := m.Oldest(); !ok || k != "mu" || v != 3 {
+		t.Errorf("Oldest=%q,%d,%v", k, v, ok)
+	}
+	if k, v, ok := m.Newest(); !ok || k != "zeta" || v != 10 {
+		t.Errorf("Newest=%q,%d,%v", k, v, ok)
+	}
+}
+
+func TestMoveToBackMode(t *testing.T) {
+	m := NewMoveToBack[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("a", 3)
+	checkKeys(t, m, []string{"b", "a"})
+	if v, _ := m.Get("a"); v != 3 {
+		t.Errorf("got %d", v)
+	}
+
+	empty := NewMoveToBack[string, int]()
+	if _, _, ok := empty.Oldest(); ok {
+		t.Errorf("Oldest on empty map")
+	}
+	if _, _, ok := empty.Newest(); ok {
+		t.Errorf("Newest on empty map")
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	m := New[string, any]()
+	m.Set("name", "server")
+	m.Set("port", 8080)
+	m.Set("debug", false)
+	inner := New[int, string]()
+	inner.Set(20, "b")
+	inner.Set(10, "a")
+	m.Set("nested", inner)
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"server","port":8080,"debug":false,"nested":{"20":"b","10":"a"}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	addrs := New[netip.Addr, int]()
+	addrs.Set(netip.MustParseAddr("10.0.0.1"), 1)
+	b, err = json.Marshal(addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"10.0.0.1":1}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	if _, err := json.Marshal(New[string, func()]()); err != nil {
+		t.Errorf("empty map: %v", err)
+	}
+	bad := New[string, func()]()
+	bad.Set("f", func() {})
+	if _, err := json.Marshal(bad); err == nil {
+		t.Errorf("expected error for unsupported value")
+	}
+}
+
+// upperKey is a string kind implementing TextMarshaler, which
+// encoding/json ignores for map keys.
+type upperKey string
+
+func (k upperKey) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(k))), nil
+}
+
+func TestMarshalJSONKeys(t *testing.T) {
+	check := func(m json.Marshaler, want string) {
+		t.Helper()
+		got, err := json.Marshal(m)
+		if want == "" {
+			var ute *json.UnsupportedTypeError
+			if !errors.As(err, &ute) {
+				t.Errorf("got %s, %v, want an UnsupportedTypeError", got, err)
+			}
+		} else if err != nil || string(got) != want {
+			t.Errorf("got %s, %v, want %s", got, err, want)
+		}
+	}
+	upper := New[upperKey, int]()
+	upper.Set("key", 1)
+	check(upper, `{"key":1}`)
+	ints := New[int8, int]()
+	ints.Set(-5, 1)
+	check(ints, `{"-5":1}`)
+	uints := New[uint64, int]()
+	uints.Set(1<<63, 1)
+	check(uints, `{"9223372036854775808":1}`)
+
+	// Like encoding/json, reject key types it can't encode, even in an
+	// empty map.
+	bools := New[bool, int]()
+	bools.Set(true, 1)
+	check(bools, "")
+	check(New[float64, int](), "")
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, moveToBack := range []bool{false, true} {
+		m := New[string, int]()
+		if moveToBack {
+			m = NewMoveToBack[string, int]()
+		}
+		// The mirror is a slice of keys in order, plus a map of values.
+		var order []string
+		values := make(map[string]int)
+		for range 3000 {
+			k := string(rune('a' + rnd.IntN(26)))
+			i := slices.Index(order, k)
+			if rnd.IntN(3) == 0 {
+				if m.Delete(k) != (i >= 0) {
+					t.Fatalf("Delete(%q) mismatch", k)
+				}
+				if i >= 0 {
+					order = slices.Delete(order, i, i+1)
+				}
+				delete(values, k)
+			} else {
+				v := rnd.Int()
+				m.Set(k, v)
+				if i >= 0 && moveToBack {
+					order = slices.Delete(order, i, i+1)
+					i = -1
+				}
+				if i < 0 {
+					order = append(order, k)
+				}
+				values[k] = v
+			}
+			checkKeys(t, m, order)
+			for k, v := range m.All() {
+				if values[k] != v {
+					t.Fatalf("got %q=%d, want %d", k, v, values[k])
+				}
+			}
+		}
+	}
+}
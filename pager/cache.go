@@ -0,0 +1,320 @@
+package pager
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/eliben/gogl/clockcache"
+)
+
+// Cache keeps recently used pages of pagers in memory, sharing its capacity
+// between them, so that the structures reading them avoid doing I/O for
+// every access. Pages are read into the cache when they're pinned, and stay
+// in memory while they're pinned; unpinned pages are evicted with the CLOCK
+// algorithm when the cache is full. Modified pages are marked dirty, and
+// written back to their pager when they're evicted or the pager is synced.
+//
+// Pagers are usually used through the cache by wrapping them with
+// [Cache.Wrap]; [Cache.Pin] gives direct access to the cached pages. A Cache
+// is safe for concurrent use by multiple goroutines. Create caches with
+// [NewCache].
+type Cache struct {
+	mu sync.Mutex
+
+	// unpinned holds the pages that can be evicted; pinned pages are moved
+	// out of it, to pinned, while they're pinned.
+	unpinned *clockcache.Cache[pageKey, *Page]
+	pinned   map[pageKey]*Page
+
+	// errs holds the errors of write-backs on eviction, by pager, until
+	// they're returned by its next Sync or Close.
+	errs map[Pager]error
+}
+
+type pageKey struct {
+	p  Pager
+	id PageID
+}
+
+// Page is a page of a pager held in a [Cache].
+type Page struct {
+	c     *Cache
+	key   pageKey
+	data  []byte
+	pins  int
+	dirty bool
+
+	// ready is closed when the page has been read, successfully if err is
+	// nil.
+	ready chan struct{}
+	err   error
+}
+
+// NewCache creates a new, empty cache holding up to capacity unpinned
+// pages; pinned pages don't count towards the capacity. capacity must be
+// positive.
+func NewCache(capacity int) *Cache {
+	if capacity <= 0 {
+		panic("pager: capacity must be positive")
+	}
+	c := &Cache{
+		pinned: make(map[pageKey]*Page),
+		errs:   make(map[Pager]error),
+	}
+	c.unpinned = clockcache.NewWithEvict(capacity, func(key pageKey, pg *Page) {
+		if err := pg.writeBack(); err != nil && c.errs[key.p] == nil {
+			c.errs[key.p] = err
+		}
+	})
+	return c
+}
+
+// Len returns the number of pages in the cache, pinned or not.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.unpinned.Len() + len(c.pinned)
+}
+
+// Pin returns the page id of p, reading it if it isn't in the cache, and
+// pins it in memory until it's unpinned with [Page.Unpin]. Pinning a page
+// several times pins it until it's unpinned as many times; the cache
+// doesn't synchronize accesses to the data of pages pinned by several
+// goroutines.
+func (c *Cache) Pin(p Pager, id PageID) (*Page, error) {
+	return c.pin(p, id, nil)
+}
+
+// pin is like Pin, but if fill isn't nil, a page that isn't in the cache
+// isn't read: its content is set to fill, and it's marked dirty.
+func (c *Cache) pin(p Pager, id PageID, fill []byte) (*Page, error) {
+	key := pageKey{p, id}
+	c.mu.Lock()
+	if pg, ok := c.pinned[key]; ok {
+		pg.pins++
+		c.mu.Unlock()
+		<-pg.ready
+		if pg.err != nil {
+			pg.Unpin()
+			return nil, pg.err
+		}
+		return pg, nil
+	}
+	if pg, ok := c.unpinned.Get(key); ok {
+		c.unpinned.Remove(key)
+		pg.pins = 1
+		c.pinned[key] = pg
+		c.mu.Unlock()
+		return pg, nil
+	}
+	pg := &Page{c: c, key: key, data: make([]byte, p.PageSize()), pins: 1, ready: make(chan struct{})}
+	c.pinned[key] = pg
+	c.mu.Unlock()
+
+	var err error
+	if fill == nil {
+		err = p.ReadPage(id, pg.data)
+	} else if id >= PageID(p.NumPages()) {
+		err = ErrInvalidPage
+	}
+	c.mu.Lock()
+	if err == nil && fill != nil {
+		copy(pg.data, fill)
+		pg.dirty = true
+	}
+	pg.err = err
+	c.mu.Unlock()
+	close(pg.ready)
+	if err != nil {
+		pg.Unpin()
+		return nil, err
+	}
+	return pg, nil
+}
+
+// ID returns the ID of the page.
+func (pg *Page) ID() PageID {
+	return pg.key.id
+}
+
+// Data returns the content of the page. It may be modified while the page
+// is pinned, after which it must be marked dirty with [Page.MarkDirty].
+func (pg *Page) Data() []byte {
+	return pg.data
+}
+
+// MarkDirty marks the page as modified, to be written back to its pager.
+func (pg *Page) MarkDirty() {
+	pg.c.mu.Lock()
+	defer pg.c.mu.Unlock()
+	pg.dirty = true
+}
+
+// Unpin unpins the page, which must not be used afterwards. Once it's
+// unpinned as many times as it was pinned, it can be evicted.
+func (pg *Page) Unpin() {
+	c := pg.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if pg.pins <= 0 {
+		panic(fmt.Sprintf("pager: unpinning page %d that isn't pinned", pg.key.id))
+	}
+	pg.pins--
+	if pg.pins > 0 {
+		return
+	}
+	if c.pinned[pg.key] != pg {
+		// The page was dropped while pinned.
+		return
+	}
+	delete(c.pinned, pg.key)
+	if pg.err == nil {
+		c.unpinned.Put(pg.key, pg)
+	}
+}
+
+// writeBack writes the page to its pager if it's dirty. It must be called
+// with c.mu held.
+func (pg *Page) writeBack() error {
+	if !pg.dirty {
+		return nil
+	}
+	if err := pg.key.p.WritePage(pg.key.id, pg.data); err != nil {
+		return err
+	}
+	pg.dirty = false
+	return nil
+}
+
+// Flush writes back the dirty pages of p, including the pinned ones, and
+// returns the first error of this or earlier write-backs of p's pages. It
+// doesn't sync p.
+func (c *Cache) Flush(p Pager) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.errs[p]
+	delete(c.errs, p)
+	for key, pg := range c.unpinned.All() {
+		if key.p == p {
+			err = errors.Join(err, pg.writeBack())
+		}
+	}
+	for key, pg := range c.pinned {
+		if key.p == p && pg.err == nil {
+			err = errors.Join(err, pg.writeBack())
+		}
+	}
+	return err
+}
+
+// Drop removes the pages of p from the cache, without writing them back.
+func (c *Cache) Drop(p Pager) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []pageKey
+	for key := range c.unpinned.All() {
+		if key.p == p {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		c.unpinned.Remove(key)
+	}
+	for key := range c.pinned {
+		if key.p == p {
+			delete(c.pinned, key)
+		}
+	}
+	delete(c.errs, p)
+}
+
+// Wrap returns a Pager reading and writing the pages of p through the
+// cache; unlike the data of pinned pages, its reads and writes are
+// synchronized. Writes are buffered in the cache until the pages are evicted or
+// the returned pager is synced or closed. p must not be used directly while
+// the returned pager is in use.
+func (c *Cache) Wrap(p Pager) Pager {
+	return &cachedPager{c: c, p: p}
+}
+
+// cachedPager is a Pager using a Cache.
+type cachedPager struct {
+	c *Cache
+	p Pager
+}
+
+func (cp *cachedPager) PageSize() int {
+	return cp.p.PageSize()
+}
+
+func (cp *cachedPager) NumPages() uint64 {
+	return cp.p.NumPages()
+}
+
+func (cp *cachedPager) ReadPage(id PageID, buf []byte) error {
+	checkBuf(buf, cp.p.PageSize())
+	pg, err := cp.c.Pin(cp.p, id)
+	if err != nil {
+		return err
+	}
+	cp.c.mu.Lock()
+	copy(buf, pg.data)
+	cp.c.mu.Unlock()
+	pg.Unpin()
+	return nil
+}
+
+func (cp *cachedPager) WritePage(id PageID, buf []byte) error {
+	checkBuf(buf, cp.p.PageSize())
+	pg, err := cp.c.pin(cp.p, id, buf)
+	if err != nil {
+		return err
+	}
+	cp.c.mu.Lock()
+	copy(pg.data, buf)
+	pg.dirty = true
+	cp.c.mu.Unlock()
+	pg.Unpin()
+	return nil
+}
+
+func (cp *cachedPager) Alloc() (PageID, error) {
+	id, err := cp.p.Alloc()
+	if err != nil {
+		return 0, err
+	}
+	cp.dropPage(id)
+	return id, nil
+}
+
+func (cp *cachedPager) Free(id PageID) error {
+	cp.dropPage(id)
+	return cp.p.Free(id)
+}
+
+// dropPage removes the page id from the cache, since its content changes
+// when it's freed or allocated.
+func (cp *cachedPager) dropPage(id PageID) {
+	key := pageKey{cp.p, id}
+	cp.c.mu.Lock()
+	defer cp.c.mu.Unlock()
+	cp.c.unpinned.Remove(key)
+	delete(cp.c.pinned, key)
+}
+
+func (cp *cachedPager) Sync() error {
+	if err := cp.c.Flush(cp.p); err != nil {
+		return err
+	}
+	return cp.p.Sync()
+}
+
+func (cp *cachedPager) Close() error {
+	err := cp.c.Flush(cp.p)
+	cp.c.Drop(cp.p)
+	if cerr := cp.p.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,279 @@
+package pager
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingPager counts the page reads and writes of a pager.
+type countingPager struct {
+	Pager
+	reads, writes atomic.Int64
+}
+
+func (cp *countingPager) ReadPage(id PageID, buf []byte) error {
+	cp.reads.Add(1)
+	return cp.Pager.ReadPage(id, buf)
+}
+
+func (cp *countingPager) WritePage(id PageID, buf []byte) error {
+	cp.writes.Add(1)
+	return cp.Pager.WritePage(id, buf)
+}
+
+// newCountingMem returns a counting Mem pager with n pages, each filled
+// with its ID plus one.
+func newCountingMem(t *testing.T, n int) *countingPager {
+	t.Helper()
+	m := NewMem(64)
+	for i := range n {
+		id, err := m.Alloc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.WritePage(id, bytes.Repeat([]byte{byte(i + 1)}, 64)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &countingPager{Pager: m}
+}
+
+func TestCachedPager(t *testing.T) {
+	testPager(t, NewCache(8).Wrap(NewMem(64)))
+}
+
+func TestCacheReads(t *testing.T) {
+	cp := newCountingMem(t, 10)
+	c := NewCache(4)
+	p := c.Wrap(cp)
+	for range 3 {
+		for id := range PageID(4) {
+			checkPage(t, p, id, bytes.Repeat([]byte{byte(id + 1)}, 64))
+		}
+	}
+	if got := cp.reads.Load(); got != 4 {
+		t.Errorf("got %d reads of 4 pages fitting in the cache, want 4", got)
+	}
+	if got := c.Len(); got != 4 {
+		t.Errorf("Len() = %d, want 4", got)
+	}
+
+	// Reading more pages evicts some.
+	for id := range PageID(10) {
+		checkPage(t, p, id, bytes.Repeat([]byte{byte(id + 1)}, 64))
+	}
+	if got := cp.reads.Load(); got <= 4 {
+		t.Errorf("got %d reads, want more than 4", got)
+	}
+	if got := c.Len(); got != 4 {
+		t.Errorf("Len() = %d, want 4", got)
+	}
+}
+
+func TestCacheWriteBack(t *testing.T) {
+	cp := newCountingMem(t, 10)
+	c := NewCache(4)
+	p := c.Wrap(cp)
+	page := bytes.Repeat([]byte{0xaa}, 64)
+	for id := range PageID(3) {
+		if err := p.WritePage(id, page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := cp.writes.Load(); got != 0 {
+		t.Errorf("got %d writes before Sync, want 0", got)
+	}
+	if got := cp.reads.Load(); got != 0 {
+		t.Errorf("got %d reads for writing whole pages, want 0", got)
+	}
+	checkPage(t, cp.Pager, 0, bytes.Repeat([]byte{1}, 64))
+	checkPage(t, p, 0, page)
+
+	if err := p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cp.writes.Load(); got != 3 {
+		t.Errorf("got %d writes after Sync, want 3", got)
+	}
+	for id := range PageID(3) {
+		checkPage(t, cp.Pager, id, page)
+	}
+	if err := p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cp.writes.Load(); got != 3 {
+		t.Errorf("got %d writes after second Sync, want 3", got)
+	}
+
+	// Evicted dirty pages are written back.
+	page2 := bytes.Repeat([]byte{0xbb}, 64)
+	for id := range PageID(10) {
+		if err := p.WritePage(id, page2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := cp.writes.Load(); got != 9 {
+		t.Errorf("got %d writes after evictions, want 9", got)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Len(); got != 0 {
+		t.Errorf("Len() = %d after Close, want 0", got)
+	}
+	if got := cp.writes.Load(); got != 13 {
+		t.Errorf("got %d writes after Close, want 13", got)
+	}
+}
+
+func TestCachePin(t *testing.T) {
+	cp := newCountingMem(t, 10)
+	c := NewCache(2)
+	var pages []*Page
+	for id := range PageID(5) {
+		pg, err := c.Pin(cp, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pg.ID() != id || pg.Data()[0] != byte(id+1) {
+			t.Fatalf("Pin(%d): got page %d with %d", id, pg.ID(), pg.Data()[0])
+		}
+		pages = append(pages, pg)
+	}
+	if got := c.Len(); got != 5 {
+		t.Errorf("Len() = %d with 5 pinned pages, want 5", got)
+	}
+
+	// Pinned pages are shared, and stay in the cache.
+	pg, err := c.Pin(cp, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pg != pages[0] {
+		t.Errorf("pinning page 0 twice returned different pages")
+	}
+	pg.Data()[1] = 42
+	pg.MarkDirty()
+	pg.Unpin()
+	for _, pg := range pages {
+		pg.Unpin()
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("Len() = %d after unpinning, want 2", got)
+	}
+	if got := cp.reads.Load(); got != 5 {
+		t.Errorf("got %d reads, want 5", got)
+	}
+	if err := c.Flush(cp); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	cp.Pager.ReadPage(0, buf)
+	if buf[1] != 42 {
+		t.Errorf("dirty page not written back")
+	}
+
+	if _, err := c.Pin(cp, 10); err != ErrInvalidPage {
+		t.Errorf("Pin past the end: got error %v, want %v", err, ErrInvalidPage)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("unpinning an unpinned page: no panic")
+		}
+	}()
+	pages[1].Unpin()
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	const numPages = 32
+	cp := newCountingMem(t, numPages)
+	c := NewCache(8)
+	p := c.Wrap(cp)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rnd := makeLoggedRand(t)
+			buf := make([]byte, 64)
+			for range 1000 {
+				id := PageID(rnd.IntN(numPages))
+				if id%8 == PageID(g) {
+					buf[0] = byte(id + 1)
+					if err := p.WritePage(id, buf); err != nil {
+						t.Error(err)
+						return
+					}
+				} else if err := p.ReadPage(id, buf); err != nil || buf[0] != byte(id+1) {
+					t.Errorf("page %d: got %d, %v, want %d", id, buf[0], err, id+1)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// gatedPager is a pager whose NumPages blocks until gate is closed, after
+// signaling on entered.
+type gatedPager struct {
+	Pager
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (gp *gatedPager) NumPages() uint64 {
+	gp.entered <- struct{}{}
+	<-gp.gate
+	return gp.Pager.NumPages()
+}
+
+func TestCacheWriteUncached(t *testing.T) {
+	// A read of a page that isn't in the cache, waiting for its write to
+	// complete, sees the new content.
+	m := NewMem(64)
+	m.Alloc()
+	m.WritePage(0, bytes.Repeat([]byte{1}, 64))
+	gp := &gatedPager{Pager: m, entered: make(chan struct{}), gate: make(chan struct{})}
+	c := NewCache(4)
+	p := c.Wrap(gp)
+	newPage := bytes.Repeat([]byte{2}, 64)
+	done := make(chan error)
+	go func() {
+		done <- p.WritePage(0, newPage)
+	}()
+	<-gp.entered
+
+	read := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		if err := p.ReadPage(0, buf); err != nil {
+			t.Error(err)
+		}
+		read <- buf
+	}()
+	// Wait for the read to pin the page being written.
+	for {
+		c.mu.Lock()
+		pins := c.pinned[pageKey{gp, 0}].pins
+		c.mu.Unlock()
+		if pins == 2 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(gp.gate)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if buf := <-read; !bytes.Equal(buf, newPage) {
+		t.Errorf("read %v... while writing the page, want %v...", buf[:4], newPage[:4])
+	}
+}
@@ -0,0 +1,232 @@
+package pager
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// File is a Pager keeping its pages in a file. The first page of the file
+// holds the pager's metadata, and page id is stored after it, at offset
+// (id+1)*pageSize. Freed pages are chained in a free list, each holding the
+// ID of the next one.
+//
+// Writes go directly to the file, and are durable once [File.Sync]
+// returns; the metadata, including the allocated and freed pages, is only
+// written by Sync. Create and open File pagers with [OpenFile].
+type File struct {
+	mu       sync.Mutex
+	f        *os.File
+	pageSize int
+
+	// numPages is the number of allocated pages; freeHead is the first page
+	// of the free list, or noPage if it's empty.
+	numPages uint64
+	freeHead PageID
+	closed   bool
+}
+
+var _ Pager = (*File)(nil)
+
+// The metadata page holds a magic string, the page size (uint32), the
+// number of pages (uint64) and the head of the free list (uint64).
+const (
+	fileMagic = "GOGLPGR1"
+	noPage    = ^PageID(0)
+)
+
+// OpenFile opens the pager file at path, creating it with pages of pageSize
+// bytes if it doesn't exist. The page size of an existing file must be
+// pageSize. It panics if pageSize is smaller than 64.
+func OpenFile(path string, pageSize int) (*File, error) {
+	checkPageSize(pageSize)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	p := &File{f: f, pageSize: pageSize, freeHead: noPage}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		err = p.writeMeta()
+	} else {
+		err = p.readMeta()
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *File) writeMeta() error {
+	meta := make([]byte, p.pageSize)
+	copy(meta, fileMagic)
+	binary.LittleEndian.PutUint32(meta[8:], uint32(p.pageSize))
+	binary.LittleEndian.PutUint64(meta[12:], p.numPages)
+	binary.LittleEndian.PutUint64(meta[20:], uint64(p.freeHead))
+	_, err := p.f.WriteAt(meta, 0)
+	return err
+}
+
+func (p *File) readMeta() error {
+	meta := make([]byte, 28)
+	if _, err := p.f.ReadAt(meta, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrInvalidData
+		}
+		return err
+	}
+	if string(meta[:8]) != fileMagic || binary.LittleEndian.Uint32(meta[8:]) != uint32(p.pageSize) {
+		return ErrInvalidData
+	}
+	p.numPages = binary.LittleEndian.Uint64(meta[12:])
+	p.freeHead = PageID(binary.LittleEndian.Uint64(meta[20:]))
+	if p.freeHead != noPage && uint64(p.freeHead) >= p.numPages {
+		return ErrInvalidData
+	}
+	return nil
+}
+
+// PageSize implements [Pager.PageSize].
+func (p *File) PageSize() int {
+	return p.pageSize
+}
+
+// NumPages implements [Pager.NumPages].
+func (p *File) NumPages() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.numPages
+}
+
+func (p *File) offset(id PageID) int64 {
+	return (int64(id) + 1) * int64(p.pageSize)
+}
+
+// ReadPage implements [Pager.ReadPage].
+func (p *File) ReadPage(id PageID, buf []byte) error {
+	checkBuf(buf, p.pageSize)
+	p.mu.Lock()
+	err := p.check(id)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.readPage(id, buf)
+}
+
+// readPage reads the page id, which may be past the end of the file if it
+// was never written.
+func (p *File) readPage(id PageID, buf []byte) error {
+	n, err := p.f.ReadAt(buf, p.offset(id))
+	if err == io.EOF {
+		clear(buf[n:])
+		return nil
+	}
+	return err
+}
+
+// WritePage implements [Pager.WritePage].
+func (p *File) WritePage(id PageID, buf []byte) error {
+	checkBuf(buf, p.pageSize)
+	p.mu.Lock()
+	err := p.check(id)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = p.f.WriteAt(buf, p.offset(id))
+	return err
+}
+
+// Alloc implements [Pager.Alloc].
+func (p *File) Alloc() (PageID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrClosed
+	}
+	if p.freeHead == noPage {
+		p.numPages++
+		return PageID(p.numPages - 1), nil
+	}
+	id := p.freeHead
+	buf := make([]byte, p.pageSize)
+	if err := p.readPage(id, buf); err != nil {
+		return 0, err
+	}
+	next := PageID(binary.LittleEndian.Uint64(buf))
+	if next != noPage && uint64(next) >= p.numPages {
+		return 0, ErrInvalidData
+	}
+	clear(buf)
+	if _, err := p.f.WriteAt(buf, p.offset(id)); err != nil {
+		return 0, err
+	}
+	p.freeHead = next
+	return id, nil
+}
+
+// Free implements [Pager.Free].
+func (p *File) Free(id PageID) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.check(id); err != nil {
+		return err
+	}
+	buf := make([]byte, p.pageSize)
+	binary.LittleEndian.PutUint64(buf, uint64(p.freeHead))
+	if _, err := p.f.WriteAt(buf, p.offset(id)); err != nil {
+		return err
+	}
+	p.freeHead = id
+	return nil
+}
+
+// Sync implements [Pager.Sync].
+func (p *File) Sync() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	return p.sync()
+}
+
+func (p *File) sync() error {
+	if err := p.writeMeta(); err != nil {
+		return err
+	}
+	return p.f.Sync()
+}
+
+// Close implements [Pager.Close].
+func (p *File) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.closed = true
+	err := p.sync()
+	if cerr := p.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (p *File) check(id PageID) error {
+	if p.closed {
+		return ErrClosed
+	}
+	if uint64(id) >= p.numPages {
+		return ErrInvalidPage
+	}
+	return nil
+}
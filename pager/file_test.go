@@ -0,0 +1,89 @@
+package pager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestFile(t *testing.T, path string, pageSize int) *File {
+	t.Helper()
+	p, err := OpenFile(path, pageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestFile(t *testing.T) {
+	testPager(t, openTestFile(t, filepath.Join(t.TempDir(), "pages"), 64))
+}
+
+func TestFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pages")
+	p := openTestFile(t, path, 128)
+	want := make([][]byte, 10)
+	for i := range want {
+		id, err := p.Alloc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[id] = make([]byte, 128)
+		want[id][0], want[id][127] = byte(i+1), byte(i+2)
+		if err := p.WritePage(id, want[id]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []PageID{3, 7} {
+		if err := p.Free(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p = openTestFile(t, path, 128)
+	defer p.Close()
+	if got := p.NumPages(); got != 10 {
+		t.Errorf("NumPages() = %d, want 10", got)
+	}
+	for id, page := range want {
+		if id != 3 && id != 7 {
+			checkPage(t, p, PageID(id), page)
+		}
+	}
+	got := map[PageID]bool{}
+	for range 3 {
+		id, err := p.Alloc()
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPage(t, p, id, make([]byte, 128))
+		got[id] = true
+	}
+	if !got[3] || !got[7] || !got[10] {
+		t.Errorf("allocated pages %v, want 3, 7 and 10", got)
+	}
+}
+
+func TestFileInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pages")
+	p := openTestFile(t, path, 64)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(path, 128); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("OpenFile with another page size: got error %v, want %v", err, ErrInvalidData)
+	}
+
+	garbage := filepath.Join(dir, "garbage")
+	if err := os.WriteFile(garbage, []byte("not a pager file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(garbage, 64); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("OpenFile of garbage: got error %v, want %v", err, ErrInvalidData)
+	}
+}
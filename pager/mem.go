@@ -0,0 +1,118 @@
+package pager
+
+import "sync"
+
+// Mem is a Pager keeping its pages in memory, for tests and for temporary
+// structures. Create Mem pagers with [NewMem].
+type Mem struct {
+	mu       sync.RWMutex
+	pageSize int
+	pages    [][]byte
+	free     []PageID
+	closed   bool
+}
+
+var _ Pager = (*Mem)(nil)
+
+// NewMem creates a new, empty Mem pager with pages of pageSize bytes. It
+// panics if pageSize is smaller than 64.
+func NewMem(pageSize int) *Mem {
+	checkPageSize(pageSize)
+	return &Mem{pageSize: pageSize}
+}
+
+// PageSize implements [Pager.PageSize].
+func (m *Mem) PageSize() int {
+	return m.pageSize
+}
+
+// NumPages implements [Pager.NumPages].
+func (m *Mem) NumPages() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return uint64(len(m.pages))
+}
+
+// ReadPage implements [Pager.ReadPage].
+func (m *Mem) ReadPage(id PageID, buf []byte) error {
+	checkBuf(buf, m.pageSize)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.check(id); err != nil {
+		return err
+	}
+	copy(buf, m.pages[id])
+	return nil
+}
+
+// WritePage implements [Pager.WritePage].
+func (m *Mem) WritePage(id PageID, buf []byte) error {
+	checkBuf(buf, m.pageSize)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.check(id); err != nil {
+		return err
+	}
+	copy(m.pages[id], buf)
+	return nil
+}
+
+// Alloc implements [Pager.Alloc].
+func (m *Mem) Alloc() (PageID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return 0, ErrClosed
+	}
+	if n := len(m.free); n > 0 {
+		id := m.free[n-1]
+		m.free = m.free[:n-1]
+		clear(m.pages[id])
+		return id, nil
+	}
+	m.pages = append(m.pages, make([]byte, m.pageSize))
+	return PageID(len(m.pages) - 1), nil
+}
+
+// Free implements [Pager.Free].
+func (m *Mem) Free(id PageID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.check(id); err != nil {
+		return err
+	}
+	m.free = append(m.free, id)
+	return nil
+}
+
+// Sync implements [Pager.Sync]; it does nothing.
+func (m *Mem) Sync() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+// Close implements [Pager.Close].
+func (m *Mem) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	m.closed = true
+	m.pages, m.free = nil, nil
+	return nil
+}
+
+func (m *Mem) check(id PageID) error {
+	if m.closed {
+		return ErrClosed
+	}
+	if id >= PageID(len(m.pages)) {
+		return ErrInvalidPage
+	}
+	return nil
+}
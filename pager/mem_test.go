@@ -0,0 +1,37 @@
+package pager
+
+import "testing"
+
+func TestMem(t *testing.T) {
+	testPager(t, NewMem(64))
+}
+
+func TestMemConcurrent(t *testing.T) {
+	m := NewMem(128)
+	for range 8 {
+		if _, err := m.Alloc(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := make(chan bool)
+	for g := range 8 {
+		go func() {
+			defer func() { done <- true }()
+			buf := make([]byte, m.PageSize())
+			for i := range 100 {
+				buf[0] = byte(i)
+				if err := m.WritePage(PageID(g), buf); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := m.ReadPage(PageID(g), buf); err != nil || buf[0] != byte(i) {
+					t.Errorf("page %d: got %d, %v, want %d", g, buf[0], err, i)
+					return
+				}
+			}
+		}()
+	}
+	for range 8 {
+		<-done
+	}
+}
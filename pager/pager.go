@@ -0,0 +1,83 @@
+// Package pager defines an interface for storage divided in fixed-size
+// pages, used by the module's disk-backed structures, with implementations
+// backed by files and by memory, and a cache of pages shared between
+// pagers.
+package pager
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PageID identifies a page of a Pager. The pages allocated by a pager have
+// IDs 0 to NumPages()-1.
+type PageID uint64
+
+// Pager is storage divided in pages of a fixed size, which are allocated,
+// read, written and freed individually. The module's disk-backed structures
+// keep their data in pagers, so that they can be stored anywhere a Pager
+// can be implemented for, such as encrypted files or object storage.
+//
+// Implementations must be safe for concurrent use by multiple goroutines.
+type Pager interface {
+	// PageSize returns the size of the pages, in bytes.
+	PageSize() int
+
+	// NumPages returns the number of pages allocated, including the ones
+	// that were freed.
+	NumPages() uint64
+
+	// ReadPage reads the page id into buf, which must be PageSize() bytes
+	// long. The content of pages that were never written is zeros.
+	ReadPage(id PageID, buf []byte) error
+
+	// WritePage writes buf, which must be PageSize() bytes long, to the page
+	// id.
+	WritePage(id PageID, buf []byte) error
+
+	// Alloc allocates a page, reusing a freed one if there's any, and
+	// returns its ID. The page's content is zeros.
+	Alloc() (PageID, error)
+
+	// Free frees the page id, making it available to Alloc. Freed pages
+	// must not be used until they're allocated again.
+	Free(id PageID) error
+
+	// Sync makes all the writes, allocations and frees durable.
+	Sync() error
+
+	// Close syncs and closes the pager.
+	Close() error
+}
+
+var (
+	// ErrInvalidPage is returned when using a page that wasn't allocated.
+	ErrInvalidPage = errors.New("pager: invalid page")
+
+	// ErrInvalidData is returned when opening a file that isn't a valid
+	// pager file, or reading a stream that isn't valid.
+	ErrInvalidData = errors.New("pager: invalid data")
+
+	// ErrClosed is returned when using a closed pager.
+	ErrClosed = errors.New("pager: pager is closed")
+)
+
+// DefaultPageSize is a common page size, that of the memory pages of most
+// platforms.
+const DefaultPageSize = 4096
+
+// minPageSize is the smallest supported page size, which must hold the
+// metadata of file pagers.
+const minPageSize = 64
+
+func checkPageSize(pageSize int) {
+	if pageSize < minPageSize {
+		panic(fmt.Sprintf("pager: invalid page size %d", pageSize))
+	}
+}
+
+func checkBuf(buf []byte, pageSize int) {
+	if len(buf) != pageSize {
+		panic(fmt.Sprintf("pager: buffer of %d bytes for pages of %d bytes", len(buf), pageSize))
+	}
+}
@@ -0,0 +1,155 @@
+package pager
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func checkPage(t *testing.T, p Pager, id PageID, want []byte) {
+	t.Helper()
+	buf := make([]byte, p.PageSize())
+	if err := p.ReadPage(id, buf); err != nil {
+		t.Fatalf("ReadPage(%d): %v", id, err)
+	}
+	if !bytes.Equal(buf, want) {
+		t.Fatalf("page %d: got %v..., want %v...", id, buf[:8], want[:8])
+	}
+}
+
+// testPager checks the behaviour of an empty pager p, comparing it to a
+// model of its pages.
+func testPager(t *testing.T, p Pager) {
+	rnd := makeLoggedRand(t)
+	pageSize := p.PageSize()
+	var model [][]byte
+	var free []PageID
+	isFree := func(id PageID) bool {
+		for _, f := range free {
+			if f == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	for range 2000 {
+		switch op := rnd.IntN(10); {
+		case op < 3 || len(model) == len(free):
+			id, err := p.Alloc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(free) > 0 {
+				if !isFree(id) {
+					t.Fatalf("Alloc returned %d, want one of the free pages %v", id, free)
+				}
+				for i, f := range free {
+					if f == id {
+						free = append(free[:i], free[i+1:]...)
+						break
+					}
+				}
+			} else if id != PageID(len(model)) {
+				t.Fatalf("Alloc returned %d, want %d", id, len(model))
+			} else {
+				model = append(model, nil)
+			}
+			model[id] = make([]byte, pageSize)
+			checkPage(t, p, id, model[id])
+		case op < 4:
+			id := PageID(rnd.IntN(len(model)))
+			if isFree(id) {
+				continue
+			}
+			if err := p.Free(id); err != nil {
+				t.Fatal(err)
+			}
+			free = append(free, id)
+		case op < 7:
+			id := PageID(rnd.IntN(len(model)))
+			if isFree(id) {
+				continue
+			}
+			buf := make([]byte, pageSize)
+			for i := range buf {
+				buf[i] = byte(rnd.Uint32())
+			}
+			if err := p.WritePage(id, buf); err != nil {
+				t.Fatal(err)
+			}
+			model[id] = buf
+		default:
+			id := PageID(rnd.IntN(len(model)))
+			if !isFree(id) {
+				checkPage(t, p, id, model[id])
+			}
+		}
+		if got := p.NumPages(); got != uint64(len(model)) {
+			t.Fatalf("NumPages() = %d, want %d", got, len(model))
+		}
+	}
+
+	buf := make([]byte, pageSize)
+	n := PageID(p.NumPages())
+	if err := p.ReadPage(n, buf); !errors.Is(err, ErrInvalidPage) {
+		t.Errorf("ReadPage past the end: got error %v, want %v", err, ErrInvalidPage)
+	}
+	if err := p.WritePage(n, buf); !errors.Is(err, ErrInvalidPage) {
+		t.Errorf("WritePage past the end: got error %v, want %v", err, ErrInvalidPage)
+	}
+	if err := p.Free(n); !errors.Is(err, ErrInvalidPage) {
+		t.Errorf("Free past the end: got error %v, want %v", err, ErrInvalidPage)
+	}
+
+	if err := p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.ReadPage(0, buf); !errors.Is(err, ErrClosed) {
+		t.Errorf("ReadPage after Close: got error %v, want %v", err, ErrClosed)
+	}
+	if _, err := p.Alloc(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Alloc after Close: got error %v, want %v", err, ErrClosed)
+	}
+	if err := p.Close(); !errors.Is(err, ErrClosed) {
+		t.Errorf("second Close: got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestBadParameters(t *testing.T) {
+	tests := map[string]func(){
+		"NewMem page size":   func() { NewMem(10) },
+		"OpenFile page size": func() { OpenFile("unused", 0) },
+		"ReadPage buffer": func() {
+			m := NewMem(64)
+			m.Alloc()
+			m.ReadPage(0, make([]byte, 63))
+		},
+		"WritePage buffer": func() {
+			m := NewMem(64)
+			m.Alloc()
+			m.WritePage(0, make([]byte, 65))
+		},
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			f()
+		})
+	}
+}
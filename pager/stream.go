@@ -0,0 +1,141 @@
+package pager
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// A stream is a sequence of bytes stored in the consecutive pages of a
+// pager, from page 0, for structures that are written sequentially, such as
+// immutable files. The stream's length is stored in the last 8 bytes of its
+// last page, after zero padding.
+const streamTrailerSize = 8
+
+// Writer writes a stream to an empty pager. Create writers with
+// [NewWriter].
+type Writer struct {
+	p    Pager
+	page []byte
+	n    int
+	size int64
+}
+
+// NewWriter returns a Writer writing a stream to p, which must be empty.
+func NewWriter(p Pager) (*Writer, error) {
+	if p.NumPages() != 0 {
+		return nil, ErrInvalidData
+	}
+	return &Writer{p: p, page: make([]byte, p.PageSize())}, nil
+}
+
+// Write implements [io.Writer].
+func (w *Writer) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		k := copy(w.page[w.n:], b)
+		w.n += k
+		b = b[k:]
+		written += k
+		w.size += int64(k)
+		if w.n == len(w.page) {
+			if err := w.flushPage(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flushPage writes the current page to a newly allocated page.
+func (w *Writer) flushPage() error {
+	id, err := w.p.Alloc()
+	if err != nil {
+		return err
+	}
+	if id != PageID(w.p.NumPages()-1) {
+		// The pager reused a freed page.
+		return ErrInvalidData
+	}
+	if err := w.p.WritePage(id, w.page); err != nil {
+		return err
+	}
+	clear(w.page)
+	w.n = 0
+	return nil
+}
+
+// Close writes the end of the stream. It doesn't sync or close the pager.
+func (w *Writer) Close() error {
+	if w.n > len(w.page)-streamTrailerSize {
+		if err := w.flushPage(); err != nil {
+			return err
+		}
+	}
+	binary.LittleEndian.PutUint64(w.page[len(w.page)-streamTrailerSize:], uint64(w.size))
+	return w.flushPage()
+}
+
+// Reader reads a stream written by a [Writer]. Create readers with
+// [NewReader].
+type Reader struct {
+	p    Pager
+	size int64
+}
+
+// NewReader returns a Reader reading the stream in p.
+func NewReader(p Pager) (*Reader, error) {
+	pageSize := int64(p.PageSize())
+	numPages := p.NumPages()
+	if numPages == 0 {
+		return nil, ErrInvalidData
+	}
+	page := make([]byte, pageSize)
+	if err := p.ReadPage(PageID(numPages-1), page); err != nil {
+		return nil, err
+	}
+	size := int64(binary.LittleEndian.Uint64(page[pageSize-streamTrailerSize:]))
+	capacity := int64(numPages)*pageSize - streamTrailerSize
+	if size < 0 || size > capacity || capacity-size >= pageSize {
+		return nil, ErrInvalidData
+	}
+	return &Reader{p: p, size: size}, nil
+}
+
+// Size returns the size of the stream.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements [io.ReaderAt].
+func (r *Reader) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrInvalidData
+	}
+	pageSize := int64(r.p.PageSize())
+	var page []byte
+	n := 0
+	for n < len(b) && off < r.size {
+		id, start := off/pageSize, off%pageSize
+		end := min(pageSize, start+int64(len(b)-n), start+r.size-off)
+		if start == 0 && end == pageSize && len(b)-n >= int(pageSize) {
+			// Read a whole page directly.
+			if err := r.p.ReadPage(PageID(id), b[n:n+int(pageSize)]); err != nil {
+				return n, err
+			}
+		} else {
+			if page == nil {
+				page = make([]byte, pageSize)
+			}
+			if err := r.p.ReadPage(PageID(id), page); err != nil {
+				return n, err
+			}
+			copy(b[n:], page[start:end])
+		}
+		n += int(end - start)
+		off += end - start
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
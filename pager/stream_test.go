@@ -0,0 +1,77 @@
+package pager
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, size := range []int{0, 1, 55, 56, 57, 64, 120, 128, 1000, 4096} {
+		p := NewMem(64)
+		w, err := NewWriter(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(rnd.Uint32())
+		}
+		for rest := data; len(rest) > 0; {
+			k := min(len(rest), rnd.IntN(100)+1)
+			if n, err := w.Write(rest[:k]); n != k || err != nil {
+				t.Fatalf("Write: got %d, %v, want %d", n, err, k)
+			}
+			rest = rest[k:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewReader(p)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if r.Size() != int64(size) {
+			t.Fatalf("Size() = %d, want %d", r.Size(), size)
+		}
+		got, err := io.ReadAll(io.NewSectionReader(r, 0, r.Size()))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("size %d: read back %d bytes, %v", size, len(got), err)
+		}
+		for range 20 {
+			if size == 0 {
+				break
+			}
+			off := rnd.IntN(size)
+			buf := make([]byte, rnd.IntN(200))
+			n, err := r.ReadAt(buf, int64(off))
+			wantN := min(len(buf), size-off)
+			if n != wantN || !bytes.Equal(buf[:n], data[off:off+n]) {
+				t.Fatalf("ReadAt(%d bytes, %d): got %d bytes, want %d", len(buf), off, n, wantN)
+			}
+			if (n < len(buf)) != (err == io.EOF) {
+				t.Fatalf("ReadAt(%d bytes, %d): got error %v", len(buf), off, err)
+			}
+		}
+	}
+}
+
+func TestStreamInvalid(t *testing.T) {
+	p := NewMem(64)
+	if _, err := NewReader(p); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("NewReader of an empty pager: got error %v, want %v", err, ErrInvalidData)
+	}
+	p.Alloc()
+	if _, err := NewWriter(p); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("NewWriter of a non-empty pager: got error %v, want %v", err, ErrInvalidData)
+	}
+	buf := make([]byte, 64)
+	buf[63] = 1
+	p.WritePage(0, buf)
+	if _, err := NewReader(p); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("NewReader of a bad trailer: got error %v, want %v", err, ErrInvalidData)
+	}
+}
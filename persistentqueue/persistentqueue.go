@@ -0,0 +1,169 @@
+// Package persistentqueue implements a persistent FIFO queue.
+package persistentqueue
+
+import (
+	"iter"
+
+	"github.com/eliben/gogl/conslist"
+)
+
+// Queue is a persistent FIFO queue. Enqueue and Dequeue return new queues
+// and leave the original unchanged, so a queue can be snapshotted for free
+// and shared by multiple goroutines without locking.
+//
+// It's the real-time queue of Hood and Melville, as presented in Okasaki's
+// "Purely Functional Data Structures": elements are dequeued from a front
+// list and enqueued onto a rear list, and when the rear list grows longer
+// than the front, the rear is reversed and appended to the front
+// incrementally, a few steps with each operation. Unlike the simpler
+// two-list queue, whose O(1) bounds are amortized and don't survive
+// dequeuing repeatedly from an old version, every operation takes O(1)
+// worst-case time.
+//
+// Create queues with [New].
+type Queue[T any] struct {
+	lenf  int
+	front *conslist.List[T]
+	rot   rotation[T]
+	lenr  int
+	rear  *conslist.List[T]
+}
+
+// rotation is the state of an incremental rotation, which computes
+// front ++ reverse(rear) in two phases: it first reverses front onto f2 and
+// rear onto r2 in lockstep, and then moves the elements of f2 back onto r2,
+// which ends up as the new front.
+//
+// Elements dequeued during a rotation are still in the copy of front it's
+// working on; valid counts the elements of f2 that haven't been dequeued,
+// which are the only ones to move onto r2.
+type rotation[T any] struct {
+	phase        phase
+	valid        int
+	f, f2, r, r2 *conslist.List[T]
+}
+
+type phase int
+
+const (
+	idle phase = iota
+	reversing
+	appending
+	done
+)
+
+// New creates a new, empty queue.
+func New[T any]() *Queue[T] {
+	return &Queue[T]{}
+}
+
+// Len returns the number of elements in the queue.
+func (q *Queue[T]) Len() int {
+	return q.lenf + q.lenr
+}
+
+// Enqueue returns a new queue with v added at the back of q.
+func (q *Queue[T]) Enqueue(v T) *Queue[T] {
+	nq := *q
+	nq.lenr++
+	nq.rear = q.rear.Push(v)
+	return nq.check()
+}
+
+// Dequeue returns the value at the front of the queue and a new queue
+// without it. It panics if the queue is empty; make sure to check Len()
+// first.
+func (q *Queue[T]) Dequeue() (T, *Queue[T]) {
+	if q.Len() == 0 {
+		panic("persistentqueue: dequeue from empty queue")
+	}
+	nq := *q
+	nq.lenf--
+	nq.front = q.front.Tail()
+	nq.rot = q.rot.invalidate()
+	return q.front.Head(), nq.check()
+}
+
+// Peek returns the value at the front of the queue. It panics if the queue
+// is empty.
+func (q *Queue[T]) Peek() T {
+	if q.Len() == 0 {
+		panic("persistentqueue: peek into empty queue")
+	}
+	return q.front.Head()
+}
+
+// All returns an iterator over the values in the queue, from front to back.
+func (q *Queue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for q.Len() > 0 {
+			var v T
+			v, q = q.Dequeue()
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// check restores the invariant that the rear isn't longer than the front,
+// by starting a rotation if needed, and advances the rotation in progress.
+// It may modify q, which must be a new queue.
+func (q *Queue[T]) check() *Queue[T] {
+	if q.lenr > q.lenf {
+		q.rot = rotation[T]{phase: reversing, f: q.front, r: q.rear}
+		q.lenf += q.lenr
+		q.lenr, q.rear = 0, nil
+	}
+	if q.rot = q.rot.step().step(); q.rot.phase == done {
+		q.front = q.rot.r2
+		q.rot = rotation[T]{}
+	}
+	return q
+}
+
+// step returns the state of the rotation after one more step.
+func (rot rotation[T]) step() rotation[T] {
+	switch rot.phase {
+	case reversing:
+		if !rot.f.IsEmpty() {
+			rot.valid++
+			rot.f2 = rot.f2.Push(rot.f.Head())
+			rot.f = rot.f.Tail()
+			rot.r2 = rot.r2.Push(rot.r.Head())
+			rot.r = rot.r.Tail()
+		} else {
+			// The rotation started with one more element in rear than in
+			// front; move it and start appending.
+			rot.phase = appending
+			rot.r2 = rot.r2.Push(rot.r.Head())
+			rot.r = nil
+		}
+	case appending:
+		if rot.valid == 0 {
+			rot.phase = done
+		} else {
+			rot.valid--
+			rot.r2 = rot.r2.Push(rot.f2.Head())
+			rot.f2 = rot.f2.Tail()
+		}
+	}
+	return rot
+}
+
+// invalidate returns the state of the rotation after an element has been
+// dequeued from the front.
+func (rot rotation[T]) invalidate() rotation[T] {
+	switch {
+	case rot.phase == reversing:
+		rot.valid--
+	case rot.phase == appending && rot.valid == 0:
+		// The element dequeued is the first of r2, which is the whole new
+		// front.
+		rot.phase = done
+		rot.r2 = rot.r2.Tail()
+	case rot.phase == appending:
+		rot.valid--
+	}
+	return rot
+}
@@ -0,0 +1,138 @@
+package persistentqueue
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func checkQueue(t *testing.T, q *Queue[int], want []int) {
+	t.Helper()
+	if q.Len() != len(want) {
+		t.Fatalf("Len=%d, want %d", q.Len(), len(want))
+	}
+	if q.lenr > q.lenf {
+		t.Fatalf("rear longer than front: %d > %d", q.lenr, q.lenf)
+	}
+	if len(want) > 0 && q.Peek() != want[0] {
+		t.Fatalf("Peek=%d, want %d", q.Peek(), want[0])
+	}
+	if got := slices.Collect(q.All()); !slices.Equal(got, want) {
+		t.Fatalf("All=%v, want %v", got, want)
+	}
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	q := New[int]()
+	checkQueue(t, q, nil)
+	var want []int
+	for i := range 100 {
+		q = q.Enqueue(i)
+		want = append(want, i)
+		checkQueue(t, q, want)
+	}
+	for len(want) > 0 {
+		var v int
+		v, q = q.Dequeue()
+		if v != want[0] {
+			t.Fatalf("Dequeue=%d, want %d", v, want[0])
+		}
+		want = want[1:]
+		checkQueue(t, q, want)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	q := New[int]()
+	var want []int
+	var versions []*Queue[int]
+	var contents [][]int
+	for i := range 20000 {
+		if rnd.IntN(5) < 3 || len(want) == 0 {
+			q = q.Enqueue(i)
+			want = append(slices.Clip(want), i)
+		} else {
+			var v int
+			v, q = q.Dequeue()
+			if v != want[0] {
+				t.Fatalf("Dequeue=%d, want %d", v, want[0])
+			}
+			want = want[1:]
+		}
+		if q.lenr > q.lenf {
+			t.Fatalf("rear longer than front: %d > %d", q.lenr, q.lenf)
+		}
+		if i%1000 == 0 {
+			versions = append(versions, q)
+			contents = append(contents, want)
+		}
+	}
+	checkQueue(t, q, want)
+	for i, v := range versions {
+		checkQueue(t, v, contents[i])
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	// Operations on a shared old version, including in the middle of a
+	// rotation, don't affect each other.
+	q := New[int]()
+	for i := range 37 {
+		q = q.Enqueue(i)
+	}
+	_, q = q.Dequeue()
+	for range 3 {
+		a := q.Enqueue(100)
+		_, b := q.Dequeue()
+		_, c := a.Dequeue()
+		checkQueue(t, a, append(seq(1, 37), 100))
+		checkQueue(t, b, seq(2, 37))
+		checkQueue(t, c, append(seq(2, 37), 100))
+		checkQueue(t, q, seq(1, 37))
+	}
+}
+
+func seq(lo, hi int) []int {
+	var s []int
+	for i := lo; i < hi; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+func TestPanics(t *testing.T) {
+	empty := New[int]()
+	for name, f := range map[string]func(){
+		"dequeue": func() { empty.Dequeue() },
+		"peek":    func() { empty.Peek() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func BenchmarkEnqueueDequeue(b *testing.B) {
+	q := New[int]()
+	for i := range 1000 {
+		q = q.Enqueue(i)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		q = q.Enqueue(i)
+		_, q = q.Dequeue()
+	}
+}
@@ -0,0 +1,243 @@
+// Package piecetable implements a piece table: the text representation used
+// by many editors, where a document is described as a sequence of pieces of
+// two immutable buffers.
+package piecetable
+
+import (
+	"iter"
+	"strings"
+
+	"github.com/eliben/gogl/list"
+)
+
+// Table is a piece table. The document's text is a sequence of pieces, each
+// referring to a span of either the original text or an append-only buffer
+// holding all the text ever inserted. Since neither buffer is ever
+// modified, edits only change the piece sequence, and every edit can be
+// undone and redone. Create tables with [New].
+type Table struct {
+	original string
+	add      strings.Builder
+	pieces   *list.List[piece]
+	length   int
+
+	// undo and redo are the stacks of edits that can be undone and redone,
+	// with the most recent at the end.
+	undo, redo []edit
+}
+
+type source uint8
+
+const (
+	sourceOriginal source = iota
+	sourceAdd
+)
+
+// piece is a span [start, start+length) of one of the table's buffers.
+type piece struct {
+	src    source
+	start  int
+	length int
+}
+
+// edit records an edit to the table: the insertion (if insert is true) or
+// deletion of pieces at position pos.
+type edit struct {
+	insert bool
+	pos    int
+	pieces []piece
+}
+
+// New creates a new table holding the given original text.
+func New(original string) *Table {
+	t := &Table{original: original, pieces: list.New[piece]()}
+	if original != "" {
+		t.pieces.InsertBack(piece{src: sourceOriginal, start: 0, length: len(original)})
+		t.length = len(original)
+	}
+	return t
+}
+
+// Len returns the length of the table's text in bytes.
+func (t *Table) Len() int {
+	return t.length
+}
+
+// String returns the table's text.
+func (t *Table) String() string {
+	var sb strings.Builder
+	sb.Grow(t.length)
+	for c := range t.Chunks() {
+		sb.WriteString(c)
+	}
+	return sb.String()
+}
+
+// Chunks returns an iterator over the text of the table's pieces, in order;
+// concatenated, the chunks form the table's text.
+func (t *Table) Chunks() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for p := range t.pieces.Values() {
+			if !yield(t.text(p)) {
+				return
+			}
+		}
+	}
+}
+
+// Insert inserts s at position pos. It panics if pos is not in the range
+// [0, Len()].
+func (t *Table) Insert(pos int, s string) {
+	if pos < 0 || pos > t.length {
+		panic("piecetable: position out of range")
+	}
+	if s == "" {
+		return
+	}
+	p := piece{src: sourceAdd, start: t.add.Len(), length: len(s)}
+	t.add.WriteString(s)
+	t.do(edit{insert: true, pos: pos, pieces: []piece{p}})
+}
+
+// Delete deletes the text in the range [i, j). It panics if the range is
+// invalid.
+func (t *Table) Delete(i, j int) {
+	if i < 0 || j < i || j > t.length {
+		panic("piecetable: range out of bounds")
+	}
+	if i == j {
+		return
+	}
+	// Deletions record the deleted pieces when they're applied.
+	t.do(edit{insert: false, pos: i, pieces: []piece{{length: j - i}}})
+}
+
+// Undo undoes the most recent edit that wasn't undone yet. It returns false
+// if there's no edit to undo.
+func (t *Table) Undo() bool {
+	if len(t.undo) == 0 {
+		return false
+	}
+	e := t.undo[len(t.undo)-1]
+	t.undo = t.undo[:len(t.undo)-1]
+	e.insert = !e.insert
+	t.apply(&e)
+	e.insert = !e.insert
+	t.redo = append(t.redo, e)
+	return true
+}
+
+// Redo redoes the most recently undone edit. It returns false if there's no
+// edit to redo; making a new edit discards the edits available for redo.
+func (t *Table) Redo() bool {
+	if len(t.redo) == 0 {
+		return false
+	}
+	e := t.redo[len(t.redo)-1]
+	t.redo = t.redo[:len(t.redo)-1]
+	t.apply(&e)
+	t.undo = append(t.undo, e)
+	return true
+}
+
+// do applies a new edit and records it in the undo history.
+func (t *Table) do(e edit) {
+	t.apply(&e)
+	t.undo = append(t.undo, e)
+	t.redo = t.redo[:0]
+}
+
+// apply applies e to the piece sequence. For deletions, it sets e.pieces to
+// the deleted pieces.
+func (t *Table) apply(e *edit) {
+	if e.insert {
+		t.insertPieces(e.pos, e.pieces)
+		return
+	}
+	n := 0
+	for _, p := range e.pieces {
+		n += p.length
+	}
+	e.pieces = t.removeRange(e.pos, e.pos+n)
+}
+
+func (t *Table) text(p piece) string {
+	if p.src == sourceOriginal {
+		return t.original[p.start : p.start+p.length]
+	}
+	// The add buffer is append-only, so the strings it returned earlier
+	// remain valid.
+	return t.add.String()[p.start : p.start+p.length]
+}
+
+// splitAt makes sure there's a piece boundary at pos, and returns the node
+// of the piece starting at pos, or nil if pos is at the end of the text.
+func (t *Table) splitAt(pos int) *list.Node[piece] {
+	for n := t.pieces.Front(); n != nil; n = t.pieces.Next(n) {
+		if pos == 0 {
+			return n
+		}
+		if pos < n.Value.length {
+			rest := piece{src: n.Value.src, start: n.Value.start + pos, length: n.Value.length - pos}
+			n.Value.length = pos
+			return t.pieces.InsertAfter(n, rest)
+		}
+		pos -= n.Value.length
+	}
+	return nil
+}
+
+// insertPieces inserts pieces at position pos.
+func (t *Table) insertPieces(pos int, pieces []piece) {
+	at := t.splitAt(pos)
+	var first, last *list.Node[piece]
+	for _, p := range pieces {
+		if at != nil {
+			last = t.pieces.InsertBefore(at, p)
+		} else {
+			t.pieces.InsertBack(p)
+			last = t.pieces.Back()
+		}
+		if first == nil {
+			first = last
+		}
+		t.length += p.length
+	}
+	if at != nil {
+		t.mergeWithPrev(at)
+	}
+	t.mergeWithPrev(first)
+}
+
+// removeRange removes the text in the range [i, j), and returns the pieces
+// that held it.
+func (t *Table) removeRange(i, j int) []piece {
+	t.splitAt(j)
+	n := t.splitAt(i)
+	var removed []piece
+	for remaining := j - i; remaining > 0; {
+		next := t.pieces.Next(n)
+		removed = append(removed, n.Value)
+		remaining -= n.Value.length
+		t.length -= n.Value.length
+		t.pieces.Remove(n)
+		n = next
+	}
+	if n != nil {
+		t.mergeWithPrev(n)
+	}
+	return removed
+}
+
+// mergeWithPrev merges the piece at n into the preceding piece if they're
+// adjacent spans of the same buffer. This keeps the number of pieces from
+// growing as edits are undone and redone, and merges consecutive typing
+// into a single piece.
+func (t *Table) mergeWithPrev(n *list.Node[piece]) {
+	prev := t.pieces.Prev(n)
+	if prev == nil || prev.Value.src != n.Value.src || prev.Value.start+prev.Value.length != n.Value.start {
+		return
+	}
+	prev.Value.length += n.Value.length
+	t.pieces.Remove(n)
+}
@@ -0,0 +1,128 @@
+package piecetable
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func checkText(t *testing.T, pt *Table, want string) {
+	t.Helper()
+	if pt.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", pt.Len(), len(want))
+	}
+	if got := pt.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for p := range pt.pieces.Values() {
+		if p.length == 0 {
+			t.Fatalf("empty piece")
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	pt := New("the quick fox")
+	pt.Insert(10, "brown ")
+	checkText(t, pt, "the quick brown fox")
+	pt.Delete(0, 4)
+	checkText(t, pt, "quick brown fox")
+	pt.Insert(pt.Len(), " jumps")
+	checkText(t, pt, "quick brown fox jumps")
+	if got := slices.Collect(pt.Chunks()); len(got) != 4 {
+		t.Errorf("got chunks %q", got)
+	}
+
+	pt.Undo()
+	checkText(t, pt, "quick brown fox")
+	pt.Undo()
+	checkText(t, pt, "the quick brown fox")
+	pt.Redo()
+	checkText(t, pt, "quick brown fox")
+	pt.Undo()
+	pt.Undo()
+	checkText(t, pt, "the quick fox")
+	if pt.Undo() {
+		t.Errorf("Undo succeeded with empty history")
+	}
+	if pt.pieces.Len() != 1 {
+		t.Errorf("got %d pieces after undoing everything, want 1", pt.pieces.Len())
+	}
+
+	// A new edit discards the redo history.
+	pt.Insert(0, "see ")
+	checkText(t, pt, "see the quick fox")
+	if pt.Redo() {
+		t.Errorf("Redo succeeded after a new edit")
+	}
+}
+
+func TestTypingMerges(t *testing.T) {
+	pt := New("")
+	for i, c := range "hello" {
+		pt.Insert(i, string(c))
+	}
+	checkText(t, pt, "hello")
+	if pt.pieces.Len() != 1 {
+		t.Errorf("got %d pieces, want 1", pt.pieces.Len())
+	}
+	for range 5 {
+		pt.Undo()
+	}
+	checkText(t, pt, "")
+}
+
+func TestRandomWithHistory(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	original := strings.Repeat("0123456789", 20)
+	pt := New(original)
+
+	// history[i] is the expected text after i edits; pos is the current
+	// position in it, as moved by undo/redo.
+	history := []string{original}
+	pos := 0
+	for range 3000 {
+		cur := history[pos]
+		switch rnd.IntN(6) {
+		case 0, 1:
+			i := rnd.IntN(len(cur) + 1)
+			s := strings.Repeat(string(rune('a'+rnd.IntN(26))), 1+rnd.IntN(5))
+			pt.Insert(i, s)
+			history = append(history[:pos+1], cur[:i]+s+cur[i:])
+			pos++
+		case 2:
+			if len(cur) == 0 {
+				continue
+			}
+			i := rnd.IntN(len(cur))
+			j := i + 1 + rnd.IntN(len(cur)-i)
+			pt.Delete(i, j)
+			history = append(history[:pos+1], cur[:i]+cur[j:])
+			pos++
+		case 3, 4:
+			if pt.Undo() != (pos > 0) {
+				t.Fatalf("unexpected Undo result at pos %d", pos)
+			}
+			pos = max(pos-1, 0)
+		case 5:
+			if pt.Redo() != (pos < len(history)-1) {
+				t.Fatalf("unexpected Redo result at pos %d", pos)
+			}
+			pos = min(pos+1, len(history)-1)
+		}
+		checkText(t, pt, history[pos])
+	}
+
+	// Undoing everything restores the original text in a single piece.
+	for pt.Undo() {
+	}
+	checkText(t, pt, original)
+	if pt.pieces.Len() != 1 {
+		t.Errorf("got %d pieces, want 1", pt.pieces.Len())
+	}
+}
@@ -0,0 +1,413 @@
+// Package pvector implements a persistent vector: an immutable sequence
+// supporting indexing, updates, appends and slicing that produce new
+// versions while sharing most of their structure with the old ones.
+package pvector
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Vector is a persistent vector in the style of Clojure's: a trie with a
+// branching factor of 32 over the positions of the elements, whose leaves
+// hold 32 elements each, plus a tail of up to 32 elements that haven't been
+// pushed into the trie yet. Get, Set, Append and Slice take O(log32 n) time,
+// which is a small constant for any practical n; Append is O(1) except once
+// every 32 elements.
+//
+// Vectors are never modified: Set, Append and Slice return new vectors that
+// share the unchanged parts of the trie with the original, which remains
+// valid. Vectors are therefore safe for concurrent use by multiple
+// goroutines. To build a vector from many elements, use a [Builder], which
+// avoids the copying of persistent updates.
+//
+// Create vectors with [New], [FromSlice] or [FromSeq].
+type Vector[T any] struct {
+	t trie[T]
+}
+
+const (
+	bits  = 5
+	width = 1 << bits
+	mask  = width - 1
+)
+
+// trie is the representation shared by vectors and builders. Its positions
+// [0, size) hold elements; those in [offset, size) are the ones visible to
+// its owner, and the rest are the prefix dropped by slicing.
+type trie[T any] struct {
+	size   int
+	offset int
+
+	// shift is the level of root: each of its children covers 1<<shift
+	// positions. The leaves are at level 0.
+	shift uint
+	root  *node[T]
+
+	// tail holds the positions from tailOffset(size) to size; it's never
+	// empty unless size is 0.
+	tail []T
+}
+
+// node is a node of the trie: inner nodes have up to 32 children, and leaves
+// exactly 32 values.
+type node[T any] struct {
+	// edit is the token of the Builder that created the node and may modify
+	// it in place, or nil if the node is immutable.
+	edit     *token
+	children []*node[T]
+	values   []T
+}
+
+// token identifies a Builder's editing session; it has non-zero size so
+// that distinct tokens have distinct addresses.
+type token struct{ _ byte }
+
+// New creates a new, empty vector.
+func New[T any]() *Vector[T] {
+	return &Vector[T]{t: emptyTrie[T]()}
+}
+
+// FromSlice creates a new vector holding a copy of values.
+func FromSlice[T any](values []T) *Vector[T] {
+	b := New[T]().Builder()
+	for _, v := range values {
+		b.Append(v)
+	}
+	return b.Vector()
+}
+
+// FromSeq creates a new vector holding the values in seq, in order.
+func FromSeq[T any](seq iter.Seq[T]) *Vector[T] {
+	b := New[T]().Builder()
+	for v := range seq {
+		b.Append(v)
+	}
+	return b.Vector()
+}
+
+// Len returns the number of elements in the vector.
+func (v *Vector[T]) Len() int {
+	return v.t.len()
+}
+
+// Get returns the i-th element of the vector. It panics if i is out of
+// range.
+func (v *Vector[T]) Get(i int) T {
+	v.t.checkIndex(i)
+	j := v.t.offset + i
+	return v.t.leafFor(j)[j&mask]
+}
+
+// Set returns a new vector with the i-th element set to x. It panics if i is
+// out of range.
+func (v *Vector[T]) Set(i int, x T) *Vector[T] {
+	v.t.checkIndex(i)
+	t := v.t
+	t.set(t.offset+i, x, nil)
+	return &Vector[T]{t: t}
+}
+
+// Append returns a new vector with x added at the end.
+func (v *Vector[T]) Append(x T) *Vector[T] {
+	t := v.t
+	t.append(x, nil)
+	return &Vector[T]{t: t}
+}
+
+// Slice returns a new vector holding the elements in the range [lo, hi) of
+// v. It panics if the range is invalid.
+//
+// The new vector shares the trie of v, so it keeps the elements before lo
+// reachable until they're dropped from the trie (which happens once all the
+// remaining elements are in the tail).
+func (v *Vector[T]) Slice(lo, hi int) *Vector[T] {
+	n := v.t.len()
+	if lo < 0 || hi < lo || hi > n {
+		panic(fmt.Sprintf("pvector: slice bounds [%d:%d] out of range [0:%d]", lo, hi, n))
+	}
+	if lo == hi {
+		return New[T]()
+	}
+	t := v.t
+	if hi < n {
+		t.truncate(t.offset + hi)
+	}
+	t.offset += lo
+	if tailOff := tailOffset(t.size); t.offset >= tailOff {
+		// All the remaining elements are in the tail; drop the trie.
+		t = trie[T]{
+			size:  t.size - t.offset,
+			shift: bits,
+			root:  &node[T]{},
+			tail:  t.tail[t.offset-tailOff:],
+		}
+	}
+	return &Vector[T]{t: t}
+}
+
+// All returns an iterator over index, value pairs in the vector, in order.
+func (v *Vector[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for x := range v.t.values() {
+			if !yield(i, x) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Values returns an iterator over the values in the vector, in order.
+func (v *Vector[T]) Values() iter.Seq[T] {
+	return v.t.values()
+}
+
+// Builder returns a new builder whose contents are initially those of v.
+func (v *Vector[T]) Builder() *Builder[T] {
+	t := v.t
+	t.tail = append(make([]T, 0, width), t.tail...)
+	return &Builder[T]{t: t, edit: new(token)}
+}
+
+// Builder is a transient version of a [Vector], for building vectors from
+// many updates efficiently. Unlike vectors, builders are modified in place:
+// nodes of the trie created by a builder are updated without copying until
+// Vector is called, which makes appends to a builder O(1) amortized with
+// little garbage. A builder may not be used by multiple goroutines
+// concurrently.
+//
+// Create builders with [Vector.Builder].
+type Builder[T any] struct {
+	t    trie[T]
+	edit *token
+}
+
+// Len returns the number of elements in the builder.
+func (b *Builder[T]) Len() int {
+	return b.t.len()
+}
+
+// Get returns the i-th element of the builder. It panics if i is out of
+// range.
+func (b *Builder[T]) Get(i int) T {
+	b.t.checkIndex(i)
+	j := b.t.offset + i
+	return b.t.leafFor(j)[j&mask]
+}
+
+// Set sets the i-th element of the builder to x. It panics if i is out of
+// range.
+func (b *Builder[T]) Set(i int, x T) {
+	b.t.checkIndex(i)
+	b.t.set(b.t.offset+i, x, b.edit)
+}
+
+// Append adds x at the end of the builder.
+func (b *Builder[T]) Append(x T) {
+	b.t.append(x, b.edit)
+}
+
+// Vector returns a vector with the current contents of the builder, in O(1)
+// time. The builder remains usable; subsequent updates to it don't affect
+// the returned vector.
+func (b *Builder[T]) Vector() *Vector[T] {
+	t := b.t
+	t.tail = make([]T, len(b.t.tail))
+	copy(t.tail, b.t.tail)
+	// The nodes created so far now belong to the vector too; start a new
+	// editing session so that they're copied before being modified again.
+	b.edit = new(token)
+	return &Vector[T]{t: t}
+}
+
+func emptyTrie[T any]() trie[T] {
+	return trie[T]{shift: bits, root: &node[T]{}}
+}
+
+func (t *trie[T]) len() int {
+	return t.size - t.offset
+}
+
+func (t *trie[T]) checkIndex(i int) {
+	if i < 0 || i >= t.len() {
+		panic(fmt.Sprintf("pvector: index %d out of range [0:%d]", i, t.len()))
+	}
+}
+
+// tailOffset returns the first position in the tail of a trie holding size
+// positions.
+func tailOffset(size int) int {
+	if size < width {
+		return 0
+	}
+	return (size - 1) &^ mask
+}
+
+// leafFor returns the values of the leaf (or tail) holding position j, at
+// index j&mask.
+func (t *trie[T]) leafFor(j int) []T {
+	if j >= tailOffset(t.size) {
+		return t.tail
+	}
+	n := t.root
+	for level := t.shift; level > 0; level -= bits {
+		n = n.children[(j>>level)&mask]
+	}
+	return n.values
+}
+
+// values returns an iterator over the visible elements of t, leaf by leaf.
+func (t *trie[T]) values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for j := t.offset; j < t.size; {
+			for _, x := range t.leafFor(j)[j&mask:] {
+				if !yield(x) {
+					return
+				}
+				j++
+			}
+		}
+	}
+}
+
+// editable returns n if it may be modified in place under edit, or else a
+// copy of n that may be. A nil edit always copies, which is how persistent
+// updates are made.
+func editable[T any](n *node[T], edit *token) *node[T] {
+	if edit != nil && n.edit == edit {
+		return n
+	}
+	c := &node[T]{edit: edit}
+	if n.children != nil {
+		c.children = append(make([]*node[T], 0, width), n.children...)
+	}
+	if n.values != nil {
+		c.values = append(make([]T, 0, width), n.values...)
+	}
+	return c
+}
+
+// set sets position j to x.
+func (t *trie[T]) set(j int, x T, edit *token) {
+	if off := tailOffset(t.size); j >= off {
+		if edit == nil {
+			tail := make([]T, len(t.tail))
+			copy(tail, t.tail)
+			t.tail = tail
+		}
+		t.tail[j-off] = x
+		return
+	}
+	t.root = setPath(t.root, t.shift, j, x, edit)
+}
+
+func setPath[T any](n *node[T], level uint, j int, x T, edit *token) *node[T] {
+	n = editable(n, edit)
+	if level == 0 {
+		n.values[j&mask] = x
+	} else {
+		i := (j >> level) & mask
+		n.children[i] = setPath(n.children[i], level-bits, j, x, edit)
+	}
+	return n
+}
+
+// append adds x at position size. Persistent appends copy the tail, whose
+// capacity always equals its length; a builder owns its tail and appends to
+// it in place.
+func (t *trie[T]) append(x T, edit *token) {
+	if len(t.tail) < width {
+		if edit == nil {
+			tail := make([]T, len(t.tail)+1)
+			copy(tail, t.tail)
+			tail[len(t.tail)] = x
+			t.tail = tail
+		} else {
+			t.tail = append(t.tail, x)
+		}
+		t.size++
+		return
+	}
+
+	// The tail is full: push it into the trie as a leaf.
+	leaf := &node[T]{edit: edit, values: t.tail}
+	if t.size>>bits > 1<<t.shift {
+		// The root is full; grow the trie by one level.
+		t.root = &node[T]{edit: edit, children: []*node[T]{t.root, newPath(t.shift, leaf, edit)}}
+		t.shift += bits
+	} else {
+		t.root = t.pushTail(t.root, t.shift, leaf, edit)
+	}
+	if edit == nil {
+		t.tail = []T{x}
+	} else {
+		t.tail = append(make([]T, 0, width), x)
+	}
+	t.size++
+}
+
+// pushTail adds leaf as the last leaf of the subtree of n, which is at the
+// given level, and returns the updated subtree.
+func (t *trie[T]) pushTail(n *node[T], level uint, leaf *node[T], edit *token) *node[T] {
+	n = editable(n, edit)
+	i := ((t.size - 1) >> level) & mask
+	var child *node[T]
+	switch {
+	case level == bits:
+		child = leaf
+	case i < len(n.children):
+		child = t.pushTail(n.children[i], level-bits, leaf, edit)
+	default:
+		child = newPath(level-bits, leaf, edit)
+	}
+	if i < len(n.children) {
+		n.children[i] = child
+	} else {
+		n.children = append(n.children, child)
+	}
+	return n
+}
+
+// newPath returns a chain of nodes from the given level down to leaf.
+func newPath[T any](level uint, leaf *node[T], edit *token) *node[T] {
+	if level == 0 {
+		return leaf
+	}
+	return &node[T]{edit: edit, children: []*node[T]{newPath(level-bits, leaf, edit)}}
+}
+
+// truncate drops the positions from n on, where t.offset < n < t.size.
+func (t *trie[T]) truncate(n int) {
+	if off := tailOffset(t.size); n > off {
+		t.tail = t.tail[: n-off : n-off]
+		t.size = n
+		return
+	}
+
+	// The last leaf that remains becomes the tail.
+	off := tailOffset(n)
+	t.tail = t.leafFor(n - 1)[: n-off : n-off]
+	if off == 0 {
+		t.root, t.shift = &node[T]{}, bits
+	} else {
+		t.root = trim(t.root, t.shift, off)
+		for t.shift > bits && len(t.root.children) == 1 {
+			t.root = t.root.children[0]
+			t.shift -= bits
+		}
+	}
+	t.size = n
+}
+
+// trim returns a copy of the subtree of n, which is at the given level,
+// holding only its first m positions; m is a positive multiple of 32.
+func trim[T any](n *node[T], level uint, m int) *node[T] {
+	k := (m-1)>>level + 1
+	c := &node[T]{children: append([]*node[T](nil), n.children[:k]...)}
+	if level > bits {
+		c.children[k-1] = trim(c.children[k-1], level-bits, m-(k-1)<<level)
+	}
+	return c
+}
@@ -0,0 +1,255 @@
+package pvector
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// checkContents checks that v holds exactly want, through all its accessors.
+func checkContents(t *testing.T, v *Vector[int], want []int) {
+	t.Helper()
+	if v.Len() != len(want) {
+		t.Fatalf("Len=%d, want %d", v.Len(), len(want))
+	}
+	for i, w := range want {
+		if got := v.Get(i); got != w {
+			t.Fatalf("Get(%d)=%d, want %d", i, got, w)
+		}
+	}
+	if got := slices.Collect(v.Values()); !slices.Equal(got, want) {
+		t.Fatalf("Values=%v, want %v", got, want)
+	}
+	i := 0
+	for j, x := range v.All() {
+		if j != i || x != want[i] {
+			t.Fatalf("All yielded %d=%d at %d", j, x, i)
+		}
+		i++
+	}
+}
+
+func iota(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
+
+func TestAppendGet(t *testing.T) {
+	// Sizes around the boundaries where the tail is pushed and the trie
+	// grows a level.
+	for _, n := range []int{0, 1, 31, 32, 33, 64, 1023, 1024, 1025, 1056, 1057, 32*1024 + 33, 100000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			v := New[int]()
+			for i := range n {
+				v = v.Append(i)
+			}
+			checkContents(t, v, iota(n))
+			checkContents(t, FromSlice(iota(n)), iota(n))
+			checkContents(t, FromSeq(slices.Values(iota(n))), iota(n))
+		})
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	var versions []*Vector[int]
+	var contents [][]int
+	v := New[int]()
+	var want []int
+	for range 5000 {
+		want = slices.Clone(want)
+		switch op := rnd.IntN(10); {
+		case op < 6 || len(want) == 0:
+			x := rnd.Int()
+			v = v.Append(x)
+			want = append(want, x)
+		case op < 9:
+			i, x := rnd.IntN(len(want)), rnd.Int()
+			v = v.Set(i, x)
+			want[i] = x
+		default:
+			lo := rnd.IntN(len(want) + 1)
+			hi := lo + rnd.IntN(len(want)-lo+1)
+			v = v.Slice(lo, hi)
+			want = want[lo:hi]
+		}
+		if rnd.IntN(50) == 0 {
+			versions = append(versions, v)
+			contents = append(contents, want)
+		}
+	}
+	checkContents(t, v, want)
+	for i, old := range versions {
+		checkContents(t, old, contents[i])
+	}
+}
+
+func TestSlice(t *testing.T) {
+	const n = 40000
+	v := FromSlice(iota(n))
+	want := iota(n)
+	for _, r := range [][2]int{
+		{0, n}, {0, 0}, {5, 5}, {0, 1}, {0, 32}, {0, 33}, {31, 33}, {32, 64},
+		{0, 1024}, {0, 1025}, {1000, 1100}, {1, n - 1}, {n - 40, n}, {12345, 34567},
+	} {
+		lo, hi := r[0], r[1]
+		s := v.Slice(lo, hi)
+		checkContents(t, s, want[lo:hi])
+
+		// Operations on the slice must not leak into v or into each other.
+		s2 := s.Append(-1)
+		checkContents(t, s2, append(slices.Clone(want[lo:hi]), -1))
+		if hi > lo {
+			s3 := s.Set(0, -2)
+			checkContents(t, s3, append([]int{-2}, want[lo+1:hi]...))
+
+			// Nested slicing.
+			mid := (hi - lo) / 2
+			checkContents(t, s.Slice(mid, hi-lo), want[lo+mid:hi])
+			checkContents(t, s.Slice(0, mid), want[lo:lo+mid])
+		}
+		checkContents(t, s, want[lo:hi])
+	}
+	checkContents(t, v, want)
+}
+
+func TestSliceThenGrow(t *testing.T) {
+	// Truncating a deep trie and growing it again must reuse the positions
+	// past the slice.
+	v := FromSlice(iota(5000))
+	s := v.Slice(10, 1050)
+	want := iota(5000)[10:1050]
+	for i := range 3000 {
+		s = s.Append(-i)
+		want = append(want, -i)
+	}
+	checkContents(t, s, want)
+	checkContents(t, v, iota(5000))
+}
+
+func TestBuilder(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	v := FromSlice(iota(1500))
+	b := v.Builder()
+	want := iota(1500)
+	var snapshots []*Vector[int]
+	var contents [][]int
+	for i := range 10000 {
+		if rnd.IntN(3) == 0 {
+			j, x := rnd.IntN(len(want)), rnd.Int()
+			b.Set(j, x)
+			want[j] = x
+		} else {
+			b.Append(i)
+			want = append(want, i)
+		}
+		if b.Len() != len(want) {
+			t.Fatalf("Len=%d, want %d", b.Len(), len(want))
+		}
+		if j := rnd.IntN(len(want)); b.Get(j) != want[j] {
+			t.Fatalf("Get(%d)=%d, want %d", j, b.Get(j), want[j])
+		}
+		if rnd.IntN(500) == 0 {
+			snapshots = append(snapshots, b.Vector())
+			contents = append(contents, slices.Clone(want))
+		}
+	}
+	checkContents(t, b.Vector(), want)
+
+	// The original vector and the snapshots are unaffected by later updates
+	// to the builder.
+	checkContents(t, v, iota(1500))
+	for i, s := range snapshots {
+		checkContents(t, s, contents[i])
+	}
+}
+
+func TestBuilderFromSlice(t *testing.T) {
+	v := FromSlice(iota(2000)).Slice(100, 1100)
+	b := v.Builder()
+	for i := range 100 {
+		b.Append(-i)
+	}
+	b.Set(0, -1000)
+	want := slices.Clone(iota(2000)[100:1100])
+	for i := range 100 {
+		want = append(want, -i)
+	}
+	want[0] = -1000
+	checkContents(t, b.Vector(), want)
+	checkContents(t, v, iota(2000)[100:1100])
+}
+
+func TestIterStop(t *testing.T) {
+	v := FromSlice(iota(100))
+	n := 0
+	for i := range v.All() {
+		if i == 40 {
+			break
+		}
+		n++
+	}
+	if n != 40 {
+		t.Errorf("got %d iterations, want 40", n)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	v := FromSlice(iota(10))
+	for name, f := range map[string]func(){
+		"get":         func() { v.Get(10) },
+		"get-neg":     func() { v.Get(-1) },
+		"set":         func() { v.Set(10, 0) },
+		"slice-hi":    func() { v.Slice(0, 11) },
+		"slice-order": func() { v.Slice(5, 4) },
+		"builder-get": func() { v.Builder().Get(10) },
+		"sliced-get":  func() { v.Slice(2, 5).Get(3) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	for range b.N {
+		v := New[int]()
+		for i := range 10000 {
+			v = v.Append(i)
+		}
+	}
+}
+
+func BenchmarkBuilderAppend(b *testing.B) {
+	for range b.N {
+		bld := New[int]().Builder()
+		for i := range 10000 {
+			bld.Append(i)
+		}
+		bld.Vector()
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	v := FromSlice(iota(100000))
+	b.ResetTimer()
+	for i := range b.N {
+		v.Get(i % 100000)
+	}
+}
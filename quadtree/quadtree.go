@@ -0,0 +1,271 @@
+// Package quadtree implements region quadtrees and octrees: spatial indexes
+// that recursively partition a fixed region of the plane or of 3D space
+// into equal quadrants or octants.
+package quadtree
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// Entry is an item stored in a tree: a region with its payload.
+type Entry[V any, B Region[B]] struct {
+	Region B
+	Value  V
+}
+
+// Tree is a region quadtree or octree holding items with payloads of type V,
+// located by regions of type B: [Rect] for a quadtree over the plane and
+// [Box] for an octree over 3D space. Items may be points or regions.
+//
+// Every node of the tree covers a region; a leaf holds up to a bucket size
+// of items, and when it overflows it's split into children covering its
+// quadrants (or octants), to which the items are moved. Items straddling
+// several children stay in the inner node. Nodes are split at most to a
+// maximal depth, below which leaves hold any number of items; deleting items
+// merges children back when they hold few enough items.
+//
+// Unlike a k-d tree or an R-tree, the partitioning of space doesn't depend
+// on the items, so it's simple and updates are cheap, but the tree is only
+// balanced for evenly distributed items. Items outside the tree's region
+// are held in the root node, where every query examines them. Create trees
+// with [New].
+type Tree[V any, B Region[B]] struct {
+	root       *node[V, B]
+	bucketSize int
+	maxDepth   int
+}
+
+type node[V any, B Region[B]] struct {
+	region  B
+	entries []Entry[V, B]
+
+	// children is nil for leaves.
+	children []*node[V, B]
+
+	// count is the number of items in the subtree of the node.
+	count int
+}
+
+// New creates a new, empty tree over region, whose leaves hold up to
+// bucketSize items unless they are at maxDepth, where the root is at depth
+// 0. Pass a [Rect] region for a quadtree or a [Box] region for an octree:
+//
+//	qt := quadtree.New[string](quadtree.Rect{Max: [2]float64{100, 100}}, 8, 16)
+//
+// It panics if bucketSize < 1 or maxDepth < 0.
+func New[V any, B Region[B]](region B, bucketSize, maxDepth int) *Tree[V, B] {
+	if bucketSize < 1 {
+		panic(fmt.Sprintf("quadtree: invalid bucket size %d", bucketSize))
+	}
+	if maxDepth < 0 {
+		panic(fmt.Sprintf("quadtree: invalid max depth %d", maxDepth))
+	}
+	return &Tree[V, B]{
+		root:       &node[V, B]{region: region},
+		bucketSize: bucketSize,
+		maxDepth:   maxDepth,
+	}
+}
+
+// Len returns the number of items in the tree.
+func (t *Tree[V, B]) Len() int {
+	return t.root.count
+}
+
+// Region returns the region covered by the tree.
+func (t *Tree[V, B]) Region() B {
+	return t.root.region
+}
+
+// Insert adds an item located by region r with payload v to the tree. The
+// same item may be inserted multiple times.
+func (t *Tree[V, B]) Insert(r B, v V) {
+	t.insert(t.root, Entry[V, B]{r, v}, 0)
+}
+
+func (t *Tree[V, B]) insert(n *node[V, B], e Entry[V, B], depth int) {
+	for {
+		n.count++
+		if n.children == nil {
+			break
+		}
+		i := t.childIndex(n, e.Region)
+		if i < 0 {
+			n.entries = append(n.entries, e)
+			return
+		}
+		n = n.children[i]
+		depth++
+	}
+
+	n.entries = append(n.entries, e)
+	if len(n.entries) <= t.bucketSize || depth >= t.maxDepth {
+		return
+	}
+
+	// Split the leaf, moving the items that fit in a child down.
+	n.children = make([]*node[V, B], regionArity(n.region))
+	for i := range n.children {
+		n.children[i] = &node[V, B]{region: regionChild(n.region, i)}
+	}
+	entries := n.entries
+	n.entries = nil
+	for _, e := range entries {
+		if i := t.childIndex(n, e.Region); i >= 0 {
+			t.insert(n.children[i], e, depth+1)
+		} else {
+			n.entries = append(n.entries, e)
+		}
+	}
+}
+
+// childIndex returns the index of the child of n that should hold an item
+// at r, or -1 if it should be held in n.
+func (t *Tree[V, B]) childIndex(n *node[V, B], r B) int {
+	if !n.region.Contains(r) {
+		return -1
+	}
+	return regionChildIndex(n.region, r)
+}
+
+// Delete removes an item located by region r whose payload satisfies match
+// from the tree. It returns true if an item was removed; if several items
+// match, only one of them is removed.
+func (t *Tree[V, B]) Delete(r B, match func(V) bool) bool {
+	return t.delete(t.root, r, match)
+}
+
+func (t *Tree[V, B]) delete(n *node[V, B], r B, match func(V) bool) bool {
+	if n.children != nil {
+		if i := t.childIndex(n, r); i >= 0 {
+			if !t.delete(n.children[i], r, match) {
+				return false
+			}
+			n.count--
+			t.merge(n)
+			return true
+		}
+	}
+	for i, e := range n.entries {
+		if e.Region == r && match(e.Value) {
+			n.entries = slices.Delete(n.entries, i, i+1)
+			n.count--
+			t.merge(n)
+			return true
+		}
+	}
+	return false
+}
+
+// merge turns n back into a leaf if its subtree holds few enough items.
+func (t *Tree[V, B]) merge(n *node[V, B]) {
+	if n.children == nil || n.count > t.bucketSize {
+		return
+	}
+	for _, c := range n.children {
+		t.collect(c, &n.entries)
+	}
+	n.children = nil
+}
+
+// collect appends all the items in the subtree of n to entries.
+func (t *Tree[V, B]) collect(n *node[V, B], entries *[]Entry[V, B]) {
+	*entries = append(*entries, n.entries...)
+	for _, c := range n.children {
+		t.collect(c, entries)
+	}
+}
+
+// SearchIntersect returns an iterator over the items in the tree whose
+// regions intersect r, in unspecified order.
+func (t *Tree[V, B]) SearchIntersect(r B) iter.Seq[Entry[V, B]] {
+	return func(yield func(Entry[V, B]) bool) {
+		t.search(t.root, r, yield)
+	}
+}
+
+func (t *Tree[V, B]) search(n *node[V, B], r B, yield func(Entry[V, B]) bool) bool {
+	for _, e := range n.entries {
+		if e.Region.Intersects(r) && !yield(e) {
+			return false
+		}
+	}
+	for _, c := range n.children {
+		if c.count > 0 && c.region.Intersects(r) && !t.search(c, r, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// All returns an iterator over all the items in the tree, in unspecified
+// order.
+func (t *Tree[V, B]) All() iter.Seq[Entry[V, B]] {
+	return func(yield func(Entry[V, B]) bool) {
+		t.walk(t.root, yield)
+	}
+}
+
+func (t *Tree[V, B]) walk(n *node[V, B], yield func(Entry[V, B]) bool) bool {
+	for _, e := range n.entries {
+		if !yield(e) {
+			return false
+		}
+	}
+	for _, c := range n.children {
+		if !t.walk(c, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Nearest returns an iterator over the items in the tree by increasing
+// distance of their regions from the point p (the minimal corner of the
+// region p, usually created with [Point] or [Point3]), with these distances;
+// regions containing the point are at distance 0. Items at equal distances
+// are yielded in unspecified order. Stopping the iteration early (e.g. after
+// the k nearest items) saves work.
+func (t *Tree[V, B]) Nearest(p B) iter.Seq2[Entry[V, B], float64] {
+	return func(yield func(Entry[V, B], float64) bool) {
+		// Best-first search: the queue holds both nodes, labeled with the
+		// distance to their regions, and items, which are yielded when no
+		// node in the queue can hold closer ones.
+		type item struct {
+			dist float64
+			n    *node[V, B]
+			e    Entry[V, B]
+		}
+		q := heap.NewDAry(4, func(a, b item) int {
+			switch {
+			case a.dist < b.dist:
+				return -1
+			case a.dist > b.dist:
+				return 1
+			}
+			return 0
+		})
+		q.Push(item{n: t.root})
+		for q.Len() > 0 {
+			it := q.Pop()
+			if it.n == nil {
+				if !yield(it.e, it.dist) {
+					return
+				}
+				continue
+			}
+			for _, e := range it.n.entries {
+				q.Push(item{dist: regionDist(e.Region, p), e: e})
+			}
+			for _, c := range it.n.children {
+				if c.count > 0 {
+					q.Push(item{dist: regionDist(c.region, p), n: c})
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package quadtree
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/eliben/gogl/rtree"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// randomRect returns a random point or small rectangle, mostly within
+// [0, 1000) along both axes.
+func randomRect(rnd *rand.Rand) Rect {
+	x, y := rnd.Float64()*1100-50, rnd.Float64()*1100-50
+	if rnd.IntN(2) == 0 {
+		return Point(x, y)
+	}
+	return Rect{Min: [2]float64{x, y}, Max: [2]float64{x + rnd.Float64()*30, y + rnd.Float64()*30}}
+}
+
+func randomBox(rnd *rand.Rand) Box {
+	x, y, z := rnd.Float64()*1100-50, rnd.Float64()*1100-50, rnd.Float64()*1100-50
+	if rnd.IntN(2) == 0 {
+		return Point3(x, y, z)
+	}
+	d := rnd.Float64() * 30
+	return Box{Min: [3]float64{x, y, z}, Max: [3]float64{x + d, y + d, z + d}}
+}
+
+// checkVerify checks the structural invariants of the tree: counts are
+// consistent, items are held in the deepest node whose region contains them,
+// and leaves above the maximal depth don't overflow.
+func checkVerify[V any, B Region[B]](t *testing.T, tr *Tree[V, B]) {
+	t.Helper()
+	var verify func(n *node[V, B], depth int) int
+	verify = func(n *node[V, B], depth int) int {
+		count := len(n.entries)
+		for _, e := range n.entries {
+			if n != tr.root && !n.region.Contains(e.Region) {
+				t.Fatalf("item %v outside node region %v", e.Region, n.region)
+			}
+			if n.children != nil && tr.childIndex(n, e.Region) >= 0 {
+				t.Fatalf("item %v should be in a child of %v", e.Region, n.region)
+			}
+		}
+		if n.children == nil {
+			if len(n.entries) > tr.bucketSize && depth < tr.maxDepth {
+				t.Fatalf("leaf at depth %d holds %d items", depth, len(n.entries))
+			}
+		} else {
+			for _, c := range n.children {
+				count += verify(c, depth+1)
+			}
+			if count <= tr.bucketSize {
+				t.Fatalf("inner node holds only %d items", count)
+			}
+		}
+		if count != n.count {
+			t.Fatalf("node holds %d items, count=%d", count, n.count)
+		}
+		return count
+	}
+	verify(tr.root, 0)
+}
+
+func entryLess[B Region[B]](a, b Entry[int, B]) int {
+	return cmp.Compare(a.Value, b.Value)
+}
+
+// testTree runs a randomized test of a tree against a brute-force search
+// over a slice of items.
+func testTree[B Region[B]](t *testing.T, rnd *rand.Rand, tr *Tree[int, B], random func(*rand.Rand) B) {
+	var entries []Entry[int, B]
+	for i := range 3000 {
+		r := random(rnd)
+		tr.Insert(r, i)
+		entries = append(entries, Entry[int, B]{r, i})
+		if i%2 == 0 {
+			// Duplicates, with different payloads.
+			tr.Insert(r, -i)
+			entries = append(entries, Entry[int, B]{r, -i})
+		}
+	}
+	checkVerify(t, tr)
+	if tr.Len() != len(entries) {
+		t.Fatalf("Len=%d, want %d", tr.Len(), len(entries))
+	}
+
+	check := func() {
+		t.Helper()
+		want := slices.SortedFunc(slices.Values(entries), entryLess)
+		if got := slices.SortedFunc(tr.All(), entryLess); !slices.Equal(got, want) {
+			t.Fatalf("All mismatch")
+		}
+		for range 50 {
+			q := random(rnd)
+			var want []Entry[int, B]
+			for _, e := range entries {
+				if e.Region.Intersects(q) {
+					want = append(want, e)
+				}
+			}
+			slices.SortFunc(want, entryLess)
+			if got := slices.SortedFunc(tr.SearchIntersect(q), entryLess); !slices.Equal(got, want) {
+				t.Fatalf("SearchIntersect(%v) = %v, want %v", q, got, want)
+			}
+
+			dists := make([]float64, len(entries))
+			for i, e := range entries {
+				dists[i] = regionDist(e.Region, q)
+			}
+			slices.Sort(dists)
+			i := 0
+			for e, d := range tr.Nearest(q) {
+				if d != regionDist(e.Region, q) || d != dists[i] {
+					t.Fatalf("Nearest(%v)[%d] = %v at %v, want distance %v", q, i, e, d, dists[i])
+				}
+				i++
+				if i == min(10, len(dists)) {
+					break
+				}
+			}
+			if i != min(10, len(dists)) {
+				t.Fatalf("Nearest yielded %d items", i)
+			}
+		}
+	}
+	check()
+
+	rnd.Shuffle(len(entries), func(i, j int) {
+		entries[i], entries[j] = entries[j], entries[i]
+	})
+	for len(entries) > 0 {
+		e := entries[len(entries)-1]
+		entries = entries[:len(entries)-1]
+		if tr.Delete(e.Region, func(v int) bool { return v == e.Value+1e9 }) {
+			t.Fatalf("deleted with non-matching payload")
+		}
+		if !tr.Delete(e.Region, func(v int) bool { return v == e.Value }) {
+			t.Fatalf("Delete(%v, %d) failed", e.Region, e.Value)
+		}
+		if tr.Len() != len(entries) {
+			t.Fatalf("Len=%d, want %d", tr.Len(), len(entries))
+		}
+		if len(entries)%500 == 0 {
+			checkVerify(t, tr)
+			check()
+		}
+	}
+	if tr.root.children != nil || len(tr.root.entries) != 0 {
+		t.Errorf("empty tree has nodes")
+	}
+}
+
+func TestQuadtree(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	region := Rect{Max: [2]float64{1000, 1000}}
+	for _, limits := range [][2]int{{1, 20}, {4, 3}, {8, 16}, {100, 16}} {
+		tr := New[int](region, limits[0], limits[1])
+		if tr.Region() != region {
+			t.Fatalf("Region = %v", tr.Region())
+		}
+		testTree(t, rnd, tr, randomRect)
+	}
+}
+
+func TestOctree(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	region := Box{Max: [3]float64{1000, 1000, 1000}}
+	for _, limits := range [][2]int{{1, 20}, {8, 16}} {
+		testTree(t, rnd, New[int](region, limits[0], limits[1]), randomBox)
+	}
+}
+
+func TestRegions(t *testing.T) {
+	r := Rect{Max: [2]float64{4, 2}}
+	for i, want := range []Rect{
+		{Min: [2]float64{0, 0}, Max: [2]float64{2, 1}},
+		{Min: [2]float64{2, 0}, Max: [2]float64{4, 1}},
+		{Min: [2]float64{0, 1}, Max: [2]float64{2, 2}},
+		{Min: [2]float64{2, 1}, Max: [2]float64{4, 2}},
+	} {
+		if got := regionChild(r, i); got != want {
+			t.Errorf("child(%d) = %v, want %v", i, got, want)
+		}
+		if ci := regionChildIndex(r, Point(want.Min[0]+0.5, want.Min[1]+0.5)); ci != i {
+			t.Errorf("childIndex in child %d = %d", i, ci)
+		}
+	}
+	if ci := regionChildIndex(r, Rect{Min: [2]float64{1, 0}, Max: [2]float64{3, 0.5}}); ci != -1 {
+		t.Errorf("childIndex of straddling rect = %d", ci)
+	}
+	if d := regionDist(r, Point(7, 6)); d != 5 {
+		t.Errorf("dist = %v", d)
+	}
+
+	if regionArity(r) != 4 || regionArity(Box{}) != 8 {
+		t.Errorf("bad arity")
+	}
+
+	// Rectangles are shared with R-trees, without conversions.
+	var rr rtree.Rect = r
+	if !rr.Contains(Point(1, 1)) {
+		t.Errorf("bad Contains for an rtree.Rect")
+	}
+
+	b := Box{Max: [3]float64{2, 2, 2}}
+	if got := regionChild(b, 5); got != (Box{Min: [3]float64{1, 0, 1}, Max: [3]float64{2, 1, 2}}) {
+		t.Errorf("child(5) = %v", got)
+	}
+	if !b.Contains(Point3(1, 1, 1)) || b.Contains(Point3(1, 1, 3)) || !b.Intersects(Box{Min: [3]float64{2, 2, 2}, Max: [3]float64{3, 3, 3}}) {
+		t.Errorf("bad Contains/Intersects")
+	}
+}
+
+func TestInvalidLimits(t *testing.T) {
+	for _, limits := range [][2]int{{0, 4}, {4, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New with limits %v didn't panic", limits)
+				}
+			}()
+			New[int](Rect{}, limits[0], limits[1])
+		}()
+	}
+}
+
+func BenchmarkSearchIntersect(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	tr := New[int](Rect{Max: [2]float64{1000, 1000}}, 8, 16)
+	for i := range 100000 {
+		tr.Insert(randomRect(rnd), i)
+	}
+	b.ResetTimer()
+	for range b.N {
+		for range tr.SearchIntersect(randomRect(rnd)) {
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package quadtree
+
+import (
+	"math"
+
+	"github.com/eliben/gogl/internal/geom"
+)
+
+// Region is the constraint for the regions indexed by trees: [Rect] for
+// quadtrees over the plane and [Box] for octrees over 3D space.
+type Region[B any] interface {
+	Rect | Box
+
+	// Intersects reports whether the region has at least one point in
+	// common with other.
+	Intersects(other B) bool
+
+	// Contains reports whether other lies entirely within the region.
+	Contains(other B) bool
+}
+
+// Rect is a closed axis-aligned rectangle in the plane, holding the points
+// (x, y) with Min[0] <= x <= Max[0] and Min[1] <= y <= Max[1]. A point is a
+// rectangle with Min == Max. It's the same type as rtree.Rect.
+type Rect = geom.Rect
+
+// Point returns the rectangle holding only the point (x, y).
+func Point(x, y float64) Rect {
+	return geom.Point(x, y)
+}
+
+// Box is a closed axis-aligned box in 3D space, holding the points
+// (x, y, z) with Min[a] <= (x, y, z)[a] <= Max[a] along every axis a. A
+// point is a box with Min == Max.
+type Box struct {
+	Min, Max [3]float64
+}
+
+// Point3 returns the box holding only the point (x, y, z).
+func Point3(x, y, z float64) Box {
+	return Box{Min: [3]float64{x, y, z}, Max: [3]float64{x, y, z}}
+}
+
+// Intersects reports whether b and other have at least one point in common;
+// boxes that only touch intersect.
+func (b Box) Intersects(other Box) bool {
+	return intersects(b.Min[:], b.Max[:], other.Min[:], other.Max[:])
+}
+
+// Contains reports whether other lies entirely within b.
+func (b Box) Contains(other Box) bool {
+	return contains(b.Min[:], b.Max[:], other.Min[:], other.Max[:])
+}
+
+// corners returns the minimal and maximal corners of the region r points
+// to, with one coordinate per axis; the slices alias r.
+func corners[B Region[B]](r *B) (lo, hi []float64) {
+	switch r := any(r).(type) {
+	case *Rect:
+		return r.Min[:], r.Max[:]
+	case *Box:
+		return r.Min[:], r.Max[:]
+	}
+	panic("unreachable")
+}
+
+// regionArity returns the number of children of a tree node covering r: 4
+// or 8.
+func regionArity[B Region[B]](r B) int {
+	lo, _ := corners(&r)
+	return 1 << len(lo)
+}
+
+// regionChild returns the i-th of the regions obtained by splitting r in
+// half along every axis; bit a of i is set for the upper half along axis a.
+func regionChild[B Region[B]](r B, i int) B {
+	lo, hi := corners(&r)
+	split(lo, hi, i)
+	return r
+}
+
+// regionChildIndex returns the index of the child region of r containing
+// other, which must be contained in r, or -1 if it straddles several
+// children.
+func regionChildIndex[B Region[B]](r, other B) int {
+	lo, hi := corners(&r)
+	min2, max2 := corners(&other)
+	return childIndex(lo, hi, min2, max2)
+}
+
+// regionDist returns the Euclidean distance from the minimal corner of p to
+// the closest point of r.
+func regionDist[B Region[B]](r, p B) float64 {
+	lo, hi := corners(&r)
+	pmin, _ := corners(&p)
+	return dist(lo, hi, pmin)
+}
+
+// The functions below implement the operations on regions over their
+// corners.
+
+func intersects(min1, max1, min2, max2 []float64) bool {
+	for a := range min1 {
+		if min1[a] > max2[a] || min2[a] > max1[a] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(min1, max1, min2, max2 []float64) bool {
+	for a := range min1 {
+		if min2[a] < min1[a] || max2[a] > max1[a] {
+			return false
+		}
+	}
+	return true
+}
+
+// split shrinks the region with corners lo and hi in place to its i-th
+// child.
+func split(lo, hi []float64, i int) {
+	for a := range lo {
+		mid := lo[a] + (hi[a]-lo[a])/2
+		if i&(1<<a) != 0 {
+			lo[a] = mid
+		} else {
+			hi[a] = mid
+		}
+	}
+}
+
+func childIndex(lo, hi, min2, max2 []float64) int {
+	i := 0
+	for a := range lo {
+		mid := lo[a] + (hi[a]-lo[a])/2
+		switch {
+		case max2[a] <= mid:
+		case min2[a] >= mid:
+			i |= 1 << a
+		default:
+			return -1
+		}
+	}
+	return i
+}
+
+func dist(lo, hi, p []float64) float64 {
+	var d float64
+	for a := range lo {
+		diff := max(lo[a]-p[a], 0, p[a]-hi[a])
+		d += diff * diff
+	}
+	return math.Sqrt(d)
+}
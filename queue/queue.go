@@ -0,0 +1,74 @@
+// Package queue implements a FIFO queue backed by a ring buffer.
+package queue
+
+import (
+	"iter"
+
+	"github.com/eliben/gogl/deque"
+)
+
+// Queue is a generic FIFO queue. Enqueue and Dequeue are O(1) (amortized,
+// for Enqueue); the queue's storage is reused as elements are dequeued, so a
+// long-lived queue doesn't leak memory like a re-sliced slice does.
+type Queue[T any] struct {
+	d *deque.Deque[T]
+}
+
+// New creates a new, empty queue.
+func New[T any]() *Queue[T] {
+	return &Queue[T]{d: deque.New[T]()}
+}
+
+// Len returns the number of elements in the queue.
+func (q *Queue[T]) Len() int {
+	return q.d.Len()
+}
+
+// Enqueue adds a value to the back of the queue.
+func (q *Queue[T]) Enqueue(v T) {
+	q.d.PushBack(v)
+}
+
+// Dequeue removes the value at the front of the queue and returns it. It
+// panics if the queue is empty; make sure to check Len() first.
+func (q *Queue[T]) Dequeue() T {
+	if q.d.Len() == 0 {
+		panic("dequeue from empty queue")
+	}
+	return q.d.PopFront()
+}
+
+// Peek returns the value at the front of the queue without removing it. It
+// panics if the queue is empty.
+func (q *Queue[T]) Peek() T {
+	if q.d.Len() == 0 {
+		panic("peek into empty queue")
+	}
+	return q.d.Front()
+}
+
+// All returns an iterator over the values in the queue, from front to back,
+// without removing them.
+func (q *Queue[T]) All() iter.Seq[T] {
+	return q.d.Values()
+}
+
+// Drain returns an iterator that dequeues values from the queue one by one
+// until it's empty. Values enqueued during iteration are also drained, which
+// makes it convenient for BFS-style loops:
+//
+//	q.Enqueue(start)
+//	for v := range q.Drain() {
+//		// ... q.Enqueue(neighbors of v)
+//	}
+//
+// If the loop is exited early, the remaining values stay in the queue.
+func (q *Queue[T]) Drain() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for q.d.Len() > 0 {
+			if !yield(q.d.PopFront()) {
+				return
+			}
+		}
+	}
+}
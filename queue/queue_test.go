@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"slices"
+	"testing"
+)
+
+func checkQueue(t *testing.T, q *Queue[int], want []int) {
+	t.Helper()
+	if q.Len() != len(want) {
+		t.Errorf("got len=%v, want %v", q.Len(), len(want))
+	}
+	got := slices.Collect(q.All())
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	q := New[int]()
+	checkQueue(t, q, nil)
+
+	q.Enqueue(1)
+	q.Enqueue(2)
+	q.Enqueue(3)
+	checkQueue(t, q, []int{1, 2, 3})
+	if q.Peek() != 1 {
+		t.Errorf("got peek=%v, want 1", q.Peek())
+	}
+
+	if v := q.Dequeue(); v != 1 {
+		t.Errorf("got dequeue=%v, want 1", v)
+	}
+	q.Enqueue(4)
+	checkQueue(t, q, []int{2, 3, 4})
+
+	for i := range 100 {
+		q.Enqueue(10 + i)
+		q.Dequeue()
+	}
+	checkQueue(t, q, []int{107, 108, 109})
+}
+
+func TestDrainBFS(t *testing.T) {
+	// Breadth-first traversal of an implicit binary tree with nodes 1..15,
+	// where node n has children 2n and 2n+1.
+	q := New[int]()
+	q.Enqueue(1)
+	var order []int
+	for n := range q.Drain() {
+		order = append(order, n)
+		if 2*n <= 15 {
+			q.Enqueue(2 * n)
+			q.Enqueue(2*n + 1)
+		}
+	}
+
+	var want []int
+	for i := 1; i <= 15; i++ {
+		want = append(want, i)
+	}
+	if !slices.Equal(order, want) {
+		t.Errorf("got %v, want %v", order, want)
+	}
+	checkQueue(t, q, nil)
+}
+
+func TestDrainEarlyExit(t *testing.T) {
+	q := New[int]()
+	for i := range 5 {
+		q.Enqueue(i)
+	}
+	for v := range q.Drain() {
+		if v == 1 {
+			break
+		}
+	}
+	checkQueue(t, q, []int{2, 3, 4})
+}
+
+func TestEmptyPanics(t *testing.T) {
+	q := New[int]()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	q.Dequeue()
+}
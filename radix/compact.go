@@ -0,0 +1,359 @@
+package radix
+
+import (
+	"encoding/binary"
+	"errors"
+	"iter"
+	"math"
+	"sort"
+)
+
+// This file implements a compact, read-only serialized form of radix trees.
+//
+// The encoding is a flat byte slice: a header followed by the nodes, each
+// written after all its descendants, so that child references always point
+// backwards. Integers are little-endian.
+//
+//	header:  "GRDX" | version u32 | number of keys u32 | root offset u32
+//	node:    flags u8 | prefix length uvarint | prefix bytes |
+//	         [value length uvarint | value bytes]  (if flags&1)
+//	         child count uvarint | child count * (first byte u8 | offset u32)
+//
+// Children are sorted by the first byte of their prefix, so a child can be
+// found by binary search directly in the encoded data.
+
+const (
+	compactMagic      = "GRDX"
+	compactVersion    = 1
+	compactHeaderSize = 16
+	childEntrySize    = 5
+	flagHasValue      = 1
+
+	// minValueNodeSize is the size of the smallest node holding a value:
+	// flags, and one byte each for the prefix length, value length and
+	// child count.
+	minValueNodeSize = 4
+)
+
+// ErrInvalidCompact is returned when opening data that isn't a valid
+// encoding of a radix tree.
+var ErrInvalidCompact = errors.New("radix: invalid compact tree data")
+
+// Encode encodes the tree into a compact flat format that can be queried
+// directly with [OpenCompact], e.g. after memory-mapping it from a file.
+// encodeValue encodes each value into bytes. It returns an error if the
+// encoding exceeds 4 GiB.
+func (t *Tree[V]) Encode(encodeValue func(V) []byte) ([]byte, error) {
+	buf := make([]byte, compactHeaderSize)
+	buf, root := encodeNode(buf, t.root, encodeValue)
+	if len(buf) > math.MaxUint32 {
+		return nil, errors.New("radix: encoded tree too large")
+	}
+	copy(buf, compactMagic)
+	binary.LittleEndian.PutUint32(buf[4:], compactVersion)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(t.length))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(root))
+	return buf, nil
+}
+
+// encodeNode appends the encoding of the subtree of n to buf, and returns
+// the updated buf with the offset of n's encoding.
+func encodeNode[V any](buf []byte, n *node[V], encodeValue func(V) []byte) ([]byte, int) {
+	offsets := make([]int, len(n.children))
+	for i, c := range n.children {
+		buf, offsets[i] = encodeNode(buf, c, encodeValue)
+	}
+
+	off := len(buf)
+	var flags byte
+	if n.hasValue {
+		flags |= flagHasValue
+	}
+	buf = append(buf, flags)
+	buf = binary.AppendUvarint(buf, uint64(len(n.prefix)))
+	buf = append(buf, n.prefix...)
+	if n.hasValue {
+		v := encodeValue(n.value)
+		buf = binary.AppendUvarint(buf, uint64(len(v)))
+		buf = append(buf, v...)
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(n.children)))
+	for i, c := range n.children {
+		buf = append(buf, c.prefix[0])
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(offsets[i]))
+	}
+	return buf, off
+}
+
+// Decode decodes a tree encoded with [Tree.Encode], using decodeValue to
+// decode each value.
+func Decode[V any](data []byte, decodeValue func([]byte) (V, error)) (*Tree[V], error) {
+	c, err := OpenCompact(data)
+	if err != nil {
+		return nil, err
+	}
+	t := New[V]()
+	for k, vb := range c.All() {
+		v, err := decodeValue(vb)
+		if err != nil {
+			return nil, err
+		}
+		t.Insert(k, v)
+	}
+	return t, nil
+}
+
+// Compact is a read-only radix tree backed directly by data produced by
+// [Tree.Encode], without rebuilding the tree in memory. Values are returned
+// as byte slices pointing into the data.
+type Compact struct {
+	data   []byte
+	root   int
+	length int
+}
+
+// compactNode is a decoded view of a node in a Compact's data.
+type compactNode struct {
+	prefix   []byte
+	value    []byte
+	hasValue bool
+
+	// children holds the encoded child entries.
+	children []byte
+}
+
+// OpenCompact opens data produced by [Tree.Encode] for querying. The data
+// is validated in a single pass without allocating, so that queries can
+// trust it; it must not be modified while the Compact is in use.
+func OpenCompact(data []byte) (*Compact, error) {
+	if len(data) < compactHeaderSize || string(data[:4]) != compactMagic ||
+		binary.LittleEndian.Uint32(data[4:]) != compactVersion {
+		return nil, ErrInvalidCompact
+	}
+	c := &Compact{
+		data:   data,
+		root:   int(binary.LittleEndian.Uint32(data[12:])),
+		length: int(binary.LittleEndian.Uint32(data[8:])),
+	}
+	// Every key takes up a node of its own, so the number of keys in the
+	// header, which bounds the work of validate, must fit in the data.
+	if c.length > (len(data)-compactHeaderSize)/minValueNodeSize {
+		return nil, ErrInvalidCompact
+	}
+	count, err := c.validate(c.root, true)
+	if err != nil || count != c.length {
+		return nil, ErrInvalidCompact
+	}
+	return c, nil
+}
+
+// Len returns the number of keys in the tree.
+func (c *Compact) Len() int {
+	return c.length
+}
+
+// Get looks for key in the tree. It returns the associated value and ok=true;
+// otherwise, it returns ok=false.
+func (c *Compact) Get(key string) (v []byte, ok bool) {
+	n := c.node(c.root)
+	for key != "" {
+		cn, found := c.findChild(n, key[0])
+		if !found || !hasPrefix(key, cn.prefix) {
+			return nil, false
+		}
+		n = cn
+		key = key[len(n.prefix):]
+	}
+	return n.value, n.hasValue
+}
+
+// LongestPrefixMatch finds the longest key in the tree that is a prefix of s.
+// It returns this key with its value and ok=true; if no key in the tree is a
+// prefix of s, it returns ok=false.
+func (c *Compact) LongestPrefixMatch(s string) (key string, v []byte, ok bool) {
+	n := c.node(c.root)
+	consumed := 0
+	for {
+		if n.hasValue {
+			key, v, ok = s[:consumed], n.value, true
+		}
+		rest := s[consumed:]
+		if rest == "" {
+			break
+		}
+		cn, found := c.findChild(n, rest[0])
+		if !found || !hasPrefix(rest, cn.prefix) {
+			break
+		}
+		n = cn
+		consumed += len(n.prefix)
+	}
+	return key, v, ok
+}
+
+// HasPrefix reports whether any key in the tree starts with prefix.
+func (c *Compact) HasPrefix(prefix string) bool {
+	n, _, ok := c.findPrefix(prefix)
+	return ok && (n.hasValue || len(n.children) > 0)
+}
+
+// All returns an iterator over all key, value pairs in the tree, in
+// lexicographic order of keys.
+func (c *Compact) All() iter.Seq2[string, []byte] {
+	return c.WithPrefix("")
+}
+
+// WithPrefix returns an iterator over all key, value pairs in the tree whose
+// keys start with prefix, in lexicographic order of keys.
+func (c *Compact) WithPrefix(prefix string) iter.Seq2[string, []byte] {
+	return func(yield func(string, []byte) bool) {
+		n, key, ok := c.findPrefix(prefix)
+		if !ok {
+			return
+		}
+		buf := []byte(key)
+		c.walk(n, &buf, yield)
+	}
+}
+
+// findPrefix is the Compact counterpart of Tree.findPrefix.
+func (c *Compact) findPrefix(prefix string) (compactNode, string, bool) {
+	n := c.node(c.root)
+	consumed := 0
+	for consumed < len(prefix) {
+		rest := prefix[consumed:]
+		cn, found := c.findChild(n, rest[0])
+		if !found {
+			return compactNode{}, "", false
+		}
+		if len(cn.prefix) >= len(rest) && string(cn.prefix[:len(rest)]) == rest {
+			// The prefix ends within cn's edge; all keys under cn match.
+			return cn, prefix[:consumed] + string(cn.prefix), true
+		}
+		if !hasPrefix(rest, cn.prefix) {
+			return compactNode{}, "", false
+		}
+		n = cn
+		consumed += len(cn.prefix)
+	}
+	return n, prefix, true
+}
+
+func (c *Compact) walk(n compactNode, buf *[]byte, yield func(string, []byte) bool) bool {
+	if n.hasValue && !yield(string(*buf), n.value) {
+		return false
+	}
+	for i := 0; i < len(n.children); i += childEntrySize {
+		cn := c.node(int(binary.LittleEndian.Uint32(n.children[i+1:])))
+		*buf = append(*buf, cn.prefix...)
+		if !c.walk(cn, buf, yield) {
+			return false
+		}
+		*buf = (*buf)[:len(*buf)-len(cn.prefix)]
+	}
+	return true
+}
+
+// findChild finds the child of n whose prefix starts with b.
+func (c *Compact) findChild(n compactNode, b byte) (compactNode, bool) {
+	count := len(n.children) / childEntrySize
+	i := sort.Search(count, func(i int) bool {
+		return n.children[i*childEntrySize] >= b
+	})
+	if i == count || n.children[i*childEntrySize] != b {
+		return compactNode{}, false
+	}
+	return c.node(int(binary.LittleEndian.Uint32(n.children[i*childEntrySize+1:]))), true
+}
+
+// node decodes the node at offset off, which must have been validated.
+func (c *Compact) node(off int) compactNode {
+	n, _ := c.decodeNode(off)
+	return n
+}
+
+// decodeNode decodes the node at offset off, checking that it lies within
+// the data.
+func (c *Compact) decodeNode(off int) (compactNode, error) {
+	if off < compactHeaderSize || off >= len(c.data) {
+		return compactNode{}, ErrInvalidCompact
+	}
+	var n compactNode
+	d := c.data[off:]
+	n.hasValue = d[0]&flagHasValue != 0
+	d = d[1:]
+
+	// readBytes reads a length-prefixed byte sequence from d.
+	readBytes := func() ([]byte, bool) {
+		l, k := binary.Uvarint(d)
+		if k <= 0 || l > uint64(len(d)-k) {
+			return nil, false
+		}
+		b := d[k : k+int(l)]
+		d = d[k+int(l):]
+		return b, true
+	}
+	var ok bool
+	if n.prefix, ok = readBytes(); !ok {
+		return compactNode{}, ErrInvalidCompact
+	}
+	if n.hasValue {
+		if n.value, ok = readBytes(); !ok {
+			return compactNode{}, ErrInvalidCompact
+		}
+	}
+	count, k := binary.Uvarint(d)
+	if k <= 0 || count > uint64(len(d)-k)/childEntrySize {
+		return compactNode{}, ErrInvalidCompact
+	}
+	n.children = d[k : k+int(count)*childEntrySize]
+	return n, nil
+}
+
+// validate checks the structure of the subtree at off, and returns the
+// number of keys in it. Children must precede their parent in the data,
+// which guarantees termination; since every non-root node must hold a value
+// or have at least two children, stopping once the count exceeds the
+// header's number of keys bounds the work even if nodes are shared. The
+// caller checks that this number is bounded by the length of the data.
+func (c *Compact) validate(off int, isRoot bool) (int, error) {
+	n, err := c.decodeNode(off)
+	if err != nil {
+		return 0, err
+	}
+	if isRoot != (len(n.prefix) == 0) || (!isRoot && !n.hasValue && len(n.children) < 2*childEntrySize) {
+		return 0, ErrInvalidCompact
+	}
+	count := 0
+	if n.hasValue {
+		count++
+	}
+	for i := 0; i < len(n.children); i += childEntrySize {
+		label := n.children[i]
+		if i > 0 && n.children[i-childEntrySize] >= label {
+			return 0, ErrInvalidCompact
+		}
+		childOff := int(binary.LittleEndian.Uint32(n.children[i+1:]))
+		if childOff >= off {
+			return 0, ErrInvalidCompact
+		}
+		cn, err := c.decodeNode(childOff)
+		if err != nil || len(cn.prefix) == 0 || cn.prefix[0] != label {
+			return 0, ErrInvalidCompact
+		}
+		childCount, err := c.validate(childOff, false)
+		if err != nil {
+			return 0, err
+		}
+		count += childCount
+		if count > c.length {
+			return 0, ErrInvalidCompact
+		}
+	}
+	return count, nil
+}
+
+// hasPrefix reports whether s starts with prefix.
+func hasPrefix(s string, prefix []byte) bool {
+	return len(s) >= len(prefix) && s[:len(prefix)] == string(prefix)
+}
@@ -0,0 +1,158 @@
+package radix
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func encodeInt(v int) []byte {
+	return strconv.AppendInt(nil, int64(v), 10)
+}
+
+func decodeInt(b []byte) (int, error) {
+	return strconv.Atoi(string(b))
+}
+
+func TestCompactRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	randKey := func() string {
+		b := make([]byte, rnd.IntN(8))
+		for i := range b {
+			b[i] = "abc/"[rnd.IntN(4)]
+		}
+		return string(b)
+	}
+
+	tr := New[int]()
+	want := make(map[string]int)
+	for i := range 500 {
+		k := randKey()
+		tr.Insert(k, i)
+		want[k] = i
+	}
+
+	data, err := tr.Encode(encodeInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenCompact(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != tr.Len() {
+		t.Errorf("got Len=%d, want %d", c.Len(), tr.Len())
+	}
+
+	for range 500 {
+		q := randKey()
+		v, ok := c.Get(q)
+		tv, tok := tr.Get(q)
+		if ok != tok || (ok && string(v) != strconv.Itoa(tv)) {
+			t.Fatalf("Get(%q)=%q,%v, want %d,%v", q, v, ok, tv, tok)
+		}
+
+		k, v, ok := c.LongestPrefixMatch(q)
+		tk, tv, tok := tr.LongestPrefixMatch(q)
+		if k != tk || ok != tok || (ok && string(v) != strconv.Itoa(tv)) {
+			t.Fatalf("LongestPrefixMatch(%q)=%q,%q,%v, want %q,%d,%v", q, k, v, ok, tk, tv, tok)
+		}
+
+		if c.HasPrefix(q) != tr.HasPrefix(q) {
+			t.Fatalf("HasPrefix(%q) mismatch", q)
+		}
+		var got, wantKeys []string
+		for k, v := range c.WithPrefix(q) {
+			if string(v) != strconv.Itoa(want[k]) {
+				t.Fatalf("WithPrefix(%q) got %q=%q", q, k, v)
+			}
+			got = append(got, k)
+		}
+		for k := range tr.WithPrefix(q) {
+			wantKeys = append(wantKeys, k)
+		}
+		if !slices.Equal(got, wantKeys) {
+			t.Fatalf("WithPrefix(%q)=%q, want %q", q, got, wantKeys)
+		}
+	}
+
+	decoded, err := Decode(data, decodeInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, decoded, want)
+}
+
+func TestCompactEmpty(t *testing.T) {
+	data, err := New[int]().Encode(encodeInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenCompact(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get(""); ok || c.Len() != 0 || c.HasPrefix("") {
+		t.Errorf("bad empty tree")
+	}
+	for k := range c.All() {
+		t.Errorf("got key %q", k)
+	}
+}
+
+func TestCompactInvalid(t *testing.T) {
+	tr := New[int]()
+	for i, k := range []string{"/api", "/api/users", "/api/items", "/static", ""} {
+		tr.Insert(k, i)
+	}
+	data, err := tr.Encode(encodeInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Truncated data is always invalid.
+	for l := range len(data) {
+		if _, err := OpenCompact(data[:l]); err != ErrInvalidCompact {
+			t.Errorf("truncated to %d: got err=%v", l, err)
+		}
+	}
+
+	// A key count that can't fit in the data is rejected up front.
+	bad := slices.Clone(data)
+	binary.LittleEndian.PutUint32(bad[8:], 1<<32-1)
+	if _, err := OpenCompact(bad); err != ErrInvalidCompact {
+		t.Errorf("huge key count: got err=%v", err)
+	}
+
+	// Corrupted data must either be rejected or remain safe to query.
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+	for range 2000 {
+		bad := slices.Clone(data)
+		bad[rnd.IntN(len(bad))] = byte(rnd.Uint32())
+		c, err := OpenCompact(bad)
+		if err != nil {
+			continue
+		}
+		for _, q := range []string{"", "/", "/api/users", "/static/x"} {
+			c.Get(q)
+			c.LongestPrefixMatch(q)
+			for range c.WithPrefix(q) {
+			}
+		}
+	}
+
+	errBad := errors.New("bad value")
+	_, err = Decode(data, func([]byte) (int, error) { return 0, errBad })
+	if err != errBad {
+		t.Errorf("got err=%v", err)
+	}
+}
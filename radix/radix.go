@@ -0,0 +1,255 @@
+// Package radix implements a radix tree (compressed trie, also known as a
+// PATRICIA tree) with string keys.
+package radix
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Tree is a radix tree mapping string keys to values of type V. Unlike a
+// plain trie, chains of nodes with a single child are merged into a single
+// edge labeled with a string, which drastically reduces memory use for keys
+// with long shared or unique segments (like URLs or IP prefixes).
+//
+// Keys are treated as byte sequences; iteration yields keys in lexicographic
+// (byte) order.
+type Tree[V any] struct {
+	root   *node[V]
+	length int
+}
+
+// node is a node in the radix tree. prefix is the label of the edge leading
+// into the node from its parent (empty for the root). children are sorted by
+// the first byte of their prefix, and no two children share a first byte.
+//
+// Every non-root node either holds a value or has at least two children.
+type node[V any] struct {
+	prefix   string
+	children []*node[V]
+	value    V
+	hasValue bool
+}
+
+// New creates a new, empty Tree.
+func New[V any]() *Tree[V] {
+	return &Tree[V]{root: &node[V]{}}
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree[V]) Len() int {
+	return t.length
+}
+
+// Insert inserts key with the given value into the tree. If key already
+// exists, its value is replaced.
+func (t *Tree[V]) Insert(key string, value V) {
+	n := t.root
+	for key != "" {
+		i, found := n.findChild(key[0])
+		if !found {
+			n.children = slices.Insert(n.children, i, &node[V]{prefix: key, value: value, hasValue: true})
+			t.length++
+			return
+		}
+
+		c := n.children[i]
+		common := commonPrefixLen(c.prefix, key)
+		if common < len(c.prefix) {
+			// key diverges from (or ends in) the middle of c's edge; split the
+			// edge by adding an intermediate node.
+			mid := &node[V]{prefix: c.prefix[:common], children: []*node[V]{c}}
+			c.prefix = c.prefix[common:]
+			n.children[i] = mid
+			c = mid
+		}
+		n = c
+		key = key[common:]
+	}
+
+	if !n.hasValue {
+		t.length++
+	}
+	n.value = value
+	n.hasValue = true
+}
+
+// Get looks for key in the tree. It returns the associated value and ok=true;
+// otherwise, it returns ok=false.
+func (t *Tree[V]) Get(key string) (v V, ok bool) {
+	n := t.root
+	for key != "" {
+		i, found := n.findChild(key[0])
+		if !found || !strings.HasPrefix(key, n.children[i].prefix) {
+			return v, false
+		}
+		n = n.children[i]
+		key = key[len(n.prefix):]
+	}
+	return n.value, n.hasValue
+}
+
+// Delete deletes key and its value from the tree. It returns true if the key
+// was found and deleted, false otherwise.
+func (t *Tree[V]) Delete(key string) bool {
+	// Find the key's node, keeping track of its parent and grandparent for
+	// restructuring.
+	var parent, grandparent *node[V]
+	n := t.root
+	for key != "" {
+		i, found := n.findChild(key[0])
+		if !found || !strings.HasPrefix(key, n.children[i].prefix) {
+			return false
+		}
+		grandparent, parent, n = parent, n, n.children[i]
+		key = key[len(n.prefix):]
+	}
+	if !n.hasValue {
+		return false
+	}
+	n.value = *new(V)
+	n.hasValue = false
+	t.length--
+
+	if n == t.root {
+		return true
+	}
+
+	// Restore the invariant that every non-root node holds a value or has at
+	// least two children.
+	switch len(n.children) {
+	case 0:
+		parent.removeChild(n)
+		if parent != t.root && !parent.hasValue && len(parent.children) == 1 {
+			grandparent.mergeWithChild(parent)
+		}
+	case 1:
+		parent.mergeWithChild(n)
+	}
+	return true
+}
+
+// LongestPrefixMatch finds the longest key in the tree that is a prefix of s.
+// It returns this key with its value and ok=true; if no key in the tree is a
+// prefix of s, it returns ok=false.
+func (t *Tree[V]) LongestPrefixMatch(s string) (key string, v V, ok bool) {
+	n := t.root
+	consumed := 0
+	for {
+		if n.hasValue {
+			key, v, ok = s[:consumed], n.value, true
+		}
+		rest := s[consumed:]
+		if rest == "" {
+			break
+		}
+		i, found := n.findChild(rest[0])
+		if !found || !strings.HasPrefix(rest, n.children[i].prefix) {
+			break
+		}
+		n = n.children[i]
+		consumed += len(n.prefix)
+	}
+	return key, v, ok
+}
+
+// HasPrefix reports whether any key in the tree starts with prefix.
+func (t *Tree[V]) HasPrefix(prefix string) bool {
+	n, _ := t.findPrefix(prefix)
+	return n != nil && (n.hasValue || len(n.children) > 0)
+}
+
+// All returns an iterator over all key, value pairs in the tree, in
+// lexicographic order of keys.
+func (t *Tree[V]) All() iter.Seq2[string, V] {
+	return t.WithPrefix("")
+}
+
+// WithPrefix returns an iterator over all key, value pairs in the tree whose
+// keys start with prefix, in lexicographic order of keys.
+func (t *Tree[V]) WithPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		n, key := t.findPrefix(prefix)
+		if n == nil {
+			return
+		}
+		buf := []byte(key)
+		n.walk(&buf, yield)
+	}
+}
+
+// findPrefix finds the highest node whose key starts with prefix, and
+// returns it along with its full key. If there's no such node, it returns
+// nil.
+func (t *Tree[V]) findPrefix(prefix string) (*node[V], string) {
+	n := t.root
+	consumed := 0
+	for consumed < len(prefix) {
+		rest := prefix[consumed:]
+		i, found := n.findChild(rest[0])
+		if !found {
+			return nil, ""
+		}
+		c := n.children[i]
+		if strings.HasPrefix(c.prefix, rest) {
+			// The prefix ends within c's edge; all keys under c match.
+			return c, prefix[:consumed] + c.prefix
+		}
+		if !strings.HasPrefix(rest, c.prefix) {
+			return nil, ""
+		}
+		n = c
+		consumed += len(c.prefix)
+	}
+	return n, prefix
+}
+
+// findChild finds the child whose prefix starts with b. It returns its index
+// and true if found; otherwise, it returns the index where such a child
+// should be inserted, and false.
+func (n *node[V]) findChild(b byte) (int, bool) {
+	return slices.BinarySearchFunc(n.children, b, func(c *node[V], b byte) int {
+		return int(c.prefix[0]) - int(b)
+	})
+}
+
+func (n *node[V]) removeChild(c *node[V]) {
+	i, _ := n.findChild(c.prefix[0])
+	n.children = slices.Delete(n.children, i, i+1)
+}
+
+// mergeWithChild merges c, a child of n that holds no value and has a single
+// child, with that single child.
+func (n *node[V]) mergeWithChild(c *node[V]) {
+	i, _ := n.findChild(c.prefix[0])
+	gc := c.children[0]
+	gc.prefix = c.prefix + gc.prefix
+	n.children[i] = gc
+}
+
+// walk yields all the key, value pairs in the subtree of n in lexicographic
+// order. buf holds the key of n. It returns false if iteration was stopped.
+func (n *node[V]) walk(buf *[]byte, yield func(string, V) bool) bool {
+	if n.hasValue && !yield(string(*buf), n.value) {
+		return false
+	}
+	for _, c := range n.children {
+		*buf = append(*buf, c.prefix...)
+		if !c.walk(buf, yield) {
+			return false
+		}
+		*buf = (*buf)[:len(*buf)-len(c.prefix)]
+	}
+	return true
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
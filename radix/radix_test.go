@@ -0,0 +1,199 @@
+package radix
+
+import (
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkTree verifies the tree's structural invariants and that its contents
+// match want.
+func checkTree[V comparable](t *testing.T, tr *Tree[V], want map[string]V) {
+	t.Helper()
+	if tr.Len() != len(want) {
+		t.Errorf("got len=%d, want %d", tr.Len(), len(want))
+	}
+	var keys []string
+	for k, v := range tr.All() {
+		if want[k] != v {
+			t.Errorf("got %q=%v, want %v", k, v, want[k])
+		}
+		keys = append(keys, k)
+	}
+	if wantKeys := slices.Sorted(maps.Keys(want)); !slices.Equal(keys, wantKeys) {
+		t.Errorf("got keys %q, want %q", keys, wantKeys)
+	}
+
+	var visit func(n *node[V])
+	visit = func(n *node[V]) {
+		if n != tr.root {
+			if n.prefix == "" {
+				t.Errorf("non-root node with empty prefix")
+			}
+			if !n.hasValue && len(n.children) < 2 {
+				t.Errorf("node %q has no value and %d children", n.prefix, len(n.children))
+			}
+		}
+		for i, c := range n.children {
+			if i > 0 && n.children[i-1].prefix[0] >= c.prefix[0] {
+				t.Errorf("children of %q not sorted", n.prefix)
+			}
+			visit(c)
+		}
+	}
+	visit(tr.root)
+}
+
+func TestInsertGet(t *testing.T) {
+	tr := New[int]()
+	want := map[string]int{}
+	for i, k := range []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "rom", ""} {
+		tr.Insert(k, i)
+		want[k] = i
+		checkTree(t, tr, want)
+	}
+
+	tr.Insert("rubens", 100)
+	want["rubens"] = 100
+	checkTree(t, tr, want)
+
+	for k, w := range want {
+		if v, ok := tr.Get(k); !ok || v != w {
+			t.Errorf("got Get(%q)=%v,%v, want %v", k, v, ok, w)
+		}
+	}
+	for _, k := range []string{"r", "ro", "roma", "romanes", "rubi", "x"} {
+		if _, ok := tr.Get(k); ok {
+			t.Errorf("found non-key %q", k)
+		}
+	}
+}
+
+func TestLongestPrefixMatch(t *testing.T) {
+	tr := New[string]()
+	tr.Insert("/", "root")
+	tr.Insert("/api/", "api")
+	tr.Insert("/api/v1/", "v1")
+	tr.Insert("/api/v1/users", "users")
+	tr.Insert("/static/", "static")
+
+	tests := []struct {
+		s       string
+		wantKey string
+		wantOk  bool
+	}{
+		{"/api/v1/users/42", "/api/v1/users", true},
+		{"/api/v1/items", "/api/v1/", true},
+		{"/api/v2/", "/api/", true},
+		{"/index.html", "/", true},
+		{"/static/", "/static/", true},
+		{"", "", false},
+		{"api", "", false},
+	}
+	for _, tt := range tests {
+		k, _, ok := tr.LongestPrefixMatch(tt.s)
+		if k != tt.wantKey || ok != tt.wantOk {
+			t.Errorf("LongestPrefixMatch(%q)=%q,%v, want %q,%v", tt.s, k, ok, tt.wantKey, tt.wantOk)
+		}
+	}
+
+	tr.Insert("", "empty")
+	if k, v, ok := tr.LongestPrefixMatch("xyz"); k != "" || v != "empty" || !ok {
+		t.Errorf("got %q,%q,%v", k, v, ok)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	tr := New[int]()
+	for i, k := range []string{"test", "team", "tester", "toast", "testing"} {
+		tr.Insert(k, i)
+	}
+	check := func(prefix string, want ...string) {
+		t.Helper()
+		got := slices.Collect(maps.Keys(maps.Collect(tr.WithPrefix(prefix))))
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("WithPrefix(%q)=%q, want %q", prefix, got, want)
+		}
+		if tr.HasPrefix(prefix) != (len(want) > 0) {
+			t.Errorf("HasPrefix(%q)=%v", prefix, tr.HasPrefix(prefix))
+		}
+	}
+	check("te", "team", "test", "tester", "testing")
+	check("tes", "test", "tester", "testing")
+	check("teste", "tester")
+	check("t", "team", "test", "tester", "testing", "toast")
+	check("x")
+	check("testers")
+	check("tea", "team")
+
+	var keys []string
+	for k := range tr.WithPrefix("test") {
+		keys = append(keys, k)
+	}
+	if want := []string{"test", "tester", "testing"}; !slices.Equal(keys, want) {
+		t.Errorf("got %q in order, want %q", keys, want)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tr := New[int]()
+	want := map[string]int{}
+	for i, k := range []string{"a", "ab", "abc", "abd", "b"} {
+		tr.Insert(k, i)
+		want[k] = i
+	}
+
+	for _, k := range []string{"abcd", "x", "", "abe"} {
+		if tr.Delete(k) {
+			t.Errorf("deleted non-existent key %q", k)
+		}
+	}
+	for _, k := range []string{"ab", "abc", "a", "abd", "b"} {
+		if !tr.Delete(k) || tr.Delete(k) {
+			t.Errorf("bad Delete(%q) results", k)
+		}
+		delete(want, k)
+		checkTree(t, tr, want)
+	}
+	if len(tr.root.children) != 0 {
+		t.Errorf("root has children after deleting everything")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	randKey := func() string {
+		var sb strings.Builder
+		for range rnd.IntN(8) {
+			sb.WriteByte("abc"[rnd.IntN(3)])
+		}
+		return sb.String()
+	}
+
+	tr := New[int]()
+	m := make(map[string]int)
+	for i := range 5000 {
+		k := randKey()
+		if rnd.IntN(3) == 0 {
+			_, inMap := m[k]
+			if tr.Delete(k) != inMap {
+				t.Fatalf("Delete(%q) mismatch", k)
+			}
+			delete(m, k)
+		} else {
+			tr.Insert(k, i)
+			m[k] = i
+		}
+		if i%500 == 0 {
+			checkTree(t, tr, m)
+		}
+	}
+	checkTree(t, tr, m)
+}
@@ -0,0 +1,144 @@
+// Package rangemap implements a map from half-open key ranges to values.
+package rangemap
+
+import (
+	"iter"
+	"slices"
+	"sort"
+)
+
+// Entry is a mapping of all the keys in the half-open range [Lo, Hi) to
+// Value.
+type Entry[K any, V comparable] struct {
+	Lo, Hi K
+	Value  V
+}
+
+// Map maps ranges of keys of an ordered type K to values. Inserting a range
+// overwrites the parts of existing ranges it overlaps, splitting them if
+// needed; adjacent ranges with equal values are coalesced, so the map always
+// holds the minimal number of entries describing the mapping.
+//
+// Lookups take O(log n) time for n entries; updates take O(n) time in the
+// worst case, since the entries are kept in a slice. Create maps with [New].
+type Map[K any, V comparable] struct {
+	cmp     func(K, K) int
+	entries []Entry[K, V]
+}
+
+// New creates a new, empty map with the given comparison function for keys.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K any, V comparable](cmp func(K, K) int) *Map[K, V] {
+	return &Map[K, V]{cmp: cmp}
+}
+
+// Len returns the number of entries in the map.
+func (m *Map[K, V]) Len() int {
+	return len(m.entries)
+}
+
+// Insert maps all the keys in the range [lo, hi) to v, overwriting any
+// previous mappings for them. An empty range (lo >= hi) inserts nothing.
+func (m *Map[K, V]) Insert(lo, hi K, v V) {
+	if m.cmp(lo, hi) >= 0 {
+		return
+	}
+	i, j := m.overlapping(lo, hi)
+	var replacement []Entry[K, V]
+	if i < j && m.cmp(m.entries[i].Lo, lo) < 0 {
+		replacement = append(replacement, Entry[K, V]{Lo: m.entries[i].Lo, Hi: lo, Value: m.entries[i].Value})
+	}
+	replacement = append(replacement, Entry[K, V]{Lo: lo, Hi: hi, Value: v})
+	if i < j && m.cmp(m.entries[j-1].Hi, hi) > 0 {
+		replacement = append(replacement, Entry[K, V]{Lo: hi, Hi: m.entries[j-1].Hi, Value: m.entries[j-1].Value})
+	}
+	m.entries = slices.Replace(m.entries, i, j, replacement...)
+	m.coalesce(i-1, i+len(replacement))
+}
+
+// Remove removes the mappings of all the keys in the range [lo, hi),
+// splitting existing entries if needed.
+func (m *Map[K, V]) Remove(lo, hi K) {
+	if m.cmp(lo, hi) >= 0 {
+		return
+	}
+	i, j := m.overlapping(lo, hi)
+	if i == j {
+		return
+	}
+	var remaining []Entry[K, V]
+	if first := m.entries[i]; m.cmp(first.Lo, lo) < 0 {
+		remaining = append(remaining, Entry[K, V]{Lo: first.Lo, Hi: lo, Value: first.Value})
+	}
+	if last := m.entries[j-1]; m.cmp(last.Hi, hi) > 0 {
+		remaining = append(remaining, Entry[K, V]{Lo: hi, Hi: last.Hi, Value: last.Value})
+	}
+	m.entries = slices.Replace(m.entries, i, j, remaining...)
+}
+
+// Get returns the value key is mapped to and ok=true, or ok=false if key
+// isn't in any range of the map.
+func (m *Map[K, V]) Get(key K) (v V, ok bool) {
+	i := sort.Search(len(m.entries), func(i int) bool { return m.cmp(m.entries[i].Hi, key) > 0 })
+	if i < len(m.entries) && m.cmp(m.entries[i].Lo, key) <= 0 {
+		return m.entries[i].Value, true
+	}
+	return v, false
+}
+
+// All returns an iterator over all the entries in the map, in ascending
+// order of their ranges.
+func (m *Map[K, V]) All() iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		for _, e := range m.entries {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Overlapping returns an iterator over the entries in the map whose ranges
+// overlap [lo, hi), in ascending order; the entries are clipped to [lo, hi).
+func (m *Map[K, V]) Overlapping(lo, hi K) iter.Seq[Entry[K, V]] {
+	return func(yield func(Entry[K, V]) bool) {
+		if m.cmp(lo, hi) >= 0 {
+			return
+		}
+		i, j := m.overlapping(lo, hi)
+		for _, e := range m.entries[i:j] {
+			if m.cmp(e.Lo, lo) < 0 {
+				e.Lo = lo
+			}
+			if m.cmp(e.Hi, hi) > 0 {
+				e.Hi = hi
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// overlapping returns the range [i, j) of indices of the entries that
+// overlap [lo, hi).
+func (m *Map[K, V]) overlapping(lo, hi K) (int, int) {
+	i := sort.Search(len(m.entries), func(i int) bool { return m.cmp(m.entries[i].Hi, lo) > 0 })
+	j := i + sort.Search(len(m.entries)-i, func(k int) bool { return m.cmp(m.entries[i+k].Lo, hi) >= 0 })
+	return i, j
+}
+
+// coalesce merges adjacent entries with equal values among the entries
+// with indices in [from, to], clamped to the valid indices.
+func (m *Map[K, V]) coalesce(from, to int) {
+	from = max(from, 0)
+	to = min(to, len(m.entries)-1)
+	for i := to; i > from; i-- {
+		prev, cur := m.entries[i-1], m.entries[i]
+		if prev.Value == cur.Value && m.cmp(prev.Hi, cur.Lo) == 0 {
+			m.entries[i-1].Hi = cur.Hi
+			m.entries = slices.Delete(m.entries, i, i+1)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package rangemap
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+type entry = Entry[int, string]
+
+func checkEntries(t *testing.T, m *Map[int, string], want []entry) {
+	t.Helper()
+	if got := slices.Collect(m.All()); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if m.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", m.Len(), len(want))
+	}
+}
+
+func TestPricingTiers(t *testing.T) {
+	m := New[int, string](cmp.Compare[int])
+	m.Insert(0, 100, "basic")
+	m.Insert(100, 1000, "pro")
+	m.Insert(1000, 10000, "enterprise")
+	checkEntries(t, m, []entry{{0, 100, "basic"}, {100, 1000, "pro"}, {1000, 10000, "enterprise"}})
+
+	// Overwriting splits the existing range.
+	m.Insert(400, 600, "promo")
+	checkEntries(t, m, []entry{{0, 100, "basic"}, {100, 400, "pro"}, {400, 600, "promo"}, {600, 1000, "pro"}, {1000, 10000, "enterprise"}})
+	if v, ok := m.Get(500); !ok || v != "promo" {
+		t.Errorf("got %q, %v", v, ok)
+	}
+	if v, ok := m.Get(600); !ok || v != "pro" {
+		t.Errorf("got %q, %v", v, ok)
+	}
+	if _, ok := m.Get(10000); ok {
+		t.Errorf("found key past the end")
+	}
+
+	// Restoring the value coalesces the pieces back.
+	m.Insert(400, 600, "pro")
+	checkEntries(t, m, []entry{{0, 100, "basic"}, {100, 1000, "pro"}, {1000, 10000, "enterprise"}})
+
+	m.Remove(50, 150)
+	checkEntries(t, m, []entry{{0, 50, "basic"}, {150, 1000, "pro"}, {1000, 10000, "enterprise"}})
+
+	got := slices.Collect(m.Overlapping(900, 1100))
+	if want := []entry{{900, 1000, "pro"}, {1000, 1100, "enterprise"}}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const universe = 150
+	// mirror[i] is the value of key i, or "" if it's unmapped.
+	var mirror [universe]string
+	m := New[int, string](cmp.Compare[int])
+	for range 3000 {
+		lo := rnd.IntN(universe)
+		hi := min(lo+rnd.IntN(25), universe)
+		if rnd.IntN(4) == 0 {
+			m.Remove(lo, hi)
+			for i := lo; i < hi; i++ {
+				mirror[i] = ""
+			}
+		} else {
+			v := string(rune('a' + rnd.IntN(3)))
+			m.Insert(lo, hi, v)
+			for i := lo; i < hi; i++ {
+				mirror[i] = v
+			}
+		}
+
+		// The entries must be exactly the maximal runs of equal values.
+		var want []entry
+		for i := 0; i < universe; i++ {
+			if mirror[i] == "" {
+				continue
+			}
+			if n := len(want); n > 0 && want[n-1].Hi == i && want[n-1].Value == mirror[i] {
+				want[n-1].Hi++
+			} else {
+				want = append(want, entry{i, i + 1, mirror[i]})
+			}
+		}
+		checkEntries(t, m, want)
+
+		p := rnd.IntN(universe)
+		if v, ok := m.Get(p); v != mirror[p] || ok != (mirror[p] != "") {
+			t.Fatalf("Get(%d)=%q,%v, want %q", p, v, ok, mirror[p])
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package rangeset implements a set of values stored as disjoint, coalesced
+// ranges.
+package rangeset
+
+import (
+	"iter"
+	"slices"
+	"sort"
+)
+
+// Range is a half-open range [Lo, Hi) of values.
+type Range[K any] struct {
+	Lo, Hi K
+}
+
+// Set is a set of values of an ordered type K, represented as a sorted
+// sequence of maximal disjoint ranges: overlapping or adjacent ranges are
+// coalesced when added. Since ranges are half-open, [1, 3) and [3, 5) are
+// adjacent and coalesce into [1, 5); this works the same for integers and
+// for continuous values like times.
+//
+// Queries take O(log n) time for n ranges; updates take O(n) time in the
+// worst case, since the ranges are kept in a slice. Create sets with [New].
+type Set[K any] struct {
+	cmp    func(K, K) int
+	ranges []Range[K]
+}
+
+// New creates a new, empty set with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K any](cmp func(K, K) int) *Set[K] {
+	return &Set[K]{cmp: cmp}
+}
+
+// Len returns the number of maximal ranges in the set.
+func (s *Set[K]) Len() int {
+	return len(s.ranges)
+}
+
+// Add adds all the values in the range [lo, hi) to the set. An empty range
+// (lo >= hi) adds nothing.
+func (s *Set[K]) Add(lo, hi K) {
+	if s.cmp(lo, hi) >= 0 {
+		return
+	}
+	// Ranges i..j-1 overlap or touch [lo, hi), and get merged with it.
+	i := s.search(func(r Range[K]) bool { return s.cmp(r.Hi, lo) >= 0 })
+	j := s.search(func(r Range[K]) bool { return s.cmp(r.Lo, hi) > 0 })
+	if i < j {
+		if s.cmp(s.ranges[i].Lo, lo) < 0 {
+			lo = s.ranges[i].Lo
+		}
+		if s.cmp(s.ranges[j-1].Hi, hi) > 0 {
+			hi = s.ranges[j-1].Hi
+		}
+	}
+	s.ranges = slices.Replace(s.ranges, i, j, Range[K]{Lo: lo, Hi: hi})
+}
+
+// Remove removes all the values in the range [lo, hi) from the set,
+// splitting a range of the set if needed. An empty range (lo >= hi) removes
+// nothing.
+func (s *Set[K]) Remove(lo, hi K) {
+	if s.cmp(lo, hi) >= 0 {
+		return
+	}
+	// Ranges i..j-1 overlap [lo, hi).
+	i := s.search(func(r Range[K]) bool { return s.cmp(r.Hi, lo) > 0 })
+	j := s.search(func(r Range[K]) bool { return s.cmp(r.Lo, hi) >= 0 })
+	if i == j {
+		return
+	}
+	var remaining []Range[K]
+	if first := s.ranges[i]; s.cmp(first.Lo, lo) < 0 {
+		remaining = append(remaining, Range[K]{Lo: first.Lo, Hi: lo})
+	}
+	if last := s.ranges[j-1]; s.cmp(last.Hi, hi) > 0 {
+		remaining = append(remaining, Range[K]{Lo: hi, Hi: last.Hi})
+	}
+	s.ranges = slices.Replace(s.ranges, i, j, remaining...)
+}
+
+// Contains reports whether v is in the set.
+func (s *Set[K]) Contains(v K) bool {
+	i := s.search(func(r Range[K]) bool { return s.cmp(r.Hi, v) > 0 })
+	return i < len(s.ranges) && s.cmp(s.ranges[i].Lo, v) <= 0
+}
+
+// ContainsRange reports whether all the values in the non-empty range
+// [lo, hi) are in the set.
+func (s *Set[K]) ContainsRange(lo, hi K) bool {
+	i := s.search(func(r Range[K]) bool { return s.cmp(r.Hi, lo) > 0 })
+	return i < len(s.ranges) && s.cmp(s.ranges[i].Lo, lo) <= 0 && s.cmp(s.ranges[i].Hi, hi) >= 0
+}
+
+// Ranges returns an iterator over the maximal ranges of the set, in
+// ascending order.
+func (s *Set[K]) Ranges() iter.Seq[Range[K]] {
+	return func(yield func(Range[K]) bool) {
+		for _, r := range s.ranges {
+			if !yield(r) {
+				return
+			}
+		}
+	}
+}
+
+// Complement returns a new set holding the values in [lo, hi) that aren't
+// in s.
+func (s *Set[K]) Complement(lo, hi K) *Set[K] {
+	c := New(s.cmp)
+	if s.cmp(lo, hi) >= 0 {
+		return c
+	}
+	cur := lo
+	i := s.search(func(r Range[K]) bool { return s.cmp(r.Hi, lo) > 0 })
+	for ; i < len(s.ranges) && s.cmp(s.ranges[i].Lo, hi) < 0; i++ {
+		if s.cmp(cur, s.ranges[i].Lo) < 0 {
+			c.ranges = append(c.ranges, Range[K]{Lo: cur, Hi: s.ranges[i].Lo})
+		}
+		cur = s.ranges[i].Hi
+	}
+	if s.cmp(cur, hi) < 0 {
+		c.ranges = append(c.ranges, Range[K]{Lo: cur, Hi: hi})
+	}
+	return c
+}
+
+// search returns the index of the first range for which f is true, assuming
+// f is false for a prefix of the ranges and true for the rest.
+func (s *Set[K]) search(f func(Range[K]) bool) int {
+	return sort.Search(len(s.ranges), func(i int) bool { return f(s.ranges[i]) })
+}
@@ -0,0 +1,118 @@
+package rangeset
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func checkRanges(t *testing.T, s *Set[int], want []Range[int]) {
+	t.Helper()
+	got := slices.Collect(s.Ranges())
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if s.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", s.Len(), len(want))
+	}
+}
+
+func TestBasic(t *testing.T) {
+	s := New(cmp.Compare[int])
+	s.Add(10, 20)
+	s.Add(30, 40)
+	s.Add(20, 25)
+	s.Add(5, 5)
+	checkRanges(t, s, []Range[int]{{10, 25}, {30, 40}})
+	s.Add(24, 31)
+	checkRanges(t, s, []Range[int]{{10, 40}})
+
+	s.Remove(15, 18)
+	checkRanges(t, s, []Range[int]{{10, 15}, {18, 40}})
+	s.Remove(0, 12)
+	s.Remove(35, 100)
+	checkRanges(t, s, []Range[int]{{12, 15}, {18, 35}})
+
+	if !s.Contains(12) || s.Contains(15) || s.Contains(17) || !s.Contains(34) {
+		t.Errorf("bad Contains results")
+	}
+	if !s.ContainsRange(20, 35) || s.ContainsRange(14, 19) {
+		t.Errorf("bad ContainsRange results")
+	}
+
+	c := s.Complement(10, 40)
+	checkRanges(t, c, []Range[int]{{10, 12}, {15, 18}, {35, 40}})
+	checkRanges(t, s.Complement(13, 14), nil)
+	checkRanges(t, s.Complement(15, 18), []Range[int]{{15, 18}})
+}
+
+func TestTimes(t *testing.T) {
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return base.Add(time.Duration(h) * time.Hour) }
+
+	busy := New(func(a, b time.Time) int { return a.Compare(b) })
+	busy.Add(at(0), at(1))
+	busy.Add(at(1), at(2))
+	busy.Add(at(4), at(5))
+	if busy.Len() != 2 {
+		t.Errorf("adjacent meetings not coalesced")
+	}
+	free := slices.Collect(busy.Complement(at(0), at(8)).Ranges())
+	want := []Range[time.Time]{{at(2), at(4)}, {at(5), at(8)}}
+	if !slices.EqualFunc(free, want, func(a, b Range[time.Time]) bool {
+		return a.Lo.Equal(b.Lo) && a.Hi.Equal(b.Hi)
+	}) {
+		t.Errorf("got free slots %v, want %v", free, want)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const universe = 200
+	var member [universe]bool
+	s := New(cmp.Compare[int])
+	for range 2000 {
+		lo := rnd.IntN(universe)
+		hi := lo + rnd.IntN(20)
+		hi = min(hi, universe)
+		add := rnd.IntN(2) == 0
+		if add {
+			s.Add(lo, hi)
+		} else {
+			s.Remove(lo, hi)
+		}
+		for i := lo; i < hi; i++ {
+			member[i] = add
+		}
+
+		// The ranges must be exactly the maximal runs of members.
+		var want []Range[int]
+		for i := 0; i < universe; i++ {
+			if member[i] && (i == 0 || !member[i-1]) {
+				want = append(want, Range[int]{i, i})
+			}
+			if member[i] {
+				want[len(want)-1].Hi = i + 1
+			}
+		}
+		checkRanges(t, s, want)
+
+		p := rnd.IntN(universe)
+		if s.Contains(p) != member[p] {
+			t.Fatalf("Contains(%d) mismatch", p)
+		}
+	}
+
+	c := s.Complement(0, universe)
+	for i := range universe {
+		if c.Contains(i) == member[i] {
+			t.Fatalf("Complement mismatch at %d", i)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package ratelimit implements rate limiters: token buckets, sliding window
+// logs and sliding window counters, and limiters keyed by client.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/eliben/gogl/lru"
+)
+
+// Limiter is the API shared by the rate limiters in this package. Events
+// are either allowed immediately or rejected with Allow, or reserved with
+// Reserve, which tells the caller how long to wait before acting. All the
+// limiters are safe for concurrent use by multiple goroutines.
+type Limiter interface {
+	// Allow is AllowN(1).
+	Allow() bool
+
+	// AllowN reports whether n events may happen now, and records them if
+	// so. Rejected events aren't recorded.
+	AllowN(n int) bool
+
+	// Reserve is ReserveN(1).
+	Reserve() (wait time.Duration, ok bool)
+
+	// ReserveN records n events to happen after waiting for wait, the
+	// earliest time the limit allows them, and returns wait with ok=true.
+	// The caller must wait before acting; events reserved earlier take
+	// precedence over later ones. If n events can never be allowed at once,
+	// ReserveN returns ok=false and records nothing.
+	ReserveN(n int) (wait time.Duration, ok bool)
+}
+
+// forever is the maximal wait for reservations.
+const forever = time.Duration(math.MaxInt64)
+
+// durationOf converts seconds to a duration, rounding up so that waiting
+// for it is always enough, and saturating for very long durations.
+func durationOf(seconds float64) time.Duration {
+	d := math.Ceil(seconds * float64(time.Second))
+	if d >= float64(forever) {
+		return forever
+	}
+	return time.Duration(d)
+}
+
+// Keyed limits the rate of events separately for each key, such as a
+// client address or a user ID, with a limiter per key created on demand.
+// To bound memory, the limiters are kept in an LRU cache: when more than
+// its capacity of keys are active, the limiter of the least recently seen
+// key is dropped, and that key starts afresh with a new limiter when seen
+// again. The capacity should therefore exceed the number of keys active
+// within a limiter's window.
+//
+// A Keyed is safe for concurrent use by multiple goroutines. Create it with
+// [NewKeyed].
+type Keyed[K comparable] struct {
+	mu         sync.Mutex
+	limiters   *lru.Cache[K, Limiter]
+	newLimiter func() Limiter
+}
+
+// NewKeyed creates a new keyed limiter tracking up to capacity keys, which
+// calls newLimiter to create the limiter of each new key. capacity must be
+// positive.
+func NewKeyed[K comparable](capacity int, newLimiter func() Limiter) *Keyed[K] {
+	return &Keyed[K]{limiters: lru.New[K, Limiter](capacity), newLimiter: newLimiter}
+}
+
+// Len returns the number of keys with a limiter.
+func (kl *Keyed[K]) Len() int {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	return kl.limiters.Len()
+}
+
+// Limiter returns the limiter of key, creating it if needed.
+func (kl *Keyed[K]) Limiter(key K) Limiter {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	l, ok := kl.limiters.Get(key)
+	if !ok {
+		l = kl.newLimiter()
+		kl.limiters.Put(key, l)
+	}
+	return l
+}
+
+// Allow is AllowN(key, 1).
+func (kl *Keyed[K]) Allow(key K) bool {
+	return kl.Limiter(key).AllowN(1)
+}
+
+// AllowN is like [Limiter.AllowN] for the limiter of key.
+func (kl *Keyed[K]) AllowN(key K, n int) bool {
+	return kl.Limiter(key).AllowN(n)
+}
+
+// Reserve is ReserveN(key, 1).
+func (kl *Keyed[K]) Reserve(key K) (wait time.Duration, ok bool) {
+	return kl.Limiter(key).ReserveN(1)
+}
+
+// ReserveN is like [Limiter.ReserveN] for the limiter of key.
+func (kl *Keyed[K]) ReserveN(key K, n int) (wait time.Duration, ok bool) {
+	return kl.Limiter(key).ReserveN(n)
+}
@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (fc *fakeClock) now() time.Time {
+	return fc.t
+}
+
+func (fc *fakeClock) advance(d time.Duration) {
+	fc.t = fc.t.Add(d)
+}
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func TestKeyed(t *testing.T) {
+	clock := newFakeClock()
+	kl := NewKeyed[string](2, func() Limiter {
+		return NewSlidingLogWithClock(2, time.Second, clock.now)
+	})
+
+	for _, key := range []string{"alice", "bob"} {
+		if !kl.Allow(key) || !kl.Allow(key) {
+			t.Errorf("%s: want first two events allowed", key)
+		}
+		if kl.Allow(key) {
+			t.Errorf("%s: want third event rejected", key)
+		}
+	}
+	if kl.Len() != 2 {
+		t.Errorf("got Len=%d, want 2", kl.Len())
+	}
+	if wait, ok := kl.Reserve("alice"); !ok || wait != time.Second {
+		t.Errorf("got Reserve=%v,%v, want 1s,true", wait, ok)
+	}
+	if kl.AllowN("bob", 3) {
+		t.Errorf("want AllowN over the limit rejected")
+	}
+	if _, ok := kl.ReserveN("bob", 3); ok {
+		t.Errorf("want ReserveN over the limit rejected")
+	}
+
+	// A third key evicts the limiter of alice, the least recently seen, so
+	// alice starts afresh.
+	if !kl.Allow("carol") {
+		t.Errorf("want carol allowed")
+	}
+	if kl.Len() != 2 {
+		t.Errorf("got Len=%d, want 2", kl.Len())
+	}
+	if !kl.Allow("alice") {
+		t.Errorf("want alice allowed after eviction")
+	}
+
+	// The limiters see time advance.
+	clock.advance(time.Second)
+	if !kl.AllowN("alice", 1) {
+		t.Errorf("want alice allowed after a window")
+	}
+}
+
+func TestKeyedConcurrent(t *testing.T) {
+	const (
+		keys       = 8
+		burst      = 50
+		goroutines = 16
+	)
+	// The bucket refills so slowly that exactly burst events are allowed per
+	// key during the test.
+	kl := NewKeyed[int](keys, func() Limiter { return NewTokenBucket(1e-9, burst) })
+	var allowed [keys]atomic.Int64
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				key := (g + i) % keys
+				if kl.Allow(key) {
+					allowed[key].Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for key := range keys {
+		if got := allowed[key].Load(); got != burst {
+			t.Errorf("key %d: got %d allowed, want %d", key, got, burst)
+		}
+	}
+}
+
+func TestLimiters(t *testing.T) {
+	// Behavior shared by all the limiters, for a limit of 3 events per
+	// second.
+	makers := map[string]func(now func() time.Time) Limiter{
+		"TokenBucket":    func(now func() time.Time) Limiter { return NewTokenBucketWithClock(3, 3, now) },
+		"SlidingLog":     func(now func() time.Time) Limiter { return NewSlidingLogWithClock(3, time.Second, now) },
+		"SlidingCounter": func(now func() time.Time) Limiter { return NewSlidingCounterWithClock(3, time.Second, now) },
+	}
+	for name, makeLimiter := range makers {
+		t.Run(name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := makeLimiter(clock.now)
+			if !l.AllowN(2) || !l.Allow() {
+				t.Errorf("want the first 3 events allowed")
+			}
+			if l.Allow() {
+				t.Errorf("want the 4th event rejected")
+			}
+			if _, ok := l.ReserveN(4); ok {
+				t.Errorf("want ReserveN over the limit rejected")
+			}
+			wait, ok := l.Reserve()
+			if !ok || wait <= 0 || wait > 2*time.Second {
+				t.Errorf("got Reserve=%v,%v, want wait in (0, 2s]", wait, ok)
+			}
+			if l.Allow() {
+				t.Errorf("want events rejected while a reservation is pending")
+			}
+			clock.advance(time.Hour)
+			if !l.AllowN(3) {
+				t.Errorf("want 3 events allowed after a long time")
+			}
+		})
+	}
+}
+
+func TestBadParameters(t *testing.T) {
+	tests := map[string]func(){
+		"TokenBucket rate":     func() { NewTokenBucket(0, 1) },
+		"TokenBucket NaN rate": func() { NewTokenBucket(0/zero(), 1) },
+		"TokenBucket burst":    func() { NewTokenBucket(1, 0) },
+		"SlidingLog limit":     func() { NewSlidingLog(0, time.Second) },
+		"SlidingLog window":    func() { NewSlidingLog(1, 0) },
+		"SlidingCounter limit": func() { NewSlidingCounter(-1, time.Second) },
+		"SlidingCounter wind":  func() { NewSlidingCounter(1, -time.Second) },
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("want panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func zero() float64 { return 0 }
+
+func ExampleKeyed() {
+	limiter := NewKeyed[string](1000, func() Limiter {
+		// Allow bursts of 2 requests, and 1 request per second on average.
+		return NewTokenBucket(1, 2)
+	})
+	for _, client := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.1", "10.0.0.2"} {
+		fmt.Println(client, limiter.Allow(client))
+	}
+	// Output:
+	// 10.0.0.1 true
+	// 10.0.0.1 true
+	// 10.0.0.1 false
+	// 10.0.0.2 true
+}
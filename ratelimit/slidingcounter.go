@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// SlidingCounter is a sliding window counter limiter: it allows about limit
+// events in any window of the given duration, in constant memory. It counts
+// the events in consecutive fixed windows, and estimates the number of
+// events in the sliding window ending now by assuming that the events of the
+// previous fixed window were spread evenly over it: the estimate is the
+// count of the current window plus the count of the previous one weighted
+// by how much of it the sliding window still covers.
+//
+// The estimate is exact when events are evenly spread, and otherwise errs
+// in either direction; for an exact limit, see [SlidingLog].
+//
+// Create sliding counters with [NewSlidingCounter] or
+// [NewSlidingCounterWithClock].
+type SlidingCounter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	// counts holds the number of events in consecutive fixed windows:
+	// counts[0] is the previous window, counts[1] the current one, which
+	// starts at start, and any further counts are windows in the future with
+	// reserved events.
+	counts []int
+	start  time.Time
+}
+
+var _ Limiter = (*SlidingCounter)(nil)
+
+// NewSlidingCounter creates a new sliding counter allowing about limit
+// events per window. It panics if limit or window isn't positive.
+func NewSlidingCounter(limit int, window time.Duration) *SlidingCounter {
+	return NewSlidingCounterWithClock(limit, window, time.Now)
+}
+
+// NewSlidingCounterWithClock is like NewSlidingCounter, but the counter gets
+// the current time from now instead of the system clock, which is useful for
+// testing.
+func NewSlidingCounterWithClock(limit int, window time.Duration, now func() time.Time) *SlidingCounter {
+	if limit <= 0 || window <= 0 {
+		panic(fmt.Sprintf("ratelimit: invalid sliding counter limit=%d, window=%v", limit, window))
+	}
+	return &SlidingCounter{limit: limit, window: window, now: now, counts: make([]int, 2), start: now()}
+}
+
+// Estimate returns the estimated number of events in the current sliding
+// window, including reserved events that haven't happened yet.
+func (sc *SlidingCounter) Estimate() float64 {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	now := sc.now()
+	sc.rotate(now)
+	f := float64(now.Sub(sc.start)) / float64(sc.window)
+	return float64(sc.counts[0])*(1-f) + float64(sc.counts[1])
+}
+
+// Allow is AllowN(1).
+func (sc *SlidingCounter) Allow() bool {
+	return sc.AllowN(1)
+}
+
+// AllowN reports whether n more events may happen in the current window,
+// recording them if so.
+func (sc *SlidingCounter) AllowN(n int) bool {
+	_, ok := sc.reserve(n, 0)
+	return ok
+}
+
+// Reserve is ReserveN(1).
+func (sc *SlidingCounter) Reserve() (wait time.Duration, ok bool) {
+	return sc.ReserveN(1)
+}
+
+// ReserveN records n events at the earliest time the estimate allows them,
+// and returns the time to wait until then. It returns ok=false if n is
+// larger than the limit.
+func (sc *SlidingCounter) ReserveN(n int) (wait time.Duration, ok bool) {
+	return sc.reserve(n, forever)
+}
+
+// reserve records n events if the estimate allows them within maxWait, and
+// returns the time to wait for them.
+func (sc *SlidingCounter) reserve(n int, maxWait time.Duration) (time.Duration, bool) {
+	if n > sc.limit {
+		return 0, false
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	now := sc.now()
+	sc.rotate(now)
+
+	// Find the first fixed window with room for n events, and the earliest
+	// time in it where the weight of the previous window is low enough.
+	for j := 1; ; j++ {
+		prev, cur := sc.count(j-1), sc.count(j)
+		if cur+n > sc.limit {
+			continue
+		}
+		f := 0.0
+		if prev > 0 {
+			f = max(0, 1-float64(sc.limit-n-cur)/float64(prev))
+		}
+		if f >= 1 {
+			continue
+		}
+		at := sc.start.Add(time.Duration(j-1) * sc.window)
+		at = at.Add(time.Duration(math.Ceil(f * float64(sc.window))))
+		wait := max(0, at.Sub(now))
+		if wait > maxWait {
+			return 0, false
+		}
+		for len(sc.counts) <= j {
+			sc.counts = append(sc.counts, 0)
+		}
+		sc.counts[j] += n
+		return wait, true
+	}
+}
+
+// count returns the number of events in the fixed window i, as indexed in
+// counts.
+func (sc *SlidingCounter) count(i int) int {
+	if i < len(sc.counts) {
+		return sc.counts[i]
+	}
+	return 0
+}
+
+// rotate moves the current fixed window forward to the one holding now.
+func (sc *SlidingCounter) rotate(now time.Time) {
+	k := int(now.Sub(sc.start) / sc.window)
+	if k <= 0 {
+		return
+	}
+	sc.start = sc.start.Add(time.Duration(k) * sc.window)
+	if k < len(sc.counts) {
+		sc.counts = append(sc.counts[:0], sc.counts[k:]...)
+	} else {
+		sc.counts = sc.counts[:0]
+	}
+	for len(sc.counts) < 2 {
+		sc.counts = append(sc.counts, 0)
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSlidingCounter(t *testing.T) {
+	clock := newFakeClock()
+	sc := NewSlidingCounterWithClock(4, time.Second, clock.now)
+	checkEstimate := func(want float64) {
+		t.Helper()
+		if got := sc.Estimate(); math.Abs(got-want) > 1e-9 {
+			t.Errorf("got Estimate=%v, want %v", got, want)
+		}
+	}
+
+	if !sc.AllowN(4) || sc.Allow() {
+		t.Errorf("want exactly 4 events allowed")
+	}
+	checkEstimate(4)
+
+	// In the next window, the events of the previous one count less and
+	// less.
+	clock.advance(1250 * time.Millisecond)
+	checkEstimate(3)
+	if !sc.Allow() || sc.Allow() {
+		t.Errorf("want exactly 1 event allowed")
+	}
+	clock.advance(250 * time.Millisecond)
+	checkEstimate(3)
+	if !sc.Allow() || sc.Allow() {
+		t.Errorf("want exactly 1 event allowed")
+	}
+
+	// Reservations wait for the weight of the previous window to drop, or
+	// for a later window.
+	if wait, ok := sc.Reserve(); !ok || wait != 250*time.Millisecond {
+		t.Errorf("got Reserve=%v,%v, want 250ms,true", wait, ok)
+	}
+	want := 833333334 * time.Nanosecond
+	if wait, ok := sc.ReserveN(2); !ok || wait != want {
+		t.Errorf("got ReserveN=%v,%v, want %v,true", wait, ok, want)
+	}
+	if sc.Allow() {
+		t.Errorf("want rejected while reservations are pending")
+	}
+	clock.advance(250 * time.Millisecond)
+	checkEstimate(4)
+	clock.advance(750 * time.Millisecond)
+	checkEstimate(3.5)
+	clock.advance(time.Hour)
+	checkEstimate(0)
+}
+
+func TestSlidingCounterRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const limit = 20
+	clock := newFakeClock()
+	sc := NewSlidingCounterWithClock(limit, time.Second, clock.now)
+	allowed := 0
+	for range 5000 {
+		clock.advance(time.Duration(rnd.IntN(30)) * time.Millisecond)
+		if sc.AllowN(1 + rnd.IntN(3)) {
+			allowed++
+			if e := sc.Estimate(); e > limit+1e-9 {
+				t.Fatalf("got Estimate=%v over the limit", e)
+			}
+		}
+	}
+	if allowed == 0 {
+		t.Errorf("no events allowed")
+	}
+}
+
+func BenchmarkSlidingCounter(b *testing.B) {
+	sc := NewSlidingCounter(1000, time.Millisecond)
+	for range b.N {
+		sc.Allow()
+	}
+}
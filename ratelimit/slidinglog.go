@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eliben/gogl/deque"
+)
+
+// SlidingLog is a sliding window log limiter: it allows up to limit events
+// in any window of the given duration, exactly. It keeps a log of the times
+// of the events in the last window, so it takes O(limit) memory; see
+// [SlidingCounter] for an approximation in constant memory.
+//
+// Create sliding logs with [NewSlidingLog] or [NewSlidingLogWithClock].
+type SlidingLog struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	// log holds the times of the recorded events, in order; an event counts
+	// in the windows that end less than window after it. The times of
+	// reserved events may be in the future.
+	log *deque.Deque[time.Time]
+}
+
+var _ Limiter = (*SlidingLog)(nil)
+
+// NewSlidingLog creates a new sliding log allowing up to limit events per
+// window. It panics if limit or window isn't positive.
+func NewSlidingLog(limit int, window time.Duration) *SlidingLog {
+	return NewSlidingLogWithClock(limit, window, time.Now)
+}
+
+// NewSlidingLogWithClock is like NewSlidingLog, but the log gets the current
+// time from now instead of the system clock, which is useful for testing.
+func NewSlidingLogWithClock(limit int, window time.Duration, now func() time.Time) *SlidingLog {
+	if limit <= 0 || window <= 0 {
+		panic(fmt.Sprintf("ratelimit: invalid sliding log limit=%d, window=%v", limit, window))
+	}
+	return &SlidingLog{limit: limit, window: window, now: now, log: deque.New[time.Time]()}
+}
+
+// Count returns the number of events recorded in the current window,
+// including reserved events that haven't happened yet.
+func (sl *SlidingLog) Count() int {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.expire(sl.now())
+	return sl.log.Len()
+}
+
+// Allow is AllowN(1).
+func (sl *SlidingLog) Allow() bool {
+	return sl.AllowN(1)
+}
+
+// AllowN reports whether n more events may happen in the current window,
+// recording them if so.
+func (sl *SlidingLog) AllowN(n int) bool {
+	_, ok := sl.reserve(n, 0)
+	return ok
+}
+
+// Reserve is ReserveN(1).
+func (sl *SlidingLog) Reserve() (wait time.Duration, ok bool) {
+	return sl.ReserveN(1)
+}
+
+// ReserveN records n events at the earliest time they fit in the window,
+// and returns the time to wait until then. It returns ok=false if n is
+// larger than the limit.
+func (sl *SlidingLog) ReserveN(n int) (wait time.Duration, ok bool) {
+	return sl.reserve(n, forever)
+}
+
+// reserve records n events if they fit in the window within maxWait, and
+// returns the time to wait for them.
+func (sl *SlidingLog) reserve(n int, maxWait time.Duration) (time.Duration, bool) {
+	if n > sl.limit {
+		return 0, false
+	}
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	now := sl.now()
+	sl.expire(now)
+	at := now
+	if excess := sl.log.Len() + n - sl.limit; excess > 0 {
+		// Wait until the excess events leave the window, and after the last
+		// reserved event, so that the log stays in order.
+		at = sl.log.At(excess - 1).Add(sl.window)
+		if sl.log.Len() > 0 && sl.log.Back().After(at) {
+			at = sl.log.Back()
+		}
+	}
+	wait := at.Sub(now)
+	if wait > maxWait {
+		return 0, false
+	}
+	for range n {
+		sl.log.PushBack(at)
+	}
+	return wait, true
+}
+
+// expire removes the events that left the window at now from the log.
+func (sl *SlidingLog) expire(now time.Time) {
+	for sl.log.Len() > 0 && !sl.log.Front().Add(sl.window).After(now) {
+		sl.log.PopFront()
+	}
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingLog(t *testing.T) {
+	clock := newFakeClock()
+	sl := NewSlidingLogWithClock(3, time.Second, clock.now)
+	checkCount := func(want int) {
+		t.Helper()
+		if got := sl.Count(); got != want {
+			t.Errorf("got Count=%d, want %d", got, want)
+		}
+	}
+
+	sl.Allow()
+	clock.advance(400 * time.Millisecond)
+	sl.AllowN(2)
+	checkCount(3)
+	if sl.Allow() {
+		t.Errorf("want full window to reject")
+	}
+
+	// The first event leaves the window a second after it happened.
+	clock.advance(599 * time.Millisecond)
+	if sl.Allow() {
+		t.Errorf("want full window to reject")
+	}
+	clock.advance(time.Millisecond)
+	checkCount(2)
+	if !sl.Allow() {
+		t.Errorf("want event allowed once the first one left")
+	}
+
+	// Reservations wait for the events they replace to leave the window;
+	// then the next reservations wait after them.
+	if wait, ok := sl.ReserveN(2); !ok || wait != 400*time.Millisecond {
+		t.Errorf("got ReserveN=%v,%v, want 400ms,true", wait, ok)
+	}
+	if wait, ok := sl.Reserve(); !ok || wait != time.Second {
+		t.Errorf("got Reserve=%v,%v, want 1s,true", wait, ok)
+	}
+	checkCount(6)
+	clock.advance(time.Second)
+	checkCount(3)
+	if sl.Allow() {
+		t.Errorf("want full window to reject")
+	}
+	clock.advance(time.Hour)
+	checkCount(0)
+}
+
+func TestSlidingLogRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const (
+		limit  = 10
+		window = time.Second
+	)
+	clock := newFakeClock()
+	sl := NewSlidingLogWithClock(limit, window, clock.now)
+
+	// Record the times of the allowed and reserved events, which are in
+	// order, and check that no window holds more than limit events.
+	var events []time.Time
+	for range 2000 {
+		clock.advance(time.Duration(rnd.IntN(100)) * time.Millisecond)
+		n := 1 + rnd.IntN(3)
+		at := clock.now()
+		if rnd.IntN(4) == 0 {
+			wait, ok := sl.ReserveN(n)
+			if !ok {
+				t.Fatalf("ReserveN(%d) failed", n)
+			}
+			at = at.Add(wait)
+		} else if !sl.AllowN(n) {
+			continue
+		}
+		for range n {
+			events = append(events, at)
+		}
+	}
+	for i := range events {
+		if i > 0 && events[i].Before(events[i-1]) {
+			t.Fatalf("events out of order at %d", i)
+		}
+		j := i
+		for j < len(events) && events[j].Sub(events[i]) < window {
+			j++
+		}
+		if j-i > limit {
+			t.Fatalf("%d events in the window starting at %v", j-i, events[i])
+		}
+	}
+}
+
+func BenchmarkSlidingLog(b *testing.B) {
+	sl := NewSlidingLog(1000, time.Millisecond)
+	for range b.N {
+		sl.Allow()
+	}
+}
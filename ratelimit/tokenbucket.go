@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TokenBucket is a token bucket limiter: a bucket holding up to burst
+// tokens, refilled at a constant rate, where each event takes a token. It
+// allows bursts of up to burst events, and a long-run average of rate
+// events per second. Reservations may take tokens before they're refilled,
+// leaving the bucket in debt, which later events must wait to repay.
+//
+// Create token buckets with [NewTokenBucket] or [NewTokenBucketWithClock].
+type TokenBucket struct {
+	mu    sync.Mutex
+	rate  float64
+	burst int
+	now   func() time.Time
+
+	// tokens is the number of tokens in the bucket at time last; it's
+	// negative when the bucket is in debt.
+	tokens float64
+	last   time.Time
+}
+
+var _ Limiter = (*TokenBucket)(nil)
+
+// NewTokenBucket creates a new token bucket, initially full, holding up to
+// burst tokens and refilled with rate tokens per second. It panics if rate
+// or burst isn't positive.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return NewTokenBucketWithClock(rate, burst, time.Now)
+}
+
+// NewTokenBucketWithClock is like NewTokenBucket, but the bucket gets the
+// current time from now instead of the system clock, which is useful for
+// testing.
+func NewTokenBucketWithClock(rate float64, burst int, now func() time.Time) *TokenBucket {
+	if !(rate > 0) || burst <= 0 {
+		panic(fmt.Sprintf("ratelimit: invalid token bucket rate=%v, burst=%d", rate, burst))
+	}
+	return &TokenBucket{rate: rate, burst: burst, now: now, tokens: float64(burst), last: now()}
+}
+
+// Tokens returns the number of tokens currently in the bucket; it's
+// negative if reservations have left the bucket in debt.
+func (tb *TokenBucket) Tokens() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	return tb.tokens
+}
+
+// Allow is AllowN(1).
+func (tb *TokenBucket) Allow() bool {
+	return tb.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now, taking n tokens if so.
+func (tb *TokenBucket) AllowN(n int) bool {
+	_, ok := tb.reserve(n, 0)
+	return ok
+}
+
+// Reserve is ReserveN(1).
+func (tb *TokenBucket) Reserve() (wait time.Duration, ok bool) {
+	return tb.ReserveN(1)
+}
+
+// ReserveN takes n tokens, and returns the time to wait until they're
+// refilled. It returns ok=false if n is larger than the burst.
+func (tb *TokenBucket) ReserveN(n int) (wait time.Duration, ok bool) {
+	return tb.reserve(n, forever)
+}
+
+// reserve takes n tokens if they're available within maxWait, and returns
+// the time to wait for them.
+func (tb *TokenBucket) reserve(n int, maxWait time.Duration) (time.Duration, bool) {
+	if n > tb.burst {
+		return 0, false
+	}
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	var wait time.Duration
+	if missing := float64(n) - tb.tokens; missing > 0 {
+		wait = durationOf(missing / tb.rate)
+	}
+	if wait > maxWait {
+		return 0, false
+	}
+	tb.tokens -= float64(n)
+	return wait, true
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (tb *TokenBucket) refill() {
+	now := tb.now()
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens = min(float64(tb.burst), tb.tokens+elapsed.Seconds()*tb.rate)
+		tb.last = now
+	}
+}
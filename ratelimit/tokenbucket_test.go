@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	clock := newFakeClock()
+	tb := NewTokenBucketWithClock(10, 5, clock.now)
+	checkTokens := func(want float64) {
+		t.Helper()
+		if got := tb.Tokens(); math.Abs(got-want) > 1e-9 {
+			t.Errorf("got Tokens=%v, want %v", got, want)
+		}
+	}
+
+	checkTokens(5)
+	if !tb.AllowN(5) {
+		t.Errorf("want burst allowed")
+	}
+	if tb.Allow() {
+		t.Errorf("want empty bucket to reject")
+	}
+	clock.advance(100 * time.Millisecond)
+	checkTokens(1)
+	if !tb.Allow() || tb.Allow() {
+		t.Errorf("want exactly one refilled token")
+	}
+
+	// Reservations put the bucket in debt.
+	if wait, ok := tb.ReserveN(5); !ok || wait != 500*time.Millisecond {
+		t.Errorf("got ReserveN=%v,%v, want 500ms,true", wait, ok)
+	}
+	checkTokens(-5)
+	if wait, ok := tb.Reserve(); !ok || wait != 600*time.Millisecond {
+		t.Errorf("got Reserve=%v,%v, want 600ms,true", wait, ok)
+	}
+	clock.advance(500 * time.Millisecond)
+	if tb.Allow() {
+		t.Errorf("want rejected while in debt")
+	}
+	checkTokens(-1)
+
+	// The bucket never holds more than the burst.
+	clock.advance(time.Hour)
+	checkTokens(5)
+	if tb.AllowN(6) {
+		t.Errorf("want AllowN over the burst rejected")
+	}
+}
+
+func TestTokenBucketRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const (
+		rate  = 20.0
+		burst = 8
+	)
+	clock := newFakeClock()
+	tb := NewTokenBucketWithClock(rate, burst, clock.now)
+
+	// Record the times of the allowed and reserved events, and check that
+	// the events in any interval don't exceed what the bucket allows.
+	type event struct {
+		at time.Time
+		n  int
+	}
+	var events []event
+	for range 600 {
+		clock.advance(time.Duration(rnd.IntN(100)) * time.Millisecond)
+		n := 1 + rnd.IntN(burst)
+		if rnd.IntN(4) == 0 {
+			if wait, ok := tb.ReserveN(n); ok {
+				events = append(events, event{clock.now().Add(wait), n})
+			}
+		} else if tb.AllowN(n) {
+			events = append(events, event{clock.now(), n})
+		}
+	}
+	if len(events) < 100 {
+		t.Fatalf("got only %d events", len(events))
+	}
+	for i := range events {
+		total := 0
+		for j := i; j < len(events); j++ {
+			total += events[j].n
+			span := events[j].at.Sub(events[i].at).Seconds()
+			if float64(total) > burst+rate*span+1e-6 {
+				t.Fatalf("%d events in %vs, over the limit", total, span)
+			}
+		}
+	}
+}
+
+func BenchmarkTokenBucket(b *testing.B) {
+	tb := NewTokenBucket(1e6, 1000)
+	for range b.N {
+		tb.Allow()
+	}
+}
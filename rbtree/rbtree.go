@@ -0,0 +1,369 @@
+// Package rbtree implements an ordered map backed by a red-black tree.
+package rbtree
+
+import "iter"
+
+// Tree is an ordered map from keys of type K to values of type V, backed by
+// a red-black tree: a binary search tree whose nodes are colored red or
+// black such that no red node has a red child, and every path from a node
+// down to a leaf has the same number of black nodes. This keeps the tree's
+// height within 2*log(n+1), so operations take O(log n) time.
+//
+// Tree has the same ordered-map API as [github.com/eliben/gogl/btree.BTree].
+// Create trees with [New].
+type Tree[K, V any] struct {
+	cmp    func(K, K) int
+	root   *node[K, V]
+	length int
+
+	// sentinel stands in for all the leaves and the root's parent, as in
+	// CLRS; it's always black, which simplifies the fixup procedures.
+	sentinel *node[K, V]
+}
+
+type color bool
+
+const (
+	red   color = false
+	black color = true
+)
+
+type node[K, V any] struct {
+	key                 K
+	value               V
+	left, right, parent *node[K, V]
+	color               color
+}
+
+// New creates a new, empty tree with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K, V any](cmp func(K, K) int) *Tree[K, V] {
+	sentinel := &node[K, V]{color: black}
+	return &Tree[K, V]{cmp: cmp, root: sentinel, sentinel: sentinel}
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree[K, V]) Len() int {
+	return t.length
+}
+
+// Get looks for the given key in the tree. It returns the associated value
+// and ok=true; otherwise, it returns ok=false.
+func (t *Tree[K, V]) Get(key K) (v V, ok bool) {
+	if n := t.find(key); n != t.sentinel {
+		return n.value, true
+	}
+	return v, false
+}
+
+// Insert inserts a new key=value pair into the tree. If key already exists
+// in the tree, its value is replaced with value.
+func (t *Tree[K, V]) Insert(key K, value V) {
+	parent := t.sentinel
+	n := t.root
+	c := 0
+	for n != t.sentinel {
+		parent = n
+		c = t.cmp(key, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			n.value = value
+			return
+		}
+	}
+
+	z := &node[K, V]{key: key, value: value, left: t.sentinel, right: t.sentinel, parent: parent, color: red}
+	switch {
+	case parent == t.sentinel:
+		t.root = z
+	case c < 0:
+		parent.left = z
+	default:
+		parent.right = z
+	}
+	t.length++
+	t.insertFixup(z)
+}
+
+// Delete deletes a key and its associated value from the tree. It returns
+// true if the key was found and deleted; if key is not found in the tree,
+// Delete is a no-op and returns false.
+func (t *Tree[K, V]) Delete(key K) bool {
+	z := t.find(key)
+	if z == t.sentinel {
+		return false
+	}
+	t.length--
+
+	// Following CLRS: y is the node actually removed from its position (z
+	// itself, or z's successor if z has two children), and x is the node
+	// that moves into y's position.
+	y := z
+	yOrigColor := y.color
+	var x *node[K, V]
+	switch {
+	case z.left == t.sentinel:
+		x = z.right
+		t.transplant(z, z.right)
+	case z.right == t.sentinel:
+		x = z.left
+		t.transplant(z, z.left)
+	default:
+		y = t.minimum(z.right)
+		yOrigColor = y.color
+		x = y.right
+		if y.parent == z {
+			// x may be the sentinel; its parent is used by deleteFixup.
+			x.parent = y
+		} else {
+			t.transplant(y, y.right)
+			y.right = z.right
+			y.right.parent = y
+		}
+		t.transplant(z, y)
+		y.left = z.left
+		y.left.parent = y
+		y.color = z.color
+	}
+	if yOrigColor == black {
+		t.deleteFixup(x)
+	}
+	t.sentinel.parent = nil
+	return true
+}
+
+// All returns an iterator over all key, value pairs in the tree, in
+// ascending order of keys.
+func (t *Tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.root, nil, nil, yield)
+	}
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys.
+func (t *Tree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.root, &lo, &hi, yield)
+	}
+}
+
+// Floor finds the largest key in the tree that's smaller than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (t *Tree[K, V]) Floor(key K) (k K, v V, ok bool) {
+	for n := t.root; n != t.sentinel; {
+		c := t.cmp(key, n.key)
+		if c == 0 {
+			return n.key, n.value, true
+		} else if c < 0 {
+			n = n.left
+		} else {
+			k, v, ok = n.key, n.value, true
+			n = n.right
+		}
+	}
+	return k, v, ok
+}
+
+// Ceiling finds the smallest key in the tree that's larger than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (t *Tree[K, V]) Ceiling(key K) (k K, v V, ok bool) {
+	for n := t.root; n != t.sentinel; {
+		c := t.cmp(key, n.key)
+		if c == 0 {
+			return n.key, n.value, true
+		} else if c > 0 {
+			n = n.right
+		} else {
+			k, v, ok = n.key, n.value, true
+			n = n.left
+		}
+	}
+	return k, v, ok
+}
+
+// find returns the node holding key, or the sentinel if there's none.
+func (t *Tree[K, V]) find(key K) *node[K, V] {
+	n := t.root
+	for n != t.sentinel {
+		c := t.cmp(key, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// ascend yields the key, value pairs in the subtree rooted at n in
+// ascending order, limited to the range [lo, hi) with nil meaning unbounded.
+// It returns false if iteration should stop, either because yield returned
+// false or because hi was reached.
+func (t *Tree[K, V]) ascend(n *node[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	if n == t.sentinel {
+		return true
+	}
+	aboveLo := lo == nil || t.cmp(n.key, *lo) >= 0
+	if aboveLo && !t.ascend(n.left, lo, hi, yield) {
+		return false
+	}
+	if hi != nil && t.cmp(n.key, *hi) >= 0 {
+		return false
+	}
+	if aboveLo && !yield(n.key, n.value) {
+		return false
+	}
+	return t.ascend(n.right, lo, hi, yield)
+}
+
+func (t *Tree[K, V]) minimum(n *node[K, V]) *node[K, V] {
+	for n.left != t.sentinel {
+		n = n.left
+	}
+	return n
+}
+
+// transplant replaces the subtree rooted at u with the subtree rooted at v.
+func (t *Tree[K, V]) transplant(u, v *node[K, V]) {
+	switch {
+	case u.parent == t.sentinel:
+		t.root = v
+	case u == u.parent.left:
+		u.parent.left = v
+	default:
+		u.parent.right = v
+	}
+	v.parent = u.parent
+}
+
+func (t *Tree[K, V]) rotateLeft(x *node[K, V]) {
+	y := x.right
+	x.right = y.left
+	if y.left != t.sentinel {
+		y.left.parent = x
+	}
+	t.transplant(x, y)
+	y.left = x
+	x.parent = y
+}
+
+func (t *Tree[K, V]) rotateRight(x *node[K, V]) {
+	y := x.left
+	x.left = y.right
+	if y.right != t.sentinel {
+		y.right.parent = x
+	}
+	t.transplant(x, y)
+	y.right = x
+	x.parent = y
+}
+
+// insertFixup restores the red-black properties after inserting the red
+// node z, which may have a red parent.
+func (t *Tree[K, V]) insertFixup(z *node[K, V]) {
+	for z.parent.color == red {
+		gp := z.parent.parent
+		if z.parent == gp.left {
+			uncle := gp.right
+			if uncle.color == red {
+				z.parent.color = black
+				uncle.color = black
+				gp.color = red
+				z = gp
+				continue
+			}
+			if z == z.parent.right {
+				z = z.parent
+				t.rotateLeft(z)
+			}
+			z.parent.color = black
+			gp.color = red
+			t.rotateRight(gp)
+		} else {
+			uncle := gp.left
+			if uncle.color == red {
+				z.parent.color = black
+				uncle.color = black
+				gp.color = red
+				z = gp
+				continue
+			}
+			if z == z.parent.left {
+				z = z.parent
+				t.rotateRight(z)
+			}
+			z.parent.color = black
+			gp.color = red
+			t.rotateLeft(gp)
+		}
+	}
+	t.root.color = black
+}
+
+// deleteFixup restores the red-black properties after a deletion, where x
+// carries an "extra black" that has to be pushed up or absorbed.
+func (t *Tree[K, V]) deleteFixup(x *node[K, V]) {
+	for x != t.root && x.color == black {
+		if x == x.parent.left {
+			w := x.parent.right
+			if w.color == red {
+				w.color = black
+				x.parent.color = red
+				t.rotateLeft(x.parent)
+				w = x.parent.right
+			}
+			if w.left.color == black && w.right.color == black {
+				w.color = red
+				x = x.parent
+				continue
+			}
+			if w.right.color == black {
+				w.left.color = black
+				w.color = red
+				t.rotateRight(w)
+				w = x.parent.right
+			}
+			w.color = x.parent.color
+			x.parent.color = black
+			w.right.color = black
+			t.rotateLeft(x.parent)
+			x = t.root
+		} else {
+			w := x.parent.left
+			if w.color == red {
+				w.color = black
+				x.parent.color = red
+				t.rotateRight(x.parent)
+				w = x.parent.left
+			}
+			if w.right.color == black && w.left.color == black {
+				w.color = red
+				x = x.parent
+				continue
+			}
+			if w.left.color == black {
+				w.right.color = black
+				w.color = red
+				t.rotateLeft(w)
+				w = x.parent.left
+			}
+			w.color = x.parent.color
+			x.parent.color = black
+			w.left.color = black
+			t.rotateRight(x.parent)
+			x = t.root
+		}
+	}
+	x.color = black
+}
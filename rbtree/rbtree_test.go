@@ -0,0 +1,130 @@
+package rbtree
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func checkVerify[K, V any](t *testing.T, tr *Tree[K, V]) {
+	t.Helper()
+	if err := tr.verify(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSequential(t *testing.T) {
+	tr := New[int, string](cmp.Compare[int])
+	for i := range 1000 {
+		tr.Insert(i, strconv.Itoa(i))
+		checkVerify(t, tr)
+	}
+	if tr.Len() != 1000 {
+		t.Errorf("got Len=%d", tr.Len())
+	}
+	for i := range 1000 {
+		if v, ok := tr.Get(i); !ok || v != strconv.Itoa(i) {
+			t.Fatalf("Get(%d)=%q,%v", i, v, ok)
+		}
+	}
+	for i := 0; i < 1000; i += 2 {
+		if !tr.Delete(i) || tr.Delete(i) {
+			t.Fatalf("bad Delete(%d) results", i)
+		}
+		checkVerify(t, tr)
+	}
+	var got []int
+	for k := range tr.All() {
+		got = append(got, k)
+	}
+	if len(got) != 500 || got[0] != 1 || got[499] != 999 || !slices.IsSorted(got) {
+		t.Errorf("bad All results")
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := New[int, int](cmp.Compare[int])
+	m := make(map[int]int)
+	for i := range 20000 {
+		k := rnd.IntN(1000)
+		if rnd.IntN(2) == 0 {
+			_, inMap := m[k]
+			if tr.Delete(k) != inMap {
+				t.Fatalf("Delete(%d) mismatch", k)
+			}
+			delete(m, k)
+		} else {
+			tr.Insert(k, i)
+			m[k] = i
+		}
+		if i%100 == 0 {
+			checkVerify(t, tr)
+		}
+	}
+	checkVerify(t, tr)
+	if tr.Len() != len(m) {
+		t.Fatalf("got Len=%d, want %d", tr.Len(), len(m))
+	}
+	for k, v := range tr.All() {
+		if m[k] != v {
+			t.Fatalf("got %d=%d, want %d", k, v, m[k])
+		}
+	}
+}
+
+func TestRangeAndBounds(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := New[int, int](cmp.Compare[int])
+	var sorted []int
+	for range 300 {
+		k := rnd.IntN(1000) * 2
+		tr.Insert(k, k)
+		sorted = append(sorted, k)
+	}
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	for range 200 {
+		lo := rnd.IntN(2100) - 50
+		hi := lo + rnd.IntN(200)
+		var got, want []int
+		for k := range tr.Range(lo, hi) {
+			got = append(got, k)
+		}
+		for _, k := range sorted {
+			if k >= lo && k < hi {
+				want = append(want, k)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("Range(%d, %d)=%v, want %v", lo, hi, got, want)
+		}
+
+		q := rnd.IntN(2100) - 50
+		i, found := slices.BinarySearch(sorted, q)
+		wantFloor, wantFloorOK := q, found
+		if !found && i > 0 {
+			wantFloor, wantFloorOK = sorted[i-1], true
+		}
+		if k, _, ok := tr.Floor(q); ok != wantFloorOK || (ok && k != wantFloor) {
+			t.Fatalf("Floor(%d)=%d,%v, want %d,%v", q, k, ok, wantFloor, wantFloorOK)
+		}
+		wantCeil, wantCeilOK := 0, i < len(sorted)
+		if wantCeilOK {
+			wantCeil = sorted[i]
+		}
+		if k, _, ok := tr.Ceiling(q); ok != wantCeilOK || (ok && k != wantCeil) {
+			t.Fatalf("Ceiling(%d)=%d,%v, want %d,%v", q, k, ok, wantCeil, wantCeilOK)
+		}
+	}
+}
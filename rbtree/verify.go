@@ -0,0 +1,64 @@
+package rbtree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// verify checks red-black tree invariants on t and returns an error
+// describing the first problem encountered. Returns nil if t is ok.
+func (t *Tree[K, V]) verify() error {
+	if t.root.color != black {
+		return errors.New("root is red")
+	}
+	if t.root != t.sentinel && t.root.parent != t.sentinel {
+		return errors.New("root's parent is not the sentinel")
+	}
+	if t.sentinel.color != black || t.sentinel.left != nil || t.sentinel.right != nil {
+		return errors.New("sentinel was modified")
+	}
+	count, _, err := t.verifyNode(t.root, nil, nil)
+	if err != nil {
+		return err
+	}
+	if count != t.length {
+		return fmt.Errorf("counted %d nodes, but length is %d", count, t.length)
+	}
+	return nil
+}
+
+// verifyNode verifies the subtree rooted at n, whose keys must lie in the
+// range (lo, hi) with nil meaning unbounded. It returns the number of nodes
+// in the subtree and its black height.
+func (t *Tree[K, V]) verifyNode(n *node[K, V], lo, hi *K) (count, blackHeight int, err error) {
+	if n == t.sentinel {
+		return 0, 1, nil
+	}
+	if (lo != nil && t.cmp(n.key, *lo) <= 0) || (hi != nil && t.cmp(n.key, *hi) >= 0) {
+		return 0, 0, fmt.Errorf("node %v: violates ordering", n.key)
+	}
+	if n.color == red && (n.left.color == red || n.right.color == red) {
+		return 0, 0, fmt.Errorf("node %v: red node with a red child", n.key)
+	}
+	for _, c := range []*node[K, V]{n.left, n.right} {
+		if c != t.sentinel && c.parent != n {
+			return 0, 0, fmt.Errorf("node %v: child %v has wrong parent", n.key, c.key)
+		}
+	}
+
+	lc, lbh, err := t.verifyNode(n.left, lo, &n.key)
+	if err != nil {
+		return 0, 0, err
+	}
+	rc, rbh, err := t.verifyNode(n.right, &n.key, hi)
+	if err != nil {
+		return 0, 0, err
+	}
+	if lbh != rbh {
+		return 0, 0, fmt.Errorf("node %v: black heights differ: %d vs %d", n.key, lbh, rbh)
+	}
+	if n.color == black {
+		lbh++
+	}
+	return lc + rc + 1, lbh, nil
+}
@@ -0,0 +1,164 @@
+// Package rendezvous implements rendezvous hashing, also known as highest
+// random weight (HRW) hashing, for mapping keys to members of a changing
+// set.
+package rendezvous
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/eliben/gogl/internal/hash"
+)
+
+// Table maps keys to members by scoring every member for a key with a hash
+// of both, and picking the member with the highest score. Since a member's
+// score for a key doesn't depend on the other members, adding a member only
+// takes over the keys it scores highest for, and removing one only moves
+// its own keys - each to the member scoring second-highest for it.
+//
+// Lookups take O(n) time for n members, so rendezvous hashing is a good fit
+// for small member sets; it needs no virtual nodes, balances load better
+// than a [consistent hashing ring] and uses less memory. Tables and rings
+// both implement [container.Partitioner], so code can switch between them.
+//
+// Members have weights, and the share of keys a member gets is proportional
+// to its weight, using the logarithmic method of Schindelhauer and Schomaker.
+// Hashing is deterministic by default, so tables with the same members map
+// keys the same way in different processes. Create tables with [New] or
+// [NewWithHash].
+//
+// [consistent hashing ring]: https://pkg.go.dev/github.com/eliben/gogl/consistenthash#Ring
+// [container.Partitioner]: https://pkg.go.dev/github.com/eliben/gogl/container#Partitioner
+type Table struct {
+	hash func(string) uint64
+
+	// members is sorted by name, so that ties in scores are broken the
+	// same way regardless of the order members were added in.
+	members []member
+}
+
+type member struct {
+	name   string
+	hash   uint64
+	weight int
+}
+
+// New creates a new, empty Table.
+func New() *Table {
+	return NewWithHash(hash.String)
+}
+
+// NewWithHash creates a new, empty Table like [New], hashing keys and
+// members with hash.
+func NewWithHash(hash func(string) uint64) *Table {
+	return &Table{hash: hash}
+}
+
+// Len returns the number of members in the table.
+func (t *Table) Len() int {
+	return len(t.members)
+}
+
+// Members returns the members of the table, sorted.
+func (t *Table) Members() []string {
+	ms := make([]string, len(t.members))
+	for i, m := range t.members {
+		ms[i] = m.name
+	}
+	return ms
+}
+
+// Weight returns the weight of member, or 0 if it's not in the table.
+func (t *Table) Weight(name string) int {
+	if i, ok := t.find(name); ok {
+		return t.members[i].weight
+	}
+	return 0
+}
+
+// Add adds a member to the table with the given positive weight. If the
+// member is already in the table, its weight is changed.
+func (t *Table) Add(name string, weight int) {
+	if weight <= 0 {
+		panic(fmt.Sprintf("rendezvous: invalid weight %d", weight))
+	}
+	i, ok := t.find(name)
+	if ok {
+		t.members[i].weight = weight
+		return
+	}
+	t.members = slices.Insert(t.members, i, member{name: name, hash: t.hash(name), weight: weight})
+}
+
+// Remove removes a member from the table, and reports whether it was in it.
+func (t *Table) Remove(name string) bool {
+	i, ok := t.find(name)
+	if ok {
+		t.members = slices.Delete(t.members, i, i+1)
+	}
+	return ok
+}
+
+func (t *Table) find(name string) (int, bool) {
+	return slices.BinarySearchFunc(t.members, name, func(m member, name string) int {
+		return cmp.Compare(m.name, name)
+	})
+}
+
+// Get returns the member key maps to. It returns false if the table is
+// empty.
+func (t *Table) Get(key string) (string, bool) {
+	if len(t.members) == 0 {
+		return "", false
+	}
+	kh := t.hash(key)
+	best, bestScore := 0, math.Inf(-1)
+	for i, m := range t.members {
+		if s := m.score(kh); s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	return t.members[best].name, true
+}
+
+// GetN returns up to n distinct members for key, in order of preference:
+// the member key maps to first, followed by the members scoring next
+// highest. These make a replica set for key that changes minimally as
+// members come and go.
+func (t *Table) GetN(key string, n int) []string {
+	n = min(n, len(t.members))
+	if n <= 0 {
+		return nil
+	}
+	type scored struct {
+		index int
+		score float64
+	}
+	kh := t.hash(key)
+	scores := make([]scored, len(t.members))
+	for i, m := range t.members {
+		scores[i] = scored{i, m.score(kh)}
+	}
+	// Sorting is fine for the small member sets rendezvous hashing is meant
+	// for; ties keep the members' order.
+	slices.SortStableFunc(scores, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	result := make([]string, n)
+	for i := range result {
+		result[i] = t.members[scores[i].index].name
+	}
+	return result
+}
+
+// score returns the score of m for a key with hash kh: -weight/ln(u),
+// where u is a uniform hash of the member and key in (0, 1). The member
+// with the highest score among all wins with probability proportional to
+// its weight.
+func (m *member) score(kh uint64) float64 {
+	h := hash.Mix(m.hash ^ kh)
+	u := (float64(h>>11) + 0.5) / (1 << 53)
+	return -float64(m.weight) / math.Log(u)
+}
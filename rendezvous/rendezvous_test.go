@@ -0,0 +1,171 @@
+package rendezvous
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/eliben/gogl/consistenthash"
+	"github.com/eliben/gogl/container"
+)
+
+func keys(n int) []string {
+	ks := make([]string, n)
+	for i := range ks {
+		ks[i] = fmt.Sprintf("key-%d", i)
+	}
+	return ks
+}
+
+func assign(s container.Partitioner, ks []string) map[string]string {
+	m := make(map[string]string, len(ks))
+	for _, k := range ks {
+		m[k], _ = s.Get(k)
+	}
+	return m
+}
+
+func TestBasic(t *testing.T) {
+	tb := New()
+	if _, ok := tb.Get("x"); ok || tb.GetN("x", 2) != nil || tb.Remove("a") {
+		t.Errorf("empty table misbehaves")
+	}
+	tb.Add("b", 1)
+	tb.Add("a", 2)
+	tb.Add("c", 1)
+	tb.Add("b", 3)
+	if tb.Len() != 3 || !slices.Equal(tb.Members(), []string{"a", "b", "c"}) {
+		t.Errorf("Members()=%v", tb.Members())
+	}
+	if tb.Weight("a") != 2 || tb.Weight("b") != 3 || tb.Weight("d") != 0 {
+		t.Errorf("weights: a=%d b=%d", tb.Weight("a"), tb.Weight("b"))
+	}
+	if !tb.Remove("c") || tb.Remove("c") || tb.Len() != 2 {
+		t.Errorf("Remove mismatch")
+	}
+}
+
+func TestBalance(t *testing.T) {
+	// Compare the balance with a consistent hashing ring of 100 replicas.
+	const n = 120000
+	ks := keys(n)
+	spread := func(s container.Partitioner) (lo, hi int) {
+		counts := make(map[string]int)
+		for _, m := range assign(s, ks) {
+			counts[m]++
+		}
+		lo, hi = n, 0
+		for _, c := range counts {
+			lo, hi = min(lo, c), max(hi, c)
+		}
+		return lo, hi
+	}
+	tb := New()
+	ring := consistenthash.New(100)
+	for i := range 12 {
+		tb.Add(fmt.Sprintf("node-%d", i), 1)
+		ring.Add(fmt.Sprintf("node-%d", i), 1)
+	}
+	lo, hi := spread(tb)
+	if lo < n/12*95/100 || hi > n/12*105/100 {
+		t.Errorf("shares between %d and %d, want about %d", lo, hi, n/12)
+	}
+	rlo, rhi := spread(ring)
+	if hi-lo >= rhi-rlo {
+		t.Errorf("spread %d-%d not better than ring's %d-%d", lo, hi, rlo, rhi)
+	}
+}
+
+func TestWeights(t *testing.T) {
+	tb := New()
+	weights := map[string]int{"a": 1, "b": 2, "c": 3, "d": 4}
+	for m, w := range weights {
+		tb.Add(m, w)
+	}
+	const n = 100000
+	counts := make(map[string]int)
+	for _, m := range assign(tb, keys(n)) {
+		counts[m]++
+	}
+	for m, w := range weights {
+		want := n * w / 10
+		if c := counts[m]; c < want*93/100 || c > want*107/100 {
+			t.Errorf("member %s (weight %d) got %d keys, want about %d", m, w, c, want)
+		}
+	}
+}
+
+func TestMinimalDisruption(t *testing.T) {
+	tb := New()
+	for i := range 8 {
+		tb.Add(fmt.Sprintf("node-%d", i), 1)
+	}
+	ks := keys(20000)
+	before := assign(tb, ks)
+
+	tb.Add("new", 2)
+	for k, m := range assign(tb, ks) {
+		if m != before[k] && m != "new" {
+			t.Fatalf("key %s moved from %s to %s", k, before[k], m)
+		}
+	}
+	tb.Remove("new")
+	if !maps.Equal(assign(tb, ks), before) {
+		t.Errorf("assignment changed after add and remove")
+	}
+
+	// A removed member's keys go to their second choice.
+	second := make(map[string]string)
+	for _, k := range ks {
+		if before[k] == "node-5" {
+			second[k] = tb.GetN(k, 2)[1]
+		}
+	}
+	tb.Remove("node-5")
+	for k, m := range assign(tb, ks) {
+		if before[k] == "node-5" {
+			if m != second[k] {
+				t.Fatalf("key %s moved to %s, want %s", k, m, second[k])
+			}
+		} else if m != before[k] {
+			t.Fatalf("key %s moved from %s to %s", k, before[k], m)
+		}
+	}
+}
+
+func TestGetN(t *testing.T) {
+	a, b := New(), New()
+	members := []string{"w", "x", "y", "z", "v"}
+	for _, m := range members {
+		a.Add(m, 1)
+	}
+	for _, m := range slices.Backward(members) {
+		b.Add(m, 1)
+	}
+	for _, k := range keys(500) {
+		got := a.GetN(k, 3)
+		first, _ := a.Get(k)
+		if len(got) != 3 || got[0] != first {
+			t.Fatalf("GetN(%s, 3)=%v, Get=%s", k, got, first)
+		}
+		if all := a.GetN(k, 10); len(all) != 5 || !slices.Equal(all[:3], got) {
+			t.Fatalf("GetN(%s, 10)=%v", k, all)
+		}
+		if !slices.Equal(b.GetN(k, 5), a.GetN(k, 5)) {
+			t.Fatalf("GetN(%s) depends on the order of adding members", k)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	tb := New()
+	for i := range 10 {
+		tb.Add(fmt.Sprintf("node-%d", i), 1)
+	}
+	ks := keys(1024)
+	b.ResetTimer()
+	for i := range b.N {
+		tb.Get(ks[i%len(ks)])
+	}
+}
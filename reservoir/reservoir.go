@@ -0,0 +1,249 @@
+// Package reservoir implements reservoir sampling: maintaining a random
+// sample of fixed size from a stream of unknown length.
+package reservoir
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// Both samplers in this package are "bottom-k" samplers: every item gets a
+// random key, and the sample is the k items with the smallest keys. The
+// keys make merging samples of different streams simple - the merged sample
+// is the k items with the smallest keys in both - and they're kept in a
+// max-heap so that the item with the largest key is the one replaced.
+
+type keyed[T any] struct {
+	key   float64
+	value T
+}
+
+// bottomK holds up to k items with the smallest keys.
+type bottomK[T any] struct {
+	k     int
+	items *heap.DAry[keyed[T]]
+}
+
+func newBottomK[T any](k int) bottomK[T] {
+	if k <= 0 {
+		panic(fmt.Sprintf("reservoir: invalid sample size %d", k))
+	}
+	return bottomK[T]{
+		k: k,
+		items: heap.NewDAry(4, func(a, b keyed[T]) int {
+			return cmp.Compare(b.key, a.key)
+		}),
+	}
+}
+
+func (b *bottomK[T]) full() bool {
+	return b.items.Len() == b.k
+}
+
+// maxKey returns the largest key in the sample; it must be full.
+func (b *bottomK[T]) maxKey() float64 {
+	return b.items.Peek().key
+}
+
+// offer adds the item with the given key to the sample if it's among the k
+// smallest.
+func (b *bottomK[T]) offer(key float64, v T) {
+	if !b.full() {
+		b.items.Push(keyed[T]{key, v})
+	} else if key < b.maxKey() {
+		b.items.Pop()
+		b.items.Push(keyed[T]{key, v})
+	}
+}
+
+func (b *bottomK[T]) merge(other *bottomK[T]) {
+	for it := range other.items.All() {
+		b.offer(it.key, it.value)
+	}
+}
+
+func (b *bottomK[T]) values() []T {
+	vs := make([]T, 0, b.items.Len())
+	for it := range b.items.All() {
+		vs = append(vs, it.value)
+	}
+	return vs
+}
+
+// Sampler maintains a uniform random sample of up to k items from a stream:
+// after n items were added, every subset of min(k, n) of them is equally
+// likely to be the sample.
+//
+// It uses Li's Algorithm L: rather than drawing a random number for every
+// item, it computes how many of the following items won't enter the sample,
+// so adding an item usually just decrements a counter and the number of
+// random draws grows only logarithmically with the stream's length.
+//
+// Samplers of different streams can be merged into a sample of the
+// combined stream. Create samplers with [New] or [NewWithRand].
+type Sampler[T any] struct {
+	sample bottomK[T]
+	rnd    *rand.Rand
+	count  int
+
+	// skip is the number of upcoming items that won't enter the sample;
+	// it's only meaningful when the sample is full.
+	skip int
+}
+
+// New creates a new, empty Sampler of up to k items.
+func New[T any](k int) *Sampler[T] {
+	return NewWithRand[T](k, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+}
+
+// NewWithRand creates a new, empty Sampler of up to k items, drawing random
+// numbers from rnd.
+func NewWithRand[T any](k int, rnd *rand.Rand) *Sampler[T] {
+	return &Sampler[T]{sample: newBottomK[T](k), rnd: rnd}
+}
+
+// K returns the maximal size of the sample.
+func (s *Sampler[T]) K() int {
+	return s.sample.k
+}
+
+// Count returns the number of items added to the sampler.
+func (s *Sampler[T]) Count() int {
+	return s.count
+}
+
+// Add adds v to the stream being sampled.
+func (s *Sampler[T]) Add(v T) {
+	s.count++
+	switch {
+	case !s.sample.full():
+		s.sample.offer(uniform(s.rnd), v)
+		if s.sample.full() {
+			s.computeSkip()
+		}
+	case s.skip > 0:
+		s.skip--
+	default:
+		// v enters the sample; conditioned on that, its key is uniform
+		// below the largest key in the sample.
+		s.sample.offer(s.sample.maxKey()*s.rnd.Float64(), v)
+		s.computeSkip()
+	}
+}
+
+// computeSkip draws the number of items to skip: each item enters the
+// sample if its key is below the largest key w in the sample, which has
+// probability w, so the number of items until one does is geometric.
+func (s *Sampler[T]) computeSkip() {
+	w := s.sample.maxKey()
+	skip := math.Floor(math.Log(uniform(s.rnd)) / math.Log1p(-w))
+	s.skip = int(min(skip, math.MaxInt32))
+}
+
+// Sample returns the items in the sample, in unspecified order.
+func (s *Sampler[T]) Sample() []T {
+	return s.sample.values()
+}
+
+// Merge adds the stream sampled by other to the stream sampled by s, so
+// that s holds a uniform sample of the combined stream. The samplers must
+// have the same k. Merging s with itself is a no-op.
+func (s *Sampler[T]) Merge(other *Sampler[T]) {
+	if s == other {
+		return
+	}
+	if s.sample.k != other.sample.k {
+		panic("reservoir: merging samplers of different sizes")
+	}
+	s.sample.merge(&other.sample)
+	s.count += other.count
+	if s.sample.full() {
+		// The skip count is drawn afresh for the new largest key; since it's
+		// geometric, discarding the old one doesn't bias the sample.
+		s.computeSkip()
+	}
+}
+
+// Weighted maintains a weighted random sample of up to k items from a
+// stream, chosen without replacement with probabilities proportional to the
+// items' weights: the sample is as if items were drawn one at a time, each
+// with probability proportional to its weight among the items not drawn
+// yet. It's the A-Res algorithm of Efraimidis and Spirakis, with keys
+// drawn from an exponential distribution with rate equal to the weight.
+//
+// Weighted samplers of different streams can be merged into a sample of the
+// combined stream. Create samplers with [NewWeighted] or
+// [NewWeightedWithRand].
+type Weighted[T any] struct {
+	sample      bottomK[T]
+	rnd         *rand.Rand
+	count       int
+	totalWeight float64
+}
+
+// NewWeighted creates a new, empty Weighted sampler of up to k items.
+func NewWeighted[T any](k int) *Weighted[T] {
+	return NewWeightedWithRand[T](k, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+}
+
+// NewWeightedWithRand creates a new, empty Weighted sampler of up to k
+// items, drawing random numbers from rnd.
+func NewWeightedWithRand[T any](k int, rnd *rand.Rand) *Weighted[T] {
+	return &Weighted[T]{sample: newBottomK[T](k), rnd: rnd}
+}
+
+// K returns the maximal size of the sample.
+func (w *Weighted[T]) K() int {
+	return w.sample.k
+}
+
+// Count returns the number of items added to the sampler.
+func (w *Weighted[T]) Count() int {
+	return w.count
+}
+
+// TotalWeight returns the sum of the weights of the items added to the
+// sampler.
+func (w *Weighted[T]) TotalWeight() float64 {
+	return w.totalWeight
+}
+
+// Add adds v with the given weight to the stream being sampled. The weight
+// must be positive.
+func (w *Weighted[T]) Add(v T, weight float64) {
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		panic(fmt.Sprintf("reservoir: invalid weight %v", weight))
+	}
+	w.count++
+	w.totalWeight += weight
+	w.sample.offer(w.rnd.ExpFloat64()/weight, v)
+}
+
+// Sample returns the items in the sample, in unspecified order.
+func (w *Weighted[T]) Sample() []T {
+	return w.sample.values()
+}
+
+// Merge adds the stream sampled by other to the stream sampled by w, so
+// that w holds a weighted sample of the combined stream. The samplers must
+// have the same k. Merging w with itself is a no-op.
+func (w *Weighted[T]) Merge(other *Weighted[T]) {
+	if w == other {
+		return
+	}
+	if w.sample.k != other.sample.k {
+		panic("reservoir: merging samplers of different sizes")
+	}
+	w.sample.merge(&other.sample)
+	w.count += other.count
+	w.totalWeight += other.totalWeight
+}
+
+// uniform returns a random number in (0, 1].
+func uniform(rnd *rand.Rand) float64 {
+	return 1 - rnd.Float64()
+}
@@ -0,0 +1,203 @@
+package reservoir
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// checkFrequency checks that item i was sampled about want[i] times; the
+// tolerance is 5 standard deviations of a binomial count.
+func checkFrequency(t *testing.T, trials int, got []int, want []float64) {
+	t.Helper()
+	for i, g := range got {
+		p := want[i] / float64(trials)
+		sd := math.Sqrt(float64(trials) * p * (1 - p))
+		if math.Abs(float64(g)-want[i]) > 5*sd+1 {
+			t.Errorf("item %d sampled %d times, want about %.0f", i, g, want[i])
+		}
+	}
+}
+
+func TestSmallStream(t *testing.T) {
+	s := New[int](10)
+	for i := range 7 {
+		s.Add(i)
+	}
+	got := s.Sample()
+	slices.Sort(got)
+	if !slices.Equal(got, []int{0, 1, 2, 3, 4, 5, 6}) || s.Count() != 7 || s.K() != 10 {
+		t.Errorf("Sample()=%v, Count=%d", got, s.Count())
+	}
+}
+
+func TestUniform(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, n := range []int{20, 1000} {
+		const k, trials = 5, 20000
+		counts := make([]int, n)
+		for range trials {
+			s := NewWithRand[int](k, rnd)
+			for i := range n {
+				s.Add(i)
+			}
+			sample := s.Sample()
+			if len(sample) != k {
+				t.Fatalf("sample size %d", len(sample))
+			}
+			for _, v := range sample {
+				counts[v]++
+			}
+		}
+		if n > 100 {
+			// Group items into buckets of 50 to get meaningful counts.
+			buckets := make([]int, n/50)
+			for i, c := range counts {
+				buckets[i/50] += c
+			}
+			counts = buckets
+		}
+		want := make([]float64, len(counts))
+		for i := range want {
+			want[i] = trials * k / float64(len(counts))
+		}
+		checkFrequency(t, trials, counts, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const k, trials = 4, 20000
+	// Streams of very different lengths; every item of the combined stream
+	// must be equally likely to be sampled.
+	counts := make([]int, 40)
+	for range trials {
+		a, b := NewWithRand[int](k, rnd), NewWithRand[int](k, rnd)
+		for i := range 10 {
+			a.Add(i)
+		}
+		for i := 10; i < 40; i++ {
+			b.Add(i)
+		}
+		a.Merge(b)
+		// Keep adding after the merge.
+		a.Add(-1)
+		for _, v := range a.Sample() {
+			if v >= 0 {
+				counts[v]++
+			}
+		}
+		if a.Count() != 41 {
+			t.Fatalf("Count=%d after merge", a.Count())
+		}
+	}
+	want := make([]float64, len(counts))
+	for i := range want {
+		want[i] = trials * k / 41.0
+	}
+	checkFrequency(t, trials, counts, want)
+}
+
+func TestMergeSelf(t *testing.T) {
+	s := New[int](4)
+	w := NewWeighted[int](4)
+	for i := range 10 {
+		s.Add(i)
+		w.Add(i, 1)
+	}
+	sample, weighted := slices.Sorted(slices.Values(s.Sample())), slices.Sorted(slices.Values(w.Sample()))
+	s.Merge(s)
+	w.Merge(w)
+	if got := slices.Sorted(slices.Values(s.Sample())); !slices.Equal(got, sample) || s.Count() != 10 {
+		t.Errorf("Sample()=%v, Count=%d after self-merge, want %v, 10", got, s.Count(), sample)
+	}
+	if got := slices.Sorted(slices.Values(w.Sample())); !slices.Equal(got, weighted) {
+		t.Errorf("weighted Sample()=%v after self-merge, want %v", got, weighted)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const trials = 30000
+	weights := []float64{1, 2, 3, 4, 10}
+
+	// With k=1, each item is sampled with probability proportional to its
+	// weight. Split the stream in two and merge to exercise Merge too.
+	counts := make([]int, len(weights))
+	for range trials {
+		a, b := NewWeightedWithRand[int](1, rnd), NewWeightedWithRand[int](1, rnd)
+		for i, w := range weights {
+			if i%2 == 0 {
+				a.Add(i, w)
+			} else {
+				b.Add(i, w)
+			}
+		}
+		a.Merge(b)
+		counts[a.Sample()[0]]++
+	}
+	want := make([]float64, len(weights))
+	for i, w := range weights {
+		want[i] = trials * w / 20
+	}
+	checkFrequency(t, trials, counts, want)
+
+	w := NewWeighted[string](3)
+	w.Add("a", 0.5)
+	w.Add("b", 1.5)
+	if w.Count() != 2 || w.TotalWeight() != 2 || w.K() != 3 || len(w.Sample()) != 2 {
+		t.Errorf("Count=%d TotalWeight=%v Sample=%v", w.Count(), w.TotalWeight(), w.Sample())
+	}
+}
+
+func TestWeightedWithoutReplacement(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const trials = 30000
+	// Sampling 2 of weights {1, 1, 2}: item 2 is drawn first with
+	// probability 1/2, and second with probability 2*(1/4)*(2/3) = 1/3.
+	counts := make([]int, 3)
+	for range trials {
+		w := NewWeightedWithRand[int](2, rnd)
+		for i, wt := range []float64{1, 1, 2} {
+			w.Add(i, wt)
+		}
+		for _, v := range w.Sample() {
+			counts[v]++
+		}
+	}
+	want := []float64{trials * 7 / 12.0, trials * 7 / 12.0, trials * 5 / 6.0}
+	checkFrequency(t, trials, counts, want)
+}
+
+func TestInvalid(t *testing.T) {
+	for name, f := range map[string]func(){
+		"zero k":      func() { New[int](0) },
+		"zero weight": func() { NewWeighted[int](1).Add(1, 0) },
+		"NaN weight":  func() { NewWeighted[int](1).Add(1, math.NaN()) },
+		"merge sizes": func() { New[int](1).Merge(New[int](2)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	s := New[int](100)
+	for i := range b.N {
+		s.Add(i)
+	}
+}
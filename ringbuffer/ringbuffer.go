@@ -0,0 +1,139 @@
+// Package ringbuffer implements a fixed-capacity circular buffer.
+package ringbuffer
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Mode determines how a RingBuffer behaves when pushing into it while it's
+// full.
+type Mode int
+
+const (
+	// Reject makes Push fail (and leave the buffer unchanged) when the buffer
+	// is full.
+	Reject Mode = iota
+
+	// Overwrite makes Push overwrite the oldest element when the buffer is
+	// full.
+	Overwrite
+)
+
+// RingBuffer is a fixed-capacity FIFO buffer. Create new buffers with [New].
+type RingBuffer[T any] struct {
+	mode Mode
+
+	// buf holds the elements; the oldest is at buf[head], and there are
+	// length elements in total, wrapping around the end of buf.
+	buf    []T
+	head   int
+	length int
+}
+
+// New creates a new, empty RingBuffer with the given capacity and mode. It
+// panics if capacity is not positive.
+func New[T any](capacity int, mode Mode) *RingBuffer[T] {
+	if capacity <= 0 {
+		panic(fmt.Sprintf("invalid ring buffer capacity %d", capacity))
+	}
+	return &RingBuffer[T]{mode: mode, buf: make([]T, capacity)}
+}
+
+// Len returns the number of elements in the buffer.
+func (rb *RingBuffer[T]) Len() int {
+	return rb.length
+}
+
+// Cap returns the capacity of the buffer.
+func (rb *RingBuffer[T]) Cap() int {
+	return len(rb.buf)
+}
+
+// Full reports whether the buffer is full.
+func (rb *RingBuffer[T]) Full() bool {
+	return rb.length == len(rb.buf)
+}
+
+// Push adds v as the newest element in the buffer. If the buffer is full, the
+// behavior depends on the buffer's mode: in Reject mode, Push returns false
+// without modifying the buffer; in Overwrite mode, the oldest element is
+// discarded to make room for v. Push returns true if v was added.
+func (rb *RingBuffer[T]) Push(v T) bool {
+	if rb.Full() {
+		if rb.mode == Reject {
+			return false
+		}
+		rb.buf[rb.head] = v
+		rb.head = rb.wrap(rb.head + 1)
+		return true
+	}
+	rb.buf[rb.wrap(rb.head+rb.length)] = v
+	rb.length++
+	return true
+}
+
+// Pop removes the oldest element from the buffer and returns it. It panics
+// if the buffer is empty; make sure to check Len() first.
+func (rb *RingBuffer[T]) Pop() T {
+	if rb.length == 0 {
+		panic("popping from empty ring buffer")
+	}
+	v := rb.buf[rb.head]
+	rb.buf[rb.head] = *new(T)
+	rb.head = rb.wrap(rb.head + 1)
+	rb.length--
+	return v
+}
+
+// Oldest returns the oldest element in the buffer. It panics if the buffer is
+// empty.
+func (rb *RingBuffer[T]) Oldest() T {
+	if rb.length == 0 {
+		panic("Oldest of empty ring buffer")
+	}
+	return rb.buf[rb.head]
+}
+
+// Newest returns the newest element in the buffer. It panics if the buffer is
+// empty.
+func (rb *RingBuffer[T]) Newest() T {
+	if rb.length == 0 {
+		panic("Newest of empty ring buffer")
+	}
+	return rb.buf[rb.wrap(rb.head+rb.length-1)]
+}
+
+// Clear removes all elements from the buffer.
+func (rb *RingBuffer[T]) Clear() {
+	clear(rb.buf)
+	rb.head = 0
+	rb.length = 0
+}
+
+// All returns an iterator over the elements of the buffer, from oldest to
+// newest. The iterator works on a snapshot of the buffer taken when All is
+// called, so the buffer may be modified during iteration.
+func (rb *RingBuffer[T]) All() iter.Seq[T] {
+	snap := rb.Snapshot()
+	return func(yield func(T) bool) {
+		for _, v := range snap {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Snapshot returns a new slice with the elements of the buffer, from oldest
+// to newest.
+func (rb *RingBuffer[T]) Snapshot() []T {
+	snap := make([]T, rb.length)
+	n := copy(snap, rb.buf[rb.head:min(rb.head+rb.length, len(rb.buf))])
+	copy(snap[n:], rb.buf[:rb.length-n])
+	return snap
+}
+
+func (rb *RingBuffer[T]) wrap(i int) int {
+	return i % len(rb.buf)
+}
@@ -0,0 +1,97 @@
+package ringbuffer
+
+import (
+	"slices"
+	"testing"
+)
+
+func checkBuffer(t *testing.T, rb *RingBuffer[int], want []int) {
+	t.Helper()
+	if rb.Len() != len(want) {
+		t.Errorf("got len=%v, want %v", rb.Len(), len(want))
+	}
+	if got := rb.Snapshot(); !slices.Equal(got, want) {
+		t.Errorf("got snapshot %v, want %v", got, want)
+	}
+	if got := slices.Collect(rb.All()); !slices.Equal(got, want) {
+		t.Errorf("got All %v, want %v", got, want)
+	}
+}
+
+func TestReject(t *testing.T) {
+	rb := New[int](3, Reject)
+	for i := range 3 {
+		if !rb.Push(i) {
+			t.Errorf("push %d rejected", i)
+		}
+	}
+	checkBuffer(t, rb, []int{0, 1, 2})
+	if !rb.Full() {
+		t.Errorf("expected full buffer")
+	}
+	if rb.Push(3) {
+		t.Errorf("push into full buffer succeeded")
+	}
+	checkBuffer(t, rb, []int{0, 1, 2})
+
+	if v := rb.Pop(); v != 0 {
+		t.Errorf("got pop=%v, want 0", v)
+	}
+	rb.Push(3)
+	checkBuffer(t, rb, []int{1, 2, 3})
+	if rb.Oldest() != 1 || rb.Newest() != 3 {
+		t.Errorf("got oldest=%v, newest=%v; want 1, 3", rb.Oldest(), rb.Newest())
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+	rb := New[int](4, Overwrite)
+	checkBuffer(t, rb, []int{})
+	for i := range 10 {
+		if !rb.Push(i) {
+			t.Errorf("push %d rejected", i)
+		}
+	}
+	checkBuffer(t, rb, []int{6, 7, 8, 9})
+	if rb.Cap() != 4 {
+		t.Errorf("got cap=%v, want 4", rb.Cap())
+	}
+
+	rb.Pop()
+	rb.Pop()
+	checkBuffer(t, rb, []int{8, 9})
+	rb.Push(10)
+	rb.Push(11)
+	rb.Push(12)
+	checkBuffer(t, rb, []int{9, 10, 11, 12})
+
+	rb.Clear()
+	checkBuffer(t, rb, []int{})
+	rb.Push(1)
+	checkBuffer(t, rb, []int{1})
+}
+
+func TestAllIsSnapshot(t *testing.T) {
+	rb := New[int](3, Overwrite)
+	rb.Push(1)
+	rb.Push(2)
+	rb.Push(3)
+	var got []int
+	for v := range rb.All() {
+		got = append(got, v)
+		rb.Push(v * 10)
+	}
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("got %v", got)
+	}
+	checkBuffer(t, rb, []int{10, 20, 30})
+}
+
+func TestBadCapacity(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	New[int](0, Reject)
+}
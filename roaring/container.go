@@ -0,0 +1,551 @@
+package roaring
+
+import (
+	"math/bits"
+	"slices"
+	"sort"
+)
+
+// This file implements the containers holding the low 16 bits of the
+// values in a bitmap that share their high 16 bits. There are three kinds:
+//
+//   - array containers hold up to arrayMaxSize values in a sorted slice;
+//   - bitmap containers hold more than arrayMaxSize values in a fixed array
+//     of 2^16 bits;
+//   - run containers hold sorted runs of consecutive values; they are only
+//     created by Bitmap.RunOptimize and by deserialization, when they are
+//     smaller than the other kinds.
+//
+// Operations that change a container return the container to use from then
+// on, which may be of a different kind; operations that produce an empty
+// container return nil.
+
+const (
+	arrayMaxSize = 4096
+	bitmapWords  = 1 << 16 / 64
+)
+
+type container interface {
+	card() int
+	contains(x uint16) bool
+	add(x uint16) (container, bool)
+	remove(x uint16) (container, bool)
+
+	// rank returns the number of values <= x.
+	rank(x uint16) int
+
+	// selectAt returns the i-th smallest value, counting from 0.
+	selectAt(i int) uint16
+
+	all(yield func(uint16) bool) bool
+
+	// bitmap returns a bitmap container with the same values. For bitmap
+	// containers it returns the container itself, so the result must not
+	// be modified.
+	bitmap() *bitmapContainer
+
+	clone() container
+	numRuns() int
+}
+
+// newArrayOrBitmap returns a container holding the sorted values.
+func newArrayOrBitmap(values []uint16) container {
+	switch {
+	case len(values) == 0:
+		return nil
+	case len(values) <= arrayMaxSize:
+		return &arrayContainer{values}
+	}
+	return (&arrayContainer{values}).bitmap()
+}
+
+type arrayContainer struct {
+	values []uint16
+}
+
+func (c *arrayContainer) card() int {
+	return len(c.values)
+}
+
+func (c *arrayContainer) contains(x uint16) bool {
+	_, ok := slices.BinarySearch(c.values, x)
+	return ok
+}
+
+func (c *arrayContainer) add(x uint16) (container, bool) {
+	i, ok := slices.BinarySearch(c.values, x)
+	if ok {
+		return c, false
+	}
+	if len(c.values) == arrayMaxSize {
+		b := c.bitmap()
+		b.add(x)
+		return b, true
+	}
+	c.values = slices.Insert(c.values, i, x)
+	return c, true
+}
+
+func (c *arrayContainer) remove(x uint16) (container, bool) {
+	i, ok := slices.BinarySearch(c.values, x)
+	if !ok {
+		return c, false
+	}
+	c.values = slices.Delete(c.values, i, i+1)
+	return c, true
+}
+
+func (c *arrayContainer) rank(x uint16) int {
+	i, ok := slices.BinarySearch(c.values, x)
+	if ok {
+		i++
+	}
+	return i
+}
+
+func (c *arrayContainer) selectAt(i int) uint16 {
+	return c.values[i]
+}
+
+func (c *arrayContainer) all(yield func(uint16) bool) bool {
+	for _, v := range c.values {
+		if !yield(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *arrayContainer) bitmap() *bitmapContainer {
+	b := &bitmapContainer{n: len(c.values)}
+	for _, v := range c.values {
+		b.words[v/64] |= 1 << (v % 64)
+	}
+	return b
+}
+
+func (c *arrayContainer) clone() container {
+	return &arrayContainer{slices.Clone(c.values)}
+}
+
+func (c *arrayContainer) numRuns() int {
+	runs := 0
+	for i, v := range c.values {
+		if i == 0 || v != c.values[i-1]+1 {
+			runs++
+		}
+	}
+	return runs
+}
+
+// filter returns a container with the values of c that other contains if
+// keep is true, or doesn't contain if keep is false.
+func (c *arrayContainer) filter(other container, keep bool) container {
+	var values []uint16
+	for _, v := range c.values {
+		if other.contains(v) == keep {
+			values = append(values, v)
+		}
+	}
+	return newArrayOrBitmap(values)
+}
+
+type bitmapContainer struct {
+	words [bitmapWords]uint64
+
+	// n is the number of set bits.
+	n int
+}
+
+func (c *bitmapContainer) card() int {
+	return c.n
+}
+
+func (c *bitmapContainer) contains(x uint16) bool {
+	return c.words[x/64]&(1<<(x%64)) != 0
+}
+
+func (c *bitmapContainer) add(x uint16) (container, bool) {
+	if c.contains(x) {
+		return c, false
+	}
+	c.words[x/64] |= 1 << (x % 64)
+	c.n++
+	return c, true
+}
+
+func (c *bitmapContainer) remove(x uint16) (container, bool) {
+	if !c.contains(x) {
+		return c, false
+	}
+	c.words[x/64] &^= 1 << (x % 64)
+	c.n--
+	if c.n <= arrayMaxSize {
+		return c.toArray(), true
+	}
+	return c, true
+}
+
+func (c *bitmapContainer) rank(x uint16) int {
+	r := 0
+	for _, w := range c.words[:x/64] {
+		r += bits.OnesCount64(w)
+	}
+	// The mask covers bits 0 to x%64; for x%64 == 63 the shift overflows to
+	// 0 and the mask has all bits set.
+	return r + bits.OnesCount64(c.words[x/64]&(uint64(2)<<(x%64)-1))
+}
+
+func (c *bitmapContainer) selectAt(i int) uint16 {
+	for wi, w := range c.words {
+		count := bits.OnesCount64(w)
+		if i >= count {
+			i -= count
+			continue
+		}
+		for range i {
+			w &= w - 1
+		}
+		return uint16(wi*64 + bits.TrailingZeros64(w))
+	}
+	panic("unreachable")
+}
+
+func (c *bitmapContainer) all(yield func(uint16) bool) bool {
+	for wi, w := range c.words {
+		for w != 0 {
+			if !yield(uint16(wi*64 + bits.TrailingZeros64(w))) {
+				return false
+			}
+			w &= w - 1
+		}
+	}
+	return true
+}
+
+func (c *bitmapContainer) bitmap() *bitmapContainer {
+	return c
+}
+
+func (c *bitmapContainer) clone() container {
+	cp := *c
+	return &cp
+}
+
+func (c *bitmapContainer) numRuns() int {
+	// A run starts at every set bit whose preceding bit is clear.
+	runs := 0
+	var carry uint64
+	for _, w := range c.words {
+		runs += bits.OnesCount64(w &^ (w<<1 | carry))
+		carry = w >> 63
+	}
+	return runs
+}
+
+// normalize recounts the set bits after c's words were changed directly,
+// and returns a container with c's values of the proper kind.
+func (c *bitmapContainer) normalize() container {
+	c.n = 0
+	for _, w := range c.words {
+		c.n += bits.OnesCount64(w)
+	}
+	switch {
+	case c.n == 0:
+		return nil
+	case c.n <= arrayMaxSize:
+		return c.toArray()
+	}
+	return c
+}
+
+func (c *bitmapContainer) toArray() *arrayContainer {
+	values := make([]uint16, 0, c.n)
+	c.all(func(v uint16) bool {
+		values = append(values, v)
+		return true
+	})
+	return &arrayContainer{values}
+}
+
+// interval is a run of consecutive values [start, last].
+type interval struct {
+	start, last uint16
+}
+
+type runContainer struct {
+	runs []interval
+
+	// n is the number of values in all runs.
+	n int
+}
+
+func (c *runContainer) card() int {
+	return c.n
+}
+
+// find returns the index of the last run starting at or before x, or -1 if
+// there's none.
+func (c *runContainer) find(x uint16) int {
+	return sort.Search(len(c.runs), func(i int) bool {
+		return c.runs[i].start > x
+	}) - 1
+}
+
+func (c *runContainer) contains(x uint16) bool {
+	i := c.find(x)
+	return i >= 0 && x <= c.runs[i].last
+}
+
+func (c *runContainer) add(x uint16) (container, bool) {
+	i := c.find(x)
+	if i >= 0 && x <= c.runs[i].last {
+		return c, false
+	}
+	c.n++
+	extendPrev := i >= 0 && int(c.runs[i].last)+1 == int(x)
+	extendNext := i+1 < len(c.runs) && int(c.runs[i+1].start) == int(x)+1
+	switch {
+	case extendPrev && extendNext:
+		c.runs[i].last = c.runs[i+1].last
+		c.runs = slices.Delete(c.runs, i+1, i+2)
+	case extendPrev:
+		c.runs[i].last = x
+	case extendNext:
+		c.runs[i+1].start = x
+	default:
+		c.runs = slices.Insert(c.runs, i+1, interval{x, x})
+		// Adding isolated values may make runs the worst representation.
+		if 2+4*len(c.runs) > plainSize(c.n) {
+			return toPlain(c), true
+		}
+	}
+	return c, true
+}
+
+func (c *runContainer) remove(x uint16) (container, bool) {
+	i := c.find(x)
+	if i < 0 || x > c.runs[i].last {
+		return c, false
+	}
+	c.n--
+	r := &c.runs[i]
+	switch {
+	case r.start == r.last:
+		c.runs = slices.Delete(c.runs, i, i+1)
+	case x == r.start:
+		r.start++
+	case x == r.last:
+		r.last--
+	default:
+		right := interval{x + 1, r.last}
+		r.last = x - 1
+		c.runs = slices.Insert(c.runs, i+1, right)
+	}
+	return c, true
+}
+
+func (c *runContainer) rank(x uint16) int {
+	i := c.find(x)
+	if i < 0 {
+		return 0
+	}
+	r := 0
+	for _, run := range c.runs[:i] {
+		r += int(run.last-run.start) + 1
+	}
+	return r + int(min(x, c.runs[i].last)-c.runs[i].start) + 1
+}
+
+func (c *runContainer) selectAt(i int) uint16 {
+	for _, run := range c.runs {
+		if length := int(run.last-run.start) + 1; i >= length {
+			i -= length
+		} else {
+			return run.start + uint16(i)
+		}
+	}
+	panic("unreachable")
+}
+
+func (c *runContainer) all(yield func(uint16) bool) bool {
+	for _, run := range c.runs {
+		for v := int(run.start); v <= int(run.last); v++ {
+			if !yield(uint16(v)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (c *runContainer) bitmap() *bitmapContainer {
+	b := &bitmapContainer{n: c.n}
+	for _, run := range c.runs {
+		lo, hi := int(run.start), int(run.last)+1
+		for lo < hi {
+			// Set the bits [lo, end) within the word of lo; for a whole word,
+			// the shift overflows to 0 and the mask has all bits set.
+			end := min(hi, lo/64*64+64)
+			b.words[lo/64] |= (uint64(1)<<(end-lo) - 1) << (lo % 64)
+			lo = end
+		}
+	}
+	return b
+}
+
+func (c *runContainer) clone() container {
+	return &runContainer{slices.Clone(c.runs), c.n}
+}
+
+func (c *runContainer) numRuns() int {
+	return len(c.runs)
+}
+
+// plainSize returns the serialized size in bytes of an array or bitmap
+// container holding n values.
+func plainSize(n int) int {
+	if n <= arrayMaxSize {
+		return 2 * n
+	}
+	return 2 * bitmapWords * 4
+}
+
+// toPlain returns an array or bitmap container with the values of c.
+func toPlain(c container) container {
+	if c.card() > arrayMaxSize {
+		return c.bitmap()
+	}
+	values := make([]uint16, 0, c.card())
+	c.all(func(v uint16) bool {
+		values = append(values, v)
+		return true
+	})
+	return &arrayContainer{values}
+}
+
+// toRun returns a run container with the values of c.
+func toRun(c container) *runContainer {
+	r := &runContainer{runs: make([]interval, 0, c.numRuns()), n: c.card()}
+	c.all(func(v uint16) bool {
+		if n := len(r.runs); n > 0 && int(r.runs[n-1].last)+1 == int(v) {
+			r.runs[n-1].last = v
+		} else {
+			r.runs = append(r.runs, interval{v, v})
+		}
+		return true
+	})
+	return r
+}
+
+// optimize returns the smallest representation of c.
+func optimize(c container) container {
+	if 2+4*c.numRuns() < plainSize(c.card()) {
+		if _, ok := c.(*runContainer); ok {
+			return c
+		}
+		return toRun(c)
+	}
+	if _, ok := c.(*runContainer); ok {
+		return toPlain(c)
+	}
+	return c
+}
+
+// copyBitmap returns a new bitmap container with the values of c.
+func copyBitmap(c container) *bitmapContainer {
+	if b, ok := c.(*bitmapContainer); ok {
+		cp := *b
+		return &cp
+	}
+	return c.bitmap()
+}
+
+// The functions below implement set operations on containers, returning
+// new containers and not modifying their arguments.
+
+func containerOr(a, b container) container {
+	if x, ok := a.(*arrayContainer); ok {
+		if y, ok := b.(*arrayContainer); ok && x.card()+y.card() <= arrayMaxSize {
+			values := make([]uint16, 0, x.card()+y.card())
+			i, j := 0, 0
+			for i < len(x.values) && j < len(y.values) {
+				switch u, v := x.values[i], y.values[j]; {
+				case u < v:
+					values = append(values, u)
+					i++
+				case u > v:
+					values = append(values, v)
+					j++
+				default:
+					values = append(values, u)
+					i++
+					j++
+				}
+			}
+			values = append(values, x.values[i:]...)
+			values = append(values, y.values[j:]...)
+			return &arrayContainer{values}
+		}
+	}
+	r := copyBitmap(a)
+	for i, w := range b.bitmap().words {
+		r.words[i] |= w
+	}
+	return r.normalize()
+}
+
+func containerAnd(a, b container) container {
+	if x, ok := a.(*arrayContainer); ok {
+		return x.filter(b, true)
+	}
+	if y, ok := b.(*arrayContainer); ok {
+		return y.filter(a, true)
+	}
+	r := copyBitmap(a)
+	for i, w := range b.bitmap().words {
+		r.words[i] &= w
+	}
+	return r.normalize()
+}
+
+func containerAndNot(a, b container) container {
+	if x, ok := a.(*arrayContainer); ok {
+		return x.filter(b, false)
+	}
+	r := copyBitmap(a)
+	for i, w := range b.bitmap().words {
+		r.words[i] &^= w
+	}
+	return r.normalize()
+}
+
+func containerXor(a, b container) container {
+	if x, ok := a.(*arrayContainer); ok {
+		if y, ok := b.(*arrayContainer); ok {
+			var values []uint16
+			i, j := 0, 0
+			for i < len(x.values) && j < len(y.values) {
+				switch u, v := x.values[i], y.values[j]; {
+				case u < v:
+					values = append(values, u)
+					i++
+				case u > v:
+					values = append(values, v)
+					j++
+				default:
+					i++
+					j++
+				}
+			}
+			values = append(values, x.values[i:]...)
+			values = append(values, y.values[j:]...)
+			return newArrayOrBitmap(values)
+		}
+	}
+	r := copyBitmap(a)
+	for i, w := range b.bitmap().words {
+		r.words[i] ^= w
+	}
+	return r.normalize()
+}
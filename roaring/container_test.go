@@ -0,0 +1,130 @@
+package roaring
+
+import (
+	"slices"
+	"testing"
+)
+
+// containersOf returns containers of all kinds holding values, which must
+// be sorted.
+func containersOf(values []uint16) []container {
+	a := &arrayContainer{slices.Clone(values)}
+	return []container{a, a.bitmap(), toRun(a)}
+}
+
+func collect(c container) []uint16 {
+	var values []uint16
+	c.all(func(v uint16) bool {
+		values = append(values, v)
+		return true
+	})
+	return values
+}
+
+func TestContainerKinds(t *testing.T) {
+	values := []uint16{0, 1, 2, 3, 10, 63, 64, 65, 200, 1000, 1001, 65534, 65535}
+	for _, c := range containersOf(values) {
+		if c.card() != len(values) || c.numRuns() != 6 {
+			t.Errorf("%T: card=%d numRuns=%d", c, c.card(), c.numRuns())
+		}
+		if got := collect(c); !slices.Equal(got, values) {
+			t.Errorf("%T: all = %v", c, got)
+		}
+		if got := collect(c.bitmap()); !slices.Equal(got, values) {
+			t.Errorf("%T: bitmap = %v", c, got)
+		}
+		for i, v := range values {
+			if !c.contains(v) || c.rank(v) != i+1 || c.selectAt(i) != v {
+				t.Errorf("%T: contains/rank/select of %d", c, v)
+			}
+		}
+		if c.contains(4) || c.rank(4) != 4 || c.rank(999) != 9 {
+			t.Errorf("%T: bad contains/rank of missing values", c)
+		}
+	}
+}
+
+func TestRunContainer(t *testing.T) {
+	var c container = &runContainer{runs: []interval{{100, 199}}, n: 100}
+	for _, v := range []uint16{5, 7, 6, 65535, 4, 9, 65534, 99, 200} {
+		c, _ = c.add(v)
+	}
+	if r := c.(*runContainer).runs; !slices.Equal(r, []interval{{4, 7}, {9, 9}, {99, 200}, {65534, 65535}}) {
+		t.Errorf("runs = %v", r)
+	}
+	if _, added := c.add(6); added {
+		t.Errorf("added existing value")
+	}
+	c, _ = c.remove(5)
+	c, _ = c.remove(65535)
+	c, _ = c.remove(150)
+	if r := c.(*runContainer).runs; !slices.Equal(r, []interval{{4, 4}, {6, 7}, {9, 9}, {99, 149}, {151, 200}, {65534, 65534}}) {
+		t.Errorf("runs = %v", r)
+	}
+	if _, removed := c.remove(5); removed {
+		t.Errorf("removed missing value")
+	}
+
+	// Many isolated values turn a run container into an array.
+	for v := range uint16(100) {
+		c, _ = c.add(1000 + 2*v)
+	}
+	if _, ok := c.(*arrayContainer); !ok || c.card() != 206 {
+		t.Errorf("got %T with %d values", c, c.card())
+	}
+}
+
+func TestContainerConversions(t *testing.T) {
+	// An array turns into a bitmap when it overflows, and back when values
+	// are removed.
+	var c container = &arrayContainer{}
+	for v := range uint16(arrayMaxSize + 1) {
+		c, _ = c.add(v * 3)
+	}
+	if _, ok := c.(*bitmapContainer); !ok {
+		t.Fatalf("got %T after %d adds", c, arrayMaxSize+1)
+	}
+	c, _ = c.remove(0)
+	if _, ok := c.(*arrayContainer); !ok || c.card() != arrayMaxSize {
+		t.Fatalf("got %T with %d values after remove", c, c.card())
+	}
+
+	// optimize picks the smallest representation.
+	dense := make([]uint16, 10000)
+	for i := range dense {
+		dense[i] = uint16(i)
+	}
+	if _, ok := optimize(newArrayOrBitmap(dense)).(*runContainer); !ok {
+		t.Errorf("dense run not optimized to a run container")
+	}
+	sparse := []uint16{1, 3, 5, 7}
+	if _, ok := optimize(toRun(&arrayContainer{sparse})).(*arrayContainer); !ok {
+		t.Errorf("sparse run container not optimized to an array")
+	}
+}
+
+func TestContainerOperations(t *testing.T) {
+	x := []uint16{1, 2, 3, 100, 101, 5000, 65535}
+	y := []uint16{0, 2, 3, 4, 101, 4999, 5000}
+	want := map[string][]uint16{
+		"or":     {0, 1, 2, 3, 4, 100, 101, 4999, 5000, 65535},
+		"and":    {2, 3, 101, 5000},
+		"andnot": {1, 100, 65535},
+		"xor":    {0, 1, 4, 100, 4999, 65535},
+	}
+	ops := map[string]func(a, b container) container{
+		"or": containerOr, "and": containerAnd, "andnot": containerAndNot, "xor": containerXor,
+	}
+	for _, a := range containersOf(x) {
+		for _, b := range containersOf(y) {
+			for name, op := range ops {
+				if got := collect(op(a, b)); !slices.Equal(got, want[name]) {
+					t.Errorf("%s(%T, %T) = %v, want %v", name, a, b, got, want[name])
+				}
+			}
+			if r := containerAnd(a, &arrayContainer{[]uint16{7}}); r != nil {
+				t.Errorf("empty and = %v", collect(r))
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package roaring implements roaring bitmaps: compressed sets of uint32
+// values.
+package roaring
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// Bitmap is a roaring bitmap: a set of uint32 values, partitioned by their
+// high 16 bits into chunks of up to 2^16 values, each stored in a container
+// chosen by its density - a sorted array of the low 16 bits for sparse
+// chunks, a bitmap of 2^16 bits for dense chunks, or a sorted list of runs
+// of consecutive values (see [Bitmap.RunOptimize]). This keeps both sparse
+// and dense sets compact, and set operations fast, since they work a chunk
+// at a time with the best algorithm for the pair of containers involved.
+//
+// The serialization format of bitmaps is the standard portable roaring
+// format, shared with other implementations of roaring bitmaps
+// (https://github.com/RoaringBitmap/RoaringFormatSpec).
+//
+// The zero value of Bitmap is an empty bitmap ready to use; bitmaps can also
+// be created with [New] or [InitWith].
+type Bitmap struct {
+	// keys holds the high 16 bits of the values of each container, in
+	// increasing order.
+	keys       []uint16
+	containers []container
+
+	length int
+}
+
+// New creates a new, empty bitmap.
+func New() *Bitmap {
+	return &Bitmap{}
+}
+
+// InitWith creates a new bitmap holding vals.
+func InitWith(vals ...uint32) *Bitmap {
+	b := New()
+	for _, v := range vals {
+		b.Add(v)
+	}
+	return b
+}
+
+// Len returns the number of values in the bitmap.
+func (b *Bitmap) Len() int {
+	return b.length
+}
+
+func split(x uint32) (hi, lo uint16) {
+	return uint16(x >> 16), uint16(x)
+}
+
+// Add adds x to the bitmap; if it's already in the bitmap, this is a no-op.
+func (b *Bitmap) Add(x uint32) {
+	hi, lo := split(x)
+	i, ok := slices.BinarySearch(b.keys, hi)
+	if !ok {
+		b.keys = slices.Insert(b.keys, i, hi)
+		b.containers = slices.Insert(b.containers, i, container(&arrayContainer{[]uint16{lo}}))
+		b.length++
+		return
+	}
+	c, added := b.containers[i].add(lo)
+	b.containers[i] = c
+	if added {
+		b.length++
+	}
+}
+
+// Delete removes x from the bitmap; if it's not in the bitmap, this is a
+// no-op.
+func (b *Bitmap) Delete(x uint32) {
+	hi, lo := split(x)
+	i, ok := slices.BinarySearch(b.keys, hi)
+	if !ok {
+		return
+	}
+	c, removed := b.containers[i].remove(lo)
+	if !removed {
+		return
+	}
+	b.length--
+	if c.card() == 0 {
+		b.keys = slices.Delete(b.keys, i, i+1)
+		b.containers = slices.Delete(b.containers, i, i+1)
+	} else {
+		b.containers[i] = c
+	}
+}
+
+// Contains reports whether x is in the bitmap.
+func (b *Bitmap) Contains(x uint32) bool {
+	hi, lo := split(x)
+	i, ok := slices.BinarySearch(b.keys, hi)
+	return ok && b.containers[i].contains(lo)
+}
+
+// All returns an iterator over all the values in the bitmap, in increasing
+// order.
+func (b *Bitmap) All() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		for i, c := range b.containers {
+			high := uint32(b.keys[i]) << 16
+			if !c.all(func(lo uint16) bool { return yield(high | uint32(lo)) }) {
+				return
+			}
+		}
+	}
+}
+
+// Rank returns the number of values in the bitmap that are <= x. It takes
+// time linear in the number of containers.
+func (b *Bitmap) Rank(x uint32) int {
+	hi, lo := split(x)
+	r := 0
+	for i, key := range b.keys {
+		if key > hi {
+			break
+		}
+		if key < hi {
+			r += b.containers[i].card()
+		} else {
+			r += b.containers[i].rank(lo)
+		}
+	}
+	return r
+}
+
+// Select returns the i-th smallest value in the bitmap, counting from 0. It
+// panics if i is not in the range [0, Len()). It takes time linear in the
+// number of containers.
+func (b *Bitmap) Select(i int) uint32 {
+	if i < 0 || i >= b.length {
+		panic(fmt.Sprintf("roaring: index %d out of range [0, %d)", i, b.length))
+	}
+	for ci, c := range b.containers {
+		if n := c.card(); i >= n {
+			i -= n
+		} else {
+			return uint32(b.keys[ci])<<16 | uint32(c.selectAt(i))
+		}
+	}
+	panic("unreachable")
+}
+
+// Clone returns a copy of the bitmap.
+func (b *Bitmap) Clone() *Bitmap {
+	r := &Bitmap{
+		keys:       slices.Clone(b.keys),
+		containers: make([]container, len(b.containers)),
+		length:     b.length,
+	}
+	for i, c := range b.containers {
+		r.containers[i] = c.clone()
+	}
+	return r
+}
+
+// RunOptimize converts every container of the bitmap to its most compact
+// representation, using run containers for chunks of values that form few
+// runs of consecutive values. Since containers are otherwise only converted
+// as values are added and removed, call RunOptimize after building a bitmap
+// with long runs, before serializing it or keeping it around.
+func (b *Bitmap) RunOptimize() {
+	for i, c := range b.containers {
+		b.containers[i] = optimize(c)
+	}
+}
+
+// Or returns the union of b and other. It creates a new bitmap.
+func (b *Bitmap) Or(other *Bitmap) *Bitmap {
+	return b.combine(other, containerOr, true, true)
+}
+
+// And returns the intersection of b and other. It creates a new bitmap.
+func (b *Bitmap) And(other *Bitmap) *Bitmap {
+	return b.combine(other, containerAnd, false, false)
+}
+
+// AndNot returns the difference b - other: the values in b that aren't in
+// other. It creates a new bitmap.
+func (b *Bitmap) AndNot(other *Bitmap) *Bitmap {
+	return b.combine(other, containerAndNot, true, false)
+}
+
+// Xor returns the symmetric difference of b and other: the values that are
+// in exactly one of them. It creates a new bitmap.
+func (b *Bitmap) Xor(other *Bitmap) *Bitmap {
+	return b.combine(other, containerXor, true, true)
+}
+
+// combine returns a new bitmap combining the containers of b and other: op
+// combines containers with the same keys, and the containers of b (other)
+// whose keys aren't in the other bitmap are copied if keepB (keepOther) is
+// true.
+func (b *Bitmap) combine(other *Bitmap, op func(a, b container) container, keepB, keepOther bool) *Bitmap {
+	r := New()
+	add := func(key uint16, c container) {
+		if c != nil {
+			r.keys = append(r.keys, key)
+			r.containers = append(r.containers, c)
+			r.length += c.card()
+		}
+	}
+	i, j := 0, 0
+	for i < len(b.keys) || j < len(other.keys) {
+		switch {
+		case j == len(other.keys) || (i < len(b.keys) && b.keys[i] < other.keys[j]):
+			if keepB {
+				add(b.keys[i], b.containers[i].clone())
+			}
+			i++
+		case i == len(b.keys) || other.keys[j] < b.keys[i]:
+			if keepOther {
+				add(other.keys[j], other.containers[j].clone())
+			}
+			j++
+		default:
+			add(b.keys[i], op(b.containers[i], other.containers[j]))
+			i++
+			j++
+		}
+	}
+	return r
+}
@@ -0,0 +1,281 @@
+package roaring
+
+import (
+	"log"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// checkBitmap checks that b holds exactly the values in want, and verifies
+// the invariants of its containers.
+func checkBitmap(t *testing.T, b *Bitmap, want map[uint32]bool) {
+	t.Helper()
+	if b.Len() != len(want) {
+		t.Fatalf("Len=%d, want %d", b.Len(), len(want))
+	}
+	sorted := slices.Sorted(maps.Keys(want))
+	if got := slices.Collect(b.All()); !slices.Equal(got, sorted) {
+		t.Fatalf("All = %v, want %v", got, sorted)
+	}
+	for i, key := range b.keys {
+		if i > 0 && key <= b.keys[i-1] {
+			t.Fatalf("keys out of order: %v", b.keys)
+		}
+		switch c := b.containers[i].(type) {
+		case *arrayContainer:
+			if c.card() == 0 || c.card() > arrayMaxSize {
+				t.Fatalf("array container with %d values", c.card())
+			}
+		case *bitmapContainer:
+			if c.card() <= arrayMaxSize || c.normalize() != c {
+				t.Fatalf("bitmap container with %d values", c.card())
+			}
+		case *runContainer:
+			n := 0
+			for j, run := range c.runs {
+				if run.start > run.last || (j > 0 && int(c.runs[j-1].last)+1 >= int(run.start)) {
+					t.Fatalf("bad runs %v", c.runs)
+				}
+				n += int(run.last-run.start) + 1
+			}
+			if n == 0 || n != c.n {
+				t.Fatalf("run container with %d values, n=%d", n, c.n)
+			}
+		}
+	}
+}
+
+// randomValues returns random values in a mix of distributions, to create
+// all kinds of containers: sparse values, dense chunks and runs.
+func randomValues(rnd *rand.Rand, n int) []uint32 {
+	var values []uint32
+	for len(values) < n {
+		switch rnd.IntN(3) {
+		case 0:
+			values = append(values, rnd.Uint32())
+		case 1:
+			base := rnd.Uint32N(8) << 16
+			for range 100 {
+				values = append(values, base|rnd.Uint32N(1<<16))
+			}
+		case 2:
+			start := rnd.Uint32N(8<<16 - 1000)
+			for v := range rnd.Uint32N(1000) {
+				values = append(values, start+v)
+			}
+		}
+	}
+	return values
+}
+
+func TestAddDelete(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	var b Bitmap
+	want := make(map[uint32]bool)
+	values := randomValues(rnd, 50000)
+	for i, v := range values {
+		b.Add(v)
+		want[v] = true
+		if i%5000 == 0 {
+			checkBitmap(t, &b, want)
+			b.RunOptimize()
+			checkBitmap(t, &b, want)
+		}
+	}
+	checkBitmap(t, &b, want)
+	for _, v := range randomValues(rnd, 1000) {
+		if b.Contains(v) != want[v] {
+			t.Fatalf("Contains(%d) = %v", v, !want[v])
+		}
+	}
+
+	rnd.Shuffle(len(values), func(i, j int) {
+		values[i], values[j] = values[j], values[i]
+	})
+	for i, v := range values {
+		b.Delete(v)
+		delete(want, v)
+		if b.Contains(v) {
+			t.Fatalf("Contains(%d) after Delete", v)
+		}
+		if i%5000 == 0 {
+			checkBitmap(t, &b, want)
+			b.RunOptimize()
+			checkBitmap(t, &b, want)
+		}
+	}
+	checkBitmap(t, &b, want)
+	if len(b.containers) != 0 {
+		t.Errorf("empty bitmap has %d containers", len(b.containers))
+	}
+}
+
+func TestExtremes(t *testing.T) {
+	b := InitWith(0, math.MaxUint32, 65535, 65536)
+	want := map[uint32]bool{0: true, math.MaxUint32: true, 65535: true, 65536: true}
+	checkBitmap(t, b, want)
+	if b.Rank(math.MaxUint32) != 4 || b.Select(3) != math.MaxUint32 || b.Rank(65535) != 2 {
+		t.Errorf("bad rank/select at extremes")
+	}
+
+	// A full container, as a bitmap and as a single run.
+	b = New()
+	want = make(map[uint32]bool)
+	for v := range uint32(1 << 16) {
+		b.Add(v + 5<<16)
+		want[v+5<<16] = true
+	}
+	checkBitmap(t, b, want)
+	b.RunOptimize()
+	if _, ok := b.containers[0].(*runContainer); !ok {
+		t.Fatalf("RunOptimize didn't create a run container")
+	}
+	checkBitmap(t, b, want)
+	if b.Rank(6<<16-1) != 1<<16 || b.Select(1<<16-1) != 6<<16-1 {
+		t.Errorf("bad rank/select in full run container")
+	}
+	b.Delete(5<<16 + 1000)
+	delete(want, 5<<16+1000)
+	checkBitmap(t, b, want)
+}
+
+func TestRankSelect(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	b := InitWith(randomValues(rnd, 30000)...)
+	for _, optimize := range []bool{false, true} {
+		if optimize {
+			b.RunOptimize()
+		}
+		sorted := slices.Collect(b.All())
+		for i, v := range sorted {
+			if got := b.Select(i); got != v {
+				t.Fatalf("Select(%d) = %d, want %d", i, got, v)
+			}
+			if got := b.Rank(v); got != i+1 {
+				t.Fatalf("Rank(%d) = %d, want %d", v, got, i+1)
+			}
+		}
+		for range 1000 {
+			x := rnd.Uint32N(9 << 16)
+			want, _ := slices.BinarySearch(sorted, x+1)
+			if got := b.Rank(x); got != want {
+				t.Fatalf("Rank(%d) = %d, want %d", x, got, want)
+			}
+		}
+	}
+
+	for _, i := range []int{-1, b.Len()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Select(%d) didn't panic", i)
+				}
+			}()
+			b.Select(i)
+		}()
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for range 20 {
+		a := InitWith(randomValues(rnd, 20000)...)
+		b := InitWith(randomValues(rnd, 20000)...)
+		if rnd.IntN(2) == 0 {
+			a.RunOptimize()
+		}
+		if rnd.IntN(2) == 0 {
+			b.RunOptimize()
+		}
+		inA := make(map[uint32]bool)
+		for v := range a.All() {
+			inA[v] = true
+		}
+		inB := make(map[uint32]bool)
+		for v := range b.All() {
+			inB[v] = true
+		}
+		aBefore, bBefore := slices.Collect(a.All()), slices.Collect(b.All())
+
+		for _, op := range []struct {
+			name string
+			f    func(a, b *Bitmap) *Bitmap
+			keep func(inA, inB bool) bool
+		}{
+			{"Or", (*Bitmap).Or, func(x, y bool) bool { return x || y }},
+			{"And", (*Bitmap).And, func(x, y bool) bool { return x && y }},
+			{"AndNot", (*Bitmap).AndNot, func(x, y bool) bool { return x && !y }},
+			{"Xor", (*Bitmap).Xor, func(x, y bool) bool { return x != y }},
+		} {
+			want := make(map[uint32]bool)
+			for v := range inA {
+				if op.keep(true, inB[v]) {
+					want[v] = true
+				}
+			}
+			for v := range inB {
+				if op.keep(inA[v], true) {
+					want[v] = true
+				}
+			}
+			t.Run(op.name, func(t *testing.T) {
+				checkBitmap(t, op.f(a, b), want)
+			})
+		}
+
+		// The operands are unchanged.
+		if !slices.Equal(slices.Collect(a.All()), aBefore) || !slices.Equal(slices.Collect(b.All()), bBefore) {
+			t.Fatalf("set operation modified its operands")
+		}
+		// x ^ x is empty, x & x == x | x == x.
+		if a.Xor(a).Len() != 0 || !slices.Equal(slices.Collect(a.And(a).All()), aBefore) || !slices.Equal(slices.Collect(a.Or(a).All()), aBefore) {
+			t.Fatalf("bad self operations")
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	a := InitWith(randomValues(rnd, 10000)...)
+	a.RunOptimize()
+	before := slices.Collect(a.All())
+	c := a.Clone()
+	for _, v := range before {
+		c.Delete(v)
+	}
+	c.Add(12345)
+	if got := slices.Collect(a.All()); !slices.Equal(got, before) {
+		t.Errorf("modifying clone changed original")
+	}
+	if c.Len() != 1 {
+		t.Errorf("clone Len=%d", c.Len())
+	}
+}
+
+func BenchmarkAnd(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	x := InitWith(randomValues(rnd, 200000)...)
+	y := InitWith(randomValues(rnd, 200000)...)
+	b.ResetTimer()
+	for range b.N {
+		x.And(y)
+	}
+}
+
+func BenchmarkContains(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	x := InitWith(randomValues(rnd, 200000)...)
+	b.ResetTimer()
+	for i := range b.N {
+		x.Contains(uint32(i) * 2654435761)
+	}
+}
@@ -0,0 +1,257 @@
+package roaring
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// The binary encoding of a bitmap is the portable roaring format. All
+// integers are little-endian. Without run containers, it's:
+//
+//	cookie u32 (12346) | container count u32 |
+//	count * (key u16 | cardinality-1 u16) |
+//	count * (offset of container u32) |
+//	containers
+//
+// and with run containers:
+//
+//	cookie u16 (12347) | container count-1 u16 |
+//	run flags (one bit per container, (count+7)/8 bytes) |
+//	count * (key u16 | cardinality-1 u16) |
+//	[count * (offset of container u32)]  (if count >= 4)
+//	containers
+//
+// Array containers are written as their sorted values (u16 each), bitmap
+// containers as 1024 u64 words, and run containers as the number of runs
+// u16 followed by (start u16 | length-1 u16) for every run. Containers that
+// aren't runs are arrays if their cardinality is at most 4096, and bitmaps
+// otherwise.
+
+const (
+	serialCookieNoRuns   = 12346
+	serialCookie         = 12347
+	noOffsetThreshold    = 4
+	bitmapSerializedSize = 8 * bitmapWords
+)
+
+// ErrInvalidData is returned when decoding data that isn't a valid
+// encoding of a bitmap.
+var ErrInvalidData = errors.New("roaring: invalid bitmap data")
+
+// MarshalBinary encodes the bitmap into the portable roaring format; it
+// implements [encoding.BinaryMarshaler].
+func (b *Bitmap) MarshalBinary() ([]byte, error) {
+	count := len(b.containers)
+	hasRuns := false
+	for _, c := range b.containers {
+		if _, ok := c.(*runContainer); ok {
+			hasRuns = true
+		}
+	}
+
+	var buf []byte
+	if hasRuns {
+		buf = binary.LittleEndian.AppendUint32(buf, serialCookie|uint32(count-1)<<16)
+		flags := make([]byte, (count+7)/8)
+		for i, c := range b.containers {
+			if _, ok := c.(*runContainer); ok {
+				flags[i/8] |= 1 << (i % 8)
+			}
+		}
+		buf = append(buf, flags...)
+	} else {
+		buf = binary.LittleEndian.AppendUint32(buf, serialCookieNoRuns)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(count))
+	}
+	for i, c := range b.containers {
+		buf = binary.LittleEndian.AppendUint16(buf, b.keys[i])
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(c.card()-1))
+	}
+	if !hasRuns || count >= noOffsetThreshold {
+		offset := len(buf) + 4*count
+		for _, c := range b.containers {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(offset))
+			offset += serializedSize(c)
+		}
+	}
+
+	for _, c := range b.containers {
+		switch c := c.(type) {
+		case *arrayContainer:
+			for _, v := range c.values {
+				buf = binary.LittleEndian.AppendUint16(buf, v)
+			}
+		case *bitmapContainer:
+			for _, w := range c.words {
+				buf = binary.LittleEndian.AppendUint64(buf, w)
+			}
+		case *runContainer:
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(len(c.runs)))
+			for _, run := range c.runs {
+				buf = binary.LittleEndian.AppendUint16(buf, run.start)
+				buf = binary.LittleEndian.AppendUint16(buf, run.last-run.start)
+			}
+		}
+	}
+	return buf, nil
+}
+
+func serializedSize(c container) int {
+	if r, ok := c.(*runContainer); ok {
+		return 2 + 4*len(r.runs)
+	}
+	return plainSize(c.card())
+}
+
+// UnmarshalBinary replaces the contents of b by decoding data in the
+// portable roaring format, e.g. produced by [Bitmap.MarshalBinary] or by
+// other roaring implementations; it implements [encoding.BinaryUnmarshaler].
+// It returns ErrInvalidData if data isn't a valid encoding.
+func (b *Bitmap) UnmarshalBinary(data []byte) error {
+	d := decoder{data: data}
+	cookie := d.uint32()
+	var count int
+	var runFlags []byte
+	switch {
+	case cookie == serialCookieNoRuns:
+		count = int(d.uint32())
+	case cookie&0xffff == serialCookie:
+		count = int(cookie>>16) + 1
+		runFlags = d.bytes((count + 7) / 8)
+	default:
+		return ErrInvalidData
+	}
+	if d.err || count > 1<<16 {
+		return ErrInvalidData
+	}
+
+	keys := make([]uint16, count)
+	cards := make([]int, count)
+	for i := range count {
+		keys[i] = d.uint16()
+		cards[i] = int(d.uint16()) + 1
+		if i > 0 && keys[i] <= keys[i-1] {
+			return ErrInvalidData
+		}
+	}
+	if runFlags == nil || count >= noOffsetThreshold {
+		// The offsets are redundant when reading the whole bitmap.
+		d.bytes(4 * count)
+	}
+
+	containers := make([]container, count)
+	length := 0
+	for i := range count {
+		var c container
+		switch {
+		case runFlags != nil && runFlags[i/8]&(1<<(i%8)) != 0:
+			c = d.runContainer()
+		case cards[i] <= arrayMaxSize:
+			c = d.arrayContainer(cards[i])
+		default:
+			c = d.bitmapContainer()
+		}
+		if d.err || c == nil || c.card() != cards[i] {
+			return ErrInvalidData
+		}
+		containers[i] = c
+		length += c.card()
+	}
+	if d.err || len(d.data) != 0 {
+		return ErrInvalidData
+	}
+
+	b.keys, b.containers, b.length = keys, containers, length
+	return nil
+}
+
+// decoder reads little-endian values from data; on reading past its end, it
+// sets err and returns zeros.
+type decoder struct {
+	data []byte
+	err  bool
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err || n > len(d.data) {
+		d.err = true
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// arrayContainer reads an array container of n values; it returns nil if
+// the values aren't strictly increasing.
+func (d *decoder) arrayContainer(n int) container {
+	b := d.bytes(2 * n)
+	if b == nil {
+		return nil
+	}
+	values := make([]uint16, n)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint16(b[2*i:])
+		if i > 0 && values[i] <= values[i-1] {
+			return nil
+		}
+	}
+	return &arrayContainer{values}
+}
+
+func (d *decoder) bitmapContainer() container {
+	b := d.bytes(bitmapSerializedSize)
+	if b == nil {
+		return nil
+	}
+	c := &bitmapContainer{}
+	for i := range c.words {
+		c.words[i] = binary.LittleEndian.Uint64(b[8*i:])
+		c.n += bits.OnesCount64(c.words[i])
+	}
+	return c
+}
+
+// runContainer reads a run container; it returns nil if the runs aren't
+// sorted and disjoint. Adjacent runs are merged.
+func (d *decoder) runContainer() container {
+	n := int(d.uint16())
+	b := d.bytes(4 * n)
+	if b == nil || n == 0 {
+		return nil
+	}
+	c := &runContainer{runs: make([]interval, 0, n)}
+	for i := range n {
+		start := int(binary.LittleEndian.Uint16(b[4*i:]))
+		last := start + int(binary.LittleEndian.Uint16(b[4*i+2:]))
+		if last > 0xffff {
+			return nil
+		}
+		c.n += last - start + 1
+		if k := len(c.runs); k > 0 {
+			if prev := int(c.runs[k-1].last); start <= prev {
+				return nil
+			} else if start == prev+1 {
+				c.runs[k-1].last = uint16(last)
+				continue
+			}
+		}
+		c.runs = append(c.runs, interval{uint16(start), uint16(last)})
+	}
+	return c
+}
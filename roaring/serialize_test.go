@@ -0,0 +1,127 @@
+package roaring
+
+import (
+	"encoding"
+	"encoding/hex"
+	"slices"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Bitmap)(nil)
+	_ encoding.BinaryUnmarshaler = (*Bitmap)(nil)
+)
+
+func TestSerializeKnown(t *testing.T) {
+	// Encodings worked out by hand from the format specification.
+	for _, tt := range []struct {
+		b    *Bitmap
+		want string
+	}{
+		{New(), "3a30000000000000"},
+		{InitWith(1, 2, 3, 1000), "3a300000" + "01000000" + "00000300" + "10000000" + "010002000300e803"},
+		{InitWith(1, 1<<16|2), "3a300000" + "02000000" + "00000000" + "01000000" + "18000000" + "1a000000" + "0100" + "0200"},
+	} {
+		data, err := tt.b.MarshalBinary()
+		if err != nil || hex.EncodeToString(data) != tt.want {
+			t.Errorf("MarshalBinary(%v) = %x, %v; want %s", slices.Collect(tt.b.All()), data, err, tt.want)
+		}
+	}
+
+	runs := New()
+	for v := range uint32(100) {
+		runs.Add(v + 1)
+	}
+	runs.RunOptimize()
+	data, _ := runs.MarshalBinary()
+	if want := "3b300000" + "01" + "00006300" + "0100" + "01006300"; hex.EncodeToString(data) != want {
+		t.Errorf("MarshalBinary of runs = %x, want %s", data, want)
+	}
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for i := range 20 {
+		b := InitWith(randomValues(rnd, 1+rnd.IntN(20000))...)
+		if i%2 == 0 {
+			b.RunOptimize()
+		}
+		data, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var d Bitmap
+		if err := d.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary: %v", err)
+		}
+		want := make(map[uint32]bool)
+		for v := range b.All() {
+			want[v] = true
+		}
+		checkBitmap(t, &d, want)
+
+		// The encoding holds the container kinds, so it's stable.
+		data2, _ := d.MarshalBinary()
+		if !slices.Equal(data, data2) {
+			t.Fatalf("re-encoding differs")
+		}
+	}
+
+	// Run containers with four or more containers include offsets.
+	b := New()
+	for key := range uint32(5) {
+		for v := range uint32(300) {
+			b.Add(key<<16 | v)
+		}
+	}
+	b.RunOptimize()
+	data, _ := b.MarshalBinary()
+	var d Bitmap
+	if err := d.UnmarshalBinary(data); err != nil || d.Len() != 1500 {
+		t.Fatalf("UnmarshalBinary: %v, Len=%d", err, d.Len())
+	}
+	want := make(map[uint32]bool)
+	for v := range b.All() {
+		want[v] = true
+	}
+	checkBitmap(t, &d, want)
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	b := InitWith(1, 2, 3, 1000, 1<<20)
+	for v := range uint32(5000) {
+		b.Add(3<<16 | v*7)
+	}
+	valid, _ := b.MarshalBinary()
+	b.RunOptimize()
+	withRuns, _ := b.MarshalBinary()
+
+	for _, data := range [][]byte{valid, withRuns} {
+		for n := range len(data) {
+			if err := new(Bitmap).UnmarshalBinary(data[:n]); err != ErrInvalidData {
+				t.Fatalf("truncated to %d bytes: err=%v", n, err)
+			}
+		}
+		if err := new(Bitmap).UnmarshalBinary(append(slices.Clone(data), 0)); err != ErrInvalidData {
+			t.Errorf("trailing data: err=%v", err)
+		}
+	}
+
+	for _, hexData := range []string{
+		// Bad cookie.
+		"3c30000000000000",
+		// Unsorted array values.
+		"3a300000" + "01000000" + "00000100" + "10000000" + "02000100",
+		// Cardinality mismatch in a run container.
+		"3b300000" + "01" + "00000500" + "0100" + "01006300",
+		// Overlapping runs.
+		"3b300000" + "01" + "00000900" + "0200" + "01000400" + "03000100",
+		// Keys out of order.
+		"3a300000" + "02000000" + "01000000" + "00000000" + "18000000" + "1a000000" + "0100" + "0200",
+	} {
+		data, _ := hex.DecodeString(hexData)
+		if err := new(Bitmap).UnmarshalBinary(data); err != ErrInvalidData {
+			t.Errorf("UnmarshalBinary(%s): err=%v", hexData, err)
+		}
+	}
+}
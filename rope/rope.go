@@ -0,0 +1,311 @@
+// Package rope implements a rope: a balanced binary tree of string chunks
+// representing a long string, with logarithmic-time editing operations.
+package rope
+
+import (
+	"io"
+	"iter"
+	"strings"
+)
+
+// maxLeaf is the maximal length of the string in a leaf created by
+// splitting longer strings. Adjacent small leaves are merged up to this size.
+const maxLeaf = 1024
+
+// Rope is a mutable string supporting O(log n) insertion, deletion, slicing
+// and concatenation. The zero value is an empty rope ready to use.
+//
+// Internally, ropes are made of immutable nodes that are shared between
+// ropes; this makes [Rope.Slice] and [Rope.Clone] cheap.
+type Rope struct {
+	root *node
+}
+
+// node is a rope node. Leaves hold a non-empty string in s and have height
+// 0; inner nodes always have two children. The tree is kept AVL-balanced:
+// the heights of an inner node's children differ by at most 1.
+type node struct {
+	left, right *node
+	s           string
+	length      int
+	height      int
+}
+
+// New creates a new rope holding s.
+func New(s string) *Rope {
+	return &Rope{root: fromString(s)}
+}
+
+// FromReader creates a new rope holding the contents read from r until EOF.
+// It returns the contents read so far along with the error, if reading fails.
+func FromReader(r io.Reader) (*Rope, error) {
+	rp := &Rope{}
+	buf := make([]byte, maxLeaf)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			rp.root = join(rp.root, newLeaf(string(buf[:n])))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return rp, nil
+		} else if err != nil {
+			return rp, err
+		}
+	}
+}
+
+// Len returns the length of the rope in bytes.
+func (r *Rope) Len() int {
+	return r.root.len()
+}
+
+// String returns the contents of the rope as a string. This takes O(n) time.
+func (r *Rope) String() string {
+	var sb strings.Builder
+	sb.Grow(r.Len())
+	for c := range r.Chunks() {
+		sb.WriteString(c)
+	}
+	return sb.String()
+}
+
+// At returns the byte at index i. It panics if i is out of range.
+func (r *Rope) At(i int) byte {
+	if i < 0 || i >= r.Len() {
+		panic("rope: index out of range")
+	}
+	n := r.root
+	for n.s == "" {
+		if i < n.left.length {
+			n = n.left
+		} else {
+			i -= n.left.length
+			n = n.right
+		}
+	}
+	return n.s[i]
+}
+
+// Insert inserts s into the rope at index i, so that it starts at index i
+// after the insertion. It panics if i is not in the range [0, Len()].
+func (r *Rope) Insert(i int, s string) {
+	r.checkRange(i, i)
+	left, right := split(r.root, i)
+	r.root = join(join(left, fromString(s)), right)
+}
+
+// Delete deletes the bytes in the range [i, j) from the rope. It panics if
+// the range is invalid.
+func (r *Rope) Delete(i, j int) {
+	r.checkRange(i, j)
+	left, rest := split(r.root, i)
+	_, right := split(rest, j-i)
+	r.root = join(left, right)
+}
+
+// Slice returns a new rope holding the bytes in the range [i, j) of r. It
+// panics if the range is invalid.
+func (r *Rope) Slice(i, j int) *Rope {
+	r.checkRange(i, j)
+	_, rest := split(r.root, i)
+	mid, _ := split(rest, j-i)
+	return &Rope{root: mid}
+}
+
+// Concat appends the contents of other to the end of r; other is not
+// modified.
+func (r *Rope) Concat(other *Rope) {
+	r.root = join(r.root, other.root)
+}
+
+// Clone returns a copy of the rope in O(1) time. Subsequent modifications
+// of either rope don't affect the other.
+func (r *Rope) Clone() *Rope {
+	return &Rope{root: r.root}
+}
+
+// Chunks returns an iterator over the chunks of the rope's contents, in
+// order; concatenated, the chunks form the contents of the rope.
+func (r *Rope) Chunks() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		r.root.chunks(yield)
+	}
+}
+
+// Reader returns an io.Reader reading the contents of the rope, as of the
+// time Reader is called.
+func (r *Rope) Reader() io.Reader {
+	rd := &reader{}
+	rd.pushLeft(r.root)
+	return rd
+}
+
+// WriteTo writes the contents of the rope to w. It implements the
+// io.WriterTo interface.
+func (r *Rope) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for c := range r.Chunks() {
+		n, err := io.WriteString(w, c)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (r *Rope) checkRange(i, j int) {
+	if i < 0 || j < i || j > r.Len() {
+		panic("rope: range out of bounds")
+	}
+}
+
+// reader reads the chunks of a rope in order. stack holds the path to the
+// next leaf to read, along with the right children of its ancestors.
+type reader struct {
+	stack []*node
+	chunk string
+}
+
+// pushLeft pushes n and its chain of left descendants onto the stack.
+func (rd *reader) pushLeft(n *node) {
+	for n != nil {
+		rd.stack = append(rd.stack, n)
+		n = n.left
+	}
+}
+
+func (rd *reader) Read(p []byte) (int, error) {
+	for rd.chunk == "" {
+		if len(rd.stack) == 0 {
+			return 0, io.EOF
+		}
+		n := rd.stack[len(rd.stack)-1]
+		rd.stack = rd.stack[:len(rd.stack)-1]
+		if n.s != "" {
+			rd.chunk = n.s
+		} else {
+			rd.pushLeft(n.right)
+		}
+	}
+	n := copy(p, rd.chunk)
+	rd.chunk = rd.chunk[n:]
+	return n, nil
+}
+
+func (n *node) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.length
+}
+
+func (n *node) ht() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *node) chunks(yield func(string) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.s != "" {
+		return yield(n.s)
+	}
+	return n.left.chunks(yield) && n.right.chunks(yield)
+}
+
+// newLeaf creates a leaf for s, or returns nil if s is empty.
+func newLeaf(s string) *node {
+	if s == "" {
+		return nil
+	}
+	return &node{s: s, length: len(s)}
+}
+
+func newInner(left, right *node) *node {
+	return &node{
+		left:   left,
+		right:  right,
+		length: left.length + right.length,
+		height: max(left.height, right.height) + 1,
+	}
+}
+
+// fromString builds a balanced tree holding s, split into leaves of at most
+// maxLeaf bytes.
+func fromString(s string) *node {
+	if len(s) <= maxLeaf {
+		return newLeaf(s)
+	}
+	// Split at a multiple of maxLeaf so that leaves are as full as possible.
+	mid := (len(s) / maxLeaf / 2) * maxLeaf
+	if mid == 0 {
+		mid = maxLeaf
+	}
+	return join(fromString(s[:mid]), fromString(s[mid:]))
+}
+
+// join returns a balanced tree holding the contents of a followed by the
+// contents of b. It takes time proportional to the difference in their
+// heights.
+func join(a, b *node) *node {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.s != "" && b.s != "" && a.length+b.length <= maxLeaf:
+		return newLeaf(a.s + b.s)
+	case a.height > b.height+1:
+		return balance(a.left, join(a.right, b))
+	case b.height > a.height+1:
+		return balance(join(a, b.left), b.right)
+	default:
+		return newInner(a, b)
+	}
+}
+
+// balance creates an inner node with the given children, whose heights may
+// differ by at most 2, performing rotations to restore balance if needed.
+func balance(left, right *node) *node {
+	switch {
+	case left.ht() > right.ht()+1:
+		if left.left.ht() >= left.right.ht() {
+			return newInner(left.left, newInner(left.right, right))
+		}
+		lr := left.right
+		return newInner(newInner(left.left, lr.left), newInner(lr.right, right))
+	case right.ht() > left.ht()+1:
+		if right.right.ht() >= right.left.ht() {
+			return newInner(newInner(left, right.left), right.right)
+		}
+		rl := right.left
+		return newInner(newInner(left, rl.left), newInner(rl.right, right.right))
+	default:
+		return newInner(left, right)
+	}
+}
+
+// split splits the tree rooted at n into two balanced trees holding the
+// first i bytes and the rest, respectively.
+func split(n *node, i int) (*node, *node) {
+	switch {
+	case n == nil:
+		return nil, nil
+	case i == 0:
+		return nil, n
+	case i == n.length:
+		return n, nil
+	case n.s != "":
+		return newLeaf(n.s[:i]), newLeaf(n.s[i:])
+	case i < n.left.length:
+		l, r := split(n.left, i)
+		return l, join(r, n.right)
+	default:
+		l, r := split(n.right, i-n.left.length)
+		return join(n.left, l), r
+	}
+}
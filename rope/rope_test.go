@@ -0,0 +1,167 @@
+package rope
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// checkRope verifies r's contents and the invariants of its tree.
+func checkRope(t *testing.T, r *Rope, want string) {
+	t.Helper()
+	if r.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", r.Len(), len(want))
+	}
+	if got := r.String(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if err := verify(r.root); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verify(n *node) error {
+	if n == nil {
+		return nil
+	}
+	if n.s != "" {
+		if n.left != nil || n.right != nil || n.height != 0 || n.length != len(n.s) {
+			return errors.New("bad leaf")
+		}
+		return nil
+	}
+	if n.left == nil || n.right == nil {
+		return errors.New("inner node missing a child")
+	}
+	if n.length != n.left.length+n.right.length {
+		return errors.New("bad length")
+	}
+	if n.height != max(n.left.height, n.right.height)+1 {
+		return errors.New("bad height")
+	}
+	if d := n.left.height - n.right.height; d < -1 || d > 1 {
+		return errors.New("unbalanced node")
+	}
+	if err := verify(n.left); err != nil {
+		return err
+	}
+	return verify(n.right)
+}
+
+func TestBasic(t *testing.T) {
+	var r Rope
+	checkRope(t, &r, "")
+	r.Insert(0, "world")
+	r.Insert(0, "hello ")
+	r.Insert(r.Len(), "!")
+	checkRope(t, &r, "hello world!")
+	if r.At(4) != 'o' {
+		t.Errorf("got At(4)=%c", r.At(4))
+	}
+
+	sl := r.Slice(6, 11)
+	checkRope(t, sl, "world")
+	r.Delete(5, 11)
+	checkRope(t, &r, "hello!")
+	checkRope(t, sl, "world")
+
+	c := r.Clone()
+	c.Concat(sl)
+	checkRope(t, c, "hello!world")
+	checkRope(t, &r, "hello!")
+}
+
+func TestLarge(t *testing.T) {
+	s := strings.Repeat("abcdefghij", 10000)
+	r := New(s)
+	checkRope(t, r, s)
+	for c := range r.Chunks() {
+		if len(c) > maxLeaf {
+			t.Fatalf("chunk of length %d", len(c))
+		}
+	}
+
+	r.Insert(55555, "XYZ")
+	s = s[:55555] + "XYZ" + s[55555:]
+	checkRope(t, r, s)
+	r.Delete(100, 90000)
+	s = s[:100] + s[90000:]
+	checkRope(t, r, s)
+}
+
+func TestRandomEdits(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	r := New("")
+	var want string
+	for i := range 3000 {
+		switch rnd.IntN(4) {
+		case 0, 1:
+			pos := rnd.IntN(len(want) + 1)
+			s := strings.Repeat(string(rune('a'+i%26)), rnd.IntN(3000))
+			r.Insert(pos, s)
+			want = want[:pos] + s + want[pos:]
+		case 2:
+			i := rnd.IntN(len(want) + 1)
+			j := i + rnd.IntN(len(want)-i+1)
+			r.Delete(i, j)
+			want = want[:i] + want[j:]
+		case 3:
+			i := rnd.IntN(len(want) + 1)
+			j := i + rnd.IntN(len(want)-i+1)
+			checkRope(t, r.Slice(i, j), want[i:j])
+		}
+		if len(want) > 0 {
+			pos := rnd.IntN(len(want))
+			if r.At(pos) != want[pos] {
+				t.Fatalf("At(%d)=%c, want %c", pos, r.At(pos), want[pos])
+			}
+		}
+		if i%100 == 0 {
+			checkRope(t, r, want)
+		}
+	}
+	checkRope(t, r, want)
+}
+
+func TestReaders(t *testing.T) {
+	s := strings.Repeat("0123456789", 5000)
+	r, err := FromReader(iotest.OneByteReader(strings.NewReader(s)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRope(t, r, s)
+
+	rd := r.Reader()
+	r.Delete(0, 100)
+	got, err := io.ReadAll(rd)
+	if err != nil || string(got) != s {
+		t.Errorf("Reader mismatch, err=%v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	if err != nil || n != int64(len(s)-100) || buf.String() != s[100:] {
+		t.Errorf("WriteTo mismatch: n=%d, err=%v", n, err)
+	}
+
+	errBoom := errors.New("boom")
+	r, err = FromReader(iotest.TimeoutReader(strings.NewReader(s)))
+	if err == nil || r.Len() != maxLeaf {
+		t.Errorf("got err=%v, Len=%d", err, r.Len())
+	}
+	_, err = FromReader(iotest.ErrReader(errBoom))
+	if err != errBoom {
+		t.Errorf("got err=%v", err)
+	}
+}
@@ -0,0 +1,493 @@
+// Package rtree implements an R-tree: a spatial index over axis-aligned
+// rectangles, supporting intersection and nearest-neighbor queries.
+package rtree
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+
+	"github.com/eliben/gogl/heap"
+	"github.com/eliben/gogl/internal/geom"
+)
+
+// Rect is a closed axis-aligned rectangle in the plane, holding the points
+// (x, y) with Min[0] <= x <= Max[0] and Min[1] <= y <= Max[1]. A point is a
+// rectangle with Min == Max. It's the same type as quadtree.Rect.
+type Rect = geom.Rect
+
+// Point returns the rectangle holding only the point (x, y).
+func Point(x, y float64) Rect {
+	return geom.Point(x, y)
+}
+
+// rectArea returns the area of r.
+func rectArea(r Rect) float64 {
+	return (r.Max[0] - r.Min[0]) * (r.Max[1] - r.Min[1])
+}
+
+// rectMargin returns the half-perimeter of r.
+func rectMargin(r Rect) float64 {
+	return (r.Max[0] - r.Min[0]) + (r.Max[1] - r.Min[1])
+}
+
+// rectOverlap returns the area of the intersection of r and other.
+func rectOverlap(r, other Rect) float64 {
+	a := 1.0
+	for axis := range 2 {
+		lo, hi := max(r.Min[axis], other.Min[axis]), min(r.Max[axis], other.Max[axis])
+		if lo >= hi {
+			return 0
+		}
+		a *= hi - lo
+	}
+	return a
+}
+
+// rectDist returns the Euclidean distance from the point (x, y) to the closest
+// point of r, which is 0 if r contains the point.
+func rectDist(r Rect, x, y float64) float64 {
+	dx := max(r.Min[0]-x, 0, x-r.Max[0])
+	dy := max(r.Min[1]-y, 0, y-r.Max[1])
+	return math.Hypot(dx, dy)
+}
+
+// rectCenter returns twice the center of r along axis, which is enough to
+// compare distances between centers.
+func rectCenter(r Rect, axis int) float64 {
+	return r.Min[axis] + r.Max[axis]
+}
+
+// Entry is a rectangle stored in the tree, with its payload.
+type Entry[V any] struct {
+	Rect  Rect
+	Value V
+}
+
+// Tree is an R-tree holding rectangles with payloads of type V. It's a
+// balanced tree in which every node holds up to a maximal number of entries,
+// and is labeled with the bounding box of the entries in its subtree; this
+// allows queries to skip the subtrees whose bounding boxes are irrelevant.
+//
+// Insertion uses the heuristics of the R*-tree (Beckmann et al., 1990),
+// which choose the node to insert into and the way to split full nodes so as
+// to minimize the overlap and area of bounding boxes, and on the first
+// overflow of a node at each level reinsert some of its entries rather than
+// splitting it. The same rectangle may be inserted multiple times. Create
+// trees with [New] or [NewWithMaxEntries].
+type Tree[V any] struct {
+	root       *node[V]
+	length     int
+	maxEntries int
+	minEntries int
+}
+
+// node is a node of the tree. Leaves are at level 0 and hold the inserted
+// entries; nodes at level l > 0 hold entries pointing to children at level
+// l-1, labeled with the children's bounding boxes.
+type node[V any] struct {
+	level   int
+	entries []entry[V]
+}
+
+type entry[V any] struct {
+	rect  Rect
+	child *node[V]
+	value V
+}
+
+func (n *node[V]) bounds() Rect {
+	r := n.entries[0].rect
+	for _, e := range n.entries[1:] {
+		r = r.Union(e.rect)
+	}
+	return r
+}
+
+// DefaultMaxEntries is the maximal number of entries in a node of trees
+// created with [New].
+const DefaultMaxEntries = 16
+
+// New creates a new, empty tree with nodes of up to [DefaultMaxEntries]
+// entries.
+func New[V any]() *Tree[V] {
+	return NewWithMaxEntries[V](DefaultMaxEntries)
+}
+
+// NewWithMaxEntries creates a new, empty tree with nodes of up to maxEntries
+// entries. Non-root nodes hold at least 40% as many entries. It panics if
+// maxEntries < 4.
+func NewWithMaxEntries[V any](maxEntries int) *Tree[V] {
+	if maxEntries < 4 {
+		panic(fmt.Sprintf("rtree: invalid max entries %d", maxEntries))
+	}
+	return &Tree[V]{
+		root:       &node[V]{},
+		maxEntries: maxEntries,
+		minEntries: max(2, maxEntries*2/5),
+	}
+}
+
+// Len returns the number of entries in the tree.
+func (t *Tree[V]) Len() int {
+	return t.length
+}
+
+// Bounds returns the bounding box of all the rectangles in the tree, and
+// ok=true; if the tree is empty, it returns ok=false.
+func (t *Tree[V]) Bounds() (r Rect, ok bool) {
+	if len(t.root.entries) == 0 {
+		return r, false
+	}
+	return t.root.bounds(), true
+}
+
+// insertion holds the state of the insertion of a single entry, which may
+// cause other entries to be reinserted.
+type insertion[V any] struct {
+	// reinserted has bit l set if entries of a node at level l have already
+	// been reinserted.
+	reinserted uint64
+	pending    []pendingEntry[V]
+}
+
+type pendingEntry[V any] struct {
+	e     entry[V]
+	level int
+}
+
+// Insert adds rectangle r with payload v to the tree.
+func (t *Tree[V]) Insert(r Rect, v V) {
+	t.insertAt(entry[V]{rect: r, value: v}, 0)
+	t.length++
+}
+
+// insertAt inserts e into a node at the given level, and then reinserts any
+// entries displaced by the insertion.
+func (t *Tree[V]) insertAt(e entry[V], level int) {
+	var ins insertion[V]
+	ins.pending = append(ins.pending, pendingEntry[V]{e, level})
+	for len(ins.pending) > 0 {
+		p := ins.pending[len(ins.pending)-1]
+		ins.pending = ins.pending[:len(ins.pending)-1]
+		if sibling := t.insert(t.root, p.e, p.level, &ins); sibling != nil {
+			old := t.root
+			t.root = &node[V]{
+				level: old.level + 1,
+				entries: []entry[V]{
+					{rect: old.bounds(), child: old},
+					{rect: sibling.bounds(), child: sibling},
+				},
+			}
+		}
+	}
+}
+
+// insert inserts e into a node at the given level in the subtree of n. If n
+// is split, it returns the new sibling node.
+func (t *Tree[V]) insert(n *node[V], e entry[V], level int, ins *insertion[V]) *node[V] {
+	if n.level == level {
+		n.entries = append(n.entries, e)
+	} else {
+		i := t.chooseSubtree(n, e.rect)
+		child := n.entries[i].child
+		sibling := t.insert(child, e, level, ins)
+		n.entries[i].rect = child.bounds()
+		if sibling != nil {
+			n.entries = append(n.entries, entry[V]{rect: sibling.bounds(), child: sibling})
+		}
+	}
+	if len(n.entries) <= t.maxEntries {
+		return nil
+	}
+
+	if n != t.root && ins.reinserted&(1<<n.level) == 0 {
+		ins.reinserted |= 1 << n.level
+		t.reinsert(n, ins)
+		return nil
+	}
+	return t.split(n)
+}
+
+// chooseSubtree returns the index of the entry of n whose subtree is the
+// best place to insert r.
+func (t *Tree[V]) chooseSubtree(n *node[V], r Rect) int {
+	best := 0
+	var bestOverlap, bestEnlargement, bestArea float64
+	for i, e := range n.entries {
+		enlarged := e.rect.Union(r)
+		area := rectArea(e.rect)
+		enlargement := rectArea(enlarged) - area
+
+		// Above leaves, minimize the overlap enlargement with the other
+		// entries; then minimize area enlargement, then area. The overlap
+		// can't grow if the entry's rectangle doesn't.
+		var overlap float64
+		if n.level == 1 && enlarged != e.rect {
+			for j, other := range n.entries {
+				if j != i {
+					overlap += rectOverlap(enlarged, other.rect) - rectOverlap(e.rect, other.rect)
+				}
+			}
+		}
+		if i == 0 || overlap < bestOverlap ||
+			(overlap == bestOverlap && (enlargement < bestEnlargement ||
+				(enlargement == bestEnlargement && area < bestArea))) {
+			best, bestOverlap, bestEnlargement, bestArea = i, overlap, enlargement, area
+		}
+	}
+	return best
+}
+
+// reinsert removes the 30% of the entries of the overflowing node n whose
+// centers are farthest from the center of n's bounding box, and schedules
+// them for reinsertion, closest first.
+func (t *Tree[V]) reinsert(n *node[V], ins *insertion[V]) {
+	b := n.bounds()
+	dist := func(e entry[V]) float64 {
+		dx, dy := rectCenter(e.rect, 0)-rectCenter(b, 0), rectCenter(e.rect, 1)-rectCenter(b, 1)
+		return dx*dx + dy*dy
+	}
+	slices.SortFunc(n.entries, func(a, b entry[V]) int {
+		return compareFloats(dist(a), dist(b))
+	})
+	k := len(n.entries) - max(1, t.maxEntries*3/10)
+	// pending is a stack, so push the farthest first.
+	for i := len(n.entries) - 1; i >= k; i-- {
+		ins.pending = append(ins.pending, pendingEntry[V]{n.entries[i], n.level})
+		n.entries[i] = entry[V]{}
+	}
+	n.entries = n.entries[:k]
+}
+
+// split splits the overflowing node n in two, keeping one group of entries
+// in n and returning a new node with the other.
+func (t *Tree[V]) split(n *node[V]) *node[V] {
+	// Choose the split axis as the one minimizing the sum of the margins of
+	// the bounding boxes of all the possible distributions of entries,
+	// sorted by either the lower or upper bounds of the entries.
+	bestAxis, bestMargin := 0, math.Inf(1)
+	for axis := range 2 {
+		var margin float64
+		for _, byMax := range []bool{false, true} {
+			sortEntries(n.entries, axis, byMax)
+			lower, upper := t.distributionBounds(n.entries)
+			for k := t.minEntries; k <= len(n.entries)-t.minEntries; k++ {
+				margin += rectMargin(lower[k]) + rectMargin(upper[k])
+			}
+		}
+		if margin < bestMargin {
+			bestAxis, bestMargin = axis, margin
+		}
+	}
+
+	// Along that axis, choose the distribution with the least overlap
+	// between groups, then with the least total area.
+	bestByMax, bestK := false, 0
+	bestOverlap, bestArea := math.Inf(1), math.Inf(1)
+	for _, byMax := range []bool{false, true} {
+		sortEntries(n.entries, bestAxis, byMax)
+		lower, upper := t.distributionBounds(n.entries)
+		for k := t.minEntries; k <= len(n.entries)-t.minEntries; k++ {
+			overlap := rectOverlap(lower[k], upper[k])
+			area := rectArea(lower[k]) + rectArea(upper[k])
+			if overlap < bestOverlap || (overlap == bestOverlap && area < bestArea) {
+				bestByMax, bestK, bestOverlap, bestArea = byMax, k, overlap, area
+			}
+		}
+	}
+
+	sortEntries(n.entries, bestAxis, bestByMax)
+	sibling := &node[V]{level: n.level, entries: slices.Clone(n.entries[bestK:])}
+	clear(n.entries[bestK:])
+	n.entries = n.entries[:bestK]
+	return sibling
+}
+
+// distributionBounds returns, for each k, the bounding boxes of entries[:k]
+// as lower[k] and of entries[k:] as upper[k]. Only the values for k in
+// [minEntries, len(entries)-minEntries] are meaningful.
+func (t *Tree[V]) distributionBounds(entries []entry[V]) (lower, upper []Rect) {
+	n := len(entries)
+	lower = make([]Rect, n+1)
+	upper = make([]Rect, n+1)
+	lower[1] = entries[0].rect
+	for k := 2; k <= n; k++ {
+		lower[k] = lower[k-1].Union(entries[k-1].rect)
+	}
+	upper[n-1] = entries[n-1].rect
+	for k := n - 2; k >= 0; k-- {
+		upper[k] = upper[k+1].Union(entries[k].rect)
+	}
+	return lower, upper
+}
+
+// sortEntries sorts entries along axis by their lower bounds, or by their
+// upper bounds if byMax is true; ties are broken by the other bound.
+func sortEntries[V any](entries []entry[V], axis int, byMax bool) {
+	slices.SortFunc(entries, func(a, b entry[V]) int {
+		if byMax {
+			if c := compareFloats(a.rect.Max[axis], b.rect.Max[axis]); c != 0 {
+				return c
+			}
+			return compareFloats(a.rect.Min[axis], b.rect.Min[axis])
+		}
+		if c := compareFloats(a.rect.Min[axis], b.rect.Min[axis]); c != 0 {
+			return c
+		}
+		return compareFloats(a.rect.Max[axis], b.rect.Max[axis])
+	})
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Delete removes an entry with rectangle r whose payload satisfies match
+// from the tree. It returns true if an entry was removed; if several entries
+// match, only one of them is removed.
+func (t *Tree[V]) Delete(r Rect, match func(V) bool) bool {
+	var orphans []*node[V]
+	if !t.delete(t.root, r, match, &orphans) {
+		return false
+	}
+	t.length--
+
+	// The orphans are ordered by increasing level. If the root lost its only
+	// child, that child becomes the root.
+	if len(t.root.entries) == 0 && len(orphans) > 0 {
+		t.root = orphans[len(orphans)-1]
+		orphans = orphans[:len(orphans)-1]
+	}
+	for _, o := range orphans {
+		for _, e := range o.entries {
+			t.insertAt(e, o.level)
+		}
+	}
+	for t.root.level > 0 && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	if len(t.root.entries) == 0 {
+		t.root = &node[V]{}
+	}
+	return true
+}
+
+// delete removes a matching entry from the subtree of n, and returns true if
+// one was found. Nodes left with too few entries are removed from the tree
+// and added to orphans, to be reinserted.
+func (t *Tree[V]) delete(n *node[V], r Rect, match func(V) bool, orphans *[]*node[V]) bool {
+	if n.level == 0 {
+		for i, e := range n.entries {
+			if e.rect == r && match(e.value) {
+				n.entries = slices.Delete(n.entries, i, i+1)
+				return true
+			}
+		}
+		return false
+	}
+	for i := range n.entries {
+		e := &n.entries[i]
+		if !e.rect.Contains(r) || !t.delete(e.child, r, match, orphans) {
+			continue
+		}
+		if len(e.child.entries) < t.minEntries {
+			if len(e.child.entries) > 0 {
+				*orphans = append(*orphans, e.child)
+			}
+			n.entries = slices.Delete(n.entries, i, i+1)
+		} else {
+			e.rect = e.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+// SearchIntersect returns an iterator over the entries in the tree whose
+// rectangles intersect r, in unspecified order.
+func (t *Tree[V]) SearchIntersect(r Rect) iter.Seq[Entry[V]] {
+	return func(yield func(Entry[V]) bool) {
+		t.search(t.root, r, yield)
+	}
+}
+
+func (t *Tree[V]) search(n *node[V], r Rect, yield func(Entry[V]) bool) bool {
+	for _, e := range n.entries {
+		if !e.rect.Intersects(r) {
+			continue
+		}
+		if n.level == 0 {
+			if !yield(Entry[V]{e.rect, e.value}) {
+				return false
+			}
+		} else if !t.search(e.child, r, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// All returns an iterator over all the entries in the tree, in unspecified
+// order.
+func (t *Tree[V]) All() iter.Seq[Entry[V]] {
+	return func(yield func(Entry[V]) bool) {
+		t.walk(t.root, yield)
+	}
+}
+
+func (t *Tree[V]) walk(n *node[V], yield func(Entry[V]) bool) bool {
+	for _, e := range n.entries {
+		if n.level == 0 {
+			if !yield(Entry[V]{e.rect, e.value}) {
+				return false
+			}
+		} else if !t.walk(e.child, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Nearest returns an iterator over the entries in the tree by increasing
+// distance of their rectangles from the point (x, y), with these distances;
+// rectangles containing the point are at distance 0. Entries at equal
+// distances are yielded in unspecified order. Stopping the iteration early
+// (e.g. after the k nearest entries) saves work: each step takes O(log n)
+// time on average.
+func (t *Tree[V]) Nearest(x, y float64) iter.Seq2[Entry[V], float64] {
+	return func(yield func(Entry[V], float64) bool) {
+		// Best-first search: the queue holds both subtrees, labeled with the
+		// distance to their bounding boxes, and data entries, which are
+		// yielded when no subtree in the queue can hold closer ones.
+		type item struct {
+			dist float64
+			e    entry[V]
+			data bool
+		}
+		q := heap.NewDAry(4, func(a, b item) int {
+			return compareFloats(a.dist, b.dist)
+		})
+		pushEntries := func(n *node[V]) {
+			for _, e := range n.entries {
+				q.Push(item{rectDist(e.rect, x, y), e, n.level == 0})
+			}
+		}
+		pushEntries(t.root)
+		for q.Len() > 0 {
+			it := q.Pop()
+			if !it.data {
+				pushEntries(it.e.child)
+			} else if !yield(Entry[V]{it.e.rect, it.e.value}, it.dist) {
+				return
+			}
+		}
+	}
+}
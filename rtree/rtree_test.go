@@ -0,0 +1,259 @@
+package rtree
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func randomRect(rnd *rand.Rand) Rect {
+	x, y := rnd.Float64()*1000, rnd.Float64()*1000
+	if rnd.IntN(4) == 0 {
+		return Point(x, y)
+	}
+	return Rect{
+		Min: [2]float64{x, y},
+		Max: [2]float64{x + rnd.Float64()*50, y + rnd.Float64()*50},
+	}
+}
+
+// checkVerify checks the structural invariants of the tree: all leaves are
+// at level 0 and all the entries of a node at level l point to nodes at
+// level l-1, labels are exact bounding boxes, and non-root nodes hold
+// between minEntries and maxEntries entries.
+func checkVerify[V any](t *testing.T, tr *Tree[V]) {
+	t.Helper()
+	count := 0
+	var verify func(n *node[V])
+	verify = func(n *node[V]) {
+		if len(n.entries) > tr.maxEntries || (n != tr.root && len(n.entries) < tr.minEntries) {
+			t.Fatalf("node at level %d has %d entries", n.level, len(n.entries))
+		}
+		if n == tr.root && n.level > 0 && len(n.entries) < 2 {
+			t.Fatalf("internal root has %d entries", len(n.entries))
+		}
+		for _, e := range n.entries {
+			if n.level == 0 {
+				count++
+				continue
+			}
+			if e.child.level != n.level-1 {
+				t.Fatalf("child at level %d under node at level %d", e.child.level, n.level)
+			}
+			if e.rect != e.child.bounds() {
+				t.Fatalf("entry rect %v != child bounds %v", e.rect, e.child.bounds())
+			}
+			verify(e.child)
+		}
+	}
+	verify(tr.root)
+	if count != tr.Len() {
+		t.Fatalf("tree holds %d entries, Len=%d", count, tr.Len())
+	}
+}
+
+func entryLess(a, b Entry[int]) int {
+	return cmp.Compare(a.Value, b.Value)
+}
+
+func TestRectMeasures(t *testing.T) {
+	a := Rect{Min: [2]float64{0, 0}, Max: [2]float64{2, 2}}
+	b := Rect{Min: [2]float64{2, 1}, Max: [2]float64{3, 5}}
+	if rectArea(a) != 4 || rectMargin(b) != 5 || rectCenter(b, 1) != 6 {
+		t.Errorf("bad area, margin or center")
+	}
+	if rectOverlap(a, b) != 0 || rectOverlap(a, Rect{Min: [2]float64{1, 1}, Max: [2]float64{5, 5}}) != 1 {
+		t.Errorf("bad overlap")
+	}
+	if rectDist(a, 1, 1) != 0 || rectDist(a, 5, 6) != 5 || rectDist(a, -1, 1) != 1 {
+		t.Errorf("bad dist")
+	}
+}
+
+func TestInsertSearch(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, maxEntries := range []int{4, 5, 16, 50} {
+		tr := NewWithMaxEntries[int](maxEntries)
+		var entries []Entry[int]
+		for i := range 2000 {
+			r := randomRect(rnd)
+			tr.Insert(r, i)
+			entries = append(entries, Entry[int]{r, i})
+			if i%200 == 0 {
+				checkVerify(t, tr)
+			}
+		}
+		checkVerify(t, tr)
+
+		all := slices.SortedFunc(tr.All(), entryLess)
+		if !slices.Equal(all, entries) {
+			t.Fatalf("All mismatch")
+		}
+		for range 200 {
+			q := randomRect(rnd)
+			q.Max[0] += rnd.Float64() * 100
+			q.Max[1] += rnd.Float64() * 100
+			var want []Entry[int]
+			for _, e := range entries {
+				if e.Rect.Intersects(q) {
+					want = append(want, e)
+				}
+			}
+			got := slices.SortedFunc(tr.SearchIntersect(q), entryLess)
+			if !slices.Equal(got, want) {
+				t.Fatalf("SearchIntersect(%v) = %v, want %v", q, got, want)
+			}
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := NewWithMaxEntries[int](6)
+	var entries []Entry[int]
+	for i := range 1500 {
+		// Duplicate rectangles, with different payloads.
+		r := randomRect(rnd)
+		for j := range 1 + i%2 {
+			tr.Insert(r, 2*i+j)
+			entries = append(entries, Entry[int]{r, 2*i + j})
+		}
+	}
+	rnd.Shuffle(len(entries), func(i, j int) {
+		entries[i], entries[j] = entries[j], entries[i]
+	})
+
+	for i, e := range entries {
+		if tr.Delete(e.Rect, func(v int) bool { return v == -1 }) {
+			t.Fatalf("deleted with non-matching payload")
+		}
+		if !tr.Delete(e.Rect, func(v int) bool { return v == e.Value }) {
+			t.Fatalf("Delete(%v, %d) failed", e.Rect, e.Value)
+		}
+		if tr.Delete(e.Rect, func(v int) bool { return v == e.Value }) {
+			t.Fatalf("Delete(%v, %d) succeeded twice", e.Rect, e.Value)
+		}
+		if tr.Len() != len(entries)-i-1 {
+			t.Fatalf("Len=%d, want %d", tr.Len(), len(entries)-i-1)
+		}
+		if i%100 == 0 {
+			checkVerify(t, tr)
+			want := slices.SortedFunc(slices.Values(entries[i+1:]), entryLess)
+			if got := slices.SortedFunc(tr.All(), entryLess); !slices.Equal(got, want) {
+				t.Fatalf("All mismatch after %d deletions", i+1)
+			}
+		}
+	}
+	checkVerify(t, tr)
+	if _, ok := tr.Bounds(); ok || tr.Len() != 0 {
+		t.Errorf("tree not empty after deleting all entries")
+	}
+
+	// The tree is usable after being emptied.
+	tr.Insert(Point(1, 2), 7)
+	if b, ok := tr.Bounds(); !ok || b != Point(1, 2) {
+		t.Errorf("Bounds = %v, %v", b, ok)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := New[int]()
+	var entries []Entry[int]
+	for i := range 3000 {
+		r := randomRect(rnd)
+		tr.Insert(r, i)
+		entries = append(entries, Entry[int]{r, i})
+	}
+	for range 100 {
+		x, y := rnd.Float64()*1200-100, rnd.Float64()*1200-100
+		want := slices.Clone(entries)
+		slices.SortFunc(want, func(a, b Entry[int]) int {
+			return cmp.Compare(rectDist(a.Rect, x, y), rectDist(b.Rect, x, y))
+		})
+
+		i := 0
+		prev := 0.0
+		for e, d := range tr.Nearest(x, y) {
+			if d != rectDist(e.Rect, x, y) || d < prev || d != rectDist(want[i].Rect, x, y) {
+				t.Fatalf("Nearest(%v, %v)[%d] = %v at %v, want distance %v", x, y, i, e, d, rectDist(want[i].Rect, x, y))
+			}
+			prev = d
+			i++
+			if i == 20 {
+				break
+			}
+		}
+		if i != 20 {
+			t.Fatalf("Nearest yielded %d entries", i)
+		}
+	}
+
+	n := 0
+	for range tr.Nearest(0, 0) {
+		n++
+	}
+	if n != len(entries) {
+		t.Errorf("Nearest yielded %d entries, want %d", n, len(entries))
+	}
+	for range New[int]().Nearest(0, 0) {
+		t.Errorf("empty tree yielded an entry")
+	}
+}
+
+func TestBounds(t *testing.T) {
+	tr := New[string]()
+	if _, ok := tr.Bounds(); ok {
+		t.Errorf("empty tree has bounds")
+	}
+	tr.Insert(Rect{Min: [2]float64{0, 0}, Max: [2]float64{1, 1}}, "a")
+	tr.Insert(Point(-3, 4), "b")
+	if b, _ := tr.Bounds(); b != (Rect{Min: [2]float64{-3, 0}, Max: [2]float64{1, 4}}) {
+		t.Errorf("Bounds = %v", b)
+	}
+}
+
+func TestInvalidMaxEntries(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewWithMaxEntries(3) didn't panic")
+		}
+	}()
+	NewWithMaxEntries[int](3)
+}
+
+func BenchmarkInsert(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	rects := make([]Rect, 100000)
+	for i := range rects {
+		rects[i] = randomRect(rnd)
+	}
+	b.ResetTimer()
+	for range b.N {
+		tr := New[int]()
+		for i, r := range rects {
+			tr.Insert(r, i)
+		}
+	}
+}
+
+func BenchmarkSearchIntersect(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	tr := New[int]()
+	for i := range 100000 {
+		tr.Insert(randomRect(rnd), i)
+	}
+	b.ResetTimer()
+	for range b.N {
+		for range tr.SearchIntersect(randomRect(rnd)) {
+		}
+	}
+}
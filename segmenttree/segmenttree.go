@@ -0,0 +1,253 @@
+// Package segmenttree implements segment trees: structures over a sequence
+// of values answering queries that combine the values of a range, while
+// supporting updates.
+//
+// The combining function must be associative, and identity must be its
+// identity element (combine(identity, x) == combine(x, identity) == x); it
+// doesn't have to be commutative. Examples are sums (with identity 0), min
+// (with identity +Inf), or string concatenation (with identity "").
+package segmenttree
+
+// Tree is a segment tree supporting point updates and range queries, both in
+// O(log n) time. Create it with [New].
+type Tree[T any] struct {
+	n        int
+	nodes    []T
+	combine  func(a, b T) T
+	identity T
+}
+
+// New creates a segment tree over a copy of values.
+func New[T any](values []T, combine func(a, b T) T, identity T) *Tree[T] {
+	n := len(values)
+	// The leaves are nodes[n:2n], and each node i < n combines nodes 2i and
+	// 2i+1. This layout works for any n, though for n that isn't a power of
+	// two some internal nodes combine non-adjacent ranges; queries only use
+	// the nodes that represent contiguous ranges.
+	t := &Tree[T]{n: n, nodes: make([]T, 2*n), combine: combine, identity: identity}
+	copy(t.nodes[n:], values)
+	for i := n - 1; i > 0; i-- {
+		t.nodes[i] = combine(t.nodes[2*i], t.nodes[2*i+1])
+	}
+	return t
+}
+
+// Len returns the number of values in the tree.
+func (t *Tree[T]) Len() int {
+	return t.n
+}
+
+// Get returns the value at index i. It panics if i is out of range.
+func (t *Tree[T]) Get(i int) T {
+	checkIndex(i, t.n)
+	return t.nodes[t.n+i]
+}
+
+// Set sets the value at index i to v. It panics if i is out of range.
+func (t *Tree[T]) Set(i int, v T) {
+	checkIndex(i, t.n)
+	i += t.n
+	t.nodes[i] = v
+	for i > 1 {
+		i /= 2
+		t.nodes[i] = t.combine(t.nodes[2*i], t.nodes[2*i+1])
+	}
+}
+
+// Query returns the combination of the values in the range [lo, hi), in
+// order; it returns the identity for an empty range. It panics if the range
+// is invalid.
+func (t *Tree[T]) Query(lo, hi int) T {
+	checkRange(lo, hi, t.n)
+	// Walk up from both ends, accumulating separately on each side so that
+	// the order of the combination is preserved.
+	left, right := t.identity, t.identity
+	for lo, hi = lo+t.n, hi+t.n; lo < hi; lo, hi = lo/2, hi/2 {
+		if lo&1 == 1 {
+			left = t.combine(left, t.nodes[lo])
+			lo++
+		}
+		if hi&1 == 1 {
+			hi--
+			right = t.combine(t.nodes[hi], right)
+		}
+	}
+	return t.combine(left, right)
+}
+
+// LazyTree is a segment tree that also supports updating all the values in
+// a range in O(log n) time, by deferring updates to subtrees until they're
+// needed. Create it with [NewLazy].
+//
+// Updates have type U. apply(u, agg, n) returns the result of applying
+// update u to each of the n values combined in agg; for example, for
+// range-add updates on sums it's agg+u*n, and for range-add on minimums it's
+// agg+u. compose(newer, older) returns a single update equivalent to
+// applying older and then newer.
+type LazyTree[T, U any] struct {
+	n        int
+	nodes    []T
+	pending  []U
+	has      []bool
+	combine  func(a, b T) T
+	identity T
+	apply    func(u U, agg T, n int) T
+	compose  func(newer, older U) U
+}
+
+// NewLazy creates a lazy segment tree over a copy of values.
+func NewLazy[T, U any](values []T, combine func(a, b T) T, identity T, apply func(u U, agg T, n int) T, compose func(newer, older U) U) *LazyTree[T, U] {
+	n := len(values)
+	size := 1
+	for size < n {
+		size *= 2
+	}
+	t := &LazyTree[T, U]{
+		n:        n,
+		nodes:    make([]T, 2*size),
+		pending:  make([]U, 2*size),
+		has:      make([]bool, 2*size),
+		combine:  combine,
+		identity: identity,
+		apply:    apply,
+		compose:  compose,
+	}
+	if n > 0 {
+		t.build(1, 0, n, values)
+	}
+	return t
+}
+
+// Len returns the number of values in the tree.
+func (t *LazyTree[T, U]) Len() int {
+	return t.n
+}
+
+// Get returns the value at index i. It panics if i is out of range.
+func (t *LazyTree[T, U]) Get(i int) T {
+	checkIndex(i, t.n)
+	return t.Query(i, i+1)
+}
+
+// Set sets the value at index i to v. It panics if i is out of range.
+func (t *LazyTree[T, U]) Set(i int, v T) {
+	checkIndex(i, t.n)
+	t.set(1, 0, t.n, i, v)
+}
+
+// Query returns the combination of the values in the range [lo, hi), in
+// order; it returns the identity for an empty range. It panics if the range
+// is invalid.
+func (t *LazyTree[T, U]) Query(lo, hi int) T {
+	checkRange(lo, hi, t.n)
+	if lo == hi {
+		return t.identity
+	}
+	return t.query(1, 0, t.n, lo, hi)
+}
+
+// Update applies u to all the values in the range [lo, hi). It panics if
+// the range is invalid.
+func (t *LazyTree[T, U]) Update(lo, hi int, u U) {
+	checkRange(lo, hi, t.n)
+	if lo < hi {
+		t.update(1, 0, t.n, lo, hi, u)
+	}
+}
+
+// In the recursive helpers below, node i covers the range [nlo, nhi) of
+// values, and its children 2i and 2i+1 cover the two halves of that range.
+
+func (t *LazyTree[T, U]) build(i, nlo, nhi int, values []T) {
+	if nhi-nlo == 1 {
+		t.nodes[i] = values[nlo]
+		return
+	}
+	mid := (nlo + nhi) / 2
+	t.build(2*i, nlo, mid, values)
+	t.build(2*i+1, mid, nhi, values)
+	t.nodes[i] = t.combine(t.nodes[2*i], t.nodes[2*i+1])
+}
+
+// applyTo applies u to node i covering n values, deferring it for the
+// node's children.
+func (t *LazyTree[T, U]) applyTo(i, n int, u U) {
+	t.nodes[i] = t.apply(u, t.nodes[i], n)
+	if t.has[i] {
+		t.pending[i] = t.compose(u, t.pending[i])
+	} else {
+		t.pending[i] = u
+		t.has[i] = true
+	}
+}
+
+// pushDown propagates node i's pending update to its children.
+func (t *LazyTree[T, U]) pushDown(i, nlo, nhi int) {
+	if !t.has[i] {
+		return
+	}
+	mid := (nlo + nhi) / 2
+	t.applyTo(2*i, mid-nlo, t.pending[i])
+	t.applyTo(2*i+1, nhi-mid, t.pending[i])
+	t.pending[i] = *new(U)
+	t.has[i] = false
+}
+
+func (t *LazyTree[T, U]) set(i, nlo, nhi, pos int, v T) {
+	if nhi-nlo == 1 {
+		t.nodes[i] = v
+		return
+	}
+	t.pushDown(i, nlo, nhi)
+	mid := (nlo + nhi) / 2
+	if pos < mid {
+		t.set(2*i, nlo, mid, pos, v)
+	} else {
+		t.set(2*i+1, mid, nhi, pos, v)
+	}
+	t.nodes[i] = t.combine(t.nodes[2*i], t.nodes[2*i+1])
+}
+
+func (t *LazyTree[T, U]) query(i, nlo, nhi, lo, hi int) T {
+	if lo <= nlo && nhi <= hi {
+		return t.nodes[i]
+	}
+	t.pushDown(i, nlo, nhi)
+	mid := (nlo + nhi) / 2
+	switch {
+	case hi <= mid:
+		return t.query(2*i, nlo, mid, lo, hi)
+	case lo >= mid:
+		return t.query(2*i+1, mid, nhi, lo, hi)
+	default:
+		return t.combine(t.query(2*i, nlo, mid, lo, hi), t.query(2*i+1, mid, nhi, lo, hi))
+	}
+}
+
+func (t *LazyTree[T, U]) update(i, nlo, nhi, lo, hi int, u U) {
+	if lo <= nlo && nhi <= hi {
+		t.applyTo(i, nhi-nlo, u)
+		return
+	}
+	t.pushDown(i, nlo, nhi)
+	mid := (nlo + nhi) / 2
+	if lo < mid {
+		t.update(2*i, nlo, mid, lo, hi, u)
+	}
+	if hi > mid {
+		t.update(2*i+1, mid, nhi, lo, hi, u)
+	}
+	t.nodes[i] = t.combine(t.nodes[2*i], t.nodes[2*i+1])
+}
+
+func checkIndex(i, n int) {
+	if i < 0 || i >= n {
+		panic("segmenttree: index out of range")
+	}
+}
+
+func checkRange(lo, hi, n int) {
+	if lo < 0 || hi < lo || hi > n {
+		panic("segmenttree: range out of bounds")
+	}
+}
@@ -0,0 +1,136 @@
+package segmenttree
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func concat(a, b string) string { return a + b }
+
+func TestNonCommutative(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, n := range []int{0, 1, 2, 5, 13, 64, 100} {
+		values := make([]string, n)
+		for i := range values {
+			values[i] = string(rune('a' + i%26))
+		}
+		st := New(values, concat, "")
+		if st.Len() != n {
+			t.Fatalf("got Len=%d, want %d", st.Len(), n)
+		}
+		for range 200 {
+			if n > 0 && rnd.IntN(3) == 0 {
+				i := rnd.IntN(n)
+				values[i] = strings.ToUpper(values[i])
+				st.Set(i, values[i])
+				if st.Get(i) != values[i] {
+					t.Fatalf("Get(%d)=%q after Set", i, st.Get(i))
+				}
+			}
+			lo := rnd.IntN(n + 1)
+			hi := lo + rnd.IntN(n-lo+1)
+			if got, want := st.Query(lo, hi), strings.Join(values[lo:hi], ""); got != want {
+				t.Fatalf("n=%d: Query(%d, %d)=%q, want %q", n, lo, hi, got, want)
+			}
+		}
+	}
+}
+
+func TestLazySumAdd(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const n = 37
+	values := make([]int, n)
+	for i := range values {
+		values[i] = rnd.IntN(100)
+	}
+	st := NewLazy(values,
+		func(a, b int) int { return a + b }, 0,
+		func(u, agg, n int) int { return agg + u*n },
+		func(newer, older int) int { return newer + older })
+
+	for range 2000 {
+		lo := rnd.IntN(n + 1)
+		hi := lo + rnd.IntN(n-lo+1)
+		switch rnd.IntN(3) {
+		case 0:
+			u := rnd.IntN(21) - 10
+			st.Update(lo, hi, u)
+			for i := lo; i < hi; i++ {
+				values[i] += u
+			}
+		case 1:
+			if lo < n {
+				v := rnd.IntN(100)
+				st.Set(lo, v)
+				values[lo] = v
+			}
+		case 2:
+			want := 0
+			for _, v := range values[lo:hi] {
+				want += v
+			}
+			if got := st.Query(lo, hi); got != want {
+				t.Fatalf("Query(%d, %d)=%d, want %d", lo, hi, got, want)
+			}
+		}
+	}
+	for i, v := range values {
+		if st.Get(i) != v {
+			t.Fatalf("Get(%d)=%d, want %d", i, st.Get(i), v)
+		}
+	}
+}
+
+func TestLazyMinAssign(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const n = 50
+	values := make([]int, n)
+	for i := range values {
+		values[i] = rnd.IntN(1000)
+	}
+	// Range assignment updates over range minimum queries; the newer of two
+	// assignments wins.
+	st := NewLazy(values,
+		func(a, b int) int { return min(a, b) }, math.MaxInt,
+		func(u, agg, n int) int { return u },
+		func(newer, older int) int { return newer })
+
+	for range 2000 {
+		lo := rnd.IntN(n + 1)
+		hi := lo + rnd.IntN(n-lo+1)
+		if rnd.IntN(2) == 0 {
+			u := rnd.IntN(1000)
+			st.Update(lo, hi, u)
+			for i := lo; i < hi; i++ {
+				values[i] = u
+			}
+		} else {
+			want := math.MaxInt
+			for _, v := range values[lo:hi] {
+				want = min(want, v)
+			}
+			if got := st.Query(lo, hi); got != want {
+				t.Fatalf("Query(%d, %d)=%d, want %d", lo, hi, got, want)
+			}
+		}
+	}
+}
+
+func TestLazyEmpty(t *testing.T) {
+	st := NewLazy([]int{}, func(a, b int) int { return a + b }, 0,
+		func(u, agg, n int) int { return agg + u*n },
+		func(newer, older int) int { return newer + older })
+	if st.Len() != 0 || st.Query(0, 0) != 0 {
+		t.Errorf("bad empty tree")
+	}
+	st.Update(0, 0, 5)
+}
@@ -0,0 +1,175 @@
+// Package skiplist implements an ordered map backed by a skip list.
+package skiplist
+
+import (
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+)
+
+// maxLevel is the maximal number of levels in a skip list; with p=1/4 it
+// supports up to 4^maxLevel elements efficiently.
+const maxLevel = 24
+
+// SkipList is an ordered map from keys of type K to values of type V. It
+// keeps its elements in a sorted linked list, augmented with a hierarchy of
+// sparser "express" lists that allow operations in O(log n) expected time.
+// Create skip lists with [New].
+type SkipList[K, V any] struct {
+	cmp    func(K, K) int
+	head   *node[K, V]
+	level  int
+	length int
+	rnd    *rand.Rand
+}
+
+// node is an element of the skip list; next[i] is the next node in the
+// level-i list. Every node is in the level-0 list, and each node is in
+// level i+1 with probability 1/4 if it's in level i.
+type node[K, V any] struct {
+	key   K
+	value V
+	next  []*node[K, V]
+}
+
+// New creates a new, empty skip list with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K, V any](cmp func(K, K) int) *SkipList[K, V] {
+	return &SkipList[K, V]{
+		cmp:   cmp,
+		head:  &node[K, V]{next: make([]*node[K, V], maxLevel)},
+		level: 1,
+		rnd:   rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// Len returns the number of elements in the skip list.
+func (sl *SkipList[K, V]) Len() int {
+	return sl.length
+}
+
+// Get looks for key in the skip list. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (sl *SkipList[K, V]) Get(key K) (v V, ok bool) {
+	n := sl.findGreaterOrEqual(key, nil)
+	if n != nil && sl.cmp(n.key, key) == 0 {
+		return n.value, true
+	}
+	return v, false
+}
+
+// Insert inserts key with the given value into the skip list. If key already
+// exists, its value is replaced.
+func (sl *SkipList[K, V]) Insert(key K, value V) {
+	var update [maxLevel]*node[K, V]
+	n := sl.findGreaterOrEqual(key, &update)
+	if n != nil && sl.cmp(n.key, key) == 0 {
+		n.value = value
+		return
+	}
+
+	level := sl.randomLevel()
+	if level > sl.level {
+		for i := sl.level; i < level; i++ {
+			update[i] = sl.head
+		}
+		sl.level = level
+	}
+	n = &node[K, V]{key: key, value: value, next: make([]*node[K, V], level)}
+	for i := range level {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+	sl.length++
+}
+
+// Delete deletes key and its value from the skip list. It returns true if
+// the key was found and deleted, false otherwise.
+func (sl *SkipList[K, V]) Delete(key K) bool {
+	var update [maxLevel]*node[K, V]
+	n := sl.findGreaterOrEqual(key, &update)
+	if n == nil || sl.cmp(n.key, key) != 0 {
+		return false
+	}
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+	for sl.level > 1 && sl.head.next[sl.level-1] == nil {
+		sl.level--
+	}
+	sl.length--
+	return true
+}
+
+// Min returns the smallest key in the skip list with its value. It returns
+// ok=false if the list is empty.
+func (sl *SkipList[K, V]) Min() (k K, v V, ok bool) {
+	if n := sl.head.next[0]; n != nil {
+		return n.key, n.value, true
+	}
+	return k, v, false
+}
+
+// Max returns the largest key in the skip list with its value. It returns
+// ok=false if the list is empty.
+func (sl *SkipList[K, V]) Max() (k K, v V, ok bool) {
+	n := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for n.next[i] != nil {
+			n = n.next[i]
+		}
+	}
+	if n == sl.head {
+		return k, v, false
+	}
+	return n.key, n.value, true
+}
+
+// All returns an iterator over all key, value pairs in the skip list, in
+// ascending order of keys.
+func (sl *SkipList[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := sl.head.next[0]; n != nil; n = n.next[0] {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys.
+func (sl *SkipList[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for n := sl.findGreaterOrEqual(lo, nil); n != nil && sl.cmp(n.key, hi) < 0; n = n.next[0] {
+			if !yield(n.key, n.value) {
+				return
+			}
+		}
+	}
+}
+
+// findGreaterOrEqual returns the first node with a key >= key, or nil if
+// there's no such node. If update is not nil, update[i] is set to the last
+// node in level i with a key < key.
+func (sl *SkipList[K, V]) findGreaterOrEqual(key K, update *[maxLevel]*node[K, V]) *node[K, V] {
+	n := sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for n.next[i] != nil && sl.cmp(n.next[i].key, key) < 0 {
+			n = n.next[i]
+		}
+		if update != nil {
+			update[i] = n
+		}
+	}
+	return n.next[0]
+}
+
+// randomLevel returns a random level for a new node: 1 with probability 3/4,
+// 2 with probability 3/16, and so on.
+func (sl *SkipList[K, V]) randomLevel() int {
+	// Each pair of random bits being zero has probability 1/4.
+	level := 1 + bits.TrailingZeros64(sl.rnd.Uint64())/2
+	return min(level, maxLevel)
+}
@@ -0,0 +1,148 @@
+package skiplist
+
+import (
+	"cmp"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// checkList verifies the skip list's structure and that its contents match
+// want.
+func checkList(t *testing.T, sl *SkipList[int, int], want map[int]int) {
+	t.Helper()
+	if sl.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", sl.Len(), len(want))
+	}
+	keys := slices.Sorted(maps.Keys(want))
+	var got []int
+	for k, v := range sl.All() {
+		if want[k] != v {
+			t.Fatalf("got %d=%d, want %d", k, v, want[k])
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, keys) {
+		t.Fatalf("got keys %v, want %v", got, keys)
+	}
+
+	// Every level must be a sorted sublist of the level below it.
+	for i := 1; i < sl.level; i++ {
+		below := sl.head.next[i-1]
+		for n := sl.head.next[i]; n != nil; n = n.next[i] {
+			for below != n {
+				if below == nil {
+					t.Fatalf("level %d node %d missing from level %d", i, n.key, i-1)
+				}
+				below = below.next[i-1]
+			}
+		}
+	}
+	if sl.level < maxLevel && sl.head.next[sl.level] != nil {
+		t.Fatalf("nodes above level %d", sl.level)
+	}
+}
+
+func TestBasic(t *testing.T) {
+	sl := New[int, int](cmp.Compare[int])
+	if _, _, ok := sl.Min(); ok {
+		t.Errorf("Min of empty list")
+	}
+	if _, _, ok := sl.Max(); ok {
+		t.Errorf("Max of empty list")
+	}
+	want := map[int]int{}
+	for _, k := range []int{50, 20, 80, 10, 30, 70, 90} {
+		sl.Insert(k, k*10)
+		want[k] = k * 10
+	}
+	sl.Insert(30, 333)
+	want[30] = 333
+	checkList(t, sl, want)
+
+	if v, ok := sl.Get(30); !ok || v != 333 {
+		t.Errorf("got %d, %v", v, ok)
+	}
+	if _, ok := sl.Get(31); ok {
+		t.Errorf("found missing key")
+	}
+	if k, _, _ := sl.Min(); k != 10 {
+		t.Errorf("got Min=%d", k)
+	}
+	if k, _, _ := sl.Max(); k != 90 {
+		t.Errorf("got Max=%d", k)
+	}
+
+	var got []int
+	for k := range sl.Range(25, 80) {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []int{30, 50, 70}) {
+		t.Errorf("got range %v", got)
+	}
+
+	if !sl.Delete(50) || sl.Delete(50) {
+		t.Errorf("bad Delete results")
+	}
+	delete(want, 50)
+	checkList(t, sl, want)
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	sl := New[int, int](cmp.Compare[int])
+	want := map[int]int{}
+	for i := range 20000 {
+		k := rnd.IntN(2000)
+		if rnd.IntN(3) == 0 {
+			_, inMap := want[k]
+			if sl.Delete(k) != inMap {
+				t.Fatalf("Delete(%d) mismatch", k)
+			}
+			delete(want, k)
+		} else {
+			sl.Insert(k, i)
+			want[k] = i
+		}
+		if i%1000 == 0 {
+			checkList(t, sl, want)
+		}
+	}
+	checkList(t, sl, want)
+
+	for range 100 {
+		lo := rnd.IntN(2000)
+		hi := lo + rnd.IntN(200)
+		var got, wantRange []int
+		for k := range sl.Range(lo, hi) {
+			got = append(got, k)
+		}
+		for _, k := range slices.Sorted(maps.Keys(want)) {
+			if k >= lo && k < hi {
+				wantRange = append(wantRange, k)
+			}
+		}
+		if !slices.Equal(got, wantRange) {
+			t.Fatalf("Range(%d, %d)=%v, want %v", lo, hi, got, wantRange)
+		}
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	keys := make([]int, 10000)
+	for i := range keys {
+		keys[i] = rnd.Int()
+	}
+	for range b.N {
+		sl := New[int, int](cmp.Compare[int])
+		for _, k := range keys {
+			sl.Insert(k, k)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package slidingwindow implements a FIFO window that maintains an arbitrary
+// associative aggregate over its contents.
+package slidingwindow
+
+// Aggregator is a FIFO queue maintaining the aggregate of all its elements
+// under an associative combine function (e.g. sum, max, min, gcd, or matrix
+// product). Push, Pop and Aggregate are all O(1) amortized.
+//
+// The combine function doesn't have to be commutative: Aggregate always
+// combines the elements in FIFO order, oldest first.
+//
+// It's implemented using the "two-stacks" sliding-window aggregation (SWAG)
+// technique.
+type Aggregator[T any] struct {
+	combine  func(T, T) T
+	identity T
+
+	// front holds the oldest elements, with the oldest at the top (end of the
+	// slice). Each entry holds the aggregate of its value together with all
+	// the entries below it on the stack (the newer elements of front).
+	front []aggEntry[T]
+
+	// back holds the newest elements in push order, and backAgg is the
+	// aggregate of all their values.
+	back    []T
+	backAgg T
+}
+
+type aggEntry[T any] struct {
+	value T
+	agg   T
+}
+
+// New creates a new, empty Aggregator with the given combine function and
+// its identity element (e.g. 0 for sum, or -Inf for max).
+func New[T any](combine func(a, b T) T, identity T) *Aggregator[T] {
+	return &Aggregator[T]{combine: combine, identity: identity, backAgg: identity}
+}
+
+// Len returns the number of elements in the window.
+func (a *Aggregator[T]) Len() int {
+	return len(a.front) + len(a.back)
+}
+
+// Push adds v as the newest element of the window.
+func (a *Aggregator[T]) Push(v T) {
+	a.back = append(a.back, v)
+	a.backAgg = a.combine(a.backAgg, v)
+}
+
+// Pop removes the oldest element from the window and returns it. It panics if
+// the window is empty; make sure to check Len() first.
+func (a *Aggregator[T]) Pop() T {
+	if a.Len() == 0 {
+		panic("popping from empty window")
+	}
+	if len(a.front) == 0 {
+		a.flip()
+	}
+	top := a.front[len(a.front)-1]
+	a.front[len(a.front)-1] = aggEntry[T]{}
+	a.front = a.front[:len(a.front)-1]
+	return top.value
+}
+
+// Aggregate returns the aggregate of all the elements in the window, combined
+// from oldest to newest. For an empty window it returns the identity.
+func (a *Aggregator[T]) Aggregate() T {
+	if len(a.front) == 0 {
+		return a.backAgg
+	}
+	return a.combine(a.front[len(a.front)-1].agg, a.backAgg)
+}
+
+// flip moves all the elements from back to front, computing the suffix
+// aggregates along the way.
+func (a *Aggregator[T]) flip() {
+	agg := a.identity
+	for i := len(a.back) - 1; i >= 0; i-- {
+		agg = a.combine(a.back[i], agg)
+		a.front = append(a.front, aggEntry[T]{value: a.back[i], agg: agg})
+	}
+	clear(a.back)
+	a.back = a.back[:0]
+	a.backAgg = a.identity
+}
@@ -0,0 +1,88 @@
+package slidingwindow
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	a := New(func(x, y int) int { return x + y }, 0)
+	if a.Aggregate() != 0 {
+		t.Errorf("got %v for empty window", a.Aggregate())
+	}
+	a.Push(1)
+	a.Push(2)
+	a.Push(3)
+	if a.Aggregate() != 6 {
+		t.Errorf("got %v, want 6", a.Aggregate())
+	}
+	if v := a.Pop(); v != 1 {
+		t.Errorf("got pop=%v, want 1", v)
+	}
+	a.Push(10)
+	if a.Aggregate() != 15 || a.Len() != 3 {
+		t.Errorf("got agg=%v len=%v, want 15, 3", a.Aggregate(), a.Len())
+	}
+}
+
+func TestMax(t *testing.T) {
+	a := New(math.Max, math.Inf(-1))
+	a.Push(3)
+	a.Push(1)
+	a.Push(2)
+	want := []float64{3, 2, 2}
+	for _, w := range want {
+		if a.Aggregate() != w {
+			t.Errorf("got %v, want %v", a.Aggregate(), w)
+		}
+		a.Pop()
+	}
+	if a.Aggregate() != math.Inf(-1) {
+		t.Errorf("got %v, want -Inf", a.Aggregate())
+	}
+}
+
+func TestNonCommutative(t *testing.T) {
+	// String concatenation is associative but not commutative; the aggregate
+	// must preserve FIFO order across front/back flips.
+	concat := func(a, b string) string { return a + b }
+
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	a := New(concat, "")
+	var window []string
+	for i := range 2000 {
+		if len(window) == 0 || rnd.IntN(5) < 3 {
+			s := string(rune('a' + i%26))
+			a.Push(s)
+			window = append(window, s)
+		} else {
+			if v := a.Pop(); v != window[0] {
+				t.Fatalf("got pop=%v, want %v", v, window[0])
+			}
+			window = window[1:]
+		}
+
+		want := ""
+		for _, s := range window {
+			want += s
+		}
+		if got := a.Aggregate(); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestEmptyPanics(t *testing.T) {
+	a := New(func(x, y int) int { return x + y }, 0)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	a.Pop()
+}
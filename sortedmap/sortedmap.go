@@ -0,0 +1,132 @@
+// Package sortedmap implements an ordered map backed by a sorted slice.
+package sortedmap
+
+import (
+	"iter"
+	"slices"
+)
+
+// Map is an ordered map from keys of type K to values of type V, stored as
+// a slice of key, value pairs sorted by key. Lookups take O(log n) time;
+// insertions and deletions take O(n) time, but for small maps (up to a few
+// hundred elements) the contiguous layout makes Map faster and more compact
+// than a B-tree.
+//
+// Map has the same API as the module's balanced trees (such as btree), so
+// callers can switch between them. Create maps with [New].
+type Map[K, V any] struct {
+	cmp     func(K, K) int
+	entries []entry[K, V]
+}
+
+type entry[K, V any] struct {
+	key   K
+	value V
+}
+
+// New creates a new, empty map with the given comparison function. cmp(a, b)
+// should return a negative number when a<b, a positive number when a>b and
+// zero when a==b.
+func New[K, V any](cmp func(K, K) int) *Map[K, V] {
+	return &Map[K, V]{cmp: cmp}
+}
+
+// NewWithCapacity is like New, but preallocates space for n entries.
+func NewWithCapacity[K, V any](cmp func(K, K) int, n int) *Map[K, V] {
+	return &Map[K, V]{cmp: cmp, entries: make([]entry[K, V], 0, n)}
+}
+
+// Len returns the number of keys in the map.
+func (m *Map[K, V]) Len() int {
+	return len(m.entries)
+}
+
+// Get looks for the given key in the map. It returns the associated value
+// and ok=true; otherwise, it returns ok=false.
+func (m *Map[K, V]) Get(key K) (v V, ok bool) {
+	if i, found := m.search(key); found {
+		return m.entries[i].value, true
+	}
+	return v, false
+}
+
+// Insert inserts a new key=value pair into the map. If `key` already exists
+// in the map, its value is replaced with `value`.
+func (m *Map[K, V]) Insert(key K, value V) {
+	i, found := m.search(key)
+	if found {
+		m.entries[i].value = value
+		return
+	}
+	m.entries = slices.Insert(m.entries, i, entry[K, V]{key: key, value: value})
+}
+
+// Delete deletes a key and its associated value from the map. It returns
+// true if the key was found and deleted; if key is not found in the map,
+// Delete is a no-op and returns false.
+func (m *Map[K, V]) Delete(key K) bool {
+	i, found := m.search(key)
+	if !found {
+		return false
+	}
+	m.entries = slices.Delete(m.entries, i, i+1)
+	return true
+}
+
+// All returns an iterator over all key, value pairs in the map, in
+// ascending order of keys.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, e := range m.entries {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys.
+func (m *Map[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		i, _ := m.search(lo)
+		for ; i < len(m.entries) && m.cmp(m.entries[i].key, hi) < 0; i++ {
+			if !yield(m.entries[i].key, m.entries[i].value) {
+				return
+			}
+		}
+	}
+}
+
+// Floor finds the largest key in the map that's smaller than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (m *Map[K, V]) Floor(key K) (k K, v V, ok bool) {
+	i, found := m.search(key)
+	if !found {
+		if i == 0 {
+			return k, v, false
+		}
+		i--
+	}
+	return m.entries[i].key, m.entries[i].value, true
+}
+
+// Ceiling finds the smallest key in the map that's larger than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (m *Map[K, V]) Ceiling(key K) (k K, v V, ok bool) {
+	i, _ := m.search(key)
+	if i == len(m.entries) {
+		return k, v, false
+	}
+	return m.entries[i].key, m.entries[i].value, true
+}
+
+// search finds the position of key in the entries, or the position where
+// it would be inserted.
+func (m *Map[K, V]) search(key K) (int, bool) {
+	return slices.BinarySearchFunc(m.entries, key, func(e entry[K, V], key K) int {
+		return m.cmp(e.key, key)
+	})
+}
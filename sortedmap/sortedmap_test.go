@@ -0,0 +1,163 @@
+package sortedmap
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/eliben/gogl/btree"
+)
+
+// orderedMap is the API Map shares with the module's trees; the tests and
+// benchmarks below run against both Map and btree through it.
+type orderedMap interface {
+	Len() int
+	Get(key int) (int, bool)
+	Insert(key, value int)
+	Delete(key int) bool
+	All() iter.Seq2[int, int]
+	Range(lo, hi int) iter.Seq2[int, int]
+	Floor(key int) (int, int, bool)
+	Ceiling(key int) (int, int, bool)
+}
+
+var (
+	_ orderedMap = (*Map[int, int])(nil)
+	_ orderedMap = (*btree.BTree[int, int])(nil)
+)
+
+func checkMap(t *testing.T, m orderedMap, want map[int]int) {
+	t.Helper()
+	if m.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", m.Len(), len(want))
+	}
+	keys := slices.Sorted(maps.Keys(want))
+	var got []int
+	for k, v := range m.All() {
+		if want[k] != v {
+			t.Fatalf("got %d=%d, want %d", k, v, want[k])
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, keys) {
+		t.Fatalf("got keys %v, want %v", got, keys)
+	}
+}
+
+func TestBasic(t *testing.T) {
+	m := New[string, int](cmp.Compare[string])
+	m.Insert("pear", 3)
+	m.Insert("apple", 1)
+	m.Insert("fig", 2)
+	m.Insert("apple", 10)
+
+	if v, ok := m.Get("apple"); !ok || v != 10 {
+		t.Errorf("Get(apple)=%d,%v", v, ok)
+	}
+	if _, ok := m.Get("kiwi"); ok {
+		t.Errorf("found missing key")
+	}
+	if got := slices.Collect(maps.Keys(maps.Collect(m.Range("b", "p")))); len(got) != 1 || got[0] != "fig" {
+		t.Errorf("Range got %v", got)
+	}
+	if k, _, ok := m.Floor("grape"); !ok || k != "fig" {
+		t.Errorf("Floor(grape)=%q,%v", k, ok)
+	}
+	if k, _, ok := m.Ceiling("grape"); !ok || k != "pear" {
+		t.Errorf("Ceiling(grape)=%q,%v", k, ok)
+	}
+	if _, _, ok := m.Floor("aardvark"); ok {
+		t.Errorf("Floor below min found a key")
+	}
+	if _, _, ok := m.Ceiling("zebra"); ok {
+		t.Errorf("Ceiling above max found a key")
+	}
+	if !m.Delete("fig") || m.Delete("fig") || m.Len() != 2 {
+		t.Errorf("bad Delete")
+	}
+}
+
+func TestRandomAgainstBTree(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, m := range []orderedMap{New[int, int](cmp.Compare[int]), btree.New[int, int](cmp.Compare[int])} {
+		want := make(map[int]int)
+		for range 5000 {
+			k := rnd.IntN(500)
+			if rnd.IntN(3) == 0 {
+				_, had := want[k]
+				if m.Delete(k) != had {
+					t.Fatalf("%T: Delete(%d) mismatch", m, k)
+				}
+				delete(want, k)
+			} else {
+				v := rnd.Int()
+				m.Insert(k, v)
+				want[k] = v
+			}
+
+			keys := slices.Sorted(maps.Keys(want))
+			q := rnd.IntN(520) - 10
+			i, found := slices.BinarySearch(keys, q)
+			fi := i
+			if !found {
+				fi--
+			}
+			fk, _, fok := m.Floor(q)
+			if wantOk := fi >= 0; fok != wantOk || (fok && fk != keys[fi]) {
+				t.Fatalf("%T: Floor(%d)=%d,%v", m, q, fk, fok)
+			}
+			ck, _, cok := m.Ceiling(q)
+			if wantOk := i < len(keys); cok != wantOk || (cok && ck != keys[i]) {
+				t.Fatalf("%T: Ceiling(%d)=%d,%v", m, q, ck, cok)
+			}
+			hi := q + rnd.IntN(50)
+			var gotRange []int
+			for k := range m.Range(q, hi) {
+				gotRange = append(gotRange, k)
+			}
+			j, _ := slices.BinarySearch(keys, hi)
+			if !slices.Equal(gotRange, keys[i:max(i, j)]) {
+				t.Fatalf("%T: Range(%d, %d)=%v, want %v", m, q, hi, gotRange, keys[i:max(i, j)])
+			}
+		}
+		checkMap(t, m, want)
+	}
+}
+
+func BenchmarkInsertGet(b *testing.B) {
+	for _, size := range []int{16, 128, 1024, 8192} {
+		rnd := rand.New(rand.NewPCG(1, 2))
+		keys := make([]int, size)
+		for i := range keys {
+			keys[i] = rnd.Int()
+		}
+		backends := []struct {
+			name string
+			make func() orderedMap
+		}{
+			{"sortedmap", func() orderedMap { return New[int, int](cmp.Compare[int]) }},
+			{"btree", func() orderedMap { return btree.New[int, int](cmp.Compare[int]) }},
+		}
+		for _, be := range backends {
+			b.Run(fmt.Sprintf("%s/%d", be.name, size), func(b *testing.B) {
+				for range b.N {
+					m := be.make()
+					for _, k := range keys {
+						m.Insert(k, k)
+					}
+					for _, k := range keys {
+						m.Get(k)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package sparseset implements a sparse set of integers from a bounded
+// universe, with constant-time clearing.
+package sparseset
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Integer is the constraint for the element type of sparse sets.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Set is a set of integers in the range [0, universe), as described by
+// Briggs and Torczon ("An efficient representation for sparse sets", 1993).
+// The elements are stored in a dense slice, and an array indexed by the
+// elements records their positions in it; an element is in the set only if
+// the dense slice holds it at the recorded position, so stale positions
+// don't matter. All operations take O(1) time, including Clear, which
+// doesn't touch memory proportional to the universe - that makes sparse sets
+// ideal when sets are emptied and refilled many times. Iteration takes time
+// proportional to the number of elements, not the universe.
+//
+// Elements are iterated in insertion order, except that deleting an element
+// moves the last element to its place. Create sets with [New].
+type Set[T Integer] struct {
+	dense  []T
+	sparse []uint32
+}
+
+// New creates a new, empty set for elements in the range [0, universe). It
+// takes O(universe) time and space. It panics if universe is negative or
+// larger than 2^32.
+func New[T Integer](universe int) *Set[T] {
+	if universe < 0 || uint64(universe) > 1<<32 {
+		panic(fmt.Sprintf("sparseset: invalid universe size %d", universe))
+	}
+	return &Set[T]{sparse: make([]uint32, universe)}
+}
+
+// Universe returns the size of the set's universe.
+func (s *Set[T]) Universe() int {
+	return len(s.sparse)
+}
+
+// Len returns the number of elements in the set.
+func (s *Set[T]) Len() int {
+	return len(s.dense)
+}
+
+// Contains reports whether x is in the set; values outside the universe
+// are never in the set.
+func (s *Set[T]) Contains(x T) bool {
+	if x < 0 || uint64(x) >= uint64(len(s.sparse)) {
+		return false
+	}
+	i := s.sparse[x]
+	return int(i) < len(s.dense) && s.dense[i] == x
+}
+
+// Add adds x to the set; if it's already in the set, this is a no-op. It
+// panics if x is outside the universe.
+func (s *Set[T]) Add(x T) {
+	if x < 0 || uint64(x) >= uint64(len(s.sparse)) {
+		panic(fmt.Sprintf("sparseset: value %d outside universe [0, %d)", x, len(s.sparse)))
+	}
+	if s.Contains(x) {
+		return
+	}
+	s.sparse[x] = uint32(len(s.dense))
+	s.dense = append(s.dense, x)
+}
+
+// Delete removes x from the set; if it's not in the set, this is a no-op.
+// The last element in iteration order takes x's place.
+func (s *Set[T]) Delete(x T) {
+	if !s.Contains(x) {
+		return
+	}
+	i := s.sparse[x]
+	last := s.dense[len(s.dense)-1]
+	s.dense[i] = last
+	s.sparse[last] = i
+	s.dense = s.dense[:len(s.dense)-1]
+}
+
+// Clear removes all the elements from the set in O(1) time.
+func (s *Set[T]) Clear() {
+	s.dense = s.dense[:0]
+}
+
+// All returns an iterator over all the elements in the set. The set must
+// not be modified during the iteration.
+func (s *Set[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, x := range s.dense {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns the elements of the set, in iteration order. The returned
+// slice is only valid until the set is next modified, and must not be
+// modified by the caller.
+func (s *Set[T]) Values() []T {
+	return s.dense
+}
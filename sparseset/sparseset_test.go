@@ -0,0 +1,106 @@
+package sparseset
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func TestBasic(t *testing.T) {
+	s := New[int](100)
+	if s.Universe() != 100 || s.Len() != 0 || s.Contains(0) {
+		t.Fatalf("bad empty set")
+	}
+	for _, x := range []int{5, 99, 0, 42, 5} {
+		s.Add(x)
+	}
+	if got := slices.Collect(s.All()); !slices.Equal(got, []int{5, 99, 0, 42}) {
+		t.Errorf("All = %v", got)
+	}
+	s.Delete(99)
+	s.Delete(7)
+	if got := s.Values(); !slices.Equal(got, []int{5, 42, 0}) {
+		t.Errorf("Values after Delete = %v", got)
+	}
+	if s.Contains(99) || !s.Contains(42) || s.Contains(-1) || s.Contains(100) {
+		t.Errorf("bad Contains")
+	}
+
+	s.Clear()
+	if s.Len() != 0 || s.Contains(5) || s.Contains(0) {
+		t.Errorf("set not empty after Clear")
+	}
+	// Stale positions from before Clear don't matter.
+	s.Add(42)
+	if s.Contains(5) || !s.Contains(42) || s.Len() != 1 {
+		t.Errorf("bad set after Clear and Add")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	const universe = 1000
+	s := New[uint16](universe)
+	want := make(map[uint16]bool)
+	for i := range 100000 {
+		x := uint16(rnd.IntN(universe))
+		switch op := rnd.IntN(100); {
+		case op < 50:
+			s.Add(x)
+			want[x] = true
+		case op < 99:
+			s.Delete(x)
+			delete(want, x)
+		default:
+			s.Clear()
+			clear(want)
+		}
+		if s.Contains(x) != want[x] || s.Len() != len(want) {
+			t.Fatalf("op %d: Contains(%d)=%v Len=%d, want %v, %d", i, x, s.Contains(x), s.Len(), want[x], len(want))
+		}
+	}
+	got := slices.Sorted(s.All())
+	var expected []uint16
+	for x := range want {
+		expected = append(expected, x)
+	}
+	slices.Sort(expected)
+	if !slices.Equal(got, expected) {
+		t.Errorf("All = %v, want %v", got, expected)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	s := New[int](10)
+	for name, f := range map[string]func(){
+		"New":     func() { New[int](-1) },
+		"Add":     func() { s.Add(10) },
+		"Add neg": func() { s.Add(-1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s didn't panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func BenchmarkFillClear(b *testing.B) {
+	s := New[uint32](1 << 20)
+	for i := range b.N {
+		for x := range uint32(64) {
+			s.Add((x*2654435761 + uint32(i)) % (1 << 20))
+		}
+		s.Clear()
+	}
+}
@@ -0,0 +1,63 @@
+// Package sparsetable implements a sparse table for constant-time range
+// queries over a static sequence.
+package sparsetable
+
+import "math/bits"
+
+// Table answers range queries over a static sequence of values for an
+// operation that is associative and idempotent (op(x, x) == x), such as
+// min, max, gcd, or bitwise and/or. It's built in O(n log n) time and space,
+// and answers queries in O(1) time. Create tables with [New].
+type Table[T any] struct {
+	op func(a, b T) T
+
+	// levels[k][i] is the result of op over the range [i, i+2^k).
+	levels [][]T
+}
+
+// New builds a table over a copy of values for the given operation.
+func New[T any](values []T, op func(a, b T) T) *Table[T] {
+	t := &Table[T]{op: op}
+	if len(values) == 0 {
+		return t
+	}
+	t.levels = append(t.levels, append([]T(nil), values...))
+	for k := 1; 1<<k <= len(values); k++ {
+		prev := t.levels[k-1]
+		half := 1 << (k - 1)
+		level := make([]T, len(values)-(1<<k)+1)
+		for i := range level {
+			level[i] = op(prev[i], prev[i+half])
+		}
+		t.levels = append(t.levels, level)
+	}
+	return t
+}
+
+// Len returns the number of values in the table.
+func (t *Table[T]) Len() int {
+	if len(t.levels) == 0 {
+		return 0
+	}
+	return len(t.levels[0])
+}
+
+// Get returns the value at index i. It panics if i is out of range.
+func (t *Table[T]) Get(i int) T {
+	if i < 0 || i >= t.Len() {
+		panic("sparsetable: index out of range")
+	}
+	return t.levels[0][i]
+}
+
+// Query returns the result of the operation over the values in the range
+// [lo, hi). It panics if the range is empty or out of bounds.
+func (t *Table[T]) Query(lo, hi int) T {
+	if lo < 0 || hi <= lo || hi > t.Len() {
+		panic("sparsetable: invalid range")
+	}
+	// Cover the range with two (possibly overlapping) power-of-two ranges;
+	// the overlap doesn't matter because op is idempotent.
+	k := bits.Len(uint(hi-lo)) - 1
+	return t.op(t.levels[k][lo], t.levels[k][hi-(1<<k)])
+}
@@ -0,0 +1,62 @@
+package sparsetable
+
+import (
+	"log"
+	"math/rand/v2"
+	"testing"
+)
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	ops := map[string]func(a, b int) int{
+		"min": func(a, b int) int { return min(a, b) },
+		"max": func(a, b int) int { return max(a, b) },
+		"gcd": gcd,
+	}
+	for _, n := range []int{1, 2, 3, 16, 17, 100} {
+		values := make([]int, n)
+		for i := range values {
+			values[i] = 6 * (1 + rnd.IntN(50))
+		}
+		for name, op := range ops {
+			st := New(values, op)
+			if st.Len() != n || st.Get(n-1) != values[n-1] {
+				t.Fatalf("bad Len or Get")
+			}
+			for range 300 {
+				lo := rnd.IntN(n)
+				hi := lo + 1 + rnd.IntN(n-lo)
+				want := values[lo]
+				for _, v := range values[lo+1 : hi] {
+					want = op(want, v)
+				}
+				if got := st.Query(lo, hi); got != want {
+					t.Fatalf("%s: Query(%d, %d)=%d, want %d", name, lo, hi, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	st := New([]int{}, func(a, b int) int { return min(a, b) })
+	if st.Len() != 0 {
+		t.Errorf("got Len=%d", st.Len())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for empty range")
+		}
+	}()
+	st.Query(0, 0)
+}
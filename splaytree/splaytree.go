@@ -0,0 +1,279 @@
+// Package splaytree implements an ordered map backed by a splay tree.
+package splaytree
+
+import "iter"
+
+// Tree is an ordered map from keys of type K to values of type V, backed by
+// a splay tree: a self-adjusting binary search tree that moves every
+// accessed key to the root. Operations take O(log n) amortized time, and
+// keys accessed often or recently are cheap to access again, which makes
+// splay trees fast for skewed access patterns.
+//
+// Since lookups restructure the tree, a Tree must not be accessed
+// concurrently even by readers only, and the tree shouldn't be accessed
+// while iterating over it. Create trees with [New].
+type Tree[K, V any] struct {
+	cmp    func(K, K) int
+	root   *node[K, V]
+	length int
+	stats  Stats
+}
+
+type node[K, V any] struct {
+	key         K
+	value       V
+	left, right *node[K, V]
+}
+
+// Stats holds counters describing the work a tree has done restructuring
+// itself, which can be used to check whether splaying pays off for a given
+// workload.
+type Stats struct {
+	// Splays is the number of splay operations, one per Get, Insert, Delete,
+	// Floor and Ceiling on a non-empty tree.
+	Splays int
+
+	// Rotations is the total number of rotations performed by splaying,
+	// which is the total depth of the nodes splayed to the root.
+	Rotations int
+}
+
+// AmortizedRotations returns the average number of rotations per splay;
+// a low number means the accessed keys are typically near the root.
+func (s Stats) AmortizedRotations() float64 {
+	if s.Splays == 0 {
+		return 0
+	}
+	return float64(s.Rotations) / float64(s.Splays)
+}
+
+// New creates a new, empty tree with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K, V any](cmp func(K, K) int) *Tree[K, V] {
+	return &Tree[K, V]{cmp: cmp}
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree[K, V]) Len() int {
+	return t.length
+}
+
+// Stats returns the tree's restructuring statistics.
+func (t *Tree[K, V]) Stats() Stats {
+	return t.stats
+}
+
+// ResetStats resets the tree's restructuring statistics to zero.
+func (t *Tree[K, V]) ResetStats() {
+	t.stats = Stats{}
+}
+
+// Get looks for the given key in the tree, and moves it to the root if
+// found. It returns the associated value and ok=true; otherwise, it returns
+// ok=false.
+func (t *Tree[K, V]) Get(key K) (v V, ok bool) {
+	t.splay(key)
+	if t.root != nil && t.cmp(t.root.key, key) == 0 {
+		return t.root.value, true
+	}
+	return v, false
+}
+
+// Insert inserts a new key=value pair into the tree, and moves it to the
+// root. If key already exists in the tree, its value is replaced with value.
+func (t *Tree[K, V]) Insert(key K, value V) {
+	if t.root == nil {
+		t.root = &node[K, V]{key: key, value: value}
+		t.length++
+		return
+	}
+	t.splay(key)
+	c := t.cmp(key, t.root.key)
+	if c == 0 {
+		t.root.value = value
+		return
+	}
+
+	// After splaying, the root is key's predecessor or successor; split the
+	// tree around it under the new node.
+	n := &node[K, V]{key: key, value: value}
+	if c < 0 {
+		n.left, n.right = t.root.left, t.root
+		t.root.left = nil
+	} else {
+		n.left, n.right = t.root, t.root.right
+		t.root.right = nil
+	}
+	t.root = n
+	t.length++
+}
+
+// Delete deletes a key and its associated value from the tree. It returns
+// true if the key was found and deleted; if key is not found in the tree,
+// Delete is a no-op and returns false.
+func (t *Tree[K, V]) Delete(key K) bool {
+	t.splay(key)
+	if t.root == nil || t.cmp(t.root.key, key) != 0 {
+		return false
+	}
+	if t.root.left == nil {
+		t.root = t.root.right
+	} else {
+		// Splaying key in the left subtree brings its maximum to the root;
+		// that node has no right child, so the right subtree can hang there.
+		right := t.root.right
+		t.root = t.root.left
+		t.splay(key)
+		t.root.right = right
+	}
+	t.length--
+	return true
+}
+
+// Floor finds the largest key in the tree that's smaller than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (t *Tree[K, V]) Floor(key K) (k K, v V, ok bool) {
+	t.splay(key)
+	n := t.root
+	if n != nil && t.cmp(n.key, key) > 0 {
+		// The root is key's successor; the floor is its predecessor.
+		n = n.left
+		for n != nil && n.right != nil {
+			n = n.right
+		}
+	}
+	if n == nil {
+		return k, v, false
+	}
+	return n.key, n.value, true
+}
+
+// Ceiling finds the smallest key in the tree that's larger than or equal to
+// key. It returns this key with its value and ok=true; if there's no such
+// key, it returns ok=false.
+func (t *Tree[K, V]) Ceiling(key K) (k K, v V, ok bool) {
+	t.splay(key)
+	n := t.root
+	if n != nil && t.cmp(n.key, key) < 0 {
+		// The root is key's predecessor; the ceiling is its successor.
+		n = n.right
+		for n != nil && n.left != nil {
+			n = n.left
+		}
+	}
+	if n == nil {
+		return k, v, false
+	}
+	return n.key, n.value, true
+}
+
+// All returns an iterator over all key, value pairs in the tree, in
+// ascending order of keys. Iteration doesn't restructure the tree.
+func (t *Tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.root, nil, nil, yield)
+	}
+}
+
+// Range returns an iterator over the key, value pairs with keys in the range
+// [lo, hi), in ascending order of keys. Iteration doesn't restructure the
+// tree.
+func (t *Tree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.root, &lo, &hi, yield)
+	}
+}
+
+// ascend yields the key, value pairs in the subtree rooted at n in
+// ascending order, limited to the range [lo, hi) with nil meaning unbounded.
+// It returns false if iteration should stop, either because yield returned
+// false or because hi was reached.
+func (t *Tree[K, V]) ascend(n *node[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	aboveLo := lo == nil || t.cmp(n.key, *lo) >= 0
+	if aboveLo && !t.ascend(n.left, lo, hi, yield) {
+		return false
+	}
+	if hi != nil && t.cmp(n.key, *hi) >= 0 {
+		return false
+	}
+	if aboveLo && !yield(n.key, n.value) {
+		return false
+	}
+	return t.ascend(n.right, lo, hi, yield)
+}
+
+// splay performs a top-down splay for key: it restructures the tree so that
+// the root is key's node if it's in the tree, or otherwise the last node on
+// the search path for key (key's predecessor or successor).
+func (t *Tree[K, V]) splay(key K) {
+	if t.root == nil {
+		return
+	}
+	t.stats.Splays++
+
+	// The nodes known to be smaller than key are collected into a "left
+	// tree" whose maximum is l, and the larger ones into a "right tree" whose
+	// minimum is r; header.right and header.left are their roots.
+	var header node[K, V]
+	l, r := &header, &header
+	n := t.root
+	for {
+		c := t.cmp(key, n.key)
+		if c < 0 {
+			if n.left == nil {
+				break
+			}
+			if t.cmp(key, n.left.key) < 0 {
+				// Zig-zig: rotate right.
+				y := n.left
+				n.left = y.right
+				y.right = n
+				n = y
+				t.stats.Rotations++
+				if n.left == nil {
+					break
+				}
+			}
+			// Link n into the right tree.
+			r.left = n
+			r = n
+			n = n.left
+			t.stats.Rotations++
+		} else if c > 0 {
+			if n.right == nil {
+				break
+			}
+			if t.cmp(key, n.right.key) > 0 {
+				// Zag-zag: rotate left.
+				y := n.right
+				n.right = y.left
+				y.left = n
+				n = y
+				t.stats.Rotations++
+				if n.right == nil {
+					break
+				}
+			}
+			// Link n into the left tree.
+			l.right = n
+			l = n
+			n = n.right
+			t.stats.Rotations++
+		} else {
+			break
+		}
+	}
+
+	// Reassemble: n becomes the root, with the left and right trees as its
+	// subtrees.
+	l.right = n.left
+	r.left = n.right
+	n.left = header.right
+	n.right = header.left
+	t.root = n
+}
@@ -0,0 +1,137 @@
+package splaytree
+
+import (
+	"cmp"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func checkTree(t *testing.T, tr *Tree[int, int], want map[int]int) {
+	t.Helper()
+	if tr.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", tr.Len(), len(want))
+	}
+	var keys []int
+	for k, v := range tr.All() {
+		if want[k] != v {
+			t.Fatalf("got %d=%d, want %d", k, v, want[k])
+		}
+		keys = append(keys, k)
+	}
+	if wantKeys := slices.Sorted(maps.Keys(want)); !slices.Equal(keys, wantKeys) {
+		t.Fatalf("got keys %v, want %v", keys, wantKeys)
+	}
+}
+
+func TestBasic(t *testing.T) {
+	tr := New[int, int](cmp.Compare[int])
+	if _, ok := tr.Get(1); ok || tr.Delete(1) {
+		t.Errorf("bad results on empty tree")
+	}
+	for _, k := range []int{50, 30, 70, 20, 40, 60, 80} {
+		tr.Insert(k, k)
+	}
+	if v, ok := tr.Get(40); !ok || v != 40 || tr.root.key != 40 {
+		t.Errorf("Get(40) didn't splay to the root")
+	}
+	if k, _, ok := tr.Floor(45); !ok || k != 40 {
+		t.Errorf("got Floor=%d,%v", k, ok)
+	}
+	if k, _, ok := tr.Ceiling(45); !ok || k != 50 {
+		t.Errorf("got Ceiling=%d,%v", k, ok)
+	}
+	if _, _, ok := tr.Floor(10); ok {
+		t.Errorf("found Floor(10)")
+	}
+	if _, _, ok := tr.Ceiling(90); ok {
+		t.Errorf("found Ceiling(90)")
+	}
+	var got []int
+	for k := range tr.Range(30, 70) {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []int{30, 40, 50, 60}) {
+		t.Errorf("got range %v", got)
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := New[int, int](cmp.Compare[int])
+	want := map[int]int{}
+	for i := range 20000 {
+		k := rnd.IntN(1000)
+		switch rnd.IntN(4) {
+		case 0:
+			_, inMap := want[k]
+			if tr.Delete(k) != inMap {
+				t.Fatalf("Delete(%d) mismatch", k)
+			}
+			delete(want, k)
+		case 1:
+			v, ok := tr.Get(k)
+			if wv, wok := want[k]; ok != wok || v != wv {
+				t.Fatalf("Get(%d)=%d,%v, want %d,%v", k, v, ok, wv, wok)
+			}
+		default:
+			tr.Insert(k, i)
+			want[k] = i
+		}
+	}
+	checkTree(t, tr, want)
+
+	keys := slices.Sorted(maps.Keys(want))
+	for range 500 {
+		q := rnd.IntN(1100) - 50
+		i, found := slices.BinarySearch(keys, q)
+		k, _, ok := tr.Floor(q)
+		switch {
+		case found && (!ok || k != q), !found && i == 0 && ok, !found && i > 0 && (!ok || k != keys[i-1]):
+			t.Fatalf("Floor(%d)=%d,%v", q, k, ok)
+		}
+		k, _, ok = tr.Ceiling(q)
+		switch {
+		case i == len(keys) && ok, i < len(keys) && (!ok || k != keys[i]):
+			t.Fatalf("Ceiling(%d)=%d,%v", q, k, ok)
+		}
+	}
+	checkTree(t, tr, want)
+}
+
+func TestSkewedAccessStats(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := New[int, int](cmp.Compare[int])
+	for _, k := range rnd.Perm(1 << 14) {
+		tr.Insert(k, k)
+	}
+
+	// Uniform random access costs about log(n) rotations per access.
+	tr.ResetStats()
+	for range 10000 {
+		tr.Get(rnd.IntN(1 << 14))
+	}
+	uniform := tr.Stats()
+	if uniform.Splays != 10000 {
+		t.Errorf("got %d splays, want 10000", uniform.Splays)
+	}
+
+	// Repeatedly accessing a small working set is much cheaper.
+	tr.ResetStats()
+	for range 10000 {
+		tr.Get(rnd.IntN(8) * 1000)
+	}
+	skewed := tr.Stats()
+	if skewed.AmortizedRotations()*3 > uniform.AmortizedRotations() {
+		t.Errorf("skewed access not cheaper: %.2f vs %.2f rotations per splay",
+			skewed.AmortizedRotations(), uniform.AmortizedRotations())
+	}
+}
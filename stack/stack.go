@@ -0,0 +1,58 @@
+// Package stack implements a slice-backed LIFO stack.
+package stack
+
+import "iter"
+
+// Stack is a generic LIFO stack. The zero value is an empty stack ready to
+// use.
+type Stack[T any] struct {
+	items []T
+}
+
+// New creates a new, empty stack.
+func New[T any]() *Stack[T] {
+	return &Stack[T]{}
+}
+
+// Len returns the number of elements in the stack.
+func (s *Stack[T]) Len() int {
+	return len(s.items)
+}
+
+// Push pushes a value onto the top of the stack.
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+// Pop removes the value at the top of the stack and returns it. It panics if
+// the stack is empty; make sure to check Len() first.
+func (s *Stack[T]) Pop() T {
+	if len(s.items) == 0 {
+		panic("popping from empty stack")
+	}
+	last := len(s.items) - 1
+	v := s.items[last]
+	s.items[last] = *new(T)
+	s.items = s.items[:last]
+	return v
+}
+
+// Peek returns the value at the top of the stack without removing it. It
+// panics if the stack is empty.
+func (s *Stack[T]) Peek() T {
+	if len(s.items) == 0 {
+		panic("peek into empty stack")
+	}
+	return s.items[len(s.items)-1]
+}
+
+// All returns an iterator over the values in the stack, from top to bottom.
+func (s *Stack[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := len(s.items) - 1; i >= 0; i-- {
+			if !yield(s.items[i]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package stack
+
+import (
+	"slices"
+	"testing"
+)
+
+func checkStack(t *testing.T, s *Stack[int], wantTopDown []int) {
+	t.Helper()
+	if s.Len() != len(wantTopDown) {
+		t.Errorf("got len=%v, want %v", s.Len(), len(wantTopDown))
+	}
+	got := slices.Collect(s.All())
+	if !slices.Equal(got, wantTopDown) {
+		t.Errorf("got %v, want %v", got, wantTopDown)
+	}
+}
+
+func TestPushPop(t *testing.T) {
+	s := New[int]()
+	checkStack(t, s, nil)
+
+	s.Push(10)
+	s.Push(20)
+	s.Push(30)
+	checkStack(t, s, []int{30, 20, 10})
+	if s.Peek() != 30 {
+		t.Errorf("got peek=%v, want 30", s.Peek())
+	}
+
+	if v := s.Pop(); v != 30 {
+		t.Errorf("got pop=%v, want 30", v)
+	}
+	checkStack(t, s, []int{20, 10})
+	s.Push(40)
+	checkStack(t, s, []int{40, 20, 10})
+
+	for _, want := range []int{40, 20, 10} {
+		if v := s.Pop(); v != want {
+			t.Errorf("got pop=%v, want %v", v, want)
+		}
+	}
+	checkStack(t, s, nil)
+}
+
+func TestZeroValue(t *testing.T) {
+	var s Stack[string]
+	s.Push("hello")
+	if s.Pop() != "hello" || s.Len() != 0 {
+		t.Errorf("zero value stack misbehaves")
+	}
+}
+
+func TestEmptyPanics(t *testing.T) {
+	s := New[int]()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	s.Pop()
+}
@@ -0,0 +1,247 @@
+// Package suffixarray implements a suffix array with an LCP (longest common
+// prefix) array, over sequences of bytes or runes.
+//
+// Unlike index/suffixarray in the standard library, the arrays are exposed
+// directly for use in algorithms that need them.
+package suffixarray
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// Symbol is the constraint for the element type of indexed sequences.
+type Symbol interface {
+	~byte | ~rune
+}
+
+// SuffixArray is a suffix array for a sequence of symbols. Create it with
+// [New].
+type SuffixArray[T Symbol] struct {
+	text []T
+	sa   []int
+	lcp  []int
+}
+
+// New builds the suffix array of text in O(n log n) time. text must not be
+// modified while the suffix array is in use.
+func New[T Symbol](text []T) *SuffixArray[T] {
+	s := make([]int, len(text))
+	for i, v := range text {
+		s[i] = int(v)
+	}
+	sa := build(s)
+	return &SuffixArray[T]{text: text, sa: sa, lcp: buildLCP(s, sa)}
+}
+
+// Len returns the length of the indexed text.
+func (x *SuffixArray[T]) Len() int {
+	return len(x.text)
+}
+
+// Text returns the indexed text.
+func (x *SuffixArray[T]) Text() []T {
+	return x.text
+}
+
+// Suffixes returns the suffix array: the starting offsets of all the suffixes
+// of the text, in lexicographic order of the suffixes. The returned slice is
+// owned by x and must not be modified.
+func (x *SuffixArray[T]) Suffixes() []int {
+	return x.sa
+}
+
+// LCP returns the LCP array: LCP()[i] is the length of the longest common
+// prefix of the suffixes starting at Suffixes()[i-1] and Suffixes()[i], and
+// LCP()[0] is 0. The returned slice is owned by x and must not be modified.
+func (x *SuffixArray[T]) LCP() []int {
+	return x.lcp
+}
+
+// Range returns the range [lo, hi) of indices into Suffixes() of the
+// suffixes that begin with sub; lo == hi if sub doesn't occur in the text.
+func (x *SuffixArray[T]) Range(sub []T) (lo, hi int) {
+	// comparePrefix compares the suffix at i, truncated to len(sub), to sub.
+	comparePrefix := func(i int) int {
+		suf := x.text[x.sa[i]:]
+		return slices.Compare(suf[:min(len(suf), len(sub))], sub)
+	}
+	lo = sort.Search(len(x.sa), func(i int) bool { return comparePrefix(i) >= 0 })
+	hi = lo + sort.Search(len(x.sa)-lo, func(i int) bool { return comparePrefix(lo+i) > 0 })
+	return lo, hi
+}
+
+// Lookup returns the sorted offsets of all the occurrences of sub in the
+// text, in O(m log n + k log k) time for m = len(sub) and k occurrences.
+func (x *SuffixArray[T]) Lookup(sub []T) []int {
+	lo, hi := x.Range(sub)
+	result := slices.Clone(x.sa[lo:hi])
+	slices.Sort(result)
+	return result
+}
+
+// Count returns the number of occurrences of sub in the text.
+func (x *SuffixArray[T]) Count(sub []T) int {
+	lo, hi := x.Range(sub)
+	return hi - lo
+}
+
+// Contains reports whether sub occurs in the text.
+func (x *SuffixArray[T]) Contains(sub []T) bool {
+	return x.Count(sub) > 0
+}
+
+// LongestRepeated finds the longest substring occurring at least twice in
+// the text (the occurrences may overlap). It returns the offset of one of the
+// occurrences and the substring's length; length is 0 if no symbol repeats.
+func (x *SuffixArray[T]) LongestRepeated() (start, length int) {
+	for i, l := range x.lcp {
+		if l > length {
+			start, length = x.sa[i], l
+		}
+	}
+	return start, length
+}
+
+// LongestCommonSubstring finds the longest sequence that's a substring of
+// both a and b in O(n log n) time for n = len(a)+len(b). It returns the
+// offsets of the substring in a and in b, and its length; length is 0 if a
+// and b have no symbols in common.
+func LongestCommonSubstring[T Symbol](a, b []T) (aStart, bStart, length int) {
+	// Index a and b concatenated with a unique separator that's smaller than
+	// all symbols, so common prefixes never extend past the end of a. The
+	// longest common substring is then the longest common prefix of two
+	// adjacent suffixes coming from different inputs. Runes may be
+	// negative, so the separator goes below the smallest symbol.
+	s := make([]int, 0, len(a)+len(b)+1)
+	sep := 0
+	for _, v := range a {
+		s = append(s, int(v))
+		sep = min(sep, int(v))
+	}
+	for _, v := range b {
+		sep = min(sep, int(v))
+	}
+	s = append(s, sep-1)
+	for _, v := range b {
+		s = append(s, int(v))
+	}
+	sa := build(s)
+	lcp := buildLCP(s, sa)
+
+	for i := 1; i < len(sa); i++ {
+		p, q := sa[i-1], sa[i]
+		if (p < len(a)) == (q < len(a)) || p == len(a) || q == len(a) {
+			continue
+		}
+		if lcp[i] > length {
+			if p > q {
+				p, q = q, p
+			}
+			aStart, bStart, length = p, q-len(a)-1, lcp[i]
+		}
+	}
+	return aStart, bStart, length
+}
+
+// build constructs the suffix array of s by prefix doubling: after the
+// round for k, suffixes are sorted by their first 2k symbols. Each round
+// is a linear-time radix sort on pairs of ranks from the previous round.
+func build(s []int) []int {
+	n := len(s)
+	sa := make([]int, n)
+	for i := range sa {
+		sa[i] = i
+	}
+	if n == 0 {
+		return sa
+	}
+	slices.SortFunc(sa, func(i, j int) int { return cmp.Compare(s[i], s[j]) })
+
+	rank := make([]int, n)
+	tmp := make([]int, n)
+	count := make([]int, n)
+	for i := 1; i < n; i++ {
+		rank[sa[i]] = rank[sa[i-1]]
+		if s[sa[i]] != s[sa[i-1]] {
+			rank[sa[i]]++
+		}
+	}
+
+	for k := 1; rank[sa[n-1]] < n-1; k *= 2 {
+		// Order suffixes by the rank of their second half: suffixes with an
+		// empty second half come first, then the rest in the order of the
+		// suffix starting at their second half.
+		p := 0
+		for i := n - k; i < n; i++ {
+			tmp[p] = i
+			p++
+		}
+		for _, j := range sa {
+			if j >= k {
+				tmp[p] = j - k
+				p++
+			}
+		}
+
+		// Stable counting sort by the rank of the first half.
+		clear(count)
+		for _, r := range rank {
+			count[r]++
+		}
+		for i := 1; i < n; i++ {
+			count[i] += count[i-1]
+		}
+		for i := n - 1; i >= 0; i-- {
+			j := tmp[i]
+			count[rank[j]]--
+			sa[count[rank[j]]] = j
+		}
+
+		// Compute new ranks; suffixes are equal if both halves are.
+		secondRank := func(i int) int {
+			if i+k < n {
+				return rank[i+k]
+			}
+			return -1
+		}
+		tmp[sa[0]] = 0
+		for i := 1; i < n; i++ {
+			prev, cur := sa[i-1], sa[i]
+			tmp[cur] = tmp[prev]
+			if rank[prev] != rank[cur] || secondRank(prev) != secondRank(cur) {
+				tmp[cur]++
+			}
+		}
+		rank, tmp = tmp, rank
+	}
+	return sa
+}
+
+// buildLCP computes the LCP array of s given its suffix array, using
+// Kasai's linear-time algorithm.
+func buildLCP(s []int, sa []int) []int {
+	n := len(s)
+	lcp := make([]int, n)
+	rank := make([]int, n)
+	for i, p := range sa {
+		rank[p] = i
+	}
+	h := 0
+	for i := range n {
+		if rank[i] == 0 {
+			h = 0
+			continue
+		}
+		j := sa[rank[i]-1]
+		for i+h < n && j+h < n && s[i+h] == s[j+h] {
+			h++
+		}
+		lcp[rank[i]] = h
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
@@ -0,0 +1,152 @@
+package suffixarray
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+func randString(rnd *rand.Rand, n int, alphabet string) string {
+	var sb strings.Builder
+	for range n {
+		sb.WriteByte(alphabet[rnd.IntN(len(alphabet))])
+	}
+	return sb.String()
+}
+
+// checkArrays verifies the suffix array and LCP array of x against naive
+// computations.
+func checkArrays[T Symbol](t *testing.T, x *SuffixArray[T]) {
+	t.Helper()
+	text := x.Text()
+	want := make([]int, len(text))
+	for i := range want {
+		want[i] = i
+	}
+	slices.SortFunc(want, func(i, j int) int { return slices.Compare(text[i:], text[j:]) })
+	if !slices.Equal(x.Suffixes(), want) {
+		t.Fatalf("text %v: got suffixes %v, want %v", text, x.Suffixes(), want)
+	}
+
+	lcp := x.LCP()
+	for i := range want {
+		l := 0
+		if i > 0 {
+			a, b := text[want[i-1]:], text[want[i]:]
+			for l < len(a) && l < len(b) && a[l] == b[l] {
+				l++
+			}
+		}
+		if lcp[i] != l {
+			t.Fatalf("text %v: lcp[%d]=%d, want %d", text, i, lcp[i], l)
+		}
+	}
+}
+
+func TestBanana(t *testing.T) {
+	x := New([]byte("banana"))
+	if !slices.Equal(x.Suffixes(), []int{5, 3, 1, 0, 4, 2}) {
+		t.Errorf("got %v", x.Suffixes())
+	}
+	if !slices.Equal(x.LCP(), []int{0, 1, 3, 0, 0, 2}) {
+		t.Errorf("got %v", x.LCP())
+	}
+	checkArrays(t, x)
+
+	if got := x.Lookup([]byte("ana")); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("got %v", got)
+	}
+	if x.Count([]byte("a")) != 3 || x.Count([]byte("")) != 6 || x.Contains([]byte("nab")) {
+		t.Errorf("bad counts")
+	}
+	if start, length := x.LongestRepeated(); length != 3 || string(x.Text()[start:start+length]) != "ana" {
+		t.Errorf("got %d, %d", start, length)
+	}
+}
+
+func TestEmptyAndRunes(t *testing.T) {
+	x := New([]byte{})
+	if x.Len() != 0 || len(x.Suffixes()) != 0 || x.Contains([]byte("a")) {
+		t.Errorf("bad empty array")
+	}
+	if _, length := x.LongestRepeated(); length != 0 {
+		t.Errorf("got length %d", length)
+	}
+
+	r := New([]rune("日本語の日本"))
+	checkArrays(t, r)
+	if got := r.Lookup([]rune("日本")); !slices.Equal(got, []int{0, 4}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestRandomArrays(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for range 200 {
+		text := randString(rnd, rnd.IntN(100), "abc")
+		x := New([]byte(text))
+		checkArrays(t, x)
+
+		sub := randString(rnd, 1+rnd.IntN(3), "abc")
+		var want []int
+		for i := 0; i+len(sub) <= len(text); i++ {
+			if text[i:i+len(sub)] == sub {
+				want = append(want, i)
+			}
+		}
+		if got := x.Lookup([]byte(sub)); !slices.Equal(got, want) {
+			t.Fatalf("text %q, lookup %q: got %v, want %v", text, sub, got, want)
+		}
+	}
+}
+
+func naiveLCS(a, b string) int {
+	best := 0
+	for i := range len(a) {
+		for j := range len(b) {
+			l := 0
+			for i+l < len(a) && j+l < len(b) && a[i+l] == b[j+l] {
+				l++
+			}
+			best = max(best, l)
+		}
+	}
+	return best
+}
+
+func TestLongestCommonSubstring(t *testing.T) {
+	a, b := "xabcdey", "zzbcdezz"
+	as, bs, l := LongestCommonSubstring([]byte(a), []byte(b))
+	if a[as:as+l] != "bcde" || b[bs:bs+l] != "bcde" {
+		t.Errorf("got %d, %d, %d", as, bs, l)
+	}
+	if _, _, l := LongestCommonSubstring([]byte("abc"), []byte("xyz")); l != 0 {
+		t.Errorf("got length %d", l)
+	}
+	// Negative runes must not be confused with the separator.
+	neg := []rune{-1, -1}
+	if as, bs, l := LongestCommonSubstring(neg, neg); as != 0 || bs != 0 || l != 2 {
+		t.Errorf("negative runes: got %d, %d, %d", as, bs, l)
+	}
+
+	rnd := makeLoggedRand(t)
+	for range 200 {
+		a := randString(rnd, rnd.IntN(30), "ab")
+		b := randString(rnd, rnd.IntN(30), "ab")
+		as, bs, l := LongestCommonSubstring([]byte(a), []byte(b))
+		if want := naiveLCS(a, b); l != want {
+			t.Fatalf("%q, %q: got length %d, want %d", a, b, l, want)
+		}
+		if a[as:as+l] != b[bs:bs+l] {
+			t.Fatalf("%q, %q: substrings differ at %d, %d", a, b, as, bs)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package syncmap implements a typed, sharded map that is safe for
+// concurrent use.
+package syncmap
+
+import (
+	"iter"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// Map is a hash map safe for concurrent use by multiple goroutines. Keys are
+// spread over a number of shards, each a builtin map protected by its own
+// RWMutex, so that operations on keys in different shards don't contend.
+//
+// Unlike sync.Map, Map is statically typed and its operations have the
+// same semantics as the ones on a mutex-protected builtin map, whatever the
+// access pattern.
+//
+// Create maps with [New] or [NewWithShards].
+type Map[K comparable, V any] struct {
+	hash   func(K) uint64
+	shards []shard[K, V]
+
+	// shift selects the shard of a key from the top bits of its hash.
+	shift uint
+}
+
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+
+	// Pad shards to the size of a typical cache line, to avoid false sharing
+	// between the mutexes of adjacent shards.
+	_ [32]byte
+}
+
+// New creates a new, empty Map using hash to pick the shard for each key;
+// [hashmap.StringHasher] and [hashmap.IntHasher] provide suitable
+// functions. The number of shards is chosen based on GOMAXPROCS.
+//
+// [hashmap.StringHasher]: https://pkg.go.dev/github.com/eliben/gogl/hashmap#StringHasher
+// [hashmap.IntHasher]: https://pkg.go.dev/github.com/eliben/gogl/hashmap#IntHasher
+func New[K comparable, V any](hash func(K) uint64) *Map[K, V] {
+	return NewWithShards[K, V](4*runtime.GOMAXPROCS(0), hash)
+}
+
+// NewWithShards creates a new, empty Map with n shards (rounded up to a
+// power of two), using hash to pick the shard for each key.
+func NewWithShards[K comparable, V any](n int, hash func(K) uint64) *Map[K, V] {
+	if n <= 0 {
+		panic("syncmap: number of shards must be positive")
+	}
+	logn := bits.Len(uint(n - 1))
+	m := &Map[K, V]{
+		hash:   hash,
+		shards: make([]shard[K, V], 1<<logn),
+		shift:  uint(64 - logn),
+	}
+	for i := range m.shards {
+		m.shards[i].m = make(map[K]V)
+	}
+	return m
+}
+
+func (m *Map[K, V]) shardFor(key K) *shard[K, V] {
+	if len(m.shards) == 1 {
+		return &m.shards[0]
+	}
+	return &m.shards[m.hash(key)>>m.shift]
+}
+
+// Len returns the number of entries in the map. Since shards are counted
+// one at a time, the result may not reflect any single point in time if
+// the map is modified concurrently.
+func (m *Map[K, V]) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Get looks for key in the map. It returns the associated value and
+// ok=true; otherwise, it returns ok=false.
+func (m *Map[K, V]) Get(key K) (v V, ok bool) {
+	s := m.shardFor(key)
+	s.mu.RLock()
+	v, ok = s.m[key]
+	s.mu.RUnlock()
+	return v, ok
+}
+
+// Set sets the value of key to value, adding key if it's not in the map.
+func (m *Map[K, V]) Set(key K, value V) {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	s.m[key] = value
+	s.mu.Unlock()
+}
+
+// Delete deletes key and its value from the map. It returns true if key was
+// found, and false otherwise.
+func (m *Map[K, V]) Delete(key K) bool {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	_, ok := s.m[key]
+	delete(s.m, key)
+	s.mu.Unlock()
+	return ok
+}
+
+// LoadOrStore returns the existing value of key and loaded=true if key is
+// in the map. Otherwise, it sets the value of key to value and returns
+// value and loaded=false.
+func (m *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m[key]; ok {
+		return v, true
+	}
+	s.m[key] = value
+	return value, false
+}
+
+// LoadAndDelete deletes key from the map, returning its previous value and
+// loaded=true if it was in the map.
+func (m *Map[K, V]) LoadAndDelete(key K) (v V, loaded bool) {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	v, loaded = s.m[key]
+	delete(s.m, key)
+	s.mu.Unlock()
+	return v, loaded
+}
+
+// DeleteFunc deletes key from the map if it's present and del returns true
+// for its value; it reports whether key was deleted. del is called with the
+// key's shard locked, so it must not access the map.
+func (m *Map[K, V]) DeleteFunc(key K, del func(V) bool) bool {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m[key]; ok && del(v) {
+		delete(s.m, key)
+		return true
+	}
+	return false
+}
+
+// Update atomically replaces the value of key with f(old, ok), where old
+// and ok are the results of Get(key), and returns the new value. f is called
+// with the key's shard locked, so it must not access the map.
+func (m *Map[K, V]) Update(key K, f func(old V, ok bool) V) V {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.m[key]
+	v := f(old, ok)
+	s.m[key] = v
+	return v
+}
+
+// CompareAndDelete deletes key from m if its value is old, and reports
+// whether it did. It's a function rather than a method because it requires
+// comparable values.
+func CompareAndDelete[K, V comparable](m *Map[K, V], key K, old V) bool {
+	return m.DeleteFunc(key, func(v V) bool { return v == old })
+}
+
+// CompareAndSwap sets the value of key to new if its current value is old,
+// and reports whether it did.
+func CompareAndSwap[K, V comparable](m *Map[K, V], key K, old, new V) bool {
+	s := m.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m[key]; ok && v == old {
+		s.m[key] = new
+		return true
+	}
+	return false
+}
+
+// All returns an iterator over all the key, value pairs in the map, in
+// unspecified order. The map may be modified during iteration: each shard
+// is copied under its lock before its entries are yielded, so the entries
+// of any single shard are consistent, but changes to shards not yet
+// reached are seen while changes to shards already visited are not.
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var keys []K
+		var values []V
+		for i := range m.shards {
+			s := &m.shards[i]
+			keys, values = keys[:0], values[:0]
+			s.mu.RLock()
+			for k, v := range s.m {
+				keys = append(keys, k)
+				values = append(values, v)
+			}
+			s.mu.RUnlock()
+			for j, k := range keys {
+				if !yield(k, values[j]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Clear deletes all the entries from the map.
+func (m *Map[K, V]) Clear() {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		clear(s.m)
+		s.mu.Unlock()
+	}
+}
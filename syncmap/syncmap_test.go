@@ -0,0 +1,216 @@
+package syncmap
+
+import (
+	"log"
+	"maps"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/eliben/gogl/hashmap"
+)
+
+func checkContents[K comparable, V comparable](t *testing.T, m *Map[K, V], want map[K]V) {
+	t.Helper()
+	if m.Len() != len(want) {
+		t.Fatalf("Len=%d, want %d", m.Len(), len(want))
+	}
+	got := maps.Collect(m.All())
+	if !maps.Equal(got, want) {
+		t.Fatalf("All=%v, want %v", got, want)
+	}
+	for k, v := range want {
+		if gv, ok := m.Get(k); !ok || gv != v {
+			t.Fatalf("Get(%v)=%v,%v, want %v", k, gv, ok, v)
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	m := New[string, int](hashmap.StringHasher().Hash)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("a", 3)
+	checkContents(t, m, map[string]int{"a": 3, "b": 2})
+
+	if v, loaded := m.LoadOrStore("a", 10); !loaded || v != 3 {
+		t.Errorf("LoadOrStore(a)=%d,%v", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("c", 10); loaded || v != 10 {
+		t.Errorf("LoadOrStore(c)=%d,%v", v, loaded)
+	}
+	if CompareAndDelete(m, "c", 11) {
+		t.Errorf("CompareAndDelete(c, 11) succeeded")
+	}
+	if !CompareAndSwap(m, "c", 10, 11) || CompareAndSwap(m, "c", 10, 12) {
+		t.Errorf("CompareAndSwap mismatch")
+	}
+	if !CompareAndDelete(m, "c", 11) || CompareAndDelete(m, "c", 11) {
+		t.Errorf("CompareAndDelete mismatch")
+	}
+	if v := m.Update("b", func(old int, ok bool) int { return old*10 + 1 }); v != 21 {
+		t.Errorf("Update(b)=%d", v)
+	}
+	if v, loaded := m.LoadAndDelete("a"); !loaded || v != 3 {
+		t.Errorf("LoadAndDelete(a)=%d,%v", v, loaded)
+	}
+	checkContents(t, m, map[string]int{"b": 21})
+
+	if !m.Delete("b") || m.Delete("b") {
+		t.Errorf("Delete mismatch")
+	}
+	checkContents(t, m, map[string]int{})
+}
+
+func TestShards(t *testing.T) {
+	for _, n := range []int{1, 3, 8, 100} {
+		m := NewWithShards[int, int](n, hashmap.IntHasher[int]().Hash)
+		if len(m.shards)&(len(m.shards)-1) != 0 || len(m.shards) < n {
+			t.Errorf("n=%d: got %d shards", n, len(m.shards))
+		}
+		want := make(map[int]int)
+		for k := range 500 {
+			m.Set(k, -k)
+			want[k] = -k
+		}
+		checkContents(t, m, want)
+		m.Clear()
+		checkContents(t, m, map[int]int{})
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	m := NewWithShards[int, int](8, hashmap.IntHasher[int]().Hash)
+	mirror := make(map[int]int)
+	for i := range 10000 {
+		k := rnd.IntN(200)
+		switch rnd.IntN(4) {
+		case 0:
+			v := rnd.IntN(5)
+			m.Set(k, v)
+			mirror[k] = v
+		case 1:
+			_, had := mirror[k]
+			if m.Delete(k) != had {
+				t.Fatalf("Delete(%d) mismatch", k)
+			}
+			delete(mirror, k)
+		case 2:
+			v := rnd.IntN(5)
+			got, loaded := m.LoadOrStore(k, v)
+			mv, had := mirror[k]
+			if loaded != had || (had && got != mv) || (!had && got != v) {
+				t.Fatalf("LoadOrStore(%d, %d)=%d,%v", k, v, got, loaded)
+			}
+			if !had {
+				mirror[k] = v
+			}
+		case 3:
+			v := rnd.IntN(5)
+			mv, had := mirror[k]
+			if CompareAndDelete(m, k, v) != (had && mv == v) {
+				t.Fatalf("CompareAndDelete(%d, %d) mismatch", k, v)
+			}
+			if had && mv == v {
+				delete(mirror, k)
+			}
+		}
+		if i%1000 == 0 {
+			checkContents(t, m, mirror)
+		}
+	}
+	checkContents(t, m, mirror)
+}
+
+func TestConcurrent(t *testing.T) {
+	m := New[int, int](hashmap.IntHasher[int]().Hash)
+	const goroutines = 8
+	const n = 2000
+
+	// Each goroutine increments every counter n times with Update, and
+	// races to create and claim tokens with LoadOrStore and
+	// CompareAndDelete.
+	var wg sync.WaitGroup
+	claimed := make([]int, goroutines)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				m.Update(i%10, func(old int, ok bool) int { return old + 1 })
+				token := 1000 + i
+				m.LoadOrStore(token, g)
+				if v, ok := m.Get(token); ok && CompareAndDelete(m, token, v) {
+					claimed[g]++
+				}
+				for range m.All() {
+					break
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for k := range 10 {
+		if v, _ := m.Get(k); v != goroutines*n/10 {
+			t.Errorf("counter %d = %d, want %d", k, v, goroutines*n/10)
+		}
+	}
+	// A token may be recreated after it's claimed, but each one is claimed
+	// at least once.
+	total := 0
+	for _, c := range claimed {
+		total += c
+	}
+	if total < n {
+		t.Errorf("claimed %d tokens, want at least %d", total, n)
+	}
+}
+
+func BenchmarkParallel(b *testing.B) {
+	const n = 1 << 16
+	hash := hashmap.IntHasher[int]().Hash
+	m := New[int, int](hash)
+	var mu sync.RWMutex
+	plain := make(map[int]int)
+	for k := range n {
+		m.Set(k, k)
+		plain[k] = k
+	}
+
+	// 90% reads, 10% writes.
+	b.Run("syncmap", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := rand.IntN(n)
+			for pb.Next() {
+				i = (i + 7919) % n
+				if i%10 == 0 {
+					m.Set(i, i)
+				} else {
+					m.Get(i)
+				}
+			}
+		})
+	})
+	b.Run("rwmutex", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := rand.IntN(n)
+			for pb.Next() {
+				i = (i + 7919) % n
+				if i%10 == 0 {
+					mu.Lock()
+					plain[i] = i
+					mu.Unlock()
+				} else {
+					mu.RLock()
+					_ = plain[i]
+					mu.RUnlock()
+				}
+			}
+		})
+	})
+}
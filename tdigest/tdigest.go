@@ -0,0 +1,343 @@
+// Package tdigest implements the t-digest, a sketch for estimating quantiles
+// of a stream of numbers.
+package tdigest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Digest is a t-digest (Dunning and Ertl, "Computing extremely accurate
+// quantiles using t-digests", 2019): it summarizes a stream of values by a
+// sorted list of centroids - clusters of nearby values, each represented by
+// their mean and total weight. Clusters are small near the extremes of the
+// distribution and larger in the middle, as limited by a scale function, so
+// quantile estimates are most accurate for the tails (such as the 99th or
+// 99.9th percentile of latencies), with a relative error roughly
+// proportional to q(1-q) and inversely proportional to the compression
+// parameter.
+//
+// This is the merging variant: values are buffered and merged into the
+// centroids in batches, so a digest holds O(compression) centroids and
+// adding a value takes amortized O(log compression) time. Digests of
+// separate streams can be merged with [Digest.Merge].
+//
+// Queries flush the buffer, so a Digest isn't safe for concurrent use even
+// by readers. Create digests with [New].
+type Digest struct {
+	compression float64
+
+	// centroids holds the merged centroids, sorted by mean, and buffer the
+	// values added since the last merge, as unit-weight centroids.
+	centroids []centroid
+	buffer    []centroid
+
+	// total is the weight of all the centroids, including buffered ones.
+	total    float64
+	min, max float64
+}
+
+type centroid struct {
+	mean, weight float64
+}
+
+// DefaultCompression is a reasonable compression parameter for most uses,
+// keeping up to about a hundred centroids.
+const DefaultCompression = 100
+
+// New creates a new, empty digest with the given compression parameter,
+// which bounds the number of centroids to about compression, trading size
+// for accuracy. It panics if compression < 10.
+func New(compression float64) *Digest {
+	if !(compression >= 10) {
+		panic(fmt.Sprintf("tdigest: invalid compression %v", compression))
+	}
+	return &Digest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Compression returns the compression parameter of the digest.
+func (d *Digest) Compression() float64 {
+	return d.compression
+}
+
+// Count returns the total weight of the values added to the digest: the
+// number of values, if they were all added with [Digest.Add].
+func (d *Digest) Count() float64 {
+	return d.total
+}
+
+// Min returns the smallest value added to the digest, or NaN if it's empty.
+func (d *Digest) Min() float64 {
+	if d.total == 0 {
+		return math.NaN()
+	}
+	return d.min
+}
+
+// Max returns the largest value added to the digest, or NaN if it's empty.
+func (d *Digest) Max() float64 {
+	if d.total == 0 {
+		return math.NaN()
+	}
+	return d.max
+}
+
+// Add adds value x to the digest. NaN values are ignored.
+func (d *Digest) Add(x float64) {
+	d.AddWeighted(x, 1)
+}
+
+// AddWeighted adds value x with weight w to the digest, as if x was added w
+// times. NaN values are ignored. It panics if w isn't positive and finite.
+func (d *Digest) AddWeighted(x, w float64) {
+	if !(w > 0) || math.IsInf(w, 1) {
+		panic(fmt.Sprintf("tdigest: invalid weight %v", w))
+	}
+	if math.IsNaN(x) {
+		return
+	}
+	d.buffer = append(d.buffer, centroid{x, w})
+	d.total += w
+	d.min = min(d.min, x)
+	d.max = max(d.max, x)
+	if len(d.buffer) >= d.bufferSize() {
+		d.flush()
+	}
+}
+
+func (d *Digest) bufferSize() int {
+	return int(5 * d.compression)
+}
+
+// Merge adds all the values summarized by other to d. other isn't modified.
+func (d *Digest) Merge(other *Digest) {
+	if other.total == 0 {
+		return
+	}
+	d.buffer = append(d.buffer, other.centroids...)
+	d.buffer = append(d.buffer, other.buffer...)
+	d.total += other.total
+	d.min = min(d.min, other.min)
+	d.max = max(d.max, other.max)
+	d.flush()
+}
+
+// Clear removes all the values from the digest.
+func (d *Digest) Clear() {
+	*d = Digest{
+		compression: d.compression,
+		centroids:   d.centroids[:0],
+		buffer:      d.buffer[:0],
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// k is the scale function k1 of the paper, mapping quantiles to the index
+// space in which every centroid may span at most 1.
+func (d *Digest) k(q float64) float64 {
+	return d.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// kInv is the inverse of k.
+func (d *Digest) kInv(k float64) float64 {
+	if k >= d.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/d.compression) + 1) / 2
+}
+
+// flush merges the buffered values into the centroids.
+func (d *Digest) flush() {
+	if len(d.buffer) == 0 {
+		return
+	}
+	all := append(d.buffer, d.centroids...)
+	slices.SortFunc(all, func(a, b centroid) int {
+		switch {
+		case a.mean < b.mean:
+			return -1
+		case a.mean > b.mean:
+			return 1
+		}
+		return 0
+	})
+
+	// Merge neighboring centroids greedily for as long as the merged
+	// centroid spans at most 1 in index space.
+	merged := d.centroids[:0]
+	cur := all[0]
+	var before float64
+	qLimit := d.kInv(d.k(0) + 1)
+	for _, c := range all[1:] {
+		if (before+cur.weight+c.weight)/d.total <= qLimit {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.weight
+		qLimit = d.kInv(d.k(before/d.total) + 1)
+		cur = c
+	}
+	d.centroids = append(merged, cur)
+	d.buffer = all[:0]
+
+	// Centroids may drift outside [min, max] by rounding.
+	d.centroids[0].mean = max(d.centroids[0].mean, d.min)
+	d.centroids[len(d.centroids)-1].mean = min(d.centroids[len(d.centroids)-1].mean, d.max)
+}
+
+// Quantile returns an estimate of the q-quantile of the values added to the
+// digest: the value x such that a fraction q of the values are <= x. It
+// returns NaN if the digest is empty, and panics if q isn't in the range
+// [0, 1].
+func (d *Digest) Quantile(q float64) float64 {
+	if !(q >= 0 && q <= 1) {
+		panic(fmt.Sprintf("tdigest: invalid quantile %v", q))
+	}
+	if d.total == 0 {
+		return math.NaN()
+	}
+	d.flush()
+	cs := d.centroids
+	if len(cs) == 1 {
+		return d.min + q*(d.max-d.min)
+	}
+
+	// Every centroid's weight is centered at its mean; interpolate linearly
+	// between the centers of neighboring centroids, and between the
+	// extreme centroids and the minimum and maximum.
+	index := q * d.total
+	if first := cs[0]; index < first.weight/2 {
+		return d.min + index/(first.weight/2)*(first.mean-d.min)
+	}
+	if last := cs[len(cs)-1]; index >= d.total-last.weight/2 {
+		return d.max - (d.total-index)/(last.weight/2)*(d.max-last.mean)
+	}
+	center := cs[0].weight / 2
+	for i := range len(cs) - 1 {
+		dw := (cs[i].weight + cs[i+1].weight) / 2
+		if index < center+dw {
+			return cs[i].mean + (index-center)/dw*(cs[i+1].mean-cs[i].mean)
+		}
+		center += dw
+	}
+	return d.max
+}
+
+// CDF returns an estimate of the fraction of the values added to the digest
+// that are <= x. It returns NaN if the digest is empty.
+func (d *Digest) CDF(x float64) float64 {
+	if d.total == 0 {
+		return math.NaN()
+	}
+	switch {
+	case x < d.min:
+		return 0
+	case x >= d.max:
+		return 1
+	}
+	d.flush()
+	cs := d.centroids
+	if len(cs) == 1 {
+		return (x - d.min) / (d.max - d.min)
+	}
+
+	// This mirrors the interpolation of Quantile.
+	if first := cs[0]; x < first.mean {
+		return (x - d.min) / (first.mean - d.min) * first.weight / 2 / d.total
+	}
+	if last := cs[len(cs)-1]; x >= last.mean {
+		return 1 - (d.max-x)/(d.max-last.mean)*last.weight/2/d.total
+	}
+	center := cs[0].weight / 2
+	for i := range len(cs) - 1 {
+		dw := (cs[i].weight + cs[i+1].weight) / 2
+		if x < cs[i+1].mean {
+			return (center + (x-cs[i].mean)/(cs[i+1].mean-cs[i].mean)*dw) / d.total
+		}
+		center += dw
+	}
+	return 1
+}
+
+// The binary encoding of a digest is a header followed by the centroids,
+// sorted by mean; all numbers are little-endian, and floats are IEEE 754:
+//
+//	"GTDG" | version u32 | compression f64 | min f64 | max f64 |
+//	number of centroids u32 | centroids * (mean f64 | weight f64)
+
+const (
+	encodingMagic      = "GTDG"
+	encodingVersion    = 1
+	encodingHeaderSize = 36
+)
+
+// ErrInvalidData is returned when decoding data that isn't a valid
+// encoding of a digest.
+var ErrInvalidData = errors.New("tdigest: invalid digest data")
+
+// MarshalBinary encodes the digest into a binary form; it implements
+// [encoding.BinaryMarshaler].
+func (d *Digest) MarshalBinary() ([]byte, error) {
+	d.flush()
+	buf := make([]byte, encodingHeaderSize, encodingHeaderSize+16*len(d.centroids))
+	copy(buf, encodingMagic)
+	binary.LittleEndian.PutUint32(buf[4:], encodingVersion)
+	binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(d.compression))
+	binary.LittleEndian.PutUint64(buf[16:], math.Float64bits(d.min))
+	binary.LittleEndian.PutUint64(buf[24:], math.Float64bits(d.max))
+	binary.LittleEndian.PutUint32(buf[32:], uint32(len(d.centroids)))
+	for _, c := range d.centroids {
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.mean))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c.weight))
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary replaces the contents of d by decoding data produced by
+// [Digest.MarshalBinary]; it implements [encoding.BinaryUnmarshaler]. It
+// returns ErrInvalidData if data isn't a valid encoding.
+func (d *Digest) UnmarshalBinary(data []byte) error {
+	if len(data) < encodingHeaderSize || string(data[:4]) != encodingMagic ||
+		binary.LittleEndian.Uint32(data[4:]) != encodingVersion {
+		return ErrInvalidData
+	}
+	compression := math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+	lo := math.Float64frombits(binary.LittleEndian.Uint64(data[16:]))
+	hi := math.Float64frombits(binary.LittleEndian.Uint64(data[24:]))
+	n := binary.LittleEndian.Uint32(data[32:])
+	data = data[encodingHeaderSize:]
+	if !(compression >= 10) || math.IsInf(compression, 1) || uint64(len(data)) != 16*uint64(n) {
+		return ErrInvalidData
+	}
+
+	centroids := make([]centroid, n)
+	var total float64
+	for i := range centroids {
+		c := centroid{
+			mean:   math.Float64frombits(binary.LittleEndian.Uint64(data[16*i:])),
+			weight: math.Float64frombits(binary.LittleEndian.Uint64(data[16*i+8:])),
+		}
+		if !(c.mean >= lo && c.mean <= hi) || !(c.weight > 0) || math.IsInf(c.weight, 1) ||
+			(i > 0 && c.mean < centroids[i-1].mean) {
+			return ErrInvalidData
+		}
+		centroids[i] = c
+		total += c.weight
+	}
+
+	*d = Digest{compression: compression, centroids: centroids, total: total, min: lo, max: hi}
+	if n == 0 {
+		d.min, d.max = math.Inf(1), math.Inf(-1)
+	}
+	return nil
+}
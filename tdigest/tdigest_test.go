@@ -0,0 +1,205 @@
+package tdigest
+
+import (
+	"encoding"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Digest)(nil)
+	_ encoding.BinaryUnmarshaler = (*Digest)(nil)
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+var distributions = map[string]func(*rand.Rand) float64{
+	"uniform":     func(rnd *rand.Rand) float64 { return rnd.Float64() * 1000 },
+	"normal":      func(rnd *rand.Rand) float64 { return rnd.NormFloat64()*10 + 50 },
+	"exponential": func(rnd *rand.Rand) float64 { return rnd.ExpFloat64() },
+	"discrete":    func(rnd *rand.Rand) float64 { return float64(rnd.IntN(20)) },
+}
+
+var quantiles = []float64{0, 0.001, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999, 1}
+
+// checkAccuracy checks the estimates of d against the exact quantiles of the
+// sorted values, in rank space: the fraction of values below the estimate
+// of the q-quantile must be close to q, more so for extreme quantiles.
+func checkAccuracy(t *testing.T, d *Digest, sorted []float64) {
+	t.Helper()
+	n := float64(len(sorted))
+	for _, q := range quantiles {
+		est := d.Quantile(q)
+		lo := float64(sort.SearchFloat64s(sorted, est)) / n
+		hi := float64(sort.Search(len(sorted), func(i int) bool { return sorted[i] > est })) / n
+		tol := 0.001 + 0.04*q*(1-q)
+		if q < lo-tol || q > hi+tol {
+			t.Errorf("Quantile(%v) = %v, at ranks [%v, %v]", q, est, lo, hi)
+		}
+	}
+	for range 20 {
+		x := sorted[rand.IntN(len(sorted))]
+		rank := float64(sort.Search(len(sorted), func(i int) bool { return sorted[i] > x })) / n
+		lower := float64(sort.SearchFloat64s(sorted, x)) / n
+		if cdf := d.CDF(x); cdf < lower-0.02 || cdf > rank+0.02 {
+			t.Errorf("CDF(%v) = %v, want in [%v, %v]", x, cdf, lower, rank)
+		}
+	}
+	if d.Quantile(0) != sorted[0] || d.Quantile(1) != sorted[len(sorted)-1] {
+		t.Errorf("extreme quantiles %v, %v; want %v, %v", d.Quantile(0), d.Quantile(1), sorted[0], sorted[len(sorted)-1])
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for name, dist := range distributions {
+		t.Run(name, func(t *testing.T) {
+			d := New(DefaultCompression)
+			values := make([]float64, 100000)
+			for i := range values {
+				values[i] = dist(rnd)
+				d.Add(values[i])
+			}
+			slices.Sort(values)
+			if d.Count() != float64(len(values)) || d.Min() != values[0] || d.Max() != values[len(values)-1] {
+				t.Fatalf("Count=%v Min=%v Max=%v", d.Count(), d.Min(), d.Max())
+			}
+			checkAccuracy(t, d, values)
+			if len(d.centroids) > 2*DefaultCompression {
+				t.Errorf("%d centroids", len(d.centroids))
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	merged := New(DefaultCompression)
+	var values []float64
+	for range 10 {
+		shard := New(DefaultCompression)
+		for range 10000 {
+			v := rnd.NormFloat64()
+			shard.Add(v)
+			values = append(values, v)
+		}
+		merged.Merge(shard)
+	}
+	merged.Merge(New(DefaultCompression))
+	slices.Sort(values)
+	if merged.Count() != float64(len(values)) {
+		t.Fatalf("Count=%v", merged.Count())
+	}
+	checkAccuracy(t, merged, values)
+}
+
+func TestSmall(t *testing.T) {
+	d := New(DefaultCompression)
+	if !math.IsNaN(d.Quantile(0.5)) || !math.IsNaN(d.CDF(0)) || !math.IsNaN(d.Min()) || !math.IsNaN(d.Max()) {
+		t.Errorf("empty digest returned numbers")
+	}
+
+	// With few values, every value is its own centroid and quantiles
+	// interpolate between them.
+	for _, v := range []float64{3, 1, 2, 4, math.NaN()} {
+		d.Add(v)
+	}
+	if d.Count() != 4 {
+		t.Errorf("Count=%v", d.Count())
+	}
+	for _, tt := range []struct{ q, want float64 }{{0, 1}, {0.125, 1}, {0.25, 1.5}, {0.5, 2.5}, {0.875, 4}, {1, 4}} {
+		if got := d.Quantile(tt.q); got != tt.want {
+			t.Errorf("Quantile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+	for _, tt := range []struct{ x, want float64 }{{0, 0}, {1.5, 0.25}, {2.5, 0.5}, {4, 1}} {
+		if got := d.CDF(tt.x); got != tt.want {
+			t.Errorf("CDF(%v) = %v, want %v", tt.x, got, tt.want)
+		}
+	}
+
+	d.Clear()
+	d.AddWeighted(7, 10)
+	if d.Quantile(0.3) != 7 || d.CDF(6.9) != 0 || d.CDF(7) != 1 || d.Count() != 10 {
+		t.Errorf("bad single-value digest")
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	d := New(50)
+	for range 20000 {
+		d.Add(rnd.ExpFloat64())
+	}
+	data, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2 := New(DefaultCompression)
+	if err := d2.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if d2.Compression() != 50 || d2.Count() != d.Count() || d2.Min() != d.Min() || d2.Max() != d.Max() {
+		t.Fatalf("decoded digest differs")
+	}
+	for _, q := range quantiles {
+		if d.Quantile(q) != d2.Quantile(q) {
+			t.Errorf("Quantile(%v) differs after decoding", q)
+		}
+	}
+
+	empty, _ := New(DefaultCompression).MarshalBinary()
+	if err := d2.UnmarshalBinary(empty); err != nil || d2.Count() != 0 {
+		t.Errorf("decoding empty digest: %v", err)
+	}
+	d2.Add(5)
+	if d2.Min() != 5 || d2.Max() != 5 {
+		t.Errorf("bad min/max after decoding empty digest")
+	}
+
+	for n := range len(data) {
+		if err := d2.UnmarshalBinary(data[:n]); err != ErrInvalidData {
+			t.Fatalf("truncated to %d bytes: err=%v", n, err)
+		}
+	}
+	bad := slices.Clone(data)
+	bad[encodingHeaderSize+8+7] = 0xff // negative NaN weight of the first centroid
+	if err := d2.UnmarshalBinary(bad); err != ErrInvalidData {
+		t.Errorf("bad weight: err=%v", err)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	d := New(DefaultCompression)
+	for name, f := range map[string]func(){
+		"New":          func() { New(5) },
+		"weight":       func() { d.AddWeighted(1, 0) },
+		"quantile":     func() { d.Quantile(1.5) },
+		"NaN quantile": func() { d.Quantile(math.NaN()) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s didn't panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	d := New(DefaultCompression)
+	for range b.N {
+		d.Add(rnd.NormFloat64())
+	}
+}
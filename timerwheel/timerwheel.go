@@ -0,0 +1,267 @@
+// Package timerwheel implements a hierarchical timer wheel, for managing
+// large numbers of timers efficiently.
+package timerwheel
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Wheel is a hierarchical timer wheel: a set of timers, each holding a
+// value, that expire at their deadlines. Time advances in discrete ticks of
+// a fixed duration; a timer expires on the first tick at or after its
+// deadline, so timers fire up to one tick late and never early.
+//
+// Timers are kept in levels of 64 slots each; a slot of level 0 holds the
+// timers due in one tick, a slot of level 1 those due in a range of 64
+// ticks, and so on. As time advances, the timers of a higher-level slot are
+// cascaded down to lower levels when their range comes up. Scheduling and
+// canceling a timer take O(1) time, and each timer is moved at most once
+// per level, which makes the wheel much cheaper than a heap for many
+// short-lived timers, such as network timeouts that are usually canceled.
+// Advancing the wheel skips over ranges of ticks with no timers due.
+//
+// The wheel is driven either manually, by calling [Wheel.Advance] with the
+// current time (which also makes it deterministic in tests), or by a
+// background goroutine started with [Wheel.Start]. A Wheel is safe for
+// concurrent use by multiple goroutines. Create wheels with [New].
+type Wheel[T any] struct {
+	mu     sync.Mutex
+	tick   time.Duration
+	origin time.Time
+
+	// now is the current tick: the wheel's time is origin + now*tick, and
+	// all the timers due at or before now have expired.
+	now    uint64
+	slots  [levels][slotsPerLevel]*Timer[T]
+	counts [levels]int
+	length int
+
+	// done is closed to stop the goroutine started by Start.
+	done chan struct{}
+}
+
+const (
+	levelBits     = 6
+	slotsPerLevel = 1 << levelBits
+	slotMask      = slotsPerLevel - 1
+	levels        = 6
+
+	// maxSpan is the number of ticks covered by the wheel; timers due
+	// further in the future are placed as if due at the end of the span,
+	// and placed again when their slot is cascaded.
+	maxSpan = 1 << (levels * levelBits)
+)
+
+// Timer is a timer scheduled in a Wheel.
+type Timer[T any] struct {
+	value T
+
+	// due is the tick at which the timer expires.
+	due uint64
+
+	// wheel is the wheel holding the timer, or nil once it has expired or
+	// been canceled. The timer is in the list of timers of slots[level][slot]
+	// in the wheel.
+	wheel      *Wheel[T]
+	level      int
+	slot       int
+	prev, next *Timer[T]
+}
+
+// Value returns the value the timer was scheduled with.
+func (t *Timer[T]) Value() T {
+	return t.value
+}
+
+// New creates a new, empty wheel with the given tick duration, whose time
+// is initially now.
+func New[T any](tick time.Duration, now time.Time) *Wheel[T] {
+	if tick <= 0 {
+		panic(fmt.Sprintf("timerwheel: invalid tick %v", tick))
+	}
+	return &Wheel[T]{tick: tick, origin: now}
+}
+
+// Len returns the number of scheduled timers that haven't expired or been
+// canceled.
+func (w *Wheel[T]) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.length
+}
+
+// Schedule schedules a timer holding v to expire at deadline, and returns
+// it. If deadline isn't after the wheel's current time, the timer expires
+// on the next tick.
+func (w *Wheel[T]) Schedule(deadline time.Time, v T) *Timer[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	t := &Timer[T]{value: v, due: w.now + 1, wheel: w}
+	if d := deadline.Sub(w.origin); d > 0 {
+		// Round up, so that timers never expire early; adding tick-1 to d
+		// first could overflow.
+		ticks := uint64(d / w.tick)
+		if d%w.tick != 0 {
+			ticks++
+		}
+		t.due = max(t.due, ticks)
+	}
+	w.insert(t)
+	w.length++
+	return t
+}
+
+// Cancel cancels the timer t, which must have been scheduled in w. It
+// returns true if t was canceled, or false if it had already expired or
+// been canceled.
+func (w *Wheel[T]) Cancel(t *Timer[T]) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t.wheel == nil {
+		return false
+	}
+	if t.wheel != w {
+		panic("timerwheel: Cancel of timer from another wheel")
+	}
+	w.unlink(t)
+	t.wheel = nil
+	w.length--
+	return true
+}
+
+// Advance advances the wheel's time to now, and returns the values of the
+// timers that expired in doing so, in order of their expiration ticks;
+// timers expiring on the same tick are in unspecified order. Times before
+// the wheel's current time are ignored.
+func (w *Wheel[T]) Advance(now time.Time) []T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d := now.Sub(w.origin)
+	if d < 0 {
+		return nil
+	}
+	target := uint64(d / w.tick)
+	var expired []T
+	for w.now < target {
+		// Nothing happens until the next tick where the lowest non-empty
+		// level is cascaded; skip to it.
+		l := 0
+		for l < levels && w.counts[l] == 0 {
+			l++
+		}
+		if l == levels {
+			w.now = target
+			break
+		}
+		if l > 0 {
+			next := (w.now>>(l*levelBits) + 1) << (l * levelBits)
+			w.now = min(target, next) - 1
+		}
+		w.now++
+		w.cascade()
+		t := w.slots[0][w.now&slotMask]
+		w.slots[0][w.now&slotMask] = nil
+		for t != nil {
+			w.counts[0]--
+			next := t.next
+			t.wheel, t.prev, t.next = nil, nil, nil
+			expired = append(expired, t.value)
+			w.length--
+			t = next
+		}
+	}
+	return expired
+}
+
+// Start starts a background goroutine that advances the wheel every tick,
+// calling onExpire with the value of each timer that expires. onExpire is
+// called without holding the wheel's lock, so it may use the wheel. Stop
+// the goroutine with [Wheel.Stop]. Start panics if it's already running.
+func (w *Wheel[T]) Start(onExpire func(v T)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done != nil {
+		panic("timerwheel: already started")
+	}
+	w.done = make(chan struct{})
+	go w.run(onExpire, w.done)
+}
+
+// Stop stops the goroutine started by [Wheel.Start], if it's running.
+func (w *Wheel[T]) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done != nil {
+		close(w.done)
+		w.done = nil
+	}
+}
+
+func (w *Wheel[T]) run(onExpire func(v T), done chan struct{}) {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			for _, v := range w.Advance(now) {
+				onExpire(v)
+			}
+		}
+	}
+}
+
+// cascade moves the timers of the higher-level slots whose range starts at
+// the current tick down to lower levels. Higher levels are cascaded first,
+// since their timers may land in the slots of lower levels cascaded next.
+func (w *Wheel[T]) cascade() {
+	top := 0
+	for l := 1; l < levels && w.now&(1<<(l*levelBits)-1) == 0; l++ {
+		top = l
+	}
+	for l := top; l >= 1; l-- {
+		i := (w.now >> (l * levelBits)) & slotMask
+		t := w.slots[l][i]
+		w.slots[l][i] = nil
+		for t != nil {
+			w.counts[l]--
+			next := t.next
+			w.insert(t)
+			t = next
+		}
+	}
+}
+
+// insert adds t to the slot where it's due, relative to the current tick.
+func (w *Wheel[T]) insert(t *Timer[T]) {
+	due := min(t.due, w.now+maxSpan-1)
+	l := 0
+	for diff := due - w.now; diff >= slotsPerLevel<<(l*levelBits); l++ {
+	}
+	t.level = l
+	t.slot = int((due >> (l * levelBits)) & slotMask)
+	w.counts[l]++
+	head := &w.slots[l][t.slot]
+	t.prev, t.next = nil, *head
+	if *head != nil {
+		(*head).prev = t
+	}
+	*head = t
+}
+
+// unlink removes t from its slot's list.
+func (w *Wheel[T]) unlink(t *Timer[T]) {
+	w.counts[t.level]--
+	if t.prev != nil {
+		t.prev.next = t.next
+	} else {
+		w.slots[t.level][t.slot] = t.next
+	}
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+	t.prev, t.next = nil, nil
+}
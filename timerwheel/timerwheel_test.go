@@ -0,0 +1,221 @@
+package timerwheel
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+var origin = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(ms int) time.Time {
+	return origin.Add(time.Duration(ms) * time.Millisecond)
+}
+
+func TestBasic(t *testing.T) {
+	w := New[string](10*time.Millisecond, origin)
+	w.Schedule(at(25), "a")
+	w.Schedule(at(30), "b")
+	c := w.Schedule(at(40), "c")
+	w.Schedule(at(5000), "d")
+	if w.Len() != 4 {
+		t.Fatalf("Len=%d", w.Len())
+	}
+
+	// Deadlines are rounded up to ticks: a at 25ms expires on the tick at
+	// 30ms, and nothing expires early.
+	if got := w.Advance(at(29)); len(got) != 0 {
+		t.Errorf("Advance(29)=%v", got)
+	}
+	got := w.Advance(at(30))
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Advance(30)=%v", got)
+	}
+	if !w.Cancel(c) || w.Cancel(c) || c.Value() != "c" {
+		t.Errorf("Cancel failed")
+	}
+	if got := w.Advance(at(4999)); len(got) != 0 {
+		t.Errorf("Advance(4999)=%v", got)
+	}
+	if got := w.Advance(at(6000)); !slices.Equal(got, []string{"d"}) {
+		t.Errorf("Advance(6000)=%v", got)
+	}
+	if w.Len() != 0 {
+		t.Errorf("Len=%d", w.Len())
+	}
+
+	// Deadlines in the past expire on the next tick; going back in time is
+	// ignored.
+	w.Schedule(at(0), "e")
+	if got := w.Advance(at(100)); len(got) != 0 {
+		t.Errorf("Advance(100)=%v", got)
+	}
+	if got := w.Advance(at(6010)); !slices.Equal(got, []string{"e"}) {
+		t.Errorf("Advance(6010)=%v", got)
+	}
+
+	// Rounding the largest deadline up to a tick doesn't overflow.
+	far := w.Schedule(origin.Add(math.MaxInt64), "f")
+	if want := uint64(math.MaxInt64/(10*time.Millisecond)) + 1; far.due != want {
+		t.Errorf("got due=%d for the largest deadline, want %d", far.due, want)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	// Compare with a brute-force model, over deadlines spanning all levels
+	// and beyond, advancing by steps of various sizes.
+	rnd := makeLoggedRand(t)
+	w := New[int](time.Millisecond, origin)
+	type timer struct {
+		h   *Timer[int]
+		due int
+	}
+	pending := make(map[int]timer)
+	dues := make(map[int]int)
+	now := 0
+	for i := range 20000 {
+		switch op := rnd.IntN(10); {
+		case op < 5:
+			var d int
+			switch rnd.IntN(4) {
+			case 0:
+				d = rnd.IntN(64)
+			case 1:
+				d = rnd.IntN(5000)
+			case 2:
+				d = rnd.IntN(1 << 20)
+			default:
+				d = rnd.IntN(1 << 40)
+			}
+			due := max(now+1, now+d)
+			pending[i] = timer{w.Schedule(at(now+d), i), due}
+			dues[i] = due
+		case op < 7:
+			for k, tm := range pending {
+				if !w.Cancel(tm.h) {
+					t.Fatalf("Cancel(%d) failed", k)
+				}
+				delete(pending, k)
+				break
+			}
+		default:
+			var step int
+			switch rnd.IntN(3) {
+			case 0:
+				step = rnd.IntN(10)
+			case 1:
+				step = rnd.IntN(10000)
+			default:
+				step = rnd.IntN(1 << 22)
+			}
+			now += step
+			got := w.Advance(at(now))
+			var want []int
+			for k, tm := range pending {
+				if tm.due <= now {
+					want = append(want, k)
+					delete(pending, k)
+				}
+			}
+			// Timers expire in order of their due ticks.
+			for j := 1; j < len(got); j++ {
+				if dues[got[j]] < dues[got[j-1]] {
+					t.Fatalf("at %d: %d (due %d) expired after %d (due %d)",
+						now, got[j], dues[got[j]], got[j-1], dues[got[j-1]])
+				}
+			}
+			slices.Sort(got)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("at %d: expired %v, want %v", now, got, want)
+			}
+		}
+		if w.Len() != len(pending) {
+			t.Fatalf("Len=%d, want %d", w.Len(), len(pending))
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	w := New[int](time.Millisecond, time.Now())
+	var mu sync.Mutex
+	var got []int
+	done := make(chan struct{})
+	w.Start(func(v int) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, v)
+		if len(got) == 3 {
+			close(done)
+		}
+	})
+	defer w.Stop()
+	now := time.Now()
+	for i := range 3 {
+		w.Schedule(now.Add(time.Duration(i+1)*5*time.Millisecond), i)
+	}
+	w.Cancel(w.Schedule(now.Add(time.Millisecond), 100))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timers didn't expire")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("expired %v", got)
+	}
+}
+
+func TestPanics(t *testing.T) {
+	w := New[int](time.Second, origin)
+	other := New[int](time.Second, origin)
+	for name, f := range map[string]func(){
+		"tick":   func() { New[int](0, origin) },
+		"cancel": func() { w.Cancel(other.Schedule(at(5000), 1)) },
+		"start": func() {
+			w.Start(func(int) {})
+			defer w.Stop()
+			w.Start(func(int) {})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func BenchmarkScheduleCancel(b *testing.B) {
+	w := New[int](time.Millisecond, origin)
+	for i := range 10000 {
+		w.Schedule(at(i%30000), i)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		w.Cancel(w.Schedule(at(i%30000), i))
+	}
+}
+
+func BenchmarkAdvance(b *testing.B) {
+	w := New[int](time.Millisecond, origin)
+	for i := range b.N {
+		w.Schedule(at(i+30000), i)
+		w.Advance(at(i))
+	}
+}
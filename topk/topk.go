@@ -0,0 +1,207 @@
+// Package topk implements the Space-Saving algorithm for finding the most
+// frequent values in a stream.
+package topk
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/eliben/gogl/heap"
+)
+
+// TopK tracks the approximate k most frequent values in a stream of values
+// of type T, using memory proportional to k regardless of the number of
+// distinct values. It's the Space-Saving algorithm of Metwally, Agrawal
+// and El Abbadi: up to k values are tracked with counters, and a value that
+// isn't tracked when the counters are all in use takes over the counter of
+// the least frequent tracked value, inheriting its count as possible
+// overcount.
+//
+// Every value occurring more than Total()/k times is guaranteed to be
+// tracked, and the count of a tracked value overestimates its true count
+// by at most the Error reported with it. Create TopK trackers with [New].
+type TopK[T comparable] struct {
+	k     int
+	total int
+
+	// items maps tracked values to their handles in counts, a min-heap of
+	// the counters ordered by count.
+	items  map[T]*heap.Handle[Item[T]]
+	counts *heap.Indexed[Item[T]]
+
+	// floor bounds the count of untracked values while fewer than k values
+	// are tracked. It's 0 unless a merge made it larger.
+	floor int
+}
+
+// Item is a tracked value with its estimated count. The true count of Value
+// is between Count-Error and Count.
+type Item[T comparable] struct {
+	Value T
+	Count int
+	Error int
+}
+
+// New creates a new, empty tracker of the k most frequent values.
+func New[T comparable](k int) *TopK[T] {
+	if k <= 0 {
+		panic(fmt.Sprintf("topk: invalid k %d", k))
+	}
+	return &TopK[T]{
+		k:      k,
+		items:  make(map[T]*heap.Handle[Item[T]], k),
+		counts: heap.NewIndexed(compareCounts[T]),
+	}
+}
+
+func compareCounts[T comparable](a, b Item[T]) int {
+	return cmp.Compare(a.Count, b.Count)
+}
+
+// K returns the maximal number of values tracked.
+func (t *TopK[T]) K() int {
+	return t.k
+}
+
+// Len returns the number of values tracked.
+func (t *TopK[T]) Len() int {
+	return len(t.items)
+}
+
+// Total returns the total number of occurrences added.
+func (t *TopK[T]) Total() int {
+	return t.total
+}
+
+// Add adds an occurrence of v.
+func (t *TopK[T]) Add(v T) {
+	t.AddN(v, 1)
+}
+
+// AddN adds n occurrences of v; n must be positive.
+func (t *TopK[T]) AddN(v T, n int) {
+	if n <= 0 {
+		panic(fmt.Sprintf("topk: invalid count %d", n))
+	}
+	t.total += n
+	if hd, ok := t.items[v]; ok {
+		it := hd.Value()
+		it.Count += n
+		t.counts.Update(hd, it)
+		return
+	}
+	if len(t.items) < t.k {
+		t.items[v] = t.counts.Push(Item[T]{Value: v, Count: t.floor + n, Error: t.floor})
+		return
+	}
+	// Take over the counter of the least frequent value.
+	least := t.counts.Pop()
+	delete(t.items, least.Value)
+	t.items[v] = t.counts.Push(Item[T]{Value: v, Count: least.Count + n, Error: least.Count})
+}
+
+// Count returns the tracked item for v and true. If v isn't tracked, it
+// returns false; v then occurred at most MinCount() times.
+func (t *TopK[T]) Count(v T) (Item[T], bool) {
+	if hd, ok := t.items[v]; ok {
+		return hd.Value(), true
+	}
+	return Item[T]{}, false
+}
+
+// MinCount returns the smallest count of the tracked values, which bounds
+// the count of every untracked value. If fewer than k values are tracked,
+// it's 0 (all values are tracked) unless the tracker was merged.
+func (t *TopK[T]) MinCount() int {
+	if len(t.items) < t.k {
+		return t.floor
+	}
+	return t.counts.Peek().Count
+}
+
+// Items returns the tracked items in descending order of count.
+func (t *TopK[T]) Items() []Item[T] {
+	items := make([]Item[T], 0, len(t.items))
+	for _, hd := range t.items {
+		items = append(items, hd.Value())
+	}
+	sortItems(items)
+	return items
+}
+
+// Guaranteed returns the tracked items that are guaranteed to be among the
+// most frequent n values, in descending order of count: those whose count
+// lower bound is at least the count upper bound of any other value.
+func (t *TopK[T]) Guaranteed(n int) []Item[T] {
+	if n <= 0 {
+		return nil
+	}
+	items := t.Items()
+	threshold := t.MinCount()
+	if n < len(items) {
+		threshold = max(threshold, items[n].Count)
+	} else {
+		n = len(items)
+	}
+	var result []Item[T]
+	for _, it := range items[:n] {
+		if it.Count-it.Error >= threshold {
+			result = append(result, it)
+		}
+	}
+	return result
+}
+
+// sortItems sorts items in descending order of count; ties are broken by
+// the smaller error first.
+func sortItems[T comparable](items []Item[T]) {
+	slices.SortFunc(items, func(a, b Item[T]) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Error, b.Error)
+	})
+}
+
+// Merge adds the occurrences summarized by other to t, so that t
+// summarizes both streams; other can track a different number of values.
+// This is the merge of Agarwal et al.'s "Mergeable Summaries": a value
+// missing from one summary is assumed to have that summary's MinCount
+// occurrences, all of them overcount. The error bounds of the merged
+// summary hold for the combined stream.
+func (t *TopK[T]) Merge(other *TopK[T]) {
+	minT, minO := t.MinCount(), other.MinCount()
+	merged := make(map[T]Item[T], len(t.items)+len(other.items))
+	for v, hd := range t.items {
+		it := hd.Value()
+		it.Count += minO
+		it.Error += minO
+		merged[v] = it
+	}
+	for v, hd := range other.items {
+		o := hd.Value()
+		if it, ok := merged[v]; ok {
+			it.Count += o.Count - minO
+			it.Error += o.Error - minO
+			merged[v] = it
+		} else {
+			merged[v] = Item[T]{Value: v, Count: o.Count + minT, Error: o.Error + minT}
+		}
+	}
+
+	items := make([]Item[T], 0, len(merged))
+	for _, it := range merged {
+		items = append(items, it)
+	}
+	sortItems(items)
+	t.total += other.total
+	// Values in neither summary occurred at most minT+minO times; every
+	// merged item's count is at least that.
+	t.floor = minT + minO
+	t.items = make(map[T]*heap.Handle[Item[T]], t.k)
+	t.counts = heap.NewIndexed(compareCounts[T])
+	for _, it := range items[:min(len(items), t.k)] {
+		t.items[it.Value] = t.counts.Push(it)
+	}
+}
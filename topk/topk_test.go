@@ -0,0 +1,159 @@
+package topk
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// checkBounds verifies the Space-Saving guarantees of tk against the exact
+// counts of the stream it summarizes.
+func checkBounds(t *testing.T, tk *TopK[int], counts map[int]int) {
+	t.Helper()
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	if tk.Total() != total {
+		t.Fatalf("Total=%d, want %d", tk.Total(), total)
+	}
+	for v, c := range counts {
+		it, ok := tk.Count(v)
+		if !ok {
+			if c > tk.MinCount() {
+				t.Fatalf("untracked %d occurred %d times, MinCount=%d", v, c, tk.MinCount())
+			}
+			if c > total/tk.K() {
+				t.Fatalf("frequent value %d (%d of %d) not tracked", v, c, total)
+			}
+			continue
+		}
+		if it.Count < c || it.Count-it.Error > c {
+			t.Fatalf("%d: count %d error %d, true count %d", v, it.Count, it.Error, c)
+		}
+	}
+	items := tk.Items()
+	if !slices.IsSortedFunc(items, func(a, b Item[int]) int { return b.Count - a.Count }) {
+		t.Fatalf("Items not sorted: %v", items)
+	}
+}
+
+func TestExact(t *testing.T) {
+	// With fewer distinct values than k, counts are exact.
+	tk := New[string](10)
+	for _, v := range []string{"a", "b", "a", "c", "a", "b"} {
+		tk.Add(v)
+	}
+	tk.AddN("d", 5)
+	want := []Item[string]{{"d", 5, 0}, {"a", 3, 0}, {"b", 2, 0}, {"c", 1, 0}}
+	if got := tk.Items(); !slices.Equal(got, want) {
+		t.Errorf("Items()=%v, want %v", got, want)
+	}
+	if got := tk.Guaranteed(2); !slices.Equal(got, want[:2]) {
+		t.Errorf("Guaranteed(2)=%v", got)
+	}
+	if got := tk.Guaranteed(10); !slices.Equal(got, want) {
+		t.Errorf("Guaranteed(10)=%v", got)
+	}
+	if _, ok := tk.Count("x"); ok || tk.MinCount() != 0 || tk.Len() != 4 {
+		t.Errorf("Count(x) found, MinCount=%d, Len=%d", tk.MinCount(), tk.Len())
+	}
+}
+
+func TestEviction(t *testing.T) {
+	tk := New[string](2)
+	tk.AddN("a", 5)
+	tk.AddN("b", 3)
+	tk.Add("c")
+	// c takes over b's counter.
+	if _, ok := tk.Count("b"); ok {
+		t.Errorf("b still tracked")
+	}
+	if it, ok := tk.Count("c"); !ok || it != (Item[string]{"c", 4, 3}) {
+		t.Errorf("Count(c)=%v,%v", it, ok)
+	}
+	if got := tk.Guaranteed(1); !slices.Equal(got, []Item[string]{{"a", 5, 0}}) {
+		t.Errorf("Guaranteed(1)=%v", got)
+	}
+}
+
+func TestZipf(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	z := rand.NewZipf(rnd, 1.2, 1, 10000)
+	tk := New[int](50)
+	counts := make(map[int]int)
+	for i := range 100000 {
+		v := int(z.Uint64())
+		tk.Add(v)
+		counts[v]++
+		if i%10000 == 0 {
+			checkBounds(t, tk, counts)
+		}
+	}
+	checkBounds(t, tk, counts)
+
+	// The true top 5 are very frequent and must be reported as guaranteed.
+	g := tk.Guaranteed(5)
+	if len(g) != 5 {
+		t.Fatalf("Guaranteed(5)=%v", g)
+	}
+	for i, it := range g {
+		if it.Value != i {
+			t.Errorf("Guaranteed(5)[%d]=%v, want value %d", i, it, i)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	// Summarize parts of a stream with differently sized trackers, then
+	// merge them; the bounds must hold for the whole stream.
+	z := rand.NewZipf(rnd, 1.1, 1, 5000)
+	parts := []*TopK[int]{New[int](30), New[int](60), New[int](5)}
+	counts := make(map[int]int)
+	for range 60000 {
+		v := int(z.Uint64())
+		parts[rnd.IntN(len(parts))].Add(v)
+		counts[v]++
+	}
+	merged := New[int](40)
+	for _, p := range parts {
+		merged.Merge(p)
+	}
+	if merged.Len() != 40 {
+		t.Errorf("merged Len=%d", merged.Len())
+	}
+	checkBounds(t, merged, counts)
+
+	// Merging into a tracker with room left keeps the untracked bound.
+	small := New[int](1000)
+	small.Merge(parts[2])
+	floor := parts[2].MinCount()
+	if floor == 0 || small.MinCount() != floor {
+		t.Errorf("MinCount=%d after merge, want %d", small.MinCount(), floor)
+	}
+	small.Add(-1)
+	if it, _ := small.Count(-1); it.Count != floor+1 || it.Error != floor {
+		t.Errorf("new value after merge: %v, want count %d", it, floor+1)
+	}
+}
+
+func BenchmarkAdd(b *testing.B) {
+	z := rand.NewZipf(rand.New(rand.NewPCG(1, 2)), 1.1, 1, 1000000)
+	vs := make([]int, 1<<16)
+	for i := range vs {
+		vs[i] = int(z.Uint64())
+	}
+	tk := New[int](100)
+	b.ResetTimer()
+	for i := range b.N {
+		tk.Add(vs[i&(len(vs)-1)])
+	}
+}
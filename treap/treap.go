@@ -0,0 +1,335 @@
+// Package treap implements treaps: randomized balanced binary search trees
+// supporting O(log n) split and merge.
+//
+// [Treap] is an ordered map. [Sequence] is an implicit treap, where nodes
+// are ordered by position rather than by key, used as a sequence with
+// O(log n) positional insertion, deletion, splitting and concatenation.
+package treap
+
+import (
+	"iter"
+	"math/rand/v2"
+)
+
+// node is a treap node. Keys are in binary search tree order, and
+// priorities are in max-heap order; since priorities are random, the tree
+// has the shape of a random BST, with expected height O(log n). size is the
+// number of nodes in the subtree, used for positional operations.
+type node[K, V any] struct {
+	key         K
+	value       V
+	priority    uint64
+	size        int
+	left, right *node[K, V]
+}
+
+func (n *node[K, V]) sz() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *node[K, V]) update() {
+	n.size = n.left.sz() + n.right.sz() + 1
+}
+
+// merge merges the treaps rooted at a and b, where all nodes of a precede
+// all nodes of b, and returns the root of the result.
+func merge[K, V any](a, b *node[K, V]) *node[K, V] {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.priority > b.priority:
+		a.right = merge(a.right, b)
+		a.update()
+		return a
+	default:
+		b.left = merge(a, b.left)
+		b.update()
+		return b
+	}
+}
+
+// splitKey splits the treap rooted at n into nodes with keys < key and nodes
+// with keys >= key.
+func splitKey[K, V any](n *node[K, V], key K, cmp func(K, K) int) (*node[K, V], *node[K, V]) {
+	if n == nil {
+		return nil, nil
+	}
+	if cmp(n.key, key) < 0 {
+		l, r := splitKey(n.right, key, cmp)
+		n.right = l
+		n.update()
+		return n, r
+	}
+	l, r := splitKey(n.left, key, cmp)
+	n.left = r
+	n.update()
+	return l, n
+}
+
+// splitSize splits the treap rooted at n into its first k nodes and the
+// rest.
+func splitSize[K, V any](n *node[K, V], k int) (*node[K, V], *node[K, V]) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.left.sz() < k {
+		l, r := splitSize(n.right, k-n.left.sz()-1)
+		n.right = l
+		n.update()
+		return n, r
+	}
+	l, r := splitSize(n.left, k)
+	n.left = r
+	n.update()
+	return l, n
+}
+
+// nth returns the node at position i in the treap rooted at n.
+func nth[K, V any](n *node[K, V], i int) *node[K, V] {
+	for {
+		switch ls := n.left.sz(); {
+		case i < ls:
+			n = n.left
+		case i > ls:
+			i -= ls + 1
+			n = n.right
+		default:
+			return n
+		}
+	}
+}
+
+// walk yields the nodes of the treap rooted at n in order. It returns false
+// if iteration was stopped.
+func walk[K, V any](n *node[K, V], yield func(*node[K, V]) bool) bool {
+	return n == nil || (walk(n.left, yield) && yield(n) && walk(n.right, yield))
+}
+
+// Treap is an ordered map from keys of type K to values of type V. Create
+// treaps with [New].
+type Treap[K, V any] struct {
+	cmp  func(K, K) int
+	root *node[K, V]
+	rnd  *rand.Rand
+}
+
+// New creates a new, empty treap with the given comparison function.
+// cmp(a, b) should return a negative number when a<b, a positive number when
+// a>b and zero when a==b.
+func New[K, V any](cmp func(K, K) int) *Treap[K, V] {
+	return &Treap[K, V]{cmp: cmp, rnd: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+}
+
+// Len returns the number of keys in the treap.
+func (t *Treap[K, V]) Len() int {
+	return t.root.sz()
+}
+
+// Get looks for the given key in the treap. It returns the associated value
+// and ok=true; otherwise, it returns ok=false.
+func (t *Treap[K, V]) Get(key K) (v V, ok bool) {
+	if n := t.find(key); n != nil {
+		return n.value, true
+	}
+	return v, false
+}
+
+// Insert inserts a new key=value pair into the treap. If key already exists
+// in the treap, its value is replaced with value.
+func (t *Treap[K, V]) Insert(key K, value V) {
+	if n := t.find(key); n != nil {
+		n.value = value
+		return
+	}
+	l, r := splitKey(t.root, key, t.cmp)
+	n := &node[K, V]{key: key, value: value, priority: t.rnd.Uint64(), size: 1}
+	t.root = merge(merge(l, n), r)
+}
+
+// Delete deletes a key and its associated value from the treap. It returns
+// true if the key was found and deleted, false otherwise.
+func (t *Treap[K, V]) Delete(key K) bool {
+	l, r := splitKey(t.root, key, t.cmp)
+	// key, if present, is the first node of r.
+	first, rest := splitSize(r, 1)
+	if first != nil && t.cmp(first.key, key) == 0 {
+		t.root = merge(l, rest)
+		return true
+	}
+	t.root = merge(l, merge(first, rest))
+	return false
+}
+
+// At returns the key and value at position i in the treap's order, i.e. the
+// key with exactly i smaller keys in the treap. It panics if i is out of
+// range.
+func (t *Treap[K, V]) At(i int) (K, V) {
+	if i < 0 || i >= t.Len() {
+		panic("treap: index out of range")
+	}
+	n := nth(t.root, i)
+	return n.key, n.value
+}
+
+// Rank returns the number of keys in the treap smaller than key.
+func (t *Treap[K, V]) Rank(key K) int {
+	rank := 0
+	for n := t.root; n != nil; {
+		if t.cmp(n.key, key) < 0 {
+			rank += n.left.sz() + 1
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return rank
+}
+
+// Split removes all the keys larger than or equal to key from t, and
+// returns them in a new treap. It takes O(log n) expected time.
+func (t *Treap[K, V]) Split(key K) *Treap[K, V] {
+	l, r := splitKey(t.root, key, t.cmp)
+	t.root = l
+	return &Treap[K, V]{cmp: t.cmp, root: r, rnd: rand.New(rand.NewPCG(t.rnd.Uint64(), t.rnd.Uint64()))}
+}
+
+// Merge moves all the keys of other into t, leaving other empty. All the
+// keys in other must be larger than all the keys in t; Merge panics
+// otherwise. It takes O(log n) expected time.
+func (t *Treap[K, V]) Merge(other *Treap[K, V]) {
+	if t.root != nil && other.root != nil {
+		maxNode := nth(t.root, t.Len()-1)
+		minNode := nth(other.root, 0)
+		if t.cmp(maxNode.key, minNode.key) >= 0 {
+			panic("treap: Merge with overlapping key ranges")
+		}
+	}
+	t.root = merge(t.root, other.root)
+	other.root = nil
+}
+
+// All returns an iterator over all key, value pairs in the treap, in
+// ascending order of keys.
+func (t *Treap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		walk(t.root, func(n *node[K, V]) bool {
+			return yield(n.key, n.value)
+		})
+	}
+}
+
+func (t *Treap[K, V]) find(key K) *node[K, V] {
+	n := t.root
+	for n != nil {
+		c := t.cmp(key, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// Sequence is a sequence of values of type T backed by an implicit treap,
+// where each node's position is determined by the sizes of the subtrees
+// preceding it. All positional operations take O(log n) expected time. The
+// zero value is an empty sequence ready to use.
+type Sequence[T any] struct {
+	root *node[struct{}, T]
+	rnd  *rand.Rand
+}
+
+// NewSequence creates a new, empty sequence.
+func NewSequence[T any]() *Sequence[T] {
+	return &Sequence[T]{}
+}
+
+// Len returns the number of values in the sequence.
+func (s *Sequence[T]) Len() int {
+	return s.root.sz()
+}
+
+// At returns the value at index i. It panics if i is out of range.
+func (s *Sequence[T]) At(i int) T {
+	s.checkIndex(i, s.Len()-1)
+	return nth(s.root, i).value
+}
+
+// Set sets the value at index i to v. It panics if i is out of range.
+func (s *Sequence[T]) Set(i int, v T) {
+	s.checkIndex(i, s.Len()-1)
+	nth(s.root, i).value = v
+}
+
+// Insert inserts v at index i, shifting the values at i and after it. It
+// panics if i is not in the range [0, Len()].
+func (s *Sequence[T]) Insert(i int, v T) {
+	s.checkIndex(i, s.Len())
+	if s.rnd == nil {
+		s.rnd = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	l, r := splitSize(s.root, i)
+	n := &node[struct{}, T]{value: v, priority: s.rnd.Uint64(), size: 1}
+	s.root = merge(merge(l, n), r)
+}
+
+// PushBack appends v to the end of the sequence.
+func (s *Sequence[T]) PushBack(v T) {
+	s.Insert(s.Len(), v)
+}
+
+// Delete removes the value at index i and returns it. It panics if i is out
+// of range.
+func (s *Sequence[T]) Delete(i int) T {
+	s.checkIndex(i, s.Len()-1)
+	l, r := splitSize(s.root, i)
+	n, rest := splitSize(r, 1)
+	s.root = merge(l, rest)
+	return n.value
+}
+
+// SplitAt removes the values at index i and after it from s, and returns
+// them in a new sequence. It panics if i is not in the range [0, Len()].
+func (s *Sequence[T]) SplitAt(i int) *Sequence[T] {
+	s.checkIndex(i, s.Len())
+	l, r := splitSize(s.root, i)
+	s.root = l
+	return &Sequence[T]{root: r}
+}
+
+// Concat appends all the values of other to s, leaving other empty. It
+// panics if other is s.
+func (s *Sequence[T]) Concat(other *Sequence[T]) {
+	if s == other {
+		panic("treap: Concat of a sequence with itself")
+	}
+	s.root = merge(s.root, other.root)
+	other.root = nil
+}
+
+// All returns an iterator over the indices and values of the sequence, in
+// order.
+func (s *Sequence[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		walk(s.root, func(n *node[struct{}, T]) bool {
+			i++
+			return yield(i-1, n.value)
+		})
+	}
+}
+
+func (s *Sequence[T]) checkIndex(i, last int) {
+	if i < 0 || i > last {
+		panic("treap: index out of range")
+	}
+}
@@ -0,0 +1,202 @@
+package treap
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// verifyNode checks the heap order of priorities and the subtree sizes in
+// the treap rooted at n.
+func verifyNode[K, V any](n *node[K, V]) error {
+	if n == nil {
+		return nil
+	}
+	for _, c := range []*node[K, V]{n.left, n.right} {
+		if c != nil && c.priority > n.priority {
+			return fmt.Errorf("heap order violated at %v", n.key)
+		}
+	}
+	if n.size != n.left.sz()+n.right.sz()+1 {
+		return fmt.Errorf("bad size at %v", n.key)
+	}
+	if err := verifyNode(n.left); err != nil {
+		return err
+	}
+	return verifyNode(n.right)
+}
+
+func checkTreap(t *testing.T, tr *Treap[int, int], want map[int]int) {
+	t.Helper()
+	if err := verifyNode(tr.root); err != nil {
+		t.Fatal(err)
+	}
+	if tr.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", tr.Len(), len(want))
+	}
+	var keys []int
+	for k, v := range tr.All() {
+		if want[k] != v {
+			t.Fatalf("got %d=%d, want %d", k, v, want[k])
+		}
+		keys = append(keys, k)
+	}
+	if wantKeys := slices.Sorted(maps.Keys(want)); !slices.Equal(keys, wantKeys) {
+		t.Fatalf("got keys %v, want %v", keys, wantKeys)
+	}
+}
+
+func TestTreapRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	tr := New[int, int](cmp.Compare[int])
+	want := map[int]int{}
+	for i := range 10000 {
+		k := rnd.IntN(1000)
+		if rnd.IntN(3) == 0 {
+			_, inMap := want[k]
+			if tr.Delete(k) != inMap {
+				t.Fatalf("Delete(%d) mismatch", k)
+			}
+			delete(want, k)
+		} else {
+			tr.Insert(k, i)
+			want[k] = i
+		}
+	}
+	checkTreap(t, tr, want)
+
+	keys := slices.Sorted(maps.Keys(want))
+	for i, k := range keys {
+		if gk, gv := tr.At(i); gk != k || gv != want[k] {
+			t.Fatalf("At(%d)=%d,%d, want %d,%d", i, gk, gv, k, want[k])
+		}
+		if tr.Rank(k) != i {
+			t.Fatalf("Rank(%d)=%d, want %d", k, tr.Rank(k), i)
+		}
+	}
+	if v, ok := tr.Get(keys[0]); !ok || v != want[keys[0]] {
+		t.Errorf("Get mismatch")
+	}
+}
+
+func TestSplitMerge(t *testing.T) {
+	tr := New[int, int](cmp.Compare[int])
+	want := map[int]int{}
+	for i := range 100 {
+		tr.Insert(i, i*i)
+		want[i] = i * i
+	}
+
+	upper := tr.Split(40)
+	lowerWant, upperWant := map[int]int{}, map[int]int{}
+	for k, v := range want {
+		if k < 40 {
+			lowerWant[k] = v
+		} else {
+			upperWant[k] = v
+		}
+	}
+	checkTreap(t, tr, lowerWant)
+	checkTreap(t, upper, upperWant)
+
+	// The split parts remain fully functional.
+	upper.Insert(1000, 1)
+	upperWant[1000] = 1
+	checkTreap(t, upper, upperWant)
+	upper.Delete(1000)
+	delete(upperWant, 1000)
+
+	tr.Merge(upper)
+	checkTreap(t, tr, want)
+	checkTreap(t, upper, map[int]int{})
+
+	other := New[int, int](cmp.Compare[int])
+	other.Insert(50, 0)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic merging overlapping treaps")
+		}
+	}()
+	tr.Merge(other)
+}
+
+func checkSequence(t *testing.T, s *Sequence[int], want []int) {
+	t.Helper()
+	if err := verifyNode(s.root); err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", s.Len(), len(want))
+	}
+	var got []int
+	for i, v := range s.All() {
+		if i != len(got) {
+			t.Fatalf("got index %d, want %d", i, len(got))
+		}
+		got = append(got, v)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSequenceRandom(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	var s Sequence[int]
+	var want []int
+	for i := range 5000 {
+		switch rnd.IntN(4) {
+		case 0, 1:
+			pos := rnd.IntN(len(want) + 1)
+			s.Insert(pos, i)
+			want = slices.Insert(want, pos, i)
+		case 2:
+			if len(want) > 0 {
+				pos := rnd.IntN(len(want))
+				if got := s.Delete(pos); got != want[pos] {
+					t.Fatalf("Delete(%d)=%d, want %d", pos, got, want[pos])
+				}
+				want = slices.Delete(want, pos, pos+1)
+			}
+		case 3:
+			if len(want) > 0 {
+				pos := rnd.IntN(len(want))
+				s.Set(pos, -i)
+				want[pos] = -i
+				if s.At(pos) != -i {
+					t.Fatalf("At(%d)=%d after Set", pos, s.At(pos))
+				}
+			}
+		}
+	}
+	checkSequence(t, &s, want)
+
+	// Rotate the sequence by splitting and concatenating.
+	k := len(want) / 3
+	tail := s.SplitAt(k)
+	checkSequence(t, &s, want[:k])
+	checkSequence(t, tail, want[k:])
+	tail.Concat(&s)
+	checkSequence(t, tail, append(slices.Clone(want[k:]), want[:k]...))
+	checkSequence(t, &s, nil)
+	s.PushBack(7)
+	checkSequence(t, &s, []int{7})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic concatenating a sequence with itself")
+		}
+		checkSequence(t, &s, []int{7})
+	}()
+	s.Concat(&s)
+}
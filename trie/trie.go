@@ -0,0 +1,215 @@
+// Package trie implements a string-keyed prefix tree.
+package trie
+
+import (
+	"iter"
+	"slices"
+)
+
+// Trie is a prefix tree mapping string keys to values of type V. Keys are
+// treated as byte sequences; iteration yields keys in lexicographic (byte)
+// order, which matches Go's string ordering.
+type Trie[V any] struct {
+	root   *node[V]
+	length int
+}
+
+// node is a trie node. The key of a node is the concatenation of the labels
+// on the path from the root to it. children is kept sorted by label.
+type node[V any] struct {
+	children []child[V]
+	value    V
+	hasValue bool
+}
+
+type child[V any] struct {
+	label byte
+	node  *node[V]
+}
+
+// New creates a new, empty Trie.
+func New[V any]() *Trie[V] {
+	return &Trie[V]{root: &node[V]{}}
+}
+
+// Len returns the number of keys in the trie.
+func (t *Trie[V]) Len() int {
+	return t.length
+}
+
+// Insert inserts key with the given value into the trie. If key already
+// exists, its value is replaced.
+func (t *Trie[V]) Insert(key string, value V) {
+	n := t.root
+	for i := 0; i < len(key); i++ {
+		j, found := n.findChild(key[i])
+		if !found {
+			n.children = slices.Insert(n.children, j, child[V]{label: key[i], node: &node[V]{}})
+		}
+		n = n.children[j].node
+	}
+	if !n.hasValue {
+		t.length++
+	}
+	n.value = value
+	n.hasValue = true
+}
+
+// Get looks for key in the trie. It returns the associated value and ok=true;
+// otherwise, it returns ok=false.
+func (t *Trie[V]) Get(key string) (v V, ok bool) {
+	n := t.find(key)
+	if n == nil || !n.hasValue {
+		return v, false
+	}
+	return n.value, true
+}
+
+// Delete deletes key and its value from the trie. It returns true if the key
+// was found and deleted, false otherwise.
+func (t *Trie[V]) Delete(key string) bool {
+	// Record the path from the root to the key's node so that nodes left
+	// empty by the deletion can be pruned on the way back up.
+	path := make([]*node[V], 0, len(key)+1)
+	n := t.root
+	path = append(path, n)
+	for i := 0; i < len(key); i++ {
+		j, found := n.findChild(key[i])
+		if !found {
+			return false
+		}
+		n = n.children[j].node
+		path = append(path, n)
+	}
+	if !n.hasValue {
+		return false
+	}
+	n.value = *new(V)
+	n.hasValue = false
+	t.length--
+
+	for i := len(key); i > 0; i-- {
+		n := path[i]
+		if n.hasValue || len(n.children) > 0 {
+			break
+		}
+		parent := path[i-1]
+		j, _ := parent.findChild(key[i-1])
+		parent.children = slices.Delete(parent.children, j, j+1)
+	}
+	return true
+}
+
+// HasPrefix reports whether any key in the trie starts with prefix.
+func (t *Trie[V]) HasPrefix(prefix string) bool {
+	n := t.find(prefix)
+	return n != nil && (n.hasValue || len(n.children) > 0)
+}
+
+// All returns an iterator over all key, value pairs in the trie, in
+// lexicographic order of keys.
+func (t *Trie[V]) All() iter.Seq2[string, V] {
+	return t.WithPrefix("")
+}
+
+// WithPrefix returns an iterator over all key, value pairs in the trie whose
+// keys start with prefix, in lexicographic order of keys.
+func (t *Trie[V]) WithPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		n := t.find(prefix)
+		if n == nil {
+			return
+		}
+		buf := []byte(prefix)
+		n.walk(&buf, yield)
+	}
+}
+
+// WithinDistance returns an iterator over all key, value pairs in the trie
+// whose keys are within Levenshtein distance d of key (counting single byte
+// insertions, deletions and substitutions), in lexicographic order of keys.
+// Subtrees that can't contain keys within distance d are pruned, so this is
+// much faster than checking every key for small d.
+func (t *Trie[V]) WithinDistance(key string, d int) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		// row[j] is the edit distance between the current node's key and
+		// key[:j]; for the root's empty key, it's j.
+		row := make([]int, len(key)+1)
+		for j := range row {
+			row[j] = j
+		}
+		buf := []byte{}
+		t.root.searchWithin(key, d, row, &buf, yield)
+	}
+}
+
+// find returns the node for key, or nil if there's no such node.
+func (t *Trie[V]) find(key string) *node[V] {
+	n := t.root
+	for i := 0; i < len(key); i++ {
+		j, found := n.findChild(key[i])
+		if !found {
+			return nil
+		}
+		n = n.children[j].node
+	}
+	return n
+}
+
+// findChild finds the child with the given label. It returns its index and
+// true if found; otherwise, it returns the index where such a child should be
+// inserted, and false.
+func (n *node[V]) findChild(label byte) (int, bool) {
+	return slices.BinarySearchFunc(n.children, label, func(c child[V], l byte) int {
+		return int(c.label) - int(l)
+	})
+}
+
+// walk yields all the key, value pairs in the subtree of n in pre-order,
+// which is lexicographic order. buf holds the key of n, and is used as
+// scratch space for building the keys of descendants. It returns false if
+// iteration was stopped.
+func (n *node[V]) walk(buf *[]byte, yield func(string, V) bool) bool {
+	if n.hasValue && !yield(string(*buf), n.value) {
+		return false
+	}
+	for _, c := range n.children {
+		*buf = append(*buf, c.label)
+		if !c.node.walk(buf, yield) {
+			return false
+		}
+		*buf = (*buf)[:len(*buf)-1]
+	}
+	return true
+}
+
+// searchWithin is the recursive helper of WithinDistance. buf holds the key
+// of n, and row holds the edit distance row for it. It returns false if
+// iteration was stopped.
+func (n *node[V]) searchWithin(key string, d int, row []int, buf *[]byte, yield func(string, V) bool) bool {
+	if n.hasValue && row[len(key)] <= d && !yield(string(*buf), n.value) {
+		return false
+	}
+	// If every entry in the row exceeds d, no extension of this node's key
+	// can get within distance d.
+	if slices.Min(row) > d {
+		return true
+	}
+	for _, c := range n.children {
+		newRow := make([]int, len(row))
+		newRow[0] = row[0] + 1
+		for j := 1; j < len(row); j++ {
+			cost := 1
+			if key[j-1] == c.label {
+				cost = 0
+			}
+			newRow[j] = min(newRow[j-1]+1, row[j]+1, row[j-1]+cost)
+		}
+		*buf = append(*buf, c.label)
+		if !c.node.searchWithin(key, d, newRow, buf, yield) {
+			return false
+		}
+		*buf = (*buf)[:len(*buf)-1]
+	}
+	return true
+}
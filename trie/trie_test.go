@@ -0,0 +1,212 @@
+package trie
+
+import (
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func checkAll[V comparable](t *testing.T, tr *Trie[V], want map[string]V) {
+	t.Helper()
+	if tr.Len() != len(want) {
+		t.Errorf("got len=%d, want %d", tr.Len(), len(want))
+	}
+	var keys []string
+	for k, v := range tr.All() {
+		if want[k] != v {
+			t.Errorf("got %q=%v, want %v", k, v, want[k])
+		}
+		keys = append(keys, k)
+	}
+	wantKeys := slices.Sorted(maps.Keys(want))
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("got keys %q, want %q", keys, wantKeys)
+	}
+}
+
+func TestInsertGet(t *testing.T) {
+	tr := New[int]()
+	tr.Insert("tea", 1)
+	tr.Insert("ten", 2)
+	tr.Insert("to", 3)
+	tr.Insert("", 4)
+	tr.Insert("inn", 5)
+	tr.Insert("tea", 10)
+
+	checkAll(t, tr, map[string]int{"tea": 10, "ten": 2, "to": 3, "": 4, "inn": 5})
+
+	if v, ok := tr.Get("to"); !ok || v != 3 {
+		t.Errorf("got Get(to)=%v,%v", v, ok)
+	}
+	for _, k := range []string{"t", "te", "teas", "x", "in"} {
+		if _, ok := tr.Get(k); ok {
+			t.Errorf("found non-key %q", k)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tr := New[string]()
+	for _, w := range []string{"car", "cart", "carbon", "cat", "dog", "do"} {
+		tr.Insert(w, w)
+	}
+
+	for _, p := range []string{"", "c", "ca", "car", "cart", "d", "do"} {
+		if !tr.HasPrefix(p) {
+			t.Errorf("HasPrefix(%q)=false", p)
+		}
+	}
+	for _, p := range []string{"x", "carts", "cb", "dogs"} {
+		if tr.HasPrefix(p) {
+			t.Errorf("HasPrefix(%q)=true", p)
+		}
+	}
+
+	var got []string
+	for k := range tr.WithPrefix("car") {
+		got = append(got, k)
+	}
+	if want := []string{"car", "carbon", "cart"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Early exit from iteration.
+	got = nil
+	for k := range tr.All() {
+		got = append(got, k)
+		if len(got) == 2 {
+			break
+		}
+	}
+	if want := []string{"car", "carbon"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if n := len(maps.Collect(tr.WithPrefix("z"))); n != 0 {
+		t.Errorf("got %d keys with prefix z", n)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tr := New[int]()
+	tr.Insert("a", 1)
+	tr.Insert("ab", 2)
+	tr.Insert("abc", 3)
+
+	if tr.Delete("abcd") || tr.Delete("x") || tr.Delete("") {
+		t.Errorf("deleted non-existent key")
+	}
+	if !tr.Delete("ab") || tr.Delete("ab") {
+		t.Errorf("bad Delete results")
+	}
+	checkAll(t, tr, map[string]int{"a": 1, "abc": 3})
+
+	tr.Delete("abc")
+	checkAll(t, tr, map[string]int{"a": 1})
+	if tr.HasPrefix("ab") {
+		t.Errorf("nodes not pruned after delete")
+	}
+	tr.Delete("a")
+	checkAll(t, tr, map[string]int{})
+	if len(tr.root.children) != 0 {
+		t.Errorf("root has children after deleting everything")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	randKey := func() string {
+		b := make([]byte, rnd.IntN(6))
+		for i := range b {
+			b[i] = "abcd"[rnd.IntN(4)]
+		}
+		return string(b)
+	}
+
+	tr := New[int]()
+	m := make(map[string]int)
+	for i := range 3000 {
+		k := randKey()
+		if rnd.IntN(3) == 0 {
+			_, inMap := m[k]
+			if tr.Delete(k) != inMap {
+				t.Fatalf("Delete(%q) mismatch", k)
+			}
+			delete(m, k)
+		} else {
+			tr.Insert(k, i)
+			m[k] = i
+		}
+	}
+	checkAll(t, tr, m)
+}
+
+// levenshtein computes the edit distance between a and b.
+func levenshtein(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+func TestWithinDistance(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	randKey := func() string {
+		b := make([]byte, rnd.IntN(7))
+		for i := range b {
+			b[i] = "abc"[rnd.IntN(3)]
+		}
+		return string(b)
+	}
+
+	tr := New[int]()
+	var keys []string
+	for i := range 300 {
+		k := randKey()
+		tr.Insert(k, i)
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	for range 100 {
+		query := randKey()
+		d := rnd.IntN(4) - 1
+		var want []string
+		for _, k := range keys {
+			if levenshtein(k, query) <= d {
+				want = append(want, k)
+			}
+		}
+		var got []string
+		for k, v := range tr.WithinDistance(query, d) {
+			if tv, _ := tr.Get(k); tv != v {
+				t.Fatalf("got value %d for %q, want %d", v, k, tv)
+			}
+			got = append(got, k)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("WithinDistance(%q, %d)=%q, want %q", query, d, got, want)
+		}
+	}
+}
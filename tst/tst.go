@@ -0,0 +1,302 @@
+// Package tst implements a ternary search tree with string keys.
+package tst
+
+import "iter"
+
+// Tree is a ternary search tree mapping string keys to values of type V. Each
+// node holds a single byte and three children: lo (smaller bytes at the same
+// position), eq (the next position) and hi (larger bytes at the same
+// position). This makes it more memory-efficient than a trie with wide node
+// fan-out, while still supporting prefix queries.
+//
+// Keys are treated as byte sequences; iteration yields keys in lexicographic
+// (byte) order. The empty string is a valid key.
+type Tree[V any] struct {
+	root   *node[V]
+	length int
+
+	// The empty key can't be represented by a node, so it's stored
+	// separately.
+	emptyValue    V
+	hasEmptyValue bool
+}
+
+type node[V any] struct {
+	c          byte
+	lo, eq, hi *node[V]
+	value      V
+	hasValue   bool
+}
+
+// New creates a new, empty Tree.
+func New[V any]() *Tree[V] {
+	return &Tree[V]{}
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree[V]) Len() int {
+	return t.length
+}
+
+// Insert inserts key with the given value into the tree. If key already
+// exists, its value is replaced.
+func (t *Tree[V]) Insert(key string, value V) {
+	if key == "" {
+		if !t.hasEmptyValue {
+			t.length++
+		}
+		t.emptyValue, t.hasEmptyValue = value, true
+		return
+	}
+
+	np := &t.root
+	for i := 0; ; {
+		n := *np
+		if n == nil {
+			n = &node[V]{c: key[i]}
+			*np = n
+		}
+		switch {
+		case key[i] < n.c:
+			np = &n.lo
+		case key[i] > n.c:
+			np = &n.hi
+		case i < len(key)-1:
+			np = &n.eq
+			i++
+		default:
+			if !n.hasValue {
+				t.length++
+			}
+			n.value, n.hasValue = value, true
+			return
+		}
+	}
+}
+
+// Get looks for key in the tree. It returns the associated value and ok=true;
+// otherwise, it returns ok=false.
+func (t *Tree[V]) Get(key string) (v V, ok bool) {
+	if key == "" {
+		return t.emptyValue, t.hasEmptyValue
+	}
+	n := t.find(key)
+	if n == nil {
+		return v, false
+	}
+	return n.value, n.hasValue
+}
+
+// Delete deletes key and its value from the tree. It returns true if the key
+// was found and deleted, false otherwise.
+func (t *Tree[V]) Delete(key string) bool {
+	if key == "" {
+		if !t.hasEmptyValue {
+			return false
+		}
+		t.emptyValue, t.hasEmptyValue = *new(V), false
+		t.length--
+		return true
+	}
+
+	var deleted bool
+	t.root = t.deleteFrom(t.root, key, 0, &deleted)
+	if deleted {
+		t.length--
+	}
+	return deleted
+}
+
+// deleteFrom deletes key (whose position i is being matched) from the
+// subtree rooted at n, and returns the new root of the subtree. Nodes that
+// no longer lead to any key are pruned.
+func (t *Tree[V]) deleteFrom(n *node[V], key string, i int, deleted *bool) *node[V] {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case key[i] < n.c:
+		n.lo = t.deleteFrom(n.lo, key, i, deleted)
+	case key[i] > n.c:
+		n.hi = t.deleteFrom(n.hi, key, i, deleted)
+	case i < len(key)-1:
+		n.eq = t.deleteFrom(n.eq, key, i+1, deleted)
+	default:
+		if n.hasValue {
+			n.value, n.hasValue = *new(V), false
+			*deleted = true
+		}
+	}
+
+	if n.hasValue || n.eq != nil {
+		return n
+	}
+
+	// n no longer leads to any key by itself; remove it from the binary
+	// search tree formed by the lo/hi links.
+	switch {
+	case n.lo == nil:
+		return n.hi
+	case n.hi == nil:
+		return n.lo
+	}
+	// Replace n by its successor: the minimal node of its hi subtree.
+	parent, succ := n, n.hi
+	for succ.lo != nil {
+		parent, succ = succ, succ.lo
+	}
+	if parent != n {
+		parent.lo = succ.hi
+		succ.hi = n.hi
+	}
+	succ.lo = n.lo
+	return succ
+}
+
+// HasPrefix reports whether any key in the tree starts with prefix.
+func (t *Tree[V]) HasPrefix(prefix string) bool {
+	if prefix == "" {
+		return t.length > 0
+	}
+	n := t.find(prefix)
+	return n != nil && (n.hasValue || n.eq != nil)
+}
+
+// All returns an iterator over all key, value pairs in the tree, in
+// lexicographic order of keys.
+func (t *Tree[V]) All() iter.Seq2[string, V] {
+	return t.WithPrefix("")
+}
+
+// WithPrefix returns an iterator over all key, value pairs in the tree whose
+// keys start with prefix, in lexicographic order of keys.
+func (t *Tree[V]) WithPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		if prefix == "" {
+			if t.hasEmptyValue && !yield("", t.emptyValue) {
+				return
+			}
+			buf := []byte{}
+			walk(t.root, &buf, yield)
+			return
+		}
+
+		n := t.find(prefix)
+		if n == nil {
+			return
+		}
+		if n.hasValue && !yield(prefix, n.value) {
+			return
+		}
+		buf := []byte(prefix)
+		walk(n.eq, &buf, yield)
+	}
+}
+
+// NearNeighbors returns an iterator over all key, value pairs in the tree
+// whose keys are within one edit (a single byte insertion, deletion or
+// substitution) of key, including key itself if it's in the tree. Keys are
+// yielded in lexicographic order.
+func (t *Tree[V]) NearNeighbors(key string) iter.Seq2[string, V] {
+	return t.WithinDistance(key, 1)
+}
+
+// WithinDistance returns an iterator over all key, value pairs in the tree
+// whose keys are within Levenshtein distance d of key (counting single byte
+// insertions, deletions and substitutions), in lexicographic order. Subtrees
+// that can't contain keys within distance d are pruned, so this is much
+// faster than checking every key for small d.
+func (t *Tree[V]) WithinDistance(key string, d int) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		// row[j] is the edit distance between the current path's prefix and
+		// key[:j]; for the empty prefix, it's j.
+		row := make([]int, len(key)+1)
+		for j := range row {
+			row[j] = j
+		}
+		if t.hasEmptyValue && row[len(key)] <= d && !yield("", t.emptyValue) {
+			return
+		}
+		buf := []byte{}
+		searchWithin(t.root, key, d, row, &buf, yield)
+	}
+}
+
+// searchWithin is the recursive helper of WithinDistance. row holds the edit
+// distance row of the prefix in buf, which is the key of n's parent in the
+// eq-chain. It returns false if iteration was stopped.
+func searchWithin[V any](n *node[V], key string, d int, row []int, buf *[]byte, yield func(string, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if !searchWithin(n.lo, key, d, row, buf, yield) {
+		return false
+	}
+
+	// Compute the row for the prefix extended with n.c.
+	newRow := make([]int, len(row))
+	newRow[0] = row[0] + 1
+	rowMin := newRow[0]
+	for j := 1; j < len(row); j++ {
+		cost := 1
+		if key[j-1] == n.c {
+			cost = 0
+		}
+		newRow[j] = min(newRow[j-1]+1, row[j]+1, row[j-1]+cost)
+		rowMin = min(rowMin, newRow[j])
+	}
+
+	*buf = append(*buf, n.c)
+	if n.hasValue && newRow[len(key)] <= d && !yield(string(*buf), n.value) {
+		return false
+	}
+	// If every entry in the row exceeds d, no extension of this prefix can
+	// get within distance d.
+	if rowMin <= d && !searchWithin(n.eq, key, d, newRow, buf, yield) {
+		return false
+	}
+	*buf = (*buf)[:len(*buf)-1]
+
+	return searchWithin(n.hi, key, d, row, buf, yield)
+}
+
+// find returns the node for the non-empty key, or nil if there's no such
+// node.
+func (t *Tree[V]) find(key string) *node[V] {
+	n := t.root
+	for i := 0; n != nil; {
+		switch {
+		case key[i] < n.c:
+			n = n.lo
+		case key[i] > n.c:
+			n = n.hi
+		case i < len(key)-1:
+			n = n.eq
+			i++
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// walk yields all the key, value pairs in the subtree rooted at n in
+// lexicographic order. buf holds the key prefix leading to n. It returns
+// false if iteration was stopped.
+func walk[V any](n *node[V], buf *[]byte, yield func(string, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	if !walk(n.lo, buf, yield) {
+		return false
+	}
+	*buf = append(*buf, n.c)
+	if n.hasValue && !yield(string(*buf), n.value) {
+		return false
+	}
+	if !walk(n.eq, buf, yield) {
+		return false
+	}
+	*buf = (*buf)[:len(*buf)-1]
+	return walk(n.hi, buf, yield)
+}
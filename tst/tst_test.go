@@ -0,0 +1,219 @@
+package tst
+
+import (
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func checkAll[V comparable](t *testing.T, tr *Tree[V], want map[string]V) {
+	t.Helper()
+	if tr.Len() != len(want) {
+		t.Errorf("got len=%d, want %d", tr.Len(), len(want))
+	}
+	var keys []string
+	for k, v := range tr.All() {
+		if want[k] != v {
+			t.Errorf("got %q=%v, want %v", k, v, want[k])
+		}
+		keys = append(keys, k)
+	}
+	if wantKeys := slices.Sorted(maps.Keys(want)); !slices.Equal(keys, wantKeys) {
+		t.Errorf("got keys %q, want %q", keys, wantKeys)
+	}
+}
+
+func TestInsertGet(t *testing.T) {
+	tr := New[int]()
+	want := map[string]int{}
+	for i, k := range []string{"she", "sells", "sea", "shells", "by", "the", "sea", "shore", ""} {
+		tr.Insert(k, i)
+		want[k] = i
+	}
+	checkAll(t, tr, want)
+
+	for k, w := range want {
+		if v, ok := tr.Get(k); !ok || v != w {
+			t.Errorf("got Get(%q)=%v,%v, want %v", k, v, ok, w)
+		}
+	}
+	for _, k := range []string{"s", "sh", "shell", "shores", "x"} {
+		if _, ok := tr.Get(k); ok {
+			t.Errorf("found non-key %q", k)
+		}
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	tr := New[bool]()
+	for _, k := range []string{"she", "sells", "sea", "shells", "shore", "the"} {
+		tr.Insert(k, true)
+	}
+	check := func(prefix string, want ...string) {
+		t.Helper()
+		var got []string
+		for k := range tr.WithPrefix(prefix) {
+			got = append(got, k)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("WithPrefix(%q)=%q, want %q", prefix, got, want)
+		}
+		if tr.HasPrefix(prefix) != (len(want) > 0) {
+			t.Errorf("HasPrefix(%q)=%v", prefix, tr.HasPrefix(prefix))
+		}
+	}
+	check("sh", "she", "shells", "shore")
+	check("she", "she", "shells")
+	check("s", "sea", "sells", "she", "shells", "shore")
+	check("shells", "shells")
+	check("x")
+	check("shellsx")
+}
+
+func TestNearNeighbors(t *testing.T) {
+	tr := New[int]()
+	for i, k := range []string{"cat", "cats", "cut", "at", "act", "dog", "cart", "scat", "c"} {
+		tr.Insert(k, i)
+	}
+	var got []string
+	for k := range tr.NearNeighbors("cat") {
+		got = append(got, k)
+	}
+	want := []string{"at", "cart", "cat", "cats", "cut", "scat"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = nil
+	for k := range tr.NearNeighbors("") {
+		got = append(got, k)
+	}
+	if want := []string{"c"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tr := New[int]()
+	want := map[string]int{}
+	for i, k := range []string{"m", "f", "t", "a", "h", "p", "z", "fa", "fab", "", "g", "i"} {
+		tr.Insert(k, i)
+		want[k] = i
+	}
+	for _, k := range []string{"x", "fabs", "ma"} {
+		if tr.Delete(k) {
+			t.Errorf("deleted non-existent key %q", k)
+		}
+	}
+	// Delete keys whose nodes have both lo and hi children, to exercise the
+	// successor replacement.
+	for _, k := range []string{"m", "f", "", "fab", "h", "t", "a", "fa", "z", "p", "g", "i"} {
+		if !tr.Delete(k) || tr.Delete(k) {
+			t.Errorf("bad Delete(%q) results", k)
+		}
+		delete(want, k)
+		checkAll(t, tr, want)
+	}
+	if tr.root != nil {
+		t.Errorf("tree not empty after deleting everything")
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	randKey := func() string {
+		var sb strings.Builder
+		for range rnd.IntN(6) {
+			sb.WriteByte("abcde"[rnd.IntN(5)])
+		}
+		return sb.String()
+	}
+
+	tr := New[int]()
+	m := make(map[string]int)
+	for i := range 4000 {
+		k := randKey()
+		if rnd.IntN(3) == 0 {
+			_, inMap := m[k]
+			if tr.Delete(k) != inMap {
+				t.Fatalf("Delete(%q) mismatch", k)
+			}
+			delete(m, k)
+		} else {
+			tr.Insert(k, i)
+			m[k] = i
+		}
+	}
+	checkAll(t, tr, m)
+}
+
+// levenshtein computes the edit distance between a and b.
+func levenshtein(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+func TestWithinDistance(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	randKey := func() string {
+		b := make([]byte, rnd.IntN(7))
+		for i := range b {
+			b[i] = "abc"[rnd.IntN(3)]
+		}
+		return string(b)
+	}
+
+	tr := New[int]()
+	var keys []string
+	for i := range 300 {
+		k := randKey()
+		tr.Insert(k, i)
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	for range 100 {
+		query := randKey()
+		d := rnd.IntN(4) - 1
+		var want []string
+		for _, k := range keys {
+			if levenshtein(k, query) <= d {
+				want = append(want, k)
+			}
+		}
+		var got []string
+		for k, v := range tr.WithinDistance(query, d) {
+			if tv, _ := tr.Get(k); tv != v {
+				t.Fatalf("got value %d for %q, want %d", v, k, tv)
+			}
+			got = append(got, k)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("WithinDistance(%q, %d)=%q, want %q", query, d, got, want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package ttlcache implements a cache whose entries expire after a time to
+// live (TTL).
+package ttlcache
+
+import (
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/eliben/gogl/cache"
+	"github.com/eliben/gogl/heap"
+)
+
+// Cache is a cache whose entries expire a fixed duration after they're put,
+// unless they're put with a different TTL. Expired entries are removed
+// lazily, when they're accessed; a background janitor started with
+// [Cache.StartJanitor] removes them as they expire, so that memory is
+// reclaimed and expiry callbacks run promptly even for entries that are
+// never accessed again.
+//
+// Expiration times are kept in a heap, so removing an expired entry takes
+// O(log n) time. A Cache is safe for concurrent use by multiple goroutines.
+// Create caches with [New] or [NewWithExpire].
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	entries  map[K]*item[K, V]
+	expiries *heap.Indexed[expiry[K]]
+	onExpire func(K, V)
+	rec      cache.Recorder
+
+	// now returns the current time; it's replaced in tests.
+	now func() time.Time
+
+	// wake is signaled when the janitor may need to run earlier than it
+	// planned; done is closed to stop it.
+	wake chan struct{}
+	done chan struct{}
+}
+
+type item[K comparable, V any] struct {
+	value V
+
+	// handle is the item's entry in the expiry heap, or nil if the item
+	// never expires.
+	handle *heap.Handle[expiry[K]]
+}
+
+type expiry[K comparable] struct {
+	key K
+	at  time.Time
+}
+
+// New creates a new, empty cache in which entries expire ttl after they're
+// put. A ttl <= 0 means entries don't expire unless put with an explicit
+// TTL.
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return NewWithExpire[K, V](ttl, nil)
+}
+
+// NewWithExpire is like New, but onExpire is called with the key and value
+// of every entry removed because it expired. onExpire is called without
+// holding the cache's lock, so it may use the cache. onExpire may be nil.
+func NewWithExpire[K comparable, V any](ttl time.Duration, onExpire func(K, V)) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:     ttl,
+		entries: make(map[K]*item[K, V]),
+		expiries: heap.NewIndexed(func(a, b expiry[K]) int {
+			return a.at.Compare(b.at)
+		}),
+		onExpire: onExpire,
+		now:      time.Now,
+	}
+}
+
+// Len returns the number of unexpired entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	expired := c.lockAndExpire()
+	n := len(c.entries)
+	c.mu.Unlock()
+	c.notify(expired)
+	return n
+}
+
+// Get looks up key in the cache. It returns the associated value and
+// ok=true; if key isn't in the cache or has expired, it returns ok=false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	expired := c.lockAndExpire()
+	it, ok := c.entries[key]
+	c.rec.Lookup(ok)
+	if ok {
+		v = it.value
+	}
+	c.mu.Unlock()
+	c.notify(expired)
+	return v, ok
+}
+
+// Contains reports whether key is in the cache and hasn't expired. Unlike
+// Get, it isn't counted as a lookup in the cache's statistics.
+func (c *Cache[K, V]) Contains(key K) bool {
+	expired := c.lockAndExpire()
+	_, ok := c.entries[key]
+	c.mu.Unlock()
+	c.notify(expired)
+	return ok
+}
+
+// TTL returns the time left until key expires and ok=true, or ok=false if
+// key isn't in the cache. For entries that never expire, TTL returns a
+// negative duration.
+func (c *Cache[K, V]) TTL(key K) (ttl time.Duration, ok bool) {
+	expired := c.lockAndExpire()
+	defer c.notify(expired)
+	defer c.mu.Unlock()
+	it, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	if it.handle == nil {
+		return -1, true
+	}
+	return it.handle.Value().at.Sub(c.now()), true
+}
+
+// Put sets the value of key in the cache, with the cache's default TTL.
+func (c *Cache[K, V]) Put(key K, value V) {
+	c.PutWithTTL(key, value, c.ttl)
+}
+
+// PutWithTTL sets the value of key in the cache; the entry expires ttl from
+// now, or never if ttl <= 0.
+func (c *Cache[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	expired := c.lockAndExpire()
+	it, ok := c.entries[key]
+	if !ok {
+		it = &item[K, V]{}
+		c.entries[key] = it
+	}
+	it.value = value
+
+	if ttl <= 0 {
+		if it.handle != nil {
+			c.expiries.Remove(it.handle)
+			it.handle = nil
+		}
+	} else {
+		e := expiry[K]{key: key, at: c.now().Add(ttl)}
+		if it.handle != nil {
+			c.expiries.Update(it.handle, e)
+		} else {
+			it.handle = c.expiries.Push(e)
+		}
+		if c.expiries.Peek().key == key {
+			c.wakeJanitor()
+		}
+	}
+	c.mu.Unlock()
+	c.notify(expired)
+}
+
+// Remove removes key from the cache. It returns true if key was found, and
+// false otherwise. The expiry callback isn't called for removed entries.
+func (c *Cache[K, V]) Remove(key K) bool {
+	expired := c.lockAndExpire()
+	it, ok := c.entries[key]
+	if ok {
+		c.removeItem(key, it)
+	}
+	c.mu.Unlock()
+	c.notify(expired)
+	return ok
+}
+
+// DeleteExpired removes all the expired entries from the cache, and returns
+// the number of entries removed.
+func (c *Cache[K, V]) DeleteExpired() int {
+	expired := c.lockAndExpire()
+	c.mu.Unlock()
+	c.notify(expired)
+	return len(expired)
+}
+
+// Stats returns statistics about the cache's activity since it was created.
+func (c *Cache[K, V]) Stats() cache.Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rec.Stats()
+}
+
+// SetHooks sets hooks to be called on cache events; nil removes them. Hooks
+// are called with the cache's lock held.
+func (c *Cache[K, V]) SetHooks(h cache.Hooks) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rec.SetHooks(h)
+}
+
+// All returns an iterator over all the unexpired entries in the cache, in
+// unspecified order. The iterator works on a snapshot of the cache taken
+// when iteration starts.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		expired := c.lockAndExpire()
+		snapshot := make([]kv[K, V], 0, len(c.entries))
+		for k, it := range c.entries {
+			snapshot = append(snapshot, kv[K, V]{k, it.value})
+		}
+		c.mu.Unlock()
+		c.notify(expired)
+
+		for _, e := range snapshot {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// StartJanitor starts a background goroutine that removes entries as they
+// expire. The janitor sleeps until the earliest expiration time in the
+// cache, so it costs nothing while no entries are due. Stop it with
+// [Cache.Close]. StartJanitor panics if the janitor is already running.
+func (c *Cache[K, V]) StartJanitor() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done != nil {
+		panic("ttlcache: janitor already running")
+	}
+	c.wake = make(chan struct{}, 1)
+	c.done = make(chan struct{})
+	go c.janitor(c.wake, c.done)
+}
+
+// Close stops the janitor, if it's running. The cache remains usable, with
+// lazy expiration only.
+func (c *Cache[K, V]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done != nil {
+		close(c.done)
+		c.done, c.wake = nil, nil
+	}
+}
+
+func (c *Cache[K, V]) janitor(wake, done chan struct{}) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-wake:
+		case <-timer.C:
+		}
+
+		expired := c.lockAndExpire()
+		// With no entries due, sleep until woken by a Put.
+		wait := time.Duration(1<<63 - 1)
+		if c.expiries.Len() > 0 {
+			wait = c.expiries.Peek().at.Sub(c.now())
+		}
+		c.mu.Unlock()
+		c.notify(expired)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// wakeJanitor signals the janitor, if it's running, to recompute its
+// wakeup time. It's called with c.mu held.
+func (c *Cache[K, V]) wakeJanitor() {
+	if c.wake == nil {
+		return
+	}
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// kv is a key, value pair.
+type kv[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// lockAndExpire locks c.mu and removes all the expired entries, returning
+// them. The caller must unlock c.mu and then pass the entries to notify.
+func (c *Cache[K, V]) lockAndExpire() []kv[K, V] {
+	c.mu.Lock()
+	var result []kv[K, V]
+	now := c.now()
+	for c.expiries.Len() > 0 && !c.expiries.Peek().at.After(now) {
+		key := c.expiries.Peek().key
+		it := c.entries[key]
+		result = append(result, kv[K, V]{key, it.value})
+		c.removeItem(key, it)
+		c.rec.Expire()
+	}
+	return result
+}
+
+// notify calls the expiry callback for the given entries; it must be
+// called without holding c.mu.
+func (c *Cache[K, V]) notify(entries []kv[K, V]) {
+	if c.onExpire == nil {
+		return
+	}
+	for _, e := range entries {
+		c.onExpire(e.key, e.value)
+	}
+}
+
+func (c *Cache[K, V]) removeItem(key K, it *item[K, V]) {
+	if it.handle != nil {
+		c.expiries.Remove(it.handle)
+	}
+	delete(c.entries, key)
+}
@@ -0,0 +1,250 @@
+package ttlcache
+
+import (
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eliben/gogl/cache"
+)
+
+// fakeClock is a manually advanced clock for tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func (fc *fakeClock) now() time.Time {
+	return fc.t
+}
+
+func (fc *fakeClock) advance(d time.Duration) {
+	fc.t = fc.t.Add(d)
+}
+
+func newWithClock[K comparable, V any](ttl time.Duration, onExpire func(K, V)) (*Cache[K, V], *fakeClock) {
+	fc := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := NewWithExpire(ttl, onExpire)
+	c.now = fc.now
+	return c, fc
+}
+
+func TestLazyExpiry(t *testing.T) {
+	var expired []string
+	c, clock := newWithClock(time.Minute, func(k string, v int) { expired = append(expired, k) })
+	c.Put("session", 1)
+	c.PutWithTTL("token", 2, 10*time.Second)
+	c.PutWithTTL("forever", 3, 0)
+
+	if ttl, ok := c.TTL("token"); !ok || ttl != 10*time.Second {
+		t.Errorf("TTL(token)=%v,%v", ttl, ok)
+	}
+	if ttl, ok := c.TTL("forever"); !ok || ttl >= 0 {
+		t.Errorf("TTL(forever)=%v,%v", ttl, ok)
+	}
+
+	clock.advance(10 * time.Second)
+	if _, ok := c.Get("token"); ok {
+		t.Errorf("got expired token")
+	}
+	if v, ok := c.Get("session"); !ok || v != 1 {
+		t.Errorf("Get(session)=%d,%v", v, ok)
+	}
+	if !slices.Equal(expired, []string{"token"}) {
+		t.Errorf("expired %v", expired)
+	}
+
+	// Putting again refreshes the TTL.
+	clock.advance(40 * time.Second)
+	c.Put("session", 10)
+	clock.advance(40 * time.Second)
+	if v, ok := c.Get("session"); !ok || v != 10 {
+		t.Errorf("Get(session)=%d,%v", v, ok)
+	}
+	clock.advance(20 * time.Second)
+	if c.Contains("session") || c.Len() != 1 || !c.Contains("forever") {
+		t.Errorf("unexpected contents, Len=%d", c.Len())
+	}
+
+	// An entry put with a TTL can be made permanent, and vice versa.
+	c.Put("x", 1)
+	c.PutWithTTL("x", 2, 0)
+	c.PutWithTTL("forever", 3, time.Second)
+	clock.advance(time.Hour)
+	if got := slices.Sorted(maps.Keys(maps.Collect(c.All()))); !slices.Equal(got, []string{"x"}) {
+		t.Errorf("got keys %v", got)
+	}
+
+	c.Put("y", 1)
+	if !c.Remove("y") || c.Remove("y") {
+		t.Errorf("bad Remove")
+	}
+	clock.advance(time.Hour)
+	if !slices.Equal(expired, []string{"token", "session", "forever"}) {
+		t.Errorf("expired %v", expired)
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	c, clock := newWithClock[int, int](time.Second, nil)
+	for k := range 10 {
+		c.PutWithTTL(k, k, time.Duration(k+1)*time.Second)
+	}
+	clock.advance(5 * time.Second)
+	if n := c.DeleteExpired(); n != 5 {
+		t.Errorf("DeleteExpired=%d", n)
+	}
+	if _, ok := c.TTL(2); ok {
+		t.Errorf("TTL of expired key")
+	}
+	if ttl, _ := c.TTL(7); ttl != 3*time.Second {
+		t.Errorf("TTL(7)=%v", ttl)
+	}
+}
+
+func TestCallbackUsesCache(t *testing.T) {
+	// The callback runs without the lock held, so it can use the cache.
+	var c *Cache[string, int]
+	c, clock := newWithClock(time.Second, func(k string, v int) {
+		c.PutWithTTL(k+"-tombstone", v, 0)
+	})
+	c.Put("a", 1)
+	clock.advance(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("got expired entry")
+	}
+	if v, ok := c.Get("a-tombstone"); !ok || v != 1 {
+		t.Errorf("Get(a-tombstone)=%d,%v", v, ok)
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	expired := make(chan string, 10)
+	c := NewWithExpire(time.Hour, func(k string, v int) { expired <- k })
+	c.StartJanitor()
+	defer c.Close()
+
+	c.Put("long", 1)
+	c.PutWithTTL("short", 2, 20*time.Millisecond)
+	select {
+	case k := <-expired:
+		if k != "short" {
+			t.Errorf("expired %q", k)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("janitor didn't expire entry")
+	}
+
+	c.Close()
+	c.Close()
+	c.StartJanitor()
+	c.PutWithTTL("shorter", 3, time.Millisecond)
+	select {
+	case k := <-expired:
+		if k != "shorter" {
+			t.Errorf("expired %q", k)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("restarted janitor didn't expire entry")
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	c := New[int, int](time.Millisecond)
+	c.StartJanitor()
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 2000 {
+				k := (g*7 + i) % 50
+				switch i % 3 {
+				case 0:
+					c.Put(k, i)
+				case 1:
+					c.Get(k)
+				default:
+					c.Remove(k)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	var expired []int
+	c, clock := newWithClock(10*time.Second, func(k, v int) { expired = append(expired, k) })
+
+	// The mirror maps keys to values and expiration times; a zero time
+	// means no expiration.
+	type state struct {
+		v  int
+		at time.Time
+	}
+	mirror := make(map[int]state)
+	for range 5000 {
+		clock.advance(time.Duration(rnd.IntN(1000)) * time.Millisecond)
+		for k, s := range mirror {
+			if !s.at.IsZero() && !s.at.After(clock.t) {
+				delete(mirror, k)
+			}
+		}
+
+		k := rnd.IntN(40)
+		switch rnd.IntN(4) {
+		case 0:
+			v, ok := c.Get(k)
+			s, want := mirror[k]
+			if ok != want || (ok && v != s.v) {
+				t.Fatalf("Get(%d)=%d,%v", k, v, ok)
+			}
+		case 1:
+			_, want := mirror[k]
+			if c.Remove(k) != want {
+				t.Fatalf("Remove(%d) mismatch", k)
+			}
+			delete(mirror, k)
+		default:
+			v := rnd.Int()
+			ttl := time.Duration(rnd.IntN(20)-2) * time.Second
+			c.PutWithTTL(k, v, ttl)
+			s := state{v: v}
+			if ttl > 0 {
+				s.at = clock.t.Add(ttl)
+			}
+			mirror[k] = s
+		}
+		if c.Len() != len(mirror) {
+			t.Fatalf("got Len=%d, want %d", c.Len(), len(mirror))
+		}
+	}
+	if len(expired) == 0 {
+		t.Errorf("no entries expired")
+	}
+}
+
+func TestStats(t *testing.T) {
+	c, clock := newWithClock[string, int](time.Second, nil)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Get("a")
+	c.Get("x")
+	c.Contains("b")
+	clock.advance(time.Second)
+	c.Get("a")
+	want := cache.Stats{Hits: 1, Misses: 2, Expirations: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,353 @@
+// Package unionfind implements a disjoint-set forest (union-find) with union
+// by rank and path compression.
+package unionfind
+
+import (
+	"fmt"
+	"iter"
+)
+
+// UnionFind is a disjoint-set forest over the integers 0..n-1. Find, Union
+// and Connected run in near-constant amortized time.
+//
+// A UnionFind created with [NewWithRollback] additionally supports undoing
+// unions with Checkpoint and Rollback (useful for backtracking searches);
+// since rollback is incompatible with path compression, its operations take
+// O(log n) time.
+type UnionFind struct {
+	// parent[i] is the parent of i in its tree; roots are their own parents.
+	// rank[i] is an upper bound on the height of the tree rooted at i, and
+	// size[i] is the number of elements in it (both only meaningful for
+	// roots).
+	parent []int
+	rank   []int
+	size   []int
+
+	// next links the elements of each set into a circular list, allowing
+	// iteration over the members of a set. Union splices two such lists.
+	next []int
+
+	count int
+
+	// rollback is true if this UnionFind supports rollback; in this case
+	// undo records each union that merged two sets.
+	rollback bool
+	undo     []union
+}
+
+// union records a union for rollback: child is the root that was attached
+// under another root, and rankIncreased is true if this increased the rank
+// of the new root.
+type union struct {
+	child         int
+	rankIncreased bool
+}
+
+// Checkpoint identifies a point in the history of a UnionFind, to which it
+// can be rolled back.
+type Checkpoint int
+
+// New creates a new UnionFind with n elements (0..n-1), each in its own set.
+func New(n int) *UnionFind {
+	uf := &UnionFind{}
+	for range n {
+		uf.Add()
+	}
+	return uf
+}
+
+// NewWithRollback creates a new UnionFind with n elements like [New], which
+// supports Checkpoint and Rollback.
+func NewWithRollback(n int) *UnionFind {
+	uf := &UnionFind{rollback: true}
+	for range n {
+		uf.Add()
+	}
+	return uf
+}
+
+// Len returns the number of elements.
+func (uf *UnionFind) Len() int {
+	return len(uf.parent)
+}
+
+// Count returns the number of disjoint sets.
+func (uf *UnionFind) Count() int {
+	return uf.count
+}
+
+// Add adds a new element in its own set and returns it. The new element is
+// Len() before the call.
+func (uf *UnionFind) Add() int {
+	x := len(uf.parent)
+	uf.parent = append(uf.parent, x)
+	uf.rank = append(uf.rank, 0)
+	uf.size = append(uf.size, 1)
+	uf.next = append(uf.next, x)
+	uf.count++
+	return x
+}
+
+// Find returns the representative element of the set containing x. It panics
+// if x is out of range.
+func (uf *UnionFind) Find(x int) int {
+	uf.checkElem(x)
+	root := x
+	for uf.parent[root] != root {
+		root = uf.parent[root]
+	}
+	if uf.rollback {
+		return root
+	}
+	// Path compression: point every node on the path directly at root.
+	for uf.parent[x] != root {
+		uf.parent[x], x = root, uf.parent[x]
+	}
+	return root
+}
+
+// Union merges the sets containing x and y. It returns true if they were
+// separate sets, and false if x and y were already in the same set.
+func (uf *UnionFind) Union(x, y int) bool {
+	rx, ry := uf.Find(x), uf.Find(y)
+	if rx == ry {
+		return false
+	}
+	// Union by rank: attach the shallower tree under the deeper one.
+	if uf.rank[rx] < uf.rank[ry] {
+		rx, ry = ry, rx
+	}
+	uf.parent[ry] = rx
+	rankIncreased := uf.rank[rx] == uf.rank[ry]
+	if rankIncreased {
+		uf.rank[rx]++
+	}
+	uf.size[rx] += uf.size[ry]
+	uf.next[rx], uf.next[ry] = uf.next[ry], uf.next[rx]
+	uf.count--
+	if uf.rollback {
+		uf.undo = append(uf.undo, union{ry, rankIncreased})
+	}
+	return true
+}
+
+// Connected reports whether x and y are in the same set.
+func (uf *UnionFind) Connected(x, y int) bool {
+	return uf.Find(x) == uf.Find(y)
+}
+
+// Size returns the number of elements in the set containing x.
+func (uf *UnionFind) Size(x int) int {
+	return uf.size[uf.Find(x)]
+}
+
+// Members returns an iterator over the elements of the set containing x.
+func (uf *UnionFind) Members(x int) iter.Seq[int] {
+	uf.checkElem(x)
+	return func(yield func(int) bool) {
+		i := x
+		for {
+			if !yield(i) {
+				return
+			}
+			i = uf.next[i]
+			if i == x {
+				return
+			}
+		}
+	}
+}
+
+// Sets returns an iterator over all the disjoint sets; each set is yielded as
+// a slice of its elements.
+func (uf *UnionFind) Sets() iter.Seq[[]int] {
+	return func(yield func([]int) bool) {
+		for x := range uf.parent {
+			if uf.Find(x) != x {
+				continue
+			}
+			var set []int
+			for m := range uf.Members(x) {
+				set = append(set, m)
+			}
+			if !yield(set) {
+				return
+			}
+		}
+	}
+}
+
+// Checkpoint returns a checkpoint for the current state, which can later be
+// passed to Rollback. It panics if uf doesn't support rollback.
+func (uf *UnionFind) Checkpoint() Checkpoint {
+	uf.checkRollback()
+	return Checkpoint(len(uf.undo))
+}
+
+// Rollback undoes all the unions that merged sets since the checkpoint cp
+// was taken. Elements added since cp remain, in sets of their own.
+// Checkpoints taken after cp become invalid. It panics if uf doesn't support
+// rollback.
+func (uf *UnionFind) Rollback(cp Checkpoint) {
+	uf.checkRollback()
+	for len(uf.undo) > int(cp) {
+		u := uf.undo[len(uf.undo)-1]
+		uf.undo = uf.undo[:len(uf.undo)-1]
+		ry := u.child
+		rx := uf.parent[ry]
+		uf.parent[ry] = ry
+		if u.rankIncreased {
+			uf.rank[rx]--
+		}
+		uf.size[rx] -= uf.size[ry]
+		// Swapping the successors again splits the circular lists.
+		uf.next[rx], uf.next[ry] = uf.next[ry], uf.next[rx]
+		uf.count++
+	}
+}
+
+func (uf *UnionFind) checkRollback() {
+	if !uf.rollback {
+		panic("UnionFind created without rollback support")
+	}
+}
+
+func (uf *UnionFind) checkElem(x int) {
+	if x < 0 || x >= len(uf.parent) {
+		panic(fmt.Sprintf("element %d out of range [0:%d]", x, len(uf.parent)))
+	}
+}
+
+// Keyed is a disjoint-set forest over arbitrary comparable keys. Keys are
+// added implicitly when first passed to Add or Union.
+type Keyed[T comparable] struct {
+	uf   *UnionFind
+	ids  map[T]int
+	keys []T
+}
+
+// NewKeyed creates a new, empty Keyed union-find.
+func NewKeyed[T comparable]() *Keyed[T] {
+	return &Keyed[T]{uf: New(0), ids: make(map[T]int)}
+}
+
+// NewKeyedWithRollback creates a new, empty Keyed union-find that supports
+// Checkpoint and Rollback, like a UnionFind created with [NewWithRollback].
+func NewKeyedWithRollback[T comparable]() *Keyed[T] {
+	return &Keyed[T]{uf: NewWithRollback(0), ids: make(map[T]int)}
+}
+
+// Len returns the number of keys.
+func (k *Keyed[T]) Len() int {
+	return len(k.keys)
+}
+
+// Count returns the number of disjoint sets.
+func (k *Keyed[T]) Count() int {
+	return k.uf.Count()
+}
+
+// Add adds key x in its own set. If x is already known, this is a no-op.
+func (k *Keyed[T]) Add(x T) {
+	k.id(x)
+}
+
+// Contains reports whether x is a known key.
+func (k *Keyed[T]) Contains(x T) bool {
+	_, ok := k.ids[x]
+	return ok
+}
+
+// Find returns the representative key of the set containing x, and ok=true
+// if x is known; otherwise it returns ok=false.
+func (k *Keyed[T]) Find(x T) (rep T, ok bool) {
+	id, ok := k.ids[x]
+	if !ok {
+		return rep, false
+	}
+	return k.keys[k.uf.Find(id)], true
+}
+
+// Union merges the sets containing x and y, adding them as keys if they're
+// not known. It returns true if they were separate sets.
+func (k *Keyed[T]) Union(x, y T) bool {
+	return k.uf.Union(k.id(x), k.id(y))
+}
+
+// Connected reports whether x and y are in the same set. Unknown keys are
+// only connected to themselves.
+func (k *Keyed[T]) Connected(x, y T) bool {
+	ix, okx := k.ids[x]
+	iy, oky := k.ids[y]
+	if !okx || !oky {
+		return x == y
+	}
+	return k.uf.Connected(ix, iy)
+}
+
+// Size returns the number of keys in the set containing x, or 0 if x is not
+// known.
+func (k *Keyed[T]) Size(x T) int {
+	id, ok := k.ids[x]
+	if !ok {
+		return 0
+	}
+	return k.uf.Size(id)
+}
+
+// Members returns an iterator over the keys in the set containing x. If x is
+// not known, the iterator is empty.
+func (k *Keyed[T]) Members(x T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		id, ok := k.ids[x]
+		if !ok {
+			return
+		}
+		for m := range k.uf.Members(id) {
+			if !yield(k.keys[m]) {
+				return
+			}
+		}
+	}
+}
+
+// Sets returns an iterator over all the disjoint sets; each set is yielded as
+// a slice of its keys.
+func (k *Keyed[T]) Sets() iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for set := range k.uf.Sets() {
+			keys := make([]T, len(set))
+			for i, id := range set {
+				keys[i] = k.keys[id]
+			}
+			if !yield(keys) {
+				return
+			}
+		}
+	}
+}
+
+// Checkpoint returns a checkpoint for the current state, which can later be
+// passed to Rollback. It panics if k doesn't support rollback.
+func (k *Keyed[T]) Checkpoint() Checkpoint {
+	return k.uf.Checkpoint()
+}
+
+// Rollback undoes all the unions that merged sets since the checkpoint cp
+// was taken. Keys added since cp remain known, in sets of their own.
+// Checkpoints taken after cp become invalid. It panics if k doesn't support
+// rollback.
+func (k *Keyed[T]) Rollback(cp Checkpoint) {
+	k.uf.Rollback(cp)
+}
+
+// id returns the element id of x in k.uf, adding it if needed.
+func (k *Keyed[T]) id(x T) int {
+	if id, ok := k.ids[x]; ok {
+		return id
+	}
+	id := k.uf.Add()
+	k.ids[x] = id
+	k.keys = append(k.keys, x)
+	return id
+}
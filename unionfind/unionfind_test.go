@@ -0,0 +1,221 @@
+package unionfind
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func sortedSets[T cmp.Ordered](sets func(func([]T) bool)) [][]T {
+	var result [][]T
+	for s := range sets {
+		slices.Sort(s)
+		result = append(result, s)
+	}
+	slices.SortFunc(result, func(a, b []T) int { return cmp.Compare(a[0], b[0]) })
+	return result
+}
+
+func TestBasic(t *testing.T) {
+	uf := New(6)
+	if uf.Count() != 6 || uf.Len() != 6 {
+		t.Errorf("got count=%d, len=%d", uf.Count(), uf.Len())
+	}
+	if !uf.Union(0, 1) || !uf.Union(2, 3) || !uf.Union(1, 3) || uf.Union(0, 2) {
+		t.Errorf("bad Union results")
+	}
+	if !uf.Connected(0, 3) || uf.Connected(0, 4) {
+		t.Errorf("bad Connected results")
+	}
+	if uf.Count() != 3 || uf.Size(2) != 4 || uf.Size(5) != 1 {
+		t.Errorf("got count=%d, size=%d", uf.Count(), uf.Size(2))
+	}
+
+	members := slices.Sorted(uf.Members(3))
+	if !slices.Equal(members, []int{0, 1, 2, 3}) {
+		t.Errorf("got members %v", members)
+	}
+
+	got := sortedSets(uf.Sets())
+	want := [][]int{{0, 1, 2, 3}, {4}, {5}}
+	if len(got) != len(want) {
+		t.Fatalf("got sets %v, want %v", got, want)
+	}
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Errorf("got sets %v, want %v", got, want)
+		}
+	}
+
+	x := uf.Add()
+	if x != 6 || uf.Count() != 4 {
+		t.Errorf("got Add()=%d, count=%d", x, uf.Count())
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	uf := New(3)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	uf.Find(3)
+}
+
+func TestKeyed(t *testing.T) {
+	k := NewKeyed[string]()
+	k.Union("apple", "banana")
+	k.Union("cherry", "date")
+	k.Add("elderberry")
+	k.Union("banana", "date")
+
+	if k.Len() != 5 || k.Count() != 2 {
+		t.Errorf("got len=%d, count=%d", k.Len(), k.Count())
+	}
+	if !k.Connected("apple", "cherry") || k.Connected("apple", "elderberry") {
+		t.Errorf("bad Connected results")
+	}
+	if k.Connected("apple", "fig") || !k.Connected("fig", "fig") {
+		t.Errorf("bad Connected results for unknown keys")
+	}
+	if k.Contains("fig") || !k.Contains("apple") {
+		t.Errorf("bad Contains results")
+	}
+
+	r1, ok1 := k.Find("apple")
+	r2, ok2 := k.Find("date")
+	if !ok1 || !ok2 || r1 != r2 {
+		t.Errorf("got Find=%v,%v / %v,%v", r1, ok1, r2, ok2)
+	}
+	if _, ok := k.Find("fig"); ok {
+		t.Errorf("found unknown key")
+	}
+
+	if k.Size("banana") != 4 || k.Size("fig") != 0 {
+		t.Errorf("bad sizes")
+	}
+	members := slices.Sorted(k.Members("cherry"))
+	if !slices.Equal(members, []string{"apple", "banana", "cherry", "date"}) {
+		t.Errorf("got members %v", members)
+	}
+	if n := len(slices.Collect(k.Members("fig"))); n != 0 {
+		t.Errorf("got %d members for unknown key", n)
+	}
+	if sets := sortedSets(k.Sets()); len(sets) != 2 || sets[1][0] != "elderberry" {
+		t.Errorf("got sets %v", sets)
+	}
+}
+
+func TestRandomAgainstNaive(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	const n = 300
+	uf := New(n)
+	label := make([]int, n)
+	for i := range label {
+		label[i] = i
+	}
+	for range 250 {
+		x, y := rnd.IntN(n), rnd.IntN(n)
+		if uf.Union(x, y) != (label[x] != label[y]) {
+			t.Fatalf("Union(%d, %d) mismatch", x, y)
+		}
+		old := label[y]
+		for i := range label {
+			if label[i] == old {
+				label[i] = label[x]
+			}
+		}
+
+		a := rnd.IntN(n)
+		var want []int
+		for i := range label {
+			if label[i] == label[a] {
+				want = append(want, i)
+			}
+		}
+		if got := slices.Sorted(uf.Members(a)); !slices.Equal(got, want) {
+			t.Fatalf("Members(%d)=%v, want %v", a, got, want)
+		}
+		if uf.Size(a) != len(want) {
+			t.Fatalf("Size(%d)=%d, want %d", a, uf.Size(a), len(want))
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	// Keep a stack of checkpoints along with copies of the naive labeling,
+	// and roll back to random ones.
+	const n = 100
+	uf := NewWithRollback(n)
+	label := make([]int, n)
+	for i := range label {
+		label[i] = i
+	}
+	type saved struct {
+		cp    Checkpoint
+		label []int
+	}
+	var stack []saved
+	for range 2000 {
+		switch r := rnd.IntN(10); {
+		case r == 0:
+			stack = append(stack, saved{uf.Checkpoint(), slices.Clone(label)})
+		case r == 1 && len(stack) > 0:
+			i := rnd.IntN(len(stack))
+			uf.Rollback(stack[i].cp)
+			label = stack[i].label
+			stack = stack[:i]
+		default:
+			x, y := rnd.IntN(n), rnd.IntN(n)
+			if uf.Union(x, y) != (label[x] != label[y]) {
+				t.Fatalf("Union(%d, %d) mismatch", x, y)
+			}
+			old := label[y]
+			for i := range label {
+				if label[i] == old {
+					label[i] = label[x]
+				}
+			}
+		}
+
+		a := rnd.IntN(n)
+		var want []int
+		for i := range label {
+			if label[i] == label[a] {
+				want = append(want, i)
+			}
+		}
+		if got := slices.Sorted(uf.Members(a)); !slices.Equal(got, want) {
+			t.Fatalf("Members(%d)=%v, want %v", a, got, want)
+		}
+		if uf.Size(a) != len(want) {
+			t.Fatalf("Size(%d)=%d, want %d", a, uf.Size(a), len(want))
+		}
+	}
+
+	k := NewKeyedWithRollback[string]()
+	k.Union("a", "b")
+	cp := k.Checkpoint()
+	k.Union("b", "c")
+	k.Rollback(cp)
+	if !k.Connected("a", "b") || k.Connected("a", "c") || k.Count() != 2 || k.Len() != 3 {
+		t.Errorf("bad state after Rollback")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	New(1).Checkpoint()
+}
@@ -0,0 +1,170 @@
+package unrolledlist
+
+import "fmt"
+
+// Cursor is a position in a List: either at one of its elements, or at the
+// end of the list, past its last element. Create cursors with
+// [List.Cursor].
+//
+// Moving a cursor, and reading, setting, inserting or removing elements
+// at it take O(B) time at most for block size B. Modifying the list other
+// than through the cursor invalidates it, as does modifying it through
+// another cursor.
+type Cursor[T any] struct {
+	lst *List[T]
+
+	// b and off are the block and offset of the element at the cursor; at
+	// the end of the list, b is the back sentinel and off is 0.
+	b   *block[T]
+	off int
+
+	// index is the index of the element at the cursor.
+	index int
+}
+
+// Cursor returns a cursor at index i, taking O(n/B) time. i may be equal to
+// Len(), in which case the cursor is at the end of the list. It panics if i
+// is out of range.
+func (lst *List[T]) Cursor(i int) *Cursor[T] {
+	lst.checkIndex(i, lst.length+1)
+	c := &Cursor[T]{lst: lst, b: lst.back, index: i}
+	if i < lst.length {
+		c.b, c.off = lst.locate(i)
+	}
+	return c
+}
+
+// Index returns the index of the cursor's position in the list.
+func (c *Cursor[T]) Index() int {
+	return c.index
+}
+
+// AtEnd reports whether the cursor is at the end of the list.
+func (c *Cursor[T]) AtEnd() bool {
+	return c.b == c.lst.back
+}
+
+// Value returns the element at the cursor. It panics if the cursor is at
+// the end of the list.
+func (c *Cursor[T]) Value() T {
+	c.checkElem()
+	return c.b.items[c.off]
+}
+
+// Set sets the element at the cursor to v. It panics if the cursor is at
+// the end of the list.
+func (c *Cursor[T]) Set(v T) {
+	c.checkElem()
+	c.b.items[c.off] = v
+}
+
+// Next moves the cursor to the next position and returns true; if the
+// cursor is at the end of the list, it returns false.
+func (c *Cursor[T]) Next() bool {
+	if c.AtEnd() {
+		return false
+	}
+	c.off++
+	if c.off == len(c.b.items) {
+		c.b, c.off = c.b.next, 0
+	}
+	c.index++
+	return true
+}
+
+// Prev moves the cursor to the previous position and returns true; if the
+// cursor is at the front of the list, it returns false.
+func (c *Cursor[T]) Prev() bool {
+	if c.index == 0 {
+		return false
+	}
+	if c.off == 0 {
+		c.b = c.b.prev
+		c.off = len(c.b.items)
+	}
+	c.off--
+	c.index--
+	return true
+}
+
+// Insert inserts v before the cursor; the cursor stays at the same element
+// (or at the end of the list).
+func (c *Cursor[T]) Insert(v T) {
+	c.index++
+	if c.AtEnd() {
+		c.lst.insertAt(c.b, 0, v)
+		return
+	}
+	c.b, c.off = c.lst.insertAt(c.b, c.off, v)
+	c.off++
+	if c.off == len(c.b.items) {
+		c.b, c.off = c.b.next, 0
+	}
+}
+
+// Remove removes the element at the cursor and returns it; the cursor
+// moves to the element that followed it. It panics if the cursor is at the
+// end of the list.
+func (c *Cursor[T]) Remove() T {
+	c.checkElem()
+	var v T
+	v, c.b, c.off = c.lst.removeAt(c.b, c.off)
+	return v
+}
+
+// Splice moves all the elements of other into the list before the cursor,
+// leaving other empty; the cursor stays at the same element (or at the end
+// of the list). It takes O(B) time regardless of the lengths of the lists.
+// It panics if other is the cursor's list, or has a different block size.
+func (c *Cursor[T]) Splice(other *List[T]) {
+	if other == c.lst {
+		panic("splicing a list into itself")
+	}
+	if other.blockSize != c.lst.blockSize {
+		panic(fmt.Sprintf("splicing a list with block size %d into one with block size %d", other.blockSize, c.lst.blockSize))
+	}
+	if other.length == 0 {
+		return
+	}
+	if c.off > 0 {
+		c.b, c.off = c.lst.splitBlock(c.b, c.off), 0
+	}
+	first, last := other.front.next, other.back.prev
+	first.prev, c.b.prev.next = c.b.prev, first
+	last.next, c.b.prev = c.b, last
+	c.lst.length += other.length
+	c.index += other.length
+
+	other.front.next, other.back.prev = other.back, other.front
+	other.length = 0
+}
+
+// Split moves the elements from the cursor to the end of the list into a
+// new list with the same block size, and returns it; the cursor moves to
+// the end of its list. It takes O(B) time regardless of the lengths of the
+// lists.
+func (c *Cursor[T]) Split() *List[T] {
+	lst := c.lst
+	nl := NewWithBlockSize[T](lst.blockSize)
+	if c.AtEnd() {
+		return nl
+	}
+	if c.off > 0 {
+		c.b = lst.splitBlock(c.b, c.off)
+	}
+	first, last := c.b, lst.back.prev
+	first.prev.next, lst.back.prev = lst.back, first.prev
+	first.prev, nl.front.next = nl.front, first
+	last.next, nl.back.prev = nl.back, last
+	nl.length = lst.length - c.index
+	lst.length = c.index
+
+	c.b, c.off = lst.back, 0
+	return nl
+}
+
+func (c *Cursor[T]) checkElem() {
+	if c.AtEnd() {
+		panic("cursor at the end of the list")
+	}
+}
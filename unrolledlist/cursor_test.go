@@ -0,0 +1,148 @@
+package unrolledlist
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	lst := NewWithBlockSize[int](4)
+	for i := range 10 {
+		lst.PushBack(i)
+	}
+	c := lst.Cursor(3)
+	if c.Value() != 3 || c.Index() != 3 || c.AtEnd() {
+		t.Errorf("got Value=%d, Index=%d", c.Value(), c.Index())
+	}
+	for range 3 {
+		c.Insert(-1)
+	}
+	c.Set(30)
+	if c.Remove() != 30 || c.Value() != 4 || c.Index() != 6 {
+		t.Errorf("got Value=%d, Index=%d after Remove", c.Value(), c.Index())
+	}
+	checkList(t, lst, []int{0, 1, 2, -1, -1, -1, 4, 5, 6, 7, 8, 9})
+
+	for c.Next() {
+	}
+	if !c.AtEnd() || c.Index() != 12 {
+		t.Errorf("got Index=%d at end", c.Index())
+	}
+	c.Insert(10)
+	if !c.Prev() || c.Value() != 10 {
+		t.Errorf("bad Prev from end")
+	}
+	for c.Prev() {
+	}
+	if c.Index() != 0 || c.Value() != 0 {
+		t.Errorf("got Index=%d, Value=%d at front", c.Index(), c.Value())
+	}
+	checkList(t, lst, []int{0, 1, 2, -1, -1, -1, 4, 5, 6, 7, 8, 9, 10})
+}
+
+func TestSpliceSplit(t *testing.T) {
+	for _, at := range []int{0, 1, 4, 5, 9, 10} {
+		lst := NewWithBlockSize[int](4)
+		other := NewWithBlockSize[int](4)
+		var want, otherWant []int
+		for i := range 10 {
+			lst.PushBack(i)
+			other.PushBack(100 + i)
+			want = append(want, i)
+			otherWant = append(otherWant, 100+i)
+		}
+
+		c := lst.Cursor(at)
+		c.Splice(other)
+		want = slices.Insert(want, at, otherWant...)
+		checkList(t, lst, want)
+		checkList(t, other, nil)
+		if c.Index() != at+10 || (at < 10 && c.Value() != at) {
+			t.Errorf("at %d: got Index=%d after Splice", at, c.Index())
+		}
+
+		tail := lst.Cursor(at).Split()
+		checkList(t, lst, want[:at])
+		checkList(t, tail, want[at:])
+		tail.PushBack(-1)
+		lst.PushBack(-2)
+		checkList(t, tail, append(slices.Clone(want[at:]), -1))
+		checkList(t, lst, append(slices.Clone(want[:at]), -2))
+	}
+}
+
+func TestCursorPanics(t *testing.T) {
+	lst := NewWithBlockSize[int](4)
+	lst.PushBack(1)
+	for name, f := range map[string]func(){
+		"value":     func() { lst.Cursor(1).Value() },
+		"remove":    func() { lst.Cursor(1).Remove() },
+		"range":     func() { lst.Cursor(2) },
+		"self":      func() { lst.Cursor(0).Splice(lst) },
+		"blocksize": func() { lst.Cursor(0).Splice(NewWithBlockSize[int](8)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestCursorRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	lst := NewWithBlockSize[int](5)
+	var want []int
+	c := lst.Cursor(0)
+	next := 0
+	for range 5000 {
+		i := c.Index()
+		switch r := rnd.IntN(20); {
+		case r < 6:
+			c.Insert(next)
+			want = slices.Insert(want, i, next)
+			next++
+		case r < 9 && i < len(want):
+			if v := c.Remove(); v != want[i] {
+				t.Fatalf("Remove()=%d, want %d", v, want[i])
+			}
+			want = slices.Delete(want, i, i+1)
+		case r < 12:
+			c.Next()
+		case r < 15:
+			c.Prev()
+		case r < 16:
+			other := NewWithBlockSize[int](5)
+			var otherWant []int
+			for range rnd.IntN(12) {
+				other.PushBack(next)
+				otherWant = append(otherWant, next)
+				next++
+			}
+			c.Splice(other)
+			want = slices.Insert(want, i, otherWant...)
+		case r < 17:
+			tail := c.Split()
+			checkList(t, tail, want[i:])
+			want = want[:i]
+		default:
+			c = lst.Cursor(rnd.IntN(len(want) + 1))
+		}
+
+		if c.Index() < len(want) && c.Value() != want[c.Index()] {
+			t.Fatalf("Value()=%d at %d, want %d", c.Value(), c.Index(), want[c.Index()])
+		}
+		if c.AtEnd() != (c.Index() == len(want)) {
+			t.Fatalf("AtEnd()=%v at %d of %d", c.AtEnd(), c.Index(), len(want))
+		}
+		checkList(t, lst, want)
+	}
+}
@@ -0,0 +1,252 @@
+// Package unrolledlist implements an unrolled linked list: a doubly-linked
+// list of fixed-capacity blocks, each holding several elements.
+package unrolledlist
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// List is an unrolled linked list. Compared to a plain linked list, it has
+// much lower per-element memory overhead and better cache behavior, while
+// still supporting cheap insertions and deletions in the middle of the
+// sequence (the cost is O(n/B) to locate a position and O(B) to shift
+// elements within a block, where B is the block size).
+//
+// A [Cursor] keeps track of a position, so that a sequence of edits around
+// it doesn't pay for locating it again; splicing a whole list in at a
+// cursor, or splitting the list at it, takes O(B) time regardless of the
+// lengths of the lists.
+type List[T any] struct {
+	// front and back are sentinel blocks that never hold elements, similarly
+	// to the list package.
+	front, back *block[T]
+	blockSize   int
+	length      int
+}
+
+// block is a node in the list. It holds between 1 and blockSize elements
+// (except sentinels, which hold none).
+type block[T any] struct {
+	items      []T
+	next, prev *block[T]
+}
+
+const defaultBlockSize = 64
+
+// New creates a new, empty list with the default block size.
+func New[T any]() *List[T] {
+	return NewWithBlockSize[T](defaultBlockSize)
+}
+
+// NewWithBlockSize creates a new, empty list with the given block size
+// (maximal number of elements per block). It panics if blockSize < 2.
+func NewWithBlockSize[T any](blockSize int) *List[T] {
+	if blockSize < 2 {
+		panic(fmt.Sprintf("invalid block size %d", blockSize))
+	}
+	lst := &List[T]{
+		front:     &block[T]{},
+		back:      &block[T]{},
+		blockSize: blockSize,
+	}
+	lst.front.next = lst.back
+	lst.back.prev = lst.front
+	return lst
+}
+
+// Len returns the number of elements in the list.
+func (lst *List[T]) Len() int {
+	return lst.length
+}
+
+// At returns the element at index i. It panics if i is out of range.
+func (lst *List[T]) At(i int) T {
+	lst.checkIndex(i, lst.length)
+	b, off := lst.locate(i)
+	return b.items[off]
+}
+
+// Set sets the element at index i to v. It panics if i is out of range.
+func (lst *List[T]) Set(i int, v T) {
+	lst.checkIndex(i, lst.length)
+	b, off := lst.locate(i)
+	b.items[off] = v
+}
+
+// PushBack adds v at the end of the list.
+func (lst *List[T]) PushBack(v T) {
+	lst.Insert(lst.length, v)
+}
+
+// PushFront adds v at the beginning of the list.
+func (lst *List[T]) PushFront(v T) {
+	lst.Insert(0, v)
+}
+
+// Insert inserts v at index i, shifting the elements at i and after it
+// forward. i may be equal to Len(), in which case v is appended. It panics if
+// i is out of range.
+func (lst *List[T]) Insert(i int, v T) {
+	lst.checkIndex(i, lst.length+1)
+	b, off := lst.back, 0
+	if i < lst.length {
+		b, off = lst.locate(i)
+	}
+	lst.insertAt(b, off, v)
+}
+
+// Remove removes the element at index i and returns it. It panics if i is
+// out of range.
+func (lst *List[T]) Remove(i int) T {
+	lst.checkIndex(i, lst.length)
+	v, _, _ := lst.removeAt(lst.locate(i))
+	return v
+}
+
+// PopFront removes the first element of the list and returns it. It panics if
+// the list is empty.
+func (lst *List[T]) PopFront() T {
+	return lst.Remove(0)
+}
+
+// PopBack removes the last element of the list and returns it. It panics if
+// the list is empty.
+func (lst *List[T]) PopBack() T {
+	return lst.Remove(lst.length - 1)
+}
+
+// All returns an iterator over index, value pairs in the list, in order.
+func (lst *List[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for b := lst.front.next; b != lst.back; b = b.next {
+			for _, v := range b.items {
+				if !yield(i, v) {
+					return
+				}
+				i++
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values in the list, in order.
+func (lst *List[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for b := lst.front.next; b != lst.back; b = b.next {
+			for _, v := range b.items {
+				if !yield(v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (lst *List[T]) checkIndex(i, limit int) {
+	if i < 0 || i >= limit {
+		panic(fmt.Sprintf("index %d out of range [0:%d]", i, limit))
+	}
+}
+
+// locate finds the block holding the element at index i (which must be in
+// range), and the offset of the element within that block. It walks from
+// whichever end of the list is closer to i.
+func (lst *List[T]) locate(i int) (*block[T], int) {
+	if i < lst.length/2 {
+		for b := lst.front.next; ; b = b.next {
+			if i < len(b.items) {
+				return b, i
+			}
+			i -= len(b.items)
+		}
+	}
+	i = lst.length - i
+	for b := lst.back.prev; ; b = b.prev {
+		if i <= len(b.items) {
+			return b, len(b.items) - i
+		}
+		i -= len(b.items)
+	}
+}
+
+// insertAt inserts v before the element at offset off of block b, or at the
+// end of the list if b is the back sentinel. It returns the position of v.
+func (lst *List[T]) insertAt(b *block[T], off int, v T) (*block[T], int) {
+	if b == lst.back {
+		// Appending: insert at the end of the last block, if there is one.
+		b = lst.back.prev
+		if b == lst.front {
+			b = lst.insertBlockAfter(lst.front)
+		}
+		off = len(b.items)
+	}
+
+	if len(b.items) == lst.blockSize {
+		// The block is full; split it in half, moving the upper half to a new
+		// block right after it.
+		half := lst.blockSize / 2
+		nb := lst.splitBlock(b, half)
+		if off > half {
+			b = nb
+			off -= half
+		}
+	}
+	b.items = slices.Insert(b.items, off, v)
+	lst.length++
+	return b, off
+}
+
+// removeAt removes the element at offset off of block b and returns it,
+// along with the position of the element that followed it (the back
+// sentinel if there is none).
+func (lst *List[T]) removeAt(b *block[T], off int) (T, *block[T], int) {
+	v := b.items[off]
+	b.items = slices.Delete(b.items, off, off+1)
+	lst.length--
+
+	if len(b.items) == 0 {
+		next := b.next
+		lst.unlinkBlock(b)
+		return v, next, 0
+	}
+	if nb := b.next; nb != lst.back && len(b.items)+len(nb.items) <= lst.blockSize/2 {
+		// Merge sparse neighboring blocks to keep memory density high.
+		b.items = append(b.items, nb.items...)
+		lst.unlinkBlock(nb)
+	}
+	if off == len(b.items) {
+		return v, b.next, 0
+	}
+	return v, b, off
+}
+
+// splitBlock moves the elements of b from offset off on to a new block
+// right after it, and returns the new block.
+func (lst *List[T]) splitBlock(b *block[T], off int) *block[T] {
+	nb := lst.insertBlockAfter(b)
+	nb.items = append(nb.items, b.items[off:]...)
+	clear(b.items[off:])
+	b.items = b.items[:off]
+	return nb
+}
+
+func (lst *List[T]) insertBlockAfter(b *block[T]) *block[T] {
+	nb := &block[T]{
+		items: make([]T, 0, lst.blockSize),
+		next:  b.next,
+		prev:  b,
+	}
+	nb.next.prev = nb
+	b.next = nb
+	return nb
+}
+
+func (lst *List[T]) unlinkBlock(b *block[T]) {
+	b.prev.next = b.next
+	b.next.prev = b.prev
+	b.next = nil
+	b.prev = nil
+}
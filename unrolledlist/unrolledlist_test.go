@@ -0,0 +1,134 @@
+package unrolledlist
+
+import (
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func checkList[T comparable](t *testing.T, lst *List[T], want []T) {
+	t.Helper()
+	if lst.Len() != len(want) {
+		t.Errorf("got len=%v, want %v", lst.Len(), len(want))
+	}
+	got := slices.Collect(lst.Values())
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for i, v := range lst.All() {
+		if v != want[i] {
+			t.Errorf("All: got %v at %d, want %v", v, i, want[i])
+		}
+	}
+
+	// Verify block invariants: links are consistent, no block is empty or
+	// over capacity.
+	total := 0
+	for b := lst.front.next; b != lst.back; b = b.next {
+		if b.next.prev != b || b.prev.next != b {
+			t.Errorf("bad block links")
+		}
+		if len(b.items) == 0 || len(b.items) > lst.blockSize {
+			t.Errorf("block with %d items", len(b.items))
+		}
+		total += len(b.items)
+	}
+	if total != lst.length {
+		t.Errorf("got %d items in blocks, want %d", total, lst.length)
+	}
+}
+
+func TestPushAndAt(t *testing.T) {
+	lst := NewWithBlockSize[int](4)
+	var want []int
+	for i := range 20 {
+		lst.PushBack(i)
+		want = append(want, i)
+		checkList(t, lst, want)
+	}
+	for i := range 20 {
+		if lst.At(i) != i {
+			t.Errorf("got At(%d)=%v", i, lst.At(i))
+		}
+	}
+	lst.PushFront(-1)
+	want = slices.Insert(want, 0, -1)
+	checkList(t, lst, want)
+
+	lst.Set(5, 500)
+	want[5] = 500
+	checkList(t, lst, want)
+}
+
+func TestInsertRemove(t *testing.T) {
+	lst := NewWithBlockSize[string](3)
+	lst.Insert(0, "b")
+	lst.Insert(0, "a")
+	lst.Insert(2, "d")
+	lst.Insert(2, "c")
+	lst.Insert(4, "e")
+	checkList(t, lst, []string{"a", "b", "c", "d", "e"})
+
+	if v := lst.Remove(2); v != "c" {
+		t.Errorf("got %v, want c", v)
+	}
+	checkList(t, lst, []string{"a", "b", "d", "e"})
+	if v := lst.PopFront(); v != "a" {
+		t.Errorf("got %v, want a", v)
+	}
+	if v := lst.PopBack(); v != "e" {
+		t.Errorf("got %v, want e", v)
+	}
+	checkList(t, lst, []string{"b", "d"})
+	lst.PopBack()
+	lst.PopBack()
+	checkList(t, lst, []string{})
+}
+
+func TestOutOfRange(t *testing.T) {
+	lst := New[int]()
+	lst.PushBack(1)
+	for _, f := range []func(){
+		func() { lst.At(1) },
+		func() { lst.Insert(2, 0) },
+		func() { lst.Remove(-1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, bs := range []int{2, 3, 8, 64} {
+		lst := NewWithBlockSize[int](bs)
+		var want []int
+		for i := range 3000 {
+			if len(want) == 0 || rnd.IntN(5) < 3 {
+				pos := rnd.IntN(len(want) + 1)
+				lst.Insert(pos, i)
+				want = slices.Insert(want, pos, i)
+			} else {
+				pos := rnd.IntN(len(want))
+				if v := lst.Remove(pos); v != want[pos] {
+					t.Fatalf("got Remove(%d)=%v, want %v", pos, v, want[pos])
+				}
+				want = slices.Delete(want, pos, pos+1)
+			}
+			if i%50 == 0 {
+				checkList(t, lst, want)
+			}
+		}
+		checkList(t, lst, want)
+	}
+}
@@ -0,0 +1,345 @@
+// Package veb implements a van Emde Boas tree: a set of integer keys from a
+// bounded universe, supporting successor and predecessor queries in
+// O(log log U) time, where U is the size of the universe.
+package veb
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// leafBits is the universe size (in bits) at and below which a node stores
+// its keys directly in a 64-bit mask, rather than recursing further.
+const leafBits = 6
+
+// Set is a set of uint32 keys in the universe [0, 2^bits), for bits given
+// to [New]. Insert, Delete, Contains, Floor and Ceiling all take
+// O(log log U) time; memory is allocated lazily as keys are inserted, so
+// sparse sets in large universes remain cheap.
+type Set struct {
+	root   *node
+	bits   uint
+	length int
+}
+
+// node is a vEB node over the universe [0, 2^bits). Leaf nodes keep their
+// keys in mask; internal nodes keep min and max out of the clusters, as in
+// the classic formulation. high(x) indexes clusters and summary tracks
+// which clusters are non-empty.
+type node struct {
+	bits uint
+
+	// Leaf nodes.
+	mask uint64
+
+	// Internal nodes.
+	nonEmpty bool
+	min, max uint64
+	summary  *node
+	clusters []*node
+}
+
+// New creates a new, empty set for keys in the universe [0, 2^bits). bits
+// must be in the range [1, 32].
+func New(bits int) *Set {
+	if bits < 1 || bits > 32 {
+		panic("veb: universe bits out of range")
+	}
+	return &Set{root: newNode(uint(bits)), bits: uint(bits)}
+}
+
+// Len returns the number of keys in the set.
+func (s *Set) Len() int {
+	return s.length
+}
+
+// Contains reports whether key is in the set.
+func (s *Set) Contains(key uint32) bool {
+	return s.inUniverse(key) && s.root.contains(uint64(key))
+}
+
+// Insert adds key to the set. It returns true if key was added, and false
+// if it was already in the set. It panics if key is outside the universe.
+func (s *Set) Insert(key uint32) bool {
+	s.checkKey(key)
+	if s.root.contains(uint64(key)) {
+		return false
+	}
+	s.root.insert(uint64(key))
+	s.length++
+	return true
+}
+
+// Delete removes key from the set. It returns true if key was in the set,
+// and false otherwise.
+func (s *Set) Delete(key uint32) bool {
+	if !s.Contains(key) {
+		return false
+	}
+	s.root.delete(uint64(key))
+	s.length--
+	return true
+}
+
+// Min returns the smallest key in the set and ok=true, or ok=false if the
+// set is empty.
+func (s *Set) Min() (key uint32, ok bool) {
+	if s.root.empty() {
+		return 0, false
+	}
+	return uint32(s.root.minimum()), true
+}
+
+// Max returns the largest key in the set and ok=true, or ok=false if the
+// set is empty.
+func (s *Set) Max() (key uint32, ok bool) {
+	if s.root.empty() {
+		return 0, false
+	}
+	return uint32(s.root.maximum()), true
+}
+
+// Floor returns the largest key in the set that is <= key and ok=true, or
+// ok=false if there's no such key.
+func (s *Set) Floor(key uint32) (k uint32, ok bool) {
+	if !s.inUniverse(key) {
+		return s.Max()
+	}
+	if s.root.contains(uint64(key)) {
+		return key, true
+	}
+	p, ok := s.root.predecessor(uint64(key))
+	return uint32(p), ok
+}
+
+// Ceiling returns the smallest key in the set that is >= key and ok=true,
+// or ok=false if there's no such key.
+func (s *Set) Ceiling(key uint32) (k uint32, ok bool) {
+	if !s.inUniverse(key) {
+		return 0, false
+	}
+	if s.root.contains(uint64(key)) {
+		return key, true
+	}
+	n, ok := s.root.successor(uint64(key))
+	return uint32(n), ok
+}
+
+// All returns an iterator over all the keys in the set, in ascending order.
+func (s *Set) All() iter.Seq[uint32] {
+	return s.Range(0, uint32(s.maxKey()))
+}
+
+// Range returns an iterator over the keys k in the set with lo <= k <= hi,
+// in ascending order. Range is inclusive on both ends so that the largest
+// key in a 32-bit universe can be included.
+func (s *Set) Range(lo, hi uint32) iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		k, ok := s.Ceiling(lo)
+		for ok && k <= hi {
+			if !yield(k) {
+				return
+			}
+			var next uint64
+			next, ok = s.root.successor(uint64(k))
+			k = uint32(next)
+		}
+	}
+}
+
+func (s *Set) maxKey() uint64 {
+	return 1<<s.bits - 1
+}
+
+func (s *Set) inUniverse(key uint32) bool {
+	return uint64(key) <= s.maxKey()
+}
+
+func (s *Set) checkKey(key uint32) {
+	if !s.inUniverse(key) {
+		panic("veb: key out of universe")
+	}
+}
+
+func newNode(bits uint) *node {
+	return &node{bits: bits}
+}
+
+func (n *node) isLeaf() bool {
+	return n.bits <= leafBits
+}
+
+// lowBits is the number of bits of a key that index within a cluster; the
+// remaining high bits index the cluster.
+func (n *node) lowBits() uint {
+	return n.bits / 2
+}
+
+func (n *node) split(x uint64) (high, low uint64) {
+	lb := n.lowBits()
+	return x >> lb, x & (1<<lb - 1)
+}
+
+func (n *node) join(high, low uint64) uint64 {
+	return high<<n.lowBits() | low
+}
+
+func (n *node) empty() bool {
+	if n == nil {
+		return true
+	}
+	if n.isLeaf() {
+		return n.mask == 0
+	}
+	return !n.nonEmpty
+}
+
+// minimum and maximum may only be called on non-empty nodes.
+func (n *node) minimum() uint64 {
+	if n.isLeaf() {
+		return uint64(bits.TrailingZeros64(n.mask))
+	}
+	return n.min
+}
+
+func (n *node) maximum() uint64 {
+	if n.isLeaf() {
+		return uint64(63 - bits.LeadingZeros64(n.mask))
+	}
+	return n.max
+}
+
+func (n *node) contains(x uint64) bool {
+	if n.isLeaf() {
+		return n.mask&(1<<x) != 0
+	}
+	if !n.nonEmpty {
+		return false
+	}
+	if x == n.min || x == n.max {
+		return true
+	}
+	h, l := n.split(x)
+	if n.clusters == nil {
+		return false
+	}
+	c := n.clusters[h]
+	return !c.empty() && c.contains(l)
+}
+
+// insert adds x to the node; x must not already be in it.
+func (n *node) insert(x uint64) {
+	if n.isLeaf() {
+		n.mask |= 1 << x
+		return
+	}
+	if !n.nonEmpty {
+		n.nonEmpty = true
+		n.min, n.max = x, x
+		return
+	}
+	if x < n.min {
+		x, n.min = n.min, x
+	}
+	if x > n.max {
+		n.max = x
+	}
+	h, l := n.split(x)
+	if n.clusters == nil {
+		n.clusters = make([]*node, 1<<(n.bits-n.lowBits()))
+		n.summary = newNode(n.bits - n.lowBits())
+	}
+	c := n.clusters[h]
+	if c == nil {
+		c = newNode(n.lowBits())
+		n.clusters[h] = c
+	}
+	if c.empty() {
+		n.summary.insert(h)
+	}
+	c.insert(l)
+}
+
+// delete removes x from the node; x must be in it.
+func (n *node) delete(x uint64) {
+	if n.isLeaf() {
+		n.mask &^= 1 << x
+		return
+	}
+	if n.min == n.max {
+		n.nonEmpty = false
+		return
+	}
+	if x == n.min {
+		// Pull the smallest key out of the clusters to become the new min,
+		// and delete it from its cluster instead.
+		h := n.summary.minimum()
+		x = n.join(h, n.clusters[h].minimum())
+		n.min = x
+	}
+	h, l := n.split(x)
+	c := n.clusters[h]
+	c.delete(l)
+	if c.empty() {
+		n.summary.delete(h)
+	}
+	if x == n.max {
+		if n.summary.empty() {
+			n.max = n.min
+		} else {
+			sh := n.summary.maximum()
+			n.max = n.join(sh, n.clusters[sh].maximum())
+		}
+	}
+}
+
+// successor returns the smallest key in the node that is > x.
+func (n *node) successor(x uint64) (uint64, bool) {
+	if n.isLeaf() {
+		m := n.mask & (^uint64(0) << (x + 1))
+		if m == 0 {
+			return 0, false
+		}
+		return uint64(bits.TrailingZeros64(m)), true
+	}
+	if !n.nonEmpty || x >= n.max {
+		return 0, false
+	}
+	if x < n.min {
+		return n.min, true
+	}
+	h, l := n.split(x)
+	if c := n.clusters[h]; !c.empty() && l < c.maximum() {
+		next, _ := c.successor(l)
+		return n.join(h, next), true
+	}
+	// x < max and max isn't in x's cluster, so a later cluster is non-empty.
+	sh, _ := n.summary.successor(h)
+	return n.join(sh, n.clusters[sh].minimum()), true
+}
+
+// predecessor returns the largest key in the node that is < x.
+func (n *node) predecessor(x uint64) (uint64, bool) {
+	if n.isLeaf() {
+		m := n.mask & (1<<x - 1)
+		if m == 0 {
+			return 0, false
+		}
+		return uint64(63 - bits.LeadingZeros64(m)), true
+	}
+	if !n.nonEmpty || x <= n.min {
+		return 0, false
+	}
+	if x > n.max {
+		return n.max, true
+	}
+	h, l := n.split(x)
+	if c := n.clusters[h]; !c.empty() && l > c.minimum() {
+		prev, _ := c.predecessor(l)
+		return n.join(h, prev), true
+	}
+	if ph, ok := n.summary.predecessor(h); ok {
+		return n.join(ph, n.clusters[ph].maximum()), true
+	}
+	return n.min, true
+}
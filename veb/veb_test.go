@@ -0,0 +1,198 @@
+package veb
+
+import (
+	"cmp"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/eliben/gogl/btree"
+)
+
+// checkSet verifies that the set holds exactly the sorted keys in want.
+func checkSet(t *testing.T, s *Set, want []uint32) {
+	t.Helper()
+	if s.Len() != len(want) {
+		t.Fatalf("got Len=%d, want %d", s.Len(), len(want))
+	}
+	if got := slices.Collect(s.All()); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	mn, okMin := s.Min()
+	mx, okMax := s.Max()
+	if len(want) == 0 {
+		if okMin || okMax {
+			t.Fatalf("got Min/Max on empty set")
+		}
+	} else if mn != want[0] || mx != want[len(want)-1] || !okMin || !okMax {
+		t.Fatalf("got Min=%d Max=%d, want %d, %d", mn, mx, want[0], want[len(want)-1])
+	}
+}
+
+// naiveFloor and naiveCeiling find the floor and ceiling of key in the
+// sorted slice keys.
+func naiveFloor(keys []uint32, key uint32) (uint32, bool) {
+	i, found := slices.BinarySearch(keys, key)
+	if found {
+		return key, true
+	}
+	if i == 0 {
+		return 0, false
+	}
+	return keys[i-1], true
+}
+
+func naiveCeiling(keys []uint32, key uint32) (uint32, bool) {
+	i, _ := slices.BinarySearch(keys, key)
+	if i == len(keys) {
+		return 0, false
+	}
+	return keys[i], true
+}
+
+func TestBasic(t *testing.T) {
+	s := New(16)
+	checkSet(t, s, nil)
+	for _, k := range []uint32{500, 3, 65535, 0, 1000} {
+		if !s.Insert(k) {
+			t.Errorf("Insert(%d) returned false", k)
+		}
+	}
+	if s.Insert(500) {
+		t.Errorf("duplicate Insert returned true")
+	}
+	checkSet(t, s, []uint32{0, 3, 500, 1000, 65535})
+
+	if k, ok := s.Floor(999); !ok || k != 500 {
+		t.Errorf("Floor(999)=%d,%v", k, ok)
+	}
+	if k, ok := s.Ceiling(501); !ok || k != 1000 {
+		t.Errorf("Ceiling(501)=%d,%v", k, ok)
+	}
+	if k, ok := s.Floor(100000); !ok || k != 65535 {
+		t.Errorf("Floor past universe=%d,%v", k, ok)
+	}
+	if _, ok := s.Ceiling(100000); ok {
+		t.Errorf("Ceiling past universe found a key")
+	}
+	if got := slices.Collect(s.Range(3, 1000)); !slices.Equal(got, []uint32{3, 500, 1000}) {
+		t.Errorf("Range got %v", got)
+	}
+
+	if !s.Delete(0) || s.Delete(0) || s.Delete(100000) {
+		t.Errorf("bad Delete results")
+	}
+	checkSet(t, s, []uint32{3, 500, 1000, 65535})
+}
+
+func TestOutOfUniverse(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	New(8).Insert(256)
+}
+
+func TestFullUniverse(t *testing.T) {
+	s := New(32)
+	for _, k := range []uint32{0, 1 << 31, 1<<32 - 1} {
+		s.Insert(k)
+	}
+	checkSet(t, s, []uint32{0, 1 << 31, 1<<32 - 1})
+	if k, ok := s.Floor(1<<31 - 1); !ok || k != 0 {
+		t.Errorf("Floor=%d,%v", k, ok)
+	}
+	if k, ok := s.Ceiling(1<<31 + 1); !ok || k != 1<<32-1 {
+		t.Errorf("Ceiling=%d,%v", k, ok)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	for _, bits := range []int{1, 5, 6, 7, 12, 20, 32} {
+		universe := uint64(1) << bits
+		// Draw keys from a limited range so that deletions and lookups
+		// hit existing keys often.
+		span := min(universe, 5000)
+		base := uint64(rnd.Uint64N(universe - span + 1))
+
+		s := New(bits)
+		mirror := make(map[uint32]bool)
+		for i := range 4000 {
+			k := uint32(base + rnd.Uint64N(span))
+			if rnd.IntN(3) == 0 {
+				if s.Delete(k) != mirror[k] {
+					t.Fatalf("bits=%d: Delete(%d) mismatch", bits, k)
+				}
+				delete(mirror, k)
+			} else {
+				if s.Insert(k) == mirror[k] {
+					t.Fatalf("bits=%d: Insert(%d) mismatch", bits, k)
+				}
+				mirror[k] = true
+			}
+
+			keys := make([]uint32, 0, len(mirror))
+			for k := range mirror {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+			if i%100 == 0 {
+				checkSet(t, s, keys)
+			}
+
+			q := uint32(base + rnd.Uint64N(span))
+			if s.Contains(q) != mirror[q] {
+				t.Fatalf("bits=%d: Contains(%d) mismatch", bits, q)
+			}
+			gotK, gotOk := s.Floor(q)
+			wantK, wantOk := naiveFloor(keys, q)
+			if gotK != wantK || gotOk != wantOk {
+				t.Fatalf("bits=%d: Floor(%d)=%d,%v, want %d,%v", bits, q, gotK, gotOk, wantK, wantOk)
+			}
+			gotK, gotOk = s.Ceiling(q)
+			wantK, wantOk = naiveCeiling(keys, q)
+			if gotK != wantK || gotOk != wantOk {
+				t.Fatalf("bits=%d: Ceiling(%d)=%d,%v, want %d,%v", bits, q, gotK, gotOk, wantK, wantOk)
+			}
+		}
+	}
+}
+
+func benchmarkKeys() []uint32 {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	keys := make([]uint32, 100000)
+	for i := range keys {
+		keys[i] = uint32(rnd.IntN(1 << 20))
+	}
+	return keys
+}
+
+func BenchmarkCeiling(b *testing.B) {
+	keys := benchmarkKeys()
+	s := New(20)
+	for _, k := range keys {
+		s.Insert(k)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		s.Ceiling(keys[i%len(keys)] + 1)
+	}
+}
+
+func BenchmarkCeilingBTree(b *testing.B) {
+	keys := benchmarkKeys()
+	bt := btree.New[uint32, struct{}](cmp.Compare[uint32])
+	for _, k := range keys {
+		bt.Insert(k, struct{}{})
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		bt.Ceiling(keys[i%len(keys)] + 1)
+	}
+}
@@ -0,0 +1,494 @@
+// Package wal implements a write-ahead log: an append-only sequence of
+// records persisted in segment files, for the crash recovery of durable
+// structures.
+package wal
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Log is a write-ahead log: a sequence of records, each an opaque byte
+// slice identified by its index, which counts up from 0. A structure using
+// the log appends a record describing each update before applying it, and
+// replays the records after a crash to recover the updates it hadn't
+// persisted otherwise.
+//
+// Records are stored in segment files in a directory, each starting with
+// the record following the last one of the previous segment; a new segment
+// is started when the current one reaches [Options.SegmentSize]. Once the
+// structure has persisted the updates of old records, it discards them with
+// [Log.TruncateFront], which removes whole segments.
+//
+// Each record is stored with its length and a CRC-32C checksum. A record
+// torn by a crash while it was being written is at the end of the last
+// segment; it's detected and discarded when the log is opened. Corruption
+// elsewhere is reported as [ErrInvalidData] when replaying.
+//
+// A Log is safe for concurrent use by multiple goroutines, but a directory
+// must only be opened by one Log at a time. Open logs with [Open].
+type Log struct {
+	dir  string
+	opts Options
+
+	mu sync.Mutex
+
+	// segments holds the segments in order; the last one is open for
+	// appending in f, through w, and has size bytes.
+	segments []segment
+	f        *os.File
+	w        *bufio.Writer
+	size     int64
+
+	// next is the index of the next record to append.
+	next   uint64
+	closed bool
+}
+
+// segment is a segment file, whose first record has index first.
+type segment struct {
+	first uint64
+	path  string
+}
+
+// Options configure a Log. Zero fields are replaced by their defaults.
+type Options struct {
+	// SegmentSize is the size above which a new segment is started. The
+	// default is 64 MiB.
+	SegmentSize int64
+}
+
+// Record is a record of a log.
+type Record struct {
+	Index uint64
+	Data  []byte
+}
+
+var (
+	// ErrInvalidData is returned when replaying a corrupted record, or
+	// opening a log with an invalid segment file.
+	ErrInvalidData = errors.New("wal: invalid log data")
+
+	// ErrClosed is returned when using a closed Log.
+	ErrClosed = errors.New("wal: log is closed")
+)
+
+// Each segment file starts with a header holding a magic string. Records
+// follow, each encoded as the length of its data (uint32), the CRC-32C of
+// the data (uint32), and the data.
+const (
+	segmentMagic      = "GOGLWAL1"
+	headerSize        = len(segmentMagic)
+	recordHeaderSize  = 8
+	segmentNameSuffix = ".wal"
+
+	// maxRecordSize is the maximal size of a record's data.
+	maxRecordSize = 1 << 30
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Open opens the log in dir, creating it if it doesn't exist. opts may be
+// nil to use the default options.
+func Open(dir string, opts *Options) (*Log, error) {
+	l := &Log{dir: dir}
+	if opts != nil {
+		l.opts = *opts
+	}
+	if l.opts.SegmentSize <= 0 {
+		l.opts.SegmentSize = 64 << 20
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if first, ok := parseSegmentName(f.Name()); ok {
+			l.segments = append(l.segments, segment{first, filepath.Join(dir, f.Name())})
+		}
+	}
+	slices.SortFunc(l.segments, func(a, b segment) int {
+		return cmp.Compare(a.first, b.first)
+	})
+
+	if len(l.segments) == 0 {
+		if err := l.startSegment(0); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	// Find the end of the last segment, discarding a torn record, and open
+	// it for appending.
+	last := l.segments[len(l.segments)-1]
+	count, end, err := scanSegment(last.path)
+	if errors.Is(err, errTornHeader) {
+		// The segment was being created; start it again.
+		l.segments = l.segments[:len(l.segments)-1]
+		if err := l.startSegment(last.first); err != nil {
+			return nil, err
+		}
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if l.f, err = os.OpenFile(last.path, os.O_RDWR, 0); err != nil {
+		return nil, err
+	}
+	if err := l.f.Truncate(end); err != nil {
+		l.f.Close()
+		return nil, err
+	}
+	if _, err := l.f.Seek(end, io.SeekStart); err != nil {
+		l.f.Close()
+		return nil, err
+	}
+	l.w = bufio.NewWriter(l.f)
+	l.size = end
+	l.next = last.first + count
+	return l, nil
+}
+
+func segmentName(first uint64) string {
+	return fmt.Sprintf("%020d%s", first, segmentNameSuffix)
+}
+
+// parseSegmentName returns the index of the first record of the segment
+// file with the given name, or ok=false if it's not the name of a segment
+// file.
+func parseSegmentName(name string) (first uint64, ok bool) {
+	s, found := strings.CutSuffix(name, segmentNameSuffix)
+	if !found {
+		return 0, false
+	}
+	first, err := strconv.ParseUint(s, 10, 64)
+	return first, err == nil
+}
+
+// scanSegment reads the segment file at path, and returns the number of
+// valid records at its start, and the offset where they end.
+func scanSegment(path string) (count uint64, end int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	r, err := newSegmentReader(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := r.readHeader(); err != nil {
+		if r.size < int64(headerSize) {
+			return 0, 0, errTornHeader
+		}
+		return 0, 0, err
+	}
+	for {
+		_, err := r.next()
+		if err == io.EOF || errors.Is(err, ErrInvalidData) {
+			return count, r.offset, nil
+		} else if err != nil {
+			return 0, 0, err
+		}
+		count++
+	}
+}
+
+// startSegment starts a new segment with the given first record, which
+// becomes the one open for appending. It must be called with l.mu held, and
+// the previous segment, if any, synced and closed.
+func (l *Log) startSegment(first uint64) error {
+	path := filepath.Join(l.dir, segmentName(first))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(segmentMagic); err != nil {
+		f.Close()
+		return err
+	}
+	if err := syncDir(l.dir); err != nil {
+		f.Close()
+		return err
+	}
+	l.segments = append(l.segments, segment{first, path})
+	l.f, l.w, l.size, l.next = f, bufio.NewWriter(f), int64(headerSize), first
+	return nil
+}
+
+// FirstIndex returns the index of the first record in the log. If the log
+// is empty, it's the index of the next record appended.
+func (l *Log) FirstIndex() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.segments[0].first
+}
+
+// NextIndex returns the index of the next record appended to the log.
+func (l *Log) NextIndex() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next
+}
+
+// Append appends a record holding data to the log, and returns its index.
+// The record is buffered: it's only durable once [Log.Sync] returns, which
+// lets multiple appends share the cost of a sync.
+func (l *Log) Append(data []byte) (uint64, error) {
+	if len(data) > maxRecordSize {
+		return 0, fmt.Errorf("wal: record of %d bytes is too large", len(data))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, ErrClosed
+	}
+	if l.size > int64(headerSize) && l.size+int64(recordHeaderSize+len(data)) > l.opts.SegmentSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	var header [recordHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[4:], crc32.Checksum(data, crcTable))
+	if _, err := l.w.Write(header[:]); err != nil {
+		return 0, err
+	}
+	if _, err := l.w.Write(data); err != nil {
+		return 0, err
+	}
+	l.size += int64(recordHeaderSize + len(data))
+	l.next++
+	return l.next - 1, nil
+}
+
+// Sync makes all the appended records durable.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	if err := l.w.Flush(); err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// Rotate syncs the current segment and starts a new one, so that all the
+// records appended so far can be discarded by truncating the log up to
+// [Log.NextIndex]. It does nothing if the current segment is empty.
+func (l *Log) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	if l.size == int64(headerSize) {
+		return nil
+	}
+	return l.rotate()
+}
+
+func (l *Log) rotate() error {
+	if err := l.closeSegment(); err != nil {
+		return err
+	}
+	return l.startSegment(l.next)
+}
+
+// closeSegment syncs and closes the segment open for appending.
+func (l *Log) closeSegment() error {
+	err := l.w.Flush()
+	if err == nil {
+		err = l.f.Sync()
+	}
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// TruncateFront discards the records before index, by removing the
+// segments holding only such records; the records of a segment are kept
+// until all of them can be discarded, so the log may still start before
+// index. Use [Log.Rotate] to end the current segment.
+func (l *Log) TruncateFront(index uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	n := 0
+	for n+1 < len(l.segments) && l.segments[n+1].first <= index {
+		n++
+	}
+	for i, s := range l.segments[:n] {
+		if err := os.Remove(s.path); err != nil {
+			l.segments = l.segments[i:]
+			return err
+		}
+	}
+	l.segments = slices.Delete(l.segments, 0, n)
+	return nil
+}
+
+// Records returns an iterator over the records of the log with indices >=
+// from, in order. Records appended after the iteration starts aren't
+// included. If reading fails, or a record is corrupted, the iterator yields
+// the error and stops. Segments removed by [Log.TruncateFront] while the
+// iteration is on them may fail to be read.
+func (l *Log) Records(from uint64) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			yield(Record{}, ErrClosed)
+			return
+		}
+		if err := l.w.Flush(); err != nil {
+			l.mu.Unlock()
+			yield(Record{}, err)
+			return
+		}
+		segments, end := slices.Clone(l.segments), l.next
+		l.mu.Unlock()
+
+		for i, s := range segments {
+			if i+1 < len(segments) && segments[i+1].first <= from {
+				continue
+			}
+			ok, err := readRecords(s, from, end, yield)
+			if err != nil {
+				yield(Record{}, err)
+				return
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// readRecords yields the records of segment s with indices in [from, end);
+// it returns false if yield asked to stop.
+func readRecords(s segment, from, end uint64, yield func(Record, error) bool) (bool, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	r, err := newSegmentReader(f)
+	if err != nil {
+		return false, err
+	}
+	if err := r.readHeader(); err != nil {
+		return false, err
+	}
+	for index := s.first; index < end; index++ {
+		data, err := r.next()
+		if err == io.EOF {
+			// Records of the next segment start here.
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		if index >= from && !yield(Record{index, data}, nil) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Close syncs and closes the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	l.closed = true
+	return l.closeSegment()
+}
+
+// errTornHeader is returned by scanSegment for a segment file shorter than
+// its header.
+var errTornHeader = errors.New("wal: torn segment header")
+
+// segmentReader reads the records of a segment file of the given size.
+type segmentReader struct {
+	r      *bufio.Reader
+	offset int64
+	size   int64
+}
+
+func newSegmentReader(f *os.File) (*segmentReader, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &segmentReader{r: bufio.NewReader(f), size: fi.Size()}, nil
+}
+
+func (sr *segmentReader) readHeader() error {
+	var magic [headerSize]byte
+	if _, err := io.ReadFull(sr.r, magic[:]); err != nil || string(magic[:]) != segmentMagic {
+		return ErrInvalidData
+	}
+	sr.offset = int64(headerSize)
+	return nil
+}
+
+// next reads the next record's data. It returns io.EOF at the end of the
+// segment, and ErrInvalidData if the record is torn or corrupted.
+func (sr *segmentReader) next() ([]byte, error) {
+	var header [recordHeaderSize]byte
+	n, err := io.ReadFull(sr.r, header[:])
+	if err == io.EOF {
+		return nil, io.EOF
+	} else if err == io.ErrUnexpectedEOF || n < recordHeaderSize {
+		return nil, ErrInvalidData
+	} else if err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(header[:])
+	if size > maxRecordSize || sr.offset+int64(recordHeaderSize)+int64(size) > sr.size {
+		return nil, ErrInvalidData
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(sr.r, data); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrInvalidData
+	} else if err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, ErrInvalidData
+	}
+	sr.offset += int64(recordHeaderSize) + int64(size)
+	return data, nil
+}
+
+// syncDir syncs the directory dir, making file creations in it durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
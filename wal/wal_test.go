@@ -0,0 +1,340 @@
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func openTestLog(t *testing.T, dir string, opts *Options) *Log {
+	t.Helper()
+	l, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func recordData(i uint64) []byte {
+	return bytes.Repeat([]byte(fmt.Sprintf("record%d;", i)), int(i%7))
+}
+
+func appendRecords(t *testing.T, l *Log, from, to uint64) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		index, err := l.Append(recordData(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index != i {
+			t.Fatalf("got index %d, want %d", index, i)
+		}
+	}
+}
+
+// checkRecords checks that replaying l from the given index yields the
+// records of indices [from, to).
+func checkRecords(t *testing.T, l *Log, from, to uint64) {
+	t.Helper()
+	want := from
+	for r, err := range l.Records(from) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Index != want || !bytes.Equal(r.Data, recordData(want)) {
+			t.Fatalf("got record %d=%q, want %d=%q", r.Index, r.Data, want, recordData(want))
+		}
+		want++
+	}
+	if want != to {
+		t.Fatalf("replay from %d stopped at %d, want %d", from, want, to)
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentNameSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestAppendReplay(t *testing.T) {
+	dir := t.TempDir()
+	opts := &Options{SegmentSize: 1000}
+	l := openTestLog(t, dir, opts)
+	if l.FirstIndex() != 0 || l.NextIndex() != 0 {
+		t.Errorf("got indices %d, %d for an empty log", l.FirstIndex(), l.NextIndex())
+	}
+	checkRecords(t, l, 0, 0)
+	appendRecords(t, l, 0, 500)
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(segmentFiles(t, dir)); n < 10 {
+		t.Errorf("got %d segments", n)
+	}
+	for _, from := range []uint64{0, 1, 99, 250, 499, 500} {
+		checkRecords(t, l, from, 500)
+	}
+
+	// Records are kept when reopening; unsynced ones too, if the log is
+	// closed.
+	appendRecords(t, l, 500, 600)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l = openTestLog(t, dir, opts)
+	defer l.Close()
+	if l.NextIndex() != 600 {
+		t.Errorf("got NextIndex=%d, want 600", l.NextIndex())
+	}
+	appendRecords(t, l, 600, 700)
+	checkRecords(t, l, 0, 700)
+	checkRecords(t, l, 650, 700)
+
+	// Stop early.
+	count := 0
+	for range l.Records(0) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("got %d records, want 3", count)
+	}
+}
+
+func TestTornTail(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, nil)
+	appendRecords(t, l, 0, 10)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := segmentFiles(t, dir)[0]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tails := map[string][]byte{
+		"partial header": {5, 0, 0},
+		"partial data":   {100, 0, 0, 0, 1, 2, 3, 4, 'a', 'b'},
+		"bad checksum":   {2, 0, 0, 0, 1, 2, 3, 4, 'a', 'b'},
+		"huge length":    {0xff, 0xff, 0xff, 0x3f, 1, 2, 3, 4},
+	}
+	for name, tail := range tails {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(path, append(bytes.Clone(data), tail...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			l := openTestLog(t, dir, nil)
+			defer l.Close()
+			if l.NextIndex() != 10 {
+				t.Errorf("got NextIndex=%d, want 10", l.NextIndex())
+			}
+			appendRecords(t, l, 10, 20)
+			checkRecords(t, l, 0, 20)
+		})
+	}
+}
+
+func TestTornHeader(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, nil)
+	appendRecords(t, l, 0, 10)
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second segment was created, but its header wasn't written.
+	files := segmentFiles(t, dir)
+	if len(files) != 2 {
+		t.Fatalf("got %d segments, want 2", len(files))
+	}
+	if err := os.WriteFile(files[1], []byte("GOG"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l = openTestLog(t, dir, nil)
+	defer l.Close()
+	appendRecords(t, l, 10, 20)
+	checkRecords(t, l, 0, 20)
+}
+
+func TestCorruption(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, &Options{SegmentSize: 1000})
+	defer l.Close()
+	appendRecords(t, l, 0, 100)
+	l.Sync()
+
+	path := segmentFiles(t, dir)[0]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var got error
+	count := 0
+	for _, err := range l.Records(0) {
+		if err != nil {
+			got = err
+		} else {
+			count++
+		}
+	}
+	if !errors.Is(got, ErrInvalidData) {
+		t.Errorf("got err=%v, want ErrInvalidData", got)
+	}
+	if count == 0 || count >= 100 {
+		t.Errorf("got %d records before the error", count)
+	}
+
+	data[0] = 'X'
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range l.Records(0) {
+		if !errors.Is(err, ErrInvalidData) {
+			t.Errorf("got err=%v for a bad header, want ErrInvalidData", err)
+		}
+	}
+}
+
+func TestTruncateFront(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir, &Options{SegmentSize: 1000})
+	defer l.Close()
+	appendRecords(t, l, 0, 300)
+	segments := len(segmentFiles(t, dir))
+
+	if err := l.TruncateFront(150); err != nil {
+		t.Fatal(err)
+	}
+	first := l.FirstIndex()
+	if first == 0 || first > 150 {
+		t.Errorf("got FirstIndex=%d, want in (0, 150]", first)
+	}
+	if n := len(segmentFiles(t, dir)); n >= segments {
+		t.Errorf("got %d segments, had %d", n, segments)
+	}
+	checkRecords(t, l, first, 300)
+	for r := range l.Records(0) {
+		if r.Index != first {
+			t.Errorf("replay from 0 started at %d, want %d", r.Index, first)
+		}
+		break
+	}
+
+	// Rotating lets all the records be discarded.
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.TruncateFront(l.NextIndex()); err != nil {
+		t.Fatal(err)
+	}
+	if l.FirstIndex() != 300 || len(segmentFiles(t, dir)) != 1 {
+		t.Errorf("got FirstIndex=%d with %d segments", l.FirstIndex(), len(segmentFiles(t, dir)))
+	}
+	checkRecords(t, l, 300, 300)
+	appendRecords(t, l, 300, 310)
+	checkRecords(t, l, 300, 310)
+}
+
+func TestConcurrent(t *testing.T) {
+	l := openTestLog(t, t.TempDir(), &Options{SegmentSize: 4096})
+	defer l.Close()
+	var wg sync.WaitGroup
+	seen := make([]bool, 8*200)
+	var mu sync.Mutex
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				index, err := l.Append([]byte("data"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				seen[index] = true
+				mu.Unlock()
+				if index%50 == 0 {
+					if err := l.Sync(); err != nil {
+						t.Error(err)
+					}
+					for _, err := range l.Records(index) {
+						if err != nil {
+							t.Error(err)
+						}
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("index %d not appended", i)
+		}
+	}
+	count := 0
+	for range l.Records(0) {
+		count++
+	}
+	if count != len(seen) {
+		t.Errorf("got %d records, want %d", count, len(seen))
+	}
+}
+
+func TestClosed(t *testing.T) {
+	l := openTestLog(t, t.TempDir(), nil)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append(nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Append: got err=%v, want ErrClosed", err)
+	}
+	for _, err := range l.Records(0) {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Records: got err=%v, want ErrClosed", err)
+		}
+	}
+	for name, f := range map[string]func() error{
+		"Sync":          l.Sync,
+		"Rotate":        l.Rotate,
+		"TruncateFront": func() error { return l.TruncateFront(0) },
+		"Close":         l.Close,
+	} {
+		if err := f(); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: got err=%v, want ErrClosed", name, err)
+		}
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	l, err := Open(b.TempDir(), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer l.Close()
+	data := bytes.Repeat([]byte("x"), 100)
+	b.ResetTimer()
+	for range b.N {
+		l.Append(data)
+	}
+}
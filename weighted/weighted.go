@@ -0,0 +1,197 @@
+// Package weighted implements weighted random selection of indices.
+package weighted
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"github.com/eliben/gogl/fenwick"
+)
+
+// Alias selects random indices in [0, n) with probabilities proportional to
+// fixed weights, in O(1) time per selection after O(n) construction, using
+// Vose's alias method: every index i owns a bucket, split between i itself
+// (with probability prob[i]) and an "alias" index; a selection picks a
+// bucket uniformly and then one of its two parts.
+//
+// An Alias is immutable, so it's safe for concurrent use by multiple
+// goroutines. Create tables with [NewAlias] or [AliasFromSeq]; for weights
+// that change, use [Dynamic].
+type Alias struct {
+	prob  []float64
+	alias []int
+}
+
+// NewAlias creates an Alias for the given weights. It panics if a weight is
+// negative, NaN or infinite, or if all weights are zero.
+func NewAlias(weights []float64) *Alias {
+	n := len(weights)
+	total := 0.0
+	for _, w := range weights {
+		checkWeight(w)
+		total += w
+	}
+	if !(total > 0) || math.IsInf(total, 1) {
+		panic(fmt.Sprintf("weighted: invalid total weight %v", total))
+	}
+
+	a := &Alias{prob: make([]float64, n), alias: make([]int, n)}
+	// Scale the weights so they average 1, and pair each bucket below 1
+	// with a bucket above 1 that fills it up.
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		a.prob[s] = scaled[s]
+		a.alias[s] = l
+		scaled[l] -= 1 - scaled[s]
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// What's left is 1 up to rounding errors.
+	for _, i := range slices.Concat(small, large) {
+		a.prob[i] = 1
+		a.alias[i] = i
+	}
+	return a
+}
+
+// AliasFromSeq creates an Alias for the weights in seq, like [NewAlias].
+func AliasFromSeq(seq iter.Seq[float64]) *Alias {
+	return NewAlias(slices.Collect(seq))
+}
+
+// Len returns the number of indices the table selects from.
+func (a *Alias) Len() int {
+	return len(a.prob)
+}
+
+// Sample returns a random index, drawing random numbers from rnd. If rnd is
+// nil, it uses the top-level functions of math/rand/v2.
+func (a *Alias) Sample(rnd *rand.Rand) int {
+	var i int
+	var u float64
+	if rnd != nil {
+		i, u = rnd.IntN(len(a.prob)), rnd.Float64()
+	} else {
+		i, u = rand.IntN(len(a.prob)), rand.Float64()
+	}
+	if u < a.prob[i] {
+		return i
+	}
+	return a.alias[i]
+}
+
+// Dynamic selects random indices in [0, n) with probabilities proportional
+// to weights that can be changed. Both selection and changing a weight take
+// O(log n) (amortized) time, using a [fenwick.Tree] of the weights' prefix
+// sums.
+//
+// Create Dynamic selectors with [NewDynamic] or [DynamicFromSlice].
+type Dynamic struct {
+	weights []float64
+	sums    *fenwick.Tree[float64]
+
+	// positive is the number of positive weights. updates is the number of
+	// weight changes since sums was built; sums is rebuilt from weights
+	// every Len() changes, so that rounding errors don't accumulate.
+	positive int
+	updates  int
+}
+
+// NewDynamic creates a Dynamic selector over n indices, all with weight 0.
+func NewDynamic(n int) *Dynamic {
+	return &Dynamic{weights: make([]float64, n), sums: fenwick.New[float64](n)}
+}
+
+// DynamicFromSlice creates a Dynamic selector with the given weights. It
+// panics if a weight is negative, NaN or infinite.
+func DynamicFromSlice(weights []float64) *Dynamic {
+	for _, w := range weights {
+		checkWeight(w)
+	}
+	d := &Dynamic{weights: slices.Clone(weights), sums: fenwick.FromSlice(weights)}
+	for _, w := range weights {
+		if w > 0 {
+			d.positive++
+		}
+	}
+	return d
+}
+
+// Len returns the number of indices the selector selects from.
+func (d *Dynamic) Len() int {
+	return len(d.weights)
+}
+
+// Weight returns the weight of index i.
+func (d *Dynamic) Weight(i int) float64 {
+	return d.weights[i]
+}
+
+// Set sets the weight of index i to w. It panics if w is negative, NaN or
+// infinite.
+func (d *Dynamic) Set(i int, w float64) {
+	checkWeight(w)
+	if d.weights[i] > 0 {
+		d.positive--
+	}
+	if w > 0 {
+		d.positive++
+	}
+	d.sums.Add(i, w-d.weights[i])
+	d.weights[i] = w
+	if d.updates++; d.updates >= len(d.weights) {
+		d.sums = fenwick.FromSlice(d.weights)
+		d.updates = 0
+	}
+}
+
+// Total returns the sum of the weights.
+func (d *Dynamic) Total() float64 {
+	return d.sums.Total()
+}
+
+// Sample returns a random index, drawing random numbers from rnd. If rnd is
+// nil, it uses the top-level functions of math/rand/v2. It panics if all
+// weights are zero.
+func (d *Dynamic) Sample(rnd *rand.Rand) int {
+	if d.positive == 0 {
+		panic("weighted: sampling with zero total weight")
+	}
+	total := d.sums.Total()
+	for {
+		var u float64
+		if rnd != nil {
+			u = rnd.Float64()
+		} else {
+			u = rand.Float64()
+		}
+		// Rounding errors in the sums may make the target land past the
+		// last index, or on an index whose weight is now 0; draw again in
+		// that unlikely case.
+		if i := d.sums.FindKth(u * total); i < len(d.weights) && d.weights[i] > 0 {
+			return i
+		}
+	}
+}
+
+func checkWeight(w float64) {
+	if !(w >= 0) || math.IsInf(w, 1) {
+		panic(fmt.Sprintf("weighted: invalid weight %v", w))
+	}
+}
@@ -0,0 +1,137 @@
+package weighted
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func makeLoggedRand(t *testing.T) *rand.Rand {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	return rand.New(rand.NewPCG(s1, s2))
+}
+
+// checkDistribution samples trials indices and checks that each index is
+// selected in proportion to weights, within 5 standard deviations.
+func checkDistribution(t *testing.T, weights []float64, trials int, sample func() int) {
+	t.Helper()
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	counts := make([]int, len(weights))
+	for range trials {
+		counts[sample()]++
+	}
+	for i, c := range counts {
+		p := weights[i] / total
+		want := p * float64(trials)
+		sd := math.Sqrt(float64(trials) * p * (1 - p))
+		if math.Abs(float64(c)-want) > 5*sd+1 {
+			t.Errorf("index %d (weight %v) selected %d times, want about %.0f", i, weights[i], c, want)
+		}
+	}
+}
+
+func TestAlias(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	for _, weights := range [][]float64{
+		{1},
+		{1, 1, 1, 1},
+		{1, 2, 3, 4},
+		{0, 5, 0, 1, 0.5},
+		{1e-9, 1, 1e9},
+	} {
+		a := NewAlias(weights)
+		if a.Len() != len(weights) {
+			t.Errorf("Len=%d", a.Len())
+		}
+		checkDistribution(t, weights, 100000, func() int { return a.Sample(rnd) })
+	}
+
+	// Random weights, with the global source.
+	weights := make([]float64, 100)
+	for i := range weights {
+		weights[i] = rnd.Float64()
+	}
+	a := AliasFromSeq(slices.Values(weights))
+	checkDistribution(t, weights, 200000, func() int { return a.Sample(nil) })
+}
+
+func TestDynamic(t *testing.T) {
+	rnd := makeLoggedRand(t)
+	weights := []float64{1, 2, 3, 4}
+	d := DynamicFromSlice(weights)
+	checkDistribution(t, weights, 50000, func() int { return d.Sample(rnd) })
+
+	d.Set(3, 0)
+	d.Set(0, 10)
+	weights = []float64{10, 2, 3, 0}
+	if d.Weight(0) != 10 || d.Total() != 15 || d.Len() != 4 {
+		t.Errorf("Weight(0)=%v Total=%v", d.Weight(0), d.Total())
+	}
+	checkDistribution(t, weights, 50000, func() int { return d.Sample(rnd) })
+
+	// Many random updates; zero weights are never selected.
+	d = NewDynamic(50)
+	weights = make([]float64, 50)
+	for range 5000 {
+		i := rnd.IntN(50)
+		w := 0.0
+		if rnd.IntN(3) > 0 {
+			w = rnd.Float64() * 100
+		}
+		d.Set(i, w)
+		weights[i] = w
+		if slices.Max(weights) == 0 {
+			continue
+		}
+		if v := d.Sample(rnd); weights[v] == 0 {
+			t.Fatalf("selected index %d with zero weight", v)
+		}
+	}
+	checkDistribution(t, weights, 100000, func() int { return d.Sample(nil) })
+}
+
+func TestInvalid(t *testing.T) {
+	for name, f := range map[string]func(){
+		"empty alias":       func() { NewAlias(nil) },
+		"zero alias":        func() { NewAlias([]float64{0, 0}) },
+		"negative alias":    func() { NewAlias([]float64{1, -1}) },
+		"NaN dynamic":       func() { NewDynamic(2).Set(0, math.NaN()) },
+		"inf dynamic":       func() { DynamicFromSlice([]float64{math.Inf(1)}) },
+		"zero total sample": func() { NewDynamic(3).Sample(nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func BenchmarkSample(b *testing.B) {
+	weights := make([]float64, 10000)
+	for i := range weights {
+		weights[i] = float64(i%17 + 1)
+	}
+	rnd := rand.New(rand.NewPCG(1, 2))
+	b.Run("alias", func(b *testing.B) {
+		a := NewAlias(weights)
+		for range b.N {
+			a.Sample(rnd)
+		}
+	})
+	b.Run("dynamic", func(b *testing.B) {
+		d := DynamicFromSlice(weights)
+		for range b.N {
+			d.Sample(rnd)
+		}
+	})
+}
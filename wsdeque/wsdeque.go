@@ -0,0 +1,137 @@
+// Package wsdeque implements a work-stealing deque.
+package wsdeque
+
+import "sync/atomic"
+
+// Deque is a Chase-Lev work-stealing deque, the core data structure of
+// work-stealing schedulers. The deque has a single owner goroutine, which
+// pushes and pops tasks at the bottom end like a stack; any number of other
+// goroutines ("thieves") may concurrently steal tasks from the top end.
+//
+// The owner's operations don't use compare-and-swap except when contending
+// with thieves for the last element, so they are very cheap; thieves
+// contend with each other on the top index only.
+//
+// The implementation follows "Correct and Efficient Work-Stealing for Weak
+// Memory Models" by Lê, Pop, Cohen and Zappa Nardelli (2013); Go's atomics
+// are sequentially consistent, so the paper's fences are implied. The
+// buffer grows as needed and is never shrunk. Elements are stored boxed so
+// that thieves can read them atomically.
+//
+// Create deques with [New].
+type Deque[T any] struct {
+	// top is the index of the next element to steal, and bottom the index
+	// of the next element to push; the deque holds the elements with
+	// indices in [top, bottom).
+	top    atomic.Int64
+	bottom atomic.Int64
+	buf    atomic.Pointer[ring[T]]
+}
+
+// ring is a circular buffer whose length is a power of two. Slot i holds
+// the element with index i (modulo the ring size).
+type ring[T any] struct {
+	slots []atomic.Pointer[T]
+}
+
+func newRing[T any](n int) *ring[T] {
+	return &ring[T]{slots: make([]atomic.Pointer[T], n)}
+}
+
+func (r *ring[T]) slot(i int64) *atomic.Pointer[T] {
+	return &r.slots[i&int64(len(r.slots)-1)]
+}
+
+// New creates a new, empty Deque.
+func New[T any]() *Deque[T] {
+	d := &Deque[T]{}
+	d.buf.Store(newRing[T](32))
+	return d
+}
+
+// Len returns the number of elements in the deque. With concurrent
+// operations in progress, the result is only an approximation.
+func (d *Deque[T]) Len() int {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	return max(0, int(b-t))
+}
+
+// PushBottom adds v at the bottom of the deque. It may only be called by
+// the deque's owner.
+func (d *Deque[T]) PushBottom(v T) {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	r := d.buf.Load()
+	if b-t >= int64(len(r.slots)) {
+		r = d.grow(r, t, b)
+	}
+	r.slot(b).Store(&v)
+	d.bottom.Store(b + 1)
+}
+
+// grow replaces the ring with one of double the size, holding the elements
+// with indices in [t, b). Thieves may still read from the old ring; that's
+// fine since the elements they're after are unchanged in it.
+func (d *Deque[T]) grow(r *ring[T], t, b int64) *ring[T] {
+	nr := newRing[T](2 * len(r.slots))
+	for i := t; i < b; i++ {
+		nr.slot(i).Store(r.slot(i).Load())
+	}
+	d.buf.Store(nr)
+	return nr
+}
+
+// PopBottom removes the element at the bottom of the deque (the most
+// recently pushed one) and returns it and true. If the deque is empty, it
+// returns false. It may only be called by the deque's owner.
+func (d *Deque[T]) PopBottom() (v T, ok bool) {
+	b := d.bottom.Load() - 1
+	r := d.buf.Load()
+	// Reserve index b before looking at top; a thief that reads bottom
+	// after this won't try to take b.
+	d.bottom.Store(b)
+	t := d.top.Load()
+	if t > b {
+		// Empty.
+		d.bottom.Store(b + 1)
+		return v, false
+	}
+	p := r.slot(b).Load()
+	if t == b {
+		// This is the last element, and thieves may be racing for it; top
+		// decides the winner. Either way, no thief can take index b after
+		// the CAS, and thieves only use the element after winning it, so
+		// the slot can be cleared.
+		won := d.top.CompareAndSwap(t, t+1)
+		r.slot(b).Store(nil)
+		d.bottom.Store(b + 1)
+		if !won {
+			return v, false
+		}
+		return *p, true
+	}
+	// No thief can take index b anymore, so drop the reference to the
+	// element to let it be collected.
+	r.slot(b).Store(nil)
+	return *p, true
+}
+
+// Steal removes the element at the top of the deque (the least recently
+// pushed one) and returns it and true. If the deque is empty, it returns
+// false. It may be called by any goroutine.
+func (d *Deque[T]) Steal() (v T, ok bool) {
+	for {
+		t := d.top.Load()
+		b := d.bottom.Load()
+		if t >= b {
+			return v, false
+		}
+		p := d.buf.Load().slot(t).Load()
+		// If the CAS succeeds, no one else has taken index t, so p is the
+		// element it held when we read it.
+		if d.top.CompareAndSwap(t, t+1) {
+			return *p, true
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package wsdeque
+
+import (
+	"log"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSequential(t *testing.T) {
+	s1, s2 := rand.Uint64(), rand.Uint64()
+	log.Printf("%s seed: %v, %v", t.Name(), s1, s2)
+	rnd := rand.New(rand.NewPCG(s1, s2))
+
+	// Without concurrency, the deque is a plain double-ended queue; push
+	// in bursts so that the ring grows and wraps around.
+	d := New[int]()
+	var model []int
+	for i := range 20000 {
+		switch r := rnd.IntN(10); {
+		case r < 5:
+			d.PushBottom(i)
+			model = append(model, i)
+		case r < 8:
+			v, ok := d.PopBottom()
+			if ok != (len(model) > 0) || (ok && v != model[len(model)-1]) {
+				t.Fatalf("PopBottom()=%d,%v, model %v", v, ok, model)
+			}
+			if ok {
+				model = model[:len(model)-1]
+			}
+		default:
+			v, ok := d.Steal()
+			if ok != (len(model) > 0) || (ok && v != model[0]) {
+				t.Fatalf("Steal()=%d,%v, model %v", v, ok, model)
+			}
+			if ok {
+				model = model[1:]
+			}
+		}
+		if d.Len() != len(model) {
+			t.Fatalf("Len=%d, want %d", d.Len(), len(model))
+		}
+	}
+}
+
+func TestPopBottomClearsSlot(t *testing.T) {
+	// Popped elements aren't referenced by the deque, including the last
+	// one, which PopBottom may race for with thieves.
+	d := New[int]()
+	for i := range 3 {
+		d.PushBottom(i)
+	}
+	for range 3 {
+		d.PopBottom()
+	}
+	for i := range d.buf.Load().slots {
+		if d.buf.Load().slots[i].Load() != nil {
+			t.Errorf("slot %d still holds an element", i)
+		}
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	const thieves = 4
+	const n = 50000
+
+	// The owner pushes n elements, popping some of them back; thieves steal
+	// the rest. Every element must be taken exactly once, and each thief
+	// must see elements in increasing order.
+	d := New[int]()
+	var done atomic.Bool
+	taken := make([][]int, thieves+1)
+	var wg sync.WaitGroup
+	for th := range thieves {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := -1
+			for {
+				v, ok := d.Steal()
+				if !ok {
+					if done.Load() && d.Len() == 0 {
+						return
+					}
+					runtime.Gosched()
+					continue
+				}
+				if v <= last {
+					t.Errorf("thief %d stole %d after %d", th, v, last)
+				}
+				last = v
+				taken[th] = append(taken[th], v)
+			}
+		}()
+	}
+
+	for i := range n {
+		d.PushBottom(i)
+		if i%3 == 0 {
+			if v, ok := d.PopBottom(); ok {
+				taken[thieves] = append(taken[thieves], v)
+			}
+		}
+	}
+	for {
+		v, ok := d.PopBottom()
+		if !ok {
+			break
+		}
+		taken[thieves] = append(taken[thieves], v)
+	}
+	done.Store(true)
+	wg.Wait()
+
+	count := make([]int, n)
+	for _, vs := range taken {
+		for _, v := range vs {
+			count[v]++
+		}
+	}
+	for v, c := range count {
+		if c != 1 {
+			t.Fatalf("element %d taken %d times", v, c)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	// A toy fork-join scheduler: each worker owns a deque, runs its own
+	// tasks LIFO and steals from others when it runs out. Tasks compute a
+	// sum over a range by splitting it in halves.
+	const workers = 4
+	type task struct{ lo, hi int }
+
+	deques := make([]*Deque[task], workers)
+	for i := range deques {
+		deques[i] = New[task]()
+	}
+	var sum, pending atomic.Int64
+	pending.Store(1)
+	deques[0].PushBottom(task{0, 100000})
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rnd := rand.New(rand.NewPCG(uint64(w), 0))
+			for pending.Load() > 0 {
+				tk, ok := deques[w].PopBottom()
+				if !ok {
+					tk, ok = deques[rnd.IntN(workers)].Steal()
+				}
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				if tk.hi-tk.lo <= 100 {
+					s := 0
+					for i := tk.lo; i < tk.hi; i++ {
+						s += i
+					}
+					sum.Add(int64(s))
+				} else {
+					mid := (tk.lo + tk.hi) / 2
+					pending.Add(2)
+					deques[w].PushBottom(task{tk.lo, mid})
+					deques[w].PushBottom(task{mid, tk.hi})
+				}
+				pending.Add(-1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want := int64(100000 * 99999 / 2); sum.Load() != want {
+		t.Errorf("sum=%d, want %d", sum.Load(), want)
+	}
+}
+
+func BenchmarkOwner(b *testing.B) {
+	d := New[int]()
+	for i := range b.N {
+		d.PushBottom(i)
+		if i%2 == 1 {
+			d.PopBottom()
+			d.PopBottom()
+		}
+	}
+}